@@ -1,7 +1,11 @@
 #include "luxdex_c.h"
 #include "lux/engine.hpp"
 #include <cstring>
+#include <memory>
+#include <mutex>
 #include <new>
+#include <shared_mutex>
+#include <unordered_map>
 
 // Convert C order to C++ order
 static lux::Order to_cpp_order(const LuxOrder* order) {
@@ -19,6 +23,8 @@ static lux::Order to_cpp_order(const LuxOrder* order) {
     o.stp_group = order->stp_group;
     o.stop_price = order->stop_price;
     o.timestamp = lux::Timestamp(order->timestamp_ns);
+    o.expire_time = lux::Timestamp(order->expire_time_ns);
+    o.display_quantity = order->display_qty;
     return o;
 }
 
@@ -37,6 +43,8 @@ static void to_c_order(const lux::Order& order, LuxOrder* out) {
     out->stp_group = order.stp_group;
     out->stop_price = order.stop_price;
     out->timestamp_ns = order.timestamp.count();
+    out->expire_time_ns = order.expire_time.count();
+    out->display_qty = order.display_quantity;
 }
 
 // Convert C++ trade to C trade
@@ -53,6 +61,79 @@ static void to_c_trade(const lux::Trade& trade, LuxTrade* out) {
     out->timestamp_ns = trade.timestamp.count();
 }
 
+// Forwards engine events to the C callbacks. The engine keeps a raw pointer
+// to its listener, so one is installed per engine and lives until the engine
+// is destroyed; replacing the callbacks swaps them under a lock that every
+// event holds while its callback runs.
+class CallbackListener : public lux::TradeListener {
+public:
+    // Returns once no event is still running the previous callbacks
+    void set(const LuxEngineCallbacks& callbacks, void* user_data) {
+        std::unique_lock lock(mutex_);
+        callbacks_ = callbacks;
+        user_data_ = user_data;
+    }
+
+    void on_trade(const lux::Trade& trade) override {
+        std::shared_lock lock(mutex_);
+        if (!callbacks_.on_trade) return;
+        LuxTrade c{};
+        to_c_trade(trade, &c);
+        callbacks_.on_trade(user_data_, &c);
+    }
+
+    void on_order_filled(const lux::Order& order) override {
+        std::shared_lock lock(mutex_);
+        if (!callbacks_.on_order_filled) return;
+        LuxOrder c{};
+        to_c_order(order, &c);
+        callbacks_.on_order_filled(user_data_, &c);
+    }
+
+    void on_order_partially_filled(const lux::Order& order, lux::Quantity fill_qty) override {
+        std::shared_lock lock(mutex_);
+        if (!callbacks_.on_order_partially_filled) return;
+        LuxOrder c{};
+        to_c_order(order, &c);
+        callbacks_.on_order_partially_filled(user_data_, &c, fill_qty);
+    }
+
+    void on_order_cancelled(const lux::Order& order) override {
+        std::shared_lock lock(mutex_);
+        if (!callbacks_.on_order_cancelled) return;
+        LuxOrder c{};
+        to_c_order(order, &c);
+        callbacks_.on_order_cancelled(user_data_, &c);
+    }
+
+    void on_order_reduced(const lux::Order& order, lux::Quantity reduced_qty) override {
+        std::shared_lock lock(mutex_);
+        if (!callbacks_.on_order_reduced) return;
+        LuxOrder c{};
+        to_c_order(order, &c);
+        callbacks_.on_order_reduced(user_data_, &c, reduced_qty);
+    }
+
+private:
+    std::shared_mutex mutex_;
+    LuxEngineCallbacks callbacks_{};
+    void* user_data_{nullptr};
+};
+
+// Listeners by engine, freed in lux_engine_destroy after the engine itself
+static std::mutex listeners_mutex;
+static std::unordered_map<lux::Engine*, std::unique_ptr<CallbackListener>> listeners;
+
+// Installs the engine's listener before the engine can be shared, so the
+// engine's listener pointer is never written while it is matching
+static lux::Engine* attach_listener(std::unique_ptr<lux::Engine> engine) {
+    auto listener = std::make_unique<CallbackListener>();
+    engine->set_trade_listener(listener.get());
+    std::lock_guard lock(listeners_mutex);
+    listeners[engine.get()] = std::move(listener);
+    return engine.release();
+}
+
 extern "C" {
 
 // =============================================================================
@@ -61,7 +142,7 @@ extern "C" {
 
 LuxEngine lux_engine_create(void) {
     try {
-        return new lux::Engine();
+        return attach_listener(std::make_unique<lux::Engine>());
     } catch (...) {
         return nullptr;
     }
@@ -76,7 +157,10 @@ LuxEngine lux_engine_create_with_config(const LuxEngineConfig* config) {
         cfg.max_batch_size = config->max_batch_size;
         cfg.enable_self_trade_prevention = config->enable_stp;
         cfg.async_mode = config->async_mode;
-        return new lux::Engine(cfg);
+        cfg.matching = static_cast<lux::MatchingAlgorithm>(config->matching);
+        cfg.pro_rata_tie_break = static_cast<lux::ProRataTieBreak>(config->pro_rata_tie_break);
+        cfg.stp_mode = static_cast<lux::STPMode>(config->stp_mode);
+        return attach_listener(std::make_unique<lux::Engine>(cfg));
     } catch (...) {
         return nullptr;
     }
@@ -84,6 +168,9 @@ LuxEngine lux_engine_create_with_config(const LuxEngineConfig* config) {
 
 void lux_engine_destroy(LuxEngine engine) {
     delete static_cast<lux::Engine*>(engine);
+
+    std::lock_guard lock(listeners_mutex);
+    listeners.erase(static_cast<lux::Engine*>(engine));
 }
 
 void lux_engine_start(LuxEngine engine) {
@@ -138,6 +225,17 @@ uint64_t* lux_engine_symbols(LuxEngine engine, size_t* count) {
     return result;
 }
 
+size_t lux_engine_symbol_count(LuxEngine engine) {
+    if (!engine) return 0;
+    return static_cast<lux::Engine*>(engine)->symbol_count();
+}
+
+size_t lux_engine_symbols_into(LuxEngine engine, uint64_t* out, size_t max) {
+    if (!engine) return 0;
+    if (!out) max = 0;
+    return static_cast<lux::Engine*>(engine)->copy_symbols(out, max);
+}
+
 LuxOrderResult lux_engine_place_order(LuxEngine engine, const LuxOrder* order) {
     LuxOrderResult result{};
 
@@ -172,6 +270,37 @@ LuxOrderResult lux_engine_place_order(LuxEngine engine, const LuxOrder* order) {
     return result;
 }
 
+void lux_engine_place_orders(LuxEngine engine, const LuxOrder* orders, size_t count,
+                             LuxOrderResult* results) {
+    if (!results) return;
+    for (size_t i = 0; i < count; ++i) {
+        results[i] = lux_engine_place_order(engine, orders ? &orders[i] : nullptr);
+    }
+}
+
+LuxOrder* lux_engine_expire_orders(LuxEngine engine, int64_t now_ns, size_t* count) {
+    if (!engine || !count) {
+        if (count) *count = 0;
+        return nullptr;
+    }
+
+    auto expired = static_cast<lux::Engine*>(engine)->expire_orders(lux::Timestamp(now_ns));
+    *count = expired.size();
+
+    if (expired.empty()) return nullptr;
+
+    LuxOrder* result = new(std::nothrow) LuxOrder[expired.size()];
+    if (!result) {
+        *count = 0;
+        return nullptr;
+    }
+
+    for (size_t i = 0; i < expired.size(); ++i) {
+        to_c_order(expired[i], &result[i]);
+    }
+    return result;
+}
+
 LuxCancelResult lux_engine_cancel_order(LuxEngine engine, uint64_t symbol_id, uint64_t order_id) {
     LuxCancelResult result{};
 
@@ -267,6 +396,20 @@ bool lux_engine_best_ask(LuxEngine engine, uint64_t symbol_id, LuxPrice* price)
     return true;
 }
 
+bool lux_engine_quote_market_order(LuxEngine engine, uint64_t symbol_id, LuxSide side,
+                                   LuxQuantity quantity, LuxMarketQuote* out) {
+    if (!engine || !out) return false;
+
+    auto quote = static_cast<lux::Engine*>(engine)->quote_market_order(
+        symbol_id, static_cast<lux::Side>(side), quantity);
+    if (!quote) return false;
+
+    out->avg_price = quote->avg_price;
+    out->filled = quote->filled;
+    out->worst_price = quote->worst_price;
+    return true;
+}
+
 LuxEngineStats lux_engine_get_stats(LuxEngine engine) {
     LuxEngineStats result{};
 
@@ -281,6 +424,42 @@ LuxEngineStats lux_engine_get_stats(LuxEngine engine) {
     return result;
 }
 
+LuxLatencyStats lux_engine_get_latency_stats(LuxEngine engine) {
+    LuxLatencyStats result{};
+
+    if (!engine) return result;
+
+    auto stats = static_cast<lux::Engine*>(engine)->get_latency_stats();
+    result.samples = stats.samples;
+    result.p50_ns = stats.p50_ns;
+    result.p90_ns = stats.p90_ns;
+    result.p99_ns = stats.p99_ns;
+    result.max_ns = stats.max_ns;
+
+    return result;
+}
+
+void lux_engine_reset_latency_stats(LuxEngine engine) {
+    if (engine) {
+        static_cast<lux::Engine*>(engine)->reset_latency_stats();
+    }
+}
+
+void lux_engine_set_callbacks(LuxEngine engine, const LuxEngineCallbacks* callbacks,
+                              void* user_data) {
+    if (!engine) return;
+    auto* e = static_cast<lux::Engine*>(engine);
+
+    CallbackListener* listener;
+    {
+        std::lock_guard lock(listeners_mutex);
+        auto it = listeners.find(e);
+        if (it == listeners.end()) return;
+        listener = it->second.get();
+    }
+    listener->set(callbacks ? *callbacks : LuxEngineCallbacks{}, user_data);
+}
+
 // =============================================================================
 // OrderBook API
 // =============================================================================
@@ -409,6 +588,34 @@ size_t lux_orderbook_ask_levels(LuxOrderBook book) {
     return static_cast<lux::OrderBook*>(book)->ask_levels();
 }
 
+LuxOrder* lux_orderbook_orders(LuxOrderBook book, size_t* count) {
+    if (!book || !count) {
+        if (count) *count = 0;
+        return nullptr;
+    }
+
+    auto orders = static_cast<lux::OrderBook*>(book)->resting_orders();
+    *count = orders.size();
+
+    if (orders.empty()) return nullptr;
+
+    LuxOrder* result = new(std::nothrow) LuxOrder[orders.size()];
+    if (!result) {
+        *count = 0;
+        return nullptr;
+    }
+
+    for (size_t i = 0; i < orders.size(); ++i) {
+        to_c_order(orders[i], &result[i]);
+    }
+    return result;
+}
+
+bool lux_orderbook_restore_order(LuxOrderBook book, const LuxOrder* order) {
+    if (!book || !order) return false;
+    return static_cast<lux::OrderBook*>(book)->restore_order(to_cpp_order(order));
+}
+
 size_t lux_orderbook_total_orders(LuxOrderBook book) {
     if (!book) return 0;
     return static_cast<lux::OrderBook*>(book)->total_orders();
@@ -441,6 +648,10 @@ void lux_symbols_free(uint64_t* symbols) {
     delete[] symbols;
 }
 
+void lux_orders_free(LuxOrder* orders) {
+    delete[] orders;
+}
+
 // =============================================================================
 // Utility
 // =============================================================================
@@ -31,7 +31,8 @@ typedef enum {
     LUX_TIF_IOC = 1,
     LUX_TIF_FOK = 2,
     LUX_TIF_GTD = 3,
-    LUX_TIF_DAY = 4
+    LUX_TIF_DAY = 4,
+    LUX_TIF_POST_ONLY = 5
 } LuxTimeInForce;
 
 typedef enum {
@@ -70,6 +71,8 @@ typedef struct {
     uint64_t stp_group;
     LuxPrice stop_price;
     int64_t timestamp_ns;
+    int64_t expire_time_ns;     // GTD/DAY expiry (0 = none)
+    LuxQuantity display_qty;    // Iceberg slice shown in the book (0 = all)
 } LuxOrder;
 
 // Trade structure
@@ -119,6 +122,13 @@ typedef struct {
     char error[256];
 } LuxCancelResult;
 
+// Market order quote (result of walking the book without mutating it)
+typedef struct {
+    LuxPrice avg_price;
+    LuxQuantity filled;
+    LuxPrice worst_price;
+} LuxMarketQuote;
+
 // Engine statistics
 typedef struct {
     uint64_t total_orders_placed;
@@ -127,14 +137,40 @@ typedef struct {
     uint64_t total_volume;
 } LuxEngineStats;
 
+// Order-to-trade latency over the last LUX_LATENCY_WINDOW matching orders
+#define LUX_LATENCY_WINDOW 4096
+
+typedef struct {
+    uint64_t samples;
+    uint64_t p50_ns;
+    uint64_t p90_ns;
+    uint64_t p99_ns;
+    uint64_t max_ns;
+} LuxLatencyStats;
+
 // Engine configuration
 typedef struct {
     size_t worker_threads;
     size_t max_batch_size;
     bool enable_stp;
     bool async_mode;
+    uint8_t matching;            // 0 = price-time, 1 = pro-rata
+    uint8_t pro_rata_tie_break;  // 0 = largest remainder, 1 = order ID, 2 = time
+    uint8_t stp_mode;            // 0 = cancel maker, 1 = cancel taker, 2 = cancel both, 3 = decrement
 } LuxEngineConfig;
 
+// Engine event callbacks. They run synchronously on the matching thread,
+// inside the engine call that caused the event, and receive the user_data
+// passed to lux_engine_set_callbacks. Any entry may be NULL.
+typedef struct {
+    void (*on_trade)(void* user_data, const LuxTrade* trade);
+    void (*on_order_filled)(void* user_data, const LuxOrder* order);
+    void (*on_order_partially_filled)(void* user_data, const LuxOrder* order, LuxQuantity fill_qty);
+    void (*on_order_cancelled)(void* user_data, const LuxOrder* order);
+    // Self-trade prevention shrank a resting order by reduced_qty
+    void (*on_order_reduced)(void* user_data, const LuxOrder* order, LuxQuantity reduced_qty);
+} LuxEngineCallbacks;
+
 // =============================================================================
 // Engine API
 // =============================================================================
@@ -169,9 +205,25 @@ bool lux_engine_has_symbol(LuxEngine engine, uint64_t symbol_id);
 // Get symbols (caller must free result)
 uint64_t* lux_engine_symbols(LuxEngine engine, size_t* count);
 
+// Get number of symbols
+size_t lux_engine_symbol_count(LuxEngine engine);
+
+// Copy up to max symbols into out (caller-allocated); returns the total
+// number of symbols, which may exceed max
+size_t lux_engine_symbols_into(LuxEngine engine, uint64_t* out, size_t max);
+
 // Place order
 LuxOrderResult lux_engine_place_order(LuxEngine engine, const LuxOrder* order);
 
+// Place count orders in sequence, writing one result per order into results
+// (caller-allocated, count entries; free each with lux_order_result_free)
+void lux_engine_place_orders(LuxEngine engine, const LuxOrder* orders, size_t count,
+                             LuxOrderResult* results);
+
+// Expire GTD/DAY orders due at or before now_ns (caller must free result
+// with lux_orders_free)
+LuxOrder* lux_engine_expire_orders(LuxEngine engine, int64_t now_ns, size_t* count);
+
 // Cancel order
 LuxCancelResult lux_engine_cancel_order(LuxEngine engine, uint64_t symbol_id, uint64_t order_id);
 
@@ -187,9 +239,26 @@ bool lux_engine_best_bid(LuxEngine engine, uint64_t symbol_id, LuxPrice* price);
 // Get best ask (returns false if no asks)
 bool lux_engine_best_ask(LuxEngine engine, uint64_t symbol_id, LuxPrice* price);
 
+// Quote a market order of the given size (returns false for unknown symbol)
+bool lux_engine_quote_market_order(LuxEngine engine, uint64_t symbol_id, LuxSide side,
+                                   LuxQuantity quantity, LuxMarketQuote* out);
+
 // Get statistics
 LuxEngineStats lux_engine_get_stats(LuxEngine engine);
 
+// Get latency percentiles (all zero until an order has traded)
+LuxLatencyStats lux_engine_get_latency_stats(LuxEngine engine);
+
+// Discard all latency samples
+void lux_engine_reset_latency_stats(LuxEngine engine);
+
+// Register callbacks for every trade, fill and cancellation, including
+// fills of resting orders caused by other accounts (NULL removes them).
+// Returns once no event is still running the previous callbacks, so their
+// user_data may be released afterwards. Must not be called from a callback.
+void lux_engine_set_callbacks(LuxEngine engine, const LuxEngineCallbacks* callbacks,
+                              void* user_data);
+
 // =============================================================================
 // OrderBook API (direct access, use with caution)
 // =============================================================================
@@ -209,6 +278,14 @@ bool lux_orderbook_get_order(LuxOrderBook book, uint64_t order_id, LuxOrder* out
 // Get depth from orderbook
 LuxMarketDepth lux_orderbook_get_depth(LuxOrderBook book, size_t levels);
 
+// Get resting orders in priority order (caller must free result with
+// lux_orders_free)
+LuxOrder* lux_orderbook_orders(LuxOrderBook book, size_t* count);
+
+// Rest an order without matching, keeping its ID, timestamp and fills
+// (returns false on duplicate ID, nothing remaining, or a crossing price)
+bool lux_orderbook_restore_order(LuxOrderBook book, const LuxOrder* order);
+
 // Get orderbook statistics
 size_t lux_orderbook_bid_levels(LuxOrderBook book);
 size_t lux_orderbook_ask_levels(LuxOrderBook book);
@@ -227,6 +304,9 @@ void lux_market_depth_free(LuxMarketDepth* depth);
 // Free symbol array
 void lux_symbols_free(uint64_t* symbols);
 
+// Free order array
+void lux_orders_free(LuxOrder* orders);
+
 // =============================================================================
 // Utility
 // =============================================================================
@@ -111,6 +111,7 @@ typedef struct {
 #define LX_FEE_005   500      /* 0.05% */
 #define LX_FEE_030   3000     /* 0.30% */
 #define LX_FEE_100   10000    /* 1.00% */
+#define LX_DYNAMIC_FEE_FLAG 0x800000 /* LP fee set with lxpool_set_dynamic_fee */
 
 /* Standard tick spacings */
 #define LX_TICK_SPACING_001  1
@@ -118,6 +119,22 @@ typedef struct {
 #define LX_TICK_SPACING_030  60
 #define LX_TICK_SPACING_100  200
 
+/* Hook flags (lxhooks_register) */
+#define LX_HOOK_BEFORE_INITIALIZE                    (1u << 13)
+#define LX_HOOK_AFTER_INITIALIZE                     (1u << 12)
+#define LX_HOOK_BEFORE_ADD_LIQUIDITY                 (1u << 11)
+#define LX_HOOK_AFTER_ADD_LIQUIDITY                  (1u << 10)
+#define LX_HOOK_BEFORE_REMOVE_LIQUIDITY              (1u << 9)
+#define LX_HOOK_AFTER_REMOVE_LIQUIDITY               (1u << 8)
+#define LX_HOOK_BEFORE_SWAP                          (1u << 7)
+#define LX_HOOK_AFTER_SWAP                           (1u << 6)
+#define LX_HOOK_BEFORE_DONATE                        (1u << 5)
+#define LX_HOOK_AFTER_DONATE                         (1u << 4)
+#define LX_HOOK_BEFORE_SWAP_RETURNS_DELTA            (1u << 3)
+#define LX_HOOK_AFTER_SWAP_RETURNS_DELTA             (1u << 2)
+#define LX_HOOK_AFTER_ADD_LIQUIDITY_RETURNS_DELTA    (1u << 1)
+#define LX_HOOK_AFTER_REMOVE_LIQUIDITY_RETURNS_DELTA (1u << 0)
+
 /* =============================================================================
  * Balance Delta (Signed Token Amounts)
  * ============================================================================= */
@@ -148,6 +165,19 @@ typedef struct {
     uint64_t salt;              /* For multiple positions at same range */
 } lx_modify_params_t;
 
+/* =============================================================================
+ * Trigger Swaps
+ * ============================================================================= */
+
+#define LX_TRIGGER_BELOW 0  /* Fire when price <= trigger price */
+#define LX_TRIGGER_ABOVE 1  /* Fire when price >= trigger price */
+
+typedef struct {
+    uint64_t trigger_id;
+    lx_account_t owner;
+    lx_balance_delta_t delta;
+} lx_triggered_swap_t;
+
 /* =============================================================================
  * Pool Slot0 State
  * ============================================================================= */
@@ -160,6 +190,20 @@ typedef struct {
     bool unlocked;
 } lx_slot0_t;
 
+typedef struct {
+    int32_t tick;
+    lx_i128_t liquidity_gross;  /* Total liquidity referencing the tick */
+    lx_i128_t liquidity_net;    /* Added to active liquidity when crossed upwards */
+} lx_tick_info_t;
+
+typedef struct {
+    lx_i128_t liquidity;
+    lx_i128_t fee_growth_inside0_last_x128;
+    lx_i128_t fee_growth_inside1_last_x128;
+    lx_i128_t tokens_owed0;     /* Fees credited at the last modification */
+    lx_i128_t tokens_owed1;
+} lx_pool_position_t;
+
 /* =============================================================================
  * Enums (Order/Trade Types)
  * ============================================================================= */
@@ -211,6 +255,16 @@ typedef enum {
     LX_PRICE_CUSTOM = 8
 } lx_price_source_t;
 
+typedef enum {
+    LX_AGG_MEDIAN = 0,
+    LX_AGG_TWAP = 1,
+    LX_AGG_VWAP = 2,
+    LX_AGG_TRIMMED_MEAN = 3,
+    LX_AGG_WEIGHTED_MEDIAN = 4,
+    LX_AGG_CONFIDENCE_WEIGHTED_MEAN = 5,  /* Weighted by inverse confidence */
+    LX_AGG_LAST_KNOWN_GOOD = 6            /* Newest submission, even once stale */
+} lx_aggregation_t;
+
 /* =============================================================================
  * LXBook Market Configuration (LP-9020)
  * ============================================================================= */
@@ -226,7 +280,8 @@ typedef struct {
     lx_i128_t max_order_size_x18;
     bool post_only_mode;
     bool reduce_only_mode;
-    uint8_t status;            /* 0=inactive, 1=active, 2=cancel-only */
+    uint8_t status;            /* 0=halted, 1=active, 2=cancel-only, 3=post-only, 4=limit-only */
+    uint8_t stp_mode;          /* 0=cancel maker, 1=cancel taker, 2=cancel both, 3=decrement */
 } lx_book_market_config_t;
 
 /* =============================================================================
@@ -245,6 +300,7 @@ typedef struct {
     uint8_t cloid[16];         /* Client order ID (UUID) */
     uint8_t group_id[16];
     uint8_t group_type;        /* 0=NONE, 1=OCO, 2=BRACKET */
+    uint64_t stp_group;        /* Self-trade prevention group (0 = exempt) */
 } lx_order_t;
 
 /* =============================================================================
@@ -256,8 +312,31 @@ typedef struct {
     uint8_t status;            /* lx_order_status_t */
     lx_i128_t filled_size_x18;
     lx_i128_t avg_px_x18;
+    int32_t reason;            /* LX_ERR_* code when rejected, else LX_OK */
 } lx_place_result_t;
 
+/* =============================================================================
+ * LXBook Order State
+ * ============================================================================= */
+
+typedef struct {
+    uint64_t oid;
+    uint8_t cloid[16];
+    uint32_t market_id;
+    bool is_buy;
+    lx_order_kind_t kind;
+    lx_tif_t tif;
+    lx_i128_t original_size_x18;
+    lx_i128_t remaining_size_x18;
+    lx_i128_t filled_size_x18;
+    lx_i128_t limit_px_x18;
+    lx_i128_t trigger_px_x18;
+    lx_i128_t avg_fill_px_x18;
+    uint8_t status;            /* lx_order_status_t */
+    uint64_t created_at;
+    uint64_t updated_at;
+} lx_book_order_t;
+
 /* =============================================================================
  * LXBook L1 Data
  * ============================================================================= */
@@ -270,6 +349,76 @@ typedef struct {
     lx_i128_t last_trade_px_x18;
 } lx_l1_t;
 
+typedef void (*lx_l1_callback_t)(void* user_data, uint32_t market_id, const lx_l1_t* l1);
+
+/* =============================================================================
+ * LXBook L2 Data
+ * ============================================================================= */
+
+typedef struct {
+    lx_i128_t px_x18;
+    lx_i128_t sz_x18;
+    uint32_t order_count;
+} lx_book_level_t;
+
+typedef struct {
+    lx_book_level_t* bids;     /* Best (highest) first */
+    size_t bid_count;
+    lx_book_level_t* asks;     /* Best (lowest) first */
+    size_t ask_count;
+    uint64_t timestamp;        /* Unix ns */
+} lx_book_depth_t;
+
+/* =============================================================================
+ * LXBook Trade
+ * ============================================================================= */
+
+typedef struct {
+    uint64_t trade_id;
+    uint32_t market_id;
+    uint64_t maker_oid;
+    uint64_t taker_oid;
+    lx_account_t maker;
+    lx_account_t taker;
+    bool taker_is_buy;
+    lx_i128_t px_x18;
+    lx_i128_t sz_x18;
+    uint64_t timestamp;        /* Unix ns */
+} lx_trade_t;
+
+typedef void (*lx_trade_callback_t)(void* user_data, const lx_trade_t* trade);
+
+/* =============================================================================
+ * System Events
+ * ============================================================================= */
+
+#define LX_EVENT_ORDER_REJECTED          0
+#define LX_EVENT_MARKET_HALTED           1
+#define LX_EVENT_LIQUIDATION             2
+#define LX_EVENT_INSURANCE_FUND_DRAW     3
+#define LX_EVENT_ORACLE_PRICE_STALE      4
+#define LX_EVENT_CIRCUIT_BREAKER_TRIPPED 5
+#define LX_EVENT_TRADE_BUSTED            6
+
+#define LX_SEVERITY_INFO     0
+#define LX_SEVERITY_WARNING  1
+#define LX_SEVERITY_CRITICAL 2
+
+typedef struct {
+    uint8_t kind;              /* LX_EVENT_* */
+    uint8_t severity;          /* LX_SEVERITY_* */
+    uint32_t market_id;        /* 0 if not market-specific */
+    uint64_t asset_id;         /* 0 if not asset-specific */
+    lx_account_t account;      /* Zero if not account-specific */
+    int32_t code;              /* Underlying error code, or 0 */
+    uint64_t timestamp;        /* Unix ns */
+} lx_system_event_t;
+
+typedef void (*lx_system_event_callback_t)(void* user_data, const lx_system_event_t* event);
+
+/* Journal record callback. record is valid only for the duration of the call. */
+typedef void (*lx_journal_callback_t)(void* user_data, const uint8_t* record, size_t len);
+
 /* =============================================================================
  * LXVault Market Configuration (LP-9030)
  * ============================================================================= */
@@ -316,6 +465,23 @@ typedef struct {
     bool liquidatable;
 } lx_margin_info_t;
 
+typedef struct {
+    lx_i128_t realized_pnl_x18;
+    lx_i128_t unrealized_pnl_x18;  /* Summed across open positions */
+    lx_i128_t fees_paid_x18;
+    lx_i128_t funding_x18;         /* Net funding; negative when paid */
+} lx_account_pnl_t;
+
+/* =============================================================================
+ * LXVault Staking Fee Tier
+ * ============================================================================= */
+
+typedef struct {
+    lx_i128_t min_staked_x18;
+    lx_i128_t taker_discount_x18;  /* Fraction of the fee, 0.5 = 50% */
+    lx_i128_t maker_discount_x18;
+} lx_staking_fee_tier_t;
+
 /* =============================================================================
  * LXVault Liquidation Result
  * ============================================================================= */
@@ -350,6 +516,47 @@ typedef struct {
     uint64_t next_funding_time;
 } lx_funding_rate_t;
 
+typedef struct {
+    lx_i128_t rate_x18;
+    uint64_t timestamp;        /* When the rate was calculated */
+} lx_funding_sample_t;
+
+typedef struct {
+    uint64_t interval_secs;    /* Seconds between funding calculations */
+    uint32_t clamp_bps;        /* Cap on the absolute rate per interval */
+    uint64_t next_funding_time; /* Zero until the first calculation */
+} lx_funding_config_t;
+
+/* =============================================================================
+ * LXFeed All Prices
+ * ============================================================================= */
+
+typedef struct {
+    lx_i128_t index_px_x18;
+    lx_i128_t mark_px_x18;
+    lx_i128_t last_px_x18;
+    lx_i128_t mid_px_x18;
+    lx_i128_t premium_x18;     /* mark - index */
+    lx_i128_t funding_rate_x18;
+    uint64_t next_funding_time;
+    uint64_t timestamp;
+} lx_feed_prices_t;
+
+/* =============================================================================
+ * LXLiquid Loan (LP-9060)
+ * ============================================================================= */
+
+typedef struct {
+    uint64_t id;
+    lx_account_t owner;
+    lx_currency_t collateral;
+    lx_i128_t collateral_x18;
+    lx_i128_t principal_x18;       /* Outstanding debt */
+    lx_i128_t yield_applied_x18;   /* Collateral yield applied to the debt */
+    lx_i128_t health_x18;          /* Collateral / debt; zero once repaid */
+    uint64_t opened_at;            /* Unix ms */
+} lx_liquid_loan_t;
+
 /* =============================================================================
  * LX Configuration
  * ============================================================================= */
@@ -359,6 +566,7 @@ typedef struct {
     size_t max_batch_size;
     bool enable_hooks;
     bool enable_flash_loans;
+    bool enable_trade_busts;   /* Permit lxbook_bust_trade */
     uint64_t funding_interval;
     lx_i128_t default_maker_fee_x18;
     lx_i128_t default_taker_fee_x18;
@@ -377,17 +585,36 @@ typedef struct {
 #define LX_ERR_INVALID_CURRENCY      -6
 #define LX_ERR_CURRENCIES_NOT_SORTED -7
 #define LX_ERR_INVALID_FEE           -8
+#define LX_ERR_NOT_DYNAMIC_FEE       -9
 #define LX_ERR_INSUFFICIENT_BALANCE  -10
 #define LX_ERR_INSUFFICIENT_MARGIN   -11
 #define LX_ERR_POSITION_NOT_FOUND    -12
 #define LX_ERR_ORDER_NOT_FOUND       -13
 #define LX_ERR_MARKET_NOT_FOUND      -14
 #define LX_ERR_NOT_LIQUIDATABLE      -15
+#define LX_ERR_TRADE_NOT_FOUND       -16
+#define LX_ERR_POSITION_OPEN         -17
+#define LX_ERR_INVALID_LEVERAGE      -18
+#define LX_ERR_MARKET_NOT_EMPTY      -19
 #define LX_ERR_PRICE_STALE           -20
 #define LX_ERR_ORACLE_UNAVAILABLE    -21
 #define LX_ERR_INVALID_PRICE         -22
+#define LX_ERR_PRICE_DEVIATION       -23
+#define LX_ERR_NOT_ARMED             -24
+#define LX_ERR_ORDER_TOO_SMALL       -25
+#define LX_ERR_ORDER_TOO_LARGE       -26
+#define LX_ERR_POSITION_LIMIT        -27
+#define LX_ERR_REDUCE_ONLY           -28
 #define LX_ERR_REENTRANCY            -30
 #define LX_ERR_HOOK_FAILED           -31
+#define LX_ERR_HOOK_NOT_REGISTERED   -32
+#define LX_ERR_INVALID_HOOK_FLAGS    -33
+#define LX_ERR_INVALID_SNAPSHOT      -34
+#define LX_ERR_INVALID_JOURNAL       -35
+#define LX_ERR_DRAINING              -36
+#define LX_ERR_MARKET_HALTED         -37
+#define LX_ERR_MARKET_RESTRICTED     -38
+#define LX_ERR_ORDER_REJECTED        -39
 #define LX_ERR_UNAUTHORIZED          -40
 #define LX_ERR_NULL_POINTER          -100
 #define LX_ERR_INTERNAL              -101
@@ -435,17 +662,58 @@ int32_t lx_stop(lx_t* dex);
  */
 bool lx_is_running(const lx_t* dex);
 
+/**
+ * Begin draining: new book placements are rejected until the next
+ * lx_start. Cancels keep working. Returns LX_OK on success.
+ */
+int32_t lx_begin_drain(lx_t* dex);
+
+/**
+ * Check if the DEX is draining.
+ */
+bool lx_is_draining(const lx_t* dex);
+
+/**
+ * Get the number of async orders queued or still being matched.
+ * Zero means it is safe to lx_stop after lx_begin_drain.
+ */
+uint64_t lx_pending_orders(const lx_t* dex);
+
 /**
  * Get DEX version string.
  */
 const char* lx_version(void);
 
+typedef struct {
+    const char* version;
+    const char* git_commit;     /* "unknown" if not built from git */
+    const char* build_date;     /* ISO 8601 UTC, or "unknown" */
+    const char* features;       /* Comma-separated, e.g. "pool,book,debug" */
+} lx_build_info_t;
+
+/**
+ * Get build information. The strings are static; do not free them.
+ */
+lx_build_info_t lx_build_info(void);
+
+/**
+ * Register a callback for operational events from every component: rejected
+ * orders, halted markets, liquidations, insurance fund draws, stale oracle
+ * prices, tripped circuit breakers and busted trades. It runs synchronously
+ * on the thread that raised the event and must not call back into dex.
+ * @param callback NULL removes the callback
+ */
+void lx_set_system_event_callback(lx_t* dex, lx_system_event_callback_t callback,
+                                  void* user_data);
+
 /* =============================================================================
  * LXPool API (LP-9010) - AMM Pool Manager
  * ============================================================================= */
 
 /**
  * Initialize a new pool.
+ * A key naming a hook contract needs it registered with lxhooks_register
+ * first (LX_ERR_HOOK_NOT_REGISTERED).
  * @param sqrt_price_x96_hi High 64 bits of initial sqrt price (Q64.96)
  * @param sqrt_price_x96_lo Low 64 bits of initial sqrt price
  * @return Initial tick on success, or negative error code
@@ -460,6 +728,44 @@ int32_t lxpool_initialize(lx_t* dex, const lx_pool_key_t* key,
 lx_balance_delta_t lxpool_swap(lx_t* dex, const lx_pool_key_t* key,
                                const lx_swap_params_t* params);
 
+/**
+ * Quote a swap without executing it. A swap the pool cannot fill in full
+ * fails with LX_ERR_INSUFFICIENT_LIQUIDITY unless allow_partial is set, in
+ * which case delta holds the largest fill and full reports whether it
+ * covers the amount.
+ * @return LX_OK, LX_ERR_POOL_NOT_INITIALIZED, LX_ERR_PRICE_LIMIT_EXCEEDED
+ *         or LX_ERR_INSUFFICIENT_LIQUIDITY
+ */
+int32_t lxpool_quote_swap(const lx_t* dex, const lx_pool_key_t* key,
+                          const lx_swap_params_t* params, bool allow_partial,
+                          lx_balance_delta_t* delta, bool* full);
+
+/**
+ * Register a swap that executes once the pool price (currency1 per
+ * currency0) crosses trigger_px_x18 in direction (LX_TRIGGER_*). Triggers
+ * do not fire on their own; a keeper calls lxpool_execute_triggers.
+ * @return LX_OK, LX_ERR_POOL_NOT_INITIALIZED or LX_ERR_INVALID_PRICE
+ */
+int32_t lxpool_place_trigger_swap(lx_t* dex, const lx_account_t* owner,
+                                  const lx_pool_key_t* key, const lx_swap_params_t* params,
+                                  lx_i128_t trigger_px_x18, uint8_t direction,
+                                  uint64_t* trigger_id);
+
+/**
+ * Execute, in registration order, every trigger swap on the pool whose
+ * condition holds at the current price. Each swap moves the price before
+ * the next trigger is evaluated. A trigger whose swap moves nothing stays
+ * registered. Free *swaps with lx_triggered_swaps_free.
+ * @return LX_OK or LX_ERR_POOL_NOT_INITIALIZED
+ */
+int32_t lxpool_execute_triggers(lx_t* dex, const lx_pool_key_t* key,
+                                lx_triggered_swap_t** swaps, size_t* count);
+
+/**
+ * Free swaps returned by lxpool_execute_triggers.
+ */
+void lx_triggered_swaps_free(lx_triggered_swap_t* swaps);
+
 /**
  * Add or remove liquidity.
  * @return Balance delta for principal + fees
@@ -488,22 +794,93 @@ bool lxpool_get_slot0(const lx_t* dex, const lx_pool_key_t* key, lx_slot0_t* out
  */
 bool lxpool_get_liquidity(const lx_t* dex, const lx_pool_key_t* key, lx_i128_t* out);
 
+/**
+ * Get an LP position by owner, tick range and salt.
+ * @param out Output position
+ * @return true if the position exists
+ */
+bool lxpool_get_position(const lx_t* dex, const lx_pool_key_t* key, const lx_address_t* owner,
+                         int32_t tick_lower, int32_t tick_upper, uint64_t salt,
+                         lx_pool_position_t* out);
+
+/**
+ * Get the initialized ticks in [tick_lower, tick_upper], ascending. Free the
+ * array with lx_tick_infos_free; it is NULL when there are none.
+ * @return LX_OK, LX_ERR_INVALID_TICK_RANGE or LX_ERR_POOL_NOT_INITIALIZED
+ */
+int32_t lxpool_get_ticks(const lx_t* dex, const lx_pool_key_t* key,
+                         int32_t tick_lower, int32_t tick_upper,
+                         lx_tick_info_t** ticks, size_t* count);
+
+/**
+ * Free ticks returned by lxpool_get_ticks.
+ */
+void lx_tick_infos_free(lx_tick_info_t* ticks);
+
 /**
  * Check if pool exists.
  */
 bool lxpool_exists(const lx_t* dex, const lx_pool_key_t* key);
 
+/**
+ * List initialized pools, in no particular order. Free the array with
+ * lx_pool_keys_free; it is NULL when there are none.
+ * @return LX_OK on success
+ */
+int32_t lxpool_list(const lx_t* dex, lx_pool_key_t** keys, size_t* count);
+
+/**
+ * Free keys returned by lxpool_list.
+ */
+void lx_pool_keys_free(lx_pool_key_t* keys);
+
 /**
  * Set protocol fee for a pool.
+ * @param new_fee Token0 fee in the low 16 bits, token1 fee in the high 16, in
+ *                hundredths of a bip; each at most a quarter of the LP fee
+ * @return LX_OK, LX_ERR_POOL_NOT_INITIALIZED or LX_ERR_INVALID_FEE
  */
 int32_t lxpool_set_protocol_fee(lx_t* dex, const lx_pool_key_t* key, uint32_t new_fee);
 
+/**
+ * Set the LP fee of a dynamic-fee pool (key fee LX_DYNAMIC_FEE_FLAG).
+ * @return LX_OK, LX_ERR_NOT_DYNAMIC_FEE for a static-fee pool, or LX_ERR_INVALID_FEE
+ */
+int32_t lxpool_set_dynamic_fee(lx_t* dex, const lx_pool_key_t* key, uint32_t new_fee);
+
 /**
  * Collect protocol fees.
  */
 lx_balance_delta_t lxpool_collect_protocol(lx_t* dex, const lx_pool_key_t* key,
                                             const lx_address_t* recipient);
 
+/**
+ * Collect a position's owed LP fees, leaving its liquidity in place.
+ * @param out Output delta (negative: paid out by the pool)
+ * @return LX_OK or LX_ERR_POSITION_NOT_FOUND
+ */
+int32_t lxpool_collect_fees(lx_t* dex, const lx_pool_key_t* key, const lx_address_t* owner,
+                            int32_t tick_lower, int32_t tick_upper, uint64_t salt,
+                            lx_balance_delta_t* out);
+
+/* =============================================================================
+ * LXHooks API (LP-9013) - Hook Contract Registry
+ * ============================================================================= */
+
+/**
+ * Declare which callbacks (LX_HOOK_* flags) a hook contract implements.
+ * Re-registering an address replaces its flags.
+ * @return LX_OK, or LX_ERR_INVALID_HOOK_FLAGS for the zero address, unknown
+ *         bits, or a returns-delta flag without its callback
+ */
+int32_t lxhooks_register(lx_t* dex, const lx_address_t* hook, uint32_t flags);
+
+/**
+ * Get the flags registered for a hook contract.
+ * @return true if the address is registered
+ */
+bool lxhooks_get_flags(const lx_t* dex, const lx_address_t* hook, uint32_t* flags);
+
 /* =============================================================================
  * LXBook API (LP-9020) - CLOB Matching Engine
  * ============================================================================= */
@@ -515,7 +892,9 @@ lx_balance_delta_t lxpool_collect_protocol(lx_t* dex, const lx_pool_key_t* key,
 int32_t lxbook_create_market(lx_t* dex, const lx_book_market_config_t* config);
 
 /**
- * Update market configuration.
+ * Update market configuration. symbol_id and the currencies are fixed at
+ * creation (LX_ERR_INVALID_CURRENCY); tick and lot size cannot change while
+ * orders rest (LX_ERR_MARKET_NOT_EMPTY).
  */
 int32_t lxbook_update_market(lx_t* dex, const lx_book_market_config_t* config);
 
@@ -524,19 +903,59 @@ int32_t lxbook_update_market(lx_t* dex, const lx_book_market_config_t* config);
  */
 bool lxbook_market_exists(const lx_t* dex, uint32_t market_id);
 
+/**
+ * List market IDs in ascending order. Free the array with
+ * lx_market_ids_free; it is NULL when there are none.
+ * @return LX_OK on success
+ */
+int32_t lxbook_list_markets(const lx_t* dex, uint32_t** ids, size_t* count);
+
+/**
+ * Free IDs returned by lxbook_list_markets or lxvault_list_markets.
+ */
+void lx_market_ids_free(uint32_t* ids);
+
+/**
+ * Remove a market that has no open orders.
+ * @return LX_OK, LX_ERR_MARKET_NOT_FOUND or LX_ERR_MARKET_NOT_EMPTY
+ */
+int32_t lxbook_remove_market(lx_t* dex, uint32_t market_id);
+
 /**
  * Get market status.
- * @return 0=inactive, 1=active, 2=cancel-only
+ * @return 0=halted, 1=active, 2=cancel-only, 3=post-only, 4=limit-only
  */
 uint8_t lxbook_get_market_status(const lx_t* dex, uint32_t market_id);
 
 /**
- * Place a new order.
+ * Set market status. Cancels are accepted in every status.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_set_market_status(lx_t* dex, uint32_t market_id, uint8_t status);
+
+/**
+ * Place a new order. A rejected order comes back LX_STATUS_REJECTED with
+ * its code in reason: LX_ERR_DRAINING, LX_ERR_MARKET_NOT_FOUND,
+ * LX_ERR_MARKET_HALTED, LX_ERR_MARKET_RESTRICTED when the market status
+ * does not allow the order, LX_ERR_REDUCE_ONLY when a reduce-only order
+ * would not only reduce the position (see lxvault_check_order_limits), or
+ * LX_ERR_ORDER_REJECTED when matching turns it away.
  * @return Place result with order ID and fill info
  */
 lx_place_result_t lxbook_place_order(lx_t* dex, const lx_account_t* sender,
                                       const lx_order_t* order);
 
+/**
+ * Place a batch of orders. Each order is placed as by lxbook_place_order;
+ * one that fails validation comes back LX_STATUS_REJECTED and does not
+ * stop the rest.
+ * @param results Output buffer of count results, in the order of orders
+ * @return LX_OK
+ */
+int32_t lxbook_place_orders(lx_t* dex, const lx_account_t* sender,
+                            const lx_order_t* orders, size_t count,
+                            lx_place_result_t* results);
+
 /**
  * Cancel order by order ID.
  * @return LX_OK on success
@@ -544,6 +963,16 @@ lx_place_result_t lxbook_place_order(lx_t* dex, const lx_account_t* sender,
 int32_t lxbook_cancel_order(lx_t* dex, const lx_account_t* sender,
                             uint32_t market_id, uint64_t oid);
 
+/**
+ * Cancel a batch of orders by order ID. A failed cancel does not stop
+ * the rest.
+ * @param codes Output buffer of count codes, as lxbook_cancel_order
+ *              returns for each oid
+ * @return LX_OK
+ */
+int32_t lxbook_cancel_orders(lx_t* dex, const lx_account_t* sender, uint32_t market_id,
+                             const uint64_t* oids, size_t count, int32_t* codes);
+
 /**
  * Cancel order by client order ID.
  * @param cloid 16-byte client order ID
@@ -559,23 +988,163 @@ int32_t lxbook_cancel_by_cloid(lx_t* dex, const lx_account_t* sender,
 int32_t lxbook_cancel_all(lx_t* dex, const lx_account_t* sender, uint32_t market_id);
 
 /**
- * Amend order price/size.
+ * Replace the account's orders in a market: all live orders are cancelled
+ * before any new one is placed, so stale and fresh quotes never rest
+ * together. Orders naming another market come back LX_STATUS_REJECTED.
+ * @param results Output buffer of count results, in the order of orders
+ * @return LX_OK, or LX_ERR_MARKET_NOT_FOUND with nothing cancelled or placed
+ */
+int32_t lxbook_reset_quotes(lx_t* dex, const lx_account_t* sender, uint32_t market_id,
+                            const lx_order_t* orders, size_t count,
+                            lx_place_result_t* results);
+
+/**
+ * Arm cancel-on-disconnect: unless lxbook_heartbeat is called within
+ * timeout_ms, every open order of the account is cancelled. Re-arming
+ * restarts the countdown; timeout_ms 0 disarms.
+ * @return LX_OK on success
+ */
+int32_t lxbook_arm_cancel_on_disconnect(lx_t* dex, const lx_account_t* account,
+                                        uint32_t timeout_ms);
+
+/**
+ * Restart the account's cancel-on-disconnect countdown.
+ * @return LX_OK, or LX_ERR_NOT_ARMED if not armed or already lapsed
+ */
+int32_t lxbook_heartbeat(lx_t* dex, const lx_account_t* account);
+
+/**
+ * Cancel the orders of every account whose heartbeat has lapsed. Placements
+ * do this first; call it to clear lapsed accounts without waiting for one.
+ * @return Number of orders cancelled
+ */
+uint64_t lxbook_check_heartbeats(lx_t* dex);
+
+/**
+ * Amend order price/size. Only the order's owner may amend it. A rejected
+ * amendment comes back LX_STATUS_REJECTED with its code in reason:
+ * LX_ERR_MARKET_NOT_FOUND, LX_ERR_ORDER_NOT_FOUND when sender has no live
+ * order oid in the market, or LX_ERR_ORDER_REJECTED.
  */
 lx_place_result_t lxbook_amend_order(lx_t* dex, const lx_account_t* sender,
                                       uint32_t market_id, uint64_t oid,
                                       lx_i128_t new_size_x18, lx_i128_t new_price_x18);
 
+/**
+ * Bust an erroneous trade (operator only): it leaves the market's trade
+ * history and both accounts get back the positions and realized PnL they had
+ * before it, with their fees refunded. Orders it filled become cancelled.
+ * Anything that consumed the trade must be reconciled by the caller.
+ * lx_initialize permits busts; lx_create_with_config only with
+ * enable_trade_busts.
+ * @return LX_OK, LX_ERR_UNAUTHORIZED if busts are not permitted, or
+ *         LX_ERR_TRADE_NOT_FOUND outside the retained history
+ */
+int32_t lxbook_bust_trade(lx_t* dex, uint32_t market_id, uint64_t trade_id);
+
+/**
+ * Register a callback for every book trade once it settles, including fills
+ * of resting orders. It runs synchronously on the thread that made the
+ * match, before the matching call returns, and must not call back into dex.
+ * Trades for one market arrive in execution order.
+ * @param callback NULL removes the callback
+ */
+void lxbook_set_trade_callback(lx_t* dex, lx_trade_callback_t callback, void* user_data);
+
+/**
+ * Activate stop and take-profit orders whose trigger the reference price
+ * has reached, placing them as market or limit orders. Free the activated
+ * OIDs with lx_oids_free; the array is NULL when none fired.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_evaluate_triggers(lx_t* dex, uint32_t market_id, lx_i128_t ref_px_x18,
+                                 uint64_t** oids, size_t* count);
+
+/**
+ * Free OIDs returned by lxbook_evaluate_triggers.
+ */
+void lx_oids_free(uint64_t* oids);
+
 /**
  * Get L1 (best bid/ask) for market.
  */
 lx_l1_t lxbook_get_l1(const lx_t* dex, uint32_t market_id);
 
+/**
+ * Subscribe to a market's L1. The callback receives the new L1 whenever an
+ * order operation changes it, synchronously on the thread that made the
+ * change, and must not call back into dex other than to unsubscribe.
+ * @param subscription_id Receives the id to pass to lxbook_unsubscribe_l1
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_subscribe_l1(lx_t* dex, uint32_t market_id, lx_l1_callback_t callback,
+                            void* user_data, uint64_t* subscription_id);
+
+/**
+ * End an L1 subscription. Unknown ids are ignored.
+ */
+void lxbook_unsubscribe_l1(lx_t* dex, uint64_t subscription_id);
+
+/**
+ * Get up to levels aggregated price levels per side. Free the result with
+ * lxbook_depth_free.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_get_l2(const lx_t* dex, uint32_t market_id, size_t levels,
+                      lx_book_depth_t* depth);
+
+/**
+ * Free the level arrays of a depth filled by lxbook_get_l2.
+ */
+void lxbook_depth_free(lx_book_depth_t* depth);
+
+/**
+ * Estimate the fees an order would pay at its market's rates: the taker fee
+ * on the part that crosses current depth, the maker fee on the part that
+ * would rest. Nothing is placed.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_estimate_fees(const lx_t* dex, const lx_order_t* order,
+                             lx_i128_t* maker_fee, lx_i128_t* taker_fee);
+
+/**
+ * Walk the book for a market order of size_x18 without placing it.
+ * @param avg_px_x18 Volume-weighted average fill price (zero if nothing fills)
+ * @param filled_x18 Size that would fill, less than size_x18 on a thin book
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_simulate_fill(const lx_t* dex, uint32_t market_id, bool is_buy,
+                             lx_i128_t size_x18, lx_i128_t* avg_px_x18,
+                             lx_i128_t* filled_x18);
+
+/**
+ * Get the current state of an order by order ID.
+ * @return true if the order is known
+ */
+bool lxbook_get_order(const lx_t* dex, uint32_t market_id, uint64_t oid,
+                      lx_book_order_t* out);
+
 /**
  * Get order count for account in market.
  */
 size_t lxbook_order_count(const lx_t* dex, const lx_account_t* account,
                           uint32_t market_id);
 
+/**
+ * Get the account's live (new or open) orders, sorted by OID. Free the
+ * array with lx_book_orders_free; it is NULL when there are none.
+ * @param market_id Market to list, or 0 for every market
+ * @return LX_OK on success
+ */
+int32_t lxbook_get_open_orders(const lx_t* dex, const lx_account_t* account,
+                               uint32_t market_id, lx_book_order_t** orders,
+                               size_t* count);
+
+/**
+ * Free orders returned by lxbook_get_open_orders.
+ */
+void lx_book_orders_free(lx_book_order_t* orders);
+
 /* =============================================================================
  * LXVault API (LP-9030) - Clearinghouse
  * ============================================================================= */
@@ -586,10 +1155,19 @@ size_t lxbook_order_count(const lx_t* dex, const lx_account_t* account,
 int32_t lxvault_create_market(lx_t* dex, const lx_vault_market_config_t* config);
 
 /**
- * Update market configuration.
+ * Update market configuration. The currencies are fixed at creation
+ * (LX_ERR_INVALID_CURRENCY). Fails with LX_ERR_INSUFFICIENT_MARGIN if the
+ * new margins would make an account with a position liquidatable.
  */
 int32_t lxvault_update_market(lx_t* dex, const lx_vault_market_config_t* config);
 
+/**
+ * List market IDs in ascending order. Free the array with
+ * lx_market_ids_free; it is NULL when there are none.
+ * @return LX_OK on success
+ */
+int32_t lxvault_list_markets(const lx_t* dex, uint32_t** ids, size_t* count);
+
 /**
  * Deposit collateral.
  * @param amount_hi High 64 bits of amount (X18)
@@ -600,6 +1178,15 @@ int32_t lxvault_deposit(lx_t* dex, const lx_account_t* account,
                         const lx_currency_t* token,
                         int64_t amount_hi, uint64_t amount_lo);
 
+/**
+ * Deposit several tokens at once: tokens[i] is credited amounts_x18[i].
+ * Nothing is credited unless every amount is valid.
+ * @return LX_OK, or LX_ERR_INVALID_PRICE if any amount is not positive
+ */
+int32_t lxvault_deposit_multi(lx_t* dex, const lx_account_t* account,
+                              const lx_currency_t* tokens, const lx_i128_t* amounts_x18,
+                              size_t count);
+
 /**
  * Withdraw collateral (checks margin requirements).
  */
@@ -609,11 +1196,19 @@ int32_t lxvault_withdraw(lx_t* dex, const lx_account_t* account,
 
 /**
  * Internal transfer between subaccounts.
+ * @return LX_OK, or LX_ERR_UNAUTHORIZED between different owners unless
+ *         lxvault_set_cross_owner_transfers allowed it
  */
 int32_t lxvault_transfer(lx_t* dex, const lx_account_t* from, const lx_account_t* to,
                          const lx_currency_t* token,
                          int64_t amount_hi, uint64_t amount_lo);
 
+/**
+ * Permit lxvault_transfer between accounts with different owners.
+ * Off by default.
+ */
+void lxvault_set_cross_owner_transfers(lx_t* dex, bool allowed);
+
 /**
  * Get balance for token.
  * @param out Output balance value
@@ -627,6 +1222,31 @@ bool lxvault_get_balance(const lx_t* dex, const lx_account_t* account,
  */
 lx_margin_info_t lxvault_get_margin(const lx_t* dex, const lx_account_t* account);
 
+/**
+ * Get realized and unrealized PnL, trading fees paid and net funding for an
+ * account. An unknown account reports zeros.
+ * @return LX_OK on success
+ */
+int32_t lxvault_get_account_pnl(const lx_t* dex, const lx_account_t* account,
+                                lx_account_pnl_t* out);
+
+/**
+ * Get the additional position notional the account can open in a market.
+ * @param out Notional supported by free margin (zero when exhausted)
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxvault_get_buying_power(const lx_t* dex, const lx_account_t* account,
+                                 uint32_t market_id, lx_i128_t* out);
+
+/**
+ * Get the mark price at which the account's equity falls to its maintenance
+ * margin, holding every other position at its last marked PnL.
+ * @param out Liquidation price (zero if price alone cannot liquidate it)
+ * @return LX_OK or LX_ERR_POSITION_NOT_FOUND
+ */
+int32_t lxvault_get_liquidation_price(const lx_t* dex, const lx_account_t* account,
+                                      uint32_t market_id, lx_i128_t* out);
+
 /**
  * Get position for market.
  * @param out Output position
@@ -635,12 +1255,84 @@ lx_margin_info_t lxvault_get_margin(const lx_t* dex, const lx_account_t* account
 bool lxvault_get_position(const lx_t* dex, const lx_account_t* account,
                           uint32_t market_id, lx_position_t* out);
 
+/**
+ * Get every open position of an account, ascending by market. Free the
+ * array with lx_positions_free; it is NULL when there are none.
+ * @return LX_OK on success
+ */
+int32_t lxvault_get_positions(const lx_t* dex, const lx_account_t* account,
+                              lx_position_t** positions, size_t* count);
+
+/**
+ * Free positions returned by lxvault_get_positions.
+ */
+void lx_positions_free(lx_position_t* positions);
+
+/**
+ * Move size of from's position in a market to to at price. to's entry
+ * price is blended with price; from realizes PnL on the size moved.
+ * Neither account changes on error.
+ * @return LX_OK, LX_ERR_POSITION_NOT_FOUND if from holds less than size,
+ *         or LX_ERR_INSUFFICIENT_MARGIN if to cannot margin the size
+ */
+int32_t lxvault_transfer_position(lx_t* dex, const lx_account_t* from, const lx_account_t* to,
+                                  uint32_t market_id, lx_i128_t size_x18, lx_i128_t price_x18);
+
+/**
+ * Check an order against its market's size limits and the account's
+ * position without placing it. Checks run in order and the first
+ * violation is returned.
+ * @return LX_OK, LX_ERR_MARKET_NOT_FOUND, LX_ERR_ORDER_TOO_SMALL,
+ *         LX_ERR_ORDER_TOO_LARGE, LX_ERR_POSITION_LIMIT or LX_ERR_REDUCE_ONLY
+ */
+int32_t lxvault_check_order_limits(const lx_t* dex, const lx_account_t* account,
+                                   const lx_order_t* order);
+
 /**
  * Set margin mode for market.
+ * @return LX_OK, or LX_ERR_POSITION_OPEN unless the account is flat there
  */
 int32_t lxvault_set_margin_mode(lx_t* dex, const lx_account_t* account,
                                 uint32_t market_id, lx_margin_mode_t mode);
 
+/**
+ * Set an account's leverage in one market. Initial margin becomes the larger
+ * of 1/leverage and the market rate.
+ * @param leverage_x18 Between 1x and the market's max_leverage_x18
+ * @return LX_OK, LX_ERR_MARKET_NOT_FOUND or LX_ERR_INVALID_LEVERAGE
+ */
+int32_t lxvault_set_leverage(lx_t* dex, const lx_account_t* account,
+                             uint32_t market_id, lx_i128_t leverage_x18);
+
+/**
+ * Set an account's fee tier. Its maker and taker rates replace every
+ * market's fees for that account's fills.
+ * @param maker_fee_x18 Maker rate (negative = rebate, at most the taker rate)
+ * @param taker_fee_x18 Taker rate, non-negative
+ * @return LX_OK or LX_ERR_INVALID_FEE
+ */
+int32_t lxvault_set_fee_tier(lx_t* dex, const lx_account_t* account,
+                             lx_i128_t maker_fee_x18, lx_i128_t taker_fee_x18);
+
+/**
+ * Record an account's staked governance token balance, which selects its
+ * staking fee tier on later fills.
+ * @return LX_OK or LX_ERR_INVALID_PRICE for a negative amount
+ */
+int32_t lxvault_set_staked_balance(lx_t* dex, const lx_account_t* account,
+                                   lx_i128_t amount_x18);
+
+/**
+ * Replace the staking fee schedule. A fill uses the highest tier whose
+ * min_staked_x18 the account meets. Where the account also has a fee tier
+ * the lower rate applies; the two do not stack.
+ * @param count 0 removes staking discounts
+ * @return LX_OK, or LX_ERR_INVALID_FEE if a discount is outside [0, 1] or
+ *         a min_staked_x18 is negative; the schedule is then unchanged
+ */
+int32_t lxvault_set_staking_fee_tiers(lx_t* dex, const lx_staking_fee_tier_t* tiers,
+                                   size_t count);
+
 /**
  * Add margin to isolated position.
  */
@@ -658,6 +1350,14 @@ int32_t lxvault_remove_margin(lx_t* dex, const lx_account_t* account,
  */
 bool lxvault_is_liquidatable(const lx_t* dex, const lx_account_t* account);
 
+/**
+ * Check whether the account has any live book order (including held
+ * triggers) or open position, across all markets.
+ * @return LX_OK
+ */
+int32_t lxvault_account_has_exposure(const lx_t* dex, const lx_account_t* account,
+                                     bool* has_orders, bool* has_positions);
+
 /**
  * Liquidate a position.
  */
@@ -676,6 +1376,14 @@ int32_t lxvault_run_adl(lx_t* dex, uint32_t market_id);
  */
 int32_t lxvault_accrue_funding(lx_t* dex, uint32_t market_id);
 
+/**
+ * Settle an account's accrued funding for a market into its balance.
+ * @param out Signed amount settled (negative = paid)
+ * @return LX_OK or LX_ERR_POSITION_NOT_FOUND
+ */
+int32_t lxvault_settle_funding(lx_t* dex, const lx_account_t* account,
+                               uint32_t market_id, lx_i128_t* out);
+
 /**
  * Get insurance fund balance.
  */
@@ -713,6 +1421,21 @@ int32_t lxoracle_update_price_with_confidence(lx_t* dex, uint64_t asset_id,
                                                int64_t price_hi, uint64_t price_lo,
                                                int64_t confidence_hi, uint64_t confidence_lo);
 
+typedef struct {
+    uint64_t asset_id;
+    lx_price_source_t source;
+    lx_i128_t price_x18;
+    lx_i128_t confidence_x18;
+} lx_oracle_update_t;
+
+/**
+ * Apply a batch of source prices in one call.
+ * @param results Output result code per update (count entries)
+ * @return LX_OK unless the batch as a whole failed
+ */
+int32_t lxoracle_update_prices(lx_t* dex, const lx_oracle_update_t* updates,
+                               size_t count, int32_t* results);
+
 /**
  * Get aggregated price.
  * @param price_hi Output high 64 bits
@@ -741,6 +1464,49 @@ bool lxoracle_get_twap(const lx_t* dex, uint64_t asset_id, uint64_t window_secon
  */
 bool lxoracle_is_price_fresh(const lx_t* dex, uint64_t asset_id);
 
+/**
+ * Reject updates more than max_move_bps from the aggregate (0 = off).
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND for an unregistered asset
+ */
+int32_t lxoracle_set_circuit_breaker(lx_t* dex, uint64_t asset_id, uint32_t max_move_bps);
+
+/**
+ * Set the maximum price age, in seconds, after which an asset's source
+ * prices are stale and excluded from aggregation.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND for an unregistered asset
+ */
+int32_t lxoracle_set_staleness(lx_t* dex, uint64_t asset_id, uint64_t max_staleness);
+
+/**
+ * Get an asset's maximum price age in seconds.
+ * Returns false for an unregistered asset.
+ */
+bool lxoracle_get_staleness(const lx_t* dex, uint64_t asset_id, uint64_t* max_staleness);
+
+/**
+ * Select how an asset's source prices are aggregated.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND for an unregistered asset
+ */
+int32_t lxoracle_set_aggregation(lx_t* dex, uint64_t asset_id, lx_aggregation_t method);
+
+/**
+ * Get an asset's aggregation method.
+ * Returns false for an unregistered asset.
+ */
+bool lxoracle_get_aggregation(const lx_t* dex, uint64_t asset_id, lx_aggregation_t* method);
+
+/**
+ * Drop a source's price from aggregation and reject its updates with
+ * LX_ERR_ORACLE_UNAVAILABLE until lxoracle_restore_source.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND for an unregistered asset
+ */
+int32_t lxoracle_remove_source(lx_t* dex, uint64_t asset_id, lx_price_source_t source);
+
+/**
+ * Accept updates from a removed source again.
+ */
+int32_t lxoracle_restore_source(lx_t* dex, uint64_t asset_id, lx_price_source_t source);
+
 /**
  * Get price age in seconds.
  */
@@ -757,6 +1523,15 @@ uint64_t lxoracle_price_age(const lx_t* dex, uint64_t asset_id);
  */
 int32_t lxfeed_register_market(lx_t* dex, uint32_t market_id, uint64_t asset_id);
 
+/**
+ * Tune a market's mark price smoothing: the premium over index is an EWMA
+ * over ewma_window seconds (0 follows the latest sample), clamped to
+ * premium_clamp_bps of the index (0 keeps the absolute premium caps).
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxfeed_set_mark_config(lx_t* dex, uint32_t market_id, uint64_t ewma_window,
+                               uint32_t premium_clamp_bps);
+
 /**
  * Get mark price for market.
  */
@@ -812,6 +1587,35 @@ bool lxfeed_get_predicted_funding(const lx_t* dex, uint32_t market_id,
  */
 void lxfeed_calculate_funding(lx_t* dex, uint32_t market_id);
 
+/**
+ * Get a market's funding interval and rate cap, and when funding is next
+ * calculated.
+ * @return true if the market is registered
+ */
+bool lxfeed_get_funding_config(const lx_t* dex, uint32_t market_id, lx_funding_config_t* out);
+
+/**
+ * Set a market's funding interval and cap its absolute funding rate at
+ * clamp_bps per interval, keeping its other funding parameters. A pending
+ * next funding time moves onto the new interval.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxfeed_set_funding_config(lx_t* dex, uint32_t market_id, uint64_t interval_secs,
+                                  uint32_t clamp_bps);
+
+/**
+ * Get up to limit recent funding rate samples, newest first. Free the array
+ * with lx_funding_samples_free; it is NULL when there are none.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxfeed_get_funding_history(const lx_t* dex, uint32_t market_id, size_t limit,
+                                   lx_funding_sample_t** samples, size_t* count);
+
+/**
+ * Free samples returned by lxfeed_get_funding_history.
+ */
+void lx_funding_samples_free(lx_funding_sample_t* samples);
+
 /**
  * Get premium (mark - index).
  */
@@ -824,6 +1628,80 @@ bool lxfeed_get_premium(const lx_t* dex, uint32_t market_id,
 bool lxfeed_get_basis(const lx_t* dex, uint32_t market_id,
                       int64_t* basis_hi, uint64_t* basis_lo);
 
+/**
+ * Get index, mark, last and mid prices, premium and funding rate in one
+ * call. Prices not yet available are zero.
+ * @return false if the market is unknown
+ */
+bool lxfeed_get_all_prices(const lx_t* dex, uint32_t market_id, lx_feed_prices_t* out);
+
+/* =============================================================================
+ * LXLend API (LP-9050) - Lending Pool
+ *
+ * The pool only accepts the native (all-zero) currency the vault settles
+ * in; every call given another token returns LX_ERR_INVALID_CURRENCY.
+ * ============================================================================= */
+
+/**
+ * Supply tokens from the account's vault balance to the lending pool.
+ * @return LX_OK, LX_ERR_INSUFFICIENT_BALANCE or LX_ERR_INSUFFICIENT_MARGIN
+ */
+int32_t lxlend_supply(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* token, lx_i128_t amount_x18);
+
+/**
+ * Withdraw supplied tokens back to the vault balance.
+ * @return LX_OK, LX_ERR_INSUFFICIENT_BALANCE beyond the supply or the pool's
+ *         free liquidity, or LX_ERR_INSUFFICIENT_MARGIN if borrows would no
+ *         longer be covered
+ */
+int32_t lxlend_withdraw(lx_t* dex, const lx_account_t* account,
+                        const lx_currency_t* token, lx_i128_t amount_x18);
+
+/**
+ * Borrow against supplied collateral (up to 75% of its value); the tokens
+ * are credited to the vault balance.
+ * @return LX_OK, LX_ERR_INSUFFICIENT_LIQUIDITY or LX_ERR_INSUFFICIENT_MARGIN
+ */
+int32_t lxlend_borrow(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* token, lx_i128_t amount_x18);
+
+/**
+ * Repay debt from the vault balance; amounts above the debt repay it in full.
+ * @return LX_OK, LX_ERR_POSITION_NOT_FOUND if nothing is owed in the token,
+ *         or LX_ERR_INSUFFICIENT_BALANCE
+ */
+int32_t lxlend_repay(lx_t* dex, const lx_account_t* account,
+                     const lx_currency_t* token, lx_i128_t amount_x18);
+
+/**
+ * Get the account's supplied collateral value and outstanding borrow value.
+ */
+int32_t lxlend_get_account_liquidity(const lx_t* dex, const lx_account_t* account,
+                                     lx_i128_t* collateral_value_x18,
+                                     lx_i128_t* borrow_value_x18);
+
+/* =============================================================================
+ * LXLiquid API (LP-9060) - Self-Repaying Loans
+ * ============================================================================= */
+
+/**
+ * Open a self-repaying loan: the collateral leaves the account's vault
+ * balance and half its amount is credited back, in the same token, as
+ * principal. Yield the collateral earns pays the principal down.
+ * @return LX_OK, LX_ERR_INVALID_PRICE for a non-positive amount, or
+ *         LX_ERR_INSUFFICIENT_BALANCE
+ */
+int32_t lxliquid_open(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* collateral, lx_i128_t amount_x18,
+                      uint64_t* loan_id);
+
+/**
+ * Get a loan by ID.
+ * @return true if the loan exists
+ */
+bool lxliquid_get_loan(const lx_t* dex, uint64_t loan_id, lx_liquid_loan_t* loan);
+
 /* =============================================================================
  * Unified Trading Interface
  * ============================================================================= */
@@ -860,6 +1738,55 @@ int32_t lx_update_funding(lx_t* dex, uint32_t market_id);
  */
 int32_t lx_run_liquidations(lx_t* dex, uint32_t market_id);
 
+/* =============================================================================
+ * Snapshot & Restore
+ * ============================================================================= */
+
+/**
+ * Serialize the state of every component. The data starts with the engine's
+ * snapshot format version and restores only on a build of the same version.
+ * Callbacks, hooks and L1 subscriptions are not included.
+ * Caller must free *data with lx_snapshot_free.
+ * @return LX_OK on success
+ */
+int32_t lx_snapshot(const lx_t* dex, uint8_t** data, size_t* len);
+
+/**
+ * Free data returned by lx_snapshot.
+ */
+void lx_snapshot_free(uint8_t* data);
+
+/**
+ * Load data from lx_snapshot into a fresh instance.
+ * @return LX_OK on success, LX_ERR_INVALID_SNAPSHOT for malformed data, another
+ *         format version or an instance that already has book markets; the
+ *         instance should then be destroyed
+ */
+int32_t lx_restore(lx_t* dex, const uint8_t* data, size_t len);
+
+/* =============================================================================
+ * Journal
+ * ============================================================================= */
+
+/**
+ * Register a callback for a record of every state-changing pool, book, vault,
+ * lend, liquid, oracle and feed operation and every trade bust, delivered once
+ * the operation completes. While a callback is set, journaled operations run
+ * one at a time, so records arrive in the order the operations took effect.
+ * Records carry a sequence number that restarts at 1 on each call; the
+ * callback must not call back into dex.
+ * @param callback NULL stops journaling
+ */
+void lx_set_journal_callback(lx_t* dex, lx_journal_callback_t callback, void* user_data);
+
+/**
+ * Re-run a journaled record. Apply records in sequence to a fresh instance,
+ * or one restored from the snapshot the journal started after.
+ * @return LX_OK on success, LX_ERR_INVALID_JOURNAL for a malformed or
+ *         out-of-sequence record, or one whose result differs on replay
+ */
+int32_t lx_journal_apply(lx_t* dex, const uint8_t* record, size_t len);
+
 /* =============================================================================
  * Statistics
  * ============================================================================= */
@@ -878,6 +1805,14 @@ typedef struct {
     uint64_t total_trades;
 } lx_book_stats_t;
 
+typedef struct {
+    uint64_t orders_placed;
+    uint64_t orders_cancelled;
+    uint64_t trades;
+    lx_i128_t volume_x18;
+    uint64_t open_orders;       /* Resting orders */
+} lx_book_market_stats_t;
+
 typedef struct {
     uint64_t total_accounts;
     uint64_t total_positions;
@@ -910,6 +1845,18 @@ typedef struct {
  */
 lx_global_stats_t lx_get_stats(const lx_t* dex);
 
+typedef struct {
+    bool running;
+    uint64_t oldest_stale_asset;  /* Valid when stale_asset_count > 0 */
+    uint64_t stale_asset_count;   /* Oracle assets past max staleness */
+    uint64_t open_orders;         /* Resting orders across all markets */
+} lx_health_t;
+
+/**
+ * Get readiness data for health probes.
+ */
+lx_health_t lx_health(const lx_t* dex);
+
 /**
  * Get pool statistics.
  */
@@ -920,6 +1867,13 @@ lx_pool_stats_t lxpool_get_stats(const lx_t* dex);
  */
 lx_book_stats_t lxbook_get_stats(const lx_t* dex);
 
+/**
+ * Get order, trade and volume counters for one book market.
+ * @return LX_OK or LX_ERR_MARKET_NOT_FOUND
+ */
+int32_t lxbook_get_market_stats(const lx_t* dex, uint32_t market_id,
+                                lx_book_market_stats_t* stats);
+
 /**
  * Get vault statistics.
  */
@@ -4,6 +4,8 @@
 
 #include "lx_c.h"
 #include "lux/lx.hpp"
+#include <algorithm>
+#include <cmath>
 #include <cstring>
 #include <chrono>
 #include <new>
@@ -97,6 +99,16 @@ static inline lux::PoolKey to_cpp_pool_key(const lx_pool_key_t* key) {
     return k;
 }
 
+static inline lx_pool_key_t to_c_pool_key(const lux::PoolKey& key) {
+    lx_pool_key_t k;
+    k.currency0 = to_c_currency(key.currency0);
+    k.currency1 = to_c_currency(key.currency1);
+    k.fee = key.fee;
+    k.tick_spacing = key.tick_spacing;
+    k.hooks = to_c_address(key.hooks);
+    return k;
+}
+
 /* =============================================================================
  * Swap Params Conversion
  * ============================================================================= */
@@ -169,6 +181,7 @@ static inline lux::BookMarketConfig to_cpp_book_config(const lx_book_market_conf
         c.post_only_mode = cfg->post_only_mode;
         c.reduce_only_mode = cfg->reduce_only_mode;
         c.status = cfg->status;
+        c.stp_mode = cfg->stp_mode;
     }
     return c;
 }
@@ -214,6 +227,7 @@ static inline lux::LXOrder to_cpp_order(const lx_order_t* order) {
         std::memcpy(o.cloid.data(), order->cloid, 16);
         std::memcpy(o.group_id.data(), order->group_id, 16);
         o.group_type = static_cast<lux::GroupType>(order->group_type);
+        o.stp_group = order->stp_group;
     }
     return o;
 }
@@ -228,9 +242,30 @@ static inline lx_place_result_t to_c_place_result(const lux::LXPlaceResult& r) {
     pr.status = r.status;
     pr.filled_size_x18 = to_c_i128(r.filled_size_x18);
     pr.avg_px_x18 = to_c_i128(r.avg_px_x18);
+    pr.reason = r.reason;
     return pr;
 }
 
+static inline lx_book_order_t to_c_book_order(const lux::BookOrderState& s) {
+    lx_book_order_t o;
+    o.oid = s.oid;
+    std::memcpy(o.cloid, s.cloid.data(), 16);
+    o.market_id = s.market_id;
+    o.is_buy = s.is_buy;
+    o.kind = static_cast<lx_order_kind_t>(s.kind);
+    o.tif = static_cast<lx_tif_t>(s.tif);
+    o.original_size_x18 = to_c_i128(s.original_size_x18);
+    o.remaining_size_x18 = to_c_i128(s.remaining_size_x18);
+    o.filled_size_x18 = to_c_i128(s.filled_size_x18);
+    o.limit_px_x18 = to_c_i128(s.limit_price_x18);
+    o.trigger_px_x18 = to_c_i128(s.trigger_price_x18);
+    o.avg_fill_px_x18 = to_c_i128(s.avg_fill_price_x18);
+    o.status = static_cast<uint8_t>(s.status);
+    o.created_at = s.created_at;
+    o.updated_at = s.updated_at;
+    return o;
+}
+
 /* =============================================================================
  * L1 Conversion
  * ============================================================================= */
@@ -245,6 +280,21 @@ static inline lx_l1_t to_c_l1(const lux::LXL1& l1) {
     return r;
 }
 
+// Depth levels carry engine prices as doubles; round back to the engine's
+// 1e-8 ticks before scaling so whole prices stay exact
+static inline lx_book_level_t* to_c_book_levels(const std::vector<lux::DepthLevel>& levels) {
+    if (levels.empty()) return nullptr;
+    auto* out = new lx_book_level_t[levels.size()];
+    for (size_t i = 0; i < levels.size(); ++i) {
+        out[i].px_x18 = to_c_i128(
+            static_cast<lux::I128>(std::llround(levels[i].price * 100000000.0)) * lux::X18_ONE / 100000000LL);
+        out[i].sz_x18 = to_c_i128(
+            static_cast<lux::I128>(std::llround(levels[i].quantity * 100000000.0)) * lux::X18_ONE / 100000000LL);
+        out[i].order_count = static_cast<uint32_t>(levels[i].order_count);
+    }
+    return out;
+}
+
 /* =============================================================================
  * Position Conversion
  * ============================================================================= */
@@ -316,6 +366,21 @@ static inline lx_liquidation_result_t to_c_liquidation_result(const lux::LXLiqui
     return r;
 }
 
+/* =============================================================================
+ * Market ID List Conversion
+ * ============================================================================= */
+
+// Copies ids into an array freed by lx_market_ids_free; none leaves *out NULL
+static inline int32_t to_c_market_ids(const std::vector<uint32_t>& ids, uint32_t** out,
+                                      size_t* count) {
+    if (ids.empty()) return LX_OK;
+    auto* array = new uint32_t[ids.size()];
+    std::copy(ids.begin(), ids.end(), array);
+    *out = array;
+    *count = ids.size();
+    return LX_OK;
+}
+
 /* =============================================================================
  * C API Implementation
  * ============================================================================= */
@@ -344,6 +409,7 @@ lx_t* lx_create_with_config(const lx_dex_config_t* config) {
         cfg.engine_config.max_batch_size = config->max_batch_size;
         cfg.enable_hooks = config->enable_hooks;
         cfg.enable_flash_loans = config->enable_flash_loans;
+        cfg.enable_trade_busts = config->enable_trade_busts;
         cfg.funding_interval = config->funding_interval;
         cfg.default_maker_fee_x18 = to_cpp_i128(config->default_maker_fee_x18);
         cfg.default_taker_fee_x18 = to_cpp_i128(config->default_taker_fee_x18);
@@ -393,10 +459,67 @@ bool lx_is_running(const lx_t* dex) {
     return reinterpret_cast<const lux::LX*>(dex)->is_running();
 }
 
+int32_t lx_begin_drain(lx_t* dex) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        reinterpret_cast<lux::LX*>(dex)->begin_drain();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lx_is_draining(const lx_t* dex) {
+    if (!dex) return false;
+    return reinterpret_cast<const lux::LX*>(dex)->is_draining();
+}
+
+uint64_t lx_pending_orders(const lx_t* dex) {
+    if (!dex) return 0;
+    try {
+        return reinterpret_cast<const lux::LX*>(dex)->pending_orders();
+    } catch (...) {
+        return 0;
+    }
+}
+
 const char* lx_version(void) {
     return lux::LX::version();
 }
 
+lx_build_info_t lx_build_info(void) {
+    auto info = lux::LX::build_info();
+    lx_build_info_t out;
+    out.version = info.version;
+    out.git_commit = info.git_commit;
+    out.build_date = info.build_date;
+    out.features = info.features;
+    return out;
+}
+
+void lx_set_system_event_callback(lx_t* dex, lx_system_event_callback_t callback,
+                                  void* user_data) {
+    if (!dex) return;
+    try {
+        auto* lx = reinterpret_cast<lux::LX*>(dex);
+        if (!callback) {
+            lx->set_system_event_callback(nullptr);
+            return;
+        }
+        lx->set_system_event_callback([callback, user_data](const lux::LXSystemEvent& event) {
+            lx_system_event_t e;
+            e.kind = static_cast<uint8_t>(event.kind);
+            e.severity = static_cast<uint8_t>(event.severity);
+            e.market_id = event.market_id;
+            e.asset_id = event.asset_id;
+            e.account = to_c_account(event.account);
+            e.code = event.code;
+            e.timestamp = event.timestamp;
+            callback(user_data, &e);
+        });
+    } catch (...) {}
+}
+
 /* =============================================================================
  * LXPool API (LP-9010)
  * ============================================================================= */
@@ -428,6 +551,70 @@ lx_balance_delta_t lxpool_swap(lx_t* dex, const lx_pool_key_t* key,
     }
 }
 
+int32_t lxpool_quote_swap(const lx_t* dex, const lx_pool_key_t* key,
+                          const lx_swap_params_t* params, bool allow_partial,
+                          lx_balance_delta_t* delta, bool* full) {
+    if (!dex || !key || !params || !delta || !full) return LX_ERR_NULL_POINTER;
+
+    try {
+        lux::BalanceDelta quoted{};
+        bool quoted_full = false;
+        int32_t rc = reinterpret_cast<const lux::LX*>(dex)->pool().quote_swap(
+            to_cpp_pool_key(key), to_cpp_swap_params(params), allow_partial, quoted, quoted_full);
+        if (rc != LX_OK) return rc;
+        *delta = to_c_balance_delta(quoted);
+        *full = quoted_full;
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxpool_place_trigger_swap(lx_t* dex, const lx_account_t* owner,
+                                  const lx_pool_key_t* key, const lx_swap_params_t* params,
+                                  lx_i128_t trigger_px_x18, uint8_t direction,
+                                  uint64_t* trigger_id) {
+    if (!dex || !owner || !key || !params || !trigger_id) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->pool().place_trigger_swap(
+            to_cpp_account(owner), to_cpp_pool_key(key), to_cpp_swap_params(params),
+            to_cpp_i128(trigger_px_x18), static_cast<lux::TriggerDir>(direction), *trigger_id);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxpool_execute_triggers(lx_t* dex, const lx_pool_key_t* key,
+                                lx_triggered_swap_t** swaps, size_t* count) {
+    if (!dex || !key || !swaps || !count) return LX_ERR_NULL_POINTER;
+    *swaps = nullptr;
+    *count = 0;
+
+    try {
+        std::vector<lux::TriggeredSwap> fired;
+        int32_t rc = reinterpret_cast<lux::LX*>(dex)->pool().execute_triggers(
+            to_cpp_pool_key(key), fired);
+        if (rc != LX_OK) return rc;
+        if (fired.empty()) return LX_OK;
+
+        auto* out = new lx_triggered_swap_t[fired.size()];
+        for (size_t i = 0; i < fired.size(); i++) {
+            out[i].trigger_id = fired[i].trigger_id;
+            out[i].owner = to_c_account(fired[i].owner);
+            out[i].delta = to_c_balance_delta(fired[i].delta);
+        }
+        *swaps = out;
+        *count = fired.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_triggered_swaps_free(lx_triggered_swap_t* swaps) {
+    delete[] swaps;
+}
+
 lx_balance_delta_t lxpool_modify_liquidity(lx_t* dex, const lx_pool_key_t* key,
                                            const lx_modify_params_t* params) {
     lx_balance_delta_t zero = {};
@@ -486,6 +673,60 @@ bool lxpool_get_liquidity(const lx_t* dex, const lx_pool_key_t* key, lx_i128_t*
     }
 }
 
+bool lxpool_get_position(const lx_t* dex, const lx_pool_key_t* key, const lx_address_t* owner,
+                         int32_t tick_lower, int32_t tick_upper, uint64_t salt,
+                         lx_pool_position_t* out) {
+    if (!dex || !key || !owner || !out) return false;
+
+    try {
+        auto k = to_cpp_pool_key(key);
+        auto pos = reinterpret_cast<const lux::LX*>(dex)->pool().get_position(
+            k, to_cpp_address(owner), tick_lower, tick_upper, salt);
+        if (!pos) return false;
+        out->liquidity = to_c_i128(pos->liquidity);
+        out->fee_growth_inside0_last_x128 = to_c_i128(pos->fee_growth_inside0_last_x128);
+        out->fee_growth_inside1_last_x128 = to_c_i128(pos->fee_growth_inside1_last_x128);
+        out->tokens_owed0 = to_c_i128(pos->tokens_owed0);
+        out->tokens_owed1 = to_c_i128(pos->tokens_owed1);
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+int32_t lxpool_get_ticks(const lx_t* dex, const lx_pool_key_t* key,
+                         int32_t tick_lower, int32_t tick_upper,
+                         lx_tick_info_t** ticks, size_t* count) {
+    if (!dex || !key || !ticks || !count) return LX_ERR_NULL_POINTER;
+    *ticks = nullptr;
+    *count = 0;
+    if (tick_lower > tick_upper) return LX_ERR_INVALID_TICK_RANGE;
+
+    try {
+        auto k = to_cpp_pool_key(key);
+        auto found = reinterpret_cast<const lux::LX*>(dex)->pool().get_ticks(k, tick_lower, tick_upper);
+        if (!found) return LX_ERR_POOL_NOT_INITIALIZED;
+        if (found->empty()) return LX_OK;
+
+        auto* out = new lx_tick_info_t[found->size()];
+        for (size_t i = 0; i < found->size(); i++) {
+            const auto& [tick, info] = (*found)[i];
+            out[i].tick = tick;
+            out[i].liquidity_gross = to_c_i128(info.liquidity_gross);
+            out[i].liquidity_net = to_c_i128(info.liquidity_net);
+        }
+        *ticks = out;
+        *count = found->size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_tick_infos_free(lx_tick_info_t* ticks) {
+    delete[] ticks;
+}
+
 bool lxpool_exists(const lx_t* dex, const lx_pool_key_t* key) {
     if (!dex || !key) return false;
     try {
@@ -496,12 +737,46 @@ bool lxpool_exists(const lx_t* dex, const lx_pool_key_t* key) {
     }
 }
 
+int32_t lxpool_list(const lx_t* dex, lx_pool_key_t** keys, size_t* count) {
+    if (!dex || !keys || !count) return LX_ERR_NULL_POINTER;
+    *keys = nullptr;
+    *count = 0;
+
+    try {
+        auto all = reinterpret_cast<const lux::LX*>(dex)->pool().list_pools();
+        if (all.empty()) return LX_OK;
+
+        auto* out = new lx_pool_key_t[all.size()];
+        for (size_t i = 0; i < all.size(); i++) {
+            out[i] = to_c_pool_key(all[i]);
+        }
+        *keys = out;
+        *count = all.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_pool_keys_free(lx_pool_key_t* keys) {
+    delete[] keys;
+}
+
 int32_t lxpool_set_protocol_fee(lx_t* dex, const lx_pool_key_t* key, uint32_t new_fee) {
     if (!dex || !key) return LX_ERR_NULL_POINTER;
     try {
         auto k = to_cpp_pool_key(key);
-        reinterpret_cast<lux::LX*>(dex)->pool().set_protocol_fee(k, new_fee);
-        return LX_OK;
+        return reinterpret_cast<lux::LX*>(dex)->pool().set_protocol_fee(k, new_fee);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxpool_set_dynamic_fee(lx_t* dex, const lx_pool_key_t* key, uint32_t new_fee) {
+    if (!dex || !key) return LX_ERR_NULL_POINTER;
+    try {
+        auto k = to_cpp_pool_key(key);
+        return reinterpret_cast<lux::LX*>(dex)->pool().set_dynamic_fee(k, new_fee);
     } catch (...) {
         return LX_ERR_INTERNAL;
     }
@@ -522,6 +797,49 @@ lx_balance_delta_t lxpool_collect_protocol(lx_t* dex, const lx_pool_key_t* key,
     }
 }
 
+int32_t lxpool_collect_fees(lx_t* dex, const lx_pool_key_t* key, const lx_address_t* owner,
+                            int32_t tick_lower, int32_t tick_upper, uint64_t salt,
+                            lx_balance_delta_t* out) {
+    if (!dex || !key || !owner || !out) return LX_ERR_NULL_POINTER;
+    try {
+        auto k = to_cpp_pool_key(key);
+        auto addr = to_cpp_address(owner);
+        auto delta = reinterpret_cast<lux::LX*>(dex)->pool().collect_fees(k, addr, tick_lower, tick_upper, salt);
+        if (!delta) return LX_ERR_POSITION_NOT_FOUND;
+        *out = to_c_balance_delta(*delta);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+/* =============================================================================
+ * LXHooks API (LP-9013)
+ * ============================================================================= */
+
+int32_t lxhooks_register(lx_t* dex, const lx_address_t* hook, uint32_t flags) {
+    if (!dex || !hook) return LX_ERR_NULL_POINTER;
+    try {
+        auto addr = to_cpp_address(hook);
+        return reinterpret_cast<lux::LX*>(dex)->pool().register_hook_flags(addr, flags);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lxhooks_get_flags(const lx_t* dex, const lx_address_t* hook, uint32_t* flags) {
+    if (!dex || !hook || !flags) return false;
+    try {
+        auto addr = to_cpp_address(hook);
+        auto registered = reinterpret_cast<const lux::LX*>(dex)->pool().get_hook_flags(addr);
+        if (!registered) return false;
+        *flags = *registered;
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
 /* =============================================================================
  * LXBook API (LP-9020)
  * ============================================================================= */
@@ -551,6 +869,41 @@ bool lxbook_market_exists(const lx_t* dex, uint32_t market_id) {
     return reinterpret_cast<const lux::LX*>(dex)->book().market_exists(market_id);
 }
 
+int32_t lxbook_list_markets(const lx_t* dex, uint32_t** ids, size_t* count) {
+    if (!dex || !ids || !count) return LX_ERR_NULL_POINTER;
+    *ids = nullptr;
+    *count = 0;
+
+    try {
+        return to_c_market_ids(reinterpret_cast<const lux::LX*>(dex)->book().list_markets(),
+                               ids, count);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_market_ids_free(uint32_t* ids) {
+    delete[] ids;
+}
+
+int32_t lxbook_remove_market(lx_t* dex, uint32_t market_id) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->book().remove_market(market_id);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxbook_set_market_status(lx_t* dex, uint32_t market_id, uint8_t status) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->book().set_market_status(market_id, status);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 uint8_t lxbook_get_market_status(const lx_t* dex, uint32_t market_id) {
     if (!dex) return 0;
     return reinterpret_cast<const lux::LX*>(dex)->book().get_market_status(market_id);
@@ -571,6 +924,22 @@ lx_place_result_t lxbook_place_order(lx_t* dex, const lx_account_t* sender,
     }
 }
 
+int32_t lxbook_place_orders(lx_t* dex, const lx_account_t* sender,
+                            const lx_order_t* orders, size_t count,
+                            lx_place_result_t* results) {
+    if (!dex || !sender || (count > 0 && (!orders || !results))) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(sender);
+        auto& book = reinterpret_cast<lux::LX*>(dex)->book();
+        for (size_t i = 0; i < count; i++) {
+            results[i] = to_c_place_result(book.place_order(acc, to_cpp_order(&orders[i])));
+        }
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 int32_t lxbook_cancel_order(lx_t* dex, const lx_account_t* sender,
                             uint32_t market_id, uint64_t oid) {
     if (!dex || !sender) return LX_ERR_NULL_POINTER;
@@ -582,6 +951,21 @@ int32_t lxbook_cancel_order(lx_t* dex, const lx_account_t* sender,
     }
 }
 
+int32_t lxbook_cancel_orders(lx_t* dex, const lx_account_t* sender, uint32_t market_id,
+                             const uint64_t* oids, size_t count, int32_t* codes) {
+    if (!dex || !sender || (count > 0 && (!oids || !codes))) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(sender);
+        auto& book = reinterpret_cast<lux::LX*>(dex)->book();
+        for (size_t i = 0; i < count; i++) {
+            codes[i] = book.cancel_order(acc, market_id, oids[i]);
+        }
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 int32_t lxbook_cancel_by_cloid(lx_t* dex, const lx_account_t* sender,
                                uint32_t market_id, const uint8_t* cloid) {
     if (!dex || !sender || !cloid) return LX_ERR_NULL_POINTER;
@@ -605,6 +989,61 @@ int32_t lxbook_cancel_all(lx_t* dex, const lx_account_t* sender, uint32_t market
     }
 }
 
+int32_t lxbook_reset_quotes(lx_t* dex, const lx_account_t* sender, uint32_t market_id,
+                            const lx_order_t* orders, size_t count,
+                            lx_place_result_t* results) {
+    if (!dex || !sender || (count > 0 && (!orders || !results))) return LX_ERR_NULL_POINTER;
+    try {
+        std::vector<lux::LXOrder> new_orders;
+        new_orders.reserve(count);
+        for (size_t i = 0; i < count; i++) {
+            new_orders.push_back(to_cpp_order(&orders[i]));
+        }
+        std::vector<lux::LXPlaceResult> placed;
+        int32_t rc = reinterpret_cast<lux::LX*>(dex)->book().reset_quotes(
+            to_cpp_account(sender), market_id, new_orders, placed);
+        if (rc != LX_OK) return rc;
+        for (size_t i = 0; i < placed.size(); i++) {
+            results[i] = to_c_place_result(placed[i]);
+        }
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxbook_arm_cancel_on_disconnect(lx_t* dex, const lx_account_t* account,
+                                        uint32_t timeout_ms) {
+    if (!dex || !account) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        return reinterpret_cast<lux::LX*>(dex)->book().arm_cancel_on_disconnect(acc, timeout_ms);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxbook_heartbeat(lx_t* dex, const lx_account_t* account) {
+    if (!dex || !account) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        return reinterpret_cast<lux::LX*>(dex)->book().heartbeat(acc);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+uint64_t lxbook_check_heartbeats(lx_t* dex) {
+    if (!dex) return 0;
+    try {
+        auto now_ms = std::chrono::duration_cast<std::chrono::milliseconds>(
+            std::chrono::system_clock::now().time_since_epoch()).count();
+        return reinterpret_cast<lux::LX*>(dex)->book().check_heartbeats(static_cast<uint64_t>(now_ms));
+    } catch (...) {
+        return 0;
+    }
+}
+
 lx_place_result_t lxbook_amend_order(lx_t* dex, const lx_account_t* sender,
                                       uint32_t market_id, uint64_t oid,
                                       lx_i128_t new_size_x18, lx_i128_t new_price_x18) {
@@ -622,6 +1061,67 @@ lx_place_result_t lxbook_amend_order(lx_t* dex, const lx_account_t* sender,
     }
 }
 
+int32_t lxbook_bust_trade(lx_t* dex, uint32_t market_id, uint64_t trade_id) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->bust_trade(market_id, trade_id);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lxbook_set_trade_callback(lx_t* dex, lx_trade_callback_t callback, void* user_data) {
+    if (!dex) return;
+    try {
+        auto* lx = reinterpret_cast<lux::LX*>(dex);
+        if (!callback) {
+            lx->set_trade_callback(nullptr);
+            return;
+        }
+        lx->set_trade_callback([callback, user_data](const lux::LXTrade& trade) {
+            lx_trade_t t;
+            t.trade_id = trade.trade_id;
+            t.market_id = trade.market_id;
+            t.maker_oid = trade.maker_oid;
+            t.taker_oid = trade.taker_oid;
+            t.maker = to_c_account(trade.maker);
+            t.taker = to_c_account(trade.taker);
+            t.taker_is_buy = trade.taker_is_buy;
+            t.px_x18 = to_c_i128(trade.px_x18);
+            t.sz_x18 = to_c_i128(trade.sz_x18);
+            t.timestamp = trade.timestamp;
+            callback(user_data, &t);
+        });
+    } catch (...) {}
+}
+
+int32_t lxbook_evaluate_triggers(lx_t* dex, uint32_t market_id, lx_i128_t ref_px_x18,
+                                 uint64_t** oids, size_t* count) {
+    if (!dex || !oids || !count) return LX_ERR_NULL_POINTER;
+    *oids = nullptr;
+    *count = 0;
+
+    try {
+        auto& book = reinterpret_cast<lux::LX*>(dex)->book();
+        if (!book.market_exists(market_id)) return LX_ERR_MARKET_NOT_FOUND;
+        auto fired = book.evaluate_triggers(market_id, to_cpp_i128(ref_px_x18));
+        if (fired.empty()) return LX_OK;
+
+        auto* out = new uint64_t[fired.size()];
+        std::copy(fired.begin(), fired.end(), out);
+        *oids = out;
+        *count = fired.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_oids_free(uint64_t* oids) {
+    delete[] oids;
+}
+
 lx_l1_t lxbook_get_l1(const lx_t* dex, uint32_t market_id) {
     lx_l1_t zero = {};
     if (!dex) return zero;
@@ -634,49 +1134,191 @@ lx_l1_t lxbook_get_l1(const lx_t* dex, uint32_t market_id) {
     }
 }
 
-size_t lxbook_order_count(const lx_t* dex, const lx_account_t* account,
-                          uint32_t market_id) {
-    if (!dex || !account) return 0;
+int32_t lxbook_subscribe_l1(lx_t* dex, uint32_t market_id, lx_l1_callback_t callback,
+                            void* user_data, uint64_t* subscription_id) {
+    if (!dex || !callback || !subscription_id) return LX_ERR_NULL_POINTER;
     try {
-        auto acc = to_cpp_account(account);
-        auto orders = reinterpret_cast<const lux::LX*>(dex)->book().get_orders(acc, market_id);
-        return orders.size();
+        return reinterpret_cast<lux::LX*>(dex)->book().subscribe_l1(market_id,
+            [callback, user_data](uint32_t id, const lux::LXL1& l1) {
+                lx_l1_t c = to_c_l1(l1);
+                callback(user_data, id, &c);
+            },
+            *subscription_id);
     } catch (...) {
-        return 0;
+        return LX_ERR_INTERNAL;
     }
 }
 
-/* =============================================================================
- * LXVault API (LP-9030)
- * ============================================================================= */
-
-int32_t lxvault_create_market(lx_t* dex, const lx_vault_market_config_t* config) {
-    if (!dex || !config) return LX_ERR_NULL_POINTER;
+void lxbook_unsubscribe_l1(lx_t* dex, uint64_t subscription_id) {
+    if (!dex) return;
     try {
-        auto cfg = to_cpp_vault_config(config);
-        return reinterpret_cast<lux::LX*>(dex)->vault().create_market(cfg);
+        reinterpret_cast<lux::LX*>(dex)->book().unsubscribe_l1(subscription_id);
+    } catch (...) {}
+}
+
+int32_t lxbook_get_l2(const lx_t* dex, uint32_t market_id, size_t levels,
+                      lx_book_depth_t* depth) {
+    if (!dex || !depth) return LX_ERR_NULL_POINTER;
+    *depth = {};
+    try {
+        const auto& book = reinterpret_cast<const lux::LX*>(dex)->book();
+        if (!book.market_exists(market_id)) return LX_ERR_MARKET_NOT_FOUND;
+
+        auto d = book.get_depth(market_id, levels);
+        depth->bids = to_c_book_levels(d.bids);
+        depth->bid_count = d.bids.size();
+        try {
+            depth->asks = to_c_book_levels(d.asks);
+        } catch (...) {
+            lxbook_depth_free(depth);
+            throw;
+        }
+        depth->ask_count = d.asks.size();
+        depth->timestamp = static_cast<uint64_t>(d.timestamp.count());
+        return LX_OK;
     } catch (...) {
         return LX_ERR_INTERNAL;
     }
 }
 
-int32_t lxvault_update_market(lx_t* dex, const lx_vault_market_config_t* config) {
-    if (!dex || !config) return LX_ERR_NULL_POINTER;
+void lxbook_depth_free(lx_book_depth_t* depth) {
+    if (!depth) return;
+    delete[] depth->bids;
+    delete[] depth->asks;
+    depth->bids = nullptr;
+    depth->asks = nullptr;
+    depth->bid_count = 0;
+    depth->ask_count = 0;
+}
+
+int32_t lxbook_estimate_fees(const lx_t* dex, const lx_order_t* order,
+                             lx_i128_t* maker_fee, lx_i128_t* taker_fee) {
+    if (!dex || !order || !maker_fee || !taker_fee) return LX_ERR_NULL_POINTER;
     try {
-        auto cfg = to_cpp_vault_config(config);
-        return reinterpret_cast<lux::LX*>(dex)->vault().update_market(cfg);
+        auto ord = to_cpp_order(order);
+        auto estimate = reinterpret_cast<const lux::LX*>(dex)->estimate_fees(ord);
+        if (!estimate) return LX_ERR_MARKET_NOT_FOUND;
+        *maker_fee = to_c_i128(estimate->maker_fee_x18);
+        *taker_fee = to_c_i128(estimate->taker_fee_x18);
+        return LX_OK;
     } catch (...) {
         return LX_ERR_INTERNAL;
     }
 }
 
-int32_t lxvault_deposit(lx_t* dex, const lx_account_t* account,
-                        const lx_currency_t* token,
-                        int64_t amount_hi, uint64_t amount_lo) {
-    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+int32_t lxbook_simulate_fill(const lx_t* dex, uint32_t market_id, bool is_buy,
+                             lx_i128_t size_x18, lx_i128_t* avg_px_x18,
+                             lx_i128_t* filled_x18) {
+    if (!dex || !avg_px_x18 || !filled_x18) return LX_ERR_NULL_POINTER;
     try {
-        auto acc = to_cpp_account(account);
-        auto cur = to_cpp_currency(token);
+        auto fill = reinterpret_cast<const lux::LX*>(dex)->book().quote_fill(
+            market_id, is_buy, to_cpp_i128(size_x18));
+        if (!fill) return LX_ERR_MARKET_NOT_FOUND;
+        *avg_px_x18 = to_c_i128(fill->avg_px_x18);
+        *filled_x18 = to_c_i128(fill->filled_x18);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lxbook_get_order(const lx_t* dex, uint32_t market_id, uint64_t oid,
+                      lx_book_order_t* out) {
+    if (!dex || !out) return false;
+    try {
+        auto order = reinterpret_cast<const lux::LX*>(dex)->book().get_order(market_id, oid);
+        if (!order) return false;
+        *out = to_c_book_order(*order);
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+size_t lxbook_order_count(const lx_t* dex, const lx_account_t* account,
+                          uint32_t market_id) {
+    if (!dex || !account) return 0;
+    try {
+        auto acc = to_cpp_account(account);
+        auto orders = reinterpret_cast<const lux::LX*>(dex)->book().get_orders(acc, market_id);
+        return orders.size();
+    } catch (...) {
+        return 0;
+    }
+}
+
+int32_t lxbook_get_open_orders(const lx_t* dex, const lx_account_t* account,
+                               uint32_t market_id, lx_book_order_t** orders,
+                               size_t* count) {
+    if (!dex || !account || !orders || !count) return LX_ERR_NULL_POINTER;
+    *orders = nullptr;
+    *count = 0;
+
+    try {
+        auto acc = to_cpp_account(account);
+        auto open = reinterpret_cast<const lux::LX*>(dex)->book().get_open_orders(acc, market_id);
+        if (open.empty()) return LX_OK;
+
+        auto* out = new lx_book_order_t[open.size()];
+        for (size_t i = 0; i < open.size(); i++) {
+            out[i] = to_c_book_order(open[i]);
+        }
+        *orders = out;
+        *count = open.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_book_orders_free(lx_book_order_t* orders) {
+    delete[] orders;
+}
+
+/* =============================================================================
+ * LXVault API (LP-9030)
+ * ============================================================================= */
+
+int32_t lxvault_create_market(lx_t* dex, const lx_vault_market_config_t* config) {
+    if (!dex || !config) return LX_ERR_NULL_POINTER;
+    try {
+        auto cfg = to_cpp_vault_config(config);
+        return reinterpret_cast<lux::LX*>(dex)->vault().create_market(cfg);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_update_market(lx_t* dex, const lx_vault_market_config_t* config) {
+    if (!dex || !config) return LX_ERR_NULL_POINTER;
+    try {
+        auto cfg = to_cpp_vault_config(config);
+        return reinterpret_cast<lux::LX*>(dex)->vault().update_market(cfg);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_list_markets(const lx_t* dex, uint32_t** ids, size_t* count) {
+    if (!dex || !ids || !count) return LX_ERR_NULL_POINTER;
+    *ids = nullptr;
+    *count = 0;
+
+    try {
+        return to_c_market_ids(reinterpret_cast<const lux::LX*>(dex)->vault().list_markets(),
+                               ids, count);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_deposit(lx_t* dex, const lx_account_t* account,
+                        const lx_currency_t* token,
+                        int64_t amount_hi, uint64_t amount_lo) {
+    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        auto cur = to_cpp_currency(token);
         auto amount = to_cpp_i128_parts(amount_hi, amount_lo);
         return reinterpret_cast<lux::LX*>(dex)->vault().deposit(acc, cur, amount);
     } catch (...) {
@@ -684,6 +1326,22 @@ int32_t lxvault_deposit(lx_t* dex, const lx_account_t* account,
     }
 }
 
+int32_t lxvault_deposit_multi(lx_t* dex, const lx_account_t* account,
+                              const lx_currency_t* tokens, const lx_i128_t* amounts_x18,
+                              size_t count) {
+    if (!dex || !account || (count > 0 && (!tokens || !amounts_x18))) return LX_ERR_NULL_POINTER;
+    try {
+        std::vector<std::pair<lux::Currency, lux::I128>> deposits;
+        deposits.reserve(count);
+        for (size_t i = 0; i < count; i++) {
+            deposits.emplace_back(to_cpp_currency(&tokens[i]), to_cpp_i128(amounts_x18[i]));
+        }
+        return reinterpret_cast<lux::LX*>(dex)->vault().deposit_multi(to_cpp_account(account), deposits);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 int32_t lxvault_withdraw(lx_t* dex, const lx_account_t* account,
                          const lx_currency_t* token,
                          int64_t amount_hi, uint64_t amount_lo) {
@@ -713,6 +1371,13 @@ int32_t lxvault_transfer(lx_t* dex, const lx_account_t* from, const lx_account_t
     }
 }
 
+void lxvault_set_cross_owner_transfers(lx_t* dex, bool allowed) {
+    if (!dex) return;
+    try {
+        reinterpret_cast<lux::LX*>(dex)->vault().set_cross_owner_transfers(allowed);
+    } catch (...) {}
+}
+
 bool lxvault_get_balance(const lx_t* dex, const lx_account_t* account,
                          const lx_currency_t* token, lx_i128_t* out) {
     if (!dex || !account || !token || !out) return false;
@@ -740,6 +1405,50 @@ lx_margin_info_t lxvault_get_margin(const lx_t* dex, const lx_account_t* account
     }
 }
 
+int32_t lxvault_get_account_pnl(const lx_t* dex, const lx_account_t* account,
+                                lx_account_pnl_t* out) {
+    if (!dex || !account || !out) return LX_ERR_NULL_POINTER;
+    try {
+        auto pnl = reinterpret_cast<const lux::LX*>(dex)->vault().get_account_pnl(
+            to_cpp_account(account));
+        out->realized_pnl_x18 = to_c_i128(pnl.realized_pnl_x18);
+        out->unrealized_pnl_x18 = to_c_i128(pnl.unrealized_pnl_x18);
+        out->fees_paid_x18 = to_c_i128(pnl.fees_paid_x18);
+        out->funding_x18 = to_c_i128(pnl.funding_x18);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_get_buying_power(const lx_t* dex, const lx_account_t* account,
+                                 uint32_t market_id, lx_i128_t* out) {
+    if (!dex || !account || !out) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        auto power = reinterpret_cast<const lux::LX*>(dex)->vault().buying_power(acc, market_id);
+        if (!power) return LX_ERR_MARKET_NOT_FOUND;
+        *out = to_c_i128(*power);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_get_liquidation_price(const lx_t* dex, const lx_account_t* account,
+                                      uint32_t market_id, lx_i128_t* out) {
+    if (!dex || !account || !out) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        auto price = reinterpret_cast<const lux::LX*>(dex)->vault().liquidation_price(acc, market_id);
+        if (!price) return LX_ERR_POSITION_NOT_FOUND;
+        *out = to_c_i128(*price);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 bool lxvault_get_position(const lx_t* dex, const lx_account_t* account,
                           uint32_t market_id, lx_position_t* out) {
     if (!dex || !account || !out) return false;
@@ -754,6 +1463,61 @@ bool lxvault_get_position(const lx_t* dex, const lx_account_t* account,
     }
 }
 
+int32_t lxvault_get_positions(const lx_t* dex, const lx_account_t* account,
+                              lx_position_t** positions, size_t* count) {
+    if (!dex || !account || !positions || !count) return LX_ERR_NULL_POINTER;
+    *positions = nullptr;
+    *count = 0;
+
+    try {
+        auto all = reinterpret_cast<const lux::LX*>(dex)->vault().get_all_positions(
+            to_cpp_account(account));
+        if (all.empty()) return LX_OK;
+        std::sort(all.begin(), all.end(), [](const lux::LXPosition& a, const lux::LXPosition& b) {
+            return a.market_id < b.market_id;
+        });
+
+        auto* out = new lx_position_t[all.size()];
+        for (size_t i = 0; i < all.size(); i++) {
+            out[i] = to_c_position(all[i]);
+        }
+        *positions = out;
+        *count = all.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_positions_free(lx_position_t* positions) {
+    delete[] positions;
+}
+
+int32_t lxvault_transfer_position(lx_t* dex, const lx_account_t* from, const lx_account_t* to,
+                                  uint32_t market_id, lx_i128_t size_x18, lx_i128_t price_x18) {
+    if (!dex || !from || !to) return LX_ERR_NULL_POINTER;
+    try {
+        auto from_acc = to_cpp_account(from);
+        auto to_acc = to_cpp_account(to);
+        return reinterpret_cast<lux::LX*>(dex)->vault().transfer_position(
+            from_acc, to_acc, market_id, to_cpp_i128(size_x18), to_cpp_i128(price_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_check_order_limits(const lx_t* dex, const lx_account_t* account,
+                                   const lx_order_t* order) {
+    if (!dex || !account || !order) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        auto ord = to_cpp_order(order);
+        return reinterpret_cast<const lux::LX*>(dex)->check_order_limits(acc, ord);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 int32_t lxvault_set_margin_mode(lx_t* dex, const lx_account_t* account,
                                 uint32_t market_id, lx_margin_mode_t mode) {
     if (!dex || !account) return LX_ERR_NULL_POINTER;
@@ -766,6 +1530,59 @@ int32_t lxvault_set_margin_mode(lx_t* dex, const lx_account_t* account,
     }
 }
 
+int32_t lxvault_set_leverage(lx_t* dex, const lx_account_t* account,
+                             uint32_t market_id, lx_i128_t leverage_x18) {
+    if (!dex || !account) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        return reinterpret_cast<lux::LX*>(dex)->vault().set_leverage(
+            acc, market_id, to_cpp_i128(leverage_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_set_fee_tier(lx_t* dex, const lx_account_t* account,
+                             lx_i128_t maker_fee_x18, lx_i128_t taker_fee_x18) {
+    if (!dex || !account) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        return reinterpret_cast<lux::LX*>(dex)->vault().set_fee_tier(
+            acc, to_cpp_i128(maker_fee_x18), to_cpp_i128(taker_fee_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_set_staked_balance(lx_t* dex, const lx_account_t* account,
+                                   lx_i128_t amount_x18) {
+    if (!dex || !account) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        return reinterpret_cast<lux::LX*>(dex)->vault().set_staked_balance(
+            acc, to_cpp_i128(amount_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxvault_set_staking_fee_tiers(lx_t* dex, const lx_staking_fee_tier_t* tiers,
+                                      size_t count) {
+    if (!dex || (count > 0 && !tiers)) return LX_ERR_NULL_POINTER;
+    try {
+        std::vector<lux::StakingFeeTier> schedule;
+        schedule.reserve(count);
+        for (size_t i = 0; i < count; i++) {
+            schedule.push_back({to_cpp_i128(tiers[i].min_staked_x18),
+                                to_cpp_i128(tiers[i].taker_discount_x18),
+                                to_cpp_i128(tiers[i].maker_discount_x18)});
+        }
+        return reinterpret_cast<lux::LX*>(dex)->vault().set_staking_fee_tiers(std::move(schedule));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 int32_t lxvault_add_margin(lx_t* dex, const lx_account_t* account,
                            uint32_t market_id, lx_i128_t amount_x18) {
     if (!dex || !account) return LX_ERR_NULL_POINTER;
@@ -800,6 +1617,20 @@ bool lxvault_is_liquidatable(const lx_t* dex, const lx_account_t* account) {
     }
 }
 
+int32_t lxvault_account_has_exposure(const lx_t* dex, const lx_account_t* account,
+                                     bool* has_orders, bool* has_positions) {
+    if (!dex || !account || !has_orders || !has_positions) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        const auto* lx = reinterpret_cast<const lux::LX*>(dex);
+        *has_orders = lx->book().has_open_orders(acc);
+        *has_positions = lx->vault().has_positions(acc);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 lx_liquidation_result_t lxvault_liquidate(lx_t* dex,
                                            const lx_account_t* liquidator,
                                            const lx_account_t* account,
@@ -836,6 +1667,20 @@ int32_t lxvault_accrue_funding(lx_t* dex, uint32_t market_id) {
     }
 }
 
+int32_t lxvault_settle_funding(lx_t* dex, const lx_account_t* account,
+                               uint32_t market_id, lx_i128_t* out) {
+    if (!dex || !account || !out) return LX_ERR_NULL_POINTER;
+    try {
+        auto acc = to_cpp_account(account);
+        auto amount = reinterpret_cast<lux::LX*>(dex)->vault().settle_funding(acc, market_id);
+        if (!amount) return LX_ERR_POSITION_NOT_FOUND;
+        *out = to_c_i128(*amount);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 lx_i128_t lxvault_insurance_balance(const lx_t* dex) {
     lx_i128_t zero = {};
     if (!dex) return zero;
@@ -896,6 +1741,26 @@ int32_t lxoracle_update_price_with_confidence(lx_t* dex, uint64_t asset_id,
     }
 }
 
+int32_t lxoracle_update_prices(lx_t* dex, const lx_oracle_update_t* updates,
+                               size_t count, int32_t* results) {
+    if (!dex || (count > 0 && (!updates || !results))) return LX_ERR_NULL_POINTER;
+    try {
+        std::vector<std::tuple<uint64_t, lux::PriceSource, lux::I128, lux::I128>> batch;
+        batch.reserve(count);
+        for (size_t i = 0; i < count; ++i) {
+            batch.emplace_back(updates[i].asset_id,
+                               static_cast<lux::PriceSource>(updates[i].source),
+                               to_cpp_i128(updates[i].price_x18),
+                               to_cpp_i128(updates[i].confidence_x18));
+        }
+        auto codes = reinterpret_cast<lux::LX*>(dex)->oracle().update_prices(batch);
+        std::copy(codes.begin(), codes.end(), results);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 bool lxoracle_get_price(const lx_t* dex, uint64_t asset_id,
                         int64_t* price_hi, uint64_t* price_lo) {
     if (!dex || !price_hi || !price_lo) return false;
@@ -943,6 +1808,78 @@ bool lxoracle_get_twap(const lx_t* dex, uint64_t asset_id, uint64_t window_secon
     }
 }
 
+int32_t lxoracle_set_circuit_breaker(lx_t* dex, uint64_t asset_id, uint32_t max_move_bps) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->oracle().set_circuit_breaker(asset_id, max_move_bps);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxoracle_set_staleness(lx_t* dex, uint64_t asset_id, uint64_t max_staleness) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->oracle().set_max_staleness(asset_id, max_staleness);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lxoracle_get_staleness(const lx_t* dex, uint64_t asset_id, uint64_t* max_staleness) {
+    if (!dex || !max_staleness) return false;
+    try {
+        auto config = reinterpret_cast<const lux::LX*>(dex)->oracle().get_config(asset_id);
+        if (!config) return false;
+        *max_staleness = config->max_staleness;
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+int32_t lxoracle_set_aggregation(lx_t* dex, uint64_t asset_id, lx_aggregation_t method) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->oracle().set_aggregation_method(
+            asset_id, static_cast<lux::AggregationMethod>(method));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lxoracle_get_aggregation(const lx_t* dex, uint64_t asset_id, lx_aggregation_t* method) {
+    if (!dex || !method) return false;
+    try {
+        auto config = reinterpret_cast<const lux::LX*>(dex)->oracle().get_config(asset_id);
+        if (!config) return false;
+        *method = static_cast<lx_aggregation_t>(config->method);
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+int32_t lxoracle_remove_source(lx_t* dex, uint64_t asset_id, lx_price_source_t source) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->oracle().remove_source(
+            asset_id, static_cast<lux::PriceSource>(source));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxoracle_restore_source(lx_t* dex, uint64_t asset_id, lx_price_source_t source) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->oracle().restore_source(
+            asset_id, static_cast<lux::PriceSource>(source));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 bool lxoracle_is_price_fresh(const lx_t* dex, uint64_t asset_id) {
     if (!dex) return false;
     return reinterpret_cast<const lux::LX*>(dex)->oracle().is_price_fresh(asset_id);
@@ -966,6 +1903,17 @@ int32_t lxfeed_register_market(lx_t* dex, uint32_t market_id, uint64_t asset_id)
     }
 }
 
+int32_t lxfeed_set_mark_config(lx_t* dex, uint32_t market_id, uint64_t ewma_window,
+                               uint32_t premium_clamp_bps) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->feed().set_mark_smoothing(
+            market_id, ewma_window, premium_clamp_bps);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 lx_mark_price_t lxfeed_get_mark_price(const lx_t* dex, uint32_t market_id) {
     lx_mark_price_t zero = {};
     if (!dex) return zero;
@@ -1079,6 +2027,63 @@ void lxfeed_calculate_funding(lx_t* dex, uint32_t market_id) {
     } catch (...) {}
 }
 
+bool lxfeed_get_funding_config(const lx_t* dex, uint32_t market_id, lx_funding_config_t* out) {
+    if (!dex || !out) return false;
+    try {
+        const auto& feed = reinterpret_cast<const lux::LX*>(dex)->feed();
+        auto funding = feed.get_funding_rate(market_id);
+        if (!funding) return false;
+        out->interval_secs = feed.funding_interval(market_id);
+        out->clamp_bps = static_cast<uint32_t>(
+            feed.max_funding_rate(market_id) * 10000 / lux::X18_ONE);
+        out->next_funding_time = funding->next_funding_time;
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+int32_t lxfeed_set_funding_config(lx_t* dex, uint32_t market_id, uint64_t interval_secs,
+                                  uint32_t clamp_bps) {
+    if (!dex) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->feed().set_funding_config(
+            market_id, interval_secs, clamp_bps);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxfeed_get_funding_history(const lx_t* dex, uint32_t market_id, size_t limit,
+                                   lx_funding_sample_t** samples, size_t* count) {
+    if (!dex || !samples || !count) return LX_ERR_NULL_POINTER;
+    *samples = nullptr;
+    *count = 0;
+
+    try {
+        const auto& feed = reinterpret_cast<const lux::LX*>(dex)->feed();
+        if (!feed.market_exists(market_id)) return LX_ERR_MARKET_NOT_FOUND;
+
+        auto history = feed.funding_history(market_id, limit);
+        if (history.empty()) return LX_OK;
+
+        auto* out = new lx_funding_sample_t[history.size()];
+        for (size_t i = 0; i < history.size(); i++) {
+            out[i].rate_x18 = to_c_i128(history[i].second);
+            out[i].timestamp = history[i].first;
+        }
+        *samples = out;
+        *count = history.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_funding_samples_free(lx_funding_sample_t* samples) {
+    delete[] samples;
+}
+
 bool lxfeed_get_premium(const lx_t* dex, uint32_t market_id,
                         int64_t* premium_hi, uint64_t* premium_lo) {
     if (!dex || !premium_hi || !premium_lo) return false;
@@ -1109,6 +2114,126 @@ bool lxfeed_get_basis(const lx_t* dex, uint32_t market_id,
     }
 }
 
+bool lxfeed_get_all_prices(const lx_t* dex, uint32_t market_id, lx_feed_prices_t* out) {
+    if (!dex || !out) return false;
+    try {
+        auto prices = reinterpret_cast<const lux::LX*>(dex)->feed().get_all_prices(market_id);
+        if (!prices) return false;
+        out->index_px_x18 = to_c_i128(prices->index_x18);
+        out->mark_px_x18 = to_c_i128(prices->mark_x18);
+        out->last_px_x18 = to_c_i128(prices->last_x18);
+        out->mid_px_x18 = to_c_i128(prices->mid_x18);
+        out->premium_x18 = to_c_i128(prices->premium_x18);
+        out->funding_rate_x18 = to_c_i128(prices->funding_rate_x18);
+        out->next_funding_time = prices->next_funding_time;
+        out->timestamp = prices->timestamp;
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
+/* =============================================================================
+ * LXLend API (LP-9050)
+ * ============================================================================= */
+
+int32_t lxlend_supply(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* token, lx_i128_t amount_x18) {
+    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->lend().supply(
+            to_cpp_account(account), to_cpp_currency(token), to_cpp_i128(amount_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxlend_withdraw(lx_t* dex, const lx_account_t* account,
+                        const lx_currency_t* token, lx_i128_t amount_x18) {
+    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->lend().withdraw(
+            to_cpp_account(account), to_cpp_currency(token), to_cpp_i128(amount_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxlend_borrow(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* token, lx_i128_t amount_x18) {
+    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->lend().borrow(
+            to_cpp_account(account), to_cpp_currency(token), to_cpp_i128(amount_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxlend_repay(lx_t* dex, const lx_account_t* account,
+                     const lx_currency_t* token, lx_i128_t amount_x18) {
+    if (!dex || !account || !token) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->lend().repay(
+            to_cpp_account(account), to_cpp_currency(token), to_cpp_i128(amount_x18));
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+int32_t lxlend_get_account_liquidity(const lx_t* dex, const lx_account_t* account,
+                                     lx_i128_t* collateral_value_x18,
+                                     lx_i128_t* borrow_value_x18) {
+    if (!dex || !account || !collateral_value_x18 || !borrow_value_x18) {
+        return LX_ERR_NULL_POINTER;
+    }
+    try {
+        auto liquidity = reinterpret_cast<const lux::LX*>(dex)->lend().get_account_liquidity(
+            to_cpp_account(account));
+        *collateral_value_x18 = to_c_i128(liquidity.collateral_value_x18);
+        *borrow_value_x18 = to_c_i128(liquidity.borrow_value_x18);
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+/* =============================================================================
+ * LXLiquid API (LP-9060)
+ * ============================================================================= */
+
+int32_t lxliquid_open(lx_t* dex, const lx_account_t* account,
+                      const lx_currency_t* collateral, lx_i128_t amount_x18,
+                      uint64_t* loan_id) {
+    if (!dex || !account || !collateral || !loan_id) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->liquid().open(
+            to_cpp_account(account), to_cpp_currency(collateral), to_cpp_i128(amount_x18),
+            *loan_id);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+bool lxliquid_get_loan(const lx_t* dex, uint64_t loan_id, lx_liquid_loan_t* loan) {
+    if (!dex || !loan) return false;
+    try {
+        auto l = reinterpret_cast<const lux::LX*>(dex)->liquid().get_loan(loan_id);
+        if (!l) return false;
+        loan->id = l->id;
+        loan->owner = to_c_account(l->owner);
+        loan->collateral = to_c_currency(l->collateral);
+        loan->collateral_x18 = to_c_i128(l->collateral_x18);
+        loan->principal_x18 = to_c_i128(l->principal_x18);
+        loan->yield_applied_x18 = to_c_i128(l->yield_applied_x18);
+        loan->health_x18 = to_c_i128(l->health_x18);
+        loan->opened_at = l->opened_at;
+        return true;
+    } catch (...) {
+        return false;
+    }
+}
+
 /* =============================================================================
  * Unified Trading Interface
  * ============================================================================= */
@@ -1175,6 +2300,65 @@ int32_t lx_run_liquidations(lx_t* dex, uint32_t market_id) {
     }
 }
 
+/* =============================================================================
+ * Snapshot & Restore
+ * ============================================================================= */
+
+int32_t lx_snapshot(const lx_t* dex, uint8_t** data, size_t* len) {
+    if (!dex || !data || !len) return LX_ERR_NULL_POINTER;
+    *data = nullptr;
+    *len = 0;
+    try {
+        auto snapshot = reinterpret_cast<const lux::LX*>(dex)->snapshot();
+        *data = new uint8_t[snapshot.size()];
+        std::memcpy(*data, snapshot.data(), snapshot.size());
+        *len = snapshot.size();
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+void lx_snapshot_free(uint8_t* data) {
+    delete[] data;
+}
+
+int32_t lx_restore(lx_t* dex, const uint8_t* data, size_t len) {
+    if (!dex || (len > 0 && !data)) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->restore(data, len);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
+/* =============================================================================
+ * Journal
+ * ============================================================================= */
+
+void lx_set_journal_callback(lx_t* dex, lx_journal_callback_t callback, void* user_data) {
+    if (!dex) return;
+    try {
+        auto* lx = reinterpret_cast<lux::LX*>(dex);
+        if (!callback) {
+            lx->set_journal_callback(nullptr);
+            return;
+        }
+        lx->set_journal_callback([callback, user_data](const std::vector<uint8_t>& record) {
+            callback(user_data, record.data(), record.size());
+        });
+    } catch (...) {}
+}
+
+int32_t lx_journal_apply(lx_t* dex, const uint8_t* record, size_t len) {
+    if (!dex || (len > 0 && !record)) return LX_ERR_NULL_POINTER;
+    try {
+        return reinterpret_cast<lux::LX*>(dex)->journal_apply(record, len);
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 /* =============================================================================
  * Statistics
  * ============================================================================= */
@@ -1217,6 +2401,23 @@ lx_global_stats_t lx_get_stats(const lx_t* dex) {
     }
 }
 
+lx_health_t lx_health(const lx_t* dex) {
+    lx_health_t zero = {};
+    if (!dex) return zero;
+
+    try {
+        auto h = reinterpret_cast<const lux::LX*>(dex)->health();
+        lx_health_t r;
+        r.running = h.running;
+        r.oldest_stale_asset = h.oldest_stale_asset;
+        r.stale_asset_count = h.stale_asset_count;
+        r.open_orders = h.open_orders;
+        return r;
+    } catch (...) {
+        return zero;
+    }
+}
+
 lx_pool_stats_t lxpool_get_stats(const lx_t* dex) {
     lx_pool_stats_t zero = {};
     if (!dex) return zero;
@@ -1251,6 +2452,24 @@ lx_book_stats_t lxbook_get_stats(const lx_t* dex) {
     }
 }
 
+int32_t lxbook_get_market_stats(const lx_t* dex, uint32_t market_id,
+                                lx_book_market_stats_t* stats) {
+    if (!dex || !stats) return LX_ERR_NULL_POINTER;
+
+    try {
+        auto found = reinterpret_cast<const lux::LX*>(dex)->book().get_market_stats(market_id);
+        if (!found) return LX_ERR_MARKET_NOT_FOUND;
+        stats->orders_placed = found->orders_placed;
+        stats->orders_cancelled = found->orders_cancelled;
+        stats->trades = found->trades;
+        stats->volume_x18 = to_c_i128(found->volume_x18);
+        stats->open_orders = found->open_orders;
+        return LX_OK;
+    } catch (...) {
+        return LX_ERR_INTERNAL;
+    }
+}
+
 lx_vault_stats_t lxvault_get_stats(const lx_t* dex) {
     lx_vault_stats_t zero = {};
     if (!dex) return zero;
@@ -0,0 +1,170 @@
+package luxdex
+
+/*
+#include "luxdex_c.h"
+
+extern void luxGoOnTrade(void* user_data, LuxTrade* trade);
+extern void luxGoOnOrderFilled(void* user_data, LuxOrder* order);
+extern void luxGoOnOrderPartiallyFilled(void* user_data, LuxOrder* order, LuxQuantity fill_qty);
+extern void luxGoOnOrderCancelled(void* user_data, LuxOrder* order);
+extern void luxGoOnOrderReduced(void* user_data, LuxOrder* order, LuxQuantity reduced_qty);
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// The C engine reports every trade, fill and cancellation through
+// callbacks, including fills of resting orders caused by other accounts.
+// They fire while the engine lock is held, so each engine's notifications
+// are queued here under its C handle, which C passes back as the callbacks'
+// user data, and handed to the TradeListener once the lock is released.
+var (
+	notifyQueuesMu sync.RWMutex
+	notifyQueues   = make(map[C.LuxEngine]*notifyQueue)
+)
+
+type notificationKind uint8
+
+const (
+	notifyTrade notificationKind = iota
+	notifyFilled
+	notifyPartiallyFilled
+	notifyCancelled
+	// notifyReduced is an STP decrement of a resting order. It is recorded
+	// in the event log but has no TradeListener method.
+	notifyReduced
+)
+
+// notification is one TradeListener call captured during an engine call.
+type notification struct {
+	kind    notificationKind
+	trade   Trade
+	order   Order
+	fillQty Quantity
+}
+
+type notifyQueue struct {
+	mu      sync.Mutex
+	pending []notification
+}
+
+func (q *notifyQueue) push(n notification) {
+	q.mu.Lock()
+	q.pending = append(q.pending, n)
+	q.mu.Unlock()
+}
+
+// mark returns a position that since can later read from.
+func (q *notifyQueue) mark() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// since returns what was queued after mark, leaving it queued.
+func (q *notifyQueue) since(mark int) []notification {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if mark >= len(q.pending) {
+		return nil
+	}
+	return q.pending[mark:len(q.pending):len(q.pending)]
+}
+
+// take removes and returns everything queued so far.
+func (q *notifyQueue) take() []notification {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := q.pending
+	q.pending = nil
+	return pending
+}
+
+// registerCallbacks routes the engine's C callbacks into a new queue.
+func registerCallbacks(handle C.LuxEngine) *notifyQueue {
+	q := &notifyQueue{}
+	notifyQueuesMu.Lock()
+	notifyQueues[handle] = q
+	notifyQueuesMu.Unlock()
+
+	callbacks := C.LuxEngineCallbacks{
+		on_trade:                  (*[0]byte)(C.luxGoOnTrade),
+		on_order_filled:           (*[0]byte)(C.luxGoOnOrderFilled),
+		on_order_partially_filled: (*[0]byte)(C.luxGoOnOrderPartiallyFilled),
+		on_order_cancelled:        (*[0]byte)(C.luxGoOnOrderCancelled),
+		on_order_reduced:          (*[0]byte)(C.luxGoOnOrderReduced),
+	}
+	C.lux_engine_set_callbacks(handle, &callbacks, unsafe.Pointer(handle))
+	return q
+}
+
+func unregisterCallbacks(handle C.LuxEngine) {
+	notifyQueuesMu.Lock()
+	delete(notifyQueues, handle)
+	notifyQueuesMu.Unlock()
+}
+
+func pushNotification(userData unsafe.Pointer, n notification) {
+	notifyQueuesMu.RLock()
+	q := notifyQueues[C.LuxEngine(userData)]
+	notifyQueuesMu.RUnlock()
+	if q != nil {
+		q.push(n)
+	}
+}
+
+// dispatch delivers queued notifications in the order the engine raised
+// them. It must be called without the engine lock held, so the listener
+// may call back into the engine.
+func dispatch(listener TradeListener, pending []notification) {
+	if listener == nil {
+		return
+	}
+	for _, n := range pending {
+		switch n.kind {
+		case notifyTrade:
+			listener.OnTrade(n.trade)
+		case notifyFilled:
+			listener.OnOrderFilled(n.order)
+		case notifyPartiallyFilled:
+			listener.OnOrderPartiallyFilled(n.order, n.fillQty)
+		case notifyCancelled:
+			listener.OnOrderCancelled(n.order)
+		}
+	}
+}
+
+//export luxGoOnTrade
+func luxGoOnTrade(userData unsafe.Pointer, trade *C.LuxTrade) {
+	pushNotification(userData, notification{kind: notifyTrade, trade: tradeFromC(*trade)})
+}
+
+//export luxGoOnOrderFilled
+func luxGoOnOrderFilled(userData unsafe.Pointer, order *C.LuxOrder) {
+	pushNotification(userData, notification{kind: notifyFilled, order: orderFromC(*order)})
+}
+
+//export luxGoOnOrderPartiallyFilled
+func luxGoOnOrderPartiallyFilled(userData unsafe.Pointer, order *C.LuxOrder, fillQty C.LuxQuantity) {
+	pushNotification(userData, notification{
+		kind:    notifyPartiallyFilled,
+		order:   orderFromC(*order),
+		fillQty: Quantity(fillQty),
+	})
+}
+
+//export luxGoOnOrderCancelled
+func luxGoOnOrderCancelled(userData unsafe.Pointer, order *C.LuxOrder) {
+	pushNotification(userData, notification{kind: notifyCancelled, order: orderFromC(*order)})
+}
+
+//export luxGoOnOrderReduced
+func luxGoOnOrderReduced(userData unsafe.Pointer, order *C.LuxOrder, reducedQty C.LuxQuantity) {
+	pushNotification(userData, notification{
+		kind:    notifyReduced,
+		order:   orderFromC(*order),
+		fillQty: Quantity(reducedQty),
+	})
+}
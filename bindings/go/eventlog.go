@@ -0,0 +1,98 @@
+package luxdex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrEventLogDisabled is returned when the engine was created without
+// EngineConfig.EventLog.
+var ErrEventLogDisabled = errors.New("event log disabled")
+
+// EventType identifies the kind of a logged order book event
+type EventType string
+
+const (
+	EventOrderPlaced    EventType = "order_placed"
+	EventTrade          EventType = "trade"
+	EventOrderCancelled EventType = "order_cancelled"
+	// EventOrderReduced is a resting order shrunk by self-trade prevention
+	// in decrement mode; Order carries its reduced quantity.
+	EventOrderReduced EventType = "order_reduced"
+)
+
+// Event is a single entry in a symbol's event log. Exactly one of Order or
+// Trade is set, according to Type. Seq numbers are per symbol, start at 1
+// and have no gaps, so a consumer can detect missed entries.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Type     EventType `json:"type"`
+	SymbolID uint64    `json:"symbol_id"`
+	Order    *Order    `json:"order,omitempty"`
+	Trade    *Trade    `json:"trade,omitempty"`
+}
+
+// eventLog is an in-memory, append-only journal of events per symbol.
+type eventLog struct {
+	mu      sync.Mutex
+	symbols map[uint64][]Event
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{symbols: make(map[uint64][]Event)}
+}
+
+func (l *eventLog) appendOrder(typ EventType, order Order) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.append(Event{Type: typ, SymbolID: order.SymbolID, Order: &order})
+}
+
+// appendPlaced logs a placed order followed by the trades, cancellations
+// and STP reductions its placement raised, in the order the engine raised
+// them.
+func (l *eventLog) appendPlaced(order Order, raised []notification) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.append(Event{Type: EventOrderPlaced, SymbolID: order.SymbolID, Order: &order})
+	for _, n := range raised {
+		switch n.kind {
+		case notifyTrade:
+			trade := n.trade
+			l.append(Event{Type: EventTrade, SymbolID: trade.SymbolID, Trade: &trade})
+		case notifyCancelled:
+			cancelled := n.order
+			l.append(Event{Type: EventOrderCancelled, SymbolID: cancelled.SymbolID, Order: &cancelled})
+		case notifyReduced:
+			reduced := n.order
+			l.append(Event{Type: EventOrderReduced, SymbolID: reduced.SymbolID, Order: &reduced})
+		}
+	}
+}
+
+// append must be called with l.mu held
+func (l *eventLog) append(ev Event) {
+	events := l.symbols[ev.SymbolID]
+	ev.Seq = uint64(len(events)) + 1
+	l.symbols[ev.SymbolID] = append(events, ev)
+}
+
+// reader returns the symbol's events as newline-delimited JSON, one Event
+// per line, in sequence order.
+func (l *eventLog) reader(symbolID uint64) (io.Reader, error) {
+	l.mu.Lock()
+	events := l.symbols[symbolID]
+	l.mu.Unlock()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &buf, nil
+}
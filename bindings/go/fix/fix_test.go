@@ -0,0 +1,198 @@
+package fix
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := NewMessage(MsgTypeNewOrderSingle).
+		Set(TagClOrdID, "c1").
+		Set(TagSymbol, "1").
+		Set(TagSide, "1")
+	raw := msg.Bytes()
+	if !bytes.HasPrefix(raw, []byte("8=FIX.4.4\x019=")) || !bytes.Contains(raw, []byte("\x0110=")) {
+		t.Fatalf("Bytes() = %q", raw)
+	}
+
+	parsed, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, msg) {
+		t.Errorf("Parse(Bytes()) = %v, want %v", parsed, msg)
+	}
+
+	tampered := bytes.Replace(raw, []byte("11=c1"), []byte("11=c2"), 1)
+	if _, err := Parse(tampered); err == nil || !strings.Contains(err.Error(), "CheckSum") {
+		t.Errorf("Parse(tampered) error = %v, want CheckSum mismatch", err)
+	}
+	truncated := bytes.Replace(raw, []byte("\x0154=1"), nil, 1)
+	if _, err := Parse(truncated); err == nil || !strings.Contains(err.Error(), "BodyLength") {
+		t.Errorf("Parse(truncated) error = %v, want BodyLength mismatch", err)
+	}
+	if _, err := Parse([]byte("8=FIX.4.2\x019=5\x0135=D\x0110=000\x01")); err == nil {
+		t.Error("Parse accepted FIX.4.2")
+	}
+}
+
+func TestOrderFIXMapping(t *testing.T) {
+	expire := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	base := func() *luxdex.OrderBuilder {
+		return luxdex.NewOrder().Symbol(7).Account(42).Qty(1.5)
+	}
+	tests := []struct {
+		name  string
+		order luxdex.Order
+		tags  map[int]string
+	}{
+		{"limit buy GTC", base().Buy().Limit(101.25).Build(),
+			map[int]string{TagSide: "1", TagOrdType: "2", TagPrice: "101.25", TagTimeInForce: "1"}},
+		{"market sell IOC", base().Sell().Market().TimeInForce(luxdex.TifIOC).Build(),
+			map[int]string{TagSide: "2", TagOrdType: "1", TagTimeInForce: "3"}},
+		{"limit FOK", base().Buy().Limit(100).TimeInForce(luxdex.TifFOK).Build(),
+			map[int]string{TagTimeInForce: "4"}},
+		{"limit DAY", base().Buy().Limit(100).TimeInForce(luxdex.TifDAY).Build(),
+			map[int]string{TagTimeInForce: "0"}},
+		{"limit GTD", base().Buy().Limit(100).ExpireAt(expire).Build(),
+			map[int]string{TagTimeInForce: "6", TagExpireTime: "20260301-12:30:00.000"}},
+		{"post-only", base().Sell().Limit(100).TimeInForce(luxdex.TifPostOnly).Build(),
+			map[int]string{TagTimeInForce: "1", TagExecInst: "6"}},
+		{"iceberg", base().Sell().Limit(100).Iceberg(0.5).Build(),
+			map[int]string{TagMaxFloor: "0.5"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := Parse(NewOrderSingle("c1", tc.order).Bytes())
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			for tag, want := range tc.tags {
+				if got, _ := msg.Get(tag); got != want {
+					t.Errorf("tag %d = %q, want %q", tag, got, want)
+				}
+			}
+
+			got, err := OrderFromFIX(msg)
+			if err != nil {
+				t.Fatalf("OrderFromFIX: %v", err)
+			}
+			want := tc.order
+			want.ID, want.Timestamp = 0, time.Time{}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("OrderFromFIX =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+
+	if _, err := OrderFromFIX(NewMessage(MsgTypeNewOrderSingle).Set(TagSymbol, "1").Set(TagSide, "1")); err == nil {
+		t.Error("OrderFromFIX accepted an order without OrderQty or OrdType")
+	}
+}
+
+// report is the part of an outgoing message the translator tests check.
+type report struct {
+	MsgType, ClOrdID, ExecType, OrdStatus, CumQty, LeavesQty string
+}
+
+func summarize(m *Message) report {
+	get := func(tag int) string { v, _ := m.Get(tag); return v }
+	return report{m.MsgType(), get(TagClOrdID), get(TagExecType), get(TagOrdStatus), get(TagCumQty), get(TagLeavesQty)}
+}
+
+func newTestTranslator(t *testing.T) (*Translator, *[]*Message) {
+	t.Helper()
+	e, err := luxdex.NewCGOEngine()
+	if err != nil {
+		t.Fatalf("NewCGOEngine() failed: %v", err)
+	}
+	t.Cleanup(e.Close)
+	e.Start()
+	t.Cleanup(e.Stop)
+	e.AddSymbol(1)
+
+	var sent []*Message
+	return NewTranslator(e, func(m *Message) {
+		// Everything sent must survive the wire
+		parsed, err := Parse(m.Bytes())
+		if err != nil {
+			t.Errorf("unparseable outgoing message %v: %v", m, err)
+			return
+		}
+		sent = append(sent, parsed)
+	}), &sent
+}
+
+func TestTranslator(t *testing.T) {
+	tr, sent := newTestTranslator(t)
+	expect := func(step string, want ...report) {
+		t.Helper()
+		var got []report
+		for _, m := range *sent {
+			got = append(got, summarize(m))
+		}
+		*sent = nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: reports =\n%+v\nwant\n%+v", step, got, want)
+		}
+	}
+	order := func(b *luxdex.OrderBuilder) luxdex.Order { return b.Symbol(1).Account(1).Build() }
+
+	ask := order(luxdex.NewOrder().Sell().Limit(100).Qty(5))
+	if err := tr.Handle(NewOrderSingle("A1", ask)); err != nil {
+		t.Fatalf("Handle(ask): %v", err)
+	}
+	expect("ask", report{"8", "A1", "0", "0", "0", "5"})
+
+	// The resting ask fills during placement; the bid's own fill follows its ack
+	bid := order(luxdex.NewOrder().Buy().Limit(100).Qty(2))
+	tr.Handle(NewOrderSingle("B1", bid))
+	expect("crossing bid",
+		report{"8", "A1", "F", "1", "2", "3"},
+		report{"8", "B1", "0", "0", "0", "2"},
+		report{"8", "B1", "F", "2", "2", "0"})
+
+	tr.Handle(OrderCancelRequest("A2", "A1", ask))
+	expect("cancel", report{"8", "A2", "4", "4", "2", "0"})
+
+	if err := tr.Handle(OrderCancelRequest("A3", "A1", ask)); err == nil {
+		t.Error("cancelling a finished order returned no error")
+	}
+	expect("cancel again", report{"9", "A3", "", "8", "", ""})
+
+	// Unfilled IOC remainders are cancelled
+	tr.Handle(NewOrderSingle("I1", order(luxdex.NewOrder().Buy().Limit(100).Qty(1).TimeInForce(luxdex.TifIOC))))
+	expect("IOC", report{"8", "I1", "0", "0", "0", "1"}, report{"8", "I1", "4", "4", "0", "0"})
+
+	bad := NewMessage(MsgTypeNewOrderSingle).Set(TagClOrdID, "X1").Set(TagSymbol, "1").Set(TagSide, "1").Set(TagOrdType, "2")
+	if err := tr.Handle(bad); err == nil {
+		t.Error("invalid order returned no error")
+	}
+	expect("invalid", report{"8", "X1", "8", "8", "0", "0"})
+
+	if err := tr.Handle(NewMessage("0")); err == nil {
+		t.Error("Heartbeat was handled")
+	}
+}
+
+func TestTranslatorFillPrices(t *testing.T) {
+	tr, sent := newTestTranslator(t)
+	tr.Handle(NewOrderSingle("A1", luxdex.NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(1).Build()))
+	tr.Handle(NewOrderSingle("A2", luxdex.NewOrder().Symbol(1).Account(1).Sell().Limit(102).Qty(1).Build()))
+	*sent = nil
+
+	tr.Handle(NewOrderSingle("B1", luxdex.NewOrder().Symbol(1).Account(2).Buy().Limit(102).Qty(2).Build()))
+	last := (*sent)[len(*sent)-1]
+	for tag, want := range map[int]string{
+		TagClOrdID: "B1", TagOrdStatus: "2", TagLastPx: "102", TagLastQty: "1", TagAvgPx: "101", TagCumQty: "2",
+	} {
+		if got, _ := last.Get(tag); got != want {
+			t.Errorf("final fill tag %d = %q, want %q", tag, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package fix translates FIX 4.4 order entry messages into luxdex Engine
+// calls and reports the results back as ExecutionReports.
+//
+// It is a message translator, not a FIX engine: there is no session layer
+// (logon, heartbeats, sequence numbers, resend). A session library or the
+// caller's transport frames messages and hands them to Translator.Handle.
+package fix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// BeginString is the FIX version written on every outgoing message.
+const BeginString = "FIX.4.4"
+
+// soh separates fields in a FIX message.
+const soh = '\x01'
+
+// FIX tags used by the translator.
+const (
+	TagAccount      = 1
+	TagAvgPx        = 6
+	TagBeginString  = 8
+	TagBodyLength   = 9
+	TagCheckSum     = 10
+	TagClOrdID      = 11
+	TagCumQty       = 14
+	TagExecID       = 17
+	TagExecInst     = 18
+	TagLastPx       = 31
+	TagLastQty      = 32
+	TagMsgType      = 35
+	TagOrderID      = 37
+	TagOrderQty     = 38
+	TagOrdStatus    = 39
+	TagOrdType      = 40
+	TagOrigClOrdID  = 41
+	TagPrice        = 44
+	TagSide         = 54
+	TagSymbol       = 55
+	TagText         = 58
+	TagTimeInForce  = 59
+	TagTransactTime = 60
+	TagStopPx       = 99
+	TagCxlRejReason = 102
+	TagMaxFloor     = 111
+	TagExpireTime   = 126
+	TagExecType     = 150
+	TagLeavesQty    = 151
+	TagCxlRejRespTo = 434
+)
+
+// Message types handled or produced by the translator.
+const (
+	MsgTypeExecutionReport    = "8"
+	MsgTypeOrderCancelReject  = "9"
+	MsgTypeNewOrderSingle     = "D"
+	MsgTypeOrderCancelRequest = "F"
+)
+
+// Field is a single tag=value pair.
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message body: every field except BeginString, BodyLength
+// and CheckSum, which Bytes adds and Parse checks and strips.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage returns a message of the given MsgType.
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{TagMsgType, msgType}}}
+}
+
+// MsgType returns the message's MsgType (35).
+func (m *Message) MsgType() string {
+	v, _ := m.Get(TagMsgType)
+	return v
+}
+
+// Get returns the value of the first field with tag.
+func (m *Message) Get(tag int) (string, bool) {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value of tag, or appends the field if it is absent.
+func (m *Message) Set(tag int, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{tag, value})
+	return m
+}
+
+// Bytes encodes the message with its header and trailer.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	for _, f := range m.Fields {
+		writeField(&body, f.Tag, f.Value)
+	}
+	var out bytes.Buffer
+	writeField(&out, TagBeginString, BeginString)
+	writeField(&out, TagBodyLength, strconv.Itoa(body.Len()))
+	out.Write(body.Bytes())
+	writeField(&out, TagCheckSum, fmt.Sprintf("%03d", checksum(out.Bytes())))
+	return out.Bytes()
+}
+
+// String renders the message with '|' in place of SOH, for logs.
+func (m *Message) String() string {
+	return string(bytes.ReplaceAll(m.Bytes(), []byte{soh}, []byte{'|'}))
+}
+
+// Parse decodes one complete FIX message, verifying BeginString, BodyLength
+// and CheckSum.
+func Parse(raw []byte) (*Message, error) {
+	var (
+		fields  []Field
+		offsets []int // start of each field in raw
+		pos     int
+	)
+	for pos < len(raw) {
+		end := bytes.IndexByte(raw[pos:], soh)
+		if end < 0 {
+			return nil, errors.New("fix: field not terminated by SOH")
+		}
+		field := raw[pos : pos+end]
+		eq := bytes.IndexByte(field, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("fix: malformed field %q", field)
+		}
+		tag, err := strconv.Atoi(string(field[:eq]))
+		if err != nil {
+			return nil, fmt.Errorf("fix: bad tag %q", field[:eq])
+		}
+		fields = append(fields, Field{tag, string(field[eq+1:])})
+		offsets = append(offsets, pos)
+		pos += end + 1
+	}
+
+	n := len(fields)
+	if n < 4 || fields[0].Tag != TagBeginString || fields[1].Tag != TagBodyLength || fields[n-1].Tag != TagCheckSum {
+		return nil, errors.New("fix: missing BeginString, BodyLength or CheckSum")
+	}
+	if fields[0].Value != BeginString {
+		return nil, fmt.Errorf("fix: unsupported BeginString %q", fields[0].Value)
+	}
+	if fields[2].Tag != TagMsgType {
+		return nil, errors.New("fix: MsgType must follow BodyLength")
+	}
+	if want := strconv.Itoa(offsets[n-1] - offsets[2]); fields[1].Value != want {
+		return nil, fmt.Errorf("fix: BodyLength %s, message has %s", fields[1].Value, want)
+	}
+	if want := fmt.Sprintf("%03d", checksum(raw[:offsets[n-1]])); fields[n-1].Value != want {
+		return nil, fmt.Errorf("fix: CheckSum %s, computed %s", fields[n-1].Value, want)
+	}
+	return &Message{Fields: fields[2 : n-1]}, nil
+}
+
+func writeField(b *bytes.Buffer, tag int, value string) {
+	b.WriteString(strconv.Itoa(tag))
+	b.WriteByte('=')
+	b.WriteString(value)
+	b.WriteByte(soh)
+}
+
+// checksum is the byte sum modulo 256 of everything before the CheckSum field.
+func checksum(b []byte) int {
+	var sum int
+	for _, c := range b {
+		sum += int(c)
+	}
+	return sum % 256
+}
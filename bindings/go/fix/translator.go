@@ -0,0 +1,436 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+// timeFormat is the FIX UTCTimestamp format with milliseconds.
+const timeFormat = "20060102-15:04:05.000"
+
+// ErrUnsupportedMsgType is returned by Handle for messages other than
+// NewOrderSingle and OrderCancelRequest.
+var ErrUnsupportedMsgType = errors.New("fix: unsupported MsgType")
+
+// Side (54)
+var sidesToFIX = map[luxdex.Side]string{
+	luxdex.SideBuy:  "1",
+	luxdex.SideSell: "2",
+}
+
+// OrdType (40)
+var orderTypesToFIX = map[luxdex.OrderType]string{
+	luxdex.OrderTypeMarket:    "1",
+	luxdex.OrderTypeLimit:     "2",
+	luxdex.OrderTypeStop:      "3",
+	luxdex.OrderTypeStopLimit: "4",
+}
+
+// TimeInForce (59). Post-only is not a FIX time in force; it is sent as
+// ExecInst (18) 6, Participate Don't Initiate, and rests like GTC.
+var tifsToFIX = map[luxdex.TimeInForce]string{
+	luxdex.TifDAY: "0",
+	luxdex.TifGTC: "1",
+	luxdex.TifIOC: "3",
+	luxdex.TifFOK: "4",
+	luxdex.TifGTD: "6",
+}
+
+// execInstPostOnly is ExecInst Participate Don't Initiate.
+const execInstPostOnly = "6"
+
+// ExecType (150) and OrdStatus (39) values.
+const (
+	execNew      = "0"
+	execPartial  = "1"
+	execFilled   = "2"
+	execCanceled = "4"
+	execRejected = "8"
+	execExpired  = "C"
+	execTrade    = "F"
+)
+
+// Translator turns FIX order entry messages into Engine calls and sends an
+// ExecutionReport for every acknowledgement, rejection, fill and cancel of
+// the orders it placed. Symbol (55) and Account (1) carry the engine's
+// numeric symbol and account IDs; prices and quantities are decimals.
+type Translator struct {
+	engine luxdex.Engine
+
+	mu        sync.Mutex
+	send      func(*Message)
+	orders    map[uint64]*orderState // by engine order ID
+	byClOrdID map[string]uint64
+	execID    uint64
+}
+
+// orderState tracks an order placed through the translator until it is done.
+type orderState struct {
+	order    luxdex.Order
+	clOrdID  string
+	cum      luxdex.Quantity
+	notional float64 // sum of price * quantity over fills, for AvgPx
+
+	// Set while an OrderCancelRequest is in flight
+	cancelClOrdID string
+
+	// While the order is being placed its acknowledgement is prepared and
+	// its fills are queued, so they are reported after it
+	pending bool
+	queued  []*Message
+}
+
+// NewTranslator returns a translator for e that passes outgoing messages to
+// send. send is called with the translator's lock held, in report order, and
+// must not call back into the translator. The translator installs itself as
+// e's trade listener, replacing any listener already set.
+func NewTranslator(e luxdex.Engine, send func(*Message)) *Translator {
+	t := &Translator{
+		engine:    e,
+		send:      send,
+		orders:    make(map[uint64]*orderState),
+		byClOrdID: make(map[string]uint64),
+	}
+	e.SetTradeListener(t)
+	return t
+}
+
+// Handle processes one inbound application message. Invalid orders are
+// rejected with an ExecutionReport and unknown cancels with an
+// OrderCancelReject; the returned error is informational.
+func (t *Translator) Handle(msg *Message) error {
+	switch msg.MsgType() {
+	case MsgTypeNewOrderSingle:
+		return t.newOrderSingle(msg)
+	case MsgTypeOrderCancelRequest:
+		return t.cancelRequest(msg)
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedMsgType, msg.MsgType())
+	}
+}
+
+func (t *Translator) newOrderSingle(msg *Message) error {
+	clOrdID, _ := msg.Get(TagClOrdID)
+	order, err := OrderFromFIX(msg)
+	if err == nil && clOrdID == "" {
+		err = errors.New("fix: missing ClOrdID")
+	}
+
+	t.mu.Lock()
+	if _, dup := t.byClOrdID[clOrdID]; err == nil && dup {
+		err = fmt.Errorf("fix: duplicate ClOrdID %q", clOrdID)
+	}
+	if err != nil {
+		st := &orderState{order: order, clOrdID: clOrdID}
+		t.send(t.report(st, execRejected, execRejected).Set(TagText, err.Error()))
+		t.mu.Unlock()
+		return err
+	}
+	order.ID = luxdex.NextOrderID()
+	order.Timestamp = time.Now()
+	st := &orderState{order: order, clOrdID: clOrdID, pending: true}
+	st.queued = []*Message{t.report(st, execNew, execNew)}
+	t.orders[order.ID] = st
+	t.byClOrdID[clOrdID] = order.ID
+	t.mu.Unlock()
+
+	result := t.engine.PlaceOrder(order)
+	// Orders not left resting were cancelled, unless completely filled
+	_, resting := t.engine.GetOrder(order.SymbolID, order.ID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st.pending = false
+	if !result.Success {
+		t.forget(st)
+		t.send(t.report(st, execRejected, execRejected).Set(TagText, result.Error))
+		return nil
+	}
+	for _, m := range st.queued {
+		t.send(m)
+	}
+	st.queued = nil
+	if st.cum < st.order.Quantity && !resting {
+		t.send(t.report(st, execCanceled, execCanceled))
+	}
+	if st.cum >= st.order.Quantity || !resting {
+		t.forget(st)
+	}
+	return nil
+}
+
+func (t *Translator) cancelRequest(msg *Message) error {
+	clOrdID, _ := msg.Get(TagClOrdID)
+	origClOrdID, _ := msg.Get(TagOrigClOrdID)
+
+	t.mu.Lock()
+	id, ok := t.byClOrdID[origClOrdID]
+	if !ok {
+		t.send(cancelReject(clOrdID, origClOrdID, "1", "unknown order"))
+		t.mu.Unlock()
+		return fmt.Errorf("fix: unknown OrigClOrdID %q", origClOrdID)
+	}
+	st := t.orders[id]
+	st.cancelClOrdID = clOrdID
+	t.mu.Unlock()
+
+	// The Canceled report is sent from OnOrderCancelled
+	result := t.engine.CancelOrder(st.order.SymbolID, id)
+	if !result.Success {
+		t.mu.Lock()
+		st.cancelClOrdID = ""
+		t.send(cancelReject(clOrdID, origClOrdID, "0", result.Error))
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// forget drops a finished order; must be called with t.mu held.
+func (t *Translator) forget(st *orderState) {
+	delete(t.orders, st.order.ID)
+	delete(t.byClOrdID, st.clOrdID)
+}
+
+// report builds an ExecutionReport for st; must be called with t.mu held.
+func (t *Translator) report(st *orderState, execType, ordStatus string) *Message {
+	t.execID++
+	o := st.order
+	m := NewMessage(MsgTypeExecutionReport).
+		Set(TagOrderID, strconv.FormatUint(o.ID, 10)).
+		Set(TagClOrdID, st.clOrdID)
+	if st.cancelClOrdID != "" {
+		m.Set(TagClOrdID, st.cancelClOrdID).Set(TagOrigClOrdID, st.clOrdID)
+	}
+	m.Set(TagExecID, strconv.FormatUint(t.execID, 10)).
+		Set(TagExecType, execType).
+		Set(TagOrdStatus, ordStatus).
+		Set(TagSymbol, strconv.FormatUint(o.SymbolID, 10)).
+		Set(TagSide, sidesToFIX[o.Side]).
+		Set(TagOrderQty, formatQty(o.Quantity)).
+		Set(TagCumQty, formatQty(st.cum))
+	leaves := o.Quantity - st.cum
+	if execType == execCanceled || execType == execRejected || execType == execExpired {
+		leaves = 0
+	}
+	m.Set(TagLeavesQty, formatQty(leaves))
+	avg := 0.0
+	if st.cum > 0 {
+		avg = st.notional / st.cum.ToFloat()
+	}
+	m.Set(TagAvgPx, strconv.FormatFloat(avg, 'f', -1, 64)).
+		Set(TagTransactTime, time.Now().UTC().Format(timeFormat))
+	return m
+}
+
+func cancelReject(clOrdID, origClOrdID, reason, text string) *Message {
+	return NewMessage(MsgTypeOrderCancelReject).
+		Set(TagOrderID, "NONE").
+		Set(TagClOrdID, clOrdID).
+		Set(TagOrigClOrdID, origClOrdID).
+		Set(TagOrdStatus, execRejected).
+		Set(TagCxlRejRespTo, "1"). // Order cancel request
+		Set(TagCxlRejReason, reason).
+		Set(TagText, text)
+}
+
+// OnTrade implements luxdex.TradeListener, reporting a fill for each side
+// placed through the translator.
+func (t *Translator) OnTrade(trade luxdex.Trade) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, id := range []uint64{trade.BuyOrderID, trade.SellOrderID} {
+		st := t.orders[id]
+		if st == nil {
+			continue
+		}
+		st.cum += trade.Quantity
+		st.notional += trade.Price.ToFloat() * trade.Quantity.ToFloat()
+
+		status := execPartial
+		if st.cum >= st.order.Quantity {
+			status = execFilled
+		}
+		m := t.report(st, execTrade, status).
+			Set(TagLastPx, formatPrice(trade.Price)).
+			Set(TagLastQty, formatQty(trade.Quantity))
+		if st.pending {
+			st.queued = append(st.queued, m)
+			continue
+		}
+		t.send(m)
+		if status == execFilled {
+			t.forget(st)
+		}
+	}
+}
+
+// OnOrderFilled implements luxdex.TradeListener.
+func (t *Translator) OnOrderFilled(order luxdex.Order) {}
+
+// OnOrderPartiallyFilled implements luxdex.TradeListener.
+func (t *Translator) OnOrderPartiallyFilled(order luxdex.Order, fillQty luxdex.Quantity) {}
+
+// OnOrderCancelled implements luxdex.TradeListener, reporting cancels and
+// expiries of orders placed through the translator.
+func (t *Translator) OnOrderCancelled(order luxdex.Order) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.orders[order.ID]
+	if st == nil || st.pending {
+		return
+	}
+	status := execCanceled
+	if order.Status == luxdex.StatusExpired {
+		status = execExpired
+	}
+	t.send(t.report(st, status, status))
+	t.forget(st)
+}
+
+// OrderFromFIX converts a NewOrderSingle to an engine order. ID and
+// Timestamp are left for the caller to assign.
+func OrderFromFIX(msg *Message) (luxdex.Order, error) {
+	var (
+		order luxdex.Order
+		err   error
+	)
+	get := func(tag int, required bool) string {
+		v, ok := msg.Get(tag)
+		if !ok && required && err == nil {
+			err = fmt.Errorf("fix: missing required tag %d", tag)
+		}
+		return v
+	}
+	parseUint := func(tag int, s string) uint64 {
+		n, perr := strconv.ParseUint(s, 10, 64)
+		if perr != nil && err == nil {
+			err = fmt.Errorf("fix: bad value %q for tag %d", s, tag)
+		}
+		return n
+	}
+	parseFloat := func(tag int, s string) float64 {
+		f, perr := strconv.ParseFloat(s, 64)
+		if perr != nil && err == nil {
+			err = fmt.Errorf("fix: bad value %q for tag %d", s, tag)
+		}
+		return f
+	}
+
+	order.SymbolID = parseUint(TagSymbol, get(TagSymbol, true))
+	if s := get(TagAccount, false); s != "" {
+		order.AccountID = parseUint(TagAccount, s)
+	}
+	order.Quantity = luxdex.QuantityFromFloat(parseFloat(TagOrderQty, get(TagOrderQty, true)))
+
+	side, ok := lookup(sidesToFIX, get(TagSide, true))
+	if !ok && err == nil {
+		err = errors.New("fix: unsupported Side")
+	}
+	order.Side = side
+
+	order.Type, ok = lookup(orderTypesToFIX, get(TagOrdType, true))
+	if !ok && err == nil {
+		err = errors.New("fix: unsupported OrdType")
+	}
+	if order.Type == luxdex.OrderTypeLimit || order.Type == luxdex.OrderTypeStopLimit {
+		order.Price = luxdex.PriceFromFloat(parseFloat(TagPrice, get(TagPrice, true)))
+	}
+	if order.Type == luxdex.OrderTypeStop || order.Type == luxdex.OrderTypeStopLimit {
+		order.StopPrice = luxdex.PriceFromFloat(parseFloat(TagStopPx, get(TagStopPx, true)))
+	}
+
+	order.TIF = luxdex.TifDAY // FIX default
+	if s, present := msg.Get(TagTimeInForce); present {
+		if order.TIF, ok = lookup(tifsToFIX, s); !ok && err == nil {
+			err = fmt.Errorf("fix: unsupported TimeInForce %q", s)
+		}
+	}
+	if order.TIF == luxdex.TifGTD {
+		expire, perr := time.Parse(timeFormat, get(TagExpireTime, true))
+		if perr != nil {
+			expire, perr = time.Parse("20060102-15:04:05", get(TagExpireTime, true))
+		}
+		if perr != nil && err == nil {
+			err = errors.New("fix: bad ExpireTime")
+		}
+		order.ExpireTime = expire
+	}
+	if s, present := msg.Get(TagExecInst); present {
+		for _, inst := range strings.Fields(s) {
+			if inst == execInstPostOnly {
+				order.TIF = luxdex.TifPostOnly
+			}
+		}
+	}
+	if s, present := msg.Get(TagMaxFloor); present {
+		order.DisplayQty = luxdex.QuantityFromFloat(parseFloat(TagMaxFloor, s))
+	}
+	order.Status = luxdex.StatusNew
+	return order, err
+}
+
+// NewOrderSingle encodes an engine order as a NewOrderSingle with the given
+// ClOrdID. It is the inverse of OrderFromFIX.
+func NewOrderSingle(clOrdID string, order luxdex.Order) *Message {
+	m := NewMessage(MsgTypeNewOrderSingle).
+		Set(TagClOrdID, clOrdID).
+		Set(TagAccount, strconv.FormatUint(order.AccountID, 10)).
+		Set(TagSymbol, strconv.FormatUint(order.SymbolID, 10)).
+		Set(TagSide, sidesToFIX[order.Side]).
+		Set(TagOrderQty, formatQty(order.Quantity)).
+		Set(TagOrdType, orderTypesToFIX[order.Type])
+	if order.Type == luxdex.OrderTypeLimit || order.Type == luxdex.OrderTypeStopLimit {
+		m.Set(TagPrice, formatPrice(order.Price))
+	}
+	if order.Type == luxdex.OrderTypeStop || order.Type == luxdex.OrderTypeStopLimit {
+		m.Set(TagStopPx, formatPrice(order.StopPrice))
+	}
+	if order.TIF == luxdex.TifPostOnly {
+		m.Set(TagTimeInForce, tifsToFIX[luxdex.TifGTC]).Set(TagExecInst, execInstPostOnly)
+	} else {
+		m.Set(TagTimeInForce, tifsToFIX[order.TIF])
+	}
+	if order.TIF == luxdex.TifGTD {
+		m.Set(TagExpireTime, order.ExpireTime.UTC().Format(timeFormat))
+	}
+	if order.DisplayQty > 0 {
+		m.Set(TagMaxFloor, formatQty(order.DisplayQty))
+	}
+	return m.Set(TagTransactTime, time.Now().UTC().Format(timeFormat))
+}
+
+// OrderCancelRequest encodes a request to cancel the order sent as
+// origClOrdID.
+func OrderCancelRequest(clOrdID, origClOrdID string, order luxdex.Order) *Message {
+	return NewMessage(MsgTypeOrderCancelRequest).
+		Set(TagOrigClOrdID, origClOrdID).
+		Set(TagClOrdID, clOrdID).
+		Set(TagSymbol, strconv.FormatUint(order.SymbolID, 10)).
+		Set(TagSide, sidesToFIX[order.Side]).
+		Set(TagTransactTime, time.Now().UTC().Format(timeFormat))
+}
+
+// lookup finds the engine value whose FIX code is code.
+func lookup[K comparable](codes map[K]string, code string) (K, bool) {
+	for k, c := range codes {
+		if c == code {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+func formatPrice(p luxdex.Price) string {
+	return strconv.FormatFloat(p.ToFloat(), 'f', -1, 64)
+}
+
+func formatQty(q luxdex.Quantity) string {
+	return strconv.FormatFloat(q.ToFloat(), 'f', -1, 64)
+}
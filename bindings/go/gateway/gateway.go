@@ -0,0 +1,270 @@
+// Package gateway serves a luxdex Engine over HTTP with a JSON wire format.
+//
+// Requests and responses are the luxdex types encoded with encoding/json, so
+// field names match the Go structs and prices and quantities are the
+// engine's fixed-point integers (value * 1e8):
+//
+//	POST /orders                  Order         -> OrderResult
+//	POST /cancel                  CancelRequest -> CancelResult
+//	GET  /depth?symbol=&levels=                 -> MarketDepth
+//	GET  /stream?symbol=&levels=                -> newline-delimited Message values
+//
+// The stream stays open and carries a Message for every trade in the symbol,
+// followed by a depth snapshot whenever the gateway sees the book change.
+// The core luxdex package does not import net/http; only programs that use
+// this package do.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+// DefaultDepthLevels is the number of levels per side when a request does
+// not set levels.
+const DefaultDepthLevels = 10
+
+// streamBuffer is how many messages a stream may fall behind before it is
+// closed; a slow client must not hold up matching.
+const streamBuffer = 256
+
+// CancelRequest is the body of POST /cancel.
+type CancelRequest struct {
+	SymbolID uint64
+	OrderID  uint64
+}
+
+// MessageType identifies the payload of a stream Message.
+type MessageType string
+
+const (
+	MessageTrade MessageType = "trade"
+	MessageDepth MessageType = "depth"
+)
+
+// Message is one line of a /stream response. Exactly one of Trade or Depth
+// is set, according to Type.
+type Message struct {
+	Type     MessageType         `json:"type"`
+	SymbolID uint64              `json:"symbol_id"`
+	Trade    *luxdex.Trade       `json:"trade,omitempty"`
+	Depth    *luxdex.MarketDepth `json:"depth,omitempty"`
+}
+
+// errorBody is the response body of a failed request.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+type gateway struct {
+	engine luxdex.Engine
+	mux    *http.ServeMux
+
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	symbolID uint64
+	levels   int
+	ch       chan Message
+	closed   bool
+}
+
+// NewJSONGateway returns a handler serving e. It installs itself as e's trade
+// listener to feed /stream, replacing any listener already set.
+func NewJSONGateway(e luxdex.Engine) http.Handler {
+	g := &gateway{
+		engine: e,
+		mux:    http.NewServeMux(),
+		subs:   make(map[*subscriber]struct{}),
+	}
+	g.mux.HandleFunc("/orders", g.handlePlace)
+	g.mux.HandleFunc("/cancel", g.handleCancel)
+	g.mux.HandleFunc("/depth", g.handleDepth)
+	g.mux.HandleFunc("/stream", g.handleStream)
+	e.SetTradeListener(g)
+	return g
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *gateway) handlePlace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var order luxdex.Order
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if order.ID == 0 {
+		order.ID = luxdex.NextOrderID()
+	}
+	if order.Timestamp.IsZero() {
+		order.Timestamp = time.Now()
+	}
+
+	result := g.engine.PlaceOrder(order)
+	if result.Success {
+		g.publishDepth(order.SymbolID)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (g *gateway) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var req CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// Depth is published from OnOrderCancelled
+	writeJSON(w, http.StatusOK, g.engine.CancelOrder(req.SymbolID, req.OrderID))
+}
+
+func (g *gateway) handleDepth(w http.ResponseWriter, r *http.Request) {
+	symbolID, levels, err := g.symbolQuery(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g.engine.GetDepth(symbolID, levels))
+}
+
+func (g *gateway) handleStream(w http.ResponseWriter, r *http.Request) {
+	symbolID, levels, err := g.symbolQuery(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+
+	sub := &subscriber{symbolID: symbolID, levels: levels, ch: make(chan Message, streamBuffer)}
+	g.mu.Lock()
+	g.subs[sub] = struct{}{}
+	g.mu.Unlock()
+	defer g.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.ch:
+			if !ok {
+				return // Fell too far behind
+			}
+			if err := enc.Encode(msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// symbolQuery parses the symbol and levels query parameters.
+func (g *gateway) symbolQuery(r *http.Request) (uint64, int, error) {
+	q := r.URL.Query()
+	symbolID, err := strconv.ParseUint(q.Get("symbol"), 10, 64)
+	if err != nil || !g.engine.HasSymbol(symbolID) {
+		return 0, 0, luxdex.ErrUnknownSymbol
+	}
+	levels := DefaultDepthLevels
+	if s := q.Get("levels"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			levels = n
+		}
+	}
+	return symbolID, levels, nil
+}
+
+func (g *gateway) unsubscribe(sub *subscriber) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.subs, sub)
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+// publish sends the message built for each subscriber of symbolID, closing
+// any subscriber whose buffer is full.
+func (g *gateway) publish(symbolID uint64, build func(sub *subscriber) Message) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for sub := range g.subs {
+		if sub.symbolID != symbolID || sub.closed {
+			continue
+		}
+		select {
+		case sub.ch <- build(sub):
+		default:
+			sub.closed = true
+			close(sub.ch)
+			delete(g.subs, sub)
+		}
+	}
+}
+
+func (g *gateway) publishDepth(symbolID uint64) {
+	depths := make(map[int]*luxdex.MarketDepth)
+	g.publish(symbolID, func(sub *subscriber) Message {
+		depth, ok := depths[sub.levels]
+		if !ok {
+			d := g.engine.GetDepth(symbolID, sub.levels)
+			depth = &d
+			depths[sub.levels] = depth
+		}
+		return Message{Type: MessageDepth, SymbolID: symbolID, Depth: depth}
+	})
+}
+
+// OnTrade implements luxdex.TradeListener.
+func (g *gateway) OnTrade(trade luxdex.Trade) {
+	g.publish(trade.SymbolID, func(*subscriber) Message {
+		return Message{Type: MessageTrade, SymbolID: trade.SymbolID, Trade: &trade}
+	})
+}
+
+// OnOrderFilled implements luxdex.TradeListener.
+func (g *gateway) OnOrderFilled(order luxdex.Order) {}
+
+// OnOrderPartiallyFilled implements luxdex.TradeListener.
+func (g *gateway) OnOrderPartiallyFilled(order luxdex.Order, fillQty luxdex.Quantity) {}
+
+// OnOrderCancelled implements luxdex.TradeListener.
+func (g *gateway) OnOrderCancelled(order luxdex.Order) {
+	g.publishDepth(order.SymbolID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
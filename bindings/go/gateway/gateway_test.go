@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	e, err := luxdex.NewCGOEngine()
+	if err != nil {
+		t.Fatalf("NewCGOEngine() failed: %v", err)
+	}
+	t.Cleanup(e.Close)
+	e.Start()
+	t.Cleanup(e.Stop)
+	e.AddSymbol(1)
+
+	srv := httptest.NewServer(NewJSONGateway(e))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url string, body, out interface{}) {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode %s response: %v", url, err)
+	}
+}
+
+func TestJSONGateway(t *testing.T) {
+	srv := newTestServer(t)
+
+	stream, err := http.Get(srv.URL + "/stream?symbol=1&levels=5")
+	if err != nil {
+		t.Fatalf("GET /stream: %v", err)
+	}
+	defer stream.Body.Close()
+	lines := bufio.NewScanner(stream.Body)
+	next := func() Message {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("stream ended: %v", lines.Err())
+		}
+		var msg Message
+		if err := json.Unmarshal(lines.Bytes(), &msg); err != nil {
+			t.Fatalf("bad stream line %q: %v", lines.Text(), err)
+		}
+		return msg
+	}
+
+	ask := luxdex.NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(5).Build()
+	var placed luxdex.OrderResult
+	post(t, srv.URL+"/orders", ask, &placed)
+	if !placed.Success || placed.OrderID != ask.ID {
+		t.Fatalf("place ask = %+v", placed)
+	}
+	if msg := next(); msg.Type != MessageDepth || len(msg.Depth.Asks) != 1 || msg.Depth.Asks[0].Quantity != 5 {
+		t.Fatalf("after ask: %+v, want depth with 5 offered", msg)
+	}
+
+	// Orders without an ID get one
+	bid := luxdex.Order{SymbolID: 1, AccountID: 2, Side: luxdex.SideBuy, Type: luxdex.OrderTypeLimit,
+		Price: luxdex.PriceFromFloat(100), Quantity: luxdex.QuantityFromFloat(2)}
+	post(t, srv.URL+"/orders", bid, &placed)
+	if !placed.Success || placed.OrderID == 0 || len(placed.Trades) != 1 {
+		t.Fatalf("place bid = %+v, want one trade", placed)
+	}
+	if msg := next(); msg.Type != MessageTrade || msg.Trade.Quantity != luxdex.QuantityFromFloat(2) || msg.Trade.SellOrderID != ask.ID {
+		t.Fatalf("after bid: %+v, want trade of 2", msg)
+	}
+	if msg := next(); msg.Type != MessageDepth || msg.Depth.Asks[0].Quantity != 3 {
+		t.Fatalf("after trade: %+v, want depth with 3 offered", msg)
+	}
+
+	var cancelled luxdex.CancelResult
+	post(t, srv.URL+"/cancel", CancelRequest{SymbolID: 1, OrderID: ask.ID}, &cancelled)
+	if !cancelled.Success || cancelled.CancelledOrder == nil || cancelled.CancelledOrder.ID != ask.ID {
+		t.Fatalf("cancel = %+v", cancelled)
+	}
+	if msg := next(); msg.Type != MessageDepth || len(msg.Depth.Asks) != 0 {
+		t.Fatalf("after cancel: %+v, want empty asks", msg)
+	}
+
+	resp, err := http.Get(srv.URL + "/depth?symbol=1")
+	if err != nil {
+		t.Fatalf("GET /depth: %v", err)
+	}
+	defer resp.Body.Close()
+	var depth luxdex.MarketDepth
+	if err := json.NewDecoder(resp.Body).Decode(&depth); err != nil {
+		t.Fatalf("decode depth: %v", err)
+	}
+	if len(depth.Bids) != 0 || len(depth.Asks) != 0 {
+		t.Errorf("depth = %+v, want empty book", depth)
+	}
+}
+
+func TestJSONGatewayErrors(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/depth?symbol=9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown symbol status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = http.Post(srv.URL+"/orders", "application/json", bytes.NewReader([]byte("{")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed order status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /orders status = %d, want 405", resp.StatusCode)
+	}
+}
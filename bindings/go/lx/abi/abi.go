@@ -0,0 +1,347 @@
+// Package abi encodes and decodes calldata for the LX precompiles.
+//
+// Calldata is a 4-byte method selector followed by 32-byte big-endian
+// words, one per field, in the order the precompile router reads them.
+// Addresses are right-aligned, integers are zero-padded and X18 values are
+// sign-extended two's complement. Use the results with LX.PrecompileCall.
+package abi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/luxcpp/dex/bindings/go/lx"
+)
+
+// WordSize is the size of one ABI word.
+const WordSize = 32
+
+// Method selectors understood by the precompile router.
+const (
+	SelPoolInitialize      uint32 = 0x7a44c8ab // initialize(PoolKey,uint160)
+	SelPoolSwap            uint32 = 0x1a686502 // swap(PoolKey,SwapParams,bytes)
+	SelPoolModifyLiquidity uint32 = 0x3a7a5b04 // modifyLiquidity(PoolKey,ModifyLiquidityParams,bytes)
+	SelPoolGetSlot0        uint32 = 0x9e5e2e15 // getSlot0(PoolKey)
+	SelBookGetL1           uint32 = 0x4f55d24d // getL1(uint32)
+	SelBookPlaceOrder      uint32 = 0x3e5b3a12 // placeOrder(LXOrder)
+	SelBookCancelOrder     uint32 = 0x9e281a98 // cancelOrder(uint32,uint64)
+	SelBookGetOrder        uint32 = 0x7c8d9e11 // getOrder(uint32,uint64)
+	SelVaultDeposit        uint32 = 0x47e7ef24 // deposit(address,uint256)
+	SelVaultWithdraw       uint32 = 0xf3fef3a3 // withdraw(address,uint256)
+	SelVaultGetPosition    uint32 = 0x4ab42e11 // getPosition(address,uint32)
+	SelVaultGetBalance     uint32 = 0xf8b2cb4f // getBalance(address,address)
+)
+
+// Selector returns the method selector of calldata.
+func Selector(calldata []byte) (uint32, error) {
+	if len(calldata) < 4 {
+		return 0, fmt.Errorf("abi: calldata is %d bytes, shorter than a selector", len(calldata))
+	}
+	return binary.BigEndian.Uint32(calldata), nil
+}
+
+// =============================================================================
+// LXPool (LP-9010)
+// =============================================================================
+
+const (
+	poolKeyWords    = 5
+	swapParamsWords = 3
+)
+
+// EncodeSwap builds calldata for swap(PoolKey,SwapParams,bytes) with empty
+// hook data.
+func EncodeSwap(key lx.PoolKey, params lx.SwapParams) []byte {
+	e := newEncoder(SelPoolSwap, poolKeyWords+swapParamsWords+2)
+	e.poolKey(key)
+	e.bool(params.ZeroForOne)
+	e.x18(params.AmountSpecified)
+	e.x18(params.SqrtPriceLimit)
+	// Empty dynamic bytes: offset to the tail, then zero length
+	e.uint(uint64((poolKeyWords + swapParamsWords + 1) * WordSize))
+	e.uint(0)
+	return e.buf
+}
+
+// DecodeSwap parses calldata built by EncodeSwap.
+func DecodeSwap(calldata []byte) (lx.PoolKey, lx.SwapParams, error) {
+	d, err := newDecoder("swap", calldata, SelPoolSwap, poolKeyWords+swapParamsWords)
+	if err != nil {
+		return lx.PoolKey{}, lx.SwapParams{}, err
+	}
+	key := d.poolKey()
+	params := lx.SwapParams{
+		ZeroForOne:      d.bool(),
+		AmountSpecified: d.x18(),
+		SqrtPriceLimit:  d.x18(),
+	}
+	return key, params, nil
+}
+
+// =============================================================================
+// LXBook (LP-9020)
+// =============================================================================
+
+const placeOrderWords = 9
+
+// EncodePlaceOrder builds calldata for placeOrder(LXOrder). The precompile
+// identifies the sender by main address only, so sender.SubaccountID and
+// order.CLOID are not encoded.
+func EncodePlaceOrder(sender lx.Account, order lx.Order) []byte {
+	e := newEncoder(SelBookPlaceOrder, placeOrderWords)
+	e.address(sender.Main)
+	e.uint(uint64(order.MarketID))
+	e.bool(order.IsBuy)
+	e.uint(uint64(order.Kind))
+	e.x18(order.SizeX18)
+	e.x18(order.LimitPxX18)
+	e.x18(order.TriggerPxX18)
+	e.bool(order.ReduceOnly)
+	e.uint(uint64(order.TIF))
+	return e.buf
+}
+
+// DecodePlaceOrder parses calldata built by EncodePlaceOrder.
+func DecodePlaceOrder(calldata []byte) (lx.Account, lx.Order, error) {
+	d, err := newDecoder("placeOrder", calldata, SelBookPlaceOrder, placeOrderWords)
+	if err != nil {
+		return lx.Account{}, lx.Order{}, err
+	}
+	sender := lx.Account{Main: d.address()}
+	order := lx.Order{
+		MarketID:     uint32(d.uint()),
+		IsBuy:        d.bool(),
+		Kind:         lx.OrderKind(d.uint()),
+		SizeX18:      d.x18(),
+		LimitPxX18:   d.x18(),
+		TriggerPxX18: d.x18(),
+		ReduceOnly:   d.bool(),
+		TIF:          lx.TIF(d.uint()),
+	}
+	return sender, order, nil
+}
+
+// =============================================================================
+// LXVault (LP-9030)
+// =============================================================================
+
+const depositWords = 3
+
+// EncodeDeposit builds calldata for deposit(address,uint256). Only the main
+// address of account is encoded.
+func EncodeDeposit(account lx.Account, token lx.Currency, amount lx.X18) []byte {
+	e := newEncoder(SelVaultDeposit, depositWords)
+	e.address(account.Main)
+	e.address(token)
+	e.x18(amount)
+	return e.buf
+}
+
+// DecodeDeposit parses calldata built by EncodeDeposit.
+func DecodeDeposit(calldata []byte) (lx.Account, lx.Currency, lx.X18, error) {
+	d, err := newDecoder("deposit", calldata, SelVaultDeposit, depositWords)
+	if err != nil {
+		return lx.Account{}, lx.Currency{}, lx.X18{}, err
+	}
+	return lx.Account{Main: d.address()}, d.address(), d.x18(), nil
+}
+
+// =============================================================================
+// Return Data
+// =============================================================================
+
+// DecodeCode parses the int32 status code returned by state-changing
+// methods such as deposit and cancelOrder. Zero means success; negative
+// values are LX error codes.
+func DecodeCode(ret []byte) (int32, error) {
+	d, err := newReturnDecoder("status code", ret, 1)
+	if err != nil {
+		return 0, err
+	}
+	return int32(d.uint()), nil
+}
+
+// DecodeBalanceDelta parses the return data of swap and modifyLiquidity.
+func DecodeBalanceDelta(ret []byte) (lx.BalanceDelta, error) {
+	d, err := newReturnDecoder("BalanceDelta", ret, 2)
+	if err != nil {
+		return lx.BalanceDelta{}, err
+	}
+	return lx.BalanceDelta{Amount0: d.x18(), Amount1: d.x18()}, nil
+}
+
+// DecodePlaceResult parses the return data of placeOrder.
+func DecodePlaceResult(ret []byte) (lx.PlaceResult, error) {
+	d, err := newReturnDecoder("PlaceResult", ret, 4)
+	if err != nil {
+		return lx.PlaceResult{}, err
+	}
+	return lx.PlaceResult{
+		OID:           d.uint(),
+		Status:        lx.OrderStatus(d.uint()),
+		FilledSizeX18: d.x18(),
+		AvgPxX18:      d.x18(),
+	}, nil
+}
+
+// DecodeL1 parses the return data of getL1.
+func DecodeL1(ret []byte) (lx.L1, error) {
+	d, err := newReturnDecoder("L1", ret, 5)
+	if err != nil {
+		return lx.L1{}, err
+	}
+	return lx.L1{
+		BestBidPxX18:   d.x18(),
+		BestBidSzX18:   d.x18(),
+		BestAskPxX18:   d.x18(),
+		BestAskSzX18:   d.x18(),
+		LastTradePxX18: d.x18(),
+	}, nil
+}
+
+// DecodePosition parses the return data of getPosition.
+func DecodePosition(ret []byte) (lx.Position, error) {
+	d, err := newReturnDecoder("Position", ret, 7)
+	if err != nil {
+		return lx.Position{}, err
+	}
+	return lx.Position{
+		MarketID:              uint32(d.uint()),
+		Side:                  lx.PositionSide(d.uint()),
+		SizeX18:               d.x18(),
+		EntryPxX18:            d.x18(),
+		UnrealizedPnlX18:      d.x18(),
+		AccumulatedFundingX18: d.x18(),
+		LastFundingTime:       d.uint(),
+	}, nil
+}
+
+// DecodeBalance parses the return data of getBalance.
+func DecodeBalance(ret []byte) (lx.X18, error) {
+	d, err := newReturnDecoder("balance", ret, 1)
+	if err != nil {
+		return lx.X18{}, err
+	}
+	return d.x18(), nil
+}
+
+// =============================================================================
+// Word Encoding
+// =============================================================================
+
+type encoder struct {
+	buf []byte
+}
+
+func newEncoder(selector uint32, words int) *encoder {
+	buf := make([]byte, 4, 4+words*WordSize)
+	binary.BigEndian.PutUint32(buf, selector)
+	return &encoder{buf: buf}
+}
+
+func (e *encoder) word() []byte {
+	e.buf = append(e.buf, make([]byte, WordSize)...)
+	return e.buf[len(e.buf)-WordSize:]
+}
+
+func (e *encoder) uint(v uint64) {
+	binary.BigEndian.PutUint64(e.word()[24:], v)
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.word()[31] = 1
+	} else {
+		e.word()
+	}
+}
+
+func (e *encoder) address(a lx.Address) {
+	copy(e.word()[12:], a[:])
+}
+
+func (e *encoder) x18(x lx.X18) {
+	w := e.word()
+	if x.Hi < 0 {
+		for i := 0; i < 16; i++ {
+			w[i] = 0xff
+		}
+	}
+	binary.BigEndian.PutUint64(w[16:], uint64(x.Hi))
+	binary.BigEndian.PutUint64(w[24:], uint64(x.Lo))
+}
+
+func (e *encoder) poolKey(k lx.PoolKey) {
+	e.address(k.Currency0)
+	e.address(k.Currency1)
+	e.uint(uint64(k.Fee))
+	e.uint(uint64(uint32(k.TickSpacing)))
+	e.address(k.Hooks)
+}
+
+type decoder struct {
+	buf []byte
+}
+
+// newDecoder checks the selector and that at least words argument words
+// follow it.
+func newDecoder(method string, calldata []byte, selector uint32, words int) (*decoder, error) {
+	sel, err := Selector(calldata)
+	if err != nil {
+		return nil, err
+	}
+	if sel != selector {
+		return nil, fmt.Errorf("abi: %s: selector %#08x, want %#08x", method, sel, selector)
+	}
+	if want := 4 + words*WordSize; len(calldata) < want {
+		return nil, fmt.Errorf("abi: %s: calldata is %d bytes, want at least %d", method, len(calldata), want)
+	}
+	return &decoder{buf: calldata[4:]}, nil
+}
+
+// newReturnDecoder checks that ret is exactly words ABI words. Precompiles
+// return no data when a lookup misses, which is reported as an error too.
+func newReturnDecoder(what string, ret []byte, words int) (*decoder, error) {
+	if want := words * WordSize; len(ret) != want {
+		return nil, fmt.Errorf("abi: %s return data is %d bytes, want %d", what, len(ret), want)
+	}
+	return &decoder{buf: ret}, nil
+}
+
+func (d *decoder) word() []byte {
+	w := d.buf[:WordSize]
+	d.buf = d.buf[WordSize:]
+	return w
+}
+
+func (d *decoder) uint() uint64 {
+	return binary.BigEndian.Uint64(d.word()[24:])
+}
+
+func (d *decoder) bool() bool {
+	return d.word()[31] != 0
+}
+
+func (d *decoder) address() lx.Address {
+	var a lx.Address
+	copy(a[:], d.word()[12:])
+	return a
+}
+
+func (d *decoder) x18() lx.X18 {
+	w := d.word()
+	return lx.X18{
+		Hi: int64(binary.BigEndian.Uint64(w[16:])),
+		Lo: int64(binary.BigEndian.Uint64(w[24:])),
+	}
+}
+
+func (d *decoder) poolKey() lx.PoolKey {
+	return lx.PoolKey{
+		Currency0:   d.address(),
+		Currency1:   d.address(),
+		Fee:         uint32(d.uint()),
+		TickSpacing: int32(uint32(d.uint())),
+		Hooks:       d.address(),
+	}
+}
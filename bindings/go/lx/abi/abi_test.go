@@ -0,0 +1,192 @@
+package abi
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/luxcpp/dex/bindings/go/lx"
+)
+
+func TestEncodeSwapLayout(t *testing.T) {
+	key := lx.PoolKey{
+		Currency0:   lx.Currency{19: 0x01},
+		Currency1:   lx.Currency{19: 0x02},
+		Fee:         lx.Fee030,
+		TickSpacing: -60,
+		Hooks:       lx.Address{0: 0xaa},
+	}
+	params := lx.SwapParams{ZeroForOne: true, AmountSpecified: lx.X18FromInt(-5)}
+
+	data := EncodeSwap(key, params)
+	if got := binary.BigEndian.Uint32(data); got != SelPoolSwap {
+		t.Fatalf("selector = %#08x, want %#08x", got, SelPoolSwap)
+	}
+	if (len(data)-4)%WordSize != 0 {
+		t.Fatalf("args are %d bytes, not word aligned", len(data)-4)
+	}
+	args := data[4:]
+	if args[WordSize-1] != 0x01 || args[2*WordSize-1] != 0x02 {
+		t.Error("currencies not right-aligned in their words")
+	}
+	if args[4*WordSize+12] != 0xaa {
+		t.Error("hooks address not right-aligned")
+	}
+	if args[5*WordSize+31] != 1 {
+		t.Error("zeroForOne not encoded as 1")
+	}
+	// Negative amount is sign-extended across the whole word
+	if args[6*WordSize] != 0xff {
+		t.Error("negative amount not sign-extended")
+	}
+
+	gotKey, gotParams, err := DecodeSwap(data)
+	if err != nil {
+		t.Fatalf("DecodeSwap failed: %v", err)
+	}
+	if gotKey != key || gotParams != params {
+		t.Errorf("round trip = %+v %+v, want %+v %+v", gotKey, gotParams, key, params)
+	}
+}
+
+func TestEncodePlaceOrderRoundTrip(t *testing.T) {
+	sender := lx.Account{Main: lx.Address{19: 0x42}}
+	order := lx.Order{
+		MarketID:   7,
+		IsBuy:      true,
+		Kind:       lx.OrderStopLimit,
+		SizeX18:    lx.X18FromInt(3),
+		LimitPxX18: lx.X18FromFloat(101.5),
+		ReduceOnly: true,
+		TIF:        lx.TifIOC,
+	}
+	data := EncodePlaceOrder(sender, order)
+	if want := 4 + placeOrderWords*WordSize; len(data) != want {
+		t.Fatalf("len = %d, want %d", len(data), want)
+	}
+	gotSender, gotOrder, err := DecodePlaceOrder(data)
+	if err != nil {
+		t.Fatalf("DecodePlaceOrder failed: %v", err)
+	}
+	if gotSender != sender || gotOrder != order {
+		t.Errorf("round trip = %+v %+v, want %+v %+v", gotSender, gotOrder, sender, order)
+	}
+}
+
+func TestEncodeDepositRoundTrip(t *testing.T) {
+	account := lx.Account{Main: lx.Address{19: 0x01}}
+	token := lx.Currency{19: 0xee}
+	amount := lx.X18FromInt(1000)
+
+	gotAccount, gotToken, gotAmount, err := DecodeDeposit(EncodeDeposit(account, token, amount))
+	if err != nil {
+		t.Fatalf("DecodeDeposit failed: %v", err)
+	}
+	if gotAccount != account || gotToken != token || gotAmount != amount {
+		t.Errorf("round trip = %v %v %v", gotAccount, gotToken, gotAmount)
+	}
+}
+
+func TestDecodeRejectsBadCalldata(t *testing.T) {
+	data := EncodeDeposit(lx.Account{}, lx.Currency{}, lx.X18{})
+	if _, _, _, err := DecodeDeposit(data[:len(data)-1]); err == nil {
+		t.Error("DecodeDeposit accepted truncated calldata")
+	}
+	if _, _, err := DecodeSwap(data); err == nil {
+		t.Error("DecodeSwap accepted deposit calldata")
+	}
+	if _, err := Selector([]byte{1, 2}); err == nil {
+		t.Error("Selector accepted 2 bytes")
+	}
+}
+
+// words concatenates ABI words built by fill functions.
+func words(fills ...func(w []byte)) []byte {
+	out := make([]byte, 0, len(fills)*WordSize)
+	for _, fill := range fills {
+		w := make([]byte, WordSize)
+		fill(w)
+		out = append(out, w...)
+	}
+	return out
+}
+
+func x18Word(x lx.X18) func([]byte) {
+	return func(w []byte) {
+		e := &encoder{}
+		e.x18(x)
+		copy(w, e.buf)
+	}
+}
+
+func uintWord(v uint64) func([]byte) {
+	return func(w []byte) { binary.BigEndian.PutUint64(w[24:], v) }
+}
+
+func TestDecodeBalanceDelta(t *testing.T) {
+	want := lx.BalanceDelta{Amount0: lx.X18FromInt(-10), Amount1: lx.X18FromFloat(9.5)}
+	got, err := DecodeBalanceDelta(words(x18Word(want.Amount0), x18Word(want.Amount1)))
+	if err != nil {
+		t.Fatalf("DecodeBalanceDelta failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodePlaceResult(t *testing.T) {
+	want := lx.PlaceResult{
+		OID:           12345,
+		Status:        lx.StatusFilled,
+		FilledSizeX18: lx.X18FromInt(2),
+		AvgPxX18:      lx.X18FromInt(100),
+	}
+	ret := words(uintWord(want.OID), uintWord(uint64(want.Status)), x18Word(want.FilledSizeX18), x18Word(want.AvgPxX18))
+	got, err := DecodePlaceResult(ret)
+	if err != nil {
+		t.Fatalf("DecodePlaceResult failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeReturnLength(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		decode func([]byte) error
+		words  int
+	}{
+		{"BalanceDelta", func(b []byte) error { _, err := DecodeBalanceDelta(b); return err }, 2},
+		{"PlaceResult", func(b []byte) error { _, err := DecodePlaceResult(b); return err }, 4},
+		{"L1", func(b []byte) error { _, err := DecodeL1(b); return err }, 5},
+		{"Position", func(b []byte) error { _, err := DecodePosition(b); return err }, 7},
+		{"Balance", func(b []byte) error { _, err := DecodeBalance(b); return err }, 1},
+		{"Code", func(b []byte) error { _, err := DecodeCode(b); return err }, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.decode(make([]byte, tc.words*WordSize)); err != nil {
+				t.Errorf("exact length rejected: %v", err)
+			}
+			for _, n := range []int{0, tc.words*WordSize - 1, (tc.words + 1) * WordSize} {
+				if err := tc.decode(make([]byte, n)); err == nil {
+					t.Errorf("%d bytes accepted", n)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCodeNegative(t *testing.T) {
+	ret := make([]byte, WordSize)
+	for i := range ret {
+		ret[i] = 0xff
+	}
+	ret[31] = 0xf6 // -10
+	code, err := DecodeCode(ret)
+	if err != nil {
+		t.Fatalf("DecodeCode failed: %v", err)
+	}
+	if code != -10 {
+		t.Errorf("code = %d, want -10", code)
+	}
+}
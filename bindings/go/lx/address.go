@@ -0,0 +1,132 @@
+package lx
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// AddressFromHex parses a 40-digit hex address, with or without a 0x
+// prefix. Case is not checked against the EIP-55 checksum.
+func AddressFromHex(s string) (Address, error) {
+	var a Address
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(digits) != 2*AddressSize {
+		return a, fmt.Errorf("lx: address %q has %d hex digits, want %d", s, len(digits), 2*AddressSize)
+	}
+	if _, err := hex.Decode(a[:], []byte(digits)); err != nil {
+		return a, fmt.Errorf("lx: address %q: %w", s, err)
+	}
+	return a, nil
+}
+
+// Hex returns the 0x-prefixed EIP-55 checksummed form of the address.
+func (a Address) Hex() string {
+	buf := make([]byte, 2+2*AddressSize)
+	copy(buf, "0x")
+	digits := buf[2:]
+	hex.Encode(digits, a[:])
+	hash := keccak256(digits)
+	for i, c := range digits {
+		// Letters are uppercased where the matching hash nibble is >= 8
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+	return string(buf)
+}
+
+// MarshalText implements encoding.TextMarshaler using Hex.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using AddressFromHex.
+func (a *Address) UnmarshalText(text []byte) error {
+	parsed, err := AddressFromHex(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// keccak256 is the original Keccak-256 used by Ethereum, which pads
+// differently from the standardized SHA3-256.
+func keccak256(data []byte) [32]byte {
+	const rate = 136
+	var state [25]uint64
+
+	absorb := func(block []byte) {
+		for i := 0; i < rate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+		}
+		keccakF1600(&state)
+	}
+	for len(data) >= rate {
+		absorb(data[:rate])
+		data = data[rate:]
+	}
+	var last [rate]byte
+	copy(last[:], data)
+	last[len(data)] ^= 0x01
+	last[rate-1] ^= 0x80
+	absorb(last[:])
+
+	var out [32]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(out[8*i:], state[i])
+	}
+	return out
+}
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations and keccakLanes drive the combined rho and pi steps.
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakLanes     = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	for _, rc := range keccakRoundConstants {
+		// Theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		// Rho and pi
+		t := a[1]
+		for i, lane := range keccakLanes {
+			t, a[lane] = a[lane], bits.RotateLeft64(t, keccakRotations[i])
+		}
+		// Chi
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := 0; x < 5; x++ {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+		// Iota
+		a[0] ^= rc
+	}
+}
@@ -0,0 +1,568 @@
+package lx
+
+/*
+#include "lx_full_c.h"
+
+extern void lxGoTradeCallback(void* user_data, LxTrade* trade);
+extern void lxGoSystemEventCallback(void* user_data, LxSystemEvent* event);
+extern void lxGoL1Callback(uint64_t token, uint32_t market_id, LxL1* l1);
+extern void lxGoJournalCallback(void* user_data, uint8_t* record, size_t len);
+*/
+import "C"
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Go functions cannot be handed to C directly, so each LX instance with a
+// listener or subscription is registered here under its C handle, which C
+// passes back as the callback's user data.
+var (
+	tradeListenersMu sync.RWMutex
+	tradeListeners   = make(map[C.LxHandle]func(Trade))
+
+	systemHubsMu sync.RWMutex
+	systemHubs   = make(map[C.LxHandle]*systemEventHub)
+
+	// L1 subscriptions are keyed by a token allocated in Go before the C
+	// registration, so no update can arrive for an unknown subscription.
+	l1SubsMu    sync.RWMutex
+	l1Subs      = make(map[uint64]*l1Subscription)
+	nextL1Token atomic.Uint64
+
+	journalsMu sync.RWMutex
+	journals   = make(map[C.LxHandle]*journal)
+
+	// Cancel-on-disconnect timers per instance and account. The lock is held
+	// across the C calls so no timer can reach an instance being closed.
+	heartbeatTimersMu sync.Mutex
+	heartbeatTimers   = make(map[C.LxHandle]map[Account]*heartbeatTimer)
+)
+
+const (
+	systemEventBuffer = 1024 // Per-subscriber system event capacity
+	l1Buffer          = 64   // Per-subscriber L1 update capacity
+)
+
+// unregisterCallbacks drops every Go callback for an instance being closed.
+func unregisterCallbacks(ptr C.LxHandle) {
+	unregisterTradeListener(ptr)
+	unregisterJournal(ptr)
+	stopHeartbeatTimers(ptr)
+
+	systemHubsMu.Lock()
+	hub := systemHubs[ptr]
+	delete(systemHubs, ptr)
+	systemHubsMu.Unlock()
+	if hub != nil {
+		hub.closeAll()
+	}
+
+	l1SubsMu.Lock()
+	for token, sub := range l1Subs {
+		if sub.ptr == ptr {
+			delete(l1Subs, token)
+			sub.close()
+		}
+	}
+	l1SubsMu.Unlock()
+}
+
+// =============================================================================
+// Trade Listener
+// =============================================================================
+
+// SetBookTradeListener registers cb to be called for every trade on the
+// book, including fills of resting orders triggered by other accounts.
+// Passing nil removes the listener.
+//
+// cb runs synchronously on the thread that performed the match, before the
+// matching call returns, so it must be quick and must not call back into
+// this LX instance. Trades for one market are delivered in execution order.
+func (d *LX) SetBookTradeListener(cb func(Trade)) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if cb == nil {
+		C.lx_book_set_trade_callback(d.ptr, nil, nil)
+		unregisterTradeListener(d.ptr)
+		return nil
+	}
+
+	tradeListenersMu.Lock()
+	tradeListeners[d.ptr] = cb
+	tradeListenersMu.Unlock()
+
+	C.lx_book_set_trade_callback(d.ptr, C.LxTradeCallback(C.lxGoTradeCallback), unsafe.Pointer(d.ptr))
+	return nil
+}
+
+func unregisterTradeListener(ptr C.LxHandle) {
+	tradeListenersMu.Lock()
+	delete(tradeListeners, ptr)
+	tradeListenersMu.Unlock()
+}
+
+//export lxGoTradeCallback
+func lxGoTradeCallback(userData unsafe.Pointer, trade *C.LxTrade) {
+	tradeListenersMu.RLock()
+	cb := tradeListeners[C.LxHandle(userData)]
+	tradeListenersMu.RUnlock()
+	if cb != nil {
+		cb(fromCTrade(trade))
+	}
+}
+
+// =============================================================================
+// Cancel-on-Disconnect
+// =============================================================================
+
+type heartbeatTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// ArmCancelOnDisconnect arms a dead man's switch for account: unless
+// Heartbeat is called within timeoutMs milliseconds of arming or of the
+// previous heartbeat, every open order of the account, in every market, is
+// cancelled. Arming an armed account replaces its timeout and restarts the
+// countdown from now; timeoutMs 0 disarms. Once the switch trips it is
+// disarmed, and the account must arm it again.
+//
+// Deadlines are kept in milliseconds. A timer cancels the orders about a
+// millisecond after the deadline; independently, the engine checks
+// deadlines before matching each new order, so a lapsed account's orders
+// never trade even if the timer runs late.
+func (d *LX) ArmCancelOnDisconnect(account Account, timeoutMs uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	ptr := d.ptr
+	cAccount := toCAccount(account)
+
+	heartbeatTimersMu.Lock()
+	defer heartbeatTimersMu.Unlock()
+	result := int32(C.lx_book_arm_cancel_on_disconnect(ptr, &cAccount, C.uint32_t(timeoutMs)))
+	if err := errorFromCode(result); err != nil {
+		return err
+	}
+
+	timers := heartbeatTimers[ptr]
+	if hb := timers[account]; hb != nil {
+		hb.timer.Stop()
+		delete(timers, account)
+	}
+	if timeoutMs == 0 {
+		return nil
+	}
+	if timers == nil {
+		timers = make(map[Account]*heartbeatTimer)
+		heartbeatTimers[ptr] = timers
+	}
+	// The extra millisecond covers the engine's rounding to whole ms
+	timeout := time.Duration(timeoutMs)*time.Millisecond + time.Millisecond
+	timers[account] = &heartbeatTimer{
+		timer:   time.AfterFunc(timeout, func() { checkHeartbeats(ptr) }),
+		timeout: timeout,
+	}
+	return nil
+}
+
+// Heartbeat restarts account's cancel-on-disconnect countdown. It returns
+// ErrNotArmed if the switch is off, including when it has already tripped
+// or its deadline has passed; the orders are then cancelled, or about to
+// be, and the account must re-arm before trading on.
+func (d *LX) Heartbeat(account Account) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+
+	heartbeatTimersMu.Lock()
+	defer heartbeatTimersMu.Unlock()
+	if err := errorFromCode(int32(C.lx_book_heartbeat(d.ptr, &cAccount))); err != nil {
+		return err
+	}
+	if hb := heartbeatTimers[d.ptr][account]; hb != nil {
+		hb.timer.Reset(hb.timeout)
+	}
+	return nil
+}
+
+// checkHeartbeats has the engine cancel the orders of lapsed accounts.
+func checkHeartbeats(ptr C.LxHandle) {
+	heartbeatTimersMu.Lock()
+	defer heartbeatTimersMu.Unlock()
+	if _, ok := heartbeatTimers[ptr]; ok {
+		C.lx_book_check_heartbeats(ptr)
+	}
+}
+
+func stopHeartbeatTimers(ptr C.LxHandle) {
+	heartbeatTimersMu.Lock()
+	defer heartbeatTimersMu.Unlock()
+	for _, hb := range heartbeatTimers[ptr] {
+		hb.timer.Stop()
+	}
+	delete(heartbeatTimers, ptr)
+}
+
+// =============================================================================
+// System Events
+// =============================================================================
+
+// systemEventHub fans one instance's system events out to its subscribers.
+type systemEventHub struct {
+	mu      sync.Mutex
+	subs    map[uint64]chan SystemEvent
+	nextID  uint64
+	dropped atomic.Uint64
+}
+
+func (h *systemEventHub) publish(ev SystemEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+func (h *systemEventHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, ch := range h.subs {
+		close(ch)
+		delete(h.subs, id)
+	}
+}
+
+// SubscribeSystemEvents returns a channel of operational events from every
+// subsystem (rejections, halts, liquidations, insurance-fund draws, oracle
+// staleness, circuit-breaker trips and trade busts) and a func that ends
+// the subscription and closes the channel.
+//
+// Each subscriber has a bounded buffer of 1024 events. Delivery never
+// blocks the engine: when a subscriber's buffer is full the event is
+// dropped for that subscriber and counted in SystemEventsDropped.
+func (d *LX) SubscribeSystemEvents() (<-chan SystemEvent, func()) {
+	ch := make(chan SystemEvent, systemEventBuffer)
+	if d.ptr == nil {
+		close(ch)
+		return ch, func() {}
+	}
+	ptr := d.ptr
+
+	systemHubsMu.Lock()
+	hub := systemHubs[ptr]
+	if hub == nil {
+		hub = &systemEventHub{subs: make(map[uint64]chan SystemEvent)}
+		systemHubs[ptr] = hub
+		C.lx_set_system_event_callback(ptr, C.LxSystemEventCallback(C.lxGoSystemEventCallback), unsafe.Pointer(ptr))
+	}
+	hub.mu.Lock()
+	id := hub.nextID
+	hub.nextID++
+	hub.subs[id] = ch
+	hub.mu.Unlock()
+	systemHubsMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			hub.mu.Lock()
+			if sub, ok := hub.subs[id]; ok {
+				close(sub)
+				delete(hub.subs, id)
+			}
+			hub.mu.Unlock()
+		})
+	}
+}
+
+// SystemEventsDropped returns how many system events have been dropped
+// because a subscriber's buffer was full.
+func (d *LX) SystemEventsDropped() uint64 {
+	systemHubsMu.RLock()
+	hub := systemHubs[d.ptr]
+	systemHubsMu.RUnlock()
+	if hub == nil {
+		return 0
+	}
+	return hub.dropped.Load()
+}
+
+//export lxGoSystemEventCallback
+func lxGoSystemEventCallback(userData unsafe.Pointer, event *C.LxSystemEvent) {
+	systemHubsMu.RLock()
+	hub := systemHubs[C.LxHandle(userData)]
+	systemHubsMu.RUnlock()
+	if hub != nil {
+		hub.publish(fromCSystemEvent(event))
+	}
+}
+
+// =============================================================================
+// L1 Streaming
+// =============================================================================
+
+// l1Subscription is a single SubscribeL1 consumer.
+type l1Subscription struct {
+	ptr    C.LxHandle
+	mu     sync.Mutex
+	ch     chan L1
+	closed bool
+}
+
+// push delivers an update without blocking, evicting the oldest buffered
+// update when the consumer has fallen behind.
+func (s *l1Subscription) push(l1 L1) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	for {
+		select {
+		case s.ch <- l1:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
+
+func (s *l1Subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// SubscribeL1 streams top-of-book changes for a market. It returns a
+// channel of L1 snapshots and a func that ends the subscription and closes
+// the channel.
+//
+// Backpressure: the channel buffers 64 updates. The engine never waits for
+// the consumer; if the buffer is full when a new update arrives, the oldest
+// buffered update is discarded to make room. A slow consumer therefore sees
+// gaps but always converges on the latest book state, which is what a ticker
+// needs. Consumers that must see every change should use
+// SetBookTradeListener instead.
+func (d *LX) SubscribeL1(marketID uint32) (<-chan L1, func()) {
+	sub := &l1Subscription{ptr: d.ptr, ch: make(chan L1, l1Buffer)}
+	if d.ptr == nil {
+		sub.close()
+		return sub.ch, func() {}
+	}
+
+	token := nextL1Token.Add(1)
+	l1SubsMu.Lock()
+	l1Subs[token] = sub
+	l1SubsMu.Unlock()
+
+	result := int32(C.lx_book_subscribe_l1(d.ptr, C.uint32_t(marketID), C.LxL1Callback(C.lxGoL1Callback), C.uint64_t(token)))
+	if result != 0 {
+		l1SubsMu.Lock()
+		delete(l1Subs, token)
+		l1SubsMu.Unlock()
+		sub.close()
+		return sub.ch, func() {}
+	}
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			l1SubsMu.Lock()
+			_, live := l1Subs[token]
+			delete(l1Subs, token)
+			l1SubsMu.Unlock()
+			if live {
+				C.lx_book_unsubscribe_l1(sub.ptr, C.uint64_t(token))
+			}
+			sub.close()
+		})
+	}
+}
+
+//export lxGoL1Callback
+func lxGoL1Callback(token C.uint64_t, marketID C.uint32_t, l1 *C.LxL1) {
+	l1SubsMu.RLock()
+	sub := l1Subs[uint64(token)]
+	l1SubsMu.RUnlock()
+	if sub != nil {
+		sub.push(fromCL1(*l1))
+	}
+}
+
+// =============================================================================
+// Journal
+// =============================================================================
+
+// JournalVersion is the journal format written by EnableJournal. Replay
+// rejects journals written in any other version.
+const JournalVersion uint32 = 1
+
+// Journal layout: 4-byte magic and big-endian uint32 version, then one
+// big-endian uint32 length prefix per engine record.
+const journalHeaderSize = 8
+
+var journalMagic = []byte("LXJN")
+
+var (
+	ErrInvalidJournal = errors.New("invalid journal")
+	ErrJournalVersion = errors.New("unsupported journal version")
+)
+
+// journal frames engine records onto a writer. After the first write error
+// every later record is dropped; the error is reported by JournalErr.
+type journal struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func (j *journal) write(p []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err == nil {
+		_, j.err = j.w.Write(p)
+	}
+}
+
+// EnableJournal appends a binary record to w for every state-changing
+// operation (pool initialization, swaps, fee changes and collections,
+// liquidity changes, order placement, amendment and cancellation, trigger
+// fills, trade busts, deposits, withdrawals, transfers, margin, leverage,
+// fee and funding changes, liquidations, lending, market configuration,
+// oracle and feed updates). Each record carries the engine timestamp and
+// sequence the operation ran with, so Replay reproduces results exactly.
+// Records are written in the order the operations took effect; while
+// journaling is on, those operations run one at a time. Passing nil stops
+// journaling.
+//
+// Records are written synchronously from the thread performing the
+// operation, so w should be buffered. Writes to w are serialized.
+func (d *LX) EnableJournal(w io.Writer) {
+	if d.ptr == nil {
+		return
+	}
+	if w == nil {
+		C.lx_set_journal_callback(d.ptr, nil, nil)
+		unregisterJournal(d.ptr)
+		return
+	}
+
+	j := &journal{w: w}
+	header := make([]byte, journalHeaderSize)
+	copy(header, journalMagic)
+	binary.BigEndian.PutUint32(header[4:], JournalVersion)
+	j.write(header)
+
+	journalsMu.Lock()
+	journals[d.ptr] = j
+	journalsMu.Unlock()
+
+	C.lx_set_journal_callback(d.ptr, C.LxJournalCallback(C.lxGoJournalCallback), unsafe.Pointer(d.ptr))
+}
+
+// JournalErr returns the first error writing the journal, if any. Records
+// after a failed write are lost, so a non-nil error means the journal
+// cannot be replayed to the current state.
+func (d *LX) JournalErr() error {
+	journalsMu.RLock()
+	j := journals[d.ptr]
+	journalsMu.RUnlock()
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+func unregisterJournal(ptr C.LxHandle) {
+	journalsMu.Lock()
+	delete(journals, ptr)
+	journalsMu.Unlock()
+}
+
+//export lxGoJournalCallback
+func lxGoJournalCallback(userData unsafe.Pointer, record *C.uint8_t, n C.size_t) {
+	journalsMu.RLock()
+	j := journals[C.LxHandle(userData)]
+	journalsMu.RUnlock()
+	if j == nil {
+		return
+	}
+	buf := make([]byte, 4+int(n))
+	binary.BigEndian.PutUint32(buf, uint32(n))
+	if n > 0 {
+		copy(buf[4:], unsafe.Slice((*byte)(unsafe.Pointer(record)), n))
+	}
+	j.write(buf)
+}
+
+// Replay builds a new, initialized and started LX instance by applying
+// every record of a journal written by EnableJournal. It fails if the
+// journal is malformed or a record does not reproduce its recorded result.
+func Replay(r io.Reader) (*LX, error) {
+	header := make([]byte, journalHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:4], journalMagic) {
+		return nil, ErrInvalidJournal
+	}
+	if binary.BigEndian.Uint32(header[4:]) != JournalVersion {
+		return nil, ErrJournalVersion
+	}
+
+	dex, err := New()
+	if err != nil {
+		return nil, err
+	}
+	dex.Initialize()
+	dex.Start()
+
+	var prefix [4]byte
+	var record []byte
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+			return dex, nil
+		} else if err != nil {
+			dex.Close()
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidJournal, i, err)
+		}
+		n := binary.BigEndian.Uint32(prefix[:])
+		if cap(record) < int(n) {
+			record = make([]byte, n)
+		}
+		record = record[:n]
+		if _, err := io.ReadFull(r, record); err != nil {
+			dex.Close()
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidJournal, i, err)
+		}
+
+		var recordPtr *C.uint8_t
+		if n > 0 {
+			recordPtr = (*C.uint8_t)(unsafe.Pointer(&record[0]))
+		}
+		if err := errorFromCode(int32(C.lx_journal_apply(dex.ptr, recordPtr, C.size_t(n)))); err != nil {
+			dex.Close()
+			return nil, fmt.Errorf("replay record %d: %w", i, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package lx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewCLOID returns a random (version 4) UUID for use as a client order ID.
+func NewCLOID() [16]byte {
+	var c [16]byte
+	if _, err := rand.Read(c[:]); err != nil {
+		panic("lx: reading random CLOID: " + err.Error())
+	}
+	c[6] = c[6]&0x0f | 0x40 // version 4
+	c[8] = c[8]&0x3f | 0x80 // RFC 4122 variant
+	return c
+}
+
+// CLOIDFromString parses a UUID in the canonical 8-4-4-4-12 hex form, in
+// either case.
+func CLOIDFromString(s string) ([16]byte, error) {
+	var c [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return c, fmt.Errorf("lx: CLOID %q is not in 8-4-4-4-12 form", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(c[:], []byte(digits)); err != nil {
+		return c, fmt.Errorf("lx: CLOID %q: %w", s, err)
+	}
+	return c, nil
+}
+
+// CLOIDString formats a client order ID as a lowercase canonical UUID.
+func CLOIDString(c [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], c[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], c[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], c[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], c[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:36], c[10:16])
+	return string(buf[:])
+}
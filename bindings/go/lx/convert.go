@@ -0,0 +1,136 @@
+package lx
+
+import (
+	"math"
+	"math/big"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+// The luxdex matching engine keeps prices and quantities as int64 scaled by
+// luxdex.PriceMultiplier (1e8); LX uses X18 (1e18). The converters live here
+// rather than in luxdex so the matching engine bindings do not depend on the
+// full stack.
+
+var bigFixed8ToX18 = big.NewInt(X18One / luxdex.PriceMultiplier)
+
+// PriceToX18 converts a luxdex price to X18. Every Price is exactly
+// representable, so no precision is lost.
+func PriceToX18(p luxdex.Price) X18 {
+	return x18FromFixed8(int64(p))
+}
+
+// X18ToPrice converts x to a luxdex price, truncating toward zero to 1e-8.
+// Values beyond the int64 range of Price (about ±9.2e10) saturate at
+// math.MaxInt64 or math.MinInt64.
+func X18ToPrice(x X18) luxdex.Price {
+	return luxdex.Price(x18ToFixed8(x))
+}
+
+// QuantityToX18 converts a luxdex quantity to X18 exactly.
+func QuantityToX18(q luxdex.Quantity) X18 {
+	return x18FromFixed8(int64(q))
+}
+
+// X18ToQuantity converts x to a luxdex quantity, truncating and saturating
+// as X18ToPrice does.
+func X18ToQuantity(x X18) luxdex.Quantity {
+	return luxdex.Quantity(x18ToFixed8(x))
+}
+
+// OrderFromLuxdex converts a matching-engine order to an LX order for
+// marketID. The conversion is exact for prices and sizes, but some fields
+// have no LX equivalent:
+//
+//   - FOK becomes IOC, so the order may fill partially.
+//   - GTD and DAY become GTC; the expiry is dropped.
+//   - ID, AccountID, Filled, Status, timestamps, DisplayQty and
+//     MaxSlippageBps are not carried over. SizeX18 is the full Quantity.
+func OrderFromLuxdex(o luxdex.Order, marketID uint32) Order {
+	lo := Order{
+		MarketID:     marketID,
+		IsBuy:        o.Side == luxdex.SideBuy,
+		SizeX18:      QuantityToX18(o.Quantity),
+		LimitPxX18:   PriceToX18(o.Price),
+		TriggerPxX18: PriceToX18(o.StopPrice),
+		STPGroup:     o.STPGroup,
+	}
+	switch o.Type {
+	case luxdex.OrderTypeMarket:
+		lo.Kind = OrderMarket
+	case luxdex.OrderTypeStop:
+		lo.Kind = OrderStopMarket
+	case luxdex.OrderTypeStopLimit:
+		lo.Kind = OrderStopLimit
+	default:
+		lo.Kind = OrderLimit
+	}
+	switch o.TIF {
+	case luxdex.TifIOC, luxdex.TifFOK:
+		lo.TIF = TifIOC
+	case luxdex.TifPostOnly:
+		lo.TIF = TifALO
+	default:
+		lo.TIF = TifGTC
+	}
+	return lo
+}
+
+// ToLuxdex converts the order to a matching-engine order for symbolID.
+// Prices and sizes are truncated toward zero to 1e-8 and saturate at the
+// int64 range. Some fields have no luxdex equivalent:
+//
+//   - Take-profit orders become stop orders, which trigger on the opposite
+//     side of the price: OrderTakeMarket maps to OrderTypeStop and
+//     OrderTakeLimit to OrderTypeStopLimit.
+//   - ReduceOnly and CLOID are dropped.
+//
+// The result is a new order: ID, AccountID and Timestamp are left for the
+// caller to set.
+func (o Order) ToLuxdex(symbolID uint64) luxdex.Order {
+	do := luxdex.Order{
+		SymbolID:  symbolID,
+		Side:      luxdex.SideSell,
+		Price:     X18ToPrice(o.LimitPxX18),
+		Quantity:  X18ToQuantity(o.SizeX18),
+		StopPrice: X18ToPrice(o.TriggerPxX18),
+		STPGroup:  o.STPGroup,
+	}
+	if o.IsBuy {
+		do.Side = luxdex.SideBuy
+	}
+	switch o.Kind {
+	case OrderMarket:
+		do.Type = luxdex.OrderTypeMarket
+	case OrderStopMarket, OrderTakeMarket:
+		do.Type = luxdex.OrderTypeStop
+	case OrderStopLimit, OrderTakeLimit:
+		do.Type = luxdex.OrderTypeStopLimit
+	default:
+		do.Type = luxdex.OrderTypeLimit
+	}
+	switch o.TIF {
+	case TifIOC:
+		do.TIF = luxdex.TifIOC
+	case TifALO:
+		do.TIF = luxdex.TifPostOnly
+	default:
+		do.TIF = luxdex.TifGTC
+	}
+	return do
+}
+
+func x18FromFixed8(v int64) X18 {
+	return x18FromBig(new(big.Int).Mul(big.NewInt(v), bigFixed8ToX18))
+}
+
+func x18ToFixed8(x X18) int64 {
+	q := new(big.Int).Quo(x.big(), bigFixed8ToX18)
+	if !q.IsInt64() {
+		if q.Sign() < 0 {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	return q.Int64()
+}
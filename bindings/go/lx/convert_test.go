@@ -0,0 +1,90 @@
+package lx
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	luxdex "github.com/luxcpp/dex/bindings/go"
+)
+
+func TestPriceX18Conversion(t *testing.T) {
+	// Every Price survives the trip through X18
+	for _, p := range []luxdex.Price{0, 1, -1, 12_345_678_901, luxdex.PriceFromFloat(50000.5), math.MaxInt64, math.MinInt64} {
+		x := PriceToX18(p)
+		if back := X18ToPrice(x); back != p {
+			t.Errorf("X18ToPrice(PriceToX18(%d)) = %d", p, back)
+		}
+		if q := luxdex.Quantity(p); X18ToQuantity(QuantityToX18(q)) != q {
+			t.Errorf("quantity %d did not round trip", q)
+		}
+	}
+	if got := PriceToX18(luxdex.PriceFromFloat(1.5)); got != X18FromFloat(1.5) {
+		t.Errorf("PriceToX18(1.5) = %f", got.ToFloat())
+	}
+
+	tests := []struct {
+		name string
+		x    X18
+		want luxdex.Price
+	}{
+		{"below 1e-8 truncates", X18{Lo: 9_999_999_999}, 0},
+		{"negative truncates toward zero", X18{Lo: -19_999_999_999, Hi: -1}, -1},
+		{"past int64 saturates", X18FromInt(1e11), math.MaxInt64},
+		{"below int64 saturates", X18FromInt(-1e11), math.MinInt64},
+		{"largest X18 saturates", x18FromBig(bigMaxX18), math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := X18ToPrice(tt.x); got != tt.want {
+			t.Errorf("%s: X18ToPrice = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrderLuxdexRoundTrip(t *testing.T) {
+	dexOrders := []luxdex.Order{
+		{SymbolID: 7, Side: luxdex.SideBuy, Type: luxdex.OrderTypeLimit, TIF: luxdex.TifGTC,
+			Price: luxdex.PriceFromFloat(101.25), Quantity: 123_456_789, STPGroup: 3},
+		{SymbolID: 7, Side: luxdex.SideSell, Type: luxdex.OrderTypeMarket, TIF: luxdex.TifIOC,
+			Quantity: luxdex.QuantityFromFloat(2)},
+		{SymbolID: 7, Side: luxdex.SideSell, Type: luxdex.OrderTypeStopLimit, TIF: luxdex.TifPostOnly,
+			Price: luxdex.PriceFromFloat(95), StopPrice: luxdex.PriceFromFloat(96), Quantity: 1},
+	}
+	for _, o := range dexOrders {
+		lo := OrderFromLuxdex(o, 4)
+		if lo.MarketID != 4 {
+			t.Errorf("MarketID = %d, want 4", lo.MarketID)
+		}
+		if back := lo.ToLuxdex(7); !reflect.DeepEqual(back, o) {
+			t.Errorf("round trip of %+v = %+v", o, back)
+		}
+	}
+
+	limit := OrderFromLuxdex(dexOrders[0], 4)
+	if limit.LimitPxX18 != X18FromFloat(101.25) || limit.SizeX18 != (X18{Lo: 1_234_567_890_000_000_000}) {
+		t.Errorf("scaled price and size = %f, %f", limit.LimitPxX18.ToFloat(), limit.SizeX18.ToFloat())
+	}
+
+	lxOrders := []Order{
+		{MarketID: 4, IsBuy: true, Kind: OrderStopMarket, TIF: TifGTC,
+			SizeX18: X18FromInt(3), TriggerPxX18: X18FromInt(110)},
+		{MarketID: 4, Kind: OrderLimit, TIF: TifALO, SizeX18: X18FromFloat(0.5), LimitPxX18: X18FromInt(99)},
+	}
+	for _, o := range lxOrders {
+		if back := OrderFromLuxdex(o.ToLuxdex(7), 4); !reflect.DeepEqual(back, o) {
+			t.Errorf("round trip of %+v = %+v", o, back)
+		}
+	}
+
+	// Lossy cases
+	fok := dexOrders[0]
+	fok.TIF = luxdex.TifFOK
+	if got := OrderFromLuxdex(fok, 4).TIF; got != TifIOC {
+		t.Errorf("FOK maps to TIF %d, want IOC", got)
+	}
+	take := Order{Kind: OrderTakeLimit, SizeX18: X18FromFloat(1e-10), LimitPxX18: X18FromInt(1)}
+	got := take.ToLuxdex(7)
+	if got.Type != luxdex.OrderTypeStopLimit || got.Quantity != 0 {
+		t.Errorf("take-limit with dust size = %v, %d, want stop-limit with zero quantity", got.Type, got.Quantity)
+	}
+}
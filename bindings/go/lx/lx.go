@@ -9,6 +9,8 @@
 //   - LXBook (LP-9020): CLOB matching engine
 //   - LXVault (LP-9030): Margin/custody clearinghouse
 //   - LXFeed (LP-9040): Mark/funding price feeds
+//   - LXLend (LP-9050): Lending pool
+//   - LXLiquid (LP-9060): Self-repaying loans
 package lx
 
 /*
@@ -20,8 +22,13 @@ package lx
 */
 import "C"
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -29,11 +36,9 @@ import (
 // Constants
 // =============================================================================
 
-// X18One is 1.0 in X18 fixed-point (1e18)
-const X18One int64 = 1_000_000_000_000_000_000
-
-// AddressSize is the byte length of an address (20 bytes)
-const AddressSize = 20
+// DefaultOracleStaleness is the freshness window, in seconds, for assets
+// without a per-asset staleness threshold.
+const DefaultOracleStaleness uint32 = 60
 
 // Error codes
 var (
@@ -46,11 +51,34 @@ var (
 	ErrInvalidCurrency        = errors.New("invalid currency")
 	ErrCurrenciesNotSorted    = errors.New("currencies not sorted")
 	ErrInvalidFee             = errors.New("invalid fee")
+	ErrNotDynamicFee          = errors.New("pool fee is not dynamic")
 	ErrInsufficientBalance    = errors.New("insufficient balance")
 	ErrInsufficientMargin     = errors.New("insufficient margin")
 	ErrPositionNotFound       = errors.New("position not found")
 	ErrOrderNotFound          = errors.New("order not found")
+	ErrAmendRejected          = errors.New("order amendment rejected")
 	ErrMarketNotFound         = errors.New("market not found")
+	ErrTradeNotFound          = errors.New("trade not found")
+	ErrPositionOpen           = errors.New("position open")
+	ErrInvalidLeverage        = errors.New("invalid leverage")
+	ErrMarketNotEmpty         = errors.New("market has open orders")
+	ErrMarketHalted           = errors.New("market halted")
+	ErrDraining               = errors.New("lx is draining")
+	ErrInvalidPrice           = errors.New("invalid price")
+	ErrPriceDeviation         = errors.New("price update tripped circuit breaker")
+	ErrSourceUnavailable      = errors.New("oracle source unavailable")
+	ErrUnauthorized           = errors.New("unauthorized")
+	ErrHookNotRegistered      = errors.New("hook not registered")
+	ErrInvalidHookFlags       = errors.New("invalid hook flags")
+	ErrBufferTooSmall         = errors.New("buffer too small")
+	ErrNoPriceHistory         = errors.New("no price history")
+	ErrNotArmed               = errors.New("cancel-on-disconnect not armed")
+	ErrOrderTooSmall          = errors.New("order below minimum size")
+	ErrOrderTooLarge          = errors.New("order above maximum size")
+	ErrPositionLimit          = errors.New("order would exceed maximum position size")
+	ErrReduceOnly             = errors.New("order would not reduce position")
+	ErrMarketRestricted       = errors.New("order not allowed in market status")
+	ErrOrderRejected          = errors.New("order rejected")
 )
 
 // Fee tiers (in hundredths of a bip)
@@ -59,24 +87,39 @@ const (
 	Fee005 uint32 = 500   // 0.05%
 	Fee030 uint32 = 3000  // 0.30%
 	Fee100 uint32 = 10000 // 1.00%
+
+	// DynamicFeeFlag as a PoolKey fee marks a pool whose LP fee is set at
+	// runtime with PoolSetDynamicFee. It starts at zero.
+	DynamicFeeFlag uint32 = 0x800000
+
+	// MaxProtocolFeeFraction caps each protocol fee at 1/4 of the LP fee.
+	MaxProtocolFeeFraction uint32 = 4
+)
+
+// Hook flags declare which pool callbacks a hook contract implements.
+const (
+	HookBeforeInitialize                 uint32 = 1 << 13
+	HookAfterInitialize                  uint32 = 1 << 12
+	HookBeforeAddLiquidity               uint32 = 1 << 11
+	HookAfterAddLiquidity                uint32 = 1 << 10
+	HookBeforeRemoveLiquidity            uint32 = 1 << 9
+	HookAfterRemoveLiquidity             uint32 = 1 << 8
+	HookBeforeSwap                       uint32 = 1 << 7
+	HookAfterSwap                        uint32 = 1 << 6
+	HookBeforeDonate                     uint32 = 1 << 5
+	HookAfterDonate                      uint32 = 1 << 4
+	HookBeforeSwapReturnsDelta           uint32 = 1 << 3
+	HookAfterSwapReturnsDelta            uint32 = 1 << 2
+	HookAfterAddLiquidityReturnsDelta    uint32 = 1 << 1
+	HookAfterRemoveLiquidityReturnsDelta uint32 = 1 << 0
+
+	HookAllFlags uint32 = 1<<14 - 1
 )
 
 // =============================================================================
 // Types
 // =============================================================================
 
-// Address is a 20-byte Ethereum-style address.
-type Address [AddressSize]byte
-
-// Currency represents a token address.
-type Currency = Address
-
-// X18 is a 128-bit fixed-point number with 18 decimal places.
-type X18 struct {
-	Lo int64
-	Hi int64
-}
-
 // Account identifies a trading account (main address + subaccount).
 type Account struct {
 	Main         Address
@@ -92,6 +135,32 @@ type PoolKey struct {
 	Hooks       Address
 }
 
+// Validate checks the key the way pool initialization does: currencies
+// sorted bytewise, a standard fee tier or DynamicFeeFlag and a positive
+// tick spacing.
+func (k PoolKey) Validate() error {
+	if bytes.Compare(k.Currency0[:], k.Currency1[:]) >= 0 {
+		return ErrCurrenciesNotSorted
+	}
+	switch k.Fee {
+	case Fee001, Fee005, Fee030, Fee100, DynamicFeeFlag:
+	default:
+		return ErrInvalidFee
+	}
+	if k.TickSpacing <= 0 {
+		return ErrInvalidTickRange
+	}
+	return nil
+}
+
+// SortCurrencies returns a and b in pool key order.
+func SortCurrencies(a, b Currency) (Currency, Currency) {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
 // BalanceDelta represents signed token amount changes.
 type BalanceDelta struct {
 	Amount0 X18
@@ -113,25 +182,23 @@ type ModifyLiquidityParams struct {
 	Salt           uint64
 }
 
-// TIF is the time-in-force for an order.
-type TIF uint8
+// Side is the direction of a taker order. The values match the luxdex
+// engine's sides.
+type Side uint8
 
 const (
-	TifGTC TIF = 0 // Good Till Cancel
-	TifIOC TIF = 1 // Immediate Or Cancel
-	TifALO TIF = 2 // Add Liquidity Only (post-only)
+	SideBuy  Side = 0 // Takes from the asks
+	SideSell Side = 1 // Takes from the bids
 )
 
-// OrderKind is the type of order.
-type OrderKind uint8
-
+// Market statuses for BookMarketConfig.Status and BookSetMarketStatus.
+// Cancels are accepted in every status.
 const (
-	OrderLimit      OrderKind = 0
-	OrderMarket     OrderKind = 1
-	OrderStopMarket OrderKind = 2
-	OrderStopLimit  OrderKind = 3
-	OrderTakeMarket OrderKind = 4
-	OrderTakeLimit  OrderKind = 5
+	MarketHalted     uint8 = 0 // No new orders
+	MarketActive     uint8 = 1
+	MarketCancelOnly uint8 = 2 // No new orders; resting orders can be cancelled
+	MarketPostOnly   uint8 = 3 // Only resting limits; crossing ones are rejected
+	MarketLimitOnly  uint8 = 4 // Only limit orders
 )
 
 // MarginMode is the margin mode for a position.
@@ -159,6 +226,16 @@ const (
 	StatusFilled    OrderStatus = 2
 	StatusCancelled OrderStatus = 3
 	StatusRejected  OrderStatus = 4
+	StatusExpired   OrderStatus = 5
+	StatusTriggered OrderStatus = 6
+)
+
+// TriggerDir is the direction the pool price must cross to fire a trigger.
+type TriggerDir uint8
+
+const (
+	TriggerBelow TriggerDir = 0 // Fire when price <= trigger price
+	TriggerAbove TriggerDir = 1 // Fire when price >= trigger price
 )
 
 // PriceType is the type of price.
@@ -185,19 +262,46 @@ const (
 	SourcePyth      PriceSource = 7
 )
 
-// Order represents an order to place on the CLOB.
-type Order struct {
-	MarketID     uint32
-	IsBuy        bool
-	Kind         OrderKind
-	SizeX18      X18
-	LimitPxX18   X18
-	TriggerPxX18 X18
-	ReduceOnly   bool
-	TIF          TIF
-	CLOID        [16]byte // Client order ID (UUID)
+// OracleUpdate is one source price in an OracleUpdatePrices batch.
+type OracleUpdate struct {
+	AssetID    uint64
+	Source     PriceSource
+	Price      X18
+	Confidence X18
+}
+
+// OracleUpdateError reports the rejected entries of an OracleUpdatePrices
+// batch. Errs has one element per update, nil where it was applied.
+type OracleUpdateError struct {
+	Errs []error
+}
+
+func (e *OracleUpdateError) Error() string {
+	failed, first := 0, -1
+	for i, err := range e.Errs {
+		if err != nil {
+			failed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return fmt.Sprintf("lx: %d oracle updates, none failed", len(e.Errs))
+	}
+	return fmt.Sprintf("lx: %d of %d oracle updates failed, first at %d: %v",
+		failed, len(e.Errs), first, e.Errs[first])
 }
 
+// AggregationMode selects how the oracle combines source prices.
+type AggregationMode uint8
+
+const (
+	AggMedian                 AggregationMode = 0 // Median of fresh sources
+	AggMeanConfidenceWeighted AggregationMode = 5 // Mean weighted by inverse confidence
+	AggLastKnownGood          AggregationMode = 6 // Newest submission, served even once stale
+)
+
 // PlaceResult is the result of placing an order.
 type PlaceResult struct {
 	OID           uint64
@@ -206,6 +310,39 @@ type PlaceResult struct {
 	AvgPxX18      X18
 }
 
+// BookOrder is the live state of an order on the book.
+type BookOrder struct {
+	OID              uint64
+	CLOID            [16]byte
+	MarketID         uint32
+	IsBuy            bool
+	Kind             OrderKind
+	TIF              TIF
+	OriginalSizeX18  X18
+	RemainingSizeX18 X18
+	FilledSizeX18    X18
+	LimitPxX18       X18
+	TriggerPxX18     X18
+	AvgFillPxX18     X18
+	Status           OrderStatus
+	CreatedAt        uint64
+	UpdatedAt        uint64
+}
+
+// Trade is an execution between a resting (maker) and incoming (taker) order.
+type Trade struct {
+	TradeID    uint64
+	MarketID   uint32
+	MakerOID   uint64
+	TakerOID   uint64
+	Maker      Account
+	Taker      Account
+	TakerIsBuy bool
+	PxX18      X18
+	SzX18      X18
+	Timestamp  uint64
+}
+
 // L1 is Level-1 market data (best bid/ask).
 type L1 struct {
 	BestBidPxX18   X18
@@ -215,6 +352,31 @@ type L1 struct {
 	LastTradePxX18 X18
 }
 
+// BookLevel is an aggregated price level in the order book.
+type BookLevel struct {
+	PxX18      X18
+	SzX18      X18
+	OrderCount uint32
+}
+
+// BookDepth is Level-2 market data (aggregated levels on both sides).
+// Bids are sorted best (highest) first, asks best (lowest) first.
+type BookDepth struct {
+	Bids      []BookLevel
+	Asks      []BookLevel
+	Timestamp uint64
+}
+
+// MarketStats holds activity counters for a single book market.
+// OpenOrders is the number of orders currently resting on the book.
+type MarketStats struct {
+	OrdersPlaced    uint64
+	OrdersCancelled uint64
+	Trades          uint64
+	VolumeX18       X18
+	OpenOrders      uint64
+}
+
 // Position represents an open position.
 type Position struct {
 	MarketID              uint32
@@ -236,6 +398,14 @@ type MarginInfo struct {
 	Liquidatable         bool
 }
 
+// AccountPnL is the lifetime PnL and fee accounting of an account.
+type AccountPnL struct {
+	RealizedPnlX18   X18
+	UnrealizedPnlX18 X18 // Summed across open positions
+	FeesPaidX18      X18
+	FundingX18       X18 // Net funding; negative when paid
+}
+
 // MarkPrice contains mark price information.
 type MarkPrice struct {
 	IndexPxX18 X18
@@ -250,6 +420,35 @@ type FundingRate struct {
 	NextFundingTime uint64
 }
 
+// FeedPrices is every price the feed computes for a market, read at once.
+// Prices not yet available are zero: the index, mark and premium until the
+// oracle has a price, last until a trade, mid until both sides are quoted.
+type FeedPrices struct {
+	IndexPxX18      X18
+	MarkPxX18       X18
+	LastPxX18       X18
+	MidPxX18        X18
+	PremiumX18      X18 // MarkPxX18 - IndexPxX18
+	FundingRateX18  X18
+	NextFundingTime uint64
+	Timestamp       uint64
+}
+
+// FundingSample is one calculated funding rate.
+type FundingSample struct {
+	RateX18   X18
+	Timestamp uint64
+}
+
+// FundingConfig is a market's funding cadence. ClampBps caps the absolute
+// funding rate per interval; annualize a rate by scaling it by the number
+// of intervals per year.
+type FundingConfig struct {
+	IntervalSecs    uint32
+	ClampBps        uint32
+	NextFundingTime uint64
+}
+
 // MarketConfig configures a perpetual market for the vault.
 type MarketConfig struct {
 	MarketID             uint32
@@ -266,19 +465,122 @@ type MarketConfig struct {
 	Active               bool
 }
 
-// BookMarketConfig configures a market for the order book.
-type BookMarketConfig struct {
-	MarketID        uint32
-	SymbolID        uint64
-	BaseCurrency    Currency
-	QuoteCurrency   Currency
-	TickSizeX18     X18
-	LotSizeX18      X18
-	MinNotionalX18  X18
-	MaxOrderSizeX18 X18
-	PostOnlyMode    bool
-	ReduceOnlyMode  bool
-	Status          uint8
+// LiquidLoan is a self-repaying loan. Yield earned on the collateral is
+// applied to the outstanding principal until the loan is repaid.
+type LiquidLoan struct {
+	ID              uint64
+	Owner           Account
+	Collateral      Currency
+	CollateralX18   X18 // Collateral amount deposited
+	PrincipalX18    X18 // Outstanding debt
+	YieldAppliedX18 X18 // Accrued yield applied to repayment so far
+	HealthX18       X18 // Collateral value / debt; zero once repaid
+	OpenedAt        uint64
+}
+
+// TriggeredSwap is a trigger swap executed by PoolExecuteTriggers.
+type TriggeredSwap struct {
+	TriggerID uint64
+	Owner     Account
+	Delta     BalanceDelta
+}
+
+// TickInfo is the liquidity recorded at an initialized pool tick.
+// LiquidityNet is added to active liquidity when the price crosses the
+// tick upwards and subtracted when it crosses downwards.
+type TickInfo struct {
+	Tick           int32
+	LiquidityGross X18
+	LiquidityNet   X18
+}
+
+// PoolPosition is an LP position. TokensOwed0/1 are the swap fees credited
+// to the position when its liquidity was last modified and not yet
+// collected.
+type PoolPosition struct {
+	Liquidity   X18
+	TokensOwed0 X18
+	TokensOwed1 X18
+}
+
+// Severity grades a SystemEvent for alerting.
+type Severity uint8
+
+const (
+	SeverityInfo     Severity = 0
+	SeverityWarning  Severity = 1
+	SeverityCritical Severity = 2
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// SystemEventKind identifies the subsystem condition behind a SystemEvent.
+type SystemEventKind uint8
+
+const (
+	EventOrderRejected         SystemEventKind = 0 // An order failed validation or risk checks
+	EventMarketHalted          SystemEventKind = 1 // A market entered a non-trading status
+	EventLiquidation           SystemEventKind = 2 // An account was liquidated
+	EventInsuranceFundDraw     SystemEventKind = 3 // The insurance fund covered a deficit
+	EventOraclePriceStale      SystemEventKind = 4 // An asset was used without a fresh price
+	EventCircuitBreakerTripped SystemEventKind = 5 // A price moved beyond its circuit breaker
+	EventTradeBusted           SystemEventKind = 6 // An operator busted a trade
+)
+
+// SystemEvent is an operational event from any LX subsystem. Fields that do
+// not apply to the event's Kind are zero.
+type SystemEvent struct {
+	Kind      SystemEventKind
+	Severity  Severity
+	MarketID  uint32
+	AssetID   uint64
+	Account   Account
+	Err       error // Underlying error, if the event reports one
+	Timestamp uint64
+}
+
+// StakingFeeTier grants a fee discount to accounts whose staked balance is at
+// least MinStakedX18. Discounts are fractions of the base fee (0.5 = 50%).
+type StakingFeeTier struct {
+	MinStakedX18     X18
+	TakerDiscountX18 X18
+	MakerDiscountX18 X18
+}
+
+// HealthStatus is the readiness data returned by Health. OldestStaleAsset
+// is the oracle asset whose price has gone longest without an update; it is
+// only meaningful when StaleAssetCount is nonzero.
+type HealthStatus struct {
+	Running          bool
+	OldestStaleAsset uint64
+	StaleAssetCount  int
+	OpenOrders       uint64
+}
+
+// Ready reports whether the engine is running with every oracle asset
+// fresh. A running engine with stale assets is degraded: it still matches,
+// but mark prices, funding and liquidations may rest on old data.
+func (h HealthStatus) Ready() bool {
+	return h.Running && h.StaleAssetCount == 0
+}
+
+// BuildInfo describes the build of the C library.
+type BuildInfo struct {
+	Version   string
+	GitCommit string
+	BuildDate string   // ISO 8601 UTC
+	Features  []string // e.g. "pool", "book", "debug"
 }
 
 // GlobalStats contains global DEX statistics.
@@ -347,45 +649,6 @@ func (a Address) IsDEXPrecompile() bool {
 	return a[17] == 0 && (a[18]&0xF0) == 0x90
 }
 
-// =============================================================================
-// X18 Arithmetic
-// =============================================================================
-
-// X18Zero returns zero.
-func X18Zero() X18 {
-	return X18{Lo: 0, Hi: 0}
-}
-
-// X18FromInt creates an X18 from an integer.
-func X18FromInt(v int64) X18 {
-	return fromCX18(C.lx_from_int(C.int64_t(v)))
-}
-
-// X18FromFloat creates an X18 from a float64.
-func X18FromFloat(v float64) X18 {
-	return fromCX18(C.lx_from_double(C.double(v)))
-}
-
-// ToInt converts X18 to int64 (truncates decimals).
-func (x X18) ToInt() int64 {
-	return int64(C.lx_to_int(toCX18(x)))
-}
-
-// ToFloat converts X18 to float64.
-func (x X18) ToFloat() float64 {
-	return float64(C.lx_to_double(toCX18(x)))
-}
-
-// IsZero returns true if the value is zero.
-func (x X18) IsZero() bool {
-	return x.Lo == 0 && x.Hi == 0
-}
-
-// IsNegative returns true if the value is negative.
-func (x X18) IsNegative() bool {
-	return x.Hi < 0
-}
-
 // =============================================================================
 // LX Controller
 // =============================================================================
@@ -409,6 +672,7 @@ func New() (*LX, error) {
 // Close releases the LX resources.
 func (d *LX) Close() {
 	if d.ptr != nil {
+		unregisterCallbacks(d.ptr)
 		C.lx_destroy(d.ptr)
 		d.ptr = nil
 	}
@@ -436,6 +700,41 @@ func (d *LX) Stop() {
 	}
 }
 
+// drainPollInterval is how often Drain checks for queued matching work.
+const drainPollInterval = time.Millisecond
+
+// Drain prepares the DEX for Stop. It makes every new placement fail with
+// ErrDraining, leaving cancels working, then waits until the matching
+// engine has no queued or in-flight async orders. If ctx is done first
+// Drain returns ctx.Err(); placements stay rejected either way until the
+// next Start.
+func (d *LX) Drain(ctx context.Context) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if err := errorFromCode(int32(C.lx_begin_drain(d.ptr))); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for C.lx_pending_orders(d.ptr) != 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// IsDraining reports whether Drain has been called since the last Start.
+func (d *LX) IsDraining() bool {
+	if d.ptr == nil {
+		return false
+	}
+	return bool(C.lx_is_draining(d.ptr))
+}
+
 // IsRunning returns true if the DEX is running.
 func (d *LX) IsRunning() bool {
 	if d.ptr == nil {
@@ -449,6 +748,40 @@ func Version() string {
 	return C.GoString(C.lx_version())
 }
 
+// Health returns probe-friendly readiness data in one call: whether the
+// engine is running, which registered oracle assets are past their max
+// staleness (an asset that has never been priced counts as stale) and the
+// number of orders resting across all markets.
+func (d *LX) Health() HealthStatus {
+	if d.ptr == nil {
+		return HealthStatus{}
+	}
+	h := C.lx_health(d.ptr)
+	return HealthStatus{
+		Running:          bool(h.running),
+		OldestStaleAsset: uint64(h.oldest_stale_asset),
+		StaleAssetCount:  int(h.stale_asset_count),
+		OpenOrders:       uint64(h.open_orders),
+	}
+}
+
+// BuildInfo returns the version, git commit, build date and compiled-in
+// features of the C library the binding is linked against. Fields the build
+// did not record read "unknown".
+func (d *LX) BuildInfo() BuildInfo {
+	info := C.lx_build_info()
+	var features []string
+	if s := C.GoString(info.features); s != "" {
+		features = strings.Split(s, ",")
+	}
+	return BuildInfo{
+		Version:   C.GoString(info.version),
+		GitCommit: C.GoString(info.git_commit),
+		BuildDate: C.GoString(info.build_date),
+		Features:  features,
+	}
+}
+
 // GetStats returns global DEX statistics.
 func (d *LX) GetStats() GlobalStats {
 	if d.ptr == nil {
@@ -475,10 +808,23 @@ func (d *LX) GetStats() GlobalStats {
 // =============================================================================
 
 // PoolInitialize initializes a new AMM pool.
+// If the key names a hook contract, it must be registered with valid flags.
 func (d *LX) PoolInitialize(key PoolKey, sqrtPriceX96 X18) (int32, error) {
 	if d.ptr == nil {
 		return 0, errors.New("LX not initialized")
 	}
+	if err := key.Validate(); err != nil {
+		return 0, err
+	}
+	if key.Hooks != (Address{}) {
+		flags, ok := d.HooksGetFlags(key.Hooks)
+		if !ok {
+			return 0, ErrHookNotRegistered
+		}
+		if err := validateHookFlags(flags); err != nil {
+			return 0, err
+		}
+	}
 	cKey := toCPoolKey(key)
 	result := int32(C.lx_pool_initialize(d.ptr, &cKey, toCX18(sqrtPriceX96)))
 	return result, errorFromCode(result)
@@ -486,15 +832,104 @@ func (d *LX) PoolInitialize(key PoolKey, sqrtPriceX96 X18) (int32, error) {
 
 // PoolSwap executes a swap on an AMM pool.
 func (d *LX) PoolSwap(key PoolKey, params SwapParams) (BalanceDelta, error) {
+	return d.PoolSwapContext(context.Background(), key, params)
+}
+
+// PoolSwapContext is PoolSwap with a context. The context is checked before
+// the swap starts; a swap already in progress runs to completion.
+func (d *LX) PoolSwapContext(ctx context.Context, key PoolKey, params SwapParams) (BalanceDelta, error) {
 	if d.ptr == nil {
 		return BalanceDelta{}, errors.New("LX not initialized")
 	}
+	if err := ctx.Err(); err != nil {
+		return BalanceDelta{}, err
+	}
 	cKey := toCPoolKey(key)
 	cParams := toCSwapParams(params)
 	result := C.lx_pool_swap(d.ptr, &cKey, &cParams)
 	return fromCBalanceDelta(result), nil
 }
 
+// PoolQuoteSwap simulates a swap without executing it and returns the
+// balance delta the swap would produce. This is the strict quote: if the
+// pool cannot satisfy the full amount (for example an exact-output swap
+// larger than available liquidity) it returns ErrInsufficientLiquidity.
+func (d *LX) PoolQuoteSwap(key PoolKey, params SwapParams) (BalanceDelta, error) {
+	delta, _, err := d.poolQuoteSwap(key, params, false)
+	return delta, err
+}
+
+// PoolQuoteSwapPartial simulates a swap like PoolQuoteSwap, but when the
+// pool cannot satisfy the full amount it quotes the largest fill available
+// (the maximum output and the input it would consume, up to the price
+// limit) instead of failing. full reports whether the requested amount
+// could be filled completely.
+func (d *LX) PoolQuoteSwapPartial(key PoolKey, params SwapParams) (delta BalanceDelta, full bool, err error) {
+	return d.poolQuoteSwap(key, params, true)
+}
+
+func (d *LX) poolQuoteSwap(key PoolKey, params SwapParams, allowPartial bool) (BalanceDelta, bool, error) {
+	if d.ptr == nil {
+		return BalanceDelta{}, false, errors.New("LX not initialized")
+	}
+	cKey := toCPoolKey(key)
+	cParams := toCSwapParams(params)
+	var cDelta C.LxBalanceDelta
+	var cFull C.bool
+	result := int32(C.lx_pool_quote_swap(d.ptr, &cKey, &cParams, C.bool(allowPartial), &cDelta, &cFull))
+	if err := errorFromCode(result); err != nil {
+		return BalanceDelta{}, false, err
+	}
+	return fromCBalanceDelta(cDelta), bool(cFull), nil
+}
+
+// PoolPlaceTriggerSwap registers a swap that executes once the pool price
+// (currency1 per currency0) crosses triggerPrice in the given direction.
+// Triggers do not fire on their own; a keeper calls PoolExecuteTriggers.
+func (d *LX) PoolPlaceTriggerSwap(owner Account, key PoolKey, params SwapParams, triggerPrice X18, direction TriggerDir) (triggerID uint64, err error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+	cOwner := toCAccount(owner)
+	cKey := toCPoolKey(key)
+	cParams := toCSwapParams(params)
+	var cID C.uint64_t
+	result := int32(C.lx_pool_place_trigger_swap(d.ptr, &cOwner, &cKey, &cParams, toCX18(triggerPrice), C.uint8_t(direction), &cID))
+	if err := errorFromCode(result); err != nil {
+		return 0, err
+	}
+	return uint64(cID), nil
+}
+
+// PoolExecuteTriggers executes every trigger swap on the pool whose
+// condition holds at the current price, in registration order, and returns
+// the swaps that fired. Each executed swap moves the price before the next
+// trigger is evaluated. A trigger whose swap moves nothing stays registered.
+func (d *LX) PoolExecuteTriggers(key PoolKey) ([]TriggeredSwap, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	cKey := toCPoolKey(key)
+	var cSwaps *C.LxTriggeredSwap
+	var count C.size_t
+	result := int32(C.lx_pool_execute_triggers(d.ptr, &cKey, &cSwaps, &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_triggered_swaps_free(cSwaps)
+	swaps := make([]TriggeredSwap, count)
+	if count > 0 {
+		for i, c := range unsafe.Slice(cSwaps, count) {
+			swaps[i] = TriggeredSwap{
+				TriggerID: uint64(c.trigger_id),
+				Owner:     fromCAccount(c.owner),
+				Delta:     fromCBalanceDelta(c.delta),
+			}
+		}
+	}
+	return swaps, nil
+}
+
 // PoolModifyLiquidity adds or removes liquidity from a pool.
 func (d *LX) PoolModifyLiquidity(key PoolKey, params ModifyLiquidityParams) (BalanceDelta, error) {
 	if d.ptr == nil {
@@ -520,6 +955,25 @@ func (d *LX) PoolExists(key PoolKey) bool {
 	return bool(C.lx_pool_exists(d.ptr, &cKey))
 }
 
+// PoolList returns the key of every initialized pool, in no particular
+// order.
+func (d *LX) PoolList() []PoolKey {
+	if d.ptr == nil {
+		return nil
+	}
+	var cKeys *C.LxPoolKey
+	var count C.size_t
+	C.lx_pool_list(d.ptr, &cKeys, &count)
+	defer C.lx_pool_keys_free(cKeys)
+	keys := make([]PoolKey, count)
+	if count > 0 {
+		for i, c := range unsafe.Slice(cKeys, count) {
+			keys[i] = fromCPoolKey(c)
+		}
+	}
+	return keys
+}
+
 // PoolGetLiquidity returns the total liquidity in a pool.
 func (d *LX) PoolGetLiquidity(key PoolKey) X18 {
 	if d.ptr == nil {
@@ -529,106 +983,671 @@ func (d *LX) PoolGetLiquidity(key PoolKey) X18 {
 	return fromCX18(C.lx_pool_get_liquidity(d.ptr, &cKey))
 }
 
-// =============================================================================
-// Book Operations (LP-9020)
-// =============================================================================
+// PoolSetDynamicFee sets the LP fee, in hundredths of a bip, of a pool
+// initialized with DynamicFeeFlag as its fee. It returns ErrNotDynamicFee
+// for a static-fee pool.
+func (d *LX) PoolSetDynamicFee(key PoolKey, newFee uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if key.Fee != DynamicFeeFlag {
+		return ErrNotDynamicFee
+	}
+	cKey := toCPoolKey(key)
+	return errorFromCode(int32(C.lx_pool_set_dynamic_fee(d.ptr, &cKey, C.uint32_t(newFee))))
+}
 
-// BookCreateMarket creates a new order book market.
-func (d *LX) BookCreateMarket(config BookMarketConfig) error {
+// PoolSetProtocolFee sets the protocol's cut of swaps selling currency0
+// (fee0) and currency1 (fee1), in hundredths of a bip of the input. The cut
+// is taken out of the LP fee and each fee may be at most
+// 1/MaxProtocolFeeFraction of it; larger fees return ErrInvalidFee.
+func (d *LX) PoolSetProtocolFee(key PoolKey, fee0, fee1 uint32) error {
 	if d.ptr == nil {
 		return errors.New("LX not initialized")
 	}
-	cConfig := toCBookMarketConfig(config)
-	result := int32(C.lx_book_create_market(d.ptr, &cConfig))
-	return errorFromCode(result)
+	if fee0 > 0xFFFF || fee1 > 0xFFFF {
+		return ErrInvalidFee
+	}
+	cKey := toCPoolKey(key)
+	return errorFromCode(int32(C.lx_pool_set_protocol_fee(d.ptr, &cKey, C.uint32_t(fee0|fee1<<16))))
 }
 
-// BookPlaceOrder places an order on the order book.
-func (d *LX) BookPlaceOrder(sender Account, order Order) (PlaceResult, error) {
+// PoolCollectProtocol sweeps the protocol fees accrued by a pool to
+// recipient. The delta is negative: the amounts paid out by the pool.
+func (d *LX) PoolCollectProtocol(key PoolKey, recipient Address) (BalanceDelta, error) {
 	if d.ptr == nil {
-		return PlaceResult{}, errors.New("LX not initialized")
+		return BalanceDelta{}, errors.New("LX not initialized")
 	}
-	cAccount := toCAccount(sender)
-	cOrder := toCOrder(order)
-	cResult := C.lx_book_place_order(d.ptr, &cAccount, &cOrder)
-	return fromCPlaceResult(cResult), nil
+	cKey := toCPoolKey(key)
+	cRecipient := toCAddress(recipient)
+	var cDelta C.LxBalanceDelta
+	result := int32(C.lx_pool_collect_protocol(d.ptr, &cKey, &cRecipient, &cDelta))
+	if err := errorFromCode(result); err != nil {
+		return BalanceDelta{}, err
+	}
+	return fromCBalanceDelta(cDelta), nil
 }
 
-// BookCancelOrder cancels an order by order ID.
-func (d *LX) BookCancelOrder(sender Account, marketID uint32, oid uint64) error {
+// PoolGetTicks returns the initialized ticks in [tickLower, tickUpper] in
+// ascending order, or an empty slice if there are none.
+func (d *LX) PoolGetTicks(key PoolKey, tickLower, tickUpper int32) ([]TickInfo, error) {
 	if d.ptr == nil {
-		return errors.New("LX not initialized")
+		return nil, errors.New("LX not initialized")
 	}
-	cAccount := toCAccount(sender)
-	result := int32(C.lx_book_cancel_order(d.ptr, &cAccount, C.uint32_t(marketID), C.uint64_t(oid)))
-	return errorFromCode(result)
+	if tickLower > tickUpper {
+		return nil, ErrInvalidTickRange
+	}
+	cKey := toCPoolKey(key)
+	var cTicks *C.LxTickInfo
+	var count C.size_t
+	result := int32(C.lx_pool_get_ticks(d.ptr, &cKey, C.int32_t(tickLower), C.int32_t(tickUpper), &cTicks, &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_tick_infos_free(cTicks)
+	ticks := make([]TickInfo, count)
+	if count > 0 {
+		for i, c := range unsafe.Slice(cTicks, count) {
+			ticks[i] = TickInfo{
+				Tick:           int32(c.tick),
+				LiquidityGross: fromCX18(c.liquidity_gross),
+				LiquidityNet:   fromCX18(c.liquidity_net),
+			}
+		}
+	}
+	return ticks, nil
 }
 
-// BookCancelByCLOID cancels an order by client order ID.
-func (d *LX) BookCancelByCLOID(sender Account, marketID uint32, cloid [16]byte) error {
+// PoolGetPosition returns the position owned by owner over
+// [tickLower, tickUpper] with the given salt, and false if there is none.
+func (d *LX) PoolGetPosition(key PoolKey, owner Address, tickLower, tickUpper int32, salt uint64) (PoolPosition, bool) {
 	if d.ptr == nil {
-		return errors.New("LX not initialized")
+		return PoolPosition{}, false
 	}
-	cAccount := toCAccount(sender)
-	result := int32(C.lx_book_cancel_by_cloid(d.ptr, &cAccount, C.uint32_t(marketID), (*C.uint8_t)(&cloid[0])))
-	return errorFromCode(result)
+	cKey := toCPoolKey(key)
+	cOwner := toCAddress(owner)
+	var cPos C.LxPoolPosition
+	if !C.lx_pool_get_position(d.ptr, &cKey, &cOwner, C.int32_t(tickLower), C.int32_t(tickUpper), C.uint64_t(salt), &cPos) {
+		return PoolPosition{}, false
+	}
+	return PoolPosition{
+		Liquidity:   fromCX18(cPos.liquidity),
+		TokensOwed0: fromCX18(cPos.tokens_owed0),
+		TokensOwed1: fromCX18(cPos.tokens_owed1),
+	}, true
+}
+
+// PoolCollectFees pays out the fees owed to a position, including those
+// accrued since it was last modified, and leaves its liquidity in place.
+// The delta is negative: the amounts paid out by the pool.
+func (d *LX) PoolCollectFees(key PoolKey, owner Address, tickLower, tickUpper int32, salt uint64) (BalanceDelta, error) {
+	if d.ptr == nil {
+		return BalanceDelta{}, errors.New("LX not initialized")
+	}
+	cKey := toCPoolKey(key)
+	cOwner := toCAddress(owner)
+	var cDelta C.LxBalanceDelta
+	result := int32(C.lx_pool_collect_fees(d.ptr, &cKey, &cOwner, C.int32_t(tickLower), C.int32_t(tickUpper), C.uint64_t(salt), &cDelta))
+	if err := errorFromCode(result); err != nil {
+		return BalanceDelta{}, err
+	}
+	return fromCBalanceDelta(cDelta), nil
 }
 
-// BookCancelAll cancels all orders for an account in a market.
-func (d *LX) BookCancelAll(sender Account, marketID uint32) error {
+// =============================================================================
+// Hooks Operations (LP-9013)
+// =============================================================================
+
+// HooksRegister declares which callbacks a hook contract implements.
+// Re-registering an address replaces its flags.
+func (d *LX) HooksRegister(hook Address, flags uint32) error {
 	if d.ptr == nil {
 		return errors.New("LX not initialized")
 	}
-	cAccount := toCAccount(sender)
-	result := int32(C.lx_book_cancel_all(d.ptr, &cAccount, C.uint32_t(marketID)))
+	if hook == (Address{}) {
+		return ErrInvalidHookFlags
+	}
+	if err := validateHookFlags(flags); err != nil {
+		return err
+	}
+	cHook := toCAddress(hook)
+	result := int32(C.lx_hooks_register(d.ptr, &cHook, C.uint32_t(flags)))
 	return errorFromCode(result)
 }
 
-// BookGetL1 returns Level-1 market data.
-func (d *LX) BookGetL1(marketID uint32) L1 {
+// HooksGetFlags returns the registered flags for a hook contract.
+func (d *LX) HooksGetFlags(hook Address) (uint32, bool) {
 	if d.ptr == nil {
-		return L1{}
+		return 0, false
 	}
-	cL1 := C.lx_book_get_l1(d.ptr, C.uint32_t(marketID))
-	return fromCL1(cL1)
+	cHook := toCAddress(hook)
+	var flags C.uint32_t
+	if !C.lx_hooks_get_flags(d.ptr, &cHook, &flags) {
+		return 0, false
+	}
+	return uint32(flags), true
 }
 
-// BookMarketExists checks if a market exists.
-func (d *LX) BookMarketExists(marketID uint32) bool {
-	if d.ptr == nil {
-		return false
+// validateHookFlags checks that only known bits are set and that every
+// returns-delta flag is paired with the callback it modifies.
+func validateHookFlags(flags uint32) error {
+	if flags == 0 || flags&^HookAllFlags != 0 {
+		return ErrInvalidHookFlags
 	}
-	return bool(C.lx_book_market_exists(d.ptr, C.uint32_t(marketID)))
+	requires := []struct{ delta, base uint32 }{
+		{HookBeforeSwapReturnsDelta, HookBeforeSwap},
+		{HookAfterSwapReturnsDelta, HookAfterSwap},
+		{HookAfterAddLiquidityReturnsDelta, HookAfterAddLiquidity},
+		{HookAfterRemoveLiquidityReturnsDelta, HookAfterRemoveLiquidity},
+	}
+	for _, r := range requires {
+		if flags&r.delta != 0 && flags&r.base == 0 {
+			return ErrInvalidHookFlags
+		}
+	}
+	return nil
 }
 
 // =============================================================================
-// Vault Operations (LP-9030)
+// Book Operations (LP-9020)
 // =============================================================================
 
-// VaultCreateMarket creates a new margin market.
-func (d *LX) VaultCreateMarket(config MarketConfig) error {
+// BookCreateMarket creates a new order book market.
+func (d *LX) BookCreateMarket(config BookMarketConfig) error {
 	if d.ptr == nil {
 		return errors.New("LX not initialized")
 	}
-	cConfig := toCMarketConfig(config)
-	result := int32(C.lx_vault_create_market(d.ptr, &cConfig))
+	cConfig := toCBookMarketConfig(config)
+	result := int32(C.lx_book_create_market(d.ptr, &cConfig))
 	return errorFromCode(result)
 }
 
-// VaultDeposit deposits tokens into the vault.
-func (d *LX) VaultDeposit(account Account, token Currency, amount X18) error {
+// BookUpdateMarket replaces the configuration of an existing market.
+// MarketID selects the market; SymbolID, BaseCurrency and QuoteCurrency are
+// immutable and changing them fails with ErrInvalidCurrency. TickSizeX18 and
+// LotSizeX18 can only change while no orders rest on the book, otherwise
+// ErrMarketNotEmpty.
+func (d *LX) BookUpdateMarket(config BookMarketConfig) error {
 	if d.ptr == nil {
 		return errors.New("LX not initialized")
 	}
-	cAccount := toCAccount(account)
-	cToken := toCCurrency(token)
-	result := int32(C.lx_vault_deposit(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	cConfig := toCBookMarketConfig(config)
+	result := int32(C.lx_book_update_market(d.ptr, &cConfig))
 	return errorFromCode(result)
 }
 
-// VaultWithdraw withdraws tokens from the vault.
-func (d *LX) VaultWithdraw(account Account, token Currency, amount X18) error {
-	if d.ptr == nil {
+// BookPlaceOrder places an order on the order book. Orders the book turns
+// away come back with Status StatusRejected and an error giving the reason:
+// ErrDraining, ErrMarketNotFound, ErrMarketHalted, ErrMarketRestricted when
+// the market status does not allow the order, ErrReduceOnly when a
+// ReduceOnly order, or any order in a reduce-only market, would not only
+// reduce the position, or ErrOrderRejected.
+func (d *LX) BookPlaceOrder(sender Account, order Order) (PlaceResult, error) {
+	return d.BookPlaceOrderContext(context.Background(), sender, order)
+}
+
+// BookPlaceOrderContext is BookPlaceOrder with a context. The context is
+// checked before the order is submitted; nothing is placed if it is done.
+func (d *LX) BookPlaceOrderContext(ctx context.Context, sender Account, order Order) (PlaceResult, error) {
+	if d.ptr == nil {
+		return PlaceResult{}, errors.New("LX not initialized")
+	}
+	if err := ctx.Err(); err != nil {
+		return PlaceResult{}, err
+	}
+	cAccount := toCAccount(sender)
+	cOrder := toCOrder(order)
+	cResult := C.lx_book_place_order(d.ptr, &cAccount, &cOrder)
+	return fromCPlaceResult(cResult), errorFromCode(int32(cResult.reason))
+}
+
+// BookPlaceOrders places a batch of orders in a single call, avoiding a CGO
+// crossing per order. Results are returned in the order of orders; an order
+// that fails validation comes back with Status StatusRejected and does not
+// abort the rest of the batch. An error is returned only if the batch as a
+// whole could not be processed.
+func (d *LX) BookPlaceOrders(sender Account, orders []Order) ([]PlaceResult, error) {
+	return d.BookPlaceOrdersContext(context.Background(), sender, orders)
+}
+
+// BookPlaceOrdersContext is BookPlaceOrders with a context. The context is
+// checked before the batch is submitted; the batch is placed as a whole or
+// not at all.
+func (d *LX) BookPlaceOrdersContext(ctx context.Context, sender Account, orders []Order) ([]PlaceResult, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return []PlaceResult{}, nil
+	}
+	cAccount := toCAccount(sender)
+	cOrders := toCOrders(orders)
+	cResults := make([]C.LxPlaceResult, len(orders))
+	result := int32(C.lx_book_place_orders(d.ptr, &cAccount, &cOrders[0], C.size_t(len(cOrders)), &cResults[0]))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	return fromCPlaceResults(cResults), nil
+}
+
+// BookCancelOrder cancels an order by order ID.
+func (d *LX) BookCancelOrder(sender Account, marketID uint32, oid uint64) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(sender)
+	result := int32(C.lx_book_cancel_order(d.ptr, &cAccount, C.uint32_t(marketID), C.uint64_t(oid)))
+	return errorFromCode(result)
+}
+
+// BookCancelOrders cancels a batch of orders in a single call. The returned
+// slice holds one error per OID, nil for orders that were cancelled and
+// ErrOrderNotFound for unknown ones; a failed slot does not stop the rest.
+// The second return value reports failure of the batch as a whole.
+func (d *LX) BookCancelOrders(sender Account, marketID uint32, oids []uint64) ([]error, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	if len(oids) == 0 {
+		return []error{}, nil
+	}
+	cAccount := toCAccount(sender)
+	cOIDs := make([]C.uint64_t, len(oids))
+	for i, oid := range oids {
+		cOIDs[i] = C.uint64_t(oid)
+	}
+	cCodes := make([]C.int32_t, len(oids))
+	result := int32(C.lx_book_cancel_orders(d.ptr, &cAccount, C.uint32_t(marketID), &cOIDs[0], C.size_t(len(cOIDs)), &cCodes[0]))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(cCodes))
+	for i, code := range cCodes {
+		errs[i] = errorFromCode(int32(code))
+	}
+	return errs, nil
+}
+
+// BookEvaluateTriggers activates the market's stop and take-profit orders
+// whose trigger refPx has reached and returns their OIDs in placement order.
+// Until activated, these orders are held off the book. Buy stops fire when
+// refPx rises to the trigger and sell stops when it falls to it;
+// take-profits fire the other way. Activated *Market kinds are placed as
+// market orders and *Limit kinds as limits at LimitPxX18.
+//
+// Pass the mark price (FeedGetMarkPrice) for perps, so a single off-market
+// print cannot set off stops; spot markets without a feed can use the last
+// trade price. Callers should evaluate on every mark price update.
+func (d *LX) BookEvaluateTriggers(marketID uint32, refPx X18) ([]uint64, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	var cOIDs *C.uint64_t
+	var count C.size_t
+	result := int32(C.lx_book_evaluate_triggers(d.ptr, C.uint32_t(marketID), toCX18(refPx), &cOIDs, &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_oids_free(cOIDs)
+	oids := make([]uint64, count)
+	if count > 0 {
+		for i, oid := range unsafe.Slice(cOIDs, count) {
+			oids[i] = uint64(oid)
+		}
+	}
+	return oids, nil
+}
+
+// BookCancelByCLOID cancels an order by client order ID.
+func (d *LX) BookCancelByCLOID(sender Account, marketID uint32, cloid [16]byte) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(sender)
+	result := int32(C.lx_book_cancel_by_cloid(d.ptr, &cAccount, C.uint32_t(marketID), (*C.uint8_t)(&cloid[0])))
+	return errorFromCode(result)
+}
+
+// BookCancelAll cancels all orders for an account in a market.
+func (d *LX) BookCancelAll(sender Account, marketID uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(sender)
+	result := int32(C.lx_book_cancel_all(d.ptr, &cAccount, C.uint32_t(marketID)))
+	return errorFromCode(result)
+}
+
+// BookModifyOrder amends a resting order's price and/or size.
+//
+// Reducing the size at an unchanged price is applied in place and keeps the
+// order's time priority. Any other amendment (a price change or a size
+// increase) re-queues the order at the back of its new price level. Returns
+// ErrMarketNotFound for an unknown market, ErrOrderNotFound if sender has no
+// live order oid in the market, and ErrAmendRejected if the book turns the
+// amendment away, for example for a non-positive price or size.
+func (d *LX) BookModifyOrder(sender Account, marketID uint32, oid uint64, newPx X18, newSz X18) (PlaceResult, error) {
+	if d.ptr == nil {
+		return PlaceResult{}, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(sender)
+	cResult := C.lx_book_amend_order(d.ptr, &cAccount, C.uint32_t(marketID), C.uint64_t(oid), toCX18(newSz), toCX18(newPx))
+	result := fromCPlaceResult(cResult)
+	err := errorFromCode(int32(cResult.reason))
+	if errors.Is(err, ErrOrderRejected) {
+		err = ErrAmendRejected
+	}
+	return result, err
+}
+
+// BookResetQuotes atomically cancels all of the account's orders in a market
+// and places newOrders in their place, so stale and fresh quotes are never
+// live at the same time. Results are returned in the order of newOrders;
+// an individual order may still come back StatusRejected. On error nothing
+// is cancelled or placed.
+func (d *LX) BookResetQuotes(sender Account, marketID uint32, newOrders []Order) ([]PlaceResult, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(sender)
+	cOrders := toCOrders(newOrders)
+	cResults := make([]C.LxPlaceResult, len(newOrders))
+	var ordersPtr *C.LxOrder
+	var resultsPtr *C.LxPlaceResult
+	if len(newOrders) > 0 {
+		ordersPtr, resultsPtr = &cOrders[0], &cResults[0]
+	}
+	result := int32(C.lx_book_reset_quotes(d.ptr, &cAccount, C.uint32_t(marketID), ordersPtr, C.size_t(len(cOrders)), resultsPtr))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	return fromCPlaceResults(cResults), nil
+}
+
+// BookBustTrade reverses an executed trade (admin-only).
+//
+// Both orders' fills are unwound, the buyer's and seller's positions and
+// balances are restored to their pre-trade state, and a correction event is
+// emitted. This is an operator action for erroneous prints: it rewrites
+// settled history, so anything that consumed the trade (PnL, funding,
+// downstream fills) must be reconciled by the caller. Returns ErrUnauthorized
+// if the instance does not permit trade busting and ErrTradeNotFound if the
+// trade is unknown or outside the retention window.
+func (d *LX) BookBustTrade(marketID uint32, tradeID uint64) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_book_bust_trade(d.ptr, C.uint32_t(marketID), C.uint64_t(tradeID)))
+	return errorFromCode(result)
+}
+
+// BookGetOrder returns the current state of an order by order ID.
+// Returns (nil, false) if the order is unknown.
+func (d *LX) BookGetOrder(marketID uint32, oid uint64) (*BookOrder, bool) {
+	if d.ptr == nil {
+		return nil, false
+	}
+	var cOrder C.LxBookOrder
+	if !C.lx_book_get_order(d.ptr, C.uint32_t(marketID), C.uint64_t(oid), &cOrder) {
+		return nil, false
+	}
+	order := fromCBookOrder(cOrder)
+	return &order, true
+}
+
+// BookGetOpenOrders returns the account's live orders in marketID, sorted
+// by order ID, so a reconnecting client can recover its working orders.
+// A marketID of 0 lists every market. Held stop and take-profit orders are
+// included; filled, cancelled and expired orders are not.
+func (d *LX) BookGetOpenOrders(account Account, marketID uint32) ([]BookOrder, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cOrders *C.LxBookOrder
+	var count C.size_t
+	result := int32(C.lx_book_get_open_orders(d.ptr, &cAccount, C.uint32_t(marketID), &cOrders, &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_book_orders_free(cOrders)
+	orders := make([]BookOrder, count)
+	if count > 0 {
+		for i, c := range unsafe.Slice(cOrders, count) {
+			orders[i] = fromCBookOrder(c)
+		}
+	}
+	return orders, nil
+}
+
+// BookGetL1 returns Level-1 market data.
+func (d *LX) BookGetL1(marketID uint32) L1 {
+	if d.ptr == nil {
+		return L1{}
+	}
+	cL1 := C.lx_book_get_l1(d.ptr, C.uint32_t(marketID))
+	return fromCL1(cL1)
+}
+
+// BookEstimateFees previews the fees order would pay in marketID at the
+// market's fee rates without placing it. taker is the fee on the size that
+// would cross the current book, up to the limit price for limit orders, at
+// the expected average fill price. maker is the fee on the remainder that
+// would rest at the limit price; it is zero for market and IOC orders,
+// whose remainders are cancelled. Post-only orders never take. order's
+// MarketID is ignored.
+func (d *LX) BookEstimateFees(marketID uint32, order Order) (maker, taker X18, err error) {
+	if d.ptr == nil {
+		return X18Zero(), X18Zero(), errors.New("LX not initialized")
+	}
+	order.MarketID = marketID
+	cOrder := toCOrder(order)
+	var cMaker, cTaker C.LxI128
+	result := int32(C.lx_book_estimate_fees(d.ptr, &cOrder, &cMaker, &cTaker))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), X18Zero(), err
+	}
+	return fromCX18(cMaker), fromCX18(cTaker), nil
+}
+
+// BookSimulateFill walks the opposite side of the book as a market order of
+// size would, without placing anything, and returns the volume-weighted
+// average fill price and the size that would fill. If the book holds less
+// than size, filled is what is available; on an empty side both are zero.
+func (d *LX) BookSimulateFill(marketID uint32, side Side, size X18) (avgPx X18, filled X18, err error) {
+	if d.ptr == nil {
+		return X18Zero(), X18Zero(), errors.New("LX not initialized")
+	}
+	if size.IsNegative() || size.IsZero() {
+		return X18Zero(), X18Zero(), errors.New("lx: size must be positive")
+	}
+	var cAvgPx, cFilled C.LxI128
+	result := int32(C.lx_book_simulate_fill(d.ptr, C.uint32_t(marketID), C.bool(side == SideBuy),
+		toCX18(size), &cAvgPx, &cFilled))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), X18Zero(), err
+	}
+	return fromCX18(cAvgPx), fromCX18(cFilled), nil
+}
+
+// BookGetL2 returns up to levels aggregated price levels per side. Fewer
+// levels are returned if the book is shallower; an empty book yields empty
+// (non-nil) sides.
+func (d *LX) BookGetL2(marketID uint32, levels int) (BookDepth, error) {
+	if d.ptr == nil {
+		return BookDepth{}, errors.New("LX not initialized")
+	}
+	if levels < 0 {
+		levels = 0
+	}
+	var cDepth C.LxBookDepth
+	result := int32(C.lx_book_get_l2(d.ptr, C.uint32_t(marketID), C.size_t(levels), &cDepth))
+	if err := errorFromCode(result); err != nil {
+		return BookDepth{}, err
+	}
+	defer C.lx_book_depth_free(&cDepth)
+	return fromCBookDepth(cDepth), nil
+}
+
+// BookGetMarketStats returns order, trade and volume counters for one
+// market. Unknown markets return ErrMarketNotFound.
+func (d *LX) BookGetMarketStats(marketID uint32) (MarketStats, error) {
+	if d.ptr == nil {
+		return MarketStats{}, errors.New("LX not initialized")
+	}
+	var cStats C.LxMarketStats
+	result := int32(C.lx_book_get_market_stats(d.ptr, C.uint32_t(marketID), &cStats))
+	if err := errorFromCode(result); err != nil {
+		return MarketStats{}, err
+	}
+	return MarketStats{
+		OrdersPlaced:    uint64(cStats.orders_placed),
+		OrdersCancelled: uint64(cStats.orders_cancelled),
+		Trades:          uint64(cStats.trades),
+		VolumeX18:       fromCX18(cStats.volume_x18),
+		OpenOrders:      uint64(cStats.open_orders),
+	}, nil
+}
+
+// BookRemoveMarket retires a market. Like luxdex RemoveSymbol it only
+// removes an empty market: with resting orders or held stop and
+// take-profit orders it returns ErrMarketNotEmpty, so cancel them first.
+func (d *LX) BookRemoveMarket(marketID uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_book_remove_market(d.ptr, C.uint32_t(marketID)))
+	return errorFromCode(result)
+}
+
+// BookSetMarketStatus halts, resumes or restricts trading on a market
+// without removing it. While MarketHalted, BookPlaceOrder fails with
+// ErrMarketHalted; cancels keep working in every status.
+func (d *LX) BookSetMarketStatus(marketID uint32, status uint8) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if status > MarketLimitOnly {
+		return fmt.Errorf("lx: invalid market status %d", status)
+	}
+	result := int32(C.lx_book_set_market_status(d.ptr, C.uint32_t(marketID), C.uint8_t(status)))
+	return errorFromCode(result)
+}
+
+// BookGetMarketStatus returns a market's current status.
+func (d *LX) BookGetMarketStatus(marketID uint32) (uint8, error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+	if !d.BookMarketExists(marketID) {
+		return 0, ErrMarketNotFound
+	}
+	return uint8(C.lx_book_get_market_status(d.ptr, C.uint32_t(marketID))), nil
+}
+
+// BookMarketExists checks if a market exists.
+func (d *LX) BookMarketExists(marketID uint32) bool {
+	if d.ptr == nil {
+		return false
+	}
+	return bool(C.lx_book_market_exists(d.ptr, C.uint32_t(marketID)))
+}
+
+// BookListMarkets returns the IDs of every order book market in ascending
+// order.
+func (d *LX) BookListMarkets() []uint32 {
+	if d.ptr == nil {
+		return nil
+	}
+	var cIDs *C.uint32_t
+	var count C.size_t
+	C.lx_book_list_markets(d.ptr, &cIDs, &count)
+	return fromCMarketIDs(cIDs, count)
+}
+
+// =============================================================================
+// Vault Operations (LP-9030)
+// =============================================================================
+
+// VaultCreateMarket creates a new margin market.
+func (d *LX) VaultCreateMarket(config MarketConfig) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cConfig := toCMarketConfig(config)
+	result := int32(C.lx_vault_create_market(d.ptr, &cConfig))
+	return errorFromCode(result)
+}
+
+// VaultUpdateMarket replaces the margin parameters of an existing market.
+// MarketID selects the market; BaseCurrency and QuoteCurrency are immutable
+// and changing them fails with ErrInvalidCurrency. A maintenance margin that
+// is not positive or exceeds the initial margin fails with
+// ErrInvalidLeverage, and a change that would leave any account with an
+// open position in the market liquidatable fails with ErrInsufficientMargin.
+func (d *LX) VaultUpdateMarket(config MarketConfig) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cConfig := toCMarketConfig(config)
+	result := int32(C.lx_vault_update_market(d.ptr, &cConfig))
+	return errorFromCode(result)
+}
+
+// VaultListMarkets returns the IDs of every margin market in ascending
+// order.
+func (d *LX) VaultListMarkets() []uint32 {
+	if d.ptr == nil {
+		return nil
+	}
+	var cIDs *C.uint32_t
+	var count C.size_t
+	C.lx_vault_list_markets(d.ptr, &cIDs, &count)
+	return fromCMarketIDs(cIDs, count)
+}
+
+// VaultDeposit deposits tokens into the vault.
+func (d *LX) VaultDeposit(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_vault_deposit(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// VaultDepositMulti deposits several tokens in one call. tokens[i] is
+// credited amounts[i]; the deposits are applied atomically, so if any
+// amount is rejected the account is left unchanged.
+func (d *LX) VaultDepositMulti(account Account, tokens []Currency, amounts []X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if len(tokens) != len(amounts) {
+		return fmt.Errorf("VaultDepositMulti: %d tokens but %d amounts", len(tokens), len(amounts))
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	cAccount := toCAccount(account)
+	cTokens := make([]C.LxCurrency, len(tokens))
+	cAmounts := make([]C.LxI128, len(amounts))
+	for i := range tokens {
+		cTokens[i] = toCCurrency(tokens[i])
+		cAmounts[i] = toCX18(amounts[i])
+	}
+	result := int32(C.lx_vault_deposit_multi(d.ptr, &cAccount, &cTokens[0], &cAmounts[0], C.size_t(len(tokens))))
+	return errorFromCode(result)
+}
+
+// VaultWithdraw withdraws tokens from the vault.
+func (d *LX) VaultWithdraw(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
 		return errors.New("LX not initialized")
 	}
 	cAccount := toCAccount(account)
@@ -637,6 +1656,72 @@ func (d *LX) VaultWithdraw(account Account, token Currency, amount X18) error {
 	return errorFromCode(result)
 }
 
+// VaultTransfer moves collateral between two accounts inside the vault
+// without touching external balances. Both accounts must have the same Main
+// address unless VaultSetCrossOwnerTransfers(true) was called; otherwise it
+// returns ErrUnauthorized. A transfer that would leave from below
+// maintenance margin fails with ErrInsufficientMargin.
+func (d *LX) VaultTransfer(from Account, to Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cFrom := toCAccount(from)
+	cTo := toCAccount(to)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_vault_transfer(d.ptr, &cFrom, &cTo, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// VaultSetCrossOwnerTransfers permits VaultTransfer between accounts with
+// different owners.
+func (d *LX) VaultSetCrossOwnerTransfers(allowed bool) {
+	if d.ptr != nil {
+		C.lx_vault_set_cross_owner_transfers(d.ptr, C.bool(allowed))
+	}
+}
+
+// VaultSetMarginMode sets the margin mode of an account in one market. The
+// account must be flat in that market: switching with an open position
+// returns ErrPositionOpen rather than silently re-margining it.
+func (d *LX) VaultSetMarginMode(account Account, marketID uint32, mode MarginMode) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	result := int32(C.lx_vault_set_margin_mode(d.ptr, &cAccount, C.uint32_t(marketID), C.uint8_t(mode)))
+	return errorFromCode(result)
+}
+
+// VaultSetLeverage sets the leverage of an account in one market. It must be
+// between 1x and the market's MaxLeverageX18, or ErrInvalidLeverage is
+// returned. Initial margin for the position becomes the larger of
+// 1/leverage and the market's InitialMarginX18.
+func (d *LX) VaultSetLeverage(account Account, marketID uint32, leverageX18 X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	result := int32(C.lx_vault_set_leverage(d.ptr, &cAccount, C.uint32_t(marketID), toCX18(leverageX18)))
+	return errorFromCode(result)
+}
+
+// VaultSetAccountFeeTier gives an account its own maker and taker fees, in
+// basis points (X18FromFloat(1.5) is 1.5 bps). A tier takes precedence over
+// the market default: once set, the account's fills in every market are
+// charged these rates instead of the market's MakerFeeX18 and TakerFeeX18.
+// Setting it again replaces the tier. A negative maker fee is a rebate; it
+// may not exceed the taker fee, and the taker fee may not be negative, or
+// ErrInvalidFee is returned.
+func (d *LX) VaultSetAccountFeeTier(account Account, makerBps, takerBps X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	bps := X18FromInt(10000)
+	result := int32(C.lx_vault_set_fee_tier(d.ptr, &cAccount, toCX18(makerBps.Div(bps)), toCX18(takerBps.Div(bps))))
+	return errorFromCode(result)
+}
+
 // VaultGetBalance returns the balance of a token for an account.
 func (d *LX) VaultGetBalance(account Account, token Currency) X18 {
 	if d.ptr == nil {
@@ -661,23 +1746,213 @@ func (d *LX) VaultGetPosition(account Account, marketID uint32) (*Position, bool
 	return &pos, true
 }
 
-// VaultGetMargin returns margin information for an account.
-func (d *LX) VaultGetMargin(account Account) MarginInfo {
+// VaultCanReduceOnly reports whether order would be accepted as a
+// ReduceOnly order: it must be on the opposite side of the account's open
+// position in order.MarketID and no larger than it. With no position, or in
+// an unknown market, nothing can be reduced. Other limit violations from
+// VaultCheckOrderLimits are returned as errors.
+//
+// BookPlaceOrder enforces the same rule, rejecting with ErrReduceOnly. A
+// passing order stays reducing as it partially fills, since each fill
+// shrinks the position and the order's remainder by the same amount. The
+// check does not see the account's other open orders: several reduce-only
+// orders that each pass can together exceed the position.
+func (d *LX) VaultCanReduceOnly(account Account, order Order) (bool, error) {
+	order.ReduceOnly = true
+	err := d.VaultCheckOrderLimits(account, order)
+	if errors.Is(err, ErrReduceOnly) || errors.Is(err, ErrMarketNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// VaultCheckOrderLimits checks order against its market's limits without
+// placing it, for precise order-entry feedback. Checks run in this order
+// and the first violation is returned:
+//
+//   - ErrOrderTooSmall: below the vault market's MinOrderSizeX18
+//   - ErrOrderTooLarge: above the book market's MaxOrderSizeX18
+//   - ErrPositionLimit: filled in full, the position would grow past
+//     MaxPositionSizeX18
+//   - ErrReduceOnly: the order is ReduceOnly, or either market is in
+//     reduce-only mode, and it would not only reduce the position
+//
+// Like VaultCanReduceOnly it ignores the account's other open orders, and
+// margin is not checked. ErrMarketNotFound is returned for an unknown market.
+func (d *LX) VaultCheckOrderLimits(account Account, order Order) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cOrder := toCOrder(order)
+	return errorFromCode(int32(C.lx_vault_check_order_limits(d.ptr, &cAccount, &cOrder)))
+}
+
+// VaultGetPositions returns every open position for an account across all
+// markets. An account with no positions yields an empty slice.
+func (d *LX) VaultGetPositions(account Account) ([]Position, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cPositions *C.LxPosition
+	var count C.size_t
+	result := int32(C.lx_vault_get_positions(d.ptr, &cAccount, &cPositions, &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_positions_free(cPositions)
+	positions := make([]Position, count)
+	if count > 0 {
+		for i, c := range unsafe.Slice(cPositions, count) {
+			positions[i] = fromCPosition(c)
+		}
+	}
+	return positions, nil
+}
+
+// VaultGetLiquidationPrice returns the mark price at which the account's
+// position in marketID would bring its equity down to maintenance margin.
+//
+// The vault margins accounts cross-wide: all collateral and the unrealized
+// PnL of the account's other positions support this one, and those other
+// positions are held at their last marked PnL. Maintenance margin is taken
+// on entry notional, as the vault computes it. A result of zero means the
+// position cannot be liquidated by price alone. It returns
+// ErrPositionNotFound if the account has no position in the market.
+func (d *LX) VaultGetLiquidationPrice(account Account, marketID uint32) (X18, error) {
+	if d.ptr == nil {
+		return X18Zero(), errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cPrice C.LxI128
+	result := int32(C.lx_vault_get_liquidation_price(d.ptr, &cAccount, C.uint32_t(marketID), &cPrice))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), err
+	}
+	return fromCX18(cPrice), nil
+}
+
+// VaultGetBuyingPower returns the additional position notional the account
+// could open in marketID: its free margin divided by the market's initial
+// margin rate, or by 1/leverage if the account chose a lower leverage with
+// VaultSetLeverage. It is zero once free margin is exhausted. Unknown markets
+// return ErrMarketNotFound.
+func (d *LX) VaultGetBuyingPower(account Account, marketID uint32) (X18, error) {
+	if d.ptr == nil {
+		return X18Zero(), errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cPower C.LxI128
+	result := int32(C.lx_vault_get_buying_power(d.ptr, &cAccount, C.uint32_t(marketID), &cPower))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), err
+	}
+	return fromCX18(cPower), nil
+}
+
+// VaultGetMargin returns margin information for an account.
+func (d *LX) VaultGetMargin(account Account) MarginInfo {
+	if d.ptr == nil {
+		return MarginInfo{}
+	}
+	cAccount := toCAccount(account)
+	cInfo := C.lx_vault_get_margin_info(d.ptr, &cAccount)
+	return fromCMarginInfo(cInfo)
+}
+
+// VaultGetAccountPnL returns realized and unrealized PnL, trading fees paid
+// and net funding for an account. An unknown account reports zeros.
+func (d *LX) VaultGetAccountPnL(account Account) (AccountPnL, error) {
+	if d.ptr == nil {
+		return AccountPnL{}, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cPnL C.LxAccountPnL
+	result := int32(C.lx_vault_get_account_pnl(d.ptr, &cAccount, &cPnL))
+	if err := errorFromCode(result); err != nil {
+		return AccountPnL{}, err
+	}
+	return AccountPnL{
+		RealizedPnlX18:   fromCX18(cPnL.realized_pnl_x18),
+		UnrealizedPnlX18: fromCX18(cPnL.unrealized_pnl_x18),
+		FeesPaidX18:      fromCX18(cPnL.fees_paid_x18),
+		FundingX18:       fromCX18(cPnL.funding_x18),
+	}, nil
+}
+
+// VaultIsLiquidatable checks if an account is liquidatable.
+func (d *LX) VaultIsLiquidatable(account Account) bool {
+	if d.ptr == nil {
+		return false
+	}
+	cAccount := toCAccount(account)
+	return bool(C.lx_vault_is_liquidatable(d.ptr, &cAccount))
+}
+
+// VaultAccountHasExposure reports whether the account has any resting orders
+// or open positions across all markets, in a single call. Use it to gate
+// withdrawals or account closure without enumerating markets.
+func (d *LX) VaultAccountHasExposure(account Account) (hasOrders bool, hasPositions bool, err error) {
+	if d.ptr == nil {
+		return false, false, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cOrders, cPositions C.bool
+	result := int32(C.lx_vault_account_has_exposure(d.ptr, &cAccount, &cOrders, &cPositions))
+	if err := errorFromCode(result); err != nil {
+		return false, false, err
+	}
+	return bool(cOrders), bool(cPositions), nil
+}
+
+// VaultTransferPosition moves size of from's position in a market to to at
+// an agreed price (give-up / novation). The receiver's entry price is blended
+// with price and the sender realizes PnL on the transferred size against its
+// entry. Returns ErrPositionNotFound if from holds less than size and
+// ErrInsufficientMargin if to cannot margin the resulting position; neither
+// account changes on error.
+func (d *LX) VaultTransferPosition(from Account, to Account, marketID uint32, size X18, price X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cFrom := toCAccount(from)
+	cTo := toCAccount(to)
+	result := int32(C.lx_vault_transfer_position(d.ptr, &cFrom, &cTo, C.uint32_t(marketID), toCX18(size), toCX18(price)))
+	return errorFromCode(result)
+}
+
+// VaultSetStakedBalance records an account's staked governance token
+// balance, which selects its StakingFeeTier on subsequent fills.
+func (d *LX) VaultSetStakedBalance(account Account, amount X18) error {
 	if d.ptr == nil {
-		return MarginInfo{}
+		return errors.New("LX not initialized")
 	}
 	cAccount := toCAccount(account)
-	cInfo := C.lx_vault_get_margin_info(d.ptr, &cAccount)
-	return fromCMarginInfo(cInfo)
+	result := int32(C.lx_vault_set_staked_balance(d.ptr, &cAccount, toCX18(amount)))
+	return errorFromCode(result)
 }
 
-// VaultIsLiquidatable checks if an account is liquidatable.
-func (d *LX) VaultIsLiquidatable(account Account) bool {
+// VaultSetStakingFeeTiers replaces the staking fee schedule. Each fill uses
+// the highest tier whose MinStakedX18 the account meets. Where an account
+// also has a VaultSetAccountFeeTier rate, the lower of the two applies;
+// they do not stack. An empty slice removes staking discounts. Discounts
+// must lie in [0, 1] and MinStakedX18 be non-negative, otherwise
+// ErrInvalidFee is returned and the schedule is left unchanged.
+func (d *LX) VaultSetStakingFeeTiers(tiers []StakingFeeTier) error {
 	if d.ptr == nil {
-		return false
+		return errors.New("LX not initialized")
 	}
-	cAccount := toCAccount(account)
-	return bool(C.lx_vault_is_liquidatable(d.ptr, &cAccount))
+	cTiers := make([]C.LxStakingFeeTier, len(tiers))
+	for i, tier := range tiers {
+		cTiers[i] = toCStakingFeeTier(tier)
+	}
+	var tiersPtr *C.LxStakingFeeTier
+	if len(cTiers) > 0 {
+		tiersPtr = &cTiers[0]
+	}
+	result := int32(C.lx_vault_set_staking_fee_tiers(d.ptr, tiersPtr, C.size_t(len(cTiers))))
+	return errorFromCode(result)
 }
 
 // VaultAccrueFunding accrues funding for a market.
@@ -689,6 +1964,24 @@ func (d *LX) VaultAccrueFunding(marketID uint32) error {
 	return errorFromCode(result)
 }
 
+// VaultSettleFunding moves the funding accrued on an account's position
+// into its balance and returns the amount settled: positive if received,
+// negative if paid. Settling again before the next accrual returns zero.
+// Returns ErrPositionNotFound if the account has no position in the
+// market. VaultAccrueFunding still does the market-wide accrual.
+func (d *LX) VaultSettleFunding(account Account, marketID uint32) (X18, error) {
+	if d.ptr == nil {
+		return X18Zero(), errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cAmount C.LxI128
+	result := int32(C.lx_vault_settle_funding(d.ptr, &cAccount, C.uint32_t(marketID), &cAmount))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), err
+	}
+	return fromCX18(cAmount), nil
+}
+
 // =============================================================================
 // Oracle Operations (LP-9011)
 // =============================================================================
@@ -702,7 +1995,10 @@ func (d *LX) OracleRegisterAsset(assetID uint64) error {
 	return errorFromCode(result)
 }
 
-// OracleUpdatePrice updates the price for an asset.
+// OracleUpdatePrice updates the price for an asset. It returns
+// ErrPriceDeviation, leaving the source's previous price in place, if the
+// asset's circuit breaker rejects the move, and ErrSourceUnavailable for a
+// source removed with OracleRemoveSource.
 func (d *LX) OracleUpdatePrice(assetID uint64, source PriceSource, price X18, confidence X18) error {
 	if d.ptr == nil {
 		return errors.New("LX not initialized")
@@ -712,6 +2008,46 @@ func (d *LX) OracleUpdatePrice(assetID uint64, source PriceSource, price X18, co
 	return errorFromCode(result)
 }
 
+// OracleUpdatePrices applies a batch of source prices in a single call, the
+// usual mode for a relayer pushing a full feed. Each entry is applied or
+// rejected on its own; if any are rejected the error is an
+// *OracleUpdateError holding the per-entry errors.
+func (d *LX) OracleUpdatePrices(updates []OracleUpdate) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	cUpdates := make([]C.LxOracleUpdate, len(updates))
+	for i, u := range updates {
+		cUpdates[i] = C.LxOracleUpdate{
+			asset_id:   C.uint64_t(u.AssetID),
+			source:     C.LxPriceSource(u.Source),
+			price:      toCX18(u.Price),
+			confidence: toCX18(u.Confidence),
+		}
+	}
+	cCodes := make([]C.int32_t, len(updates))
+	result := int32(C.lx_oracle_update_prices(d.ptr, &cUpdates[0], C.size_t(len(cUpdates)), &cCodes[0]))
+	if err := errorFromCode(result); err != nil {
+		return err
+	}
+	var batchErr *OracleUpdateError
+	for i, code := range cCodes {
+		if err := errorFromCode(int32(code)); err != nil {
+			if batchErr == nil {
+				batchErr = &OracleUpdateError{Errs: make([]error, len(updates))}
+			}
+			batchErr.Errs[i] = err
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
 // OracleGetPrice returns the aggregated price for an asset.
 func (d *LX) OracleGetPrice(assetID uint64) (X18, error) {
 	if d.ptr == nil {
@@ -724,6 +2060,120 @@ func (d *LX) OracleGetPrice(assetID uint64) (X18, error) {
 	return fromCX18(cPrice), nil
 }
 
+// OracleGetSourcePrice returns the last price, confidence and timestamp
+// submitted by a single source for an asset. It returns false if that
+// source has never reported the asset.
+func (d *LX) OracleGetSourcePrice(assetID uint64, source PriceSource) (X18, X18, uint64, bool) {
+	if d.ptr == nil {
+		return X18Zero(), X18Zero(), 0, false
+	}
+	var cPrice, cConfidence C.LxI128
+	var cTimestamp C.uint64_t
+	if !C.lx_oracle_get_source_price(d.ptr, C.uint64_t(assetID), C.LxPriceSource(source),
+		&cPrice, &cConfidence, &cTimestamp) {
+		return X18Zero(), X18Zero(), 0, false
+	}
+	return fromCX18(cPrice), fromCX18(cConfidence), uint64(cTimestamp), true
+}
+
+// OracleGetTWAP returns the time-weighted average of the aggregated price
+// over the last windowSecs seconds. The oracle retains 24 hours of history;
+// when the retained history is shorter than the window, the earliest sample
+// is taken to cover the gap, so the result is the TWAP of what is available.
+// It returns ErrNoPriceHistory if the asset has no samples in the window.
+func (d *LX) OracleGetTWAP(assetID uint64, windowSecs uint32) (X18, error) {
+	if d.ptr == nil {
+		return X18Zero(), errors.New("LX not initialized")
+	}
+	var cPrice C.LxI128
+	if !C.lx_oracle_get_twap(d.ptr, C.uint64_t(assetID), C.uint64_t(windowSecs), &cPrice) {
+		return X18Zero(), ErrNoPriceHistory
+	}
+	return fromCX18(cPrice), nil
+}
+
+// OracleSetStaleness sets the maximum price age, in seconds, after which an
+// asset's source prices are treated as stale by OracleIsPriceFresh and
+// excluded from aggregation.
+func (d *LX) OracleSetStaleness(assetID uint64, maxAgeSecs uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_oracle_set_staleness(d.ptr, C.uint64_t(assetID), C.uint32_t(maxAgeSecs)))
+	return errorFromCode(result)
+}
+
+// OracleGetStaleness returns the staleness threshold in effect for an
+// asset, which is DefaultOracleStaleness unless OracleSetStaleness was called.
+func (d *LX) OracleGetStaleness(assetID uint64) (uint32, error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+	var cMaxAge C.uint32_t
+	if !C.lx_oracle_get_staleness(d.ptr, C.uint64_t(assetID), &cMaxAge) {
+		return 0, ErrMarketNotFound
+	}
+	return uint32(cMaxAge), nil
+}
+
+// OracleSetCircuitBreaker rejects source updates that move more than
+// maxDeviationBps away from the asset's current aggregate, so a single
+// manipulated print cannot drive marks and liquidations. Rejected updates
+// fail with ErrPriceDeviation. The breaker stands aside while the asset
+// has no fresh aggregate, which lets a genuine repricing through once the
+// old prices go stale. Zero disables it.
+func (d *LX) OracleSetCircuitBreaker(assetID uint64, maxDeviationBps uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_oracle_set_circuit_breaker(d.ptr, C.uint64_t(assetID), C.uint32_t(maxDeviationBps)))
+	return errorFromCode(result)
+}
+
+// OracleRemoveSource quarantines a source: its stored price is dropped, so
+// the aggregate is recomputed without it from the next read, and its
+// further updates fail with ErrSourceUnavailable until OracleRestoreSource.
+func (d *LX) OracleRemoveSource(assetID uint64, source PriceSource) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_oracle_remove_source(d.ptr, C.uint64_t(assetID), C.LxPriceSource(source)))
+	return errorFromCode(result)
+}
+
+// OracleRestoreSource lets a source removed by OracleRemoveSource report
+// again. It contributes from its next update.
+func (d *LX) OracleRestoreSource(assetID uint64, source PriceSource) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_oracle_restore_source(d.ptr, C.uint64_t(assetID), C.LxPriceSource(source)))
+	return errorFromCode(result)
+}
+
+// OracleSetAggregation selects how OracleGetPrice aggregates an asset's
+// sources. Median is the most robust choice: a single source reporting a
+// tight confidence interval can dominate AggMeanConfidenceWeighted.
+func (d *LX) OracleSetAggregation(assetID uint64, mode AggregationMode) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_oracle_set_aggregation(d.ptr, C.uint64_t(assetID), C.uint8_t(mode)))
+	return errorFromCode(result)
+}
+
+// OracleGetAggregation returns the aggregation mode of an asset.
+func (d *LX) OracleGetAggregation(assetID uint64) (AggregationMode, error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+	var cMode C.uint8_t
+	if !C.lx_oracle_get_aggregation(d.ptr, C.uint64_t(assetID), &cMode) {
+		return 0, ErrMarketNotFound
+	}
+	return AggregationMode(cMode), nil
+}
+
 // OracleIsPriceFresh checks if the price is fresh.
 func (d *LX) OracleIsPriceFresh(assetID uint64) bool {
 	if d.ptr == nil {
@@ -753,18 +2203,41 @@ func (d *LX) FeedRegisterMarket(marketID uint32, assetID uint64) error {
 	return errorFromCode(result)
 }
 
-// FeedGetIndexPrice returns the index price for a market.
-func (d *LX) FeedGetIndexPrice(marketID uint32) (X18, error) {
+// FeedGetPrice returns the market's price of type pt, so generic code can
+// pick the price by value. Unknown price types are rejected. A price that
+// is not available yet returns ErrMarketNotFound, as the FeedGet*Price
+// methods do.
+func (d *LX) FeedGetPrice(marketID uint32, pt PriceType) (X18, error) {
 	if d.ptr == nil {
 		return X18Zero(), errors.New("LX not initialized")
 	}
 	var cPrice C.LxI128
-	if !C.lx_feed_get_index_price(d.ptr, C.uint32_t(marketID), &cPrice) {
+	var ok C.bool
+	switch pt {
+	case PriceIndex:
+		ok = C.lx_feed_get_index_price(d.ptr, C.uint32_t(marketID), &cPrice)
+	case PriceMark:
+		var cMP C.LxMarkPrice
+		ok = C.lx_feed_get_mark_price(d.ptr, C.uint32_t(marketID), &cMP)
+		cPrice = cMP.mark_px_x18
+	case PriceLast:
+		ok = C.lx_feed_get_last_price(d.ptr, C.uint32_t(marketID), &cPrice)
+	case PriceMid:
+		ok = C.lx_feed_get_mid_price(d.ptr, C.uint32_t(marketID), &cPrice)
+	default:
+		return X18Zero(), fmt.Errorf("lx: unknown price type %d", pt)
+	}
+	if !ok {
 		return X18Zero(), ErrMarketNotFound
 	}
 	return fromCX18(cPrice), nil
 }
 
+// FeedGetIndexPrice returns the index price for a market.
+func (d *LX) FeedGetIndexPrice(marketID uint32) (X18, error) {
+	return d.FeedGetPrice(marketID, PriceIndex)
+}
+
 // FeedGetMarkPrice returns the mark price for a market.
 func (d *LX) FeedGetMarkPrice(marketID uint32) (MarkPrice, error) {
 	if d.ptr == nil {
@@ -779,26 +2252,12 @@ func (d *LX) FeedGetMarkPrice(marketID uint32) (MarkPrice, error) {
 
 // FeedGetLastPrice returns the last trade price for a market.
 func (d *LX) FeedGetLastPrice(marketID uint32) (X18, error) {
-	if d.ptr == nil {
-		return X18Zero(), errors.New("LX not initialized")
-	}
-	var cPrice C.LxI128
-	if !C.lx_feed_get_last_price(d.ptr, C.uint32_t(marketID), &cPrice) {
-		return X18Zero(), ErrMarketNotFound
-	}
-	return fromCX18(cPrice), nil
+	return d.FeedGetPrice(marketID, PriceLast)
 }
 
 // FeedGetMidPrice returns the mid price for a market.
 func (d *LX) FeedGetMidPrice(marketID uint32) (X18, error) {
-	if d.ptr == nil {
-		return X18Zero(), errors.New("LX not initialized")
-	}
-	var cPrice C.LxI128
-	if !C.lx_feed_get_mid_price(d.ptr, C.uint32_t(marketID), &cPrice) {
-		return X18Zero(), ErrMarketNotFound
-	}
-	return fromCX18(cPrice), nil
+	return d.FeedGetPrice(marketID, PriceMid)
 }
 
 // FeedGetFundingRate returns the funding rate for a market.
@@ -813,6 +2272,109 @@ func (d *LX) FeedGetFundingRate(marketID uint32) (FundingRate, error) {
 	return fromCFundingRate(cFR), nil
 }
 
+// FeedGetAllPrices returns a market's index, mark, last and mid prices,
+// premium and funding rate in a single call, in place of one FeedGet* call
+// per price. It returns ErrMarketNotFound for an unknown market.
+func (d *LX) FeedGetAllPrices(marketID uint32) (FeedPrices, error) {
+	if d.ptr == nil {
+		return FeedPrices{}, errors.New("LX not initialized")
+	}
+	var c C.LxFeedPrices
+	if !C.lx_feed_get_all_prices(d.ptr, C.uint32_t(marketID), &c) {
+		return FeedPrices{}, ErrMarketNotFound
+	}
+	return FeedPrices{
+		IndexPxX18:      fromCX18(c.index_px_x18),
+		MarkPxX18:       fromCX18(c.mark_px_x18),
+		LastPxX18:       fromCX18(c.last_px_x18),
+		MidPxX18:        fromCX18(c.mid_px_x18),
+		PremiumX18:      fromCX18(c.premium_x18),
+		FundingRateX18:  fromCX18(c.funding_rate_x18),
+		NextFundingTime: uint64(c.next_funding_time),
+		Timestamp:       uint64(c.timestamp),
+	}, nil
+}
+
+// FeedGetFundingConfig returns a market's funding interval and rate cap
+// along with when funding is next calculated. NextFundingTime is zero
+// until the first FeedCalculateFundingRate.
+func (d *LX) FeedGetFundingConfig(marketID uint32) (FundingConfig, error) {
+	if d.ptr == nil {
+		return FundingConfig{}, errors.New("LX not initialized")
+	}
+	var cCfg C.LxFundingConfig
+	if !C.lx_feed_get_funding_config(d.ptr, C.uint32_t(marketID), &cCfg) {
+		return FundingConfig{}, ErrMarketNotFound
+	}
+	return FundingConfig{
+		IntervalSecs:    uint32(cCfg.interval_secs),
+		ClampBps:        uint32(cCfg.clamp_bps),
+		NextFundingTime: uint64(cCfg.next_funding_time),
+	}, nil
+}
+
+// FeedSetFundingConfig sets how often a market's funding rate is
+// calculated and caps its absolute value at clampBps per interval. The
+// other funding parameters are kept. A pending NextFundingTime moves onto
+// the new interval.
+func (d *LX) FeedSetFundingConfig(marketID uint32, intervalSecs uint32, clampBps uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if intervalSecs == 0 {
+		return errors.New("lx: funding interval must be positive")
+	}
+	result := int32(C.lx_feed_set_funding_config(d.ptr, C.uint32_t(marketID),
+		C.uint32_t(intervalSecs), C.uint32_t(clampBps)))
+	return errorFromCode(result)
+}
+
+// FeedGetFundingHistory returns up to limit recent funding rate samples for
+// a market, newest first. A registered market with no history yet returns an
+// empty slice.
+func (d *LX) FeedGetFundingHistory(marketID uint32, limit int) ([]FundingSample, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	if limit < 0 {
+		limit = 0
+	}
+
+	cSamples := make([]C.LxFundingSample, limit)
+	var samplesPtr *C.LxFundingSample
+	if limit > 0 {
+		samplesPtr = &cSamples[0]
+	}
+	var count C.size_t
+	result := int32(C.lx_feed_get_funding_history(d.ptr, C.uint32_t(marketID), samplesPtr, C.size_t(limit), &count))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+
+	samples := make([]FundingSample, int(count))
+	for i := range samples {
+		samples[i] = FundingSample{
+			RateX18:   fromCX18(cSamples[i].rate_x18),
+			Timestamp: uint64(cSamples[i].timestamp),
+		}
+	}
+	return samples, nil
+}
+
+// FeedSetMarkConfig tunes how a market's mark price is smoothed. The premium
+// over index is an EWMA over emaWindowSecs (0 follows the latest sample) and
+// is clamped to premiumClampBps of the index price, so a single print cannot
+// move the mark far enough to trigger spurious liquidations. A zero clamp
+// keeps the market's absolute premium caps.
+func (d *LX) FeedSetMarkConfig(marketID uint32, emaWindowSecs uint32, premiumClampBps uint32) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	result := int32(C.lx_feed_set_mark_config(d.ptr, C.uint32_t(marketID),
+		C.uint32_t(emaWindowSecs), C.uint32_t(premiumClampBps)))
+	return errorFromCode(result)
+}
+
 // FeedUpdateLastPrice updates the last trade price.
 func (d *LX) FeedUpdateLastPrice(marketID uint32, price X18) {
 	if d.ptr != nil {
@@ -834,15 +2396,133 @@ func (d *LX) FeedCalculateFundingRate(marketID uint32) {
 	}
 }
 
+// =============================================================================
+// Lend Operations (LP-9050)
+// =============================================================================
+
+// LendSupply moves tokens from the account's vault balance into the
+// lending pool, where they back its borrows.
+func (d *LX) LendSupply(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_lend_supply(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// LendWithdraw returns supplied tokens to the vault balance. It fails with
+// ErrInsufficientBalance beyond the supply or the pool's unborrowed
+// liquidity, and ErrInsufficientMargin if the account's borrows would no
+// longer be covered.
+func (d *LX) LendWithdraw(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_lend_withdraw(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// LendBorrow borrows tokens against the account's supplied collateral, up
+// to 75% of its value, crediting them to the vault balance. It fails with
+// ErrInsufficientLiquidity if the pool cannot cover the amount.
+//
+// Lending is restricted to the native (zero) Currency the vault settles
+// fees, funding and PnL in, which it values 1:1; there is no per-token price
+// to value other collateral against it. LendSupply, LendWithdraw, LendBorrow
+// and LendRepay return ErrInvalidCurrency for any other token.
+func (d *LX) LendBorrow(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_lend_borrow(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// LendRepay repays borrowed tokens from the vault balance; amounts above
+// the debt repay it in full. It returns ErrPositionNotFound if nothing is
+// owed in the token.
+func (d *LX) LendRepay(account Account, token Currency, amount X18) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cToken := toCCurrency(token)
+	result := int32(C.lx_lend_repay(d.ptr, &cAccount, &cToken, toCX18(amount)))
+	return errorFromCode(result)
+}
+
+// LendGetAccountLiquidity returns the account's collateral value and
+// borrow value, both in the native currency.
+func (d *LX) LendGetAccountLiquidity(account Account) (X18, X18, error) {
+	if d.ptr == nil {
+		return X18Zero(), X18Zero(), errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	var cCollateral, cBorrow C.LxI128
+	result := int32(C.lx_lend_get_account_liquidity(d.ptr, &cAccount, &cCollateral, &cBorrow))
+	if err := errorFromCode(result); err != nil {
+		return X18Zero(), X18Zero(), err
+	}
+	return fromCX18(cCollateral), fromCX18(cBorrow), nil
+}
+
+// =============================================================================
+// Liquid Operations (LP-9060)
+// =============================================================================
+
+// LiquidOpen opens a self-repaying loan against the given collateral and
+// returns the loan ID. The collateral leaves the account's vault balance
+// and half of it is credited back, in the same token, as principal.
+func (d *LX) LiquidOpen(account Account, collateral Currency, amount X18) (uint64, error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+	cAccount := toCAccount(account)
+	cCollateral := toCCurrency(collateral)
+	var loanID C.uint64_t
+	result := int32(C.lx_liquid_open(d.ptr, &cAccount, &cCollateral, toCX18(amount), &loanID))
+	if err := errorFromCode(result); err != nil {
+		return 0, err
+	}
+	return uint64(loanID), nil
+}
+
+// LiquidGetLoan returns a self-repaying loan by ID.
+func (d *LX) LiquidGetLoan(id uint64) (LiquidLoan, bool) {
+	if d.ptr == nil {
+		return LiquidLoan{}, false
+	}
+	var cLoan C.LxLiquidLoan
+	if !C.lx_liquid_get_loan(d.ptr, C.uint64_t(id), &cLoan) {
+		return LiquidLoan{}, false
+	}
+	return fromCLiquidLoan(cLoan), true
+}
+
 // =============================================================================
 // Precompile Router
 // =============================================================================
 
 // PrecompileCall calls a precompile with the given calldata.
 func (d *LX) PrecompileCall(precompile Address, calldata []byte) ([]byte, error) {
+	return d.PrecompileCallContext(context.Background(), precompile, calldata)
+}
+
+// PrecompileCallContext is PrecompileCall with a context. The context is
+// checked before the call starts and again before the result is fetched.
+func (d *LX) PrecompileCallContext(ctx context.Context, precompile Address, calldata []byte) ([]byte, error) {
 	if d.ptr == nil {
 		return nil, errors.New("LX not initialized")
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	cAddr := toCAddress(precompile)
 	var calldataPtr *C.uint8_t
@@ -857,6 +2537,9 @@ func (d *LX) PrecompileCall(precompile Address, calldata []byte) ([]byte, error)
 	}
 
 	// Allocate and call again
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	result := make([]byte, resultSize)
 	C.lx_precompile_call(d.ptr, &cAddr, calldataPtr, C.size_t(len(calldata)),
 		(*C.uint8_t)(unsafe.Pointer(&result[0])), C.size_t(len(result)))
@@ -864,6 +2547,30 @@ func (d *LX) PrecompileCall(precompile Address, calldata []byte) ([]byte, error)
 	return result, nil
 }
 
+// PrecompileCallInto calls a precompile and writes its return data into out
+// in a single crossing, returning the number of bytes written. If out cannot
+// hold the result it returns the required size and ErrBufferTooSmall.
+func (d *LX) PrecompileCallInto(precompile Address, calldata []byte, out []byte) (int, error) {
+	if d.ptr == nil {
+		return 0, errors.New("LX not initialized")
+	}
+
+	cAddr := toCAddress(precompile)
+	var calldataPtr, outPtr *C.uint8_t
+	if len(calldata) > 0 {
+		calldataPtr = (*C.uint8_t)(unsafe.Pointer(&calldata[0]))
+	}
+	if len(out) > 0 {
+		outPtr = (*C.uint8_t)(unsafe.Pointer(&out[0]))
+	}
+
+	n := int(C.lx_precompile_call(d.ptr, &cAddr, calldataPtr, C.size_t(len(calldata)), outPtr, C.size_t(len(out))))
+	if n > len(out) {
+		return n, ErrBufferTooSmall
+	}
+	return n, nil
+}
+
 // IsPrecompile checks if the address is a DEX precompile.
 func IsPrecompile(addr Address) bool {
 	cAddr := toCAddress(addr)
@@ -913,6 +2620,28 @@ func fromCAddress(c C.LxAddress) Address {
 	return a
 }
 
+func fromCPoolKey(c C.LxPoolKey) PoolKey {
+	return PoolKey{
+		Currency0:   fromCAddress(c.currency0),
+		Currency1:   fromCAddress(c.currency1),
+		Fee:         uint32(c.fee),
+		TickSpacing: int32(c.tick_spacing),
+		Hooks:       fromCAddress(c.hooks),
+	}
+}
+
+// fromCMarketIDs copies a market ID array and frees it.
+func fromCMarketIDs(cIDs *C.uint32_t, count C.size_t) []uint32 {
+	defer C.lx_market_ids_free(cIDs)
+	ids := make([]uint32, count)
+	if count > 0 {
+		for i, id := range unsafe.Slice(cIDs, count) {
+			ids[i] = uint32(id)
+		}
+	}
+	return ids
+}
+
 func toCCurrency(c Currency) C.LxCurrency {
 	return toCAddress(c)
 }
@@ -961,6 +2690,7 @@ func toCOrder(o Order) C.LxOrder {
 		trigger_px_x18: toCX18(o.TriggerPxX18),
 		reduce_only:    C.bool(o.ReduceOnly),
 		tif:            C.LxTIF(o.TIF),
+		stp_group:      C.uint64_t(o.STPGroup),
 	}
 	for i := 0; i < 16; i++ {
 		co.cloid[i] = C.uint8_t(o.CLOID[i])
@@ -968,6 +2698,14 @@ func toCOrder(o Order) C.LxOrder {
 	return co
 }
 
+func toCOrders(orders []Order) []C.LxOrder {
+	out := make([]C.LxOrder, len(orders))
+	for i, o := range orders {
+		out[i] = toCOrder(o)
+	}
+	return out
+}
+
 func toCMarketConfig(c MarketConfig) C.LxMarketConfig {
 	return C.LxMarketConfig{
 		market_id:              C.uint32_t(c.MarketID),
@@ -998,6 +2736,15 @@ func toCBookMarketConfig(c BookMarketConfig) C.LxBookMarketConfig {
 		post_only_mode:     C.bool(c.PostOnlyMode),
 		reduce_only_mode:   C.bool(c.ReduceOnlyMode),
 		status:             C.uint8_t(c.Status),
+		stp_mode:           C.uint8_t(c.STPMode),
+	}
+}
+
+func toCStakingFeeTier(t StakingFeeTier) C.LxStakingFeeTier {
+	return C.LxStakingFeeTier{
+		min_staked_x18:     toCX18(t.MinStakedX18),
+		taker_discount_x18: toCX18(t.TakerDiscountX18),
+		maker_discount_x18: toCX18(t.MakerDiscountX18),
 	}
 }
 
@@ -1017,6 +2764,64 @@ func fromCPlaceResult(c C.LxPlaceResult) PlaceResult {
 	}
 }
 
+func fromCBookOrder(c C.LxBookOrder) BookOrder {
+	o := BookOrder{
+		OID:              uint64(c.oid),
+		MarketID:         uint32(c.market_id),
+		IsBuy:            bool(c.is_buy),
+		Kind:             OrderKind(c.kind),
+		TIF:              TIF(c.tif),
+		OriginalSizeX18:  fromCX18(c.original_size_x18),
+		RemainingSizeX18: fromCX18(c.remaining_size_x18),
+		FilledSizeX18:    fromCX18(c.filled_size_x18),
+		LimitPxX18:       fromCX18(c.limit_px_x18),
+		TriggerPxX18:     fromCX18(c.trigger_px_x18),
+		AvgFillPxX18:     fromCX18(c.avg_fill_px_x18),
+		Status:           OrderStatus(c.status),
+		CreatedAt:        uint64(c.created_at),
+		UpdatedAt:        uint64(c.updated_at),
+	}
+	for i := range o.CLOID {
+		o.CLOID[i] = byte(c.cloid[i])
+	}
+	return o
+}
+
+func fromCPlaceResults(c []C.LxPlaceResult) []PlaceResult {
+	out := make([]PlaceResult, len(c))
+	for i, r := range c {
+		out[i] = fromCPlaceResult(r)
+	}
+	return out
+}
+
+func fromCTrade(c *C.LxTrade) Trade {
+	return Trade{
+		TradeID:    uint64(c.trade_id),
+		MarketID:   uint32(c.market_id),
+		MakerOID:   uint64(c.maker_oid),
+		TakerOID:   uint64(c.taker_oid),
+		Maker:      fromCAccount(c.maker),
+		Taker:      fromCAccount(c.taker),
+		TakerIsBuy: bool(c.taker_is_buy),
+		PxX18:      fromCX18(c.px_x18),
+		SzX18:      fromCX18(c.sz_x18),
+		Timestamp:  uint64(c.timestamp),
+	}
+}
+
+func fromCSystemEvent(c *C.LxSystemEvent) SystemEvent {
+	return SystemEvent{
+		Kind:      SystemEventKind(c.kind),
+		Severity:  Severity(c.severity),
+		MarketID:  uint32(c.market_id),
+		AssetID:   uint64(c.asset_id),
+		Account:   fromCAccount(c.account),
+		Err:       errorFromCode(int32(c.code)),
+		Timestamp: uint64(c.timestamp),
+	}
+}
+
 func fromCL1(c C.LxL1) L1 {
 	return L1{
 		BestBidPxX18:   fromCX18(c.best_bid_px_x18),
@@ -1027,6 +2832,29 @@ func fromCL1(c C.LxL1) L1 {
 	}
 }
 
+func fromCBookLevels(levels *C.LxBookLevel, count C.size_t) []BookLevel {
+	out := make([]BookLevel, count)
+	if count == 0 || levels == nil {
+		return out[:0]
+	}
+	for i, l := range unsafe.Slice(levels, count) {
+		out[i] = BookLevel{
+			PxX18:      fromCX18(l.px_x18),
+			SzX18:      fromCX18(l.sz_x18),
+			OrderCount: uint32(l.order_count),
+		}
+	}
+	return out
+}
+
+func fromCBookDepth(c C.LxBookDepth) BookDepth {
+	return BookDepth{
+		Bids:      fromCBookLevels(c.bids, c.bid_count),
+		Asks:      fromCBookLevels(c.asks, c.ask_count),
+		Timestamp: uint64(c.timestamp),
+	}
+}
+
 func fromCPosition(c C.LxPosition) Position {
 	return Position{
 		MarketID:              uint32(c.market_id),
@@ -1066,37 +2894,89 @@ func fromCFundingRate(c C.LxFundingRate) FundingRate {
 	}
 }
 
+func fromCAccount(c C.LxAccount) Account {
+	return Account{
+		Main:         fromCAddress(c.main),
+		SubaccountID: uint16(c.subaccount_id),
+	}
+}
+
+func fromCLiquidLoan(c C.LxLiquidLoan) LiquidLoan {
+	return LiquidLoan{
+		ID:              uint64(c.id),
+		Owner:           fromCAccount(c.owner),
+		Collateral:      fromCAddress(c.collateral),
+		CollateralX18:   fromCX18(c.collateral_x18),
+		PrincipalX18:    fromCX18(c.principal_x18),
+		YieldAppliedX18: fromCX18(c.yield_applied_x18),
+		HealthX18:       fromCX18(c.health_x18),
+		OpenedAt:        uint64(c.opened_at),
+	}
+}
+
+// LXError is the error returned for a non-zero C result code. It unwraps
+// to the matching sentinel (ErrInvalidFee and so on), so errors.Is works,
+// and errors.As recovers the raw Code, including for codes this package
+// does not know.
+type LXError struct {
+	Code int32
+	msg  string
+}
+
+func (e *LXError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the sentinel for Code, or nil for an unknown code.
+func (e *LXError) Unwrap() error {
+	return codeErrors[e.Code]
+}
+
+// codeErrors maps C result codes to their sentinel errors.
+var codeErrors = map[int32]error{
+	-1:  ErrPoolNotInitialized,
+	-2:  ErrPoolAlreadyInitialized,
+	-3:  ErrInvalidTickRange,
+	-4:  ErrInsufficientLiquidity,
+	-5:  ErrPriceLimitExceeded,
+	-6:  ErrInvalidCurrency,
+	-7:  ErrCurrenciesNotSorted,
+	-8:  ErrInvalidFee,
+	-9:  ErrNotDynamicFee,
+	-10: ErrInsufficientBalance,
+	-11: ErrInsufficientMargin,
+	-12: ErrPositionNotFound,
+	-13: ErrOrderNotFound,
+	-14: ErrMarketNotFound,
+	-16: ErrTradeNotFound,
+	-17: ErrPositionOpen,
+	-18: ErrInvalidLeverage,
+	-19: ErrMarketNotEmpty,
+	-21: ErrSourceUnavailable,
+	-22: ErrInvalidPrice,
+	-23: ErrPriceDeviation,
+	-24: ErrNotArmed,
+	-25: ErrOrderTooSmall,
+	-26: ErrOrderTooLarge,
+	-27: ErrPositionLimit,
+	-28: ErrReduceOnly,
+	-32: ErrHookNotRegistered,
+	-33: ErrInvalidHookFlags,
+	-34: ErrInvalidSnapshot,
+	-35: ErrInvalidJournal,
+	-36: ErrDraining,
+	-37: ErrMarketHalted,
+	-38: ErrMarketRestricted,
+	-39: ErrOrderRejected,
+	-40: ErrUnauthorized,
+}
+
 func errorFromCode(code int32) error {
-	switch code {
-	case 0:
+	if code == 0 {
 		return nil
-	case -1:
-		return ErrPoolNotInitialized
-	case -2:
-		return ErrPoolAlreadyInitialized
-	case -3:
-		return ErrInvalidTickRange
-	case -4:
-		return ErrInsufficientLiquidity
-	case -5:
-		return ErrPriceLimitExceeded
-	case -6:
-		return ErrInvalidCurrency
-	case -7:
-		return ErrCurrenciesNotSorted
-	case -8:
-		return ErrInvalidFee
-	case -10:
-		return ErrInsufficientBalance
-	case -11:
-		return ErrInsufficientMargin
-	case -12:
-		return ErrPositionNotFound
-	case -13:
-		return ErrOrderNotFound
-	case -14:
-		return ErrMarketNotFound
-	default:
-		return errors.New("unknown error")
 	}
+	if sentinel, ok := codeErrors[code]; ok {
+		return &LXError{Code: code, msg: sentinel.Error()}
+	}
+	return &LXError{Code: code, msg: fmt.Sprintf("unknown error (code %d)", code)}
 }
@@ -1,7 +1,18 @@
+//go:build cgo
+
 package lx
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAddressFromLP(t *testing.T) {
@@ -44,6 +55,90 @@ func TestAddressToLP(t *testing.T) {
 	}
 }
 
+func TestAddressHex(t *testing.T) {
+	// EIP-55 test vectors
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		for _, in := range []string{want, strings.ToLower(want), strings.ToUpper(want[2:])} {
+			a, err := AddressFromHex(in)
+			if err != nil {
+				t.Fatalf("AddressFromHex(%q): %v", in, err)
+			}
+			if got := a.Hex(); got != want {
+				t.Errorf("AddressFromHex(%q).Hex() = %s, want %s", in, got, want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "0x", "0x1234", LXPoolAddress.Hex() + "00", "0xzz" + LXPoolAddress.Hex()[4:]} {
+		if _, err := AddressFromHex(bad); err == nil {
+			t.Errorf("AddressFromHex(%q) succeeded", bad)
+		}
+	}
+
+	type config struct {
+		Pool  Address
+		Other map[Address]int
+	}
+	in := config{Pool: LXPoolAddress, Other: map[Address]int{LXBookAddress: 1}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"Pool":"`+LXPoolAddress.Hex()+`"`)) {
+		t.Errorf("Marshal = %s", data)
+	}
+	var out config
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.Pool != in.Pool || out.Other[LXBookAddress] != 1 {
+		t.Errorf("Unmarshal(%s) = %+v", data, out)
+	}
+}
+
+func TestCLOID(t *testing.T) {
+	const canonical = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	c, err := CLOIDFromString(strings.ToUpper(canonical))
+	if err != nil {
+		t.Fatalf("CLOIDFromString: %v", err)
+	}
+	if c[0] != 0x6b || c[15] != 0xc8 {
+		t.Errorf("CLOIDFromString = %x", c)
+	}
+	if got := CLOIDString(c); got != canonical {
+		t.Errorf("CLOIDString = %s, want %s", got, canonical)
+	}
+
+	for _, bad := range []string{
+		"",
+		"6ba7b8109dad11d180b400c04fd430c8",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c",
+		"6ba7b810-9dad11d1-80b4-00c04fd430c8-",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430cg",
+		"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
+	} {
+		if _, err := CLOIDFromString(bad); err == nil {
+			t.Errorf("CLOIDFromString(%q) succeeded", bad)
+		}
+	}
+
+	a, b := NewCLOID(), NewCLOID()
+	if a == b {
+		t.Error("NewCLOID returned the same ID twice")
+	}
+	if a[6]>>4 != 4 || a[8]>>6 != 2 {
+		t.Errorf("NewCLOID = %s, want a version 4 RFC 4122 UUID", CLOIDString(a))
+	}
+	if parsed, err := CLOIDFromString(CLOIDString(a)); err != nil || parsed != a {
+		t.Errorf("CLOIDFromString(CLOIDString(%x)) = %x, %v", a, parsed, err)
+	}
+}
+
 func TestIsDEXPrecompile(t *testing.T) {
 	tests := []struct {
 		addr Address
@@ -67,32 +162,48 @@ func TestIsDEXPrecompile(t *testing.T) {
 	}
 }
 
-func TestX18Arithmetic(t *testing.T) {
-	// Test X18FromInt
-	one := X18FromInt(1)
-	if one.Lo != X18One || one.Hi != 0 {
-		t.Errorf("X18FromInt(1) = {%d, %d}, want {%d, 0}", one.Lo, one.Hi, X18One)
+// TestX18PureGoMatchesCGO cross-checks the pure-Go conversions used when
+// cgo is disabled against the C implementations.
+func TestX18PureGoMatchesCGO(t *testing.T) {
+	ints := []int64{0, 1, -1, 42, -42, 1_000_000_007, math.MaxInt64, math.MinInt64}
+	for _, v := range ints {
+		if got, want := x18FromIntGo(v), X18FromInt(v); got != want {
+			t.Errorf("x18FromIntGo(%d) = %+v, want %+v", v, got, want)
+		}
 	}
 
-	// Test ToInt
-	if one.ToInt() != 1 {
-		t.Errorf("X18FromInt(1).ToInt() = %d, want 1", one.ToInt())
+	floats := []float64{0, 1, -1, 0.1, -0.1, 1e-18, 1.5, 123.456, -987654.321, 3.3e-7, 9.2e18, 1e20, -1.5e20}
+	for _, v := range floats {
+		if got, want := x18FromFloatGo(v), X18FromFloat(v); got != want {
+			t.Errorf("x18FromFloatGo(%g) = %+v, want %+v", v, got, want)
+		}
 	}
 
-	// Test X18FromFloat
-	half := X18FromFloat(0.5)
-	got := half.ToFloat()
-	if got < 0.49 || got > 0.51 {
-		t.Errorf("X18FromFloat(0.5).ToFloat() = %f, want ~0.5", got)
+	values := []X18{
+		{},
+		{Lo: 1},
+		{Lo: -1, Hi: -1},
+		{Lo: X18One / 2},
+		{Lo: -X18One / 2, Hi: -1},
+		{Lo: math.MaxInt64},
+		{Lo: 12345, Hi: 1},
+		{Lo: 0, Hi: math.MaxInt64},
+		{Lo: 0, Hi: math.MinInt64},
+		{Lo: -7, Hi: -123456789},
 	}
-
-	// Test IsZero
-	zero := X18Zero()
-	if !zero.IsZero() {
-		t.Error("X18Zero().IsZero() = false, want true")
+	for _, v := range ints {
+		values = append(values, X18FromInt(v))
+	}
+	for _, v := range floats {
+		values = append(values, X18FromFloat(v))
 	}
-	if one.IsZero() {
-		t.Error("X18FromInt(1).IsZero() = true, want false")
+	for _, x := range values {
+		if got, want := x18ToIntGo(x), x.ToInt(); got != want {
+			t.Errorf("x18ToIntGo(%+v) = %d, want %d", x, got, want)
+		}
+		if got, want := x18ToFloatGo(x), x.ToFloat(); got != want {
+			t.Errorf("x18ToFloatGo(%+v) = %g, want %g", x, got, want)
+		}
 	}
 }
 
@@ -154,6 +265,216 @@ func TestEnums(t *testing.T) {
 	}
 }
 
+func TestValidateHookFlags(t *testing.T) {
+	tests := []struct {
+		flags uint32
+		ok    bool
+	}{
+		{HookBeforeSwap | HookAfterSwap, true},
+		{HookBeforeSwap | HookBeforeSwapReturnsDelta, true},
+		{HookBeforeSwapReturnsDelta, false}, // delta without its callback
+		{HookAfterRemoveLiquidityReturnsDelta, false},
+		{0, false},
+		{1 << 14, false}, // unknown bit
+	}
+
+	for _, tt := range tests {
+		err := validateHookFlags(tt.flags)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateHookFlags(0x%x) = %v, want ok=%v", tt.flags, err, tt.ok)
+		}
+	}
+}
+
+func TestPoolKeyValidate(t *testing.T) {
+	lo, hi := Currency{19: 0x01}, Currency{0: 0x01}
+	if a, b := SortCurrencies(hi, lo); a != lo || b != hi {
+		t.Errorf("SortCurrencies(hi, lo) = %x, %x", a, b)
+	}
+	if a, b := SortCurrencies(lo, hi); a != lo || b != hi {
+		t.Errorf("SortCurrencies(lo, hi) = %x, %x", a, b)
+	}
+
+	valid := PoolKey{Currency0: lo, Currency1: hi, Fee: Fee030, TickSpacing: 60}
+	tests := []struct {
+		name   string
+		modify func(*PoolKey)
+		want   error
+	}{
+		{"valid", func(*PoolKey) {}, nil},
+		{"swapped", func(k *PoolKey) { k.Currency0, k.Currency1 = hi, lo }, ErrCurrenciesNotSorted},
+		{"equal", func(k *PoolKey) { k.Currency1 = lo }, ErrCurrenciesNotSorted},
+		{"fee", func(k *PoolKey) { k.Fee = 2500 }, ErrInvalidFee},
+		{"dynamic fee", func(k *PoolKey) { k.Fee = DynamicFeeFlag }, nil},
+		{"tick spacing", func(k *PoolKey) { k.TickSpacing = 0 }, ErrInvalidTickRange},
+	}
+	for _, tt := range tests {
+		key := valid
+		tt.modify(&key)
+		if err := key.Validate(); err != tt.want {
+			t.Errorf("%s: Validate() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	dex, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer dex.Close()
+	swapped := valid
+	swapped.Currency0, swapped.Currency1 = hi, lo
+	if _, err := dex.PoolInitialize(swapped, X18{Hi: 1 << 32}); err != ErrCurrenciesNotSorted {
+		t.Errorf("PoolInitialize(swapped) = %v, want ErrCurrenciesNotSorted", err)
+	}
+}
+
+// x18FromDecimal parses a raw 128-bit integer, such as a Q64.96 value.
+func x18FromDecimal(t *testing.T, s string) X18 {
+	t.Helper()
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("bad decimal %q", s)
+	}
+	return x18FromBig(n)
+}
+
+func TestSqrtPriceX96FromPrice(t *testing.T) {
+	// Exact in binary, so these match Uniswap's encodePriceSqrt to the unit
+	tests := []struct {
+		price float64
+		want  string
+	}{
+		{1, "79228162514264337593543950336"},
+		{4, "158456325028528675187087900672"},
+		{0.25, "39614081257132168796771975168"},
+		{2.25, "118842243771396506390315925504"},
+		{0, "0"},
+		{-1, "0"},
+	}
+	for _, tt := range tests {
+		want := x18FromDecimal(t, tt.want)
+		if got := SqrtPriceX96FromPrice(tt.price); got != want {
+			t.Errorf("SqrtPriceX96FromPrice(%v) = %s, want %s", tt.price, got.big(), tt.want)
+		}
+		if tt.price > 0 {
+			if got := PriceFromSqrtPriceX96(want); got != tt.price {
+				t.Errorf("PriceFromSqrtPriceX96(%s) = %v, want %v", tt.want, got, tt.price)
+			}
+		}
+	}
+	if got := SqrtPriceX96FromPrice(1e40); got != (X18{Lo: -1, Hi: math.MaxInt64}) {
+		t.Errorf("SqrtPriceX96FromPrice(1e40) = %s, want the largest X18", got.big())
+	}
+	for _, price := range []float64{1e-12, 0.0123, 3.5, 1850.25, 1e12} {
+		got := PriceFromSqrtPriceX96(SqrtPriceX96FromPrice(price))
+		if math.Abs(got-price)/price > 1e-12 {
+			t.Errorf("price %v round-trips to %v", price, got)
+		}
+	}
+}
+
+func TestTickFromPrice(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  int32
+	}{
+		{1, 0},
+		{1.0001, 1},
+		{0.9999, -2}, // between ticks -2 and -1 (0.99990001)
+		{2, 6931},
+		{0.5, -6932},
+		{1e-300, MinTick},
+		{0, MinTick},
+		{1e300, MaxTick},
+	}
+	for _, tt := range tests {
+		if got := TickFromPrice(tt.price); got != tt.want {
+			t.Errorf("TickFromPrice(%v) = %d, want %d", tt.price, got, tt.want)
+		}
+	}
+	for _, tick := range []int32{MinTick, -200000, -60, -1, 0, 1, 60, 200000, MaxTick} {
+		if got := TickFromPrice(PriceFromTick(tick)); got != tick {
+			t.Errorf("TickFromPrice(PriceFromTick(%d)) = %d", tick, got)
+		}
+	}
+}
+
+func TestSqrtPriceX96FromTick(t *testing.T) {
+	// Reference values from Uniswap v3 TickMath.getSqrtRatioAtTick
+	tests := []struct {
+		tick int32
+		want string
+	}{
+		{MinTick, "4295128739"},
+		{MinTick + 1, "4295343490"},
+		{-500000, "1101692437043807371"},
+		{-50000, "6504256538020985011912221507"},
+		{-1000, "75364347830767020784054125655"},
+		{-50, "79030349367926598376800521322"},
+		{0, "79228162514264337593543950336"},
+		{50, "79426470787362580746886972461"},
+		{1000, "83290069058676223003182343270"},
+		{50000, "965075977353221155028623082916"},
+		{250000, "21246587762933397357449903968194344"},
+	}
+	for _, tt := range tests {
+		want := x18FromDecimal(t, tt.want)
+		if got := SqrtPriceX96FromTick(tt.tick); got != want {
+			t.Errorf("SqrtPriceX96FromTick(%d) = %s, want %s", tt.tick, got.big(), tt.want)
+		}
+		if got := TickFromSqrtPriceX96(want); got != tt.tick {
+			t.Errorf("TickFromSqrtPriceX96(%s) = %d, want %d", tt.want, got, tt.tick)
+		}
+	}
+
+	maxX18 := X18{Lo: -1, Hi: math.MaxInt64}
+	if got := SqrtPriceX96FromTick(MaxTick); got != maxX18 {
+		t.Errorf("SqrtPriceX96FromTick(MaxTick) = %s, want the largest X18", got.big())
+	}
+	if got := TickFromSqrtPriceX96(X18{}); got != MinTick {
+		t.Errorf("TickFromSqrtPriceX96(0) = %d, want MinTick", got)
+	}
+
+	// Every ratio maps back to its tick and one unit below to the tick before
+	one := X18{Lo: 1}
+	for tick := MinTick + 1; tick <= MaxTick; tick += 997 {
+		sqrtP := SqrtPriceX96FromTick(tick)
+		if sqrtP == maxX18 {
+			break
+		}
+		if got := TickFromSqrtPriceX96(sqrtP); got != tick {
+			t.Fatalf("TickFromSqrtPriceX96(ratio at %d) = %d", tick, got)
+		}
+		if got := TickFromSqrtPriceX96(sqrtP.Sub(one)); got != tick-1 {
+			t.Fatalf("TickFromSqrtPriceX96(ratio at %d - 1) = %d", tick, got)
+		}
+	}
+}
+
+func TestNearestUsableTick(t *testing.T) {
+	half := MaxTick/2 + 100
+	tests := []struct {
+		tick, spacing, want int32
+	}{
+		{0, 60, 0},
+		{29, 60, 0},
+		{30, 60, 60},
+		{-30, 60, 0},
+		{-31, 60, -60},
+		{5, 10, 10},
+		{-6, 10, -10},
+		{MinTick, 60, -887220},
+		{MaxTick, 60, 887220},
+		{MinTick, half, -half},
+		{MaxTick, half, half},
+	}
+	for _, tt := range tests {
+		if got := NearestUsableTick(tt.tick, tt.spacing); got != tt.want {
+			t.Errorf("NearestUsableTick(%d, %d) = %d, want %d", tt.tick, tt.spacing, got, tt.want)
+		}
+	}
+}
+
 func TestIsPrecompile(t *testing.T) {
 	if !IsPrecompile(LXPoolAddress) {
 		t.Error("IsPrecompile(LXPoolAddress) = false, want true")
@@ -230,8 +551,8 @@ func TestPoolOperations(t *testing.T) {
 		Hooks:       Address{},
 	}
 
-	// Initialize pool with sqrt price = 1 (in Q96 format)
-	sqrtPriceX96 := X18FromInt(1) // Simplified for test
+	// Initialize pool at price 1
+	sqrtPriceX96 := SqrtPriceX96FromPrice(1)
 	tick, err := dex.PoolInitialize(key, sqrtPriceX96)
 	if err != nil {
 		t.Logf("PoolInitialize returned error (expected if not fully implemented): %v", err)
@@ -243,6 +564,148 @@ func TestPoolOperations(t *testing.T) {
 	t.Logf("Pool exists: %v", exists)
 }
 
+func TestHooksRegister(t *testing.T) {
+	dex := newTestLX(t)
+
+	hook := Address{19: 0x42}
+	key := PoolKey{
+		Currency0:   Address{19: 0x01},
+		Currency1:   Address{19: 0x02},
+		Fee:         Fee030,
+		TickSpacing: 60,
+		Hooks:       hook,
+	}
+
+	if _, err := dex.PoolInitialize(key, SqrtPriceX96FromPrice(1)); !errors.Is(err, ErrHookNotRegistered) {
+		t.Fatalf("PoolInitialize(unregistered hook) = %v, want ErrHookNotRegistered", err)
+	}
+	if _, ok := dex.HooksGetFlags(hook); ok {
+		t.Fatal("HooksGetFlags found an unregistered hook")
+	}
+	if err := dex.HooksRegister(hook, HookBeforeSwapReturnsDelta); !errors.Is(err, ErrInvalidHookFlags) {
+		t.Errorf("HooksRegister(unpaired delta flag) = %v, want ErrInvalidHookFlags", err)
+	}
+
+	flags := HookBeforeSwap | HookAfterSwap
+	if err := dex.HooksRegister(hook, flags); err != nil {
+		t.Fatalf("HooksRegister failed: %v", err)
+	}
+	if got, ok := dex.HooksGetFlags(hook); !ok || got != flags {
+		t.Errorf("HooksGetFlags = %#x, %v; want %#x", got, ok, flags)
+	}
+	if _, err := dex.PoolInitialize(key, SqrtPriceX96FromPrice(1)); err != nil {
+		t.Fatalf("PoolInitialize(registered hook) failed: %v", err)
+	}
+	if !dex.PoolExists(key) {
+		t.Error("pool with a registered hook was not created")
+	}
+}
+
+func TestLend(t *testing.T) {
+	dex := newTestLX(t)
+
+	lender, borrower := testAccount(1), testAccount(2)
+	var native Currency // the only token lending accepts
+	for _, acct := range []Account{lender, borrower} {
+		if err := dex.VaultDeposit(acct, native, X18FromInt(1000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+		if err := dex.LendSupply(acct, native, X18FromInt(1000)); err != nil {
+			t.Fatalf("LendSupply failed: %v", err)
+		}
+	}
+	if got := dex.VaultGetBalance(lender, native); !got.IsZero() {
+		t.Errorf("lender vault balance after supply = %f, want 0", got.ToFloat())
+	}
+
+	// Other tokens have no price to value them against the native currency
+	if err := dex.VaultDeposit(borrower, testQuote, X18FromInt(1000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	if err := dex.LendSupply(borrower, testQuote, X18FromInt(1000)); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("LendSupply(non-native) = %v, want ErrInvalidCurrency", err)
+	}
+	if err := dex.LendBorrow(borrower, testQuote, X18FromInt(1)); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("LendBorrow(non-native) = %v, want ErrInvalidCurrency", err)
+	}
+
+	if err := dex.LendBorrow(borrower, native, X18FromInt(800)); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("LendBorrow(over 75%%) = %v, want ErrInsufficientMargin", err)
+	}
+	if err := dex.LendBorrow(borrower, native, X18FromInt(500)); err != nil {
+		t.Fatalf("LendBorrow failed: %v", err)
+	}
+	if got := dex.VaultGetBalance(borrower, native); got != X18FromInt(500) {
+		t.Errorf("borrower vault balance = %f, want 500", got.ToFloat())
+	}
+	collateralValue, borrowValue, err := dex.LendGetAccountLiquidity(borrower)
+	if err != nil {
+		t.Fatalf("LendGetAccountLiquidity failed: %v", err)
+	}
+	if collateralValue != X18FromInt(1000) || borrowValue != X18FromInt(500) {
+		t.Errorf("liquidity = %f / %f, want 1000 / 500", collateralValue.ToFloat(), borrowValue.ToFloat())
+	}
+
+	if err := dex.LendWithdraw(borrower, native, X18FromInt(400)); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("LendWithdraw(pinned by debt) = %v, want ErrInsufficientMargin", err)
+	}
+	if err := dex.LendWithdraw(lender, native, X18FromInt(1001)); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("LendWithdraw(over supply) = %v, want ErrInsufficientBalance", err)
+	}
+	if err := dex.LendRepay(borrower, native, X18FromInt(500)); err != nil {
+		t.Fatalf("LendRepay failed: %v", err)
+	}
+	if err := dex.LendRepay(borrower, native, X18FromInt(1)); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("LendRepay(no debt) = %v, want ErrPositionNotFound", err)
+	}
+	if err := dex.LendWithdraw(lender, native, X18FromInt(1000)); err != nil {
+		t.Fatalf("LendWithdraw failed: %v", err)
+	}
+	if got := dex.VaultGetBalance(lender, native); got != X18FromInt(1000) {
+		t.Errorf("lender vault balance after withdraw = %f, want 1000", got.ToFloat())
+	}
+}
+
+func TestLiquidLoan(t *testing.T) {
+	dex := newTestLX(t)
+	owner := testAccount(1)
+	if err := dex.VaultDeposit(owner, testQuote, X18FromInt(1000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+
+	if _, err := dex.LiquidOpen(owner, testQuote, X18FromInt(2000)); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("LiquidOpen(over balance) = %v, want ErrInsufficientBalance", err)
+	}
+	id, err := dex.LiquidOpen(owner, testQuote, X18FromInt(1000))
+	if err != nil {
+		t.Fatalf("LiquidOpen failed: %v", err)
+	}
+
+	loan, ok := dex.LiquidGetLoan(id)
+	if !ok {
+		t.Fatal("LiquidGetLoan: loan not found")
+	}
+	if loan.ID != id || loan.Owner != owner || loan.Collateral != testQuote {
+		t.Errorf("loan = %+v, want ID %d owned by %v in %v", loan, id, owner, testQuote)
+	}
+	if loan.CollateralX18 != X18FromInt(1000) || loan.PrincipalX18 != X18FromInt(500) {
+		t.Errorf("collateral %f principal %f, want 1000 and 500",
+			loan.CollateralX18.ToFloat(), loan.PrincipalX18.ToFloat())
+	}
+	if !loan.YieldAppliedX18.IsZero() || loan.HealthX18 != X18FromInt(2) || loan.OpenedAt == 0 {
+		t.Errorf("yield %f health %f opened %d, want 0, 2 and a timestamp",
+			loan.YieldAppliedX18.ToFloat(), loan.HealthX18.ToFloat(), loan.OpenedAt)
+	}
+	// The principal is credited to the vault balance
+	if got := dex.VaultGetBalance(owner, testQuote); got != X18FromInt(500) {
+		t.Errorf("vault balance after open = %f, want 500", got.ToFloat())
+	}
+
+	if _, ok := dex.LiquidGetLoan(id + 1); ok {
+		t.Error("LiquidGetLoan(unknown) found a loan")
+	}
+}
+
 func TestBookOperations(t *testing.T) {
 	dex, err := New()
 	if err != nil {
@@ -398,29 +861,2898 @@ func TestVersion(t *testing.T) {
 	t.Logf("LX version: %s", v)
 }
 
-// Benchmark tests
+func TestBuildInfo(t *testing.T) {
+	dex := newTestLX(t)
+	info := dex.BuildInfo()
+	if info.Version != Version() {
+		t.Errorf("BuildInfo().Version = %q, want %q", info.Version, Version())
+	}
+	if info.GitCommit == "" || info.BuildDate == "" {
+		t.Errorf("BuildInfo() = %+v, want commit and date set", info)
+	}
+	var hasBook bool
+	for _, f := range info.Features {
+		hasBook = hasBook || f == "book"
+	}
+	if !hasBook {
+		t.Errorf("Features = %v, want book among them", info.Features)
+	}
+}
 
-func BenchmarkX18FromInt(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_ = X18FromInt(int64(i))
+func newTestLX(t testing.TB) *LX {
+	t.Helper()
+	dex, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
 	}
+	t.Cleanup(dex.Close)
+
+	dex.Initialize()
+	dex.Start()
+	t.Cleanup(dex.Stop)
+	return dex
 }
 
-func BenchmarkX18FromFloat(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_ = X18FromFloat(float64(i))
+// setupPerpMarket creates a vault and book market with the given ID.
+func setupPerpMarket(t testing.TB, dex *LX, marketID uint32) {
+	t.Helper()
+	if err := dex.VaultCreateMarket(MarketConfig{
+		MarketID:             marketID,
+		InitialMarginX18:     X18FromFloat(0.1),
+		MaintenanceMarginX18: X18FromFloat(0.05),
+		MaxLeverageX18:       X18FromInt(10),
+		TakerFeeX18:          X18FromFloat(0.0005),
+		MakerFeeX18:          X18FromFloat(0.0002),
+		MinOrderSizeX18:      X18FromFloat(0.001),
+		MaxPositionSizeX18:   X18FromInt(1000),
+		Active:               true,
+	}); err != nil {
+		t.Fatalf("VaultCreateMarket failed: %v", err)
+	}
+	if err := dex.BookCreateMarket(BookMarketConfig{
+		MarketID:        marketID,
+		SymbolID:        uint64(marketID),
+		TickSizeX18:     X18FromFloat(0.01),
+		LotSizeX18:      X18FromFloat(0.001),
+		MinNotionalX18:  X18FromFloat(1.0),
+		MaxOrderSizeX18: X18FromInt(1000),
+		Status:          1, // Active
+	}); err != nil {
+		t.Fatalf("BookCreateMarket failed: %v", err)
 	}
 }
 
-func BenchmarkAddressFromLP(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_ = AddressFromLP(0x9010)
+func testAccount(b byte) Account {
+	return Account{Main: Address{19: b}}
+}
+
+var testQuote = Currency{19: 0xee}
+
+// openPosition funds both accounts and crosses a resting sell from short
+// with an IOC buy from long, leaving long/short positions of size at px.
+func openPosition(t *testing.T, dex *LX, long, short Account, marketID uint32, size, px int64) {
+	t.Helper()
+	// Fills charge fees in the zero currency, so fund that for them to settle
+	var native Currency
+	for _, acct := range []Account{long, short} {
+		if err := dex.VaultDeposit(acct, native, X18FromInt(10000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+	}
+	if _, err := dex.BookPlaceOrder(short, Order{
+		MarketID: marketID, Kind: OrderLimit, SizeX18: X18FromInt(size), LimitPxX18: X18FromInt(px),
+	}); err != nil {
+		t.Fatalf("BookPlaceOrder (short) failed: %v", err)
+	}
+	res, err := dex.BookPlaceOrder(long, Order{
+		MarketID: marketID, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(size), LimitPxX18: X18FromInt(px), TIF: TifIOC,
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder (long) failed: %v", err)
+	}
+	if res.Status != StatusFilled {
+		t.Fatalf("opening order status = %d, want StatusFilled", res.Status)
 	}
 }
 
-func BenchmarkIsDEXPrecompile(b *testing.B) {
-	addr := LXPoolAddress
-	for i := 0; i < b.N; i++ {
-		_ = addr.IsDEXPrecompile()
+func TestBookBustTrade(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	// Fills charge fees in the zero currency, so fund that for the bust to
+	// have something to refund
+	var native Currency
+	maker, taker := testAccount(1), testAccount(2)
+	for _, acct := range []Account{maker, taker} {
+		if err := dex.VaultDeposit(acct, native, X18FromInt(10000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+	}
+	makerBefore := dex.VaultGetBalance(maker, native)
+	takerBefore := dex.VaultGetBalance(taker, native)
+
+	if _, err := dex.BookPlaceOrder(maker, Order{
+		MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100),
+	}); err != nil {
+		t.Fatalf("maker BookPlaceOrder failed: %v", err)
+	}
+	res, err := dex.BookPlaceOrder(taker, Order{
+		MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100), TIF: TifIOC,
+	})
+	if err != nil {
+		t.Fatalf("taker BookPlaceOrder failed: %v", err)
+	}
+	if res.Status != StatusFilled {
+		t.Fatalf("taker status = %d, want filled", res.Status)
+	}
+	if pos, ok := dex.VaultGetPosition(taker, 1); !ok || pos.SizeX18 != X18FromInt(1) {
+		t.Fatalf("taker position = %+v, %v; want long 1", pos, ok)
+	}
+	if got := dex.VaultGetBalance(maker, native); got == makerBefore {
+		t.Fatal("maker was not charged a fee")
+	}
+
+	// Trade IDs start at 1 in a fresh market
+	if err := dex.BookBustTrade(1, 1); err != nil {
+		t.Fatalf("BookBustTrade failed: %v", err)
+	}
+
+	if got := dex.VaultGetBalance(maker, native); got != makerBefore {
+		t.Errorf("maker balance = %f, want %f", got.ToFloat(), makerBefore.ToFloat())
+	}
+	if got := dex.VaultGetBalance(taker, native); got != takerBefore {
+		t.Errorf("taker balance = %f, want %f", got.ToFloat(), takerBefore.ToFloat())
+	}
+	for _, acct := range []Account{maker, taker} {
+		if _, ok := dex.VaultGetPosition(acct, 1); ok {
+			t.Errorf("position for %v still open after bust", acct.Main)
+		}
+	}
+
+	if err := dex.BookBustTrade(1, 1); !errors.Is(err, ErrTradeNotFound) {
+		t.Errorf("second BookBustTrade = %v, want ErrTradeNotFound", err)
+	}
+}
+
+func TestVaultAccountHasExposure(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	// Fills charge fees in the zero currency, so fund that for trades to settle
+	var native Currency
+	alice, bob := testAccount(1), testAccount(2)
+	for _, acct := range []Account{alice, bob} {
+		if err := dex.VaultDeposit(acct, native, X18FromInt(10000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+	}
+
+	trade := func(buyer, seller Account) {
+		t.Helper()
+		if _, err := dex.BookPlaceOrder(seller, Order{
+			MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100),
+		}); err != nil {
+			t.Fatalf("sell BookPlaceOrder failed: %v", err)
+		}
+		if _, err := dex.BookPlaceOrder(buyer, Order{
+			MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100), TIF: TifIOC,
+		}); err != nil {
+			t.Fatalf("buy BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	// Alice goes long one unit against Bob
+	trade(alice, bob)
+	hasOrders, hasPositions, err := dex.VaultAccountHasExposure(alice)
+	if err != nil {
+		t.Fatalf("VaultAccountHasExposure failed: %v", err)
+	}
+	if hasOrders || !hasPositions {
+		t.Errorf("open: hasOrders=%v hasPositions=%v, want false, true", hasOrders, hasPositions)
+	}
+
+	// Alice sells it back, flattening both accounts
+	trade(bob, alice)
+	hasOrders, hasPositions, err = dex.VaultAccountHasExposure(alice)
+	if err != nil {
+		t.Fatalf("VaultAccountHasExposure failed: %v", err)
+	}
+	if hasOrders || hasPositions {
+		t.Errorf("closed: hasOrders=%v hasPositions=%v, want false, false", hasOrders, hasPositions)
+	}
+}
+
+// setupPool initializes a 0.30% pool at price 1.0 with the given liquidity.
+func setupPool(t *testing.T, dex *LX, liquidity X18) PoolKey {
+	t.Helper()
+	key := PoolKey{
+		Currency0:   Currency{19: 0x01},
+		Currency1:   Currency{19: 0x02},
+		Fee:         Fee030,
+		TickSpacing: 60,
+	}
+	sqrtPriceX96 := SqrtPriceX96FromPrice(1)
+	if _, err := dex.PoolInitialize(key, sqrtPriceX96); err != nil {
+		t.Fatalf("PoolInitialize failed: %v", err)
+	}
+	if _, err := dex.PoolModifyLiquidity(key, ModifyLiquidityParams{
+		TickLower:      -600,
+		TickUpper:      600,
+		LiquidityDelta: liquidity,
+	}); err != nil {
+		t.Fatalf("PoolModifyLiquidity failed: %v", err)
+	}
+	return key
+}
+
+func TestPoolQuoteSwapPartial(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(10))
+
+	// Exact output (negative amount) far beyond what the shallow pool holds
+	want := X18FromInt(-1000)
+	params := SwapParams{ZeroForOne: true, AmountSpecified: want}
+
+	if _, err := dex.PoolQuoteSwap(key, params); !errors.Is(err, ErrInsufficientLiquidity) {
+		t.Fatalf("PoolQuoteSwap() error = %v, want ErrInsufficientLiquidity", err)
+	}
+
+	delta, full, err := dex.PoolQuoteSwapPartial(key, params)
+	if err != nil {
+		t.Fatalf("PoolQuoteSwapPartial failed: %v", err)
+	}
+	if full {
+		t.Error("full = true, want partial fill")
+	}
+	// Positive amounts are owed to the pool, negative paid out of it
+	out := -delta.Amount1.ToFloat()
+	if out <= 0 || out >= 1000 {
+		t.Errorf("partial output = %f, want in (0, 1000)", out)
+	}
+	if in := delta.Amount0.ToFloat(); in <= 0 {
+		t.Errorf("partial input = %f, want positive (owed to pool)", in)
+	}
+
+	// Quoting must not move the pool
+	if got := dex.PoolGetLiquidity(key); got != X18FromInt(10) {
+		t.Errorf("liquidity after quote = %f, want 10", got.ToFloat())
+	}
+}
+
+func TestPoolGetTicks(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(1_000))
+	if _, err := dex.PoolModifyLiquidity(key, ModifyLiquidityParams{
+		TickLower:      -120,
+		TickUpper:      600,
+		LiquidityDelta: X18FromInt(500),
+	}); err != nil {
+		t.Fatalf("PoolModifyLiquidity failed: %v", err)
+	}
+
+	ticks, err := dex.PoolGetTicks(key, -1000, 1000)
+	if err != nil {
+		t.Fatalf("PoolGetTicks failed: %v", err)
+	}
+	want := []struct {
+		tick       int32
+		gross, net int64
+	}{
+		{-600, 1_000, 1_000},
+		{-120, 500, 500},
+		{600, 1_500, -1_500},
+	}
+	if len(ticks) != len(want) {
+		t.Fatalf("PoolGetTicks = %+v, want %d ticks", ticks, len(want))
+	}
+	for i, w := range want {
+		got := ticks[i]
+		if got.Tick != w.tick || got.LiquidityGross != X18FromInt(w.gross) || got.LiquidityNet != X18FromInt(w.net) {
+			t.Errorf("tick %d = {%d gross %v net %v}, want {%d gross %d net %d}",
+				i, got.Tick, got.LiquidityGross.ToFloat(), got.LiquidityNet.ToFloat(), w.tick, w.gross, w.net)
+		}
+	}
+
+	ticks, err = dex.PoolGetTicks(key, -60, 540)
+	if err != nil || ticks == nil || len(ticks) != 0 {
+		t.Errorf("PoolGetTicks(empty range) = %v, %v, want empty slice", ticks, err)
+	}
+	if _, err := dex.PoolGetTicks(key, 60, -60); err != ErrInvalidTickRange {
+		t.Errorf("PoolGetTicks(reversed) error = %v, want ErrInvalidTickRange", err)
+	}
+}
+
+func TestPoolGetPosition(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(1_000_000))
+	// Positions are currently keyed to the zero owner
+	var owner Address
+
+	pos, ok := dex.PoolGetPosition(key, owner, -600, 600, 0)
+	if !ok {
+		t.Fatal("PoolGetPosition found no position for setupPool's liquidity")
+	}
+	if pos.Liquidity != X18FromInt(1_000_000) || !pos.TokensOwed0.IsZero() || !pos.TokensOwed1.IsZero() {
+		t.Errorf("position = %+v, want 1000000 liquidity and nothing owed", pos)
+	}
+
+	// Fees from a swap are credited when the position is next modified
+	if _, err := dex.PoolSwap(key, SwapParams{ZeroForOne: true, AmountSpecified: X18FromInt(10_000)}); err != nil {
+		t.Fatalf("PoolSwap failed: %v", err)
+	}
+	if _, err := dex.PoolModifyLiquidity(key, ModifyLiquidityParams{
+		TickLower:      -600,
+		TickUpper:      600,
+		LiquidityDelta: X18FromInt(-1),
+	}); err != nil {
+		t.Fatalf("PoolModifyLiquidity failed: %v", err)
+	}
+	pos, _ = dex.PoolGetPosition(key, owner, -600, 600, 0)
+	if pos.Liquidity != X18FromInt(999_999) || pos.TokensOwed0.Cmp(X18Zero()) <= 0 {
+		t.Errorf("position after swap = %+v, want 999999 liquidity and token0 fees owed", pos)
+	}
+
+	if _, ok := dex.PoolGetPosition(key, owner, -600, 600, 1); ok {
+		t.Error("PoolGetPosition found a position with a different salt")
+	}
+	if _, ok := dex.PoolGetPosition(key, owner, -60, 60, 0); ok {
+		t.Error("PoolGetPosition found a position with a different range")
+	}
+}
+
+func TestPoolCollectFees(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(1_000_000))
+	var owner Address
+
+	if _, err := dex.PoolSwap(key, SwapParams{ZeroForOne: true, AmountSpecified: X18FromInt(10_000)}); err != nil {
+		t.Fatalf("PoolSwap failed: %v", err)
+	}
+	delta, err := dex.PoolCollectFees(key, owner, -600, 600, 0)
+	if err != nil {
+		t.Fatalf("PoolCollectFees failed: %v", err)
+	}
+	if !delta.Amount0.IsNegative() || !delta.Amount1.IsZero() {
+		t.Errorf("collected %+v, want token0 fees paid out", delta)
+	}
+
+	pos, ok := dex.PoolGetPosition(key, owner, -600, 600, 0)
+	if !ok {
+		t.Fatal("position gone after collecting")
+	}
+	if pos.Liquidity != X18FromInt(1_000_000) || !pos.TokensOwed0.IsZero() || !pos.TokensOwed1.IsZero() {
+		t.Errorf("position after collect = %+v, want liquidity unchanged and nothing owed", pos)
+	}
+
+	// Fees are paid once
+	delta, err = dex.PoolCollectFees(key, owner, -600, 600, 0)
+	if err != nil || !delta.Amount0.IsZero() || !delta.Amount1.IsZero() {
+		t.Errorf("second PoolCollectFees = %+v, %v, want zero delta", delta, err)
+	}
+
+	if _, err := dex.PoolCollectFees(key, owner, -600, 600, 1); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("PoolCollectFees(missing position) error = %v, want ErrPositionNotFound", err)
+	}
+}
+
+func TestPoolDynamicFee(t *testing.T) {
+	dex := newTestLX(t)
+	static := setupPool(t, dex, X18FromInt(1_000))
+	if err := dex.PoolSetDynamicFee(static, Fee005); err != ErrNotDynamicFee {
+		t.Errorf("PoolSetDynamicFee(static pool) error = %v, want ErrNotDynamicFee", err)
+	}
+
+	dynamic := static
+	dynamic.Fee = DynamicFeeFlag
+	if err := dex.PoolSetDynamicFee(dynamic, Fee005); !errors.Is(err, ErrPoolNotInitialized) {
+		t.Errorf("PoolSetDynamicFee(uninitialized pool) error = %v, want ErrPoolNotInitialized", err)
+	}
+	if _, err := dex.PoolInitialize(dynamic, X18{Hi: 1 << 32}); err != nil {
+		t.Fatalf("PoolInitialize(dynamic) failed: %v", err)
+	}
+	if err := dex.PoolSetDynamicFee(dynamic, 2500); err != nil {
+		t.Errorf("PoolSetDynamicFee failed: %v", err)
+	}
+	if err := dex.PoolSetDynamicFee(dynamic, 200_000); !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("PoolSetDynamicFee(20%%) error = %v, want ErrInvalidFee", err)
+	}
+}
+
+func TestPoolProtocolFee(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(1_000_000))
+	treasury := Address{19: 0xfe}
+
+	// Fee030 allows up to 750 (0.075%) per side
+	if err := dex.PoolSetProtocolFee(key, 751, 0); !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("PoolSetProtocolFee(above cap) error = %v, want ErrInvalidFee", err)
+	}
+	if err := dex.PoolSetProtocolFee(key, 750, 300); err != nil {
+		t.Fatalf("PoolSetProtocolFee failed: %v", err)
+	}
+
+	if _, err := dex.PoolSwap(key, SwapParams{ZeroForOne: true, AmountSpecified: X18FromInt(10_000)}); err != nil {
+		t.Fatalf("PoolSwap failed: %v", err)
+	}
+	delta, err := dex.PoolCollectProtocol(key, treasury)
+	if err != nil {
+		t.Fatalf("PoolCollectProtocol failed: %v", err)
+	}
+	// A quarter of the 0.3% fee on 10000 token0 in
+	if got := delta.Amount0.ToFloat(); got > -7 || got < -8 || !delta.Amount1.IsZero() {
+		t.Errorf("collected %v / %v, want about -7.5 token0", got, delta.Amount1.ToFloat())
+	}
+
+	delta, err = dex.PoolCollectProtocol(key, treasury)
+	if err != nil || !delta.Amount0.IsZero() || !delta.Amount1.IsZero() {
+		t.Errorf("second PoolCollectProtocol = %+v, %v, want zero delta", delta, err)
+	}
+
+	missing := key
+	missing.Fee = Fee005
+	if _, err := dex.PoolCollectProtocol(missing, treasury); !errors.Is(err, ErrPoolNotInitialized) {
+		t.Errorf("PoolCollectProtocol(missing pool) error = %v, want ErrPoolNotInitialized", err)
+	}
+}
+
+func TestPoolTriggerSwap(t *testing.T) {
+	dex := newTestLX(t)
+	key := setupPool(t, dex, X18FromInt(1_000_000))
+	owner := testAccount(1)
+
+	// Buy currency0 once its price falls to 0.99 (pool starts at 1.0)
+	buy := SwapParams{ZeroForOne: false, AmountSpecified: X18FromInt(100)}
+	id, err := dex.PoolPlaceTriggerSwap(owner, key, buy, X18FromFloat(0.99), TriggerBelow)
+	if err != nil {
+		t.Fatalf("PoolPlaceTriggerSwap failed: %v", err)
+	}
+
+	// Price is still above the trigger
+	swaps, err := dex.PoolExecuteTriggers(key)
+	if err != nil {
+		t.Fatalf("PoolExecuteTriggers failed: %v", err)
+	}
+	if len(swaps) != 0 {
+		t.Fatalf("fired %d triggers above the trigger price, want 0", len(swaps))
+	}
+
+	// Sell currency0 to push the price down through 0.99
+	if _, err := dex.PoolSwap(key, SwapParams{ZeroForOne: true, AmountSpecified: X18FromInt(20_000)}); err != nil {
+		t.Fatalf("PoolSwap failed: %v", err)
+	}
+
+	swaps, err = dex.PoolExecuteTriggers(key)
+	if err != nil {
+		t.Fatalf("PoolExecuteTriggers failed: %v", err)
+	}
+	if len(swaps) != 1 {
+		t.Fatalf("fired %d triggers, want 1", len(swaps))
+	}
+	if swaps[0].TriggerID != id || swaps[0].Owner != owner {
+		t.Errorf("fired = %+v, want trigger %d for owner", swaps[0], id)
+	}
+	// Positive amounts are owed to the pool: currency1 in, currency0 out
+	if swaps[0].Delta.Amount1 != X18FromInt(100) || swaps[0].Delta.Amount0.ToFloat() >= 0 {
+		t.Errorf("trigger swap delta = %+v, want 100 currency1 in for currency0 out", swaps[0].Delta)
+	}
+
+	// A fired trigger is consumed
+	if swaps, _ := dex.PoolExecuteTriggers(key); len(swaps) != 0 {
+		t.Errorf("trigger fired twice")
+	}
+}
+
+func TestBookGetL2(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	depth, err := dex.BookGetL2(1, 10)
+	if err != nil {
+		t.Fatalf("BookGetL2 failed: %v", err)
+	}
+	if depth.Bids == nil || depth.Asks == nil || len(depth.Bids) != 0 || len(depth.Asks) != 0 {
+		t.Errorf("empty book depth = %+v, want empty non-nil sides", depth)
+	}
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, px := range []int64{99, 98, 97} {
+		if _, err := dex.BookPlaceOrder(maker, Order{
+			MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(px),
+		}); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	depth, err = dex.BookGetL2(1, 2)
+	if err != nil {
+		t.Fatalf("BookGetL2 failed: %v", err)
+	}
+	if len(depth.Bids) != 2 {
+		t.Fatalf("len(Bids) = %d, want 2 (capped)", len(depth.Bids))
+	}
+	if depth.Bids[0].PxX18 != X18FromInt(99) || depth.Bids[1].PxX18 != X18FromInt(98) {
+		t.Errorf("bid prices = %f, %f, want 99, 98", depth.Bids[0].PxX18.ToFloat(), depth.Bids[1].PxX18.ToFloat())
+	}
+
+	if _, err := dex.BookGetL2(42, 10); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookGetL2(unknown) error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestBookEstimateFees(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1) // maker 0.02%, taker 0.05%
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, level := range []struct{ size, px int64 }{{2, 100}, {3, 101}} {
+		if _, err := dex.BookPlaceOrder(maker, Order{
+			MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(level.size), LimitPxX18: X18FromInt(level.px),
+		}); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name         string
+		order        Order
+		maker, taker float64
+	}{
+		// 2 fill at 100, 2 rest at 100.5
+		{"partly marketable limit", Order{IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(4), LimitPxX18: X18FromFloat(100.5)}, 201 * 0.0002, 200 * 0.0005},
+		// Sweeps both levels, 5 of the 10 go unfilled
+		{"market", Order{IsBuy: true, Kind: OrderMarket, SizeX18: X18FromInt(10)}, 0, 503 * 0.0005},
+		{"resting limit", Order{IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}, 99 * 0.0002, 0},
+		{"ioc limit", Order{IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(4), LimitPxX18: X18FromFloat(100.5), TIF: TifIOC}, 0, 200 * 0.0005},
+		{"post-only", Order{IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99), TIF: TifALO}, 99 * 0.0002, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			makerFee, takerFee, err := dex.BookEstimateFees(1, tc.order)
+			if err != nil {
+				t.Fatalf("BookEstimateFees failed: %v", err)
+			}
+			if diff := makerFee.ToFloat() - tc.maker; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("maker fee = %f, want %f", makerFee.ToFloat(), tc.maker)
+			}
+			if diff := takerFee.ToFloat() - tc.taker; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("taker fee = %f, want %f", takerFee.ToFloat(), tc.taker)
+			}
+		})
+	}
+
+	if _, _, err := dex.BookEstimateFees(42, Order{Kind: OrderMarket, SizeX18: X18FromInt(1)}); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookEstimateFees(unknown) error = %v, want ErrMarketNotFound", err)
+	}
+	// Estimating places nothing
+	depth, err := dex.BookGetL2(1, 10)
+	if err != nil {
+		t.Fatalf("BookGetL2 failed: %v", err)
+	}
+	if len(depth.Bids) != 0 || len(depth.Asks) != 2 || depth.Asks[0].SzX18 != X18FromInt(2) {
+		t.Errorf("depth after estimates = %+v, want the two resting asks only", depth)
+	}
+}
+
+func TestBookSimulateFill(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	if avgPx, filled, err := dex.BookSimulateFill(1, SideBuy, X18FromInt(1)); err != nil || !avgPx.IsZero() || !filled.IsZero() {
+		t.Errorf("empty book = %f, %f, %v; want zeros", avgPx.ToFloat(), filled.ToFloat(), err)
+	}
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, o := range []Order{
+		{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100)},
+		{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(3), LimitPxX18: X18FromInt(104)},
+		{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(2), LimitPxX18: X18FromInt(95)},
+	} {
+		if _, err := dex.BookPlaceOrder(maker, o); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name          string
+		side          Side
+		size          int64
+		avgPx, filled float64
+	}{
+		{"within top level", SideBuy, 1, 100, 1},
+		{"across levels", SideBuy, 2, 102, 2},
+		{"larger than the book", SideBuy, 10, 103, 4},
+		{"sell", SideSell, 1, 95, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			avgPx, filled, err := dex.BookSimulateFill(1, tc.side, X18FromInt(tc.size))
+			if err != nil {
+				t.Fatalf("BookSimulateFill failed: %v", err)
+			}
+			if avgPx.ToFloat() != tc.avgPx || filled.ToFloat() != tc.filled {
+				t.Errorf("got avgPx %f, filled %f; want %f, %f", avgPx.ToFloat(), filled.ToFloat(), tc.avgPx, tc.filled)
+			}
+		})
+	}
+
+	if _, _, err := dex.BookSimulateFill(42, SideBuy, X18FromInt(1)); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookSimulateFill(unknown) error = %v, want ErrMarketNotFound", err)
+	}
+	if _, _, err := dex.BookSimulateFill(1, SideBuy, X18Zero()); err == nil {
+		t.Error("BookSimulateFill with zero size succeeded")
+	}
+}
+
+func TestBookGetOrder(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	cloid := [16]byte{0: 0xab, 15: 0xcd}
+	res, err := dex.BookPlaceOrder(maker, Order{
+		MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(2), LimitPxX18: X18FromInt(99), CLOID: cloid,
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	order, ok := dex.BookGetOrder(1, res.OID)
+	if !ok {
+		t.Fatalf("BookGetOrder(%d) not found", res.OID)
+	}
+	if order.OID != res.OID || order.CLOID != cloid || !order.IsBuy {
+		t.Errorf("order = %+v, want OID %d, CLOID %x, buy", order, res.OID, cloid)
+	}
+	if order.Status != StatusOpen {
+		t.Errorf("Status = %d, want StatusOpen", order.Status)
+	}
+	if order.OriginalSizeX18 != X18FromInt(2) || order.RemainingSizeX18 != X18FromInt(2) {
+		t.Errorf("sizes = %f/%f, want 2/2", order.OriginalSizeX18.ToFloat(), order.RemainingSizeX18.ToFloat())
+	}
+	if order.LimitPxX18 != X18FromInt(99) {
+		t.Errorf("LimitPxX18 = %f, want 99", order.LimitPxX18.ToFloat())
+	}
+
+	if _, ok := dex.BookGetOrder(1, res.OID+1000); ok {
+		t.Error("BookGetOrder(unknown) found an order")
+	}
+}
+
+func TestBookEvaluateTriggers(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	maker, trader := testAccount(1), testAccount(2)
+	for _, acct := range []Account{maker, trader} {
+		if err := dex.VaultDeposit(acct, testQuote, X18FromInt(10000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+	}
+	if _, err := dex.BookPlaceOrder(maker, Order{
+		MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(5), LimitPxX18: X18FromInt(110),
+	}); err != nil {
+		t.Fatalf("BookPlaceOrder (ask) failed: %v", err)
+	}
+
+	stop, err := dex.BookPlaceOrder(trader, Order{
+		MarketID: 1, IsBuy: true, Kind: OrderStopMarket, SizeX18: X18FromInt(2), TriggerPxX18: X18FromInt(105),
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder (stop) failed: %v", err)
+	}
+	take, err := dex.BookPlaceOrder(trader, Order{
+		MarketID: 1, Kind: OrderTakeLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(120), TriggerPxX18: X18FromInt(115),
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder (take-profit) failed: %v", err)
+	}
+	if !stop.FilledSizeX18.IsZero() {
+		t.Errorf("stop filled %f before triggering", stop.FilledSizeX18.ToFloat())
+	}
+
+	if oids, err := dex.BookEvaluateTriggers(1, X18FromInt(104)); err != nil || len(oids) != 0 {
+		t.Errorf("BookEvaluateTriggers(104) = %v, %v, want none", oids, err)
+	}
+	oids, err := dex.BookEvaluateTriggers(1, X18FromInt(105))
+	if err != nil {
+		t.Fatalf("BookEvaluateTriggers failed: %v", err)
+	}
+	if len(oids) != 1 || oids[0] != stop.OID {
+		t.Fatalf("BookEvaluateTriggers(105) = %v, want [%d]", oids, stop.OID)
+	}
+	if order, ok := dex.BookGetOrder(1, stop.OID); !ok || order.Status != StatusFilled {
+		t.Errorf("stop after triggering = %+v, want filled", order)
+	}
+
+	// Cancelled conditional orders never fire
+	if err := dex.BookCancelOrder(trader, 1, take.OID); err != nil {
+		t.Fatalf("BookCancelOrder failed: %v", err)
+	}
+	if oids, _ := dex.BookEvaluateTriggers(1, X18FromInt(130)); len(oids) != 0 {
+		t.Errorf("BookEvaluateTriggers fired %v after cancel", oids)
+	}
+}
+
+func TestBookRemoveMarket(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	trader := testAccount(1)
+	if err := dex.VaultDeposit(trader, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, order := range []Order{
+		{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(90)},
+		{MarketID: 1, Kind: OrderStopMarket, SizeX18: X18FromInt(1), TriggerPxX18: X18FromInt(80)},
+	} {
+		if _, err := dex.BookPlaceOrder(trader, order); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	if err := dex.BookRemoveMarket(1); !errors.Is(err, ErrMarketNotEmpty) {
+		t.Fatalf("BookRemoveMarket with open orders: err = %v, want ErrMarketNotEmpty", err)
+	}
+	if !dex.BookMarketExists(1) {
+		t.Fatal("market removed despite open orders")
+	}
+
+	if err := dex.BookCancelAll(trader, 1); err != nil {
+		t.Fatalf("BookCancelAll failed: %v", err)
+	}
+	if err := dex.BookRemoveMarket(1); err != nil {
+		t.Fatalf("BookRemoveMarket after cancel-all: %v", err)
+	}
+	if dex.BookMarketExists(1) {
+		t.Error("BookMarketExists(1) = true after removal")
+	}
+	if err := dex.BookRemoveMarket(1); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("second BookRemoveMarket: err = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestBookSetMarketStatus(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	maker, taker := testAccount(1), testAccount(2)
+	for _, acct := range []Account{maker, taker} {
+		if err := dex.VaultDeposit(acct, testQuote, X18FromInt(10000)); err != nil {
+			t.Fatalf("VaultDeposit failed: %v", err)
+		}
+	}
+	ask := Order{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100)}
+	resting, err := dex.BookPlaceOrder(maker, ask)
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	if err := dex.BookSetMarketStatus(1, MarketHalted); err != nil {
+		t.Fatalf("BookSetMarketStatus(halted) failed: %v", err)
+	}
+	if status, err := dex.BookGetMarketStatus(1); err != nil || status != MarketHalted {
+		t.Errorf("BookGetMarketStatus = %d, %v, want MarketHalted", status, err)
+	}
+	result, err := dex.BookPlaceOrder(maker, ask)
+	if !errors.Is(err, ErrMarketHalted) || result.Status != StatusRejected {
+		t.Errorf("place while halted = %+v, %v, want rejected with ErrMarketHalted", result, err)
+	}
+	if err := dex.BookCancelOrder(maker, 1, resting.OID); err != nil {
+		t.Errorf("cancel while halted: %v", err)
+	}
+
+	// Post-only: resting limits are accepted, crossing ones are not
+	if err := dex.BookSetMarketStatus(1, MarketPostOnly); err != nil {
+		t.Fatalf("BookSetMarketStatus(post-only) failed: %v", err)
+	}
+	if result, err := dex.BookPlaceOrder(maker, ask); err != nil || result.Status == StatusRejected {
+		t.Errorf("resting limit in post-only = %+v, %v", result, err)
+	}
+	crossing := Order{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(101)}
+	if result, err := dex.BookPlaceOrder(taker, crossing); !errors.Is(err, ErrOrderRejected) || result.Status != StatusRejected {
+		t.Errorf("crossing limit in post-only = %+v, %v, want rejected with ErrOrderRejected", result, err)
+	}
+
+	// Cancel-only turns away every new order, limits included
+	if err := dex.BookSetMarketStatus(1, MarketCancelOnly); err != nil {
+		t.Fatalf("BookSetMarketStatus(cancel-only) failed: %v", err)
+	}
+	if result, err := dex.BookPlaceOrder(maker, ask); !errors.Is(err, ErrMarketRestricted) || result.Status != StatusRejected {
+		t.Errorf("limit in cancel-only = %+v, %v, want rejected with ErrMarketRestricted", result, err)
+	}
+
+	if err := dex.BookSetMarketStatus(1, MarketActive); err != nil {
+		t.Fatalf("BookSetMarketStatus(active) failed: %v", err)
+	}
+	if result, err := dex.BookPlaceOrder(taker, crossing); err != nil || result.FilledSizeX18 != X18FromInt(1) {
+		t.Errorf("crossing limit once active = %+v, %v, want filled", result, err)
+	}
+
+	if err := dex.BookSetMarketStatus(1, 9); err == nil {
+		t.Error("BookSetMarketStatus accepted status 9")
+	}
+	if err := dex.BookSetMarketStatus(99, MarketHalted); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+	if _, err := dex.BookGetMarketStatus(99); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookGetMarketStatus unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestVaultUpdateMarket(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 2, 100)
+
+	config := MarketConfig{
+		MarketID:             1,
+		InitialMarginX18:     X18FromFloat(0.2),
+		MaintenanceMarginX18: X18FromFloat(0.1),
+		MaxLeverageX18:       X18FromInt(5),
+		TakerFeeX18:          X18FromFloat(0.0005),
+		MakerFeeX18:          X18FromFloat(0.0002),
+		MinOrderSizeX18:      X18FromFloat(0.001),
+		MaxPositionSizeX18:   X18FromInt(1000),
+		Active:               true,
+	}
+
+	moved := config
+	moved.QuoteCurrency = testQuote
+	if err := dex.VaultUpdateMarket(moved); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("changing the quote currency: err = %v, want ErrInvalidCurrency", err)
+	}
+	inverted := config
+	inverted.MaintenanceMarginX18 = X18FromFloat(0.3)
+	if err := dex.VaultUpdateMarket(inverted); !errors.Is(err, ErrInvalidLeverage) {
+		t.Errorf("maintenance above initial margin: err = %v, want ErrInvalidLeverage", err)
+	}
+	// 200 of notional at 60x maintenance is more than either side's equity
+	unsafe := config
+	unsafe.InitialMarginX18 = X18FromInt(60)
+	unsafe.MaintenanceMarginX18 = X18FromInt(60)
+	if err := dex.VaultUpdateMarket(unsafe); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("update liquidating open positions: err = %v, want ErrInsufficientMargin", err)
+	}
+
+	before := dex.VaultGetMargin(long).MaintenanceMarginX18
+	if err := dex.VaultUpdateMarket(config); err != nil {
+		t.Fatalf("VaultUpdateMarket: %v", err)
+	}
+	if got, want := dex.VaultGetMargin(long).MaintenanceMarginX18, before.Add(before); got.Cmp(want) != 0 {
+		t.Errorf("maintenance margin after doubling the rate = %v, want %v", got.ToFloat(), want.ToFloat())
+	}
+
+	config.MarketID = 2
+	if err := dex.VaultUpdateMarket(config); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market: err = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestBookUpdateMarket(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	trader := testAccount(1)
+	if err := dex.VaultDeposit(trader, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+
+	config := BookMarketConfig{
+		MarketID:        1,
+		SymbolID:        1,
+		TickSizeX18:     X18FromFloat(0.5),
+		LotSizeX18:      X18FromFloat(0.001),
+		MinNotionalX18:  X18FromFloat(1.0),
+		MaxOrderSizeX18: X18FromInt(1000),
+		Status:          MarketActive,
+	}
+	renamed := config
+	renamed.SymbolID = 7
+	if err := dex.BookUpdateMarket(renamed); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("changing the symbol: err = %v, want ErrInvalidCurrency", err)
+	}
+
+	if _, err := dex.BookPlaceOrder(trader, Order{
+		MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(90),
+	}); err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+	if err := dex.BookUpdateMarket(config); !errors.Is(err, ErrMarketNotEmpty) {
+		t.Errorf("tick size change with a resting order: err = %v, want ErrMarketNotEmpty", err)
+	}
+
+	// Other parameters may change while orders rest
+	limitOnly := config
+	limitOnly.TickSizeX18 = X18FromFloat(0.01)
+	limitOnly.Status = MarketLimitOnly
+	if err := dex.BookUpdateMarket(limitOnly); err != nil {
+		t.Fatalf("BookUpdateMarket: %v", err)
+	}
+	if got, err := dex.BookGetMarketStatus(1); err != nil || got != MarketLimitOnly {
+		t.Errorf("BookGetMarketStatus after update = %d, %v; want MarketLimitOnly", got, err)
+	}
+
+	if err := dex.BookCancelAll(trader, 1); err != nil {
+		t.Fatalf("BookCancelAll failed: %v", err)
+	}
+	if err := dex.BookUpdateMarket(config); err != nil {
+		t.Errorf("tick size change on an empty book: %v", err)
+	}
+}
+
+func TestListMarketsAndPools(t *testing.T) {
+	dex := newTestLX(t)
+	if ids := dex.BookListMarkets(); len(ids) != 0 {
+		t.Errorf("BookListMarkets on a fresh engine = %v, want none", ids)
+	}
+	if keys := dex.PoolList(); len(keys) != 0 {
+		t.Errorf("PoolList on a fresh engine = %v, want none", keys)
+	}
+
+	setupPerpMarket(t, dex, 3)
+	setupPerpMarket(t, dex, 1)
+	if err := dex.VaultCreateMarket(MarketConfig{
+		MarketID:             5,
+		InitialMarginX18:     X18FromFloat(0.1),
+		MaintenanceMarginX18: X18FromFloat(0.05),
+		Active:               true,
+	}); err != nil {
+		t.Fatalf("VaultCreateMarket failed: %v", err)
+	}
+	key := setupPool(t, dex, X18FromInt(1000))
+
+	if got := dex.BookListMarkets(); !reflect.DeepEqual(got, []uint32{1, 3}) {
+		t.Errorf("BookListMarkets = %v, want [1 3]", got)
+	}
+	if got := dex.VaultListMarkets(); !reflect.DeepEqual(got, []uint32{1, 3, 5}) {
+		t.Errorf("VaultListMarkets = %v, want [1 3 5]", got)
+	}
+	if got := dex.PoolList(); !reflect.DeepEqual(got, []PoolKey{key}) {
+		t.Errorf("PoolList = %v, want [%v]", got, key)
+	}
+}
+
+func TestBookResetQuotes(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	quote := func(isBuy bool, px int64) Order {
+		return Order{MarketID: 1, IsBuy: isBuy, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(px), TIF: TifALO}
+	}
+
+	var oldOIDs []uint64
+	for _, o := range []Order{quote(true, 98), quote(false, 102)} {
+		res, err := dex.BookPlaceOrder(maker, o)
+		if err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+		oldOIDs = append(oldOIDs, res.OID)
+	}
+
+	results, err := dex.BookResetQuotes(maker, 1, []Order{quote(true, 99), quote(false, 101)})
+	if err != nil {
+		t.Fatalf("BookResetQuotes failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	for i, r := range results {
+		if r.Status != StatusOpen {
+			t.Errorf("result %d status = %d, want StatusOpen", i, r.Status)
+		}
+	}
+
+	for _, oid := range oldOIDs {
+		if o, ok := dex.BookGetOrder(1, oid); ok && o.Status == StatusOpen {
+			t.Errorf("old quote %d still open", oid)
+		}
+	}
+	l1 := dex.BookGetL1(1)
+	if l1.BestBidPxX18 != X18FromInt(99) || l1.BestAskPxX18 != X18FromInt(101) {
+		t.Errorf("BBO = %f/%f, want 99/101", l1.BestBidPxX18.ToFloat(), l1.BestAskPxX18.ToFloat())
+	}
+	if l1.BestBidSzX18 != X18FromInt(1) || l1.BestAskSzX18 != X18FromInt(1) {
+		t.Errorf("BBO sizes = %f/%f, want 1/1", l1.BestBidSzX18.ToFloat(), l1.BestAskSzX18.ToFloat())
+	}
+}
+
+func TestBookModifyOrder(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	res, err := dex.BookPlaceOrder(maker, Order{
+		MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(5), LimitPxX18: X18FromInt(99),
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	// Size reduction in place
+	if _, err := dex.BookModifyOrder(maker, 1, res.OID, X18FromInt(99), X18FromInt(3)); err != nil {
+		t.Fatalf("BookModifyOrder failed: %v", err)
+	}
+	order, ok := dex.BookGetOrder(1, res.OID)
+	if !ok {
+		t.Fatal("order not found after amend")
+	}
+	if order.RemainingSizeX18 != X18FromInt(3) || order.LimitPxX18 != X18FromInt(99) {
+		t.Errorf("amended = %f @ %f, want 3 @ 99", order.RemainingSizeX18.ToFloat(), order.LimitPxX18.ToFloat())
+	}
+
+	// Price change re-queues at the new level
+	if _, err := dex.BookModifyOrder(maker, 1, res.OID, X18FromInt(98), X18FromInt(3)); err != nil {
+		t.Fatalf("BookModifyOrder failed: %v", err)
+	}
+	if l1 := dex.BookGetL1(1); l1.BestBidPxX18 != X18FromInt(98) {
+		t.Errorf("best bid = %f, want 98", l1.BestBidPxX18.ToFloat())
+	}
+
+	if _, err := dex.BookModifyOrder(maker, 1, res.OID+1000, X18FromInt(98), X18FromInt(1)); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("BookModifyOrder(unknown) error = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := dex.BookModifyOrder(maker, 99, res.OID, X18FromInt(98), X18FromInt(1)); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookModifyOrder(unknown market) error = %v, want ErrMarketNotFound", err)
+	}
+	if _, err := dex.BookModifyOrder(maker, 1, res.OID, X18FromInt(98), X18Zero()); !errors.Is(err, ErrAmendRejected) {
+		t.Errorf("BookModifyOrder(zero size) error = %v, want ErrAmendRejected", err)
+	}
+
+	// Another account cannot amend the maker's order
+	other := testAccount(2)
+	if _, err := dex.BookModifyOrder(other, 1, res.OID, X18FromInt(97), X18FromInt(1)); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("BookModifyOrder(other account) error = %v, want ErrOrderNotFound", err)
+	}
+	if order, _ := dex.BookGetOrder(1, res.OID); order.RemainingSizeX18 != X18FromInt(3) || order.LimitPxX18 != X18FromInt(98) {
+		t.Errorf("after foreign amend = %f @ %f, want 3 @ 98", order.RemainingSizeX18.ToFloat(), order.LimitPxX18.ToFloat())
+	}
+}
+
+func TestVaultTransferPosition(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	a, b, c := testAccount(1), testAccount(2), testAccount(3)
+	openPosition(t, dex, a, c, 1, 2, 100)
+	if err := dex.VaultDeposit(b, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+
+	if err := dex.VaultTransferPosition(a, b, 1, X18FromInt(1), X18FromInt(110)); err != nil {
+		t.Fatalf("VaultTransferPosition failed: %v", err)
+	}
+
+	posA, ok := dex.VaultGetPosition(a, 1)
+	if !ok {
+		t.Fatal("sender position closed, want size 1 remaining")
+	}
+	if posA.Side != PositionLong || posA.SizeX18 != X18FromInt(1) || posA.EntryPxX18 != X18FromInt(100) {
+		t.Errorf("sender = %+v, want long 1 @ 100", posA)
+	}
+	posB, ok := dex.VaultGetPosition(b, 1)
+	if !ok {
+		t.Fatal("receiver has no position")
+	}
+	if posB.Side != PositionLong || posB.SizeX18 != X18FromInt(1) || posB.EntryPxX18 != X18FromInt(110) {
+		t.Errorf("receiver = %+v, want long 1 @ 110", posB)
+	}
+
+	// Receiver without collateral cannot take the position
+	if err := dex.VaultTransferPosition(a, testAccount(4), 1, X18FromInt(1), X18FromInt(100)); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("transfer to unfunded account error = %v, want ErrInsufficientMargin", err)
+	}
+}
+
+func TestBookPlaceOrders(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	orders := []Order{
+		{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)},
+		{MarketID: 42, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}, // unknown market
+		{MarketID: 1, IsBuy: false, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(101)},
+	}
+	results, err := dex.BookPlaceOrders(maker, orders)
+	if err != nil {
+		t.Fatalf("BookPlaceOrders failed: %v", err)
+	}
+	if len(results) != len(orders) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(orders))
+	}
+	wantStatus := []OrderStatus{StatusOpen, StatusRejected, StatusOpen}
+	for i, r := range results {
+		if r.Status != wantStatus[i] {
+			t.Errorf("result %d status = %d, want %d", i, r.Status, wantStatus[i])
+		}
+	}
+	if l1 := dex.BookGetL1(1); l1.BestBidPxX18 != X18FromInt(99) || l1.BestAskPxX18 != X18FromInt(101) {
+		t.Errorf("BBO = %f/%f, want 99/101", l1.BestBidPxX18.ToFloat(), l1.BestAskPxX18.ToFloat())
+	}
+}
+
+func TestBookCancelOrders(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	results, err := dex.BookPlaceOrders(maker, benchQuotes(3))
+	if err != nil {
+		t.Fatalf("BookPlaceOrders failed: %v", err)
+	}
+
+	oids := []uint64{results[0].OID, 999_999, results[2].OID}
+	errs, err := dex.BookCancelOrders(maker, 1, oids)
+	if err != nil {
+		t.Fatalf("BookCancelOrders failed: %v", err)
+	}
+	if len(errs) != len(oids) {
+		t.Fatalf("len(errs) = %d, want %d", len(errs), len(oids))
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("errs = %v, want nil for known OIDs", errs)
+	}
+	if !errors.Is(errs[1], ErrOrderNotFound) {
+		t.Errorf("errs[1] = %v, want ErrOrderNotFound", errs[1])
+	}
+
+	// Only the uncancelled middle bid is left
+	if o, ok := dex.BookGetOrder(1, results[1].OID); !ok || o.Status != StatusOpen {
+		t.Errorf("order %d not open after batch cancel", results[1].OID)
+	}
+	if l1 := dex.BookGetL1(1); l1.BestBidPxX18 != X18FromInt(51) {
+		t.Errorf("best bid = %f, want 51", l1.BestBidPxX18.ToFloat())
+	}
+}
+
+func TestVaultStakingFeeDiscount(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	if err := dex.VaultSetStakingFeeTiers([]StakingFeeTier{
+		{MinStakedX18: X18FromInt(1000), TakerDiscountX18: X18FromFloat(0.5)},
+	}); err != nil {
+		t.Fatalf("VaultSetStakingFeeTiers failed: %v", err)
+	}
+
+	// takerFee returns what one 1 @ 100 IOC buy costs the taker. Fees are
+	// charged in the zero currency.
+	var native Currency
+	takerFee := func(maker, taker Account) float64 {
+		t.Helper()
+		for _, acct := range []Account{maker, taker} {
+			if err := dex.VaultDeposit(acct, native, X18FromInt(10000)); err != nil {
+				t.Fatalf("VaultDeposit failed: %v", err)
+			}
+		}
+		before := dex.VaultGetBalance(taker, native).ToFloat()
+		if _, err := dex.BookPlaceOrder(maker, Order{
+			MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100),
+		}); err != nil {
+			t.Fatalf("maker BookPlaceOrder failed: %v", err)
+		}
+		if _, err := dex.BookPlaceOrder(taker, Order{
+			MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100), TIF: TifIOC,
+		}); err != nil {
+			t.Fatalf("taker BookPlaceOrder failed: %v", err)
+		}
+		return before - dex.VaultGetBalance(taker, native).ToFloat()
+	}
+
+	base := takerFee(testAccount(1), testAccount(2))
+	if base <= 0 {
+		t.Fatalf("base taker fee = %f, want > 0", base)
+	}
+
+	staker := testAccount(4)
+	if err := dex.VaultSetStakedBalance(staker, X18FromInt(1500)); err != nil {
+		t.Fatalf("VaultSetStakedBalance failed: %v", err)
+	}
+	discounted := takerFee(testAccount(3), staker)
+	if diff := discounted - base/2; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("staked taker fee = %f, want half of %f", discounted, base)
+	}
+
+	// A discount above 1 would pay the account to trade
+	for _, tier := range []StakingFeeTier{
+		{MinStakedX18: X18FromInt(1000), TakerDiscountX18: X18FromFloat(1.5)},
+		{MinStakedX18: X18FromInt(1000), MakerDiscountX18: X18FromFloat(-0.1)},
+		{MinStakedX18: X18FromInt(-1), TakerDiscountX18: X18FromFloat(0.5)},
+	} {
+		if err := dex.VaultSetStakingFeeTiers([]StakingFeeTier{tier}); !errors.Is(err, ErrInvalidFee) {
+			t.Errorf("VaultSetStakingFeeTiers(%+v) error = %v, want ErrInvalidFee", tier, err)
+		}
+	}
+	if again := takerFee(testAccount(5), staker); again-discounted > 1e-9 || again-discounted < -1e-9 {
+		t.Errorf("staked taker fee after rejected schedules = %f, want %f", again, discounted)
+	}
+}
+
+func TestSetBookTradeListener(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	var trades []Trade
+	if err := dex.SetBookTradeListener(func(tr Trade) { trades = append(trades, tr) }); err != nil {
+		t.Fatalf("SetBookTradeListener failed: %v", err)
+	}
+
+	maker, taker := testAccount(1), testAccount(2)
+	openPosition(t, dex, taker, maker, 1, 1, 100)
+
+	if len(trades) != 1 {
+		t.Fatalf("listener saw %d trades, want 1", len(trades))
+	}
+	tr := trades[0]
+	if tr.Maker != maker || tr.Taker != taker || !tr.TakerIsBuy {
+		t.Errorf("trade parties = %+v, want maker sell / taker buy", tr)
+	}
+	if tr.PxX18 != X18FromInt(100) || tr.SzX18 != X18FromInt(1) {
+		t.Errorf("trade = %f @ %f, want 1 @ 100", tr.SzX18.ToFloat(), tr.PxX18.ToFloat())
+	}
+
+	// Removing the listener stops delivery
+	if err := dex.SetBookTradeListener(nil); err != nil {
+		t.Fatalf("SetBookTradeListener(nil) failed: %v", err)
+	}
+	openPosition(t, dex, taker, maker, 1, 1, 100)
+	if len(trades) != 1 {
+		t.Errorf("listener saw %d trades after removal, want 1", len(trades))
+	}
+}
+
+func TestSubscribeSystemEvents(t *testing.T) {
+	dex := newTestLX(t)
+	events, unsubscribe := dex.SubscribeSystemEvents()
+	defer unsubscribe()
+
+	// A feed market whose asset has never been priced reads a stale index
+	if err := dex.OracleRegisterAsset(7); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	if err := dex.FeedRegisterMarket(1, 7); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+	if _, err := dex.FeedGetIndexPrice(1); err == nil {
+		t.Fatal("FeedGetIndexPrice succeeded without a price")
+	}
+
+	// A book market in inactive status is halted
+	if err := dex.BookCreateMarket(BookMarketConfig{
+		MarketID: 2, SymbolID: 2, TickSizeX18: X18FromFloat(0.01), LotSizeX18: X18FromFloat(0.001), Status: 0,
+	}); err != nil {
+		t.Fatalf("BookCreateMarket failed: %v", err)
+	}
+
+	want := map[SystemEventKind]Severity{
+		EventOraclePriceStale: SeverityWarning,
+		EventMarketHalted:     SeverityCritical,
+	}
+	for len(want) > 0 {
+		var ev SystemEvent
+		select {
+		case ev = <-events:
+		default:
+			t.Fatalf("missing system events: %v", want)
+		}
+		sev, ok := want[ev.Kind]
+		if !ok {
+			continue
+		}
+		if ev.Severity != sev {
+			t.Errorf("event %d severity = %s, want %s", ev.Kind, ev.Severity, sev)
+		}
+		delete(want, ev.Kind)
+	}
+
+	unsubscribe()
+	for {
+		select {
+		case _, open := <-events:
+			if !open {
+				return
+			}
+		default:
+			t.Fatal("channel still open after unsubscribe")
+		}
+	}
+}
+
+func TestSubscribeL1(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(1_000_000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	updates, unsubscribe := dex.SubscribeL1(1)
+
+	// Improve the bid more times than the buffer holds without reading
+	const n = 100
+	for i := 1; i <= n; i++ {
+		if _, err := dex.BookPlaceOrder(maker, Order{
+			MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(int64(i)),
+		}); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	// Drop-oldest keeps the newest updates
+	if len(updates) != cap(updates) {
+		t.Fatalf("buffered %d updates, want full buffer of %d", len(updates), cap(updates))
+	}
+	var last L1
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	if last.BestBidPxX18 != X18FromInt(n) {
+		t.Errorf("latest bid = %f, want %d", last.BestBidPxX18.ToFloat(), n)
+	}
+
+	unsubscribe()
+	if _, open := <-updates; open {
+		t.Error("channel still open after unsubscribe")
+	}
+}
+
+func TestContextVariantsHonorCancellation(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	key := setupPool(t, dex, X18FromInt(1000))
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dex.PoolSwapContext(ctx, key, SwapParams{ZeroForOne: true, AmountSpecified: X18FromInt(1)}); !errors.Is(err, context.Canceled) {
+		t.Errorf("PoolSwapContext error = %v, want context.Canceled", err)
+	}
+	order := Order{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}
+	if _, err := dex.BookPlaceOrderContext(ctx, maker, order); !errors.Is(err, context.Canceled) {
+		t.Errorf("BookPlaceOrderContext error = %v, want context.Canceled", err)
+	}
+	if _, err := dex.BookPlaceOrdersContext(ctx, maker, []Order{order}); !errors.Is(err, context.Canceled) {
+		t.Errorf("BookPlaceOrdersContext error = %v, want context.Canceled", err)
+	}
+	if _, err := dex.PrecompileCallContext(ctx, LXPoolAddress, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("PrecompileCallContext error = %v, want context.Canceled", err)
+	}
+
+	// The cancelled placements never reached the book
+	if l1 := dex.BookGetL1(1); !l1.BestBidPxX18.IsZero() {
+		t.Errorf("best bid = %f after cancelled placement, want none", l1.BestBidPxX18.ToFloat())
+	}
+}
+
+func TestOracleGetSourcePrice(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	if err := dex.OracleRegisterAsset(assetID); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	if err := dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(50000), X18FromInt(100)); err != nil {
+		t.Fatalf("OracleUpdatePrice failed: %v", err)
+	}
+
+	px, conf, ts, ok := dex.OracleGetSourcePrice(assetID, SourceBinance)
+	if !ok {
+		t.Fatal("OracleGetSourcePrice(Binance) = false, want true")
+	}
+	if px != X18FromInt(50000) || conf != X18FromInt(100) {
+		t.Errorf("got price %f conf %f, want 50000 and 100", px.ToFloat(), conf.ToFloat())
+	}
+	if ts == 0 {
+		t.Error("timestamp = 0, want submission time")
+	}
+
+	if _, _, _, ok := dex.OracleGetSourcePrice(assetID, SourceCoinbase); ok {
+		t.Error("OracleGetSourcePrice(Coinbase) = true for a source that never reported")
+	}
+}
+
+func TestOracleUpdatePrices(t *testing.T) {
+	dex := newTestLX(t)
+	for _, id := range []uint64{1, 2} {
+		if err := dex.OracleRegisterAsset(id); err != nil {
+			t.Fatalf("OracleRegisterAsset(%d) failed: %v", id, err)
+		}
+	}
+
+	err := dex.OracleUpdatePrices([]OracleUpdate{
+		{AssetID: 1, Source: SourceBinance, Price: X18FromInt(50000), Confidence: X18FromInt(10)},
+		{AssetID: 2, Source: SourceBinance, Price: X18Zero()},
+		{AssetID: 2, Source: SourcePyth, Price: X18FromInt(3000), Confidence: X18FromInt(1)},
+	})
+	var batchErr *OracleUpdateError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("OracleUpdatePrices error = %v, want *OracleUpdateError", err)
+	}
+	if len(batchErr.Errs) != 3 || batchErr.Errs[0] != nil || !errors.Is(batchErr.Errs[1], ErrInvalidPrice) || batchErr.Errs[2] != nil {
+		t.Errorf("per-entry errors = %v, want [nil invalid price nil]", batchErr.Errs)
+	}
+	if msg := (&OracleUpdateError{Errs: []error{nil}}).Error(); msg == "" {
+		t.Error("OracleUpdateError with no failures has an empty message")
+	}
+
+	if px, _, _, ok := dex.OracleGetSourcePrice(1, SourceBinance); !ok || px != X18FromInt(50000) {
+		t.Errorf("asset 1 Binance = %f, %v, want 50000", px.ToFloat(), ok)
+	}
+	if px, _, _, ok := dex.OracleGetSourcePrice(2, SourcePyth); !ok || px != X18FromInt(3000) {
+		t.Errorf("asset 2 Pyth = %f, %v, want 3000", px.ToFloat(), ok)
+	}
+	if _, _, _, ok := dex.OracleGetSourcePrice(2, SourceBinance); ok {
+		t.Error("rejected update was applied")
+	}
+
+	if err := dex.OracleUpdatePrices([]OracleUpdate{{AssetID: 1, Source: SourceCoinbase, Price: X18FromInt(50010)}}); err != nil {
+		t.Errorf("OracleUpdatePrices(valid) = %v", err)
+	}
+	if err := dex.OracleUpdatePrices(nil); err != nil {
+		t.Errorf("OracleUpdatePrices(nil) = %v", err)
+	}
+}
+
+func TestOracleCircuitBreaker(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	if err := dex.OracleRegisterAsset(assetID); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	if err := dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1)); err != nil {
+		t.Fatalf("OracleUpdatePrice failed: %v", err)
+	}
+	if err := dex.OracleSetCircuitBreaker(assetID, 500); err != nil {
+		t.Fatalf("OracleSetCircuitBreaker failed: %v", err)
+	}
+
+	// 4% from the aggregate passes a 5% breaker
+	if err := dex.OracleUpdatePrice(assetID, SourceCoinbase, X18FromInt(104), X18FromInt(1)); err != nil {
+		t.Errorf("4%% move: %v", err)
+	}
+	err := dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(110), X18FromInt(1))
+	if !errors.Is(err, ErrPriceDeviation) {
+		t.Errorf("10%% move: err = %v, want ErrPriceDeviation", err)
+	}
+	if px, _, _, _ := dex.OracleGetSourcePrice(assetID, SourceBinance); px != X18FromInt(100) {
+		t.Errorf("Binance price after rejection = %f, want 100", px.ToFloat())
+	}
+
+	var batchErr *OracleUpdateError
+	err = dex.OracleUpdatePrices([]OracleUpdate{{AssetID: assetID, Source: SourcePyth, Price: X18FromInt(50)}})
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errs[0], ErrPriceDeviation) {
+		t.Errorf("batched 50%% move: err = %v, want ErrPriceDeviation", err)
+	}
+
+	if err := dex.OracleSetCircuitBreaker(assetID, 0); err != nil {
+		t.Fatalf("OracleSetCircuitBreaker(0) failed: %v", err)
+	}
+	if err := dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(110), X18FromInt(1)); err != nil {
+		t.Errorf("move with breaker off: %v", err)
+	}
+
+	if err := dex.OracleSetCircuitBreaker(99, 500); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unregistered asset error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestOracleRemoveSource(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	if err := dex.OracleRegisterAsset(assetID); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	dex.OracleUpdatePrice(assetID, SourceCoinbase, X18FromInt(300), X18FromInt(1))
+
+	if err := dex.OracleRemoveSource(assetID, SourceCoinbase); err != nil {
+		t.Fatalf("OracleRemoveSource failed: %v", err)
+	}
+	if px, err := dex.OracleGetPrice(assetID); err != nil || px != X18FromInt(100) {
+		t.Errorf("aggregate after removal = %f, %v, want 100", px.ToFloat(), err)
+	}
+	if _, _, _, ok := dex.OracleGetSourcePrice(assetID, SourceCoinbase); ok {
+		t.Error("removed source still has a stored price")
+	}
+	err := dex.OracleUpdatePrice(assetID, SourceCoinbase, X18FromInt(300), X18FromInt(1))
+	if !errors.Is(err, ErrSourceUnavailable) {
+		t.Errorf("update from removed source: err = %v, want ErrSourceUnavailable", err)
+	}
+
+	if err := dex.OracleRestoreSource(assetID, SourceCoinbase); err != nil {
+		t.Fatalf("OracleRestoreSource failed: %v", err)
+	}
+	if err := dex.OracleUpdatePrice(assetID, SourceCoinbase, X18FromInt(101), X18FromInt(1)); err != nil {
+		t.Errorf("update after restore: %v", err)
+	}
+	if _, _, _, ok := dex.OracleGetSourcePrice(assetID, SourceCoinbase); !ok {
+		t.Error("restored source has no stored price")
+	}
+
+	if err := dex.OracleRemoveSource(99, SourceBinance); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unregistered asset error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestOracleGetTWAP(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	if err := dex.OracleRegisterAsset(assetID); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	if _, err := dex.OracleGetTWAP(assetID, 60); !errors.Is(err, ErrNoPriceHistory) {
+		t.Errorf("TWAP before any update error = %v, want ErrNoPriceHistory", err)
+	}
+
+	if err := dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(50000), X18FromInt(100)); err != nil {
+		t.Fatalf("OracleUpdatePrice failed: %v", err)
+	}
+	twap, err := dex.OracleGetTWAP(assetID, 60)
+	if err != nil {
+		t.Fatalf("OracleGetTWAP failed: %v", err)
+	}
+	if twap != X18FromInt(50000) {
+		t.Errorf("TWAP = %f, want 50000", twap.ToFloat())
+	}
+}
+
+func TestOracleStaleness(t *testing.T) {
+	dex := newTestLX(t)
+	const stable, volatile = 1, 2
+	for _, id := range []uint64{stable, volatile} {
+		if err := dex.OracleRegisterAsset(id); err != nil {
+			t.Fatalf("OracleRegisterAsset(%d) failed: %v", id, err)
+		}
+	}
+	if got, err := dex.OracleGetStaleness(stable); err != nil || got != DefaultOracleStaleness {
+		t.Errorf("OracleGetStaleness() = %d, %v, want %d, nil", got, err, DefaultOracleStaleness)
+	}
+	if err := dex.OracleSetStaleness(volatile, 1); err != nil {
+		t.Fatalf("OracleSetStaleness failed: %v", err)
+	}
+	if got, err := dex.OracleGetStaleness(volatile); err != nil || got != 1 {
+		t.Errorf("OracleGetStaleness() = %d, %v, want 1, nil", got, err)
+	}
+	if _, err := dex.OracleGetStaleness(99); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("OracleGetStaleness(unknown) error = %v, want ErrMarketNotFound", err)
+	}
+
+	for _, id := range []uint64{stable, volatile} {
+		if err := dex.OracleUpdatePrice(id, SourceBinance, X18FromInt(100), X18FromInt(1)); err != nil {
+			t.Fatalf("OracleUpdatePrice(%d) failed: %v", id, err)
+		}
+	}
+	time.Sleep(2100 * time.Millisecond)
+
+	if dex.OracleIsPriceFresh(volatile) {
+		t.Error("price with a 1s threshold still fresh after 2s")
+	}
+	if !dex.OracleIsPriceFresh(stable) {
+		t.Error("price with the default threshold stale after 2s")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	if h := dex.Health(); !h.Running || !h.Ready() || h.OpenOrders != 0 {
+		t.Errorf("fresh engine health = %+v, want running and ready", h)
+	}
+
+	// Asset 2 is registered but never priced
+	for _, id := range []uint64{1, 2} {
+		if err := dex.OracleRegisterAsset(id); err != nil {
+			t.Fatalf("OracleRegisterAsset(%d) failed: %v", id, err)
+		}
+	}
+	if err := dex.OracleUpdatePrice(1, SourceBinance, X18FromInt(100), X18FromInt(1)); err != nil {
+		t.Fatalf("OracleUpdatePrice failed: %v", err)
+	}
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, px := range []int64{98, 99} {
+		if _, err := dex.BookPlaceOrder(maker, Order{
+			MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(px),
+		}); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	h := dex.Health()
+	if h.Ready() || h.StaleAssetCount != 1 || h.OldestStaleAsset != 2 || h.OpenOrders != 2 {
+		t.Errorf("degraded health = %+v, want asset 2 stale and 2 open orders", h)
+	}
+
+	if err := dex.OracleUpdatePrice(2, SourceBinance, X18FromInt(50), X18FromInt(1)); err != nil {
+		t.Fatalf("OracleUpdatePrice failed: %v", err)
+	}
+	if h := dex.Health(); !h.Ready() {
+		t.Errorf("health with every asset priced = %+v, want ready", h)
+	}
+
+	dex.Stop()
+	if h := dex.Health(); h.Running || h.Ready() {
+		t.Errorf("stopped engine health = %+v, want not running", h)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	bid := Order{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}
+	resting, err := dex.BookPlaceOrder(maker, bid)
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := dex.Drain(ctx); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if !dex.IsDraining() {
+		t.Error("IsDraining = false after Drain")
+	}
+	if result, err := dex.BookPlaceOrder(maker, bid); !errors.Is(err, ErrDraining) || result.Status != StatusRejected {
+		t.Errorf("place while draining = %+v, %v, want rejected with ErrDraining", result, err)
+	}
+	if err := dex.BookCancelOrder(maker, 1, resting.OID); err != nil {
+		t.Errorf("cancel while draining: %v", err)
+	}
+
+	// Start after Stop accepts orders again
+	dex.Stop()
+	dex.Start()
+	if dex.IsDraining() {
+		t.Error("IsDraining = true after restart")
+	}
+	if result, err := dex.BookPlaceOrder(maker, bid); err != nil || result.Status == StatusRejected {
+		t.Errorf("place after restart = %+v, %v", result, err)
+	}
+}
+
+func TestCancelOnDisconnect(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	openOrders := func() uint64 {
+		stats, err := dex.BookGetMarketStats(1)
+		if err != nil {
+			t.Fatalf("BookGetMarketStats failed: %v", err)
+		}
+		return stats.OpenOrders
+	}
+
+	if err := dex.Heartbeat(maker); !errors.Is(err, ErrNotArmed) {
+		t.Errorf("Heartbeat before arming = %v, want ErrNotArmed", err)
+	}
+	if err := dex.ArmCancelOnDisconnect(maker, 50); err != nil {
+		t.Fatalf("ArmCancelOnDisconnect failed: %v", err)
+	}
+	bid := Order{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}
+	if _, err := dex.BookPlaceOrder(maker, bid); err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	// Heartbeats keep the order alive past the original deadline
+	for i := 0; i < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if err := dex.Heartbeat(maker); err != nil {
+			t.Fatalf("Heartbeat %d failed: %v", i, err)
+		}
+	}
+	if n := openOrders(); n != 1 {
+		t.Fatalf("open orders while heartbeating = %d, want 1", n)
+	}
+
+	// Stop heartbeating: the timer cancels the order and disarms
+	deadline := time.Now().Add(time.Second)
+	for openOrders() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := openOrders(); n != 0 {
+		t.Errorf("open orders after the switch tripped = %d, want 0", n)
+	}
+	if err := dex.Heartbeat(maker); !errors.Is(err, ErrNotArmed) {
+		t.Errorf("Heartbeat after tripping = %v, want ErrNotArmed", err)
+	}
+
+	// Disarming leaves orders resting
+	if err := dex.ArmCancelOnDisconnect(maker, 10); err != nil {
+		t.Fatalf("ArmCancelOnDisconnect failed: %v", err)
+	}
+	if err := dex.ArmCancelOnDisconnect(maker, 0); err != nil {
+		t.Fatalf("disarm failed: %v", err)
+	}
+	if _, err := dex.BookPlaceOrder(maker, bid); err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := openOrders(); n != 1 {
+		t.Errorf("open orders after disarming = %d, want 1", n)
+	}
+}
+
+func TestBookGetOpenOrders(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	setupPerpMarket(t, dex, 2)
+	trader := testAccount(1)
+	if err := dex.VaultDeposit(trader, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+
+	var oids []uint64
+	for _, marketID := range []uint32{1, 2, 1} {
+		bid := Order{MarketID: marketID, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(99)}
+		res, err := dex.BookPlaceOrder(trader, bid)
+		if err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+		oids = append(oids, res.OID)
+	}
+	if err := dex.BookCancelOrder(trader, 1, oids[0]); err != nil {
+		t.Fatalf("BookCancelOrder failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		marketID uint32
+		want     []uint64
+	}{
+		{1, []uint64{oids[2]}},
+		{2, []uint64{oids[1]}},
+		{0, []uint64{oids[1], oids[2]}},
+	} {
+		orders, err := dex.BookGetOpenOrders(trader, tc.marketID)
+		if err != nil {
+			t.Fatalf("BookGetOpenOrders(%d) failed: %v", tc.marketID, err)
+		}
+		var got []uint64
+		for _, o := range orders {
+			if o.Status != StatusOpen {
+				t.Errorf("order %d status = %v, want open", o.OID, o.Status)
+			}
+			got = append(got, o.OID)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("BookGetOpenOrders(%d) = %v, want %v", tc.marketID, got, tc.want)
+		}
+	}
+
+	orders, err := dex.BookGetOpenOrders(testAccount(2), 0)
+	if err != nil || len(orders) != 0 {
+		t.Errorf("BookGetOpenOrders for an idle account = %v, %v, want none", orders, err)
+	}
+}
+
+func TestOracleAggregation(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	if err := dex.OracleRegisterAsset(assetID); err != nil {
+		t.Fatalf("OracleRegisterAsset failed: %v", err)
+	}
+	if mode, err := dex.OracleGetAggregation(assetID); err != nil || mode != AggMedian {
+		t.Errorf("OracleGetAggregation() = %d, %v, want AggMedian, nil", mode, err)
+	}
+
+	// 100 with confidence 1 and 200 with confidence 3: the median is 150,
+	// the confidence-weighted mean (100*1 + 200/3) / (1 + 1/3) is 125.
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	dex.OracleUpdatePrice(assetID, SourceCoinbase, X18FromInt(200), X18FromInt(3))
+
+	for _, tc := range []struct {
+		mode AggregationMode
+		want float64
+	}{
+		{AggMedian, 150},
+		{AggMeanConfidenceWeighted, 125},
+	} {
+		if err := dex.OracleSetAggregation(assetID, tc.mode); err != nil {
+			t.Fatalf("OracleSetAggregation(%d) failed: %v", tc.mode, err)
+		}
+		if mode, _ := dex.OracleGetAggregation(assetID); mode != tc.mode {
+			t.Errorf("OracleGetAggregation() = %d, want %d", mode, tc.mode)
+		}
+		px, err := dex.OracleGetPrice(assetID)
+		if err != nil {
+			t.Fatalf("OracleGetPrice failed: %v", err)
+		}
+		if diff := px.ToFloat() - tc.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("mode %d price = %f, want %f", tc.mode, px.ToFloat(), tc.want)
+		}
+	}
+}
+
+func TestOracleLastKnownGood(t *testing.T) {
+	dex := newTestLX(t)
+	const assetID = 1
+	dex.OracleRegisterAsset(assetID)
+	dex.OracleSetStaleness(assetID, 1)
+	if err := dex.OracleSetAggregation(assetID, AggLastKnownGood); err != nil {
+		t.Fatalf("OracleSetAggregation failed: %v", err)
+	}
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	time.Sleep(2100 * time.Millisecond)
+
+	if dex.OracleIsPriceFresh(assetID) {
+		t.Error("price still fresh after 2s with a 1s threshold")
+	}
+	px, err := dex.OracleGetPrice(assetID)
+	if err != nil {
+		t.Fatalf("OracleGetPrice with AggLastKnownGood failed: %v", err)
+	}
+	if px != X18FromInt(100) {
+		t.Errorf("price = %f, want 100", px.ToFloat())
+	}
+}
+
+func TestFeedGetFundingHistory(t *testing.T) {
+	dex := newTestLX(t)
+	const marketID, assetID = 1, 1
+	dex.OracleRegisterAsset(assetID)
+	if err := dex.FeedRegisterMarket(marketID, assetID); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+
+	history, err := dex.FeedGetFundingHistory(marketID, 10)
+	if err != nil {
+		t.Fatalf("FeedGetFundingHistory failed: %v", err)
+	}
+	if history == nil || len(history) != 0 {
+		t.Errorf("history before any funding = %v, want empty slice", history)
+	}
+
+	for i := 0; i < 3; i++ {
+		dex.FeedCalculateFundingRate(marketID)
+	}
+	history, err = dex.FeedGetFundingHistory(marketID, 2)
+	if err != nil {
+		t.Fatalf("FeedGetFundingHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d samples, want 2", len(history))
+	}
+	if history[0].Timestamp < history[1].Timestamp {
+		t.Errorf("samples not newest-first: %d before %d", history[0].Timestamp, history[1].Timestamp)
+	}
+	current, _ := dex.FeedGetFundingRate(marketID)
+	if history[0].RateX18 != current.RateX18 {
+		t.Errorf("newest sample rate = %f, want current rate %f", history[0].RateX18.ToFloat(), current.RateX18.ToFloat())
+	}
+
+	if _, err := dex.FeedGetFundingHistory(99, 10); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestFeedFundingConfig(t *testing.T) {
+	dex := newTestLX(t)
+	const marketID, assetID = 1, 1
+	dex.OracleRegisterAsset(assetID)
+	if err := dex.FeedRegisterMarket(marketID, assetID); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+
+	cfg, err := dex.FeedGetFundingConfig(marketID)
+	if err != nil {
+		t.Fatalf("FeedGetFundingConfig failed: %v", err)
+	}
+	if want := (FundingConfig{IntervalSecs: 28800, ClampBps: 100}); cfg != want {
+		t.Errorf("default config = %+v, want %+v", cfg, want)
+	}
+
+	if err := dex.FeedSetFundingConfig(marketID, 3600, 50); err != nil {
+		t.Fatalf("FeedSetFundingConfig failed: %v", err)
+	}
+	dex.FeedCalculateFundingRate(marketID)
+	cfg, _ = dex.FeedGetFundingConfig(marketID)
+	if cfg.IntervalSecs != 3600 || cfg.ClampBps != 50 || cfg.NextFundingTime == 0 {
+		t.Fatalf("config after calculation = %+v", cfg)
+	}
+
+	// Lengthening the interval pushes the pending funding time back
+	if err := dex.FeedSetFundingConfig(marketID, 7200, 50); err != nil {
+		t.Fatalf("FeedSetFundingConfig failed: %v", err)
+	}
+	if got, _ := dex.FeedGetFundingConfig(marketID); got.NextFundingTime != cfg.NextFundingTime+3600 {
+		t.Errorf("NextFundingTime = %d, want %d", got.NextFundingTime, cfg.NextFundingTime+3600)
+	}
+
+	if err := dex.FeedSetFundingConfig(marketID, 0, 50); err == nil {
+		t.Error("FeedSetFundingConfig accepted a zero interval")
+	}
+	if err := dex.FeedSetFundingConfig(99, 3600, 50); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+	if _, err := dex.FeedGetFundingConfig(99); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("FeedGetFundingConfig unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestFeedSetMarkConfig(t *testing.T) {
+	dex := newTestLX(t)
+	const marketID, assetID = 1, 1
+	dex.OracleRegisterAsset(assetID)
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	if err := dex.FeedRegisterMarket(marketID, assetID); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+
+	// No smoothing, 1% clamp: a mid of 110 lifts the mark only to 101
+	if err := dex.FeedSetMarkConfig(marketID, 0, 100); err != nil {
+		t.Fatalf("FeedSetMarkConfig failed: %v", err)
+	}
+	dex.FeedUpdateBBO(marketID, X18FromInt(109), X18FromInt(111))
+	mp, err := dex.FeedGetMarkPrice(marketID)
+	if err != nil {
+		t.Fatalf("FeedGetMarkPrice failed: %v", err)
+	}
+	if mp.MarkPxX18 != X18FromInt(101) {
+		t.Errorf("clamped mark = %f, want 101", mp.MarkPxX18.ToFloat())
+	}
+
+	// Widen the clamp to 20%: the full premium passes through
+	if err := dex.FeedSetMarkConfig(marketID, 0, 2000); err != nil {
+		t.Fatalf("FeedSetMarkConfig failed: %v", err)
+	}
+	dex.FeedUpdateBBO(marketID, X18FromInt(109), X18FromInt(111))
+	if mp, _ := dex.FeedGetMarkPrice(marketID); mp.MarkPxX18 != X18FromInt(110) {
+		t.Errorf("unclamped mark = %f, want 110", mp.MarkPxX18.ToFloat())
+	}
+
+	if err := dex.FeedSetMarkConfig(99, 60, 100); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestFeedGetAllPrices(t *testing.T) {
+	dex := newTestLX(t)
+	const marketID, assetID = 1, 1
+	dex.OracleRegisterAsset(assetID)
+	if err := dex.FeedRegisterMarket(marketID, assetID); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+
+	// Registered but unpriced: zeros rather than an error
+	prices, err := dex.FeedGetAllPrices(marketID)
+	if err != nil {
+		t.Fatalf("FeedGetAllPrices failed: %v", err)
+	}
+	if !prices.IndexPxX18.IsZero() || !prices.LastPxX18.IsZero() || !prices.MidPxX18.IsZero() {
+		t.Errorf("unpriced market = %+v, want zero prices", prices)
+	}
+
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	dex.FeedUpdateLastPrice(marketID, X18FromInt(102))
+	dex.FeedUpdateBBO(marketID, X18FromInt(100), X18FromInt(104))
+	prices, err = dex.FeedGetAllPrices(marketID)
+	if err != nil {
+		t.Fatalf("FeedGetAllPrices failed: %v", err)
+	}
+	index, _ := dex.FeedGetIndexPrice(marketID)
+	mark, _ := dex.FeedGetMarkPrice(marketID)
+	funding, _ := dex.FeedGetFundingRate(marketID)
+	for _, c := range []struct {
+		name      string
+		got, want X18
+	}{
+		{"index", prices.IndexPxX18, index},
+		{"mark", prices.MarkPxX18, mark.MarkPxX18},
+		{"last", prices.LastPxX18, X18FromInt(102)},
+		{"mid", prices.MidPxX18, X18FromInt(102)},
+		{"premium", prices.PremiumX18, mark.PremiumX18},
+		{"funding", prices.FundingRateX18, funding.RateX18},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s = %f, want %f", c.name, c.got.ToFloat(), c.want.ToFloat())
+		}
+	}
+	if prices.NextFundingTime != funding.NextFundingTime {
+		t.Errorf("NextFundingTime = %d, want %d", prices.NextFundingTime, funding.NextFundingTime)
+	}
+
+	if _, err := dex.FeedGetAllPrices(99); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestFeedGetPrice(t *testing.T) {
+	dex := newTestLX(t)
+	const marketID, assetID = 1, 1
+	dex.OracleRegisterAsset(assetID)
+	dex.OracleUpdatePrice(assetID, SourceBinance, X18FromInt(100), X18FromInt(1))
+	if err := dex.FeedRegisterMarket(marketID, assetID); err != nil {
+		t.Fatalf("FeedRegisterMarket failed: %v", err)
+	}
+	dex.FeedUpdateLastPrice(marketID, X18FromInt(102))
+	dex.FeedUpdateBBO(marketID, X18FromInt(100), X18FromInt(104))
+
+	prices, err := dex.FeedGetAllPrices(marketID)
+	if err != nil {
+		t.Fatalf("FeedGetAllPrices failed: %v", err)
+	}
+	for pt, want := range map[PriceType]X18{
+		PriceIndex: prices.IndexPxX18,
+		PriceMark:  prices.MarkPxX18,
+		PriceLast:  prices.LastPxX18,
+		PriceMid:   prices.MidPxX18,
+	} {
+		got, err := dex.FeedGetPrice(marketID, pt)
+		if err != nil || got != want {
+			t.Errorf("FeedGetPrice(%d) = %f, %v, want %f", pt, got.ToFloat(), err, want.ToFloat())
+		}
+	}
+
+	if _, err := dex.FeedGetPrice(marketID, PriceType(9)); err == nil {
+		t.Error("unknown price type accepted")
+	}
+	if _, err := dex.FeedGetPrice(99, PriceIndex); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestNotional(t *testing.T) {
+	largest := x18FromBig(bigMaxX18)
+	smallest := x18FromBig(new(big.Int).Not(bigMaxX18))
+	tests := []struct {
+		name        string
+		price, size X18
+		want        X18
+	}{
+		{"whole", X18FromInt(100), X18FromInt(3), X18FromInt(300)},
+		{"fractional", X18FromFloat(0.5), X18FromFloat(0.25), X18FromFloat(0.125)},
+		{"short", X18FromInt(100), X18FromInt(-2), X18FromInt(-200)},
+		{"truncates toward zero", X18{Lo: 1}, X18{Lo: -999_999_999_999_999_999, Hi: -1}, X18Zero()},
+		{"saturates high", X18FromInt(1e12), X18FromInt(1e12), largest},
+		{"saturates low", X18FromInt(1e12), X18FromInt(-1e12), smallest},
+	}
+	for _, tt := range tests {
+		if got := Notional(tt.price, tt.size); got != tt.want {
+			t.Errorf("%s: Notional = %s, want %s", tt.name, got.big(), tt.want.big())
+		}
+	}
+
+	cfg := BookMarketConfig{MinNotionalX18: X18FromInt(10)}
+	for _, tt := range []struct {
+		px, sz X18
+		want   bool
+	}{
+		{X18FromInt(100), X18FromFloat(0.1), true},
+		{X18FromInt(100), X18FromFloat(0.099), false},
+		{X18FromInt(100), X18FromFloat(-0.1), true},
+		{X18FromInt(1e12), X18FromInt(-1e12), true},
+	} {
+		if got := cfg.MeetsMinNotional(tt.px, tt.sz); got != tt.want {
+			t.Errorf("MeetsMinNotional(%f, %f) = %v, want %v", tt.px.ToFloat(), tt.sz.ToFloat(), got, tt.want)
+		}
+	}
+	if !(BookMarketConfig{}).MeetsMinNotional(X18Zero(), X18Zero()) {
+		t.Error("market without a minimum rejected an order")
+	}
+}
+
+func TestVaultDepositMulti(t *testing.T) {
+	dex := newTestLX(t)
+	acct := testAccount(1)
+	base := Currency{19: 0xbb}
+
+	if err := dex.VaultDepositMulti(acct, []Currency{testQuote, base}, []X18{X18FromInt(1)}); err == nil {
+		t.Error("mismatched lengths accepted")
+	}
+
+	err := dex.VaultDepositMulti(acct, []Currency{testQuote, base}, []X18{X18FromInt(100), X18FromInt(5)})
+	if err != nil {
+		t.Fatalf("VaultDepositMulti failed: %v", err)
+	}
+	if got := dex.VaultGetBalance(acct, testQuote); got != X18FromInt(100) {
+		t.Errorf("quote balance = %f, want 100", got.ToFloat())
+	}
+	if got := dex.VaultGetBalance(acct, base); got != X18FromInt(5) {
+		t.Errorf("base balance = %f, want 5", got.ToFloat())
+	}
+
+	// A rejected amount rolls back the whole batch
+	err = dex.VaultDepositMulti(acct, []Currency{testQuote, base}, []X18{X18FromInt(50), X18FromInt(-1)})
+	if err == nil {
+		t.Fatal("negative amount accepted")
+	}
+	if got := dex.VaultGetBalance(acct, testQuote); got != X18FromInt(100) {
+		t.Errorf("quote balance after failed batch = %f, want 100", got.ToFloat())
+	}
+}
+
+func TestVaultTransfer(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	main := testAccount(1)
+	sub := Account{Main: main.Main, SubaccountID: 1}
+	other := testAccount(2)
+
+	if err := dex.VaultDeposit(main, testQuote, X18FromInt(1000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	if err := dex.VaultTransfer(main, sub, testQuote, X18FromInt(400)); err != nil {
+		t.Fatalf("VaultTransfer failed: %v", err)
+	}
+	if got := dex.VaultGetBalance(main, testQuote); got != X18FromInt(600) {
+		t.Errorf("from balance = %f, want 600", got.ToFloat())
+	}
+	if got := dex.VaultGetBalance(sub, testQuote); got != X18FromInt(400) {
+		t.Errorf("to balance = %f, want 400", got.ToFloat())
+	}
+
+	if err := dex.VaultTransfer(main, other, testQuote, X18FromInt(1)); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("cross-owner transfer error = %v, want ErrUnauthorized", err)
+	}
+	dex.VaultSetCrossOwnerTransfers(true)
+	if err := dex.VaultTransfer(main, other, testQuote, X18FromInt(1)); err != nil {
+		t.Errorf("cross-owner transfer after opt-in failed: %v", err)
+	}
+
+	if err := dex.VaultTransfer(sub, main, testQuote, X18FromInt(401)); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("overdraw error = %v, want ErrInsufficientBalance", err)
+	}
+}
+
+func TestVaultTransferMaintenanceMargin(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 100, 100)
+
+	var native Currency
+	sub := Account{Main: long.Main, SubaccountID: 1}
+	bal := dex.VaultGetBalance(long, native)
+	if err := dex.VaultTransfer(long, sub, native, bal); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("draining transfer error = %v, want ErrInsufficientMargin", err)
+	}
+}
+
+func TestVaultGetPositions(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	setupPerpMarket(t, dex, 2)
+	long, short := testAccount(1), testAccount(2)
+
+	positions, err := dex.VaultGetPositions(long)
+	if err != nil {
+		t.Fatalf("VaultGetPositions failed: %v", err)
+	}
+	if positions == nil || len(positions) != 0 {
+		t.Errorf("positions before trading = %v, want empty slice", positions)
+	}
+
+	openPosition(t, dex, long, short, 1, 1, 100)
+	openPosition(t, dex, long, short, 2, 2, 50)
+
+	positions, err = dex.VaultGetPositions(long)
+	if err != nil {
+		t.Fatalf("VaultGetPositions failed: %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("got %d positions, want 2", len(positions))
+	}
+	sizes := make(map[uint32]X18)
+	for _, p := range positions {
+		if p.Side != PositionLong {
+			t.Errorf("market %d side = %d, want long", p.MarketID, p.Side)
+		}
+		sizes[p.MarketID] = p.SizeX18
+	}
+	if sizes[1] != X18FromInt(1) || sizes[2] != X18FromInt(2) {
+		t.Errorf("sizes = %v, want 1 on market 1 and 2 on market 2", sizes)
+	}
+}
+
+func TestVaultCanReduceOnly(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+
+	order := func(isBuy bool, size int64) Order {
+		return Order{MarketID: 1, IsBuy: isBuy, Kind: OrderLimit, SizeX18: X18FromInt(size), LimitPxX18: X18FromInt(100), ReduceOnly: true}
+	}
+	if ok, err := dex.VaultCanReduceOnly(long, order(false, 1)); err != nil || ok {
+		t.Errorf("VaultCanReduceOnly without a position = %v, %v, want false", ok, err)
+	}
+
+	openPosition(t, dex, long, short, 1, 2, 100)
+	tests := []struct {
+		name    string
+		account Account
+		order   Order
+		want    bool
+	}{
+		{"long sells part", long, order(false, 1), true},
+		{"long sells all", long, order(false, 2), true},
+		{"long sells more", long, order(false, 3), false},
+		{"long buys", long, order(true, 1), false},
+		{"short buys all", short, order(true, 2), true},
+		{"short sells", short, order(false, 1), false},
+		{"other market", long, Order{MarketID: 2, SizeX18: X18FromInt(1)}, false},
+	}
+	for _, tt := range tests {
+		ok, err := dex.VaultCanReduceOnly(tt.account, tt.order)
+		if err != nil || ok != tt.want {
+			t.Errorf("%s: VaultCanReduceOnly = %v, %v, want %v", tt.name, ok, err, tt.want)
+		}
+	}
+}
+
+func TestBookPlaceOrderReduceOnly(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 2, 100)
+
+	flip := Order{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(3), LimitPxX18: X18FromInt(110), ReduceOnly: true}
+	result, err := dex.BookPlaceOrder(long, flip)
+	if !errors.Is(err, ErrReduceOnly) || result.Status != StatusRejected {
+		t.Errorf("flipping reduce-only = %+v, %v, want rejected with ErrReduceOnly", result, err)
+	}
+	if orders, _ := dex.BookGetOpenOrders(long, 1); len(orders) != 0 {
+		t.Errorf("open orders after rejected reduce-only = %d, want 0", len(orders))
+	}
+
+	closing := flip
+	closing.SizeX18 = X18FromInt(2)
+	if result, err := dex.BookPlaceOrder(long, closing); err != nil || result.Status == StatusRejected {
+		t.Errorf("closing reduce-only = %+v, %v, want accepted", result, err)
+	}
+}
+
+func TestVaultCheckOrderLimits(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 2, 100)
+
+	order := func(isBuy bool, size X18, reduceOnly bool) Order {
+		return Order{MarketID: 1, IsBuy: isBuy, Kind: OrderLimit, SizeX18: size, LimitPxX18: X18FromInt(100), ReduceOnly: reduceOnly}
+	}
+	tests := []struct {
+		name    string
+		account Account
+		order   Order
+		want    error
+	}{
+		{"within limits", long, order(true, X18FromInt(1), false), nil},
+		{"below min size", long, order(true, X18FromFloat(0.0005), false), ErrOrderTooSmall},
+		{"zero size", long, order(true, X18Zero(), false), ErrOrderTooSmall},
+		{"above max order size", long, order(true, X18FromInt(1001), false), ErrOrderTooLarge},
+		{"past max position", long, order(true, X18FromInt(999), false), ErrPositionLimit},
+		{"up to max position", long, order(true, X18FromInt(998), false), nil},
+		{"flip within max position", long, order(false, X18FromInt(1000), false), nil},
+		{"short past max position", short, order(false, X18FromInt(999), false), ErrPositionLimit},
+		{"reduce-only closes", long, order(false, X18FromInt(2), true), nil},
+		{"reduce-only flips", long, order(false, X18FromInt(3), true), ErrReduceOnly},
+		{"reduce-only adds", short, order(false, X18FromInt(1), true), ErrReduceOnly},
+		{"reduce-only without position", testAccount(3), order(true, X18FromInt(1), true), ErrReduceOnly},
+		{"unknown market", long, Order{MarketID: 2, SizeX18: X18FromInt(1)}, ErrMarketNotFound},
+	}
+	for _, tt := range tests {
+		err := dex.VaultCheckOrderLimits(tt.account, tt.order)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: VaultCheckOrderLimits = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVaultSettleFunding(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+
+	if _, err := dex.VaultSettleFunding(long, 1); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("VaultSettleFunding without a position: err = %v, want ErrPositionNotFound", err)
+	}
+
+	openPosition(t, dex, long, short, 1, 2, 100)
+	if err := dex.VaultAccrueFunding(1); err != nil {
+		t.Fatalf("VaultAccrueFunding: %v", err)
+	}
+
+	var quote Currency // funding settles in the default currency, like fees
+	before := dex.VaultGetBalance(long, quote)
+	paid, err := dex.VaultSettleFunding(long, 1)
+	if err != nil {
+		t.Fatalf("VaultSettleFunding(long): %v", err)
+	}
+	if got, want := dex.VaultGetBalance(long, quote), before.Add(paid); got.Cmp(want) != 0 {
+		t.Errorf("long balance after settlement = %v, want %v", got.ToFloat(), want.ToFloat())
+	}
+	received, err := dex.VaultSettleFunding(short, 1)
+	if err != nil {
+		t.Fatalf("VaultSettleFunding(short): %v", err)
+	}
+	// Equal and opposite positions: what the long pays the short receives
+	if !paid.Add(received).IsZero() {
+		t.Errorf("settled long %v, short %v; want opposite amounts", paid.ToFloat(), received.ToFloat())
+	}
+
+	again, err := dex.VaultSettleFunding(long, 1)
+	if err != nil || !again.IsZero() {
+		t.Errorf("second VaultSettleFunding = %v, %v, want zero", again.ToFloat(), err)
+	}
+}
+
+func TestVaultGetLiquidationPrice(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 100, 100)
+
+	// Single position: liq = entry -/+ (collateral - maintenance) / size
+	for _, tc := range []struct {
+		name    string
+		account Account
+		sign    float64
+	}{
+		{"long", long, -1},
+		{"short", short, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, ok := dex.VaultGetPosition(tc.account, 1)
+			if !ok {
+				t.Fatal("position not found")
+			}
+			margin := dex.VaultGetMargin(tc.account)
+			cushion := margin.TotalCollateralX18.ToFloat() - margin.MaintenanceMarginX18.ToFloat()
+			size := pos.SizeX18.ToFloat()
+			if size < 0 {
+				size = -size
+			}
+			want := pos.EntryPxX18.ToFloat() + tc.sign*cushion/size
+			if want < 0 {
+				want = 0
+			}
+
+			liq, err := dex.VaultGetLiquidationPrice(tc.account, 1)
+			if err != nil {
+				t.Fatalf("VaultGetLiquidationPrice failed: %v", err)
+			}
+			if diff := liq.ToFloat() - want; diff > 1e-6 || diff < -1e-6 {
+				t.Errorf("liquidation price = %f, want %f", liq.ToFloat(), want)
+			}
+		})
+	}
+
+	if _, err := dex.VaultGetLiquidationPrice(long, 2); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("missing position error = %v, want ErrPositionNotFound", err)
+	}
+}
+
+func TestVaultGetBuyingPower(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short, flat := testAccount(1), testAccount(2), testAccount(3)
+
+	if power, err := dex.VaultGetBuyingPower(flat, 1); err != nil || !power.IsZero() {
+		t.Errorf("VaultGetBuyingPower without collateral = %v, %v; want zero", power.ToFloat(), err)
+	}
+	if _, err := dex.VaultGetBuyingPower(flat, 2); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market: err = %v, want ErrMarketNotFound", err)
+	}
+
+	// Free margin at the 10% initial margin rate
+	openPosition(t, dex, long, short, 1, 10, 100)
+	power, err := dex.VaultGetBuyingPower(long, 1)
+	if err != nil {
+		t.Fatalf("VaultGetBuyingPower: %v", err)
+	}
+	want := dex.VaultGetMargin(long).FreeMarginX18.ToFloat() / 0.1
+	if diff := power.ToFloat() - want; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("buying power = %f, want %f", power.ToFloat(), want)
+	}
+
+	// A lower chosen leverage is the stricter rate
+	if err := dex.VaultDeposit(flat, testQuote, X18FromInt(1000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	if err := dex.VaultSetLeverage(flat, 1, X18FromInt(2)); err != nil {
+		t.Fatalf("VaultSetLeverage failed: %v", err)
+	}
+	power, err = dex.VaultGetBuyingPower(flat, 1)
+	if err != nil {
+		t.Fatalf("VaultGetBuyingPower: %v", err)
+	}
+	if got := power.ToFloat(); got < 1999.999999 || got > 2000.000001 {
+		t.Errorf("buying power at 2x leverage = %f, want 2000", got)
+	}
+}
+
+func TestVaultSetMarginModeAndLeverage(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+
+	if err := dex.VaultSetMarginMode(long, 1, MarginIsolated); err != nil {
+		t.Fatalf("VaultSetMarginMode while flat failed: %v", err)
+	}
+	if err := dex.VaultSetMarginMode(long, 99, MarginIsolated); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("unknown market error = %v, want ErrMarketNotFound", err)
+	}
+
+	if err := dex.VaultSetLeverage(long, 1, X18FromInt(5)); err != nil {
+		t.Fatalf("VaultSetLeverage(5x) failed: %v", err)
+	}
+	for _, lev := range []X18{X18FromInt(1000), X18FromFloat(0.5)} {
+		if err := dex.VaultSetLeverage(long, 1, lev); !errors.Is(err, ErrInvalidLeverage) {
+			t.Errorf("VaultSetLeverage(%fx) error = %v, want ErrInvalidLeverage", lev.ToFloat(), err)
+		}
+	}
+
+	// 5x means 20% initial margin, above the market's 10%
+	openPosition(t, dex, long, short, 1, 10, 100)
+	if got := dex.VaultGetMargin(long).UsedMarginX18; got != X18FromInt(200) {
+		t.Errorf("used margin at 5x = %f, want 200", got.ToFloat())
+	}
+	if got := dex.VaultGetMargin(short).UsedMarginX18; got != X18FromInt(100) {
+		t.Errorf("used margin at market default = %f, want 100", got.ToFloat())
+	}
+
+	if err := dex.VaultSetMarginMode(long, 1, MarginCross); !errors.Is(err, ErrPositionOpen) {
+		t.Errorf("mode switch with open position error = %v, want ErrPositionOpen", err)
+	}
+}
+
+func TestVaultGetAccountPnL(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+
+	if pnl, err := dex.VaultGetAccountPnL(long); err != nil || pnl != (AccountPnL{}) {
+		t.Errorf("fresh account PnL = %+v, %v, want zeros", pnl, err)
+	}
+
+	// Buy 10 @ 100, then sell them back @ 110 by reversing roles
+	openPosition(t, dex, long, short, 1, 10, 100)
+	openPosition(t, dex, short, long, 1, 10, 110)
+
+	pnl, err := dex.VaultGetAccountPnL(long)
+	if err != nil {
+		t.Fatalf("VaultGetAccountPnL failed: %v", err)
+	}
+	if pnl.RealizedPnlX18 != X18FromInt(100) {
+		t.Errorf("realized PnL = %f, want 100", pnl.RealizedPnlX18.ToFloat())
+	}
+	if !pnl.UnrealizedPnlX18.IsZero() {
+		t.Errorf("unrealized PnL when flat = %f, want 0", pnl.UnrealizedPnlX18.ToFloat())
+	}
+	if pnl.FeesPaidX18.IsZero() || pnl.FeesPaidX18.IsNegative() {
+		t.Errorf("fees paid = %f, want > 0", pnl.FeesPaidX18.ToFloat())
+	}
+}
+
+func TestVaultSetAccountFeeTier(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1) // market default: maker 2 bps, taker 5 bps
+	long, short, other := testAccount(1), testAccount(2), testAccount(3)
+
+	if err := dex.VaultSetAccountFeeTier(other, X18Zero(), X18FromInt(-1)); !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("negative taker fee: err = %v, want ErrInvalidFee", err)
+	}
+	if err := dex.VaultSetAccountFeeTier(other, X18FromInt(-2), X18FromInt(1)); !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("rebate above taker fee: err = %v, want ErrInvalidFee", err)
+	}
+
+	// The long takes at 1 bps; the short makes at a 0.5 bps rebate
+	if err := dex.VaultSetAccountFeeTier(long, X18Zero(), X18FromInt(1)); err != nil {
+		t.Fatalf("VaultSetAccountFeeTier(long): %v", err)
+	}
+	if err := dex.VaultSetAccountFeeTier(short, X18FromFloat(-0.5), X18FromInt(2)); err != nil {
+		t.Fatalf("VaultSetAccountFeeTier(short): %v", err)
+	}
+	openPosition(t, dex, long, short, 1, 2, 100)
+
+	for _, tc := range []struct {
+		name    string
+		account Account
+		want    float64
+	}{
+		{"taker", long, 200 * 0.0001},
+		{"maker", short, 200 * -0.00005},
+	} {
+		pnl, err := dex.VaultGetAccountPnL(tc.account)
+		if err != nil {
+			t.Fatalf("VaultGetAccountPnL failed: %v", err)
+		}
+		if diff := pnl.FeesPaidX18.ToFloat() - tc.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s fees paid = %f, want %f", tc.name, pnl.FeesPaidX18.ToFloat(), tc.want)
+		}
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	maker := testAccount(1)
+	if err := dex.VaultDeposit(maker, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	for _, o := range []Order{
+		{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(2), LimitPxX18: X18FromInt(99)},
+		{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(3), LimitPxX18: X18FromInt(101)},
+	} {
+		if _, err := dex.BookPlaceOrder(maker, o); err != nil {
+			t.Fatalf("BookPlaceOrder failed: %v", err)
+		}
+	}
+
+	snap, err := dex.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	restored := newTestLX(t)
+	if err := restored.Restore(snap); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got, want := restored.BookGetL1(1), dex.BookGetL1(1); got != want {
+		t.Errorf("restored L1 = %+v, want %+v", got, want)
+	}
+	if got, want := restored.VaultGetBalance(maker, testQuote), dex.VaultGetBalance(maker, testQuote); got != want {
+		t.Errorf("restored balance = %f, want %f", got.ToFloat(), want.ToFloat())
+	}
+
+	bad := append([]byte(nil), snap...)
+	bad[7]++
+	if err := newTestLX(t).Restore(bad); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("Restore(other version) error = %v, want ErrSnapshotVersion", err)
+	}
+	if err := newTestLX(t).Restore([]byte("garbage")); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("Restore(garbage) error = %v, want ErrInvalidSnapshot", err)
+	}
+}
+
+func TestJournalReplay(t *testing.T) {
+	dex := newTestLX(t)
+	var journal bytes.Buffer
+	dex.EnableJournal(&journal)
+
+	setupPerpMarket(t, dex, 1)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 2, 100)
+	res, err := dex.BookPlaceOrder(short, Order{
+		MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(105),
+	})
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+	if _, err := dex.BookModifyOrder(short, 1, res.OID, X18FromInt(106), X18FromInt(2)); err != nil {
+		t.Fatalf("BookModifyOrder failed: %v", err)
+	}
+	var native Currency // margin, fees and funding settle in the zero currency
+	sub := Account{Main: long.Main, SubaccountID: 1}
+	if err := dex.VaultTransfer(long, sub, native, X18FromInt(10)); err != nil {
+		t.Fatalf("VaultTransfer failed: %v", err)
+	}
+	if err := dex.VaultAccrueFunding(1); err != nil {
+		t.Fatalf("VaultAccrueFunding failed: %v", err)
+	}
+	if _, err := dex.VaultSettleFunding(long, 1); err != nil {
+		t.Fatalf("VaultSettleFunding failed: %v", err)
+	}
+	if err := dex.JournalErr(); err != nil {
+		t.Fatalf("JournalErr() = %v", err)
+	}
+
+	replayed, err := Replay(bytes.NewReader(journal.Bytes()))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	defer replayed.Close()
+
+	if got, want := replayed.BookGetL1(1), dex.BookGetL1(1); got != want {
+		t.Errorf("replayed L1 = %+v, want %+v", got, want)
+	}
+	for _, acct := range []Account{long, short, sub} {
+		for _, token := range []Currency{testQuote, native} {
+			if got, want := replayed.VaultGetBalance(acct, token), dex.VaultGetBalance(acct, token); got != want {
+				t.Errorf("replayed balance = %f, want %f", got.ToFloat(), want.ToFloat())
+			}
+		}
+		if acct == sub {
+			continue
+		}
+		got, _ := replayed.VaultGetPosition(acct, 1)
+		want, _ := dex.VaultGetPosition(acct, 1)
+		if got == nil || want == nil || *got != *want {
+			t.Errorf("replayed position = %+v, want %+v", got, want)
+		}
+	}
+
+	truncated := journal.Bytes()[:journal.Len()-1]
+	if _, err := Replay(bytes.NewReader(truncated)); !errors.Is(err, ErrInvalidJournal) {
+		t.Errorf("Replay(truncated) error = %v, want ErrInvalidJournal", err)
+	}
+}
+
+// failingWriter rejects every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestJournalWriteError(t *testing.T) {
+	dex := newTestLX(t)
+	dex.EnableJournal(failingWriter{})
+	if err := dex.JournalErr(); err == nil {
+		t.Error("JournalErr() = nil after a failed write")
+	}
+	dex.EnableJournal(nil)
+	if err := dex.JournalErr(); err != nil {
+		t.Errorf("JournalErr() after disabling = %v, want nil", err)
+	}
+}
+
+func TestBookGetMarketStats(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	setupPerpMarket(t, dex, 2)
+	long, short := testAccount(1), testAccount(2)
+	openPosition(t, dex, long, short, 1, 2, 100)
+
+	resting := Order{MarketID: 1, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(90), IsBuy: true}
+	res, err := dex.BookPlaceOrder(long, resting)
+	if err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+	if err := dex.BookCancelOrder(long, 1, res.OID); err != nil {
+		t.Fatalf("BookCancelOrder failed: %v", err)
+	}
+	if _, err := dex.BookPlaceOrder(long, resting); err != nil {
+		t.Fatalf("BookPlaceOrder failed: %v", err)
+	}
+
+	stats, err := dex.BookGetMarketStats(1)
+	if err != nil {
+		t.Fatalf("BookGetMarketStats failed: %v", err)
+	}
+	want := MarketStats{OrdersPlaced: 4, OrdersCancelled: 1, Trades: 1, VolumeX18: X18FromInt(2), OpenOrders: 1}
+	if stats != want {
+		t.Errorf("market 1 stats = %+v, want %+v", stats, want)
+	}
+
+	if stats, err := dex.BookGetMarketStats(2); err != nil || stats != (MarketStats{}) {
+		t.Errorf("market 2 stats = %+v, %v, want zero", stats, err)
+	}
+	if _, err := dex.BookGetMarketStats(99); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("BookGetMarketStats(99) error = %v, want ErrMarketNotFound", err)
+	}
+}
+
+func TestBookSelfTradePrevention(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+
+	trader := testAccount(1)
+	if err := dex.VaultDeposit(trader, testQuote, X18FromInt(10000)); err != nil {
+		t.Fatalf("VaultDeposit failed: %v", err)
+	}
+	order := func(isBuy bool, group uint64) Order {
+		return Order{MarketID: 1, IsBuy: isBuy, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(100), TIF: TifGTC, STPGroup: group}
+	}
+
+	if _, err := dex.BookPlaceOrder(trader, order(false, 7)); err != nil {
+		t.Fatalf("BookPlaceOrder(ask) failed: %v", err)
+	}
+	res, err := dex.BookPlaceOrder(trader, order(true, 7))
+	if err != nil {
+		t.Fatalf("BookPlaceOrder(bid) failed: %v", err)
+	}
+	if !res.FilledSizeX18.IsZero() {
+		t.Fatalf("same-group orders traded %f", res.FilledSizeX18.ToFloat())
+	}
+
+	// The default STPCancelMaker removes the resting ask; the bid rests
+	l1 := dex.BookGetL1(1)
+	if !l1.BestAskSzX18.IsZero() || l1.BestBidPxX18 != X18FromInt(100) {
+		t.Errorf("BBO = %f(%f)/%f, want bid 100 and no ask",
+			l1.BestBidPxX18.ToFloat(), l1.BestBidSzX18.ToFloat(), l1.BestAskPxX18.ToFloat())
+	}
+
+	// STPGroup 0 is exempt
+	res, err = dex.BookPlaceOrder(trader, order(false, 0))
+	if err != nil {
+		t.Fatalf("BookPlaceOrder(exempt) failed: %v", err)
+	}
+	if res.FilledSizeX18 != X18FromInt(1) {
+		t.Errorf("exempt order filled %f, want 1", res.FilledSizeX18.ToFloat())
+	}
+}
+
+func TestErrorFromCode(t *testing.T) {
+	if err := errorFromCode(0); err != nil {
+		t.Errorf("errorFromCode(0) = %v, want nil", err)
+	}
+
+	err := errorFromCode(-8)
+	if !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("errorFromCode(-8) = %v, want ErrInvalidFee", err)
+	}
+	var lxErr *LXError
+	if !errors.As(err, &lxErr) || lxErr.Code != -8 {
+		t.Errorf("errors.As(errorFromCode(-8)) = %+v, want Code -8", lxErr)
+	}
+	if err.Error() != ErrInvalidFee.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), ErrInvalidFee.Error())
+	}
+
+	err = errorFromCode(-99)
+	if !errors.As(err, &lxErr) || lxErr.Code != -99 {
+		t.Errorf("errors.As(errorFromCode(-99)) = %+v, want Code -99", lxErr)
+	}
+	if !strings.Contains(err.Error(), "-99") {
+		t.Errorf("unknown code error %q does not include the code", err)
+	}
+	if errors.Unwrap(err) != nil {
+		t.Errorf("unknown code unwraps to %v, want nil", errors.Unwrap(err))
+	}
+}
+
+// l1Calldata encodes getL1(marketID) for the book precompile.
+func l1Calldata(marketID uint32) []byte {
+	calldata := make([]byte, 36)
+	copy(calldata, []byte{0x4f, 0x55, 0xd2, 0x4d})
+	calldata[32] = byte(marketID >> 24)
+	calldata[33] = byte(marketID >> 16)
+	calldata[34] = byte(marketID >> 8)
+	calldata[35] = byte(marketID)
+	return calldata
+}
+
+func TestPrecompileCallInto(t *testing.T) {
+	dex := newTestLX(t)
+	setupPerpMarket(t, dex, 1)
+	calldata := l1Calldata(1)
+
+	want, err := dex.PrecompileCall(LXBookAddress, calldata)
+	if err != nil {
+		t.Fatalf("PrecompileCall failed: %v", err)
+	}
+	if len(want) == 0 {
+		t.Fatal("getL1 returned no data")
+	}
+
+	out := make([]byte, 256)
+	n, err := dex.PrecompileCallInto(LXBookAddress, calldata, out)
+	if err != nil {
+		t.Fatalf("PrecompileCallInto failed: %v", err)
+	}
+	if string(out[:n]) != string(want) {
+		t.Errorf("PrecompileCallInto wrote %x, want %x", out[:n], want)
+	}
+
+	n, err = dex.PrecompileCallInto(LXBookAddress, calldata, out[:len(want)-1])
+	if !errors.Is(err, ErrBufferTooSmall) {
+		t.Errorf("short buffer error = %v, want ErrBufferTooSmall", err)
+	}
+	if n != len(want) {
+		t.Errorf("short buffer n = %d, want required size %d", n, len(want))
+	}
+}
+
+// Benchmark tests
+
+func BenchmarkX18FromInt(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = X18FromInt(int64(i))
+	}
+}
+
+func BenchmarkX18FromFloat(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = X18FromFloat(float64(i))
+	}
+}
+
+func BenchmarkAddressFromLP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = AddressFromLP(0x9010)
+	}
+}
+
+func BenchmarkIsDEXPrecompile(b *testing.B) {
+	addr := LXPoolAddress
+	for i := 0; i < b.N; i++ {
+		_ = addr.IsDEXPrecompile()
+	}
+}
+
+func BenchmarkPrecompileCall(b *testing.B) {
+	dex := newTestLX(b)
+	setupPerpMarket(b, dex, 1)
+	calldata := l1Calldata(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dex.PrecompileCall(LXBookAddress, calldata)
+	}
+}
+
+func BenchmarkPrecompileCallInto(b *testing.B) {
+	dex := newTestLX(b)
+	setupPerpMarket(b, dex, 1)
+	calldata := l1Calldata(1)
+	out := make([]byte, 256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dex.PrecompileCallInto(LXBookAddress, calldata, out)
+	}
+}
+
+// benchQuotes returns n non-crossing resting bids for market 1.
+func benchQuotes(n int) []Order {
+	orders := make([]Order, n)
+	for i := range orders {
+		orders[i] = Order{MarketID: 1, IsBuy: true, Kind: OrderLimit, SizeX18: X18FromInt(1), LimitPxX18: X18FromInt(int64(50 + i))}
+	}
+	return orders
+}
+
+func BenchmarkBookPlaceOrders(b *testing.B) {
+	dex := newTestLX(b)
+	setupPerpMarket(b, dex, 1)
+	maker := testAccount(1)
+	dex.VaultDeposit(maker, testQuote, X18FromInt(1_000_000_000))
+	orders := benchQuotes(32)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dex.BookPlaceOrders(maker, orders)
+		b.StopTimer()
+		dex.BookCancelAll(maker, 1)
+		b.StartTimer()
+	}
+}
+
+func BenchmarkBookPlaceOrderLoop(b *testing.B) {
+	dex := newTestLX(b)
+	setupPerpMarket(b, dex, 1)
+	maker := testAccount(1)
+	dex.VaultDeposit(maker, testQuote, X18FromInt(1_000_000_000))
+	orders := benchQuotes(32)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, o := range orders {
+			dex.BookPlaceOrder(maker, o)
+		}
+		b.StopTimer()
+		dex.BookCancelAll(maker, 1)
+		b.StartTimer()
 	}
 }
@@ -0,0 +1,162 @@
+// Package mm provides market-making helpers built on the LX bindings.
+//
+// The Quoter is an Avellaneda-Stoikov style skeleton: it derives a
+// reservation price from the mark price skewed against inventory, sets a
+// spread from risk aversion and volatility, and keeps a two-sided quote
+// resting on the LX book. It is orchestration over existing LX calls and
+// adds no C code.
+package mm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/luxcpp/dex/bindings/go/lx"
+)
+
+// MarkSource supplies the fair value the quoter centers on.
+type MarkSource interface {
+	FeedGetMarkPrice(marketID uint32) (lx.MarkPrice, error)
+}
+
+// Venue is the subset of the LX controller the quoter trades through.
+type Venue interface {
+	BookCancelAll(sender lx.Account, marketID uint32) error
+	BookPlaceOrder(sender lx.Account, order lx.Order) (lx.PlaceResult, error)
+	VaultGetPosition(account lx.Account, marketID uint32) (*lx.Position, bool)
+}
+
+// Params configures the quoting model.
+type Params struct {
+	Size            float64       // Quote size per side
+	InventoryTarget float64       // Desired signed position (long > 0)
+	MaxInventory    float64       // Inventory deviation at which the spread grows by Widening
+	Gamma           float64       // Risk aversion
+	Sigma           float64       // Volatility, in price units per sqrt(second)
+	K               float64       // Order arrival intensity
+	Horizon         time.Duration // Remaining trading horizon (T - t)
+	Widening        float64       // Extra spread multiple at MaxInventory deviation
+	Interval        time.Duration // How often Run re-evaluates quotes
+}
+
+// DefaultParams returns conservative defaults for a quoter of the given size.
+func DefaultParams(size float64) Params {
+	return Params{
+		Size:         size,
+		MaxInventory: size * 10,
+		Gamma:        0.1,
+		Sigma:        1.0,
+		K:            1.5,
+		Horizon:      time.Minute,
+		Widening:     1.0,
+		Interval:     100 * time.Millisecond,
+	}
+}
+
+// Quoter maintains a two-sided quote in one market.
+type Quoter struct {
+	venue    Venue
+	marks    MarkSource
+	account  lx.Account
+	marketID uint32
+	params   Params
+
+	lastMark      float64
+	lastInventory float64
+}
+
+// NewQuoter creates a quoter for an account in a market.
+func NewQuoter(venue Venue, marks MarkSource, account lx.Account, marketID uint32, params Params) (*Quoter, error) {
+	if params.Size <= 0 || params.Gamma <= 0 || params.K <= 0 || params.Interval <= 0 {
+		return nil, errors.New("mm: invalid params")
+	}
+	return &Quoter{
+		venue:    venue,
+		marks:    marks,
+		account:  account,
+		marketID: marketID,
+		params:   params,
+	}, nil
+}
+
+// Quote computes bid and ask prices for a mark price and signed inventory.
+//
+// The reservation price is shifted against inventory so fills pull the
+// position back toward the target, and the spread widens linearly with the
+// absolute inventory deviation.
+func (q *Quoter) Quote(mark, inventory float64) (bid, ask float64) {
+	p := q.params
+	dev := inventory - p.InventoryTarget
+	tau := p.Horizon.Seconds()
+	variance := p.Sigma * p.Sigma * tau
+
+	reservation := mark - dev*p.Gamma*variance
+	spread := p.Gamma*variance + (2/p.Gamma)*math.Log(1+p.Gamma/p.K)
+	if p.MaxInventory > 0 {
+		spread *= 1 + p.Widening*math.Abs(dev)/p.MaxInventory
+	}
+	return reservation - spread/2, reservation + spread/2
+}
+
+// Run re-evaluates quotes every Interval until ctx is cancelled, re-quoting
+// only when the mark price or inventory has changed. On exit it pulls the
+// account's resting quotes and returns ctx.Err().
+func (q *Quoter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.params.Interval)
+	defer ticker.Stop()
+	defer q.venue.BookCancelAll(q.account, q.marketID)
+
+	for {
+		if err := q.step(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Quoter) step() error {
+	mp, err := q.marks.FeedGetMarkPrice(q.marketID)
+	if err != nil {
+		return err
+	}
+	mark := mp.MarkPxX18.ToFloat()
+	inventory := q.inventory()
+	if mark == q.lastMark && inventory == q.lastInventory {
+		return nil
+	}
+
+	bid, ask := q.Quote(mark, inventory)
+	if err := q.venue.BookCancelAll(q.account, q.marketID); err != nil {
+		return err
+	}
+	for _, o := range []lx.Order{
+		{MarketID: q.marketID, IsBuy: true, Kind: lx.OrderLimit, SizeX18: lx.X18FromFloat(q.params.Size), LimitPxX18: lx.X18FromFloat(bid), TIF: lx.TifALO},
+		{MarketID: q.marketID, IsBuy: false, Kind: lx.OrderLimit, SizeX18: lx.X18FromFloat(q.params.Size), LimitPxX18: lx.X18FromFloat(ask), TIF: lx.TifALO},
+	} {
+		if _, err := q.venue.BookPlaceOrder(q.account, o); err != nil {
+			return err
+		}
+	}
+
+	q.lastMark, q.lastInventory = mark, inventory
+	return nil
+}
+
+// inventory returns the account's signed position size (long > 0).
+func (q *Quoter) inventory() float64 {
+	pos, ok := q.venue.VaultGetPosition(q.account, q.marketID)
+	if !ok {
+		return 0
+	}
+	size := pos.SizeX18.ToFloat()
+	if pos.Side == lx.PositionShort {
+		return -size
+	}
+	return size
+}
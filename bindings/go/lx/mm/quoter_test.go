@@ -0,0 +1,99 @@
+package mm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luxcpp/dex/bindings/go/lx"
+)
+
+// scriptedVenue replays a fixed sequence of mark prices and grows the long
+// position by one quote size on every requote, as if each bid were hit.
+type scriptedVenue struct {
+	marks    []float64
+	size     float64
+	position float64
+	placed   []lx.Order
+}
+
+func (v *scriptedVenue) FeedGetMarkPrice(marketID uint32) (lx.MarkPrice, error) {
+	if len(v.marks) == 0 {
+		return lx.MarkPrice{}, errors.New("script exhausted")
+	}
+	mark := v.marks[0]
+	v.marks = v.marks[1:]
+	return lx.MarkPrice{MarkPxX18: lx.X18FromFloat(mark)}, nil
+}
+
+func (v *scriptedVenue) BookCancelAll(sender lx.Account, marketID uint32) error {
+	return nil
+}
+
+func (v *scriptedVenue) BookPlaceOrder(sender lx.Account, order lx.Order) (lx.PlaceResult, error) {
+	v.placed = append(v.placed, order)
+	if order.IsBuy {
+		v.position += v.size
+	}
+	return lx.PlaceResult{Status: lx.StatusOpen}, nil
+}
+
+func (v *scriptedVenue) VaultGetPosition(account lx.Account, marketID uint32) (*lx.Position, bool) {
+	if v.position == 0 {
+		return nil, false
+	}
+	return &lx.Position{Side: lx.PositionLong, SizeX18: lx.X18FromFloat(v.position)}, true
+}
+
+func TestQuoterWidensWithInventory(t *testing.T) {
+	venue := &scriptedVenue{
+		marks: []float64{100, 100.5, 101, 100.5, 100},
+		size:  1,
+	}
+	params := DefaultParams(1)
+	params.Interval = time.Millisecond
+
+	q, err := NewQuoter(venue, venue, lx.Account{}, 1, params)
+	if err != nil {
+		t.Fatalf("NewQuoter failed: %v", err)
+	}
+	if err := q.Run(context.Background()); err == nil {
+		t.Fatal("Run() = nil, want error once the script is exhausted")
+	}
+
+	if len(venue.placed) != 10 {
+		t.Fatalf("placed %d orders, want 10 (bid+ask per mark)", len(venue.placed))
+	}
+
+	prevSpread := 0.0
+	for i := 0; i < len(venue.placed); i += 2 {
+		bid := venue.placed[i].LimitPxX18.ToFloat()
+		ask := venue.placed[i+1].LimitPxX18.ToFloat()
+		if bid >= ask {
+			t.Fatalf("quote %d crossed: bid %f >= ask %f", i/2, bid, ask)
+		}
+		spread := ask - bid
+		if spread <= prevSpread {
+			t.Errorf("quote %d spread = %f, want wider than %f", i/2, spread, prevSpread)
+		}
+		prevSpread = spread
+	}
+}
+
+func TestQuoteSkewsAgainstInventory(t *testing.T) {
+	q, err := NewQuoter(nil, nil, lx.Account{}, 1, DefaultParams(1))
+	if err != nil {
+		t.Fatalf("NewQuoter failed: %v", err)
+	}
+
+	flatBid, flatAsk := q.Quote(100, 0)
+	longBid, longAsk := q.Quote(100, 5)
+	if (longBid+longAsk)/2 >= (flatBid+flatAsk)/2 {
+		t.Errorf("long inventory should lower the quote midpoint")
+	}
+	shortBid, shortAsk := q.Quote(100, -5)
+	if (shortBid+shortAsk)/2 <= (flatBid+flatAsk)/2 {
+		t.Errorf("short inventory should raise the quote midpoint")
+	}
+}
@@ -0,0 +1,69 @@
+package lx
+
+import "math/big"
+
+// RoundingMode selects how a value is rounded to a multiple of an
+// increment.
+type RoundingMode uint8
+
+const (
+	RoundDown    RoundingMode = 0 // Toward negative infinity
+	RoundNearest RoundingMode = 1 // To the nearest multiple, halves rounding up
+	RoundUp      RoundingMode = 2 // Toward positive infinity
+)
+
+// RoundToLot rounds size down to a multiple of the market's LotSizeX18, so
+// the order never exceeds what the caller asked for. A market with no lot
+// size returns size unchanged.
+func (c BookMarketConfig) RoundToLot(size X18) X18 {
+	return roundToIncrement(size, c.LotSizeX18, RoundDown)
+}
+
+// RoundToLotMode rounds size to a multiple of LotSizeX18 in the given
+// direction.
+func (c BookMarketConfig) RoundToLotMode(size X18, mode RoundingMode) X18 {
+	return roundToIncrement(size, c.LotSizeX18, mode)
+}
+
+// RoundToTick rounds px to the nearest multiple of the market's
+// TickSizeX18, halves rounding up. A market with no tick size returns px
+// unchanged. Use RoundToTickMode to round buys down and sells up so the
+// limit never becomes more aggressive.
+func (c BookMarketConfig) RoundToTick(px X18) X18 {
+	return roundToIncrement(px, c.TickSizeX18, RoundNearest)
+}
+
+// RoundToTickMode rounds px to a multiple of TickSizeX18 in the given
+// direction.
+func (c BookMarketConfig) RoundToTickMode(px X18, mode RoundingMode) X18 {
+	return roundToIncrement(px, c.TickSizeX18, mode)
+}
+
+// MeetsMinNotional reports whether an order of sz at px is worth at least
+// the market's MinNotionalX18. The notional's magnitude is compared, so the
+// sign of sz does not matter. A market with no minimum accepts any order.
+func (c BookMarketConfig) MeetsMinNotional(px, sz X18) bool {
+	n := Notional(px, sz).big()
+	return n.Abs(n).Cmp(c.MinNotionalX18.big()) >= 0
+}
+
+// roundToIncrement rounds x to a multiple of inc. Non-positive increments
+// leave x unchanged.
+func roundToIncrement(x, inc X18, mode RoundingMode) X18 {
+	if inc.IsZero() || inc.IsNegative() {
+		return x
+	}
+	n, step := x.big(), inc.big()
+	if mode == RoundNearest {
+		// floor((2x + inc) / 2inc) * inc
+		n.Lsh(n, 1).Add(n, step)
+		step2 := new(big.Int).Lsh(step, 1)
+		q, _ := n.DivMod(n, step2, new(big.Int))
+		return x18FromBig(q.Mul(q, step))
+	}
+	q, m := new(big.Int).DivMod(n, step, new(big.Int))
+	if mode == RoundUp && m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return x18FromBig(q.Mul(q, step))
+}
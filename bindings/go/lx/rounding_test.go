@@ -0,0 +1,64 @@
+package lx
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBookMarketConfigRounding(t *testing.T) {
+	cfg := BookMarketConfig{TickSizeX18: X18FromFloat(0.5), LotSizeX18: X18FromFloat(0.001)}
+	x := func(s string) X18 {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			t.Fatalf("bad literal %q", s)
+		}
+		return x18FromBig(n)
+	}
+
+	lots := []struct {
+		size, want X18
+	}{
+		{x("1234500000000000000"), x("1234000000000000000")},
+		{x("1000000000000000"), x("1000000000000000")},
+		{x("999999999999999"), X18Zero()},
+	}
+	for _, tt := range lots {
+		if got := cfg.RoundToLot(tt.size); got != tt.want {
+			t.Errorf("RoundToLot(%s) = %s, want %s", tt.size.big(), got.big(), tt.want.big())
+		}
+	}
+	if got := cfg.RoundToLotMode(x("1000000000000001"), RoundUp); got != x("2000000000000000") {
+		t.Errorf("RoundToLotMode up = %s", got.big())
+	}
+
+	ticks := []struct {
+		px   X18
+		mode RoundingMode
+		want X18
+	}{
+		{X18FromFloat(100.2), RoundNearest, X18FromInt(100)},
+		{X18FromFloat(100.25), RoundNearest, X18FromFloat(100.5)},
+		{X18FromFloat(100.7), RoundNearest, X18FromFloat(100.5)},
+		{X18FromFloat(100.7), RoundDown, X18FromFloat(100.5)},
+		{X18FromFloat(100.2), RoundUp, X18FromFloat(100.5)},
+		{X18FromInt(100), RoundUp, X18FromInt(100)},
+		{X18FromFloat(-0.2), RoundDown, X18FromFloat(-0.5)},
+	}
+	for _, tt := range ticks {
+		if got := cfg.RoundToTickMode(tt.px, tt.mode); got != tt.want {
+			t.Errorf("RoundToTickMode(%f, %d) = %f, want %f", tt.px.ToFloat(), tt.mode, got.ToFloat(), tt.want.ToFloat())
+		}
+	}
+	if got := cfg.RoundToTick(X18FromFloat(100.2)); got != X18FromInt(100) {
+		t.Errorf("RoundToTick rounds to %f, want nearest", got.ToFloat())
+	}
+
+	// No tick or lot size: unchanged
+	px := X18FromFloat(1.2345)
+	if got := (BookMarketConfig{}).RoundToTick(px); got != px {
+		t.Errorf("RoundToTick without a tick size = %f", got.ToFloat())
+	}
+	if got := (BookMarketConfig{}).RoundToLot(px); got != px {
+		t.Errorf("RoundToLot without a lot size = %f", got.ToFloat())
+	}
+}
@@ -0,0 +1,72 @@
+package lx
+
+/*
+#include "lx_full_c.h"
+*/
+import "C"
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"unsafe"
+)
+
+// SnapshotVersion is the snapshot format written by Snapshot. Restore
+// rejects snapshots written in any other version.
+const SnapshotVersion uint32 = 1
+
+// Snapshot layout: 4-byte magic, big-endian uint32 version, engine state.
+const snapshotHeaderSize = 8
+
+var snapshotMagic = []byte("LXSS")
+
+var (
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+// Snapshot serializes the full engine state (pools, books, vault, oracle
+// and feed) into a versioned checkpoint that Restore can load.
+func (d *LX) Snapshot() ([]byte, error) {
+	if d.ptr == nil {
+		return nil, errors.New("LX not initialized")
+	}
+	var data *C.uint8_t
+	var n C.size_t
+	result := int32(C.lx_snapshot(d.ptr, &data, &n))
+	if err := errorFromCode(result); err != nil {
+		return nil, err
+	}
+	defer C.lx_snapshot_free(data)
+
+	out := make([]byte, snapshotHeaderSize+int(n))
+	copy(out, snapshotMagic)
+	binary.BigEndian.PutUint32(out[4:], SnapshotVersion)
+	if n > 0 {
+		copy(out[snapshotHeaderSize:], unsafe.Slice((*byte)(unsafe.Pointer(data)), n))
+	}
+	return out, nil
+}
+
+// Restore loads a checkpoint produced by Snapshot. It must be called on a
+// fresh instance, before any markets, pools or accounts are created.
+// Snapshots from another format version fail with ErrSnapshotVersion.
+func (d *LX) Restore(snapshot []byte) error {
+	if d.ptr == nil {
+		return errors.New("LX not initialized")
+	}
+	if len(snapshot) < snapshotHeaderSize || !bytes.Equal(snapshot[:4], snapshotMagic) {
+		return ErrInvalidSnapshot
+	}
+	if binary.BigEndian.Uint32(snapshot[4:]) != SnapshotVersion {
+		return ErrSnapshotVersion
+	}
+
+	state := snapshot[snapshotHeaderSize:]
+	var statePtr *C.uint8_t
+	if len(state) > 0 {
+		statePtr = (*C.uint8_t)(unsafe.Pointer(&state[0]))
+	}
+	result := int32(C.lx_restore(d.ptr, statePtr, C.size_t(len(state))))
+	return errorFromCode(result)
+}
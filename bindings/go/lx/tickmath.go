@@ -0,0 +1,185 @@
+package lx
+
+import (
+	"math"
+	"math/big"
+)
+
+// Tick bounds of the pool engine. A tick t is the price 1.0001^t.
+const (
+	MinTick int32 = -887272
+	MaxTick int32 = 887272
+)
+
+var (
+	bigQ96    = new(big.Int).Lsh(big.NewInt(1), 96)
+	bigMaxX18 = new(big.Int).Rsh(bigMask128, 1)
+	bigMax256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+// tickRatios[i] is 1/sqrt(1.0001^(2^i)) in Q128.128, as in Uniswap's
+// TickMath.getSqrtRatioAtTick.
+var tickRatios = func() [20]*big.Int {
+	hexes := [20]string{
+		"fffcb933bd6fad37aa2d162d1a594001", "fff97272373d413259a46990580e213a",
+		"fff2e50f5f656932ef12357cf3c7fdcc", "ffe5caca7e10e4e61c3624eaa0941cd0",
+		"ffcb9843d60f6159c9db58835c926644", "ff973b41fa98c081472e6896dfb254c0",
+		"ff2ea16466c96a3843ec78b326b52861", "fe5dee046a99a2a811c461f1969c3053",
+		"fcbe86c7900a88aedcffc83b479aa3a4", "f987a7253ac413176f2b074cf7815e54",
+		"f3392b0822b70005940c7a398e4b70f3", "e7159475a2c29b7443b29c7fa6e889d9",
+		"d097f3bdfd2022b8845ad8f792aa5825", "a9f746462d870fdf8a65dc1f90e061e5",
+		"70d869a156d2a1b890bb3df62baf32f7", "31be135f97d08fd981231505542fcfa6",
+		"9aa508b5b7a84e1c677de54f3e99bc9", "5d6af8dedb81196699c329225ee604",
+		"2216e584f5fa1ea926041bedfe98", "48a170391f7dc42444e8fa2",
+	}
+	var ratios [20]*big.Int
+	for i, h := range hexes {
+		ratios[i], _ = new(big.Int).SetString(h, 16)
+	}
+	return ratios
+}()
+
+// SqrtPriceX96FromPrice returns sqrt(price) as a Q64.96 fixed-point number,
+// the form PoolInitialize takes. price is currency1 per currency0 in raw
+// token units. Non-positive prices return zero; prices whose square root
+// does not fit in X18 (above about 4.6e18) saturate at the largest X18.
+func SqrtPriceX96FromPrice(price float64) X18 {
+	if math.IsNaN(price) || price <= 0 {
+		return X18{}
+	}
+	if math.IsInf(price, 1) {
+		return x18FromBig(bigMaxX18)
+	}
+	f := new(big.Float).SetPrec(256).SetFloat64(price)
+	f.Sqrt(f)
+	n, _ := f.SetMantExp(f, 96).Int(nil)
+	return x18Saturate(n)
+}
+
+// PriceFromSqrtPriceX96 is the inverse of SqrtPriceX96FromPrice. Zero and
+// negative inputs return zero.
+func PriceFromSqrtPriceX96(sqrtP X18) float64 {
+	if sqrtP.IsNegative() || sqrtP.IsZero() {
+		return 0
+	}
+	f := new(big.Float).SetPrec(256).SetInt(sqrtP.big())
+	f.Quo(f, new(big.Float).SetInt(bigQ96))
+	price, _ := f.Mul(f, f).Float64()
+	return price
+}
+
+// PriceFromTick returns 1.0001^tick.
+func PriceFromTick(tick int32) float64 {
+	return math.Pow(1.0001, float64(tick))
+}
+
+// TickFromPrice returns the largest tick whose price does not exceed price,
+// clamped to [MinTick, MaxTick]. Non-positive prices return MinTick.
+func TickFromPrice(price float64) int32 {
+	if math.IsNaN(price) || price <= 0 {
+		return MinTick
+	}
+	t := math.Floor(math.Log(price) / math.Log(1.0001))
+	if t <= float64(MinTick) {
+		return MinTick
+	}
+	if t >= float64(MaxTick) {
+		return MaxTick
+	}
+	// The logarithm can land one tick off either side of a boundary
+	tick := int32(t)
+	for tick < MaxTick && PriceFromTick(tick+1) <= price {
+		tick++
+	}
+	for tick > MinTick && PriceFromTick(tick) > price {
+		tick--
+	}
+	return tick
+}
+
+// SqrtPriceX96FromTick returns sqrt(1.0001^tick) in Q64.96, bit for bit
+// what Uniswap's getSqrtRatioAtTick returns. Ticks outside [MinTick,
+// MaxTick] are clamped. Above about tick 429,000 the result no longer fits
+// in X18 and saturates at its largest value.
+func SqrtPriceX96FromTick(tick int32) X18 {
+	return x18Saturate(sqrtRatioAtTick(tick))
+}
+
+// TickFromSqrtPriceX96 returns the largest tick whose SqrtPriceX96FromTick
+// does not exceed sqrtP, matching Uniswap's getTickAtSqrtRatio. Values
+// below the MinTick ratio return MinTick.
+func TickFromSqrtPriceX96(sqrtP X18) int32 {
+	n := sqrtP.big()
+	lo, hi := MinTick, MaxTick
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		if sqrtRatioAtTick(mid).Cmp(n) <= 0 {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// NearestUsableTick rounds tick to the nearest multiple of spacing, halves
+// rounding up, staying within [MinTick, MaxTick]. It panics if spacing is
+// not positive.
+func NearestUsableTick(tick, spacing int32) int32 {
+	if spacing <= 0 {
+		panic("lx: tick spacing must be positive")
+	}
+	s := int64(spacing)
+	n := 2*int64(tick) + s
+	q := n / (2 * s)
+	if n%(2*s) < 0 {
+		q--
+	}
+	rounded := q * s
+	if rounded < int64(MinTick) {
+		rounded += s
+	} else if rounded > int64(MaxTick) {
+		rounded -= s
+	}
+	return int32(rounded)
+}
+
+// sqrtRatioAtTick is SqrtPriceX96FromTick without the X18 bound.
+func sqrtRatioAtTick(tick int32) *big.Int {
+	if tick < MinTick {
+		tick = MinTick
+	} else if tick > MaxTick {
+		tick = MaxTick
+	}
+	abs := tick
+	if abs < 0 {
+		abs = -abs
+	}
+
+	ratio := new(big.Int).Lsh(big.NewInt(1), 128)
+	for i, r := range tickRatios {
+		if abs&(1<<i) != 0 {
+			ratio.Mul(ratio, r).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(bigMax256, ratio)
+	}
+
+	// Q128.128 to Q64.96, rounding up
+	rem := new(big.Int).And(ratio, big.NewInt(1<<32-1))
+	ratio.Rsh(ratio, 32)
+	if rem.Sign() != 0 {
+		ratio.Add(ratio, big.NewInt(1))
+	}
+	return ratio
+}
+
+// x18Saturate converts a non-negative n, clamping at the largest X18
+// instead of wrapping.
+func x18Saturate(n *big.Int) X18 {
+	if n.Cmp(bigMaxX18) > 0 {
+		return x18FromBig(bigMaxX18)
+	}
+	return x18FromBig(n)
+}
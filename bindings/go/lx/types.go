@@ -0,0 +1,80 @@
+package lx
+
+// Types in this file carry no cgo dependency, so the pure-Go helpers that
+// use them build with CGO_ENABLED=0.
+
+// AddressSize is the byte length of an address (20 bytes)
+const AddressSize = 20
+
+// Address is a 20-byte Ethereum-style address.
+type Address [AddressSize]byte
+
+// Currency represents a token address.
+type Currency = Address
+
+// TIF is the time-in-force for an order.
+type TIF uint8
+
+const (
+	TifGTC TIF = 0 // Good Till Cancel
+	TifIOC TIF = 1 // Immediate Or Cancel
+	TifALO TIF = 2 // Add Liquidity Only (post-only)
+)
+
+// OrderKind is the type of order.
+type OrderKind uint8
+
+const (
+	OrderLimit      OrderKind = 0
+	OrderMarket     OrderKind = 1
+	OrderStopMarket OrderKind = 2
+	OrderStopLimit  OrderKind = 3
+	OrderTakeMarket OrderKind = 4
+	OrderTakeLimit  OrderKind = 5
+)
+
+// Order represents an order to place on the CLOB.
+type Order struct {
+	MarketID     uint32
+	IsBuy        bool
+	Kind         OrderKind
+	SizeX18      X18
+	LimitPxX18   X18
+	TriggerPxX18 X18
+	ReduceOnly   bool // rejected with ErrReduceOnly unless it only reduces the position
+	TIF          TIF
+	CLOID        [16]byte // Client order ID (UUID)
+
+	// STPGroup tags orders that must never trade with each other; the
+	// market's STPMode decides how a would-be match is resolved. Orders with
+	// STPGroup 0 are exempt from self-trade prevention.
+	STPGroup uint64
+}
+
+// STPMode selects how self-trade prevention resolves an incoming order that
+// would match a resting order with the same non-zero STPGroup. The values
+// match the luxdex engine's STP modes.
+type STPMode uint8
+
+const (
+	STPCancelMaker STPMode = 0 // Cancel the resting order and keep matching (default)
+	STPCancelTaker STPMode = 1 // Cancel the incoming order's unfilled remainder
+	STPCancelBoth  STPMode = 2 // Cancel the resting order and the incoming remainder
+	STPDecrement   STPMode = 3 // Shrink both by the smaller remaining size
+)
+
+// BookMarketConfig configures a market for the order book.
+type BookMarketConfig struct {
+	MarketID        uint32
+	SymbolID        uint64
+	BaseCurrency    Currency
+	QuoteCurrency   Currency
+	TickSizeX18     X18
+	LotSizeX18      X18
+	MinNotionalX18  X18
+	MaxOrderSizeX18 X18
+	PostOnlyMode    bool
+	ReduceOnlyMode  bool
+	Status          uint8   // MarketHalted, MarketActive, MarketCancelOnly, MarketPostOnly or MarketLimitOnly
+	STPMode         STPMode // Self-trade resolution for orders sharing an STPGroup
+}
@@ -0,0 +1,164 @@
+package lx
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// X18One is 1.0 in X18 fixed-point (1e18)
+const X18One int64 = 1_000_000_000_000_000_000
+
+// X18 is a 128-bit fixed-point number with 18 decimal places.
+type X18 struct {
+	Lo int64
+	Hi int64
+}
+
+// =============================================================================
+// X18 Arithmetic
+// =============================================================================
+//
+// Conversions (X18FromInt, X18FromFloat, ToInt, ToFloat) call into C when
+// cgo is enabled and fall back to the pure-Go versions below otherwise; both
+// paths produce identical results. Everything else in this file is pure Go.
+
+// X18Zero returns zero.
+func X18Zero() X18 {
+	return X18{Lo: 0, Hi: 0}
+}
+
+// IsZero returns true if the value is zero.
+func (x X18) IsZero() bool {
+	return x.Lo == 0 && x.Hi == 0
+}
+
+// IsNegative returns true if the value is negative.
+func (x X18) IsNegative() bool {
+	return x.Hi < 0
+}
+
+// Cmp returns -1, 0 or +1 as x is less than, equal to or greater than y.
+func (x X18) Cmp(y X18) int {
+	switch {
+	case x.Hi < y.Hi:
+		return -1
+	case x.Hi > y.Hi:
+		return 1
+	case uint64(x.Lo) < uint64(y.Lo):
+		return -1
+	case uint64(x.Lo) > uint64(y.Lo):
+		return 1
+	}
+	return 0
+}
+
+// Add returns x + y, wrapping on 128-bit overflow.
+func (x X18) Add(y X18) X18 {
+	lo, carry := bits.Add64(uint64(x.Lo), uint64(y.Lo), 0)
+	hi, _ := bits.Add64(uint64(x.Hi), uint64(y.Hi), carry)
+	return X18{Lo: int64(lo), Hi: int64(hi)}
+}
+
+// Sub returns x - y, wrapping on 128-bit overflow.
+func (x X18) Sub(y X18) X18 {
+	lo, borrow := bits.Sub64(uint64(x.Lo), uint64(y.Lo), 0)
+	hi, _ := bits.Sub64(uint64(x.Hi), uint64(y.Hi), borrow)
+	return X18{Lo: int64(lo), Hi: int64(hi)}
+}
+
+// Neg returns -x.
+func (x X18) Neg() X18 {
+	return X18{}.Sub(x)
+}
+
+// Mul returns x * y, truncated toward zero to 18 decimals.
+func (x X18) Mul(y X18) X18 {
+	p := new(big.Int).Mul(x.big(), y.big())
+	return x18FromBig(p.Quo(p, bigX18One))
+}
+
+// Notional returns price × size, the quote value of a position or order,
+// truncated toward zero to 18 decimals. The sign follows the operands, so a
+// negative (short) size gives a negative notional. Unlike Mul, a product
+// beyond the X18 range saturates at its largest or smallest value instead
+// of wrapping.
+func Notional(price, size X18) X18 {
+	p := new(big.Int).Mul(price.big(), size.big())
+	p.Quo(p, bigX18One)
+	if p.Cmp(bigMaxX18) > 0 {
+		return x18FromBig(bigMaxX18)
+	}
+	if lowest := new(big.Int).Not(bigMaxX18); p.Cmp(lowest) < 0 {
+		return x18FromBig(lowest)
+	}
+	return x18FromBig(p)
+}
+
+// Div returns x / y, truncated toward zero to 18 decimals. It panics if y
+// is zero.
+func (x X18) Div(y X18) X18 {
+	if y.IsZero() {
+		panic("lx: X18 division by zero")
+	}
+	n := new(big.Int).Mul(x.big(), bigX18One)
+	return x18FromBig(n.Quo(n, y.big()))
+}
+
+// =============================================================================
+// Pure-Go Conversions
+// =============================================================================
+//
+// These mirror the C conversions in include/lux/types.hpp: float inputs are
+// scaled in float64 and truncated toward zero, and ToInt truncates the
+// quotient to its low 64 bits.
+
+var (
+	bigX18One  = big.NewInt(X18One)
+	bigMask128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+)
+
+func x18FromIntGo(v int64) X18 {
+	return x18FromBig(new(big.Int).Mul(big.NewInt(v), bigX18One))
+}
+
+func x18FromFloatGo(v float64) X18 {
+	f := v * float64(X18One)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return X18{}
+	}
+	if math.Abs(f) < math.MaxInt64 {
+		n := int64(f)
+		return X18{Lo: n, Hi: n >> 63}
+	}
+	n, _ := big.NewFloat(f).Int(nil)
+	return x18FromBig(n)
+}
+
+func x18ToIntGo(x X18) int64 {
+	q := new(big.Int).Quo(x.big(), bigX18One)
+	return x18FromBig(q).Lo
+}
+
+func x18ToFloatGo(x X18) float64 {
+	if x.Hi == x.Lo>>63 {
+		return float64(x.Lo) / float64(X18One)
+	}
+	f, _ := new(big.Float).SetInt(x.big()).Float64()
+	return f / float64(X18One)
+}
+
+// big returns x as a big.Int.
+func (x X18) big() *big.Int {
+	n := big.NewInt(x.Hi)
+	n.Lsh(n, 64)
+	return n.Or(n, new(big.Int).SetUint64(uint64(x.Lo)))
+}
+
+// x18FromBig returns the low 128 bits of n in two's complement.
+func x18FromBig(n *big.Int) X18 {
+	u := new(big.Int).And(n, bigMask128)
+	lo := new(big.Int).And(u, new(big.Int).SetUint64(math.MaxUint64)).Uint64()
+	hi := u.Rsh(u, 64).Uint64()
+	return X18{Lo: int64(lo), Hi: int64(hi)}
+}
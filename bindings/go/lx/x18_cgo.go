@@ -0,0 +1,28 @@
+//go:build cgo
+
+package lx
+
+/*
+#include "lx_full_c.h"
+*/
+import "C"
+
+// X18FromInt creates an X18 from an integer.
+func X18FromInt(v int64) X18 {
+	return fromCX18(C.lx_from_int(C.int64_t(v)))
+}
+
+// X18FromFloat creates an X18 from a float64.
+func X18FromFloat(v float64) X18 {
+	return fromCX18(C.lx_from_double(C.double(v)))
+}
+
+// ToInt converts X18 to int64 (truncates decimals).
+func (x X18) ToInt() int64 {
+	return int64(C.lx_to_int(toCX18(x)))
+}
+
+// ToFloat converts X18 to float64.
+func (x X18) ToFloat() float64 {
+	return float64(C.lx_to_double(toCX18(x)))
+}
@@ -0,0 +1,23 @@
+//go:build !cgo
+
+package lx
+
+// X18FromInt creates an X18 from an integer.
+func X18FromInt(v int64) X18 {
+	return x18FromIntGo(v)
+}
+
+// X18FromFloat creates an X18 from a float64.
+func X18FromFloat(v float64) X18 {
+	return x18FromFloatGo(v)
+}
+
+// ToInt converts X18 to int64 (truncates decimals).
+func (x X18) ToInt() int64 {
+	return x18ToIntGo(x)
+}
+
+// ToFloat converts X18 to float64.
+func (x X18) ToFloat() float64 {
+	return x18ToFloatGo(x)
+}
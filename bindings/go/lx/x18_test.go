@@ -0,0 +1,55 @@
+package lx
+
+import "testing"
+
+func TestX18Arithmetic(t *testing.T) {
+	// Test X18FromInt
+	one := X18FromInt(1)
+	if one.Lo != X18One || one.Hi != 0 {
+		t.Errorf("X18FromInt(1) = {%d, %d}, want {%d, 0}", one.Lo, one.Hi, X18One)
+	}
+
+	// Test ToInt
+	if one.ToInt() != 1 {
+		t.Errorf("X18FromInt(1).ToInt() = %d, want 1", one.ToInt())
+	}
+
+	// Test X18FromFloat
+	half := X18FromFloat(0.5)
+	got := half.ToFloat()
+	if got < 0.49 || got > 0.51 {
+		t.Errorf("X18FromFloat(0.5).ToFloat() = %f, want ~0.5", got)
+	}
+
+	// Test IsZero
+	zero := X18Zero()
+	if !zero.IsZero() {
+		t.Error("X18Zero().IsZero() = false, want true")
+	}
+	if one.IsZero() {
+		t.Error("X18FromInt(1).IsZero() = true, want false")
+	}
+}
+
+func TestX18AddSubMulDiv(t *testing.T) {
+	a, b := X18FromFloat(2.5), X18FromFloat(-0.4)
+	tests := []struct {
+		name string
+		got  X18
+		want float64
+	}{
+		{"Add", a.Add(b), 2.1},
+		{"Sub", a.Sub(b), 2.9},
+		{"Neg", b.Neg(), 0.4},
+		{"Mul", a.Mul(b), -1},
+		{"Div", a.Div(b), -6.25},
+	}
+	for _, tt := range tests {
+		if tt.got != X18FromFloat(tt.want) {
+			t.Errorf("%s = %f, want %f", tt.name, tt.got.ToFloat(), tt.want)
+		}
+	}
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Errorf("Cmp ordering wrong for %f, %f", a.ToFloat(), b.ToFloat())
+	}
+}
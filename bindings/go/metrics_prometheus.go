@@ -0,0 +1,88 @@
+//go:build prometheus
+
+package luxdex
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsDepthLevels is how many price levels per side are summed for the
+// luxdex_depth_quantity gauge.
+const MetricsDepthLevels = 10
+
+var (
+	descOrdersPlaced = prometheus.NewDesc("luxdex_orders_placed_total",
+		"Orders accepted by the engine.", nil, nil)
+	descOrdersCancelled = prometheus.NewDesc("luxdex_orders_cancelled_total",
+		"Orders cancelled, including expiries.", nil, nil)
+	descTrades = prometheus.NewDesc("luxdex_trades_total",
+		"Trades executed.", nil, nil)
+	descVolume = prometheus.NewDesc("luxdex_volume_total",
+		"Quantity traded, summed over all symbols.", nil, nil)
+	descBestBid = prometheus.NewDesc("luxdex_best_bid",
+		"Best bid price; absent while the bid side is empty.", []string{"symbol"}, nil)
+	descBestAsk = prometheus.NewDesc("luxdex_best_ask",
+		"Best ask price; absent while the ask side is empty.", []string{"symbol"}, nil)
+	descDepthQuantity = prometheus.NewDesc("luxdex_depth_quantity",
+		"Resting quantity in the top MetricsDepthLevels price levels.", []string{"symbol", "side"}, nil)
+	descDepthLevels = prometheus.NewDesc("luxdex_depth_levels",
+		"Price levels counted in luxdex_depth_quantity.", []string{"symbol", "side"}, nil)
+)
+
+// engineCollector samples an engine each time it is scraped.
+type engineCollector struct {
+	engine *CGOEngine
+}
+
+// MetricsCollector returns a Prometheus collector for the engine. Every
+// scrape reads GetStats for the engine-wide counters and, per symbol, the
+// best prices and the top MetricsDepthLevels levels of depth. Only built
+// with the prometheus build tag, so the package has no Prometheus
+// dependency otherwise.
+func (e *CGOEngine) MetricsCollector() prometheus.Collector {
+	return &engineCollector{engine: e}
+}
+
+func (c *engineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descOrdersPlaced
+	ch <- descOrdersCancelled
+	ch <- descTrades
+	ch <- descVolume
+	ch <- descBestBid
+	ch <- descBestAsk
+	ch <- descDepthQuantity
+	ch <- descDepthLevels
+}
+
+func (c *engineCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.engine.GetStats()
+	ch <- prometheus.MustNewConstMetric(descOrdersPlaced, prometheus.CounterValue, float64(stats.TotalOrdersPlaced))
+	ch <- prometheus.MustNewConstMetric(descOrdersCancelled, prometheus.CounterValue, float64(stats.TotalOrdersCancelled))
+	ch <- prometheus.MustNewConstMetric(descTrades, prometheus.CounterValue, float64(stats.TotalTrades))
+	ch <- prometheus.MustNewConstMetric(descVolume, prometheus.CounterValue, Quantity(stats.TotalVolume).ToFloat())
+
+	for _, symbolID := range c.engine.Symbols() {
+		symbol := strconv.FormatUint(symbolID, 10)
+		if px, ok := c.engine.BestBid(symbolID); ok {
+			ch <- prometheus.MustNewConstMetric(descBestBid, prometheus.GaugeValue, px.ToFloat(), symbol)
+		}
+		if px, ok := c.engine.BestAsk(symbolID); ok {
+			ch <- prometheus.MustNewConstMetric(descBestAsk, prometheus.GaugeValue, px.ToFloat(), symbol)
+		}
+
+		depth := c.engine.GetDepth(symbolID, MetricsDepthLevels)
+		for _, side := range []struct {
+			name   string
+			levels []DepthLevel
+		}{{"bid", depth.Bids}, {"ask", depth.Asks}} {
+			var qty float64
+			for _, l := range side.levels {
+				qty += l.Quantity
+			}
+			ch <- prometheus.MustNewConstMetric(descDepthQuantity, prometheus.GaugeValue, qty, symbol, side.name)
+			ch <- prometheus.MustNewConstMetric(descDepthLevels, prometheus.GaugeValue, float64(len(side.levels)), symbol, side.name)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build prometheus
+
+package luxdex
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	e := newTestEngine(t, 1)
+	e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(101).Qty(5).Build())
+	e.PlaceOrder(NewOrder().Symbol(1).Account(2).Buy().Limit(99).Qty(2).Build())
+	e.PlaceOrder(NewOrder().Symbol(1).Account(3).Buy().Limit(101).Qty(1).Build())
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(e.MetricsCollector()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName()
+			for _, lp := range m.GetLabel() {
+				key += "," + lp.GetName() + "=" + lp.GetValue()
+			}
+			switch {
+			case m.GetCounter() != nil:
+				got[key] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				got[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	for key, want := range map[string]float64{
+		"luxdex_orders_placed_total":              3,
+		"luxdex_trades_total":                     1,
+		"luxdex_volume_total":                     1,
+		"luxdex_best_bid,symbol=1":                99,
+		"luxdex_best_ask,symbol=1":                101,
+		"luxdex_depth_quantity,side=ask,symbol=1": 4,
+		"luxdex_depth_quantity,side=bid,symbol=1": 2,
+		"luxdex_depth_levels,side=bid,symbol=1":   1,
+	} {
+		if v, ok := got[key]; !ok || v != want {
+			t.Errorf("%s = %v (present %v), want %v", key, v, ok, want)
+		}
+	}
+}
@@ -59,6 +59,10 @@ const (
 	TifFOK TimeInForce = 2 // Fill Or Kill
 	TifGTD TimeInForce = 3 // Good Till Date
 	TifDAY TimeInForce = 4 // Day order
+
+	// TifPostOnly rests like GTC but is rejected, without trading, if any
+	// part of it would match on arrival
+	TifPostOnly TimeInForce = 5
 )
 
 func (t TimeInForce) String() string {
@@ -73,6 +77,8 @@ func (t TimeInForce) String() string {
 		return "GTD"
 	case TifDAY:
 		return "DAY"
+	case TifPostOnly:
+		return "POST_ONLY"
 	default:
 		return "unknown"
 	}
@@ -151,6 +157,11 @@ type Order struct {
 	StopPrice  Price
 	Timestamp  time.Time
 	ExpireTime time.Time
+	DisplayQty Quantity // Iceberg: quantity shown in the book at a time (0 = all)
+
+	// MaxSlippageBps bounds how far a market order may fill from the
+	// opposite best price at placement (0 = unbounded)
+	MaxSlippageBps int
 }
 
 // Remaining returns the unfilled quantity
@@ -214,6 +225,19 @@ type OrderResult struct {
 	Trades  []Trade
 }
 
+// Err returns nil if the order was accepted, otherwise an *OrderError for
+// Error, so callers can test errors.Is(res.Err(), ErrUnknownSymbol).
+func (r OrderResult) Err() error {
+	if r.Success {
+		return nil
+	}
+	msg := r.Error
+	if msg == "" {
+		msg = "order rejected"
+	}
+	return &OrderError{Msg: msg, err: engineErrors[r.Error]}
+}
+
 // CancelResult represents the result of cancelling an order
 type CancelResult struct {
 	Success        bool
@@ -221,6 +245,49 @@ type CancelResult struct {
 	Error          string
 }
 
+// MatchingAlgorithm selects how a price level allocates an incoming order
+// among its resting orders
+type MatchingAlgorithm uint8
+
+const (
+	MatchPriceTime MatchingAlgorithm = 0 // FIFO within a level
+	MatchProRata   MatchingAlgorithm = 1 // Proportional to resting size
+)
+
+// ProRataTieBreak selects which resting orders receive the units left over
+// after pro-rata shares are rounded down. Each leftover unit goes to a
+// distinct order, so the allocation is a pure function of the book state.
+type ProRataTieBreak uint8
+
+const (
+	// TieBreakLargestRemainder favors the orders whose exact share lost the
+	// most to rounding; equal remainders fall back to queue position.
+	TieBreakLargestRemainder ProRataTieBreak = 0
+	// TieBreakOrderID favors the lowest order IDs.
+	TieBreakOrderID ProRataTieBreak = 1
+	// TieBreakTime favors the earliest order timestamps, then queue position.
+	TieBreakTime ProRataTieBreak = 2
+)
+
+// STPMode selects how self-trade prevention resolves an incoming order that
+// would match a resting order with the same non-zero STPGroup. No trade is
+// ever generated between such orders.
+type STPMode uint8
+
+const (
+	// STPCancelMaker cancels the resting order and keeps matching the
+	// incoming one. This is the default.
+	STPCancelMaker STPMode = 0
+	// STPCancelTaker cancels the incoming order's unfilled remainder and
+	// leaves the resting order in the book.
+	STPCancelTaker STPMode = 1
+	// STPCancelBoth cancels the resting order and the incoming remainder.
+	STPCancelBoth STPMode = 2
+	// STPDecrement shrinks both orders by the smaller remaining size and
+	// cancels whichever is left empty.
+	STPDecrement STPMode = 3
+)
+
 // EngineStats contains engine statistics
 type EngineStats struct {
 	TotalOrdersPlaced    uint64
@@ -229,12 +296,32 @@ type EngineStats struct {
 	TotalVolume          uint64
 }
 
+// LatencyWindow is how many of the most recent matching orders LatencyStats
+// covers.
+const LatencyWindow = 4096
+
+// LatencyStats holds order-to-trade latency percentiles in nanoseconds,
+// measured in the C engine as the time spent matching each order that
+// produced at least one trade. Orders that only rest are not sampled.
+// Samples is the number of orders in the window, at most LatencyWindow.
+type LatencyStats struct {
+	Samples uint64
+	P50     uint64
+	P90     uint64
+	P99     uint64
+	Max     uint64
+}
+
 // EngineConfig contains engine configuration
 type EngineConfig struct {
 	WorkerThreads       int
 	MaxBatchSize        int
 	EnableSelfTradePrev bool
 	AsyncMode           bool
+	EventLog            bool // Record a replayable per-symbol event log
+	Matching            MatchingAlgorithm
+	ProRataTieBreak     ProRataTieBreak // Only used with MatchProRata
+	STPMode             STPMode         // Self-trade resolution (default STPCancelMaker)
 }
 
 // DefaultEngineConfig returns a default engine configuration
@@ -247,10 +334,15 @@ func DefaultEngineConfig() EngineConfig {
 	}
 }
 
-// TradeListener is called when trades occur
+// TradeListener is called when trades occur. Each fill is reported to
+// both the incoming and the resting order.
 type TradeListener interface {
 	OnTrade(trade Trade)
+	// OnOrderFilled fires exactly once per order, for the fill that
+	// completes it
 	OnOrderFilled(order Order)
+	// OnOrderPartiallyFilled fires for each fill that leaves the order
+	// open; fillQty is that fill and order.Filled the running total
 	OnOrderPartiallyFilled(order Order, fillQty Quantity)
 	OnOrderCancelled(order Order)
 }
@@ -281,6 +373,9 @@ type Engine interface {
 	// PlaceOrder places an order
 	PlaceOrder(order Order) OrderResult
 
+	// PlaceOrders places orders in sequence, returning results in input order
+	PlaceOrders(orders []Order) []OrderResult
+
 	// CancelOrder cancels an order
 	CancelOrder(symbolID, orderID uint64) CancelResult
 
@@ -320,6 +415,15 @@ func NextOrderID() uint64 {
 	return id
 }
 
+// reserveOrderID makes NextOrderID return IDs above id
+func reserveOrderID(id uint64) {
+	globalOrderIDGen.mu.Lock()
+	defer globalOrderIDGen.mu.Unlock()
+	if globalOrderIDGen.counter <= id {
+		globalOrderIDGen.counter = id + 1
+	}
+}
+
 // ResetOrderIDGenerator resets the order ID generator
 func ResetOrderIDGenerator(start uint64) {
 	globalOrderIDGen.mu.Lock()
@@ -333,8 +437,40 @@ var (
 	ErrOrderNotFound  = errors.New("order not found")
 	ErrInvalidOrder   = errors.New("invalid order")
 	ErrEngineNotReady = errors.New("engine not ready")
+
+	// ErrNoReferencePrice is reported when a slippage-bounded market order
+	// finds the opposite side of the book empty
+	ErrNoReferencePrice = errors.New("no reference price")
+
+	// ErrPostOnlyWouldCross is reported when a post-only order would take
+	// liquidity
+	ErrPostOnlyWouldCross = errors.New("post-only order would cross")
 )
 
+// engineErrors maps the engine's error strings to sentinel errors
+var engineErrors = map[string]error{
+	"Unknown symbol":                        ErrUnknownSymbol,
+	"Order not found":                       ErrOrderNotFound,
+	"Invalid engine or order":               ErrInvalidOrder,
+	"Order quantity must be positive":       ErrInvalidOrder,
+	"Limit order price must be positive":    ErrInvalidOrder,
+	"Post-only order must be a limit order": ErrInvalidOrder,
+	"Post-only order would cross the book":  ErrPostOnlyWouldCross,
+	ErrNoReferencePrice.Error():             ErrNoReferencePrice,
+}
+
+// OrderError is the error for a failed order. Error returns the engine's
+// message unchanged; Unwrap returns the matching sentinel, or nil for a
+// message without one.
+type OrderError struct {
+	Msg string
+	err error
+}
+
+func (e *OrderError) Error() string { return e.Msg }
+
+func (e *OrderError) Unwrap() error { return e.err }
+
 // OrderBuilder helps construct orders
 type OrderBuilder struct {
 	order Order
@@ -396,6 +532,16 @@ func (b *OrderBuilder) Market() *OrderBuilder {
 	return b
 }
 
+// MaxSlippage bounds a market order to fill within bps basis points of the
+// opposite best price (best ask for buys, best bid for sells). The engine
+// reads that price when the order is placed and submits the order as an
+// IOC limit at the bound, so any quantity that would fill beyond it is
+// cancelled rather than filled.
+func (b *OrderBuilder) MaxSlippage(bps int) *OrderBuilder {
+	b.order.MaxSlippageBps = bps
+	return b
+}
+
 // Qty sets the order quantity
 func (b *OrderBuilder) Qty(qty float64) *OrderBuilder {
 	b.order.Quantity = QuantityFromFloat(qty)
@@ -408,6 +554,21 @@ func (b *OrderBuilder) TimeInForce(tif TimeInForce) *OrderBuilder {
 	return b
 }
 
+// Iceberg rests the order showing at most displayQty at a time. Each time
+// the visible slice fills, the next one is shown from the hidden reserve
+// and joins the back of its price level's queue.
+func (b *OrderBuilder) Iceberg(displayQty float64) *OrderBuilder {
+	b.order.DisplayQty = QuantityFromFloat(displayQty)
+	return b
+}
+
+// ExpireAt makes the order good-till-date, expiring at t
+func (b *OrderBuilder) ExpireAt(t time.Time) *OrderBuilder {
+	b.order.TIF = TifGTD
+	b.order.ExpireTime = t
+	return b
+}
+
 // STPGroup sets the self-trade prevention group
 func (b *OrderBuilder) STPGroup(group uint64) *OrderBuilder {
 	b.order.STPGroup = group
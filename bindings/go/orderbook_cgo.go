@@ -9,15 +9,24 @@ package luxdex
 */
 import "C"
 import (
+	"fmt"
+	"io"
 	"runtime"
+	"sync"
 	"time"
 	"unsafe"
 )
 
-// CGOEngine wraps the C++ Engine via CGO
+// CGOEngine wraps the C++ Engine via CGO. It is safe for concurrent use:
+// mutating calls are serialized, so the event log and trade listener see
+// orders in the same sequence as the matcher, while queries share a read lock.
 type CGOEngine struct {
+	mu       sync.RWMutex
 	handle   C.LuxEngine
 	listener TradeListener
+	notify   *notifyQueue // listener calls raised by the C engine
+	events   *eventLog
+	trades   map[uint64]*tradeRing // recent trades per symbol, for GetTrades
 }
 
 // Ensure CGOEngine implements Engine
@@ -30,7 +39,7 @@ func NewCGOEngine() (*CGOEngine, error) {
 		return nil, ErrEngineNotReady
 	}
 
-	e := &CGOEngine{handle: handle}
+	e := &CGOEngine{handle: handle, notify: registerCallbacks(handle)}
 	runtime.SetFinalizer(e, (*CGOEngine).destroy)
 	return e, nil
 }
@@ -42,6 +51,10 @@ func NewCGOEngineWithConfig(config EngineConfig) (*CGOEngine, error) {
 		max_batch_size: C.size_t(config.MaxBatchSize),
 		enable_stp:     C.bool(config.EnableSelfTradePrev),
 		async_mode:     C.bool(config.AsyncMode),
+
+		matching:           C.uint8_t(config.Matching),
+		pro_rata_tie_break: C.uint8_t(config.ProRataTieBreak),
+		stp_mode:           C.uint8_t(config.STPMode),
 	}
 
 	handle := C.lux_engine_create_with_config(&cConfig)
@@ -49,14 +62,20 @@ func NewCGOEngineWithConfig(config EngineConfig) (*CGOEngine, error) {
 		return nil, ErrEngineNotReady
 	}
 
-	e := &CGOEngine{handle: handle}
+	e := &CGOEngine{handle: handle, notify: registerCallbacks(handle)}
+	if config.EventLog {
+		e.events = newEventLog()
+	}
 	runtime.SetFinalizer(e, (*CGOEngine).destroy)
 	return e, nil
 }
 
 func (e *CGOEngine) destroy() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	if e.handle != nil {
 		C.lux_engine_destroy(e.handle)
+		unregisterCallbacks(e.handle)
 		e.handle = nil
 	}
 }
@@ -68,76 +87,206 @@ func (e *CGOEngine) Close() {
 }
 
 func (e *CGOEngine) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	C.lux_engine_start(e.handle)
 }
 
 func (e *CGOEngine) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	C.lux_engine_stop(e.handle)
 }
 
 func (e *CGOEngine) IsRunning() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	return bool(C.lux_engine_is_running(e.handle))
 }
 
 func (e *CGOEngine) AddSymbol(symbolID uint64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	return bool(C.lux_engine_add_symbol(e.handle, C.uint64_t(symbolID)))
 }
 
 func (e *CGOEngine) RemoveSymbol(symbolID uint64) bool {
-	return bool(C.lux_engine_remove_symbol(e.handle, C.uint64_t(symbolID)))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	removed := bool(C.lux_engine_remove_symbol(e.handle, C.uint64_t(symbolID)))
+	if removed {
+		delete(e.trades, symbolID)
+	}
+	return removed
 }
 
 func (e *CGOEngine) HasSymbol(symbolID uint64) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.hasSymbol(symbolID)
+}
+
+func (e *CGOEngine) hasSymbol(symbolID uint64) bool {
 	return bool(C.lux_engine_has_symbol(e.handle, C.uint64_t(symbolID)))
 }
 
+// Symbols returns the engine's symbol IDs in a new slice, in no particular
+// order. Use SymbolsInto to reuse a buffer instead.
 func (e *CGOEngine) Symbols() []uint64 {
-	var count C.size_t
-	ptr := C.lux_engine_symbols(e.handle, &count)
-	if ptr == nil || count == 0 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	count := int(C.lux_engine_symbol_count(e.handle))
+	if count == 0 {
 		return nil
 	}
-	defer C.lux_symbols_free(ptr)
-
-	// Copy to Go slice
 	result := make([]uint64, count)
-	symbols := (*[1 << 30]C.uint64_t)(unsafe.Pointer(ptr))[:count:count]
-	for i := range result {
-		result[i] = uint64(symbols[i])
-	}
+	e.symbolsIntoLocked(result)
 	return result
 }
 
+// SymbolCount returns the number of symbols, for sizing a SymbolsInto buffer.
+func (e *CGOEngine) SymbolCount() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return int(C.lux_engine_symbol_count(e.handle))
+}
+
+// SymbolsInto copies symbol IDs into dst without allocating and returns the
+// total number of symbols. When that is more than len(dst), only the first
+// len(dst) are written; size dst with SymbolCount and call again.
+func (e *CGOEngine) SymbolsInto(dst []uint64) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.symbolsIntoLocked(dst)
+}
+
+func (e *CGOEngine) symbolsIntoLocked(dst []uint64) int {
+	if len(dst) == 0 {
+		return int(C.lux_engine_symbol_count(e.handle))
+	}
+	return int(C.lux_engine_symbols_into(e.handle, (*C.uint64_t)(unsafe.Pointer(&dst[0])), C.size_t(len(dst))))
+}
+
 func (e *CGOEngine) PlaceOrder(order Order) OrderResult {
-	cOrder := orderToC(order)
-	cResult := C.lux_engine_place_order(e.handle, &cOrder)
-	defer C.lux_order_result_free(&cResult)
+	e.mu.Lock()
+	result := e.placeOrderLocked(order)
+	listener, pending := e.listener, e.notify.take()
+	e.mu.Unlock()
 
-	result := OrderResult{
-		Success: bool(cResult.success),
-		OrderID: uint64(cResult.order_id),
-		Error:   C.GoString(&cResult.error[0]),
+	// Notify outside the lock so the listener may call back into the engine
+	dispatch(listener, pending)
+	return result
+}
+
+// PlaceOrders places orders in sequence with a single cgo call, or one per
+// order when the event log is enabled, and returns their results in input
+// order. Orders are matched exactly as if placed one
+// by one, and the trade listener sees the same notifications.
+func (e *CGOEngine) PlaceOrders(orders []Order) []OrderResult {
+	if len(orders) == 0 {
+		return nil
 	}
+	cOrders := make([]C.LuxOrder, len(orders))
+	for i, order := range orders {
+		cOrders[i] = orderToC(order)
+	}
+	cResults := make([]C.LuxOrderResult, len(orders))
+	results := make([]OrderResult, len(orders))
+
+	e.mu.Lock()
+	if hasSlippageBound(orders) || e.events != nil {
+		// Each bound must be priced off the book as its order arrives, and
+		// the event log needs the notifications each order raised
+		for i, order := range orders {
+			results[i] = e.placeOrderLocked(order)
+		}
+	} else {
+		C.lux_engine_place_orders(e.handle, &cOrders[0], C.size_t(len(cOrders)), &cResults[0])
+		for i := range cResults {
+			results[i] = takeOrderResult(&cResults[i])
+			e.recordPlaced(orders[i], results[i], nil)
+		}
+	}
+	listener, pending := e.listener, e.notify.take()
+	e.mu.Unlock()
 
-	if cResult.trade_count > 0 && cResult.trades != nil {
-		trades := (*[1 << 20]C.LuxTrade)(unsafe.Pointer(cResult.trades))[:cResult.trade_count:cResult.trade_count]
-		result.Trades = make([]Trade, len(trades))
-		for i, ct := range trades {
-			result.Trades[i] = tradeFromC(ct)
+	dispatch(listener, pending)
+	return results
+}
+
+func (e *CGOEngine) placeOrderLocked(order Order) OrderResult {
+	if order.Type == OrderTypeMarket && order.MaxSlippageBps > 0 {
+		var ok bool
+		if order, ok = e.boundSlippageLocked(order); !ok {
+			return OrderResult{Error: ErrNoReferencePrice.Error()}
 		}
 	}
+	cOrder := orderToC(order)
+	mark := e.notify.mark()
+	cResult := C.lux_engine_place_order(e.handle, &cOrder)
+	result := takeOrderResult(&cResult)
+	e.recordPlaced(order, result, e.notify.since(mark))
+	return result
+}
 
-	// Notify listener
-	if e.listener != nil {
-		for _, trade := range result.Trades {
-			e.listener.OnTrade(trade)
+// boundSlippageLocked turns a slippage-bounded market order into an IOC
+// limit priced MaxSlippageBps away from the opposite best price. FOK is kept.
+func (e *CGOEngine) boundSlippageLocked(order Order) (Order, bool) {
+	var ref C.LuxPrice
+	if order.IsBuy() {
+		if !C.lux_engine_best_ask(e.handle, C.uint64_t(order.SymbolID), &ref) {
+			return order, false
 		}
+	} else if !C.lux_engine_best_bid(e.handle, C.uint64_t(order.SymbolID), &ref) {
+		return order, false
 	}
 
-	return result
+	// Round the bound toward the reference so it is never exceeded
+	offset := Price(ref) * Price(order.MaxSlippageBps) / 10_000
+	if order.IsBuy() {
+		order.Price = Price(ref) + offset
+	} else {
+		order.Price = Price(ref) - offset
+	}
+	order.Type = OrderTypeLimit
+	if order.TIF != TifFOK {
+		order.TIF = TifIOC
+	}
+	return order, true
+}
+
+func hasSlippageBound(orders []Order) bool {
+	for _, order := range orders {
+		if order.Type == OrderTypeMarket && order.MaxSlippageBps > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordPlaced adds a placed order's trades to the trade history and
+// appends the order to the event log with the trades, cancellations and
+// STP reductions its placement raised.
+func (e *CGOEngine) recordPlaced(order Order, result OrderResult, raised []notification) {
+	for _, trade := range result.Trades {
+		ring := e.trades[trade.SymbolID]
+		if ring == nil {
+			if e.trades == nil {
+				e.trades = make(map[uint64]*tradeRing)
+			}
+			ring = &tradeRing{}
+			e.trades[trade.SymbolID] = ring
+		}
+		ring.add(trade)
+	}
+	if e.events != nil && result.Success {
+		order.ID = result.OrderID
+		e.events.appendPlaced(order, raised)
+	}
 }
 
 func (e *CGOEngine) CancelOrder(symbolID, orderID uint64) CancelResult {
+	e.mu.Lock()
 	cResult := C.lux_engine_cancel_order(e.handle, C.uint64_t(symbolID), C.uint64_t(orderID))
 
 	result := CancelResult{
@@ -149,15 +298,72 @@ func (e *CGOEngine) CancelOrder(symbolID, orderID uint64) CancelResult {
 		order := orderFromC(cResult.cancelled_order)
 		result.CancelledOrder = &order
 
-		if e.listener != nil {
-			e.listener.OnOrderCancelled(order)
+		if e.events != nil {
+			e.events.appendOrder(EventOrderCancelled, order)
 		}
 	}
+	listener, pending := e.listener, e.notify.take()
+	e.mu.Unlock()
 
+	dispatch(listener, pending)
 	return result
 }
 
+// ExpireOrders cancels every resting GTD or DAY order whose expiry is at or
+// before now and returns them with StatusExpired. GTD orders expire at
+// ExpireTime; DAY orders at ExpireTime if set, otherwise at the UTC midnight
+// after they were placed. Call it periodically: orders are not expired on
+// their own, so until then a lapsed order can still match.
+func (e *CGOEngine) ExpireOrders(now time.Time) []Order {
+	e.mu.Lock()
+	var count C.size_t
+	ptr := C.lux_engine_expire_orders(e.handle, C.int64_t(now.UnixNano()), &count)
+	var expired []Order
+	if ptr != nil && count > 0 {
+		expired = make([]Order, count)
+		for i, c := range unsafe.Slice(ptr, count) {
+			expired[i] = orderFromC(c)
+			if e.events != nil {
+				e.events.appendOrder(EventOrderCancelled, expired[i])
+			}
+		}
+		C.lux_orders_free(ptr)
+	}
+	listener, pending := e.listener, e.notify.take()
+	e.mu.Unlock()
+
+	dispatch(listener, pending)
+	return expired
+}
+
+// GetTrades returns up to limit of the symbol's most recent trades, oldest
+// first; limit <= 0 returns everything retained. Only the last
+// TradeHistorySize trades per symbol are kept, older ones are evicted, and
+// the history of a symbol is dropped when it is removed.
+func (e *CGOEngine) GetTrades(symbolID uint64, limit int) []Trade {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	ring := e.trades[symbolID]
+	if ring == nil {
+		return nil
+	}
+	return ring.recent(limit)
+}
+
+// GetCandles returns OHLCV candles of intervalSecs seconds for the count
+// intervals up to and including the current one, oldest first. Intervals are
+// aligned to multiples of intervalSecs since the Unix epoch (UTC), and
+// OpenTime is in UTC. An interval without trades carries the previous close
+// with zero volume. Candles are computed from the trade history kept for
+// GetTrades, so intervals older than the retained trades are omitted.
+func (e *CGOEngine) GetCandles(symbolID uint64, intervalSecs int, count int) []Candle {
+	trades := e.GetTrades(symbolID, 0)
+	return buildCandles(trades, time.Duration(intervalSecs)*time.Second, count, time.Now())
+}
+
 func (e *CGOEngine) GetOrder(symbolID, orderID uint64) (*Order, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	var cOrder C.LuxOrder
 	if !C.lux_engine_get_order(e.handle, C.uint64_t(symbolID), C.uint64_t(orderID), &cOrder) {
 		return nil, false
@@ -167,13 +373,34 @@ func (e *CGOEngine) GetOrder(symbolID, orderID uint64) (*Order, bool) {
 }
 
 func (e *CGOEngine) GetDepth(symbolID uint64, levels int) MarketDepth {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	cDepth := C.lux_engine_get_depth(e.handle, C.uint64_t(symbolID), C.size_t(levels))
 	defer C.lux_market_depth_free(&cDepth)
 
 	return depthFromC(cDepth)
 }
 
+// GetDepthInto is GetDepth without the per-call allocations: it fills dst,
+// reusing the backing arrays of dst.Bids and dst.Asks and growing them only
+// when a side has more levels than they can hold. The slices are overwritten
+// by the next call, so callers must copy any levels they need to retain.
+func (e *CGOEngine) GetDepthInto(symbolID uint64, levels int, dst *MarketDepth) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !e.hasSymbol(symbolID) {
+		return ErrUnknownSymbol
+	}
+	cDepth := C.lux_engine_get_depth(e.handle, C.uint64_t(symbolID), C.size_t(levels))
+	defer C.lux_market_depth_free(&cDepth)
+
+	depthIntoC(cDepth, dst)
+	return nil
+}
+
 func (e *CGOEngine) BestBid(symbolID uint64) (Price, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	var price C.LuxPrice
 	if !C.lux_engine_best_bid(e.handle, C.uint64_t(symbolID), &price) {
 		return 0, false
@@ -182,6 +409,8 @@ func (e *CGOEngine) BestBid(symbolID uint64) (Price, bool) {
 }
 
 func (e *CGOEngine) BestAsk(symbolID uint64) (Price, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	var price C.LuxPrice
 	if !C.lux_engine_best_ask(e.handle, C.uint64_t(symbolID), &price) {
 		return 0, false
@@ -189,7 +418,22 @@ func (e *CGOEngine) BestAsk(symbolID uint64) (Price, bool) {
 	return Price(price), true
 }
 
+// QuoteMarketOrder walks the book for a market order of the given size without
+// mutating it. It returns the volume-weighted average and worst fill prices and
+// how much would fill; filledQty is less than quantity if the book is too thin.
+func (e *CGOEngine) QuoteMarketOrder(symbolID uint64, side Side, quantity Quantity) (avgPx Price, filledQty Quantity, worstPx Price) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var cQuote C.LuxMarketQuote
+	if !C.lux_engine_quote_market_order(e.handle, C.uint64_t(symbolID), C.LuxSide(side), C.LuxQuantity(quantity), &cQuote) {
+		return 0, 0, 0
+	}
+	return Price(cQuote.avg_price), Quantity(cQuote.filled), Price(cQuote.worst_price)
+}
+
 func (e *CGOEngine) GetStats() EngineStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	cStats := C.lux_engine_get_stats(e.handle)
 	return EngineStats{
 		TotalOrdersPlaced:    uint64(cStats.total_orders_placed),
@@ -199,10 +443,62 @@ func (e *CGOEngine) GetStats() EngineStats {
 	}
 }
 
+// LatencyStats returns order-to-trade latency percentiles over the last
+// LatencyWindow orders that traded. Reading does not reset the window; the
+// samples accumulate for the engine's lifetime, oldest overwritten first,
+// until ResetLatencyStats. All fields are zero before any order has traded.
+func (e *CGOEngine) LatencyStats() LatencyStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	cStats := C.lux_engine_get_latency_stats(e.handle)
+	return LatencyStats{
+		Samples: uint64(cStats.samples),
+		P50:     uint64(cStats.p50_ns),
+		P90:     uint64(cStats.p90_ns),
+		P99:     uint64(cStats.p99_ns),
+		Max:     uint64(cStats.max_ns),
+	}
+}
+
+// ResetLatencyStats discards every latency sample, so the next LatencyStats
+// only reflects orders matched from now on.
+func (e *CGOEngine) ResetLatencyStats() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	C.lux_engine_reset_latency_stats(e.handle)
+}
+
+// SetTradeListener registers listener for every trade, fill and
+// cancellation the engine performs. OnTrade fires for each trade, and
+// OnOrderFilled or OnOrderPartiallyFilled for both the incoming and the
+// resting order, so a maker hears about its fills even though another
+// account's order caused them. OnOrderCancelled covers explicit cancels,
+// expiries, unfilled IOC remainders and self-trade prevention. Calls are
+// made after the engine lock is released, in the order the engine raised
+// them, on the goroutine whose call caused them.
 func (e *CGOEngine) SetTradeListener(listener TradeListener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.listener = listener
 }
 
+// SymbolEventLog returns a replayable stream of a symbol's events since the
+// engine was created, as newline-delimited JSON Event records in sequence
+// order. The stream is a snapshot: events recorded after the call are not
+// included. Requires EngineConfig.EventLog; the log is kept in memory for
+// the engine's lifetime.
+func (e *CGOEngine) SymbolEventLog(symbolID uint64) (io.Reader, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.events == nil {
+		return nil, ErrEventLogDisabled
+	}
+	if !e.hasSymbol(symbolID) {
+		return nil, ErrUnknownSymbol
+	}
+	return e.events.reader(symbolID)
+}
+
 // CGOOrderBook provides direct access to a single order book
 type CGOOrderBook struct {
 	handle C.LuxOrderBook
@@ -210,6 +506,8 @@ type CGOOrderBook struct {
 
 // GetOrderBook returns the order book for a symbol
 func (e *CGOEngine) GetOrderBook(symbolID uint64) *CGOOrderBook {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	handle := C.lux_engine_get_orderbook(e.handle, C.uint64_t(symbolID))
 	if handle == nil {
 		return nil
@@ -220,23 +518,7 @@ func (e *CGOEngine) GetOrderBook(symbolID uint64) *CGOOrderBook {
 func (b *CGOOrderBook) PlaceOrder(order Order) OrderResult {
 	cOrder := orderToC(order)
 	cResult := C.lux_orderbook_place_order(b.handle, &cOrder)
-	defer C.lux_order_result_free(&cResult)
-
-	result := OrderResult{
-		Success: bool(cResult.success),
-		OrderID: uint64(cResult.order_id),
-		Error:   C.GoString(&cResult.error[0]),
-	}
-
-	if cResult.trade_count > 0 && cResult.trades != nil {
-		trades := (*[1 << 20]C.LuxTrade)(unsafe.Pointer(cResult.trades))[:cResult.trade_count:cResult.trade_count]
-		result.Trades = make([]Trade, len(trades))
-		for i, ct := range trades {
-			result.Trades[i] = tradeFromC(ct)
-		}
-	}
-
-	return result
+	return takeOrderResult(&cResult)
 }
 
 func (b *CGOOrderBook) CancelOrder(orderID uint64) CancelResult {
@@ -283,44 +565,119 @@ func (b *CGOOrderBook) TotalOrders() int {
 	return int(C.lux_orderbook_total_orders(b.handle))
 }
 
+// SaveSnapshot writes the book's resting orders to w in priority order,
+// with their IDs, timestamps and fill state.
+func (b *CGOOrderBook) SaveSnapshot(w io.Writer) error {
+	var count C.size_t
+	ptr := C.lux_orderbook_orders(b.handle, &count)
+	var orders []Order
+	if ptr != nil && count > 0 {
+		orders = make([]Order, count)
+		for i, c := range unsafe.Slice(ptr, count) {
+			orders[i] = orderFromC(c)
+		}
+		C.lux_orders_free(ptr)
+	}
+	return writeSnapshot(w, orders)
+}
+
+// LoadSnapshot rests the orders saved by SaveSnapshot into this book, which
+// must be empty, without matching them. Order IDs, timestamps and queue
+// priority are preserved exactly; an iceberg comes back with a full display
+// slice. NextOrderID is advanced past the highest restored ID. A snapshot
+// with an order that cannot rest is rejected before any order is restored.
+func (b *CGOOrderBook) LoadSnapshot(r io.Reader) error {
+	orders, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	if b.TotalOrders() != 0 {
+		return ErrBookNotEmpty
+	}
+	if err := validateSnapshot(orders); err != nil {
+		return err
+	}
+	for _, order := range orders {
+		cOrder := orderToC(order)
+		if !C.lux_orderbook_restore_order(b.handle, &cOrder) {
+			return fmt.Errorf("%w: order %d cannot be restored", ErrInvalidSnapshot, order.ID)
+		}
+		reserveOrderID(order.ID)
+	}
+	return nil
+}
+
 // Conversion helpers
 
 func orderToC(o Order) C.LuxOrder {
+	var expireNs int64
+	if !o.ExpireTime.IsZero() {
+		expireNs = o.ExpireTime.UnixNano()
+	}
 	return C.LuxOrder{
-		id:           C.uint64_t(o.ID),
-		symbol_id:    C.uint64_t(o.SymbolID),
-		account_id:   C.uint64_t(o.AccountID),
-		price:        C.LuxPrice(o.Price),
-		quantity:     C.LuxQuantity(o.Quantity),
-		filled:       C.LuxQuantity(o.Filled),
-		side:         C.LuxSide(o.Side),
-		order_type:   C.LuxOrderType(o.Type),
-		tif:          C.LuxTimeInForce(o.TIF),
-		status:       C.LuxOrderStatus(o.Status),
-		stp_group:    C.uint64_t(o.STPGroup),
-		stop_price:   C.LuxPrice(o.StopPrice),
-		timestamp_ns: C.int64_t(o.Timestamp.UnixNano()),
+		id:             C.uint64_t(o.ID),
+		symbol_id:      C.uint64_t(o.SymbolID),
+		account_id:     C.uint64_t(o.AccountID),
+		price:          C.LuxPrice(o.Price),
+		quantity:       C.LuxQuantity(o.Quantity),
+		filled:         C.LuxQuantity(o.Filled),
+		side:           C.LuxSide(o.Side),
+		order_type:     C.LuxOrderType(o.Type),
+		tif:            C.LuxTimeInForce(o.TIF),
+		status:         C.LuxOrderStatus(o.Status),
+		stp_group:      C.uint64_t(o.STPGroup),
+		stop_price:     C.LuxPrice(o.StopPrice),
+		timestamp_ns:   C.int64_t(o.Timestamp.UnixNano()),
+		expire_time_ns: C.int64_t(expireNs),
+		display_qty:    C.LuxQuantity(o.DisplayQty),
 	}
 }
 
 func orderFromC(c C.LuxOrder) Order {
+	var expire time.Time
+	if c.expire_time_ns != 0 {
+		expire = time.Unix(0, int64(c.expire_time_ns))
+	}
 	return Order{
-		ID:        uint64(c.id),
-		SymbolID:  uint64(c.symbol_id),
-		AccountID: uint64(c.account_id),
-		Price:     Price(c.price),
-		Quantity:  Quantity(c.quantity),
-		Filled:    Quantity(c.filled),
-		Side:      Side(c.side),
-		Type:      OrderType(c.order_type),
-		TIF:       TimeInForce(c.tif),
-		Status:    OrderStatus(c.status),
-		STPGroup:  uint64(c.stp_group),
-		StopPrice: Price(c.stop_price),
-		Timestamp: time.Unix(0, int64(c.timestamp_ns)),
+		ID:         uint64(c.id),
+		SymbolID:   uint64(c.symbol_id),
+		AccountID:  uint64(c.account_id),
+		Price:      Price(c.price),
+		Quantity:   Quantity(c.quantity),
+		Filled:     Quantity(c.filled),
+		Side:       Side(c.side),
+		Type:       OrderType(c.order_type),
+		TIF:        TimeInForce(c.tif),
+		Status:     OrderStatus(c.status),
+		STPGroup:   uint64(c.stp_group),
+		StopPrice:  Price(c.stop_price),
+		Timestamp:  time.Unix(0, int64(c.timestamp_ns)),
+		ExpireTime: expire,
+		DisplayQty: Quantity(c.display_qty),
 	}
 }
 
+// takeOrderResult converts c and frees its trade array.
+func takeOrderResult(c *C.LuxOrderResult) OrderResult {
+	defer C.lux_order_result_free(c)
+
+	result := OrderResult{
+		Success: bool(c.success),
+		OrderID: uint64(c.order_id),
+		Error:   C.GoString(&c.error[0]),
+	}
+
+	if c.trade_count > 0 && c.trades != nil {
+		trades := (*[1 << 20]C.LuxTrade)(unsafe.Pointer(c.trades))[:c.trade_count:c.trade_count]
+		result.Trades = make([]Trade, len(trades))
+		for i, ct := range trades {
+			result.Trades[i] = tradeFromC(ct)
+		}
+	}
+
+	return result
+}
+
 func tradeFromC(c C.LuxTrade) Trade {
 	return Trade{
 		ID:              uint64(c.id),
@@ -337,35 +694,34 @@ func tradeFromC(c C.LuxTrade) Trade {
 }
 
 func depthFromC(c C.LuxMarketDepth) MarketDepth {
-	depth := MarketDepth{
-		Timestamp: time.Unix(0, int64(c.timestamp_ns)),
-	}
-
-	if c.bid_count > 0 && c.bids != nil {
-		bids := (*[1 << 20]C.LuxDepthLevel)(unsafe.Pointer(c.bids))[:c.bid_count:c.bid_count]
-		depth.Bids = make([]DepthLevel, len(bids))
-		for i, b := range bids {
-			depth.Bids[i] = DepthLevel{
-				Price:      float64(b.price),
-				Quantity:   float64(b.quantity),
-				OrderCount: int(b.order_count),
-			}
-		}
-	}
+	var depth MarketDepth
+	depthIntoC(c, &depth)
+	return depth
+}
 
-	if c.ask_count > 0 && c.asks != nil {
-		asks := (*[1 << 20]C.LuxDepthLevel)(unsafe.Pointer(c.asks))[:c.ask_count:c.ask_count]
-		depth.Asks = make([]DepthLevel, len(asks))
-		for i, a := range asks {
-			depth.Asks[i] = DepthLevel{
-				Price:      float64(a.price),
-				Quantity:   float64(a.quantity),
-				OrderCount: int(a.order_count),
-			}
-		}
-	}
+// depthIntoC copies c into dst, reusing dst's level slices. A side with no
+// levels keeps dst's existing slice type: nil stays nil, non-nil is emptied.
+func depthIntoC(c C.LuxMarketDepth, dst *MarketDepth) {
+	dst.Timestamp = time.Unix(0, int64(c.timestamp_ns))
+	dst.Bids = levelsFromC(dst.Bids[:0], c.bids, c.bid_count)
+	dst.Asks = levelsFromC(dst.Asks[:0], c.asks, c.ask_count)
+}
 
-	return depth
+func levelsFromC(dst []DepthLevel, levels *C.LuxDepthLevel, count C.size_t) []DepthLevel {
+	if count == 0 || levels == nil {
+		return dst
+	}
+	if cap(dst) < int(count) {
+		dst = make([]DepthLevel, 0, count)
+	}
+	for _, l := range (*[1 << 20]C.LuxDepthLevel)(unsafe.Pointer(levels))[:count:count] {
+		dst = append(dst, DepthLevel{
+			Price:      float64(l.price),
+			Quantity:   float64(l.quantity),
+			OrderCount: int(l.order_count),
+		})
+	}
+	return dst
 }
 
 // GenerateOrderID generates a unique order ID using the C++ generator
@@ -0,0 +1,1100 @@
+package luxdex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Integration tests require the C++ library to be built
+// Run with: CGO_ENABLED=1 go test -v
+
+func newTestEngine(t testing.TB, symbols ...uint64) *CGOEngine {
+	t.Helper()
+	e, err := NewCGOEngine()
+	if err != nil {
+		t.Fatalf("NewCGOEngine() failed: %v", err)
+	}
+	t.Cleanup(e.Close)
+
+	e.Start()
+	t.Cleanup(e.Stop)
+
+	for _, s := range symbols {
+		if !e.AddSymbol(s) {
+			t.Fatalf("AddSymbol(%d) = false", s)
+		}
+	}
+	return e
+}
+
+func TestQuoteMarketOrder(t *testing.T) {
+	e := newTestEngine(t, 1)
+
+	// Two ask levels: 10 @ 100 and 10 @ 101
+	for _, o := range []Order{
+		NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(10).Build(),
+		NewOrder().Symbol(1).Account(1).Sell().Limit(101).Qty(10).Build(),
+	} {
+		if res := e.PlaceOrder(o); !res.Success {
+			t.Fatalf("PlaceOrder failed: %s", res.Error)
+		}
+	}
+
+	// 15 across both levels: (10*100 + 5*101) / 15 = 100.333...
+	avg, filled, worst := e.QuoteMarketOrder(1, SideBuy, QuantityFromFloat(15))
+	if filled != QuantityFromFloat(15) {
+		t.Errorf("filled = %v, want 15", filled.ToFloat())
+	}
+	if want := Price(10033333333); avg != want {
+		t.Errorf("avgPx = %d, want %d", avg, want)
+	}
+	if worst != PriceFromFloat(101) {
+		t.Errorf("worstPx = %v, want 101", worst.ToFloat())
+	}
+
+	// More than the book holds reports a partial fill
+	avg, filled, worst = e.QuoteMarketOrder(1, SideBuy, QuantityFromFloat(50))
+	if filled != QuantityFromFloat(20) {
+		t.Errorf("filled = %v, want 20", filled.ToFloat())
+	}
+	if avg != PriceFromFloat(100.5) {
+		t.Errorf("avgPx = %v, want 100.5", avg.ToFloat())
+	}
+	if worst != PriceFromFloat(101) {
+		t.Errorf("worstPx = %v, want 101", worst.ToFloat())
+	}
+
+	// Quoting must not have consumed any liquidity
+	if ask, ok := e.BestAsk(1); !ok || ask != PriceFromFloat(100) {
+		t.Errorf("BestAsk() = %v, %v, want 100, true", ask.ToFloat(), ok)
+	}
+
+	// Nothing on the bid side
+	if _, filled, _ := e.QuoteMarketOrder(1, SideSell, QuantityFromFloat(1)); filled != 0 {
+		t.Errorf("sell filled = %v, want 0", filled.ToFloat())
+	}
+}
+
+func TestSymbolEventLog(t *testing.T) {
+	config := DefaultEngineConfig()
+	config.EventLog = true
+	e, err := NewCGOEngineWithConfig(config)
+	if err != nil {
+		t.Fatalf("NewCGOEngineWithConfig() failed: %v", err)
+	}
+	defer e.Close()
+	e.Start()
+	defer e.Stop()
+	e.AddSymbol(1)
+	e.AddSymbol(2)
+
+	ask := NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(2).Build()
+	other := NewOrder().Symbol(2).Account(1).Buy().Limit(50).Qty(1).Build()
+	bid := NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(1).Build()
+	for _, o := range []Order{ask, other, bid} {
+		if res := e.PlaceOrder(o); !res.Success {
+			t.Fatalf("PlaceOrder failed: %s", res.Error)
+		}
+	}
+	if res := e.CancelOrder(1, ask.ID); !res.Success {
+		t.Fatalf("CancelOrder failed: %s", res.Error)
+	}
+
+	r, err := e.SymbolEventLog(1)
+	if err != nil {
+		t.Fatalf("SymbolEventLog failed: %v", err)
+	}
+	var events []Event
+	dec := json.NewDecoder(r)
+	for {
+		var ev Event
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		events = append(events, ev)
+	}
+
+	want := []EventType{EventOrderPlaced, EventOrderPlaced, EventTrade, EventOrderCancelled}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, ev := range events {
+		if ev.Seq != uint64(i+1) {
+			t.Errorf("event %d seq = %d, want %d", i, ev.Seq, i+1)
+		}
+		if ev.SymbolID != 1 {
+			t.Errorf("event %d symbol = %d, want 1", i, ev.SymbolID)
+		}
+		if ev.Type != want[i] {
+			t.Errorf("event %d type = %s, want %s", i, ev.Type, want[i])
+		}
+	}
+	if events[0].Order.ID != ask.ID || events[1].Order.ID != bid.ID {
+		t.Errorf("placed order IDs = %d, %d, want %d, %d", events[0].Order.ID, events[1].Order.ID, ask.ID, bid.ID)
+	}
+	if events[2].Trade.Quantity != QuantityFromFloat(1) {
+		t.Errorf("trade qty = %v, want 1", events[2].Trade.Quantity.ToFloat())
+	}
+
+	if _, err := e.SymbolEventLog(3); err != ErrUnknownSymbol {
+		t.Errorf("SymbolEventLog(3) error = %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestSymbolEventLogSTP(t *testing.T) {
+	// A group-7 buy of 3 @ 100 crosses its own ask of 5, queued ahead of
+	// another account's ask of 5.
+	tests := []struct {
+		mode STPMode
+		want []EventType // Events raised by the buy
+		self float64     // Quantity of the self-trading ask in its last event
+	}{
+		{STPCancelMaker, []EventType{EventOrderPlaced, EventOrderCancelled, EventTrade}, 5},
+		{STPDecrement, []EventType{EventOrderPlaced, EventOrderReduced, EventOrderCancelled}, 2},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.mode), func(t *testing.T) {
+			config := DefaultEngineConfig()
+			config.EventLog = true
+			config.STPMode = tc.mode
+			e, err := NewCGOEngineWithConfig(config)
+			if err != nil {
+				t.Fatalf("NewCGOEngineWithConfig() failed: %v", err)
+			}
+			defer e.Close()
+			e.AddSymbol(1)
+
+			self := NewOrder().Symbol(1).Account(1).STPGroup(7).Sell().Limit(100).Qty(5).Build()
+			other := NewOrder().Symbol(1).Account(2).Sell().Limit(100).Qty(5).Build()
+			buy := NewOrder().Symbol(1).Account(3).STPGroup(7).Buy().Limit(100).Qty(3).Build()
+			e.PlaceOrders([]Order{self, other, buy})
+
+			r, err := e.SymbolEventLog(1)
+			if err != nil {
+				t.Fatalf("SymbolEventLog failed: %v", err)
+			}
+			var events []Event
+			dec := json.NewDecoder(r)
+			for {
+				var ev Event
+				if err := dec.Decode(&ev); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				events = append(events, ev)
+			}
+
+			if len(events) != 2+len(tc.want) {
+				t.Fatalf("got %d events, want %d", len(events), 2+len(tc.want))
+			}
+			var selfEvent *Event
+			for i, ev := range events[2:] {
+				if ev.Type != tc.want[i] {
+					t.Errorf("event %d type = %s, want %s", i+2, ev.Type, tc.want[i])
+				}
+				if ev.Order != nil && ev.Order.ID == self.ID {
+					selfEvent = &events[2+i]
+				}
+			}
+			if selfEvent == nil {
+				t.Fatalf("no event for the self-trading ask")
+			}
+			if selfEvent.Order.Quantity != QuantityFromFloat(tc.self) {
+				t.Errorf("self-trading ask quantity = %v, want %v", selfEvent.Order.Quantity.ToFloat(), tc.self)
+			}
+		})
+	}
+}
+
+func TestProRataTieBreak(t *testing.T) {
+	// Three equal bids of 10 units share a 10-unit sell: 3 each, 1 left over.
+	// Order IDs run against queue order so OrderID and Time disagree.
+	run := func(tieBreak ProRataTieBreak) map[uint64]Quantity {
+		config := DefaultEngineConfig()
+		config.Matching = MatchProRata
+		config.ProRataTieBreak = tieBreak
+		e, err := NewCGOEngineWithConfig(config)
+		if err != nil {
+			t.Fatalf("NewCGOEngineWithConfig() failed: %v", err)
+		}
+		defer e.Close()
+		e.AddSymbol(1)
+
+		for i, id := range []uint64{30, 10, 20} {
+			o := NewOrder().ID(id).Symbol(1).Account(uint64(i + 1)).Buy().Limit(100).Build()
+			o.Quantity = 10
+			if res := e.PlaceOrder(o); !res.Success {
+				t.Fatalf("PlaceOrder failed: %s", res.Error)
+			}
+		}
+		sell := NewOrder().ID(40).Symbol(1).Account(9).Sell().Limit(100).Build()
+		sell.Quantity = 10
+		res := e.PlaceOrder(sell)
+		if !res.Success {
+			t.Fatalf("PlaceOrder failed: %s", res.Error)
+		}
+
+		fills := make(map[uint64]Quantity)
+		for _, tr := range res.Trades {
+			fills[tr.BuyOrderID] += tr.Quantity
+		}
+		return fills
+	}
+
+	for _, tc := range []struct {
+		name     string
+		tieBreak ProRataTieBreak
+		winner   uint64
+	}{
+		{"LargestRemainder", TieBreakLargestRemainder, 30}, // equal remainders: queue order
+		{"OrderID", TieBreakOrderID, 10},
+		{"Time", TieBreakTime, 30},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fills := run(tc.tieBreak)
+			for _, id := range []uint64{10, 20, 30} {
+				want := Quantity(3)
+				if id == tc.winner {
+					want = 4
+				}
+				if fills[id] != want {
+					t.Errorf("order %d filled %d, want %d", id, fills[id], want)
+				}
+			}
+			for i := 0; i < 5; i++ {
+				again := run(tc.tieBreak)
+				for id, qty := range fills {
+					if again[id] != qty {
+						t.Fatalf("run %d: order %d filled %d, want %d", i, id, again[id], qty)
+					}
+				}
+			}
+		})
+	}
+}
+
+// countingListener tallies trade notifications.
+type countingListener struct {
+	trades atomic.Uint64
+}
+
+func (l *countingListener) OnTrade(Trade)                          { l.trades.Add(1) }
+func (l *countingListener) OnOrderFilled(Order)                    {}
+func (l *countingListener) OnOrderPartiallyFilled(Order, Quantity) {}
+func (l *countingListener) OnOrderCancelled(Order)                 {}
+
+// fillRecorder records fill notifications.
+type fillRecorder struct {
+	countingListener
+	filled  []uint64   // Order IDs
+	partial []Quantity // Fill quantities, in notification order
+	partIDs []uint64
+}
+
+func (r *fillRecorder) OnOrderFilled(order Order) { r.filled = append(r.filled, order.ID) }
+
+func (r *fillRecorder) OnOrderPartiallyFilled(order Order, fillQty Quantity) {
+	r.partIDs = append(r.partIDs, order.ID)
+	r.partial = append(r.partial, fillQty)
+}
+
+func TestTradeListenerMakerFills(t *testing.T) {
+	e := newTestEngine(t, 1)
+	listener := &fillRecorder{}
+	e.SetTradeListener(listener)
+
+	maker := NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(5).Build()
+	e.PlaceOrder(maker)
+	if n := listener.trades.Load(); n != 0 {
+		t.Fatalf("OnTrade fired %d times for a resting order", n)
+	}
+
+	// The taker's order fills the maker in part, then another takes the rest
+	taker := NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(3).Build()
+	e.PlaceOrder(taker)
+	if n := listener.trades.Load(); n != 1 {
+		t.Errorf("OnTrade fired %d times, want 1", n)
+	}
+	if !reflect.DeepEqual(listener.partIDs, []uint64{maker.ID}) ||
+		!reflect.DeepEqual(listener.partial, []Quantity{QuantityFromFloat(3)}) {
+		t.Errorf("partial fills = %v %v, want maker %d for 3", listener.partIDs, listener.partial, maker.ID)
+	}
+	if !reflect.DeepEqual(listener.filled, []uint64{taker.ID}) {
+		t.Errorf("filled = %v, want taker %d", listener.filled, taker.ID)
+	}
+
+	second := NewOrder().Symbol(1).Account(3).Buy().Limit(100).Qty(2).Build()
+	e.PlaceOrder(second)
+	if n := listener.trades.Load(); n != 2 {
+		t.Errorf("OnTrade fired %d times, want 2", n)
+	}
+	if want := []uint64{taker.ID, second.ID, maker.ID}; !reflect.DeepEqual(listener.filled, want) {
+		t.Errorf("filled = %v, want %v", listener.filled, want)
+	}
+}
+
+func TestTradeListenerTakerFilled(t *testing.T) {
+	for _, matching := range []MatchingAlgorithm{MatchPriceTime, MatchProRata} {
+		t.Run(fmt.Sprint(matching), func(t *testing.T) {
+			config := DefaultEngineConfig()
+			config.Matching = matching
+			e, err := NewCGOEngineWithConfig(config)
+			if err != nil {
+				t.Fatalf("NewCGOEngineWithConfig() failed: %v", err)
+			}
+			defer e.Close()
+			e.AddSymbol(1)
+			listener := &fillRecorder{}
+			e.SetTradeListener(listener)
+
+			// A buy of 6 sweeps 2 @ 100 and 4 of 5 @ 101
+			e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(2).Build())
+			e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(101).Qty(5).Build())
+			taker := NewOrder().Symbol(1).Account(2).Buy().Limit(101).Qty(6).Build()
+			e.PlaceOrder(taker)
+
+			var filled int
+			for _, id := range listener.filled {
+				if id == taker.ID {
+					filled++
+				}
+			}
+			if filled != 1 {
+				t.Errorf("taker OnOrderFilled fired %d times, want once", filled)
+			}
+			var partial []Quantity
+			for i, id := range listener.partIDs {
+				if id == taker.ID {
+					partial = append(partial, listener.partial[i])
+				}
+			}
+			if want := []Quantity{QuantityFromFloat(2)}; !reflect.DeepEqual(partial, want) {
+				t.Errorf("taker partial fills = %v, want %v", partial, want)
+			}
+
+			// A resting order that never trades gets neither callback
+			before := len(listener.filled) + len(listener.partIDs)
+			e.PlaceOrder(NewOrder().Symbol(1).Account(3).Buy().Limit(90).Qty(1).Build())
+			if after := len(listener.filled) + len(listener.partIDs); after != before {
+				t.Errorf("resting order raised %d fill callbacks", after-before)
+			}
+		})
+	}
+}
+
+// TestCGOEngineConcurrent hammers one engine from many goroutines; run it
+// with -race.
+func TestCGOEngineConcurrent(t *testing.T) {
+	e := newTestEngine(t, 1)
+	listener := &countingListener{}
+	e.SetTradeListener(listener)
+
+	const workers, perWorker = 8, 200
+	var cancelled atomic.Uint64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				b := NewOrder().Symbol(1).Account(uint64(w)).Limit(float64(95 + i%10)).Qty(1)
+				if (w+i)%2 == 0 {
+					b = b.Buy()
+				} else {
+					b = b.Sell()
+				}
+				res := e.PlaceOrder(b.Build())
+				if !res.Success {
+					t.Errorf("PlaceOrder failed: %s", res.Error)
+					return
+				}
+				if i%5 == 0 && e.CancelOrder(1, res.OrderID).Success {
+					cancelled.Add(1)
+				}
+				e.BestBid(1)
+				e.GetDepth(1, 5)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := e.GetStats()
+	if stats.TotalOrdersPlaced != workers*perWorker {
+		t.Errorf("TotalOrdersPlaced = %d, want %d", stats.TotalOrdersPlaced, workers*perWorker)
+	}
+	if stats.TotalOrdersCancelled != cancelled.Load() {
+		t.Errorf("TotalOrdersCancelled = %d, want %d", stats.TotalOrdersCancelled, cancelled.Load())
+	}
+	if got := listener.trades.Load(); got != stats.TotalTrades {
+		t.Errorf("listener saw %d trades, engine reports %d", got, stats.TotalTrades)
+	}
+}
+
+func TestGetDepthInto(t *testing.T) {
+	e := newTestEngine(t, 1)
+	for i := 0; i < 5; i++ {
+		e.PlaceOrder(NewOrder().Symbol(1).Account(1).Buy().Limit(float64(90 + i)).Qty(1).Build())
+		e.PlaceOrder(NewOrder().Symbol(1).Account(2).Sell().Limit(float64(100 + i)).Qty(2).Build())
+	}
+
+	var depth MarketDepth
+	if err := e.GetDepthInto(1, 5, &depth); err != nil {
+		t.Fatalf("GetDepthInto failed: %v", err)
+	}
+	want := e.GetDepth(1, 5)
+	if !reflect.DeepEqual(depth.Bids, want.Bids) || !reflect.DeepEqual(depth.Asks, want.Asks) {
+		t.Fatalf("GetDepthInto = %+v, want %+v", depth, want)
+	}
+
+	// A shallower read reuses the same backing arrays
+	bids := &depth.Bids[0]
+	if err := e.GetDepthInto(1, 2, &depth); err != nil {
+		t.Fatalf("GetDepthInto failed: %v", err)
+	}
+	if len(depth.Bids) != 2 || len(depth.Asks) != 2 {
+		t.Errorf("levels = %d/%d, want 2/2", len(depth.Bids), len(depth.Asks))
+	}
+	if &depth.Bids[0] != bids {
+		t.Error("GetDepthInto reallocated the bid slice")
+	}
+	if !reflect.DeepEqual(depth.Bids, want.Bids[:2]) || !reflect.DeepEqual(depth.Asks, want.Asks[:2]) {
+		t.Errorf("GetDepthInto(2) = %+v, want top two levels of %+v", depth, want)
+	}
+
+	if err := e.GetDepthInto(99, 5, &depth); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("GetDepthInto(99) error = %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func benchDepthEngine(b *testing.B) *CGOEngine {
+	e := newTestEngine(b, 1)
+	for i := 0; i < 20; i++ {
+		e.PlaceOrder(NewOrder().Symbol(1).Account(1).Buy().Limit(float64(80 + i)).Qty(1).Build())
+		e.PlaceOrder(NewOrder().Symbol(1).Account(2).Sell().Limit(float64(101 + i)).Qty(1).Build())
+	}
+	return e
+}
+
+func BenchmarkGetDepth(b *testing.B) {
+	e := benchDepthEngine(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.GetDepth(1, 10)
+	}
+}
+
+func BenchmarkGetDepthInto(b *testing.B) {
+	e := benchDepthEngine(b)
+	var depth MarketDepth
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.GetDepthInto(1, 10, &depth)
+	}
+}
+
+func TestSymbolsInto(t *testing.T) {
+	e := newTestEngine(t)
+	if n := e.SymbolCount(); n != 0 {
+		t.Fatalf("SymbolCount of empty engine = %d", n)
+	}
+	if got := e.Symbols(); got != nil {
+		t.Fatalf("Symbols of empty engine = %v, want nil", got)
+	}
+	for _, s := range []uint64{3, 1, 2} {
+		e.AddSymbol(s)
+	}
+
+	if n := e.SymbolCount(); n != 3 {
+		t.Fatalf("SymbolCount = %d, want 3", n)
+	}
+	buf := make([]uint64, e.SymbolCount())
+	if n := e.SymbolsInto(buf); n != 3 {
+		t.Fatalf("SymbolsInto = %d, want 3", n)
+	}
+	sort.Slice(buf, func(i, j int) bool { return buf[i] < buf[j] })
+	if !reflect.DeepEqual(buf, []uint64{1, 2, 3}) {
+		t.Errorf("SymbolsInto filled %v, want [1 2 3]", buf)
+	}
+
+	// A short buffer is filled as far as it goes and reports the total
+	short := []uint64{0, 0}
+	if n := e.SymbolsInto(short); n != 3 || short[0] == 0 || short[1] == 0 {
+		t.Errorf("SymbolsInto(short) = %d, %v, want 3 with both slots filled", n, short)
+	}
+	if n := e.SymbolsInto(nil); n != 3 {
+		t.Errorf("SymbolsInto(nil) = %d, want 3", n)
+	}
+	if got := e.Symbols(); len(got) != 3 {
+		t.Errorf("Symbols = %v, want 3 IDs", got)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() { e.SymbolsInto(buf) })
+	if allocs != 0 {
+		t.Errorf("SymbolsInto allocates %v times per call, want 0", allocs)
+	}
+}
+
+func TestPlaceOrders(t *testing.T) {
+	e := newTestEngine(t, 1)
+	listener := &countingListener{}
+	e.SetTradeListener(listener)
+
+	orders := []Order{
+		NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(5).Build(),
+		NewOrder().Symbol(1).Account(1).Sell().Limit(101).Qty(5).Build(),
+		NewOrder().Symbol(1).Account(2).Buy().Limit(101).Qty(8).Build(),
+		NewOrder().Symbol(2).Account(2).Buy().Limit(100).Qty(1).Build(), // unknown symbol
+	}
+	results := e.PlaceOrders(orders)
+	if len(results) != len(orders) {
+		t.Fatalf("got %d results, want %d", len(results), len(orders))
+	}
+	for i, res := range results[:3] {
+		if !res.Success || res.OrderID != orders[i].ID {
+			t.Errorf("result %d = %+v, want success for order %d", i, res, orders[i].ID)
+		}
+	}
+	if len(results[0].Trades) != 0 || len(results[2].Trades) != 2 {
+		t.Errorf("trade counts = %d/%d, want 0/2", len(results[0].Trades), len(results[2].Trades))
+	}
+	if results[3].Success {
+		t.Error("order for unknown symbol succeeded")
+	}
+	if got := listener.trades.Load(); got != 2 {
+		t.Errorf("listener saw %d trades, want 2", got)
+	}
+	if ask, ok := e.BestAsk(1); !ok || ask != PriceFromFloat(101) {
+		t.Errorf("BestAsk() = %v, %v, want 101, true", ask.ToFloat(), ok)
+	}
+
+	if results := e.PlaceOrders(nil); len(results) != 0 {
+		t.Errorf("PlaceOrders(nil) = %v, want empty", results)
+	}
+}
+
+func benchOrders(n int) []Order {
+	orders := make([]Order, n)
+	for i := range orders {
+		orders[i] = NewOrder().Symbol(1).Account(1).Buy().Limit(float64(50 + i%50)).Qty(1).Build()
+	}
+	return orders
+}
+
+func BenchmarkPlaceOrderLoop(b *testing.B) {
+	e := newTestEngine(b, 1)
+	orders := benchOrders(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, o := range orders {
+			e.PlaceOrder(o)
+		}
+	}
+}
+
+func BenchmarkPlaceOrders(b *testing.B) {
+	e := newTestEngine(b, 1)
+	orders := benchOrders(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.PlaceOrders(orders)
+	}
+}
+
+func TestMarketOrderMaxSlippage(t *testing.T) {
+	e := newTestEngine(t, 1)
+	for _, px := range []float64{100, 100.5, 102} {
+		e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(px).Qty(5).Build())
+	}
+
+	// 100bps from a 100 best ask allows fills up to 101
+	res := e.PlaceOrder(NewOrder().Symbol(1).Account(2).Buy().Market().Qty(15).MaxSlippage(100).Build())
+	if !res.Success {
+		t.Fatalf("PlaceOrder failed: %s", res.Error)
+	}
+	var filled Quantity
+	for _, tr := range res.Trades {
+		if tr.Price > PriceFromFloat(101) {
+			t.Errorf("filled at %v, beyond the 101 bound", tr.Price.ToFloat())
+		}
+		filled += tr.Quantity
+	}
+	if filled != QuantityFromFloat(10) {
+		t.Errorf("filled = %v, want 10", filled.ToFloat())
+	}
+	if ask, ok := e.BestAsk(1); !ok || ask != PriceFromFloat(102) {
+		t.Errorf("BestAsk() = %v, %v, want 102, true", ask.ToFloat(), ok)
+	}
+	if _, ok := e.BestBid(1); ok {
+		t.Error("unfilled remainder rested on the book")
+	}
+
+	// No bids to price a sell against
+	res = e.PlaceOrder(NewOrder().Symbol(1).Account(2).Sell().Market().Qty(1).MaxSlippage(50).Build())
+	if res.Success || res.Error != ErrNoReferencePrice.Error() {
+		t.Errorf("sell into empty book = %+v, want ErrNoReferencePrice", res)
+	}
+}
+
+func TestExpireOrders(t *testing.T) {
+	e := newTestEngine(t, 1)
+	listener := &cancelRecorder{}
+	e.SetTradeListener(listener)
+
+	now := time.Now()
+	gtd := NewOrder().Symbol(1).Account(1).Buy().Limit(99).Qty(1).ExpireAt(now.Add(time.Minute)).Build()
+	gtc := NewOrder().Symbol(1).Account(1).Buy().Limit(98).Qty(1).Build()
+	for _, o := range []Order{gtd, gtc} {
+		if res := e.PlaceOrder(o); !res.Success {
+			t.Fatalf("PlaceOrder failed: %s", res.Error)
+		}
+	}
+	if got, ok := e.GetOrder(1, gtd.ID); !ok || !got.ExpireTime.Equal(gtd.ExpireTime) {
+		t.Errorf("GetOrder ExpireTime = %v, want %v", got.ExpireTime, gtd.ExpireTime)
+	}
+
+	if expired := e.ExpireOrders(now); len(expired) != 0 {
+		t.Fatalf("ExpireOrders before expiry = %+v, want none", expired)
+	}
+
+	expired := e.ExpireOrders(now.Add(time.Minute))
+	if len(expired) != 1 || expired[0].ID != gtd.ID || expired[0].Status != StatusExpired {
+		t.Fatalf("ExpireOrders = %+v, want order %d expired", expired, gtd.ID)
+	}
+	if _, ok := e.GetOrder(1, gtd.ID); ok {
+		t.Error("expired order still on the book")
+	}
+	if _, ok := e.GetOrder(1, gtc.ID); !ok {
+		t.Error("GTC order was expired")
+	}
+	if len(listener.cancelled) != 1 || listener.cancelled[0].ID != gtd.ID {
+		t.Errorf("listener cancellations = %+v, want order %d", listener.cancelled, gtd.ID)
+	}
+
+	// DAY orders without an explicit expiry lapse at the next UTC midnight
+	day := NewOrder().Symbol(1).Account(1).Sell().Limit(200).Qty(1).TimeInForce(TifDAY).Build()
+	e.PlaceOrder(day)
+	midnight := day.Timestamp.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if expired := e.ExpireOrders(midnight.Add(-time.Nanosecond)); len(expired) != 0 {
+		t.Errorf("DAY order expired before midnight: %+v", expired)
+	}
+	if expired := e.ExpireOrders(midnight); len(expired) != 1 || expired[0].ID != day.ID {
+		t.Errorf("ExpireOrders at midnight = %+v, want DAY order %d", expired, day.ID)
+	}
+}
+
+func TestIcebergOrder(t *testing.T) {
+	e := newTestEngine(t, 1)
+	iceberg := NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(10).Iceberg(2).Build()
+	other := NewOrder().Symbol(1).Account(2).Sell().Limit(100).Qty(3).Build()
+	e.PlaceOrder(iceberg)
+	e.PlaceOrder(other)
+
+	// Only the 2-lot slice is displayed
+	if got := sideQty(e.GetDepth(1, 5).Asks); got != 5 {
+		t.Errorf("displayed ask quantity = %v, want 5", got)
+	}
+
+	// The slice fills first, then the refilled iceberg queues behind other
+	res := e.PlaceOrder(NewOrder().Symbol(1).Account(3).Buy().Limit(100).Qty(3).Build())
+	if len(res.Trades) != 2 || res.Trades[0].SellOrderID != iceberg.ID || res.Trades[0].Quantity != QuantityFromFloat(2) ||
+		res.Trades[1].SellOrderID != other.ID || res.Trades[1].Quantity != QuantityFromFloat(1) {
+		t.Fatalf("trades = %+v, want 2 from the iceberg then 1 from order %d", res.Trades, other.ID)
+	}
+	if got := sideQty(e.GetDepth(1, 5).Asks); got != 4 {
+		t.Errorf("displayed ask quantity = %v, want 4", got)
+	}
+	if o, ok := e.GetOrder(1, iceberg.ID); !ok || o.Filled != QuantityFromFloat(2) || o.DisplayQty != QuantityFromFloat(2) {
+		t.Errorf("GetOrder(iceberg) = %+v, %v, want 2 filled", o, ok)
+	}
+
+	// A large buy sweeps the hidden reserve at the same price
+	res = e.PlaceOrder(NewOrder().Symbol(1).Account(3).Buy().Limit(100).Qty(20).Build())
+	var fromIceberg Quantity
+	for _, tr := range res.Trades {
+		if tr.SellOrderID == iceberg.ID {
+			fromIceberg += tr.Quantity
+		}
+	}
+	if fromIceberg != QuantityFromFloat(8) {
+		t.Errorf("filled %v from the iceberg reserve, want 8", fromIceberg.ToFloat())
+	}
+	if _, ok := e.BestAsk(1); ok {
+		t.Error("asks remain after sweeping the level")
+	}
+}
+
+func TestQuoteMarketOrderIceberg(t *testing.T) {
+	e := newTestEngine(t, 1)
+	e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(10).Iceberg(2).Build())
+
+	// The hidden reserve is fillable, so it counts towards the quote
+	_, filled, _ := e.QuoteMarketOrder(1, SideBuy, QuantityFromFloat(10))
+	if filled != QuantityFromFloat(10) {
+		t.Errorf("filled = %v, want 10", filled.ToFloat())
+	}
+
+	// A FOK order can likewise fill through the reserve
+	res := e.PlaceOrder(NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(8).TimeInForce(TifFOK).Build())
+	if !res.Success || len(res.Trades) == 0 {
+		t.Fatalf("FOK against the iceberg = %+v, want a fill", res)
+	}
+}
+
+func TestPostOnly(t *testing.T) {
+	e := newTestEngine(t, 1)
+	ask := NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(5).Build()
+	e.PlaceOrder(ask)
+
+	crossing := NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(1).TimeInForce(TifPostOnly).Build()
+	res := e.PlaceOrder(crossing)
+	if res.Success || len(res.Trades) != 0 {
+		t.Fatalf("crossing post-only order: success = %v, trades = %+v, want rejection", res.Success, res.Trades)
+	}
+	if res.Error != "Post-only order would cross the book" {
+		t.Errorf("Error = %q", res.Error)
+	}
+	if _, ok := e.GetOrder(1, crossing.ID); ok {
+		t.Error("rejected post-only order is resting")
+	}
+	if got := sideQty(e.GetDepth(1, 5).Asks); got != 5 {
+		t.Errorf("ask quantity = %v, want 5 (untouched)", got)
+	}
+
+	passive := NewOrder().Symbol(1).Account(2).Buy().Limit(99).Qty(1).TimeInForce(TifPostOnly).Build()
+	if res := e.PlaceOrder(passive); !res.Success || len(res.Trades) != 0 {
+		t.Fatalf("passive post-only order: %+v", res)
+	}
+	if o, ok := e.GetOrder(1, passive.ID); !ok || o.TIF != TifPostOnly {
+		t.Errorf("GetOrder(passive) = %+v, %v, want resting post-only order", o, ok)
+	}
+}
+
+func TestGetTrades(t *testing.T) {
+	e := newTestEngine(t, 1, 2)
+	if trades := e.GetTrades(1, 10); len(trades) != 0 {
+		t.Fatalf("GetTrades before any trade = %+v", trades)
+	}
+
+	// Each pair of orders produces one trade of Qty(i+1)
+	const total = TradeHistorySize + 5
+	orders := make([]Order, 0, 2*total)
+	for i := 0; i < total; i++ {
+		qty := float64(i + 1)
+		orders = append(orders,
+			NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(qty).Build(),
+			NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(qty).Build())
+	}
+	e.PlaceOrders(orders)
+
+	recent := e.GetTrades(1, 3)
+	if len(recent) != 3 {
+		t.Fatalf("len(GetTrades(1, 3)) = %d, want 3", len(recent))
+	}
+	for i, tr := range recent {
+		if want := QuantityFromFloat(float64(total - 2 + i)); tr.Quantity != want {
+			t.Errorf("trade %d quantity = %v, want %v", i, tr.Quantity.ToFloat(), want.ToFloat())
+		}
+	}
+
+	all := e.GetTrades(1, 0)
+	if len(all) != TradeHistorySize {
+		t.Fatalf("len(GetTrades(1, 0)) = %d, want %d", len(all), TradeHistorySize)
+	}
+	if want := QuantityFromFloat(6); all[0].Quantity != want {
+		t.Errorf("oldest retained quantity = %v, want %v (first 5 evicted)", all[0].Quantity.ToFloat(), want.ToFloat())
+	}
+	if trades := e.GetTrades(2, 10); len(trades) != 0 {
+		t.Errorf("GetTrades(2) = %+v, want none", trades)
+	}
+}
+
+func TestBuildCandles(t *testing.T) {
+	at := func(sec int64) time.Time { return time.Unix(sec, 0) }
+	trade := func(sec int64, px, qty float64) Trade {
+		return Trade{Price: PriceFromFloat(px), Quantity: QuantityFromFloat(qty), Timestamp: at(sec)}
+	}
+	trades := []Trade{
+		trade(1000, 99, 1), // before the window: seeds the carried close
+		trade(1200, 100, 1),
+		trade(1230, 104, 2),
+		trade(1250, 98, 1),
+		trade(1259, 101, 3),
+		// nothing in [1260, 1320)
+		trade(1330, 102, 1),
+	}
+
+	candles := buildCandles(trades, time.Minute, 4, at(1355))
+	want := []Candle{
+		{OpenTime: at(1140).UTC(), Open: PriceFromFloat(99), High: PriceFromFloat(99), Low: PriceFromFloat(99), Close: PriceFromFloat(99)},
+		{OpenTime: at(1200).UTC(), Open: PriceFromFloat(100), High: PriceFromFloat(104), Low: PriceFromFloat(98), Close: PriceFromFloat(101), Volume: QuantityFromFloat(7)},
+		{OpenTime: at(1260).UTC(), Open: PriceFromFloat(101), High: PriceFromFloat(101), Low: PriceFromFloat(101), Close: PriceFromFloat(101)},
+		{OpenTime: at(1320).UTC(), Open: PriceFromFloat(102), High: PriceFromFloat(102), Low: PriceFromFloat(102), Close: PriceFromFloat(102), Volume: QuantityFromFloat(1)},
+	}
+	if !reflect.DeepEqual(candles, want) {
+		t.Errorf("candles =\n%+v\nwant\n%+v", candles, want)
+	}
+
+	// Without an earlier trade, leading empty intervals are omitted
+	if got := buildCandles(trades[1:], time.Minute, 4, at(1355)); len(got) != 3 || !got[0].OpenTime.Equal(at(1200)) {
+		t.Errorf("candles without seed = %+v, want 3 starting at 1200", got)
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	e := newTestEngine(t, 1)
+	e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(3).Build())
+	e.PlaceOrder(NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(3).Build())
+
+	candles := e.GetCandles(1, 3600, 2)
+	if len(candles) == 0 {
+		t.Fatal("GetCandles returned no candles")
+	}
+	var volume Quantity
+	for _, c := range candles {
+		volume += c.Volume
+		if c.OpenTime.Unix()%3600 != 0 {
+			t.Errorf("OpenTime %v is not hour-aligned", c.OpenTime)
+		}
+	}
+	if last := candles[len(candles)-1]; last.Close != PriceFromFloat(100) || volume != QuantityFromFloat(3) {
+		t.Errorf("candles = %+v, want close 100 and volume 3", candles)
+	}
+}
+
+func TestOrderBookSnapshot(t *testing.T) {
+	src := newTestEngine(t, 1)
+	for _, o := range []Order{
+		NewOrder().Symbol(1).Account(1).Sell().Limit(101).Qty(2).Build(),
+		NewOrder().Symbol(1).Account(2).Sell().Limit(101).Qty(3).Build(),
+		NewOrder().Symbol(1).Account(3).Sell().Limit(102).Qty(1).ExpireAt(time.Now().Add(time.Hour)).Build(),
+		NewOrder().Symbol(1).Account(4).Buy().Limit(99).Qty(4).Build(),
+		NewOrder().Symbol(1).Account(5).Buy().Limit(99).Qty(1).Build(),
+	} {
+		src.PlaceOrder(o)
+	}
+	// Partially fill the first ask so fill state must survive too
+	src.PlaceOrder(NewOrder().Symbol(1).Account(6).Buy().Limit(101).Qty(1).Build())
+
+	var buf bytes.Buffer
+	if err := src.GetOrderBook(1).SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	saved := buf.Bytes()
+
+	dst := newTestEngine(t, 1)
+	book := dst.GetOrderBook(1)
+	if err := book.LoadSnapshot(bytes.NewReader(saved)); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	before, after := src.GetDepth(1, 10), dst.GetDepth(1, 10)
+	if !reflect.DeepEqual(before.Bids, after.Bids) || !reflect.DeepEqual(before.Asks, after.Asks) {
+		t.Errorf("depth after reload =\n%+v\nwant\n%+v", after, before)
+	}
+	var reloaded bytes.Buffer
+	book.SaveSnapshot(&reloaded)
+	if !bytes.Equal(reloaded.Bytes(), saved) {
+		t.Error("snapshot of the reloaded book differs from the original")
+	}
+
+	// Queue priority is unchanged: the same sweep fills the same orders
+	sweep := func(e *CGOEngine) []uint64 {
+		var ids []uint64
+		for _, tr := range e.PlaceOrder(NewOrder().Symbol(1).Account(7).Buy().Limit(101).Qty(4).Build()).Trades {
+			ids = append(ids, tr.SellOrderID)
+		}
+		return ids
+	}
+	if want, got := sweep(src), sweep(dst); !reflect.DeepEqual(got, want) || len(got) != 2 {
+		t.Errorf("reloaded fills from %v, want %v", got, want)
+	}
+
+	if err := book.LoadSnapshot(bytes.NewReader(saved)); !errors.Is(err, ErrBookNotEmpty) {
+		t.Errorf("LoadSnapshot into a non-empty book = %v, want ErrBookNotEmpty", err)
+	}
+	empty := newTestEngine(t, 1).GetOrderBook(1)
+	if err := empty.LoadSnapshot(bytes.NewReader([]byte("garbage"))); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("LoadSnapshot(garbage) = %v, want ErrInvalidSnapshot", err)
+	}
+	future := append([]byte(nil), saved...)
+	future[7] = 2
+	if err := empty.LoadSnapshot(bytes.NewReader(future)); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("LoadSnapshot(version 2) = %v, want ErrSnapshotVersion", err)
+	}
+
+	// A crossed snapshot is rejected without resting any of its orders
+	var crossed bytes.Buffer
+	writeSnapshot(&crossed, []Order{
+		NewOrder().Symbol(1).Account(1).Buy().Limit(100).Qty(1).Build(),
+		NewOrder().Symbol(1).Account(2).Sell().Limit(99).Qty(1).Build(),
+	})
+	if err := empty.LoadSnapshot(&crossed); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("LoadSnapshot(crossed) = %v, want ErrInvalidSnapshot", err)
+	}
+	if n := empty.TotalOrders(); n != 0 {
+		t.Errorf("TotalOrders after a rejected snapshot = %d, want 0", n)
+	}
+}
+
+func TestOrderResultErr(t *testing.T) {
+	e := newTestEngine(t, 1)
+	if err := e.PlaceOrder(NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(1).Build()).Err(); err != nil {
+		t.Fatalf("Err() for an accepted order = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		order Order
+		want  error
+	}{
+		{"unknown symbol", NewOrder().Symbol(9).Buy().Limit(100).Qty(1).Build(), ErrUnknownSymbol},
+		{"zero quantity", NewOrder().Symbol(1).Buy().Limit(100).Build(), ErrInvalidOrder},
+		{"crossing post-only", NewOrder().Symbol(1).Buy().Limit(100).Qty(1).TimeInForce(TifPostOnly).Build(), ErrPostOnlyWouldCross},
+		{"no reference price", NewOrder().Symbol(1).Sell().Market().Qty(1).MaxSlippage(50).Build(), ErrNoReferencePrice},
+	}
+	for _, tc := range tests {
+		res := e.PlaceOrder(tc.order)
+		err := res.Err()
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: Err() = %v, want %v", tc.name, err, tc.want)
+		}
+		var orderErr *OrderError
+		if !errors.As(err, &orderErr) || orderErr.Msg != res.Error {
+			t.Errorf("%s: Err() = %#v, want *OrderError with message %q", tc.name, err, res.Error)
+		}
+	}
+
+	unknown := OrderResult{Error: "something new"}.Err()
+	if unknown == nil || unknown.Error() != "something new" || errors.Unwrap(unknown) != nil {
+		t.Errorf("Err() for an unmapped message = %v", unknown)
+	}
+}
+
+// cancelRecorder records cancellation notifications.
+type cancelRecorder struct {
+	countingListener
+	cancelled []Order
+}
+
+func (r *cancelRecorder) OnOrderCancelled(order Order) { r.cancelled = append(r.cancelled, order) }
+
+func TestSTPMode(t *testing.T) {
+	// A self-trading ask (group 7) queued ahead of another account's ask,
+	// both 5 @ 100, meet a group-7 buy of 8 @ 100.
+	tests := []struct {
+		mode      STPMode
+		traded    float64 // Quantity filled by the buy
+		askQty    float64 // Left on the ask side
+		bidQty    float64 // Buy remainder resting on the book
+		selfAlive bool    // Self-trading ask still resting
+	}{
+		{STPCancelMaker, 5, 0, 3, false},
+		{STPCancelTaker, 0, 10, 0, true},
+		{STPCancelBoth, 0, 5, 0, false},
+		{STPDecrement, 3, 2, 0, false},
+	}
+	for _, matching := range []MatchingAlgorithm{MatchPriceTime, MatchProRata} {
+		for _, tc := range tests {
+			t.Run(fmt.Sprintf("%d/%d", matching, tc.mode), func(t *testing.T) {
+				config := DefaultEngineConfig()
+				config.Matching = matching
+				config.STPMode = tc.mode
+				e, err := NewCGOEngineWithConfig(config)
+				if err != nil {
+					t.Fatalf("NewCGOEngineWithConfig() failed: %v", err)
+				}
+				defer e.Close()
+				e.AddSymbol(1)
+
+				self := NewOrder().Symbol(1).Account(1).STPGroup(7).Sell().Limit(100).Qty(5).Build()
+				other := NewOrder().Symbol(1).Account(2).Sell().Limit(100).Qty(5).Build()
+				buy := NewOrder().Symbol(1).Account(3).STPGroup(7).Buy().Limit(100).Qty(8).Build()
+				e.PlaceOrder(self)
+				e.PlaceOrder(other)
+				res := e.PlaceOrder(buy)
+
+				var traded Quantity
+				for _, tr := range res.Trades {
+					if tr.SellOrderID == self.ID {
+						t.Errorf("self-trade executed: %+v", tr)
+					}
+					traded += tr.Quantity
+				}
+				if traded != QuantityFromFloat(tc.traded) {
+					t.Errorf("traded = %v, want %v", traded.ToFloat(), tc.traded)
+				}
+
+				depth := e.GetDepth(1, 5)
+				if got := sideQty(depth.Asks); got != tc.askQty {
+					t.Errorf("ask quantity = %v, want %v", got, tc.askQty)
+				}
+				if got := sideQty(depth.Bids); got != tc.bidQty {
+					t.Errorf("bid quantity = %v, want %v", got, tc.bidQty)
+				}
+				if _, ok := e.GetOrder(1, self.ID); ok != tc.selfAlive {
+					t.Errorf("self-trading ask resting = %v, want %v", ok, tc.selfAlive)
+				}
+			})
+		}
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	e := newTestEngine(t, 1)
+	if got := e.LatencyStats(); got != (LatencyStats{}) {
+		t.Fatalf("LatencyStats before any trade = %+v, want zero", got)
+	}
+
+	// Resting orders are not sampled; each crossing buy is
+	e.PlaceOrder(NewOrder().Symbol(1).Account(1).Buy().Limit(90).Qty(1).Build())
+	const crosses = LatencyWindow + 10
+	orders := make([]Order, 0, 2*crosses)
+	for i := 0; i < crosses; i++ {
+		orders = append(orders,
+			NewOrder().Symbol(1).Account(1).Sell().Limit(100).Qty(1).Build(),
+			NewOrder().Symbol(1).Account(2).Buy().Limit(100).Qty(1).Build())
+	}
+	e.PlaceOrders(orders)
+
+	got := e.LatencyStats()
+	if got.Samples != LatencyWindow {
+		t.Errorf("Samples = %d, want %d", got.Samples, LatencyWindow)
+	}
+	if got.P50 == 0 || got.P50 > got.P90 || got.P90 > got.P99 || got.P99 > got.Max {
+		t.Errorf("percentiles not ordered: %+v", got)
+	}
+
+	e.ResetLatencyStats()
+	if got := e.LatencyStats(); got != (LatencyStats{}) {
+		t.Errorf("LatencyStats after reset = %+v, want zero", got)
+	}
+	e.PlaceOrder(NewOrder().Symbol(1).Account(3).Sell().Limit(90).Qty(1).Build())
+	if got := e.LatencyStats(); got.Samples != 1 || got.P50 != got.Max {
+		t.Errorf("LatencyStats after one trade = %+v, want a single sample", got)
+	}
+}
+
+// sideQty sums the quantity on one side of a depth snapshot.
+func sideQty(levels []DepthLevel) float64 {
+	var total float64
+	for _, l := range levels {
+		total += l.Quantity
+	}
+	return total
+}
@@ -0,0 +1,157 @@
+package luxdex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the order book snapshot format written by
+// CGOOrderBook.SaveSnapshot. LoadSnapshot rejects any other version.
+const SnapshotVersion uint32 = 1
+
+// Snapshot layout, big-endian: 4-byte magic, uint32 version, uint64 order
+// count, then one snapshotOrder per resting order in priority order.
+var snapshotMagic = []byte("LXOB")
+
+var (
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	ErrBookNotEmpty    = errors.New("order book not empty")
+)
+
+// snapshotOrder is the fixed-size record of one resting order.
+type snapshotOrder struct {
+	ID           uint64
+	SymbolID     uint64
+	AccountID    uint64
+	Price        int64
+	Quantity     int64
+	Filled       int64
+	Side         uint8
+	Type         uint8
+	TIF          uint8
+	Status       uint8
+	STPGroup     uint64
+	StopPrice    int64
+	TimestampNs  int64
+	ExpireTimeNs int64 // 0 = none
+	DisplayQty   int64
+}
+
+func snapshotOrderFrom(o Order) snapshotOrder {
+	var expireNs int64
+	if !o.ExpireTime.IsZero() {
+		expireNs = o.ExpireTime.UnixNano()
+	}
+	return snapshotOrder{
+		ID:           o.ID,
+		SymbolID:     o.SymbolID,
+		AccountID:    o.AccountID,
+		Price:        int64(o.Price),
+		Quantity:     int64(o.Quantity),
+		Filled:       int64(o.Filled),
+		Side:         uint8(o.Side),
+		Type:         uint8(o.Type),
+		TIF:          uint8(o.TIF),
+		Status:       uint8(o.Status),
+		STPGroup:     o.STPGroup,
+		StopPrice:    int64(o.StopPrice),
+		TimestampNs:  o.Timestamp.UnixNano(),
+		ExpireTimeNs: expireNs,
+		DisplayQty:   int64(o.DisplayQty),
+	}
+}
+
+func (r snapshotOrder) order() Order {
+	var expire time.Time
+	if r.ExpireTimeNs != 0 {
+		expire = time.Unix(0, r.ExpireTimeNs)
+	}
+	return Order{
+		ID:         r.ID,
+		SymbolID:   r.SymbolID,
+		AccountID:  r.AccountID,
+		Price:      Price(r.Price),
+		Quantity:   Quantity(r.Quantity),
+		Filled:     Quantity(r.Filled),
+		Side:       Side(r.Side),
+		Type:       OrderType(r.Type),
+		TIF:        TimeInForce(r.TIF),
+		Status:     OrderStatus(r.Status),
+		STPGroup:   r.STPGroup,
+		StopPrice:  Price(r.StopPrice),
+		Timestamp:  time.Unix(0, r.TimestampNs),
+		ExpireTime: expire,
+		DisplayQty: Quantity(r.DisplayQty),
+	}
+}
+
+// validateSnapshot applies the checks the book makes when resting a
+// restored order, so a snapshot that cannot be loaded is rejected before
+// any of it is: every order has quantity left, IDs are unique and the
+// best bid is below the best ask.
+func validateSnapshot(orders []Order) error {
+	ids := make(map[uint64]struct{}, len(orders))
+	var bestBid, bestAsk Price
+	var haveBid, haveAsk bool
+	for _, o := range orders {
+		if o.Remaining() <= 0 {
+			return fmt.Errorf("%w: order %d has nothing left to fill", ErrInvalidSnapshot, o.ID)
+		}
+		if _, dup := ids[o.ID]; dup {
+			return fmt.Errorf("%w: order %d appears twice", ErrInvalidSnapshot, o.ID)
+		}
+		ids[o.ID] = struct{}{}
+		if o.IsBuy() {
+			if !haveBid || o.Price > bestBid {
+				bestBid, haveBid = o.Price, true
+			}
+		} else if !haveAsk || o.Price < bestAsk {
+			bestAsk, haveAsk = o.Price, true
+		}
+	}
+	if haveBid && haveAsk && bestBid >= bestAsk {
+		return fmt.Errorf("%w: bid %d crosses ask %d", ErrInvalidSnapshot, bestBid, bestAsk)
+	}
+	return nil
+}
+
+func writeSnapshot(w io.Writer, orders []Order) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(snapshotMagic)
+	binary.Write(bw, binary.BigEndian, SnapshotVersion)
+	binary.Write(bw, binary.BigEndian, uint64(len(orders)))
+	for _, o := range orders {
+		if err := binary.Write(bw, binary.BigEndian, snapshotOrderFrom(o)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func readSnapshot(r io.Reader) ([]Order, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(br, header); err != nil || !bytes.Equal(header[:4], snapshotMagic) {
+		return nil, ErrInvalidSnapshot
+	}
+	if binary.BigEndian.Uint32(header[4:]) != SnapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+
+	count := binary.BigEndian.Uint64(header[8:])
+	var orders []Order
+	for i := uint64(0); i < count; i++ {
+		var rec snapshotOrder
+		if err := binary.Read(br, binary.BigEndian, &rec); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		orders = append(orders, rec.order())
+	}
+	return orders, nil
+}
@@ -0,0 +1,110 @@
+package luxdex
+
+import "time"
+
+// TradeHistorySize is the number of recent trades kept per symbol for
+// GetTrades. Once a symbol's buffer is full each new trade evicts the oldest.
+const TradeHistorySize = 1024
+
+// tradeRing is a fixed-size circular buffer of a symbol's most recent trades.
+type tradeRing struct {
+	buf  []Trade
+	next int // index the next trade is written to
+	full bool
+}
+
+func (r *tradeRing) add(trade Trade) {
+	if r.buf == nil {
+		r.buf = make([]Trade, TradeHistorySize)
+	}
+	r.buf[r.next] = trade
+	r.next++
+	if r.next == len(r.buf) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// recent returns up to limit of the newest trades, oldest first.
+func (r *tradeRing) recent(limit int) []Trade {
+	n := r.next
+	if r.full {
+		n = len(r.buf)
+	}
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	out := make([]Trade, limit)
+	start := r.next - limit
+	if start < 0 {
+		start += len(r.buf)
+	}
+	copied := copy(out, r.buf[start:])
+	if copied < limit {
+		copy(out[copied:], r.buf[:limit-copied])
+	}
+	return out
+}
+
+// Candle is an OHLCV bar covering [OpenTime, OpenTime+interval).
+type Candle struct {
+	OpenTime time.Time
+	Open     Price
+	High     Price
+	Low      Price
+	Close    Price
+	Volume   Quantity
+}
+
+// buildCandles aggregates trades (oldest first) into the count intervals
+// ending with the one containing now. Interval boundaries are multiples of
+// interval since the Unix epoch, so they are UTC-aligned. An interval without
+// trades repeats the previous close with zero volume; intervals before the
+// first known trade are omitted.
+func buildCandles(trades []Trade, interval time.Duration, count int, now time.Time) []Candle {
+	if interval <= 0 || count <= 0 {
+		return nil
+	}
+	step := int64(interval)
+	last := now.UnixNano() - now.UnixNano()%step
+	first := last - int64(count-1)*step
+
+	var (
+		candles []Candle
+		prev    *Candle
+		i       int
+	)
+	// The last trade before the window seeds the first close
+	for ; i < len(trades) && trades[i].Timestamp.UnixNano() < first; i++ {
+		px := trades[i].Price
+		prev = &Candle{Open: px, High: px, Low: px, Close: px}
+	}
+	for open := first; open <= last; open += step {
+		var c *Candle
+		for ; i < len(trades) && trades[i].Timestamp.UnixNano() < open+step; i++ {
+			tr := trades[i]
+			if c == nil {
+				c = &Candle{Open: tr.Price, High: tr.Price, Low: tr.Price}
+			}
+			if tr.Price > c.High {
+				c.High = tr.Price
+			}
+			if tr.Price < c.Low {
+				c.Low = tr.Price
+			}
+			c.Close = tr.Price
+			c.Volume += tr.Quantity
+		}
+		if c == nil {
+			if prev == nil {
+				continue
+			}
+			px := prev.Close
+			c = &Candle{Open: px, High: px, Low: px, Close: px}
+		}
+		c.OpenTime = time.Unix(0, open).UTC()
+		candles = append(candles, *c)
+		prev = c
+	}
+	return candles
+}
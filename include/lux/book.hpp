@@ -8,8 +8,11 @@
 #include <functional>
 
 #include "types.hpp"
+#include "events.hpp"
 #include "orderbook.hpp"
 #include "engine.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
 
 // Hash specialization for CLOID (must be before lux namespace)
 namespace std {
@@ -42,7 +45,8 @@ struct BookMarketConfig {
     I128 max_order_size_x18;
     bool post_only_mode;         // Only allow maker orders
     bool reduce_only_mode;       // Only allow reducing positions
-    uint8_t status;              // 0=inactive, 1=active, 2=cancel-only
+    uint8_t status;              // 0=halted, 1=active, 2=cancel-only, 3=post-only, 4=limit-only
+    uint8_t stp_mode = 0;        // STPMode for orders sharing an STP group
 };
 
 // =============================================================================
@@ -110,10 +114,18 @@ public:
     // =========================================================================
 
     int32_t create_market(const BookMarketConfig& config);
+    // symbol_id and currencies are immutable (INVALID_CURRENCY); tick and
+    // lot size only change on an empty book (MARKET_NOT_EMPTY)
     int32_t update_market_config(const BookMarketConfig& config);
     std::optional<BookMarketConfig> get_market_config(uint32_t market_id) const;
     uint8_t get_market_status(uint32_t market_id) const;
+    int32_t set_market_status(uint32_t market_id, uint8_t status);
     bool market_exists(uint32_t market_id) const;
+    std::vector<uint32_t> list_markets() const;  // ascending
+
+    // Remove a market with no resting or held conditional orders;
+    // MARKET_NOT_EMPTY otherwise
+    int32_t remove_market(uint32_t market_id);
 
     // =========================================================================
     // Execute Interface (Hyperliquid-style batch execution)
@@ -133,6 +145,10 @@ public:
     // Place a new order
     LXPlaceResult place_order(const LXAccount& sender, const LXOrder& order);
 
+    // While draining, every placement is rejected; cancels keep working
+    void set_draining(bool draining) { draining_.store(draining); }
+    bool is_draining() const { return draining_.load(); }
+
     // Cancel order by OID
     int32_t cancel_order(const LXAccount& sender, uint32_t market_id, uint64_t oid);
 
@@ -143,10 +159,40 @@ public:
     // Cancel all orders for a market
     int32_t cancel_all(const LXAccount& sender, uint32_t market_id);
 
+    // Replace the account's orders in a market: everything live is cancelled
+    // before the first new order is placed, so stale and fresh quotes never
+    // rest together. results follow orders; one naming another market is
+    // REJECTED. Returns MARKET_NOT_FOUND, having touched nothing, for an
+    // unknown market.
+    int32_t reset_quotes(const LXAccount& sender, uint32_t market_id,
+                         const std::vector<LXOrder>& orders,
+                         std::vector<LXPlaceResult>& results);
+
     // Amend order price/size
     LXPlaceResult amend_order(const LXAccount& sender, uint32_t market_id,
                                uint64_t oid, I128 new_size_x18, I128 new_price_x18);
 
+    // Cancel-on-disconnect: once armed, every open order of the account, in
+    // every market, is cancelled if heartbeat() is not called within
+    // timeout_ms. Re-arming restarts the countdown with the new timeout;
+    // timeout_ms 0 disarms. A tripped switch disarms itself, after which
+    // heartbeat() returns NOT_ARMED.
+    int32_t arm_cancel_on_disconnect(const LXAccount& account, uint32_t timeout_ms);
+    int32_t heartbeat(const LXAccount& account);
+
+    // Trip every switch whose deadline is at or before now_ms (unix ms);
+    // returns the number of orders cancelled. place_order runs this first,
+    // so a lapsed account's orders never trade.
+    size_t check_heartbeats(uint64_t now_ms);
+
+    // Stop and take-profit orders are held off the book until a reference
+    // price reaches their trigger. Stops fire when it rises to a buy trigger
+    // or falls to a sell trigger; take-profits the reverse. Fired orders are
+    // placed as market or limit orders by kind; one the order check rejects
+    // is dropped.
+    // Returns: OIDs activated, in placement order
+    std::vector<uint64_t> evaluate_triggers(uint32_t market_id, I128 ref_px_x18);
+
     // =========================================================================
     // Order Queries
     // =========================================================================
@@ -164,6 +210,18 @@ public:
     // Get all open orders for an account across all markets
     std::vector<BookOrderState> get_all_orders(const LXAccount& account) const;
 
+    // Account that placed orders under an engine account ID (LXAccount::hash);
+    // nullopt if none has
+    std::optional<LXAccount> get_account(uint64_t account_id) const;
+
+    // Get the account's live (NEW or OPEN) orders in a market, or in every
+    // market when market_id is 0, sorted by OID
+    std::vector<BookOrderState> get_open_orders(const LXAccount& account, uint32_t market_id) const;
+
+    // Whether the account has any live order, including held triggers, in
+    // any market
+    bool has_open_orders(const LXAccount& account) const;
+
     // =========================================================================
     // Market Data
     // =========================================================================
@@ -171,15 +229,40 @@ public:
     // Get L1 (best bid/ask)
     LXL1 get_l1(uint32_t market_id) const;
 
+    // Called with the market's new L1 whenever an order operation changes
+    // it, on the thread that made the change. MARKET_NOT_FOUND for an
+    // unknown market; id identifies the subscription to unsubscribe_l1.
+    using L1Callback = std::function<void(uint32_t market_id, const LXL1& l1)>;
+    int32_t subscribe_l1(uint32_t market_id, L1Callback callback, uint64_t& id);
+    void unsubscribe_l1(uint64_t id);
+
     // Get depth (multiple levels)
     MarketDepth get_depth(uint32_t market_id, size_t levels = 10) const;
 
+    // Expected fill of a taker order against current depth, without placing
+    // it; a zero limit_px_x18 walks the book without a price bound
+    struct FillQuote {
+        I128 avg_px_x18;
+        I128 filled_x18;
+    };
+    std::optional<FillQuote> quote_fill(uint32_t market_id, bool is_buy, I128 size_x18,
+                                        I128 limit_px_x18 = 0) const;
+
     // Get last trade
     std::optional<Trade> get_last_trade(uint32_t market_id) const;
 
     // Get recent trades
     std::vector<Trade> get_recent_trades(uint32_t market_id, size_t count = 100) const;
 
+    // Trades retained per market for get_recent_trades and bust_trade
+    static constexpr size_t TRADE_HISTORY_SIZE = 1000;
+
+    // Remove a retained trade from the market's history and roll back its
+    // trade counters and both orders' filled and remaining sizes. The busted
+    // size does not return to the book, so an order the trade filled becomes
+    // CANCELLED. Returns the trade, or nullopt if it is not retained.
+    std::optional<Trade> bust_trade(uint32_t market_id, uint64_t trade_id);
+
     // =========================================================================
     // HFT Interface (Packed ABI for low latency)
     // =========================================================================
@@ -200,6 +283,22 @@ public:
     using SettlementCallback = std::function<int32_t(const std::vector<Trade>&)>;
     void set_settlement_callback(SettlementCallback callback);
 
+    // Set the pre-trade check run on each order before it reaches the book,
+    // and on conditional orders when they fire. A non-OK result rejects the
+    // order with that reason.
+    using OrderCheck = std::function<int32_t(const LXAccount&, const LXOrder&)>;
+    void set_order_check(OrderCheck check);
+
+    // Receives ORDER_REJECTED and MARKET_HALTED events
+    void set_event_callback(SystemEventCallback callback) { events_.set(std::move(callback)); }
+
+    // Receives a record for each market change, placement, cancel, amend and
+    // trigger evaluation, including cancels made on an account's behalf.
+    // Sinks sharing order number their records as one journal.
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
     // =========================================================================
     // Statistics
     // =========================================================================
@@ -214,6 +313,16 @@ public:
     };
     Stats get_stats() const;
 
+    // Per-market counters; open_orders is the number of resting orders
+    struct MarketStats {
+        uint64_t orders_placed;
+        uint64_t orders_cancelled;
+        uint64_t trades;
+        I128 volume_x18;
+        uint64_t open_orders;
+    };
+    std::optional<MarketStats> get_market_stats(uint32_t market_id) const;
+
     // =========================================================================
     // Direct Engine Access (for advanced use)
     // =========================================================================
@@ -221,6 +330,17 @@ public:
     Engine* get_engine() { return &engine_; }
     const Engine* get_engine() const { return &engine_; }
 
+    // =========================================================================
+    // Snapshot
+    // =========================================================================
+
+    // Markets, resting orders in queue order, order state, held triggers,
+    // recent trades and cancel-on-disconnect switches. L1 subscriptions and
+    // callbacks are not included, and engine latency samples start over.
+    void snapshot(SnapshotWriter& out) const;
+    // false if the data is malformed or a market already exists
+    bool restore(SnapshotReader& in);
+
 private:
     // Core matching engine
     Engine engine_;
@@ -239,18 +359,67 @@ private:
     std::unordered_map<uint64_t, AccountOrders> account_orders_;  // account_hash -> orders
     mutable std::shared_mutex orders_mutex_;
 
+    // Accounts by hash, so fills can be settled to their owners
+    std::unordered_map<uint64_t, LXAccount> accounts_;
+    mutable std::shared_mutex accounts_mutex_;
+
     // Last trade per market
     std::unordered_map<uint32_t, Trade> last_trades_;
     std::unordered_map<uint32_t, std::vector<Trade>> recent_trades_;
     mutable std::shared_mutex trades_mutex_;
 
+    // Conditional orders awaiting their trigger, per market
+    struct PendingTrigger {
+        LXAccount sender;
+        LXOrder order;
+        Order internal;
+    };
+    std::unordered_map<uint32_t, std::vector<PendingTrigger>> pending_triggers_;
+    mutable std::shared_mutex triggers_mutex_;
+
     // Settlement callback
     SettlementCallback settlement_callback_;
+    OrderCheck order_check_;
+    SystemEventSink events_;
+    JournalSink journal_;
+
+    // L1 subscriptions by id, and the L1 last published per market
+    struct L1Subscription {
+        uint32_t market_id;
+        L1Callback callback;
+    };
+    std::unordered_map<uint64_t, L1Subscription> l1_subscriptions_;
+    std::unordered_map<uint32_t, LXL1> published_l1_;
+    uint64_t next_l1_subscription_id_{1};
+    std::mutex l1_mutex_;
+    void publish_l1(uint32_t market_id);
+
+    std::atomic<bool> draining_{false};
+
+    // Cancel-on-disconnect switches by account hash
+    struct DeadManSwitch {
+        LXAccount account;
+        uint32_t timeout_ms;
+        uint64_t deadline_ms;
+    };
+    std::unordered_map<uint64_t, DeadManSwitch> dead_man_switches_;
+    std::atomic<uint64_t> next_deadline_ms_{UINT64_MAX};  // Earliest deadline
+    mutable std::mutex switches_mutex_;
+    void update_next_deadline();  // switches_mutex_ must be held
 
     // Statistics
     std::atomic<uint64_t> total_orders_placed_{0};
     std::atomic<uint64_t> total_orders_filled_{0};
 
+    struct MarketCounters {
+        uint64_t orders_placed = 0;
+        uint64_t orders_cancelled = 0;
+        uint64_t trades = 0;
+        I128 volume_x18 = 0;
+    };
+    std::unordered_map<uint32_t, MarketCounters> market_counters_;
+    mutable std::shared_mutex stats_mutex_;
+
     // Internal trade listener
     class BookTradeListener : public TradeListener {
     public:
@@ -273,6 +442,22 @@ private:
     void update_order_state(const LXAccount& account, uint64_t oid,
                             const std::function<void(BookOrderState&)>& updater);
     void record_trade(uint32_t market_id, const Trade& trade);
+    int32_t try_create_market(const BookMarketConfig& config);
+    LXPlaceResult try_place_order(const LXAccount& sender, const LXOrder& order);
+    int32_t try_cancel_order(const LXAccount& sender, uint32_t market_id, uint64_t oid);
+    int32_t try_update_market_config(const BookMarketConfig& config);
+    int32_t try_set_market_status(uint32_t market_id, uint8_t status);
+    int32_t try_remove_market(uint32_t market_id);
+    LXPlaceResult try_amend_order(const LXAccount& sender, uint32_t market_id,
+                                  uint64_t oid, I128 new_size_x18, I128 new_price_x18);
+    std::vector<uint64_t> try_evaluate_triggers(uint32_t market_id, I128 ref_px_x18);
+    LXPlaceResult submit_order(const LXAccount& sender, const LXOrder& order,
+                               const Order& internal_order, bool count_placed);
+    LXPlaceResult hold_trigger(const LXAccount& sender, const LXOrder& order,
+                               const Order& internal_order);
+    bool cancel_trigger(uint32_t market_id, uint64_t oid);
+    static bool is_conditional(OrderKind kind);
+    static bool trigger_fires(const LXOrder& order, I128 ref_px_x18);
 
     // Action handlers
     ExecuteResult handle_place(const LXAccount& sender, const std::vector<uint8_t>& data);
@@ -11,6 +11,7 @@
 #include <atomic>
 #include <functional>
 #include <future>
+#include <mutex>
 
 #include "orderbook.hpp"
 #include "order.hpp"
@@ -49,12 +50,18 @@ struct BatchResult {
     std::vector<Trade> all_trades;
 };
 
+// Number of most recent order-to-trade latency samples kept by the engine
+constexpr size_t LATENCY_WINDOW = 4096;
+
 // Engine configuration
 struct EngineConfig {
     size_t worker_threads = 1;
     size_t max_batch_size = 1000;
     bool enable_self_trade_prevention = true;
     bool async_mode = false;
+    MatchingAlgorithm matching = MatchingAlgorithm::PriceTime;
+    ProRataTieBreak pro_rata_tie_break = ProRataTieBreak::LargestRemainder;
+    STPMode stp_mode = STPMode::CancelMaker;
 };
 
 // Trading engine managing multiple orderbooks
@@ -77,6 +84,10 @@ public:
     bool remove_symbol(uint64_t symbol_id);
     bool has_symbol(uint64_t symbol_id) const;
     std::vector<uint64_t> symbols() const;
+    size_t symbol_count() const;
+    // Copy up to max symbol IDs into out without allocating; returns the
+    // total number of symbols
+    size_t copy_symbols(uint64_t* out, size_t max) const;
 
     // Order operations
     OrderResult place_order(Order order);
@@ -84,6 +95,10 @@ public:
     OrderResult modify_order(uint64_t symbol_id, uint64_t order_id,
                             Price new_price, Quantity new_quantity);
 
+    // Expire GTD/DAY orders across all books (see OrderBook::expire_orders).
+    // Expired orders count as cancellations and are reported to the listener.
+    std::vector<Order> expire_orders(Timestamp now);
+
     // Batch operations
     BatchResult process_batch(const std::vector<BatchOrder>& batch);
 
@@ -92,6 +107,8 @@ public:
     MarketDepth get_depth(uint64_t symbol_id, size_t levels = 10) const;
     std::optional<Price> best_bid(uint64_t symbol_id) const;
     std::optional<Price> best_ask(uint64_t symbol_id) const;
+    std::optional<MarketQuote> quote_market_order(uint64_t symbol_id, Side side,
+                                                  Quantity quantity, Price limit = 0) const;
 
     // Statistics
     struct Stats {
@@ -102,6 +119,24 @@ public:
     };
     Stats get_stats() const;
 
+    // Order-to-trade latency in nanoseconds: the time place_order spends
+    // matching an order that produced at least one trade. Percentiles
+    // (nearest rank) cover the last LATENCY_WINDOW such orders; samples is
+    // how many of those slots are filled.
+    struct LatencyStats {
+        uint64_t samples;
+        uint64_t p50_ns;
+        uint64_t p90_ns;
+        uint64_t p99_ns;
+        uint64_t max_ns;
+    };
+    LatencyStats get_latency_stats() const;
+    void reset_latency_stats();
+
+    // Async orders queued or being processed by a worker; always zero
+    // outside async_mode
+    size_t pending_orders() const;
+
     // Trade listener registration
     void set_trade_listener(TradeListener* listener);
 
@@ -123,6 +158,11 @@ private:
     std::atomic<uint64_t> total_trades_{0};
     std::atomic<uint64_t> total_volume_{0};
 
+    // Latency ring buffer; latency_recorded_ counts every sample ever taken
+    std::vector<uint64_t> latency_samples_ = std::vector<uint64_t>(LATENCY_WINDOW);
+    uint64_t latency_recorded_{0};
+    mutable std::mutex latency_mutex_;
+
     // Trade listener
     TradeListener* trade_listener_{nullptr};
 
@@ -132,7 +172,8 @@ private:
         std::promise<OrderResult> promise;
     };
     std::queue<AsyncOrder> order_queue_;
-    std::mutex queue_mutex_;
+    mutable std::mutex queue_mutex_;
+    size_t in_flight_{0};  // Popped but not yet processed; guarded by queue_mutex_
     std::condition_variable queue_cv_;
     std::vector<std::thread> worker_threads_;
 
@@ -156,6 +197,14 @@ public:
         counter_.store(start, std::memory_order_relaxed);
     }
 
+    // Never hand out an ID below start, e.g. one held by restored orders
+    void advance_to(uint64_t start) {
+        uint64_t current = counter_.load(std::memory_order_relaxed);
+        while (current < start &&
+               !counter_.compare_exchange_weak(current, start, std::memory_order_relaxed)) {
+        }
+    }
+
 private:
     OrderIdGenerator() = default;
     std::atomic<uint64_t> counter_{1};
@@ -0,0 +1,86 @@
+#ifndef LUX_EVENTS_HPP
+#define LUX_EVENTS_HPP
+
+#include <chrono>
+#include <functional>
+#include <mutex>
+
+#include "types.hpp"
+
+namespace lux {
+
+// =============================================================================
+// System Events
+// =============================================================================
+
+enum class SystemEventKind : uint8_t {
+    ORDER_REJECTED = 0,          // An order failed validation or risk checks
+    MARKET_HALTED = 1,           // A market entered a non-trading status
+    LIQUIDATION = 2,             // An account was liquidated
+    INSURANCE_FUND_DRAW = 3,     // The insurance fund covered a deficit
+    ORACLE_PRICE_STALE = 4,      // An asset was used without a fresh price
+    CIRCUIT_BREAKER_TRIPPED = 5, // A price moved beyond its circuit breaker
+    TRADE_BUSTED = 6             // An operator busted a trade
+};
+
+enum class Severity : uint8_t {
+    INFO = 0,
+    WARNING = 1,
+    CRITICAL = 2
+};
+
+// Operational event from any component; fields that do not apply to the
+// kind are zero
+struct LXSystemEvent {
+    SystemEventKind kind;
+    Severity severity;
+    uint32_t market_id;
+    uint64_t asset_id;
+    LXAccount account;
+    int32_t code;                // Underlying error, if the event reports one
+    uint64_t timestamp;          // Unix ns
+};
+
+using SystemEventCallback = std::function<void(const LXSystemEvent&)>;
+
+// Holds a component's event callback. emit runs the callback synchronously
+// on the calling thread, so components emit after releasing their locks.
+class SystemEventSink {
+public:
+    void set(SystemEventCallback callback) {
+        std::lock_guard lock(mutex_);
+        callback_ = std::move(callback);
+    }
+
+    void emit(SystemEventKind kind, Severity severity, uint32_t market_id = 0,
+              uint64_t asset_id = 0, const LXAccount& account = {}, int32_t code = 0) const {
+        SystemEventCallback callback;
+        {
+            std::lock_guard lock(mutex_);
+            callback = callback_;
+        }
+        if (!callback) return;
+
+        LXSystemEvent event{};
+        event.kind = kind;
+        event.severity = severity;
+        event.market_id = market_id;
+        event.asset_id = asset_id;
+        event.account = account;
+        event.code = code;
+        event.timestamp = static_cast<uint64_t>(
+            std::chrono::duration_cast<std::chrono::nanoseconds>(
+                std::chrono::system_clock::now().time_since_epoch()
+            ).count()
+        );
+        callback(event);
+    }
+
+private:
+    SystemEventCallback callback_;
+    mutable std::mutex mutex_;
+};
+
+} // namespace lux
+
+#endif // LUX_EVENTS_HPP
@@ -10,6 +10,8 @@
 
 #include "types.hpp"
 #include "oracle.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
 
 namespace lux {
 
@@ -36,6 +38,7 @@ struct MarkPriceConfig {
     I128 min_premium_x18;            // Minimum premium floor
     bool use_mid_price;              // Use mid price for premium calc
     bool cap_to_oracle;              // Cap mark to oracle bounds
+    uint32_t premium_clamp_bps;      // Premium cap relative to index; replaces min/max when non-zero
 };
 
 // =============================================================================
@@ -90,11 +93,28 @@ public:
     void set_mark_price_config(uint32_t market_id, const MarkPriceConfig& config);
     std::optional<MarkPriceConfig> get_mark_price_config(uint32_t market_id) const;
 
+    // Tune mark price smoothing on a registered market. A zero window makes
+    // the mark follow the latest premium sample.
+    int32_t set_mark_smoothing(uint32_t market_id, uint64_t ewma_window, uint32_t premium_clamp_bps);
+
     void set_funding_params(uint32_t market_id, const FundingParams& params);
+
+    // Set a registered market's funding interval (seconds) and rate cap (bps
+    // per interval), keeping its other funding parameters
+    int32_t set_funding_config(uint32_t market_id, uint64_t interval, uint32_t clamp_bps);
     std::optional<FundingParams> get_funding_params(uint32_t market_id) const;
 
     void set_trigger_rules(uint32_t market_id, const std::vector<TriggerRule>& rules);
 
+    // Receives ORACLE_PRICE_STALE events
+    void set_event_callback(SystemEventCallback callback) { events_.set(std::move(callback)); }
+
+    // Receives a record for each register_market, mark and funding
+    // configuration change, update_last_price and update_bbo call
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
     // =========================================================================
     // Index Price
     // =========================================================================
@@ -135,13 +155,17 @@ public:
 
     std::optional<I128> get_price(uint32_t market_id, PriceType type) const;
 
-    // Get all prices for a market
+    // Get all prices for a market in one call. Prices not yet available are
+    // zero; nullopt means the market is unknown.
     struct AllPrices {
         I128 index_x18;
         I128 mark_x18;
         I128 last_x18;
         I128 mid_x18;
         uint64_t timestamp;
+        I128 premium_x18;            // Mark - index
+        I128 funding_rate_x18;
+        uint64_t next_funding_time;
     };
     std::optional<AllPrices> get_all_prices(uint32_t market_id) const;
 
@@ -187,6 +211,9 @@ public:
     // Calculate and update funding rate
     void calculate_funding_rate(uint32_t market_id);
 
+    // Recent funding rate samples, newest first, at most limit entries
+    std::vector<std::pair<uint64_t, I128>> funding_history(uint32_t market_id, size_t limit) const;
+
     // =========================================================================
     // Trigger Price (for SL/TP, Liquidation)
     // =========================================================================
@@ -221,8 +248,18 @@ public:
     };
     Stats get_stats() const;
 
+    // =========================================================================
+    // Snapshot
+    // =========================================================================
+
+    // Market registrations, configs and price and funding state
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
 private:
     LXOracle& oracle_;
+    SystemEventSink events_;
+    JournalSink journal_;
 
     // Market -> asset mapping
     std::unordered_map<uint32_t, uint64_t> market_assets_;
@@ -247,6 +284,9 @@ private:
 
         // Premium history for EWMA
         std::vector<std::pair<uint64_t, I128>> premium_history;
+
+        // Calculated funding rates, oldest first
+        std::vector<std::pair<uint64_t, I128>> funding_history;
     };
     std::unordered_map<uint32_t, MarketPriceState> price_states_;
     mutable std::shared_mutex price_mutex_;
@@ -259,6 +299,18 @@ private:
     MarketPriceState* get_price_state(uint32_t market_id);
     const MarketPriceState* get_price_state(uint32_t market_id) const;
 
+    // Record mark - index from the mid (from_trade=false) or the last trade,
+    // whichever the market's config uses for premium
+    void sample_premium(uint32_t market_id, bool from_trade);
+
+    // Unjournaled bodies of the recorded operations
+    int32_t try_register_market(uint32_t market_id, uint64_t asset_id);
+    void try_set_mark_price_config(uint32_t market_id, const MarkPriceConfig& config);
+    int32_t try_set_mark_smoothing(uint32_t market_id, uint64_t ewma_window,
+                                   uint32_t premium_clamp_bps);
+    void try_set_funding_params(uint32_t market_id, const FundingParams& params);
+    int32_t try_set_funding_config(uint32_t market_id, uint64_t interval, uint32_t clamp_bps);
+
     // EWMA calculation
     I128 calculate_ewma(const std::vector<std::pair<uint64_t, I128>>& history,
                         uint64_t window_seconds, uint64_t current_time) const;
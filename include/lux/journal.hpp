@@ -0,0 +1,135 @@
+#ifndef LUX_JOURNAL_HPP
+#define LUX_JOURNAL_HPP
+
+#include <atomic>
+#include <functional>
+#include <mutex>
+#include <vector>
+
+#include "snapshot.hpp"
+
+namespace lux {
+
+// =============================================================================
+// Operation Journal
+// =============================================================================
+
+// Operations written to the journal. A record holds the operation, its
+// arguments and its result, in the snapshot encoding.
+enum class JournalOp : uint8_t {
+    POOL_INITIALIZE = 0,
+    POOL_SWAP = 1,
+    POOL_MODIFY_LIQUIDITY = 2,
+    BOOK_CREATE_MARKET = 3,
+    BOOK_PLACE_ORDER = 4,
+    BOOK_CANCEL_ORDER = 5,
+    VAULT_CREATE_MARKET = 6,
+    VAULT_DEPOSIT = 7,
+    VAULT_WITHDRAW = 8,
+    ORACLE_REGISTER_ASSET = 9,
+    ORACLE_UPDATE_PRICE = 10,
+    FEED_REGISTER_MARKET = 11,
+    FEED_UPDATE_LAST_PRICE = 12,
+    BOOK_AMEND_ORDER = 13,
+    BOOK_UPDATE_MARKET = 14,
+    BOOK_SET_MARKET_STATUS = 15,
+    BOOK_REMOVE_MARKET = 16,
+    BOOK_EVALUATE_TRIGGERS = 17,
+    BUST_TRADE = 18,
+    VAULT_UPDATE_MARKET = 19,
+    VAULT_TRANSFER = 20,
+    VAULT_SET_CROSS_OWNER_TRANSFERS = 21,
+    VAULT_TRANSFER_POSITION = 22,
+    VAULT_SET_MARGIN_MODE = 23,
+    VAULT_SET_LEVERAGE = 24,
+    VAULT_SET_FEE_TIER = 25,
+    VAULT_SET_STAKED_BALANCE = 26,
+    VAULT_SET_STAKING_FEE_TIERS = 27,
+    VAULT_LIQUIDATE = 28,
+    VAULT_ACCRUE_FUNDING = 29,
+    VAULT_SETTLE_FUNDING = 30,
+    VAULT_SET_FUNDING_RATE = 31,
+    LEND_SUPPLY = 32,
+    LEND_WITHDRAW = 33,
+    LEND_BORROW = 34,
+    LEND_REPAY = 35,
+    LIQUID_OPEN = 36,
+    LIQUID_APPLY_YIELD = 37,
+    POOL_DONATE = 38,
+    POOL_COLLECT_FEES = 39,
+    POOL_SET_PROTOCOL_FEE = 40,
+    POOL_SET_DYNAMIC_FEE = 41,
+    POOL_COLLECT_PROTOCOL = 42,
+    ORACLE_UPDATE_CONFIG = 43,
+    ORACLE_SET_CIRCUIT_BREAKER = 44,
+    ORACLE_SET_MAX_STALENESS = 45,
+    ORACLE_SET_AGGREGATION_METHOD = 46,
+    ORACLE_REMOVE_SOURCE = 47,
+    ORACLE_RESTORE_SOURCE = 48,
+    FEED_SET_MARK_PRICE_CONFIG = 49,
+    FEED_SET_MARK_SMOOTHING = 50,
+    FEED_SET_FUNDING_PARAMS = 51,
+    FEED_SET_FUNDING_CONFIG = 52,
+    FEED_UPDATE_BBO = 53
+};
+
+using JournalCallback = std::function<void(const std::vector<uint8_t>& record)>;
+
+// Holds a component's journal callback. record runs it synchronously on the
+// calling thread; with no callback set it returns before encoding anything.
+//
+// A journaled operation holds order() from before it changes any state
+// until its record is written, so records are numbered in the order the
+// operations took effect. Sinks writing to one journal share an order lock;
+// it is recursive because an operation may run others that record first,
+// such as the cancels of a lapsed cancel-on-disconnect inside place_order.
+class JournalSink {
+public:
+    using OrderLock = std::unique_lock<std::recursive_mutex>;
+
+    // Without an order lock the sink orders its own records
+    void set(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        std::lock_guard lock(mutex_);
+        enabled_.store(static_cast<bool>(callback), std::memory_order_relaxed);
+        callback_ = std::move(callback);
+        order_ = order ? order : &own_order_;
+    }
+
+    // Empty while journaling is off
+    OrderLock order() const {
+        if (!enabled_.load(std::memory_order_relaxed)) return {};
+        std::recursive_mutex* order = nullptr;
+        {
+            std::lock_guard lock(mutex_);
+            order = order_;
+        }
+        return OrderLock(*order);
+    }
+
+    template<typename... Fields>
+    void record(JournalOp op, const Fields&... fields) const {
+        if (!enabled_.load(std::memory_order_relaxed)) return;
+        JournalCallback callback;
+        {
+            std::lock_guard lock(mutex_);
+            callback = callback_;
+        }
+        if (!callback) return;
+
+        SnapshotWriter out;
+        out.put(op);
+        (out.put(fields), ...);
+        callback(out.take());
+    }
+
+private:
+    JournalCallback callback_;
+    std::atomic<bool> enabled_{false};
+    mutable std::mutex mutex_;
+    mutable std::recursive_mutex own_order_;
+    std::recursive_mutex* order_{&own_order_};
+};
+
+} // namespace lux
+
+#endif // LUX_JOURNAL_HPP
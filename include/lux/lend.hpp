@@ -0,0 +1,108 @@
+#ifndef LUX_LEND_HPP
+#define LUX_LEND_HPP
+
+#include <unordered_map>
+#include <shared_mutex>
+
+#include "types.hpp"
+#include "vault.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
+
+namespace lux {
+
+// =============================================================================
+// Account Liquidity
+// =============================================================================
+
+// Values are in the vault's quote currency, the only token LXLend accepts
+struct LXLendLiquidity {
+    I128 collateral_value_x18;   // Supplied value
+    I128 borrow_value_x18;       // Outstanding debt
+};
+
+// =============================================================================
+// LXLend - Lending Pool (LP-9050)
+// =============================================================================
+
+// Supplied tokens leave the account's vault balance and back its borrows;
+// borrowed tokens are credited to the vault balance. An account may borrow
+// up to COLLATERAL_FACTOR_BPS of its supplied value, out of what other
+// suppliers have left in the pool.
+//
+// There is no price feed per token, so the pool only takes the native
+// currency LXVault settles fees, funding and PnL in, and values it 1:1.
+// Operations on any other token fail with INVALID_CURRENCY.
+class LXLend {
+public:
+    static constexpr uint32_t COLLATERAL_FACTOR_BPS = 7500;  // 75%
+
+    explicit LXLend(LXVault& vault);
+    ~LXLend() = default;
+
+    // Non-copyable
+    LXLend(const LXLend&) = delete;
+    LXLend& operator=(const LXLend&) = delete;
+
+    // Move tokens from the vault balance into the pool
+    int32_t supply(const LXAccount& account, const Currency& token, I128 amount_x18);
+
+    // Return supplied tokens to the vault balance; INSUFFICIENT_BALANCE above
+    // what was supplied or the pool holds, INSUFFICIENT_MARGIN if the
+    // account's borrows would no longer be covered
+    int32_t withdraw(const LXAccount& account, const Currency& token, I128 amount_x18);
+
+    // INSUFFICIENT_LIQUIDITY if the pool cannot cover the amount,
+    // INSUFFICIENT_MARGIN if the account's collateral cannot
+    int32_t borrow(const LXAccount& account, const Currency& token, I128 amount_x18);
+
+    // Pay debt from the vault balance; amounts above the debt repay it in
+    // full. POSITION_NOT_FOUND if the account owes nothing in the token.
+    int32_t repay(const LXAccount& account, const Currency& token, I128 amount_x18);
+
+    LXLendLiquidity get_account_liquidity(const LXAccount& account) const;
+
+    // Per-token balances
+    I128 get_supplied(const LXAccount& account, const Currency& token) const;
+    I128 get_borrowed(const LXAccount& account, const Currency& token) const;
+
+    // Receives a record for each supply, withdraw, borrow and repay call
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
+    // Supplies, borrows and reserves
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
+private:
+    struct AccountState {
+        std::unordered_map<uint64_t, I128> supplied;  // currency_hash -> amount_x18
+        std::unordered_map<uint64_t, I128> borrowed;
+    };
+
+    struct Reserve {
+        I128 total_supplied_x18 = 0;
+        I128 total_borrowed_x18 = 0;
+    };
+
+    LXVault& vault_;
+
+    std::unordered_map<uint64_t, AccountState> accounts_;  // account hash -> state
+    std::unordered_map<uint64_t, Reserve> reserves_;       // currency_hash -> reserve
+    mutable std::shared_mutex mutex_;
+    JournalSink journal_;
+
+    // Unjournaled bodies of the recorded operations
+    int32_t try_supply(const LXAccount& account, const Currency& token, I128 amount_x18);
+    int32_t try_withdraw(const LXAccount& account, const Currency& token, I128 amount_x18);
+    int32_t try_borrow(const LXAccount& account, const Currency& token, I128 amount_x18);
+    int32_t try_repay(const LXAccount& account, const Currency& token, I128 amount_x18);
+
+    static LXLendLiquidity liquidity_of(const AccountState& state);
+    static I128 borrow_limit(I128 collateral_value_x18);
+};
+
+} // namespace lux
+
+#endif // LUX_LEND_HPP
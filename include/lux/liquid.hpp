@@ -0,0 +1,87 @@
+#ifndef LUX_LIQUID_HPP
+#define LUX_LIQUID_HPP
+
+#include <optional>
+#include <unordered_map>
+#include <shared_mutex>
+
+#include "types.hpp"
+#include "vault.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
+
+namespace lux {
+
+// =============================================================================
+// Self-Repaying Loan
+// =============================================================================
+
+struct LXLiquidLoan {
+    uint64_t id;
+    LXAccount owner;
+    Currency collateral;
+    I128 collateral_x18;         // Collateral locked
+    I128 principal_x18;          // Outstanding debt
+    I128 yield_applied_x18;      // Collateral yield applied to the debt so far
+    I128 health_x18;             // Collateral value / debt; zero once repaid
+    uint64_t opened_at;          // Unix ms
+};
+
+// =============================================================================
+// LXLiquid - Self-Repaying Loans (LP-9060)
+// =============================================================================
+
+// Opening a loan locks collateral out of the account's vault balance and
+// credits MAX_LTV_BPS of it, in the same token, as principal. Yield the
+// collateral earns is applied to the principal; once it is paid off the
+// collateral and any surplus yield return to the vault balance.
+class LXLiquid {
+public:
+    static constexpr uint32_t MAX_LTV_BPS = 5000;  // 50%
+
+    explicit LXLiquid(LXVault& vault);
+    ~LXLiquid() = default;
+
+    // Non-copyable
+    LXLiquid(const LXLiquid&) = delete;
+    LXLiquid& operator=(const LXLiquid&) = delete;
+
+    // Returns: OK, INVALID_PRICE for a non-positive amount, or the vault's
+    // INSUFFICIENT_BALANCE
+    int32_t open(const LXAccount& account, const Currency& collateral, I128 amount_x18,
+                 uint64_t& loan_id);
+
+    // Apply yield earned by a loan's collateral to its principal
+    // Returns: OK, or POSITION_NOT_FOUND for an unknown or repaid loan
+    int32_t apply_yield(uint64_t loan_id, I128 yield_x18);
+
+    std::optional<LXLiquidLoan> get_loan(uint64_t loan_id) const;
+
+    // Receives a record for each open and apply_yield call
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
+    // Open and repaid loans
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
+private:
+    LXVault& vault_;
+
+    std::unordered_map<uint64_t, LXLiquidLoan> loans_;  // loan_id -> loan
+    uint64_t next_loan_id_{1};
+    mutable std::shared_mutex mutex_;
+    JournalSink journal_;
+
+    // Unjournaled bodies of the recorded operations
+    int32_t try_open(const LXAccount& account, const Currency& collateral, I128 amount_x18,
+                     uint64_t& loan_id);
+    int32_t try_apply_yield(uint64_t loan_id, I128 yield_x18);
+
+    static I128 health_of(const LXLiquidLoan& loan);
+};
+
+} // namespace lux
+
+#endif // LUX_LIQUID_HPP
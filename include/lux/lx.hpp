@@ -18,6 +18,9 @@
 //
 // =============================================================================
 
+#include <deque>
+#include <mutex>
+
 #include "types.hpp"
 #include "order.hpp"
 #include "trade.hpp"
@@ -28,9 +31,29 @@
 #include "vault.hpp"
 #include "oracle.hpp"
 #include "feed.hpp"
+#include "lend.hpp"
+#include "liquid.hpp"
 
 namespace lux {
 
+// =============================================================================
+// Settled Trade
+// =============================================================================
+
+// A book trade after settlement, with its accounts resolved
+struct LXTrade {
+    uint64_t trade_id;
+    uint32_t market_id;
+    uint64_t maker_oid;
+    uint64_t taker_oid;
+    LXAccount maker;
+    LXAccount taker;
+    bool taker_is_buy;
+    I128 px_x18;
+    I128 sz_x18;
+    uint64_t timestamp;          // Unix ns
+};
+
 // =============================================================================
 // LX - Unified DEX Controller
 // =============================================================================
@@ -63,6 +86,12 @@ public:
     LXFeed& feed() { return *feed_; }
     const LXFeed& feed() const { return *feed_; }
 
+    LXLend& lend() { return *lend_; }
+    const LXLend& lend() const { return *lend_; }
+
+    LXLiquid& liquid() { return *liquid_; }
+    const LXLiquid& liquid() const { return *liquid_; }
+
     // =========================================================================
     // Initialization
     // =========================================================================
@@ -75,6 +104,7 @@ public:
         EngineConfig engine_config;
         bool enable_hooks;
         bool enable_flash_loans;
+        bool enable_trade_busts;
         uint64_t funding_interval;
         I128 default_maker_fee_x18;
         I128 default_taker_fee_x18;
@@ -89,6 +119,12 @@ public:
     void stop();
     bool is_running() const;
 
+    // Stop accepting placements ahead of stop(); start() clears it.
+    // pending_orders() counts async orders the engine has yet to finish.
+    void begin_drain();
+    bool is_draining() const;
+    size_t pending_orders() const;
+
     // =========================================================================
     // Market Creation (Unified)
     // =========================================================================
@@ -134,6 +170,77 @@ public:
     // Run liquidation check for all accounts
     int32_t run_liquidations(uint32_t market_id);
 
+    // Operator correction for an erroneous print: the trade leaves the
+    // book's history, both accounts get back the positions and realized PnL
+    // they had before it with their fees refunded, and the bust callback is
+    // told. UNAUTHORIZED unless initialized with enable_trade_busts;
+    // TRADE_NOT_FOUND outside the book's retained history.
+    int32_t bust_trade(uint32_t market_id, uint64_t trade_id);
+
+    using TradeBustCallback = std::function<void(const Trade&)>;
+    void set_trade_bust_callback(TradeBustCallback callback);
+
+    // Called for every book trade once it settles, in execution order, on
+    // the thread that made the match. An empty callback removes it.
+    using TradeCallback = std::function<void(const LXTrade&)>;
+    void set_trade_callback(TradeCallback callback);
+
+    // Routes operational events from every component to one callback, on
+    // the thread that raised them. An empty callback removes it.
+    void set_system_event_callback(SystemEventCallback callback);
+
+    // Fees the order would pay at the market's rates: taker on the part that
+    // crosses current depth, maker on the part that would rest
+    struct FeeEstimate {
+        I128 maker_fee_x18;
+        I128 taker_fee_x18;
+    };
+    std::optional<FeeEstimate> estimate_fees(const LXOrder& order) const;
+
+    // Check an order against the market's min and max order size, the max
+    // position size and reduce-only rules without placing it. Returns the
+    // first violation, checked in that order, or OK.
+    int32_t check_order_limits(const LXAccount& account, const LXOrder& order) const;
+
+    // The reduce-only part of check_order_limits, which the book enforces on
+    // every order it places. Returns REDUCE_ONLY or OK.
+    int32_t check_reduce_only(const LXAccount& account, const LXOrder& order) const;
+
+    // =========================================================================
+    // Snapshot & Restore
+    // =========================================================================
+
+    // Checkpoint of every component's state, led by SNAPSHOT_VERSION. Take
+    // it with no operations in flight: components are captured one after
+    // another. Callbacks, hooks and L1 subscriptions are not included.
+    std::vector<uint8_t> snapshot() const;
+
+    // Load a snapshot into a fresh instance, replacing its state. Returns
+    // INVALID_SNAPSHOT for malformed data or another SNAPSHOT_VERSION, after
+    // which the instance should be discarded.
+    int32_t restore(const uint8_t* data, size_t size);
+
+    // =========================================================================
+    // Journal
+    // =========================================================================
+
+    // Called with a record for every journaled operation (see JournalOp) on
+    // the thread that ran it, once it completes. Each record leads with a
+    // sequence number, restarting at 1 whenever a callback is set, and the
+    // unix ns time it was taken. Journaled operations run one at a time
+    // while a callback is set, so sequence numbers follow the order they
+    // changed state in. The callback runs inside that order and must not
+    // call back into this instance. An empty callback stops journaling.
+    void set_journal_callback(JournalCallback callback);
+
+    // Re-run a journaled operation. Records are applied in sequence to a
+    // fresh instance; OIDs the book assigns are mapped from the recorded
+    // ones, so recorded cancels reach the replayed orders. Time-dependent
+    // checks such as staleness use the current time. Returns INVALID_JOURNAL
+    // for a malformed or out-of-sequence record, or one whose result differs
+    // from the recorded result.
+    int32_t journal_apply(const uint8_t* data, size_t size);
+
     // =========================================================================
     // Statistics
     // =========================================================================
@@ -148,12 +255,32 @@ public:
     };
     GlobalStats get_stats() const;
 
+    // Readiness probe: oldest_stale_asset is meaningful only when
+    // stale_asset_count is nonzero
+    struct Health {
+        bool running;
+        uint64_t oldest_stale_asset;
+        uint64_t stale_asset_count;
+        uint64_t open_orders;
+    };
+    Health health() const;
+
     // =========================================================================
     // Version & Info
     // =========================================================================
 
     static constexpr const char* version() { return "1.0.0"; }
 
+    // Build provenance. git_commit and build_date come from the build system
+    // ("unknown" if it did not set them); features is a comma-separated list.
+    struct BuildInfo {
+        const char* version;
+        const char* git_commit;
+        const char* build_date;
+        const char* features;
+    };
+    static BuildInfo build_info();
+
     struct ComponentInfo {
         const char* name;
         Address address;
@@ -180,12 +307,39 @@ private:
     std::unique_ptr<LXVault> vault_;
     std::unique_ptr<LXBook> book_;
     std::unique_ptr<LXFeed> feed_;
+    std::unique_ptr<LXLend> lend_;
+    std::unique_ptr<LXLiquid> liquid_;
 
     std::atomic<bool> running_{false};
     uint64_t start_time_{0};
+    std::atomic<bool> trade_busts_enabled_{false};
+
+    // Settled fills by market, oldest first, retained as long as the book
+    // keeps their trades so a bust can reverse them
+    std::unordered_map<uint32_t, std::deque<std::pair<uint64_t, LXSettlement>>> settled_fills_;
+    mutable std::mutex settled_mutex_;
+    TradeBustCallback trade_bust_callback_;
+    TradeCallback trade_callback_;
+    std::mutex trade_callback_mutex_;
+    SystemEventSink events_;
+
+    // Journal being written, and the replay state of journal_apply. Every
+    // component's sink, and journal_ for bust_trade, shares journal_order_.
+    JournalCallback journal_callback_;
+    uint64_t journal_sequence_{0};
+    std::mutex journal_mutex_;
+    std::recursive_mutex journal_order_;
+    JournalSink journal_;
+    uint64_t applied_sequence_{0};
+    std::unordered_map<uint64_t, uint64_t> replayed_oids_;  // recorded -> replayed OID
+    std::mutex replay_mutex_;
+    void write_journal(const std::vector<uint8_t>& body);
 
     // Internal settlement callback
     int32_t on_book_trades(const std::vector<Trade>& trades);
+
+    // Unjournaled body of bust_trade
+    int32_t try_bust_trade(uint32_t market_id, uint64_t trade_id);
 };
 
 // =============================================================================
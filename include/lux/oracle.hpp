@@ -10,6 +10,9 @@
 #include <cmath>
 
 #include "types.hpp"
+#include "events.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
 
 namespace lux {
 
@@ -52,6 +55,8 @@ struct OracleConfig {
     AggregationMethod method;
     std::vector<PriceSource> sources;
     std::vector<I128> weights_x18;  // Source weights for weighted methods
+    uint32_t max_move_bps = 0;   // Circuit breaker vs. the aggregate; 0 = off
+    uint16_t removed_sources = 0; // Bit per PriceSource quarantined by remove_source
 };
 
 // =============================================================================
@@ -86,6 +91,32 @@ public:
     int32_t update_config(uint64_t asset_id, const OracleConfig& config);
     std::optional<OracleConfig> get_config(uint64_t asset_id) const;
 
+    // Reject updates more than max_move_bps away from the current aggregate
+    // with PRICE_DEVIATION; 0 disables the breaker
+    int32_t set_circuit_breaker(uint64_t asset_id, uint32_t max_move_bps);
+
+    // Change a registered asset's max_staleness (seconds): older source
+    // prices count as stale and drop out of aggregation
+    int32_t set_max_staleness(uint64_t asset_id, uint64_t max_staleness);
+
+    // Change how a registered asset's source prices are aggregated
+    int32_t set_aggregation_method(uint64_t asset_id, AggregationMethod method);
+
+    // Receives CIRCUIT_BREAKER_TRIPPED events
+    void set_event_callback(SystemEventCallback callback) { events_.set(std::move(callback)); }
+
+    // Receives a record for each registration, configuration change, source
+    // removal or restore and update_price call; updates are recorded with
+    // the timestamp they were stored under
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
+    // Drop a source's stored price and reject its updates with
+    // ORACLE_SOURCE_UNAVAILABLE until restore_source
+    int32_t remove_source(uint64_t asset_id, PriceSource source);
+    int32_t restore_source(uint64_t asset_id, PriceSource source);
+
     void set_robust_params(uint64_t asset_id, const RobustParams& params);
     std::optional<RobustParams> get_robust_params(uint64_t asset_id) const;
 
@@ -98,8 +129,8 @@ public:
                          I128 price_x18, I128 confidence_x18,
                          uint64_t timestamp = 0);
 
-    // Batch update multiple prices
-    int32_t update_prices(const std::vector<std::tuple<uint64_t, PriceSource, I128, I128>>& updates);
+    // Batch update multiple prices; returns one result code per update
+    std::vector<int32_t> update_prices(const std::vector<std::tuple<uint64_t, PriceSource, I128, I128>>& updates);
 
     // =========================================================================
     // Price Queries
@@ -157,6 +188,10 @@ public:
     bool is_price_fresh(uint64_t asset_id, uint64_t max_staleness) const;
     uint64_t price_age(uint64_t asset_id) const;
 
+    // Registered assets older than their max staleness, or never priced,
+    // oldest first
+    std::vector<uint64_t> stale_assets() const;
+
     // =========================================================================
     // Statistics
     // =========================================================================
@@ -168,7 +203,18 @@ public:
     };
     Stats get_stats() const;
 
+    // =========================================================================
+    // Snapshot
+    // =========================================================================
+
+    // Asset configs, source prices and TWAP samples
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
 private:
+    SystemEventSink events_;
+    JournalSink journal_;
+
     // Asset configurations
     std::unordered_map<uint64_t, OracleConfig> configs_;
     std::unordered_map<uint64_t, RobustParams> robust_params_;
@@ -191,10 +237,27 @@ private:
     I128 aggregate_trimmed_mean(const std::vector<I128>& prices, I128 trim_percent_x18) const;
     I128 aggregate_weighted_median(const std::vector<I128>& prices,
                                     const std::vector<I128>& weights) const;
+    I128 aggregate_confidence_weighted(const std::vector<I128>& prices,
+                                        const std::vector<I128>& confidences) const;
 
     // Outlier detection
     std::vector<bool> detect_outliers(const std::vector<I128>& prices, I128 threshold_x18) const;
 
+    // Reject non-positive prices, removed sources and moves that trip the
+    // asset's circuit breaker
+    int32_t validate_update(uint64_t asset_id, PriceSource source, I128 price_x18) const;
+
+    // Unjournaled bodies of the recorded operations
+    int32_t try_register_asset(const OracleConfig& config);
+    int32_t try_update_config(uint64_t asset_id, const OracleConfig& config);
+    int32_t try_set_circuit_breaker(uint64_t asset_id, uint32_t max_move_bps);
+    int32_t try_set_max_staleness(uint64_t asset_id, uint64_t max_staleness);
+    int32_t try_set_aggregation_method(uint64_t asset_id, AggregationMethod method);
+    int32_t try_remove_source(uint64_t asset_id, PriceSource source);
+    int32_t try_restore_source(uint64_t asset_id, PriceSource source);
+    int32_t try_update_price(uint64_t asset_id, PriceSource source,
+                             I128 price_x18, I128 confidence_x18, uint64_t timestamp);
+
     // Helper
     uint64_t current_timestamp() const;
 };
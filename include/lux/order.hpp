@@ -2,6 +2,7 @@
 #define LUX_ORDER_HPP
 
 #include <cstdint>
+#include <algorithm>
 #include <chrono>
 #include <string>
 
@@ -24,7 +25,8 @@ enum class TimeInForce : uint8_t {
     IOC = 1,  // Immediate Or Cancel
     FOK = 2,  // Fill Or Kill
     GTD = 3,  // Good Till Date
-    DAY = 4   // Day order
+    DAY = 4,  // Day order
+    PostOnly = 5  // Rests as GTC; rejected if it would take liquidity
 };
 
 enum class OrderStatus : uint8_t {
@@ -66,6 +68,16 @@ struct Order {
     // For stop orders
     Price stop_price;
 
+    // Iceberg orders rest showing at most display_quantity (0 = show all).
+    // display_left is the unfilled part of the current slice, set by the book.
+    Quantity display_quantity = 0;
+    Quantity display_left = 0;
+
+    // Quantity shown in the book
+    Quantity visible() const {
+        return display_quantity > 0 ? std::min(display_left, remaining()) : remaining();
+    }
+
     bool is_buy() const { return side == Side::Buy; }
     bool is_sell() const { return side == Side::Sell; }
     bool is_active() const {
@@ -20,20 +20,40 @@ namespace lux {
 struct PriceLevel {
     Price price;
     std::list<Order> orders;
-    Quantity total_quantity{0};
+    Quantity total_quantity{0};  // Visible quantity; iceberg reserves are excluded
 
     size_t order_count() const { return orders.size(); }
 
+    // Everything that can fill at this price, hidden reserves included
+    Quantity fillable_quantity() const {
+        Quantity total = 0;
+        for (const auto& order : orders) total += order.remaining();
+        return total;
+    }
+
     void add_order(Order order) {
-        total_quantity += order.remaining();
+        total_quantity += order.visible();
         orders.push_back(std::move(order));
     }
 
+    // Shrink an order in place, keeping its queue position
+    bool reduce_order(uint64_t order_id, Quantity new_quantity) {
+        for (auto& order : orders) {
+            if (order.id == order_id) {
+                Quantity shown = order.visible();
+                order.quantity = new_quantity;
+                total_quantity -= shown - order.visible();
+                return true;
+            }
+        }
+        return false;
+    }
+
     // Remove order by ID, returns true if found
     bool remove_order(uint64_t order_id) {
         for (auto it = orders.begin(); it != orders.end(); ++it) {
             if (it->id == order_id) {
-                total_quantity -= it->remaining();
+                total_quantity -= it->visible();
                 orders.erase(it);
                 return true;
             }
@@ -52,12 +72,20 @@ struct PriceLevel {
 
     void pop_front() {
         if (!orders.empty()) {
-            total_quantity -= orders.front().remaining();
+            total_quantity -= orders.front().visible();
             orders.pop_front();
         }
     }
 
     bool empty() const { return orders.empty(); }
+
+    // Refill an iceberg whose slice is used up and send it to the back of
+    // the queue, as a newly displayed slice has no time priority
+    void replenish(std::list<Order>::iterator it) {
+        it->display_left = it->display_quantity;
+        total_quantity += it->visible();
+        orders.splice(orders.end(), orders, it);
+    }
 };
 
 // Market depth snapshot for a single side
@@ -73,6 +101,36 @@ struct MarketDepth {
     Timestamp timestamp;
 };
 
+// Result of walking the book for a hypothetical market order
+struct MarketQuote {
+    Price avg_price{0};     // Volume-weighted average fill price
+    Quantity filled{0};     // Quantity that would fill (< requested if book is thin)
+    Price worst_price{0};   // Price of the deepest level touched
+};
+
+// How a price level allocates an aggressor among its resting orders
+enum class MatchingAlgorithm : uint8_t {
+    PriceTime = 0,  // FIFO within a level
+    ProRata = 1     // Proportional to resting size within a level
+};
+
+// Who receives the units left over when pro-rata shares are rounded down.
+// Each leftover unit goes to a distinct order; ties fall back to queue order.
+enum class ProRataTieBreak : uint8_t {
+    LargestRemainder = 0,  // Largest truncated fractional share first
+    OrderID = 1,           // Lowest order ID first
+    Time = 2               // Earliest order timestamp first
+};
+
+// How self-trade prevention resolves an aggressor meeting a resting order
+// from the same STP group
+enum class STPMode : uint8_t {
+    CancelMaker = 0,  // Cancel the resting order and keep matching (default)
+    CancelTaker = 1,  // Cancel the aggressor's unfilled remainder
+    CancelBoth = 2,   // Cancel the resting order and the aggressor's remainder
+    Decrement = 3     // Shrink both by the smaller remaining size; cancel any left empty
+};
+
 // Order location for O(1) cancel
 struct OrderLocation {
     uint64_t order_id;
@@ -93,6 +151,12 @@ public:
 
     uint64_t symbol_id() const { return symbol_id_; }
 
+    // Matching algorithm; set before the book receives orders
+    void set_matching(MatchingAlgorithm algorithm, ProRataTieBreak tie_break);
+
+    // Self-trade resolution; set before the book receives orders
+    void set_stp_mode(STPMode mode);
+
     // Core operations - all thread-safe
     // Returns trades generated from matching
     std::vector<Trade> place_order(Order order, TradeListener* listener = nullptr);
@@ -100,9 +164,31 @@ public:
     // Cancel order by ID, returns the cancelled order if found
     std::optional<Order> cancel_order(uint64_t order_id);
 
-    // Modify order (cancel + replace)
+    // Modify order. A size reduction at the same price is applied in place and
+    // keeps time priority; any other change is a cancel + replace.
     std::optional<Order> modify_order(uint64_t order_id, Price new_price, Quantity new_quantity);
 
+    // Remove resting GTD/DAY orders whose expiry is at or before now and
+    // return them with status Expired. GTD orders expire at expire_time; DAY
+    // orders at expire_time if set, otherwise at the UTC midnight after they
+    // were placed.
+    std::vector<Order> expire_orders(Timestamp now);
+
+    // Resting orders in priority order: bids best price first, then asks,
+    // each level in queue order
+    std::vector<Order> resting_orders() const;
+
+    // Rest an order without matching, keeping its ID, timestamp and fills.
+    // Restoring another book's resting_orders() in sequence reproduces its
+    // queue priority. Returns false if the ID is already resting, nothing
+    // is left to fill, or the order would cross the opposite side.
+    bool restore_order(const Order& order);
+
+    // ID the next trade will take; carried across a restore so trade IDs
+    // stay unique
+    uint64_t next_trade_id() const { return next_trade_id_.load(std::memory_order_relaxed); }
+    void set_next_trade_id(uint64_t id) { next_trade_id_.store(id, std::memory_order_relaxed); }
+
     // Query operations - lock-free reads
     std::optional<Order> get_order(uint64_t order_id) const;
     bool has_order(uint64_t order_id) const;
@@ -115,6 +201,10 @@ public:
     // Market depth
     MarketDepth get_depth(size_t levels = 10) const;
 
+    // Walk the opposite side for a market order of the given size (no mutation),
+    // stopping at levels beyond limit when it is nonzero
+    MarketQuote quote_market(Side side, Quantity quantity, Price limit = 0) const;
+
     // Statistics
     size_t bid_levels() const;
     size_t ask_levels() const;
@@ -138,6 +228,10 @@ private:
     // Trade ID generator
     std::atomic<uint64_t> next_trade_id_{1};
 
+    MatchingAlgorithm matching_{MatchingAlgorithm::PriceTime};
+    ProRataTieBreak tie_break_{ProRataTieBreak::LargestRemainder};
+    STPMode stp_mode_{STPMode::CancelMaker};
+
     // Reader-writer lock for thread safety
     mutable std::shared_mutex mutex_;
 
@@ -151,6 +245,14 @@ private:
         TradeListener* listener
     );
 
+    // Pro-rata fill of one crossing level, appending to trades
+    void match_level_pro_rata(
+        Order& aggressor,
+        PriceLevel& level,
+        std::vector<Trade>& trades,
+        TradeListener* listener
+    );
+
     // Check if prices cross (can match)
     bool prices_cross(Price bid_price, Price ask_price) const {
         return bid_price >= ask_price;
@@ -161,6 +263,11 @@ private:
         return a.stp_group != 0 && a.stp_group == b.stp_group;
     }
 
+    // Apply stp_mode_ to a self-trading pair. Returns how much to take off
+    // resting (all of its remaining() removes it); marks the aggressor
+    // Cancelled when it must stop matching.
+    Quantity resolve_self_trade(Order& aggressor, const Order& resting) const;
+
     // Add order to resting book
     void add_to_book(Order order);
 
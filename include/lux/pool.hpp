@@ -4,12 +4,15 @@
 #include <map>
 #include <unordered_map>
 #include <shared_mutex>
+#include <mutex>
 #include <optional>
 #include <vector>
 #include <functional>
 #include <cmath>
 
 #include "types.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
 
 namespace lux {
 
@@ -20,7 +23,7 @@ namespace lux {
 struct Slot0 {
     I128 sqrt_price_x96;    // Current sqrt(price) as Q64.96
     int32_t tick;           // Current tick
-    uint32_t protocol_fee;  // Protocol fee (hundredths of bip)
+    uint32_t protocol_fee;  // Protocol fee (hundredths of bip): token0 low 16 bits, token1 high 16
     uint32_t lp_fee;        // LP fee (hundredths of bip)
     bool unlocked;          // Reentrancy lock
 };
@@ -54,6 +57,7 @@ struct PositionInfo {
 // =============================================================================
 
 struct PoolState {
+    PoolKey key;
     Slot0 slot0;
     I128 fee_growth_global0_x128;
     I128 fee_growth_global1_x128;
@@ -64,6 +68,32 @@ struct PoolState {
     std::unordered_map<uint64_t, PositionInfo> positions;  // position_key -> info
 };
 
+// =============================================================================
+// Trigger Swaps
+// =============================================================================
+
+// Direction the pool price (currency1 per currency0) must cross to fire
+enum class TriggerDir : uint8_t {
+    BELOW = 0,  // Fire when price <= trigger price
+    ABOVE = 1   // Fire when price >= trigger price
+};
+
+struct TriggerSwap {
+    uint64_t id;
+    LXAccount owner;
+    PoolKey key;
+    SwapParams params;
+    I128 trigger_px_x18;
+    TriggerDir direction;
+};
+
+// A trigger swap executed by execute_triggers
+struct TriggeredSwap {
+    uint64_t trigger_id;
+    LXAccount owner;
+    BalanceDelta delta;
+};
+
 // =============================================================================
 // Flash Context (explicit accounting state for lock operations)
 // =============================================================================
@@ -129,6 +159,29 @@ public:
     BalanceDelta swap(const PoolKey& key, const SwapParams& params,
                       const std::vector<uint8_t>& hook_data = {});
 
+    // Simulate a swap without moving the pool or calling hooks. Unless
+    // allow_partial is set, a swap the pool cannot fill in full (liquidity
+    // or price limit) returns INSUFFICIENT_LIQUIDITY; otherwise delta is the
+    // largest fill and full reports whether it covers the amount.
+    // Returns: OK, POOL_NOT_INITIALIZED or PRICE_LIMIT_EXCEEDED
+    int32_t quote_swap(const PoolKey& key, const SwapParams& params, bool allow_partial,
+                       BalanceDelta& delta, bool& full) const;
+
+    // Register a swap that executes once the pool price crosses
+    // trigger_px_x18 in direction. Triggers do not fire on their own; a
+    // keeper calls execute_triggers.
+    // Returns: OK, POOL_NOT_INITIALIZED, or INVALID_PRICE for a non-positive
+    // trigger price
+    int32_t place_trigger_swap(const LXAccount& owner, const PoolKey& key, const SwapParams& params,
+                               I128 trigger_px_x18, TriggerDir direction, uint64_t& trigger_id);
+
+    // Execute, in registration order, every trigger swap on the pool whose
+    // condition holds at the current price. Each swap moves the price before
+    // the next trigger is evaluated; fired triggers are removed, while one
+    // whose swap moves nothing stays registered.
+    // Returns: OK or POOL_NOT_INITIALIZED
+    int32_t execute_triggers(const PoolKey& key, std::vector<TriggeredSwap>& fired);
+
     // Add or remove liquidity
     // Returns: balance delta for principal + fees
     BalanceDelta modify_liquidity(const PoolKey& key, const ModifyLiquidityParams& params,
@@ -138,6 +191,13 @@ public:
     BalanceDelta donate(const PoolKey& key, I128 amount0, I128 amount1,
                         const std::vector<uint8_t>& hook_data = {});
 
+    // Pay out a position's owed fees, including those accrued since it was
+    // last modified, leaving its liquidity in place
+    // Returns: negative delta for the fees paid, nullopt if no such position
+    std::optional<BalanceDelta> collect_fees(const PoolKey& key, const Address& owner,
+                                             int32_t tick_lower, int32_t tick_upper,
+                                             uint64_t salt = 0);
+
     // =========================================================================
     // Flash Accounting (Uniswap v4 transient storage pattern)
     // =========================================================================
@@ -177,14 +237,28 @@ public:
                                               int32_t tick_upper,
                                               uint64_t salt = 0) const;
 
+    // Initialized ticks in [tick_lower, tick_upper], ascending; nullopt if
+    // the pool does not exist
+    std::optional<std::vector<std::pair<int32_t, TickInfo>>> get_ticks(
+        const PoolKey& key, int32_t tick_lower, int32_t tick_upper) const;
+
     // Check if pool exists
     bool pool_exists(const PoolKey& key) const;
 
+    // Keys of every initialized pool, in no particular order
+    std::vector<PoolKey> list_pools() const;
+
     // =========================================================================
     // Protocol Fee Management
     // =========================================================================
 
-    void set_protocol_fee(const PoolKey& key, uint32_t new_fee);
+    // new_fee packs the token0 fee in the low 16 bits and the token1 fee in
+    // the high 16; each is taken out of the LP fee and may be at most
+    // MAX_PROTOCOL_FEE_FRACTION of it
+    int32_t set_protocol_fee(const PoolKey& key, uint32_t new_fee);
+
+    // Set the LP fee of a pool whose key fee is fees::DYNAMIC_FEE_FLAG
+    int32_t set_dynamic_fee(const PoolKey& key, uint32_t new_fee);
     BalanceDelta collect_protocol(const PoolKey& key, const Address& recipient);
 
     // =========================================================================
@@ -194,6 +268,15 @@ public:
     void register_hooks(const Address& hook_addr, IHooks* hooks);
     void unregister_hooks(const Address& hook_addr);
 
+    // Declare the callbacks (hook_flags) a hook contract implements; pools
+    // naming the address can only be initialized once it is registered.
+    // Re-registering replaces the flags.
+    int32_t register_hook_flags(const Address& hook_addr, uint32_t flags);
+    std::optional<uint32_t> get_hook_flags(const Address& hook_addr) const;
+
+    // Known bits only, and each returns-delta flag paired with its callback
+    static bool valid_hook_flags(uint32_t flags);
+
     // =========================================================================
     // Statistics
     // =========================================================================
@@ -207,6 +290,21 @@ public:
     };
     Stats get_stats() const;
 
+    // Receives a record for each initialize, standalone swap, modify_liquidity,
+    // donate and fee collection call, and each protocol or dynamic fee change
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
+    // =========================================================================
+    // Snapshot
+    // =========================================================================
+
+    // Pools, positions, ticks and trigger swaps. Hooks are live objects and
+    // are not included; register them again after restore.
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
 private:
     // Pool storage: pool_id -> state
     std::unordered_map<uint64_t, PoolState> pools_;
@@ -214,8 +312,14 @@ private:
 
     // Hook registry
     std::unordered_map<uint64_t, IHooks*> hooks_;  // hash(address) -> hooks
+    std::unordered_map<uint64_t, uint32_t> hook_flags_;  // hash(address) -> hook_flags
     mutable std::shared_mutex hooks_mutex_;
 
+    // Trigger swaps in registration order
+    std::vector<TriggerSwap> triggers_;
+    uint64_t next_trigger_id_{1};
+    mutable std::mutex triggers_mutex_;
+
     // Flash accounting state
     bool locked_{false};
     std::unordered_map<uint64_t, I128> currency_deltas_;  // currency_hash -> delta
@@ -224,6 +328,21 @@ private:
     std::atomic<uint64_t> total_swaps_{0};
     std::atomic<uint64_t> total_liquidity_ops_{0};
 
+    JournalSink journal_;
+
+    // Unjournaled bodies of the recorded operations
+    int32_t try_initialize(const PoolKey& key, I128 sqrt_price_x96);
+    BalanceDelta try_modify_liquidity(const PoolKey& key, const ModifyLiquidityParams& params,
+                                      const std::vector<uint8_t>& hook_data);
+    BalanceDelta try_donate(const PoolKey& key, I128 amount0, I128 amount1,
+                            const std::vector<uint8_t>& hook_data);
+    std::optional<BalanceDelta> try_collect_fees(const PoolKey& key, const Address& owner,
+                                                 int32_t tick_lower, int32_t tick_upper,
+                                                 uint64_t salt);
+    int32_t try_set_protocol_fee(const PoolKey& key, uint32_t new_fee);
+    int32_t try_set_dynamic_fee(const PoolKey& key, uint32_t new_fee);
+    BalanceDelta try_collect_protocol(const PoolKey& key, const Address& recipient);
+
     // Internal helpers
     PoolState* get_pool(const PoolKey& key);
     const PoolState* get_pool(const PoolKey& key) const;
@@ -237,6 +356,10 @@ private:
     static uint64_t position_key(const Address& owner, int32_t tick_lower,
                                   int32_t tick_upper, uint64_t salt);
 
+    // Fee growth per unit of liquidity between two ticks
+    static std::pair<I128, I128> fee_growth_inside(const PoolState& pool,
+                                                   int32_t tick_lower, int32_t tick_upper);
+
     // Swap computation
     struct SwapState {
         I128 amount_remaining;
@@ -244,9 +367,15 @@ private:
         I128 sqrt_price_x96;
         int32_t tick;
         I128 liquidity;
+        I128 fee_amount;         // Fee charged by the last step
     };
-    SwapState compute_swap_step(SwapState state, I128 sqrt_price_target_x96,
-                                 uint32_t fee_pips, bool zero_for_one);
+    static SwapState compute_swap_step(SwapState state, I128 sqrt_price_target_x96,
+                                        uint32_t fee_pips, bool zero_for_one);
+
+    // Run a swap against pool; full is false if it stopped short of the amount
+    // Returns: OK or PRICE_LIMIT_EXCEEDED
+    static int32_t swap_in_pool(PoolState& pool, const PoolKey& key, const SwapParams& params,
+                                BalanceDelta& delta, bool& full);
 };
 
 // =============================================================================
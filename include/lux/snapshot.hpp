@@ -0,0 +1,156 @@
+#ifndef LUX_SNAPSHOT_HPP
+#define LUX_SNAPSHOT_HPP
+
+#include <cstdint>
+#include <cstring>
+#include <map>
+#include <optional>
+#include <type_traits>
+#include <unordered_map>
+#include <utility>
+#include <vector>
+
+namespace lux {
+
+// =============================================================================
+// Snapshot Encoding
+// =============================================================================
+
+// Format of the engine state written by LX::snapshot. Bump it whenever a
+// serialized type or the order of the sections changes.
+constexpr uint32_t SNAPSHOT_VERSION = 2;
+
+// Appends values to a snapshot. Trivially copyable values are written as
+// their raw bytes, so a snapshot restores only on a build with the same
+// struct layout; containers are written as a uint64 count and then their
+// elements.
+class SnapshotWriter {
+public:
+    template<typename T, std::enable_if_t<std::is_trivially_copyable_v<T>, int> = 0>
+    void put(const T& value) {
+        const auto* bytes = reinterpret_cast<const uint8_t*>(&value);
+        data_.insert(data_.end(), bytes, bytes + sizeof(T));
+    }
+
+    template<typename A, typename B>
+    void put(const std::pair<A, B>& value) {
+        put(value.first);
+        put(value.second);
+    }
+
+    template<typename T>
+    void put(const std::optional<T>& value) {
+        put(value.has_value());
+        if (value) put(*value);
+    }
+
+    template<typename T>
+    void put(const std::vector<T>& values) {
+        put(static_cast<uint64_t>(values.size()));
+        for (const auto& value : values) put(value);
+    }
+
+    template<typename K, typename V, typename C>
+    void put(const std::map<K, V, C>& values) { put_entries(values); }
+
+    template<typename K, typename V, typename H>
+    void put(const std::unordered_map<K, V, H>& values) { put_entries(values); }
+
+    std::vector<uint8_t> take() { return std::move(data_); }
+
+private:
+    std::vector<uint8_t> data_;
+
+    template<typename M>
+    void put_entries(const M& entries) {
+        put(static_cast<uint64_t>(entries.size()));
+        for (const auto& [key, value] : entries) {
+            put(key);
+            put(value);
+        }
+    }
+};
+
+// Reads values in the order a SnapshotWriter wrote them. Every get returns
+// false once the data runs out or a count exceeds what is left.
+class SnapshotReader {
+public:
+    SnapshotReader(const uint8_t* data, size_t size) : data_(data), size_(size) {}
+
+    template<typename T, std::enable_if_t<std::is_trivially_copyable_v<T>, int> = 0>
+    bool get(T& value) {
+        if (size_ - offset_ < sizeof(T)) return false;
+        std::memcpy(&value, data_ + offset_, sizeof(T));
+        offset_ += sizeof(T);
+        return true;
+    }
+
+    template<typename A, typename B>
+    bool get(std::pair<A, B>& value) {
+        return get(value.first) && get(value.second);
+    }
+
+    template<typename T>
+    bool get(std::optional<T>& value) {
+        bool present = false;
+        if (!get(present)) return false;
+        value.reset();
+        if (!present) return true;
+        T inner{};
+        if (!get(inner)) return false;
+        value = std::move(inner);
+        return true;
+    }
+
+    template<typename T>
+    bool get(std::vector<T>& values) {
+        uint64_t count = 0;
+        if (!get_count(count)) return false;
+        values.clear();
+        values.reserve(count);
+        for (uint64_t i = 0; i < count; ++i) {
+            T value{};
+            if (!get(value)) return false;
+            values.push_back(std::move(value));
+        }
+        return true;
+    }
+
+    template<typename K, typename V, typename C>
+    bool get(std::map<K, V, C>& values) { return get_entries(values); }
+
+    template<typename K, typename V, typename H>
+    bool get(std::unordered_map<K, V, H>& values) { return get_entries(values); }
+
+    // Element count written as a uint64. Every element takes at least one
+    // byte, so a count beyond the bytes left is rejected.
+    bool get_count(uint64_t& count) {
+        return get(count) && count <= size_ - offset_;
+    }
+
+    // Whether every byte has been read
+    bool done() const { return offset_ == size_; }
+
+private:
+    const uint8_t* data_;
+    size_t size_;
+    size_t offset_ = 0;
+
+    template<typename M>
+    bool get_entries(M& entries) {
+        uint64_t count = 0;
+        if (!get_count(count)) return false;
+        entries.clear();
+        for (uint64_t i = 0; i < count; ++i) {
+            typename M::key_type key{};
+            typename M::mapped_type value{};
+            if (!get(key) || !get(value)) return false;
+            entries.emplace(std::move(key), std::move(value));
+        }
+        return true;
+    }
+};
+
+} // namespace lux
+
+#endif // LUX_SNAPSHOT_HPP
@@ -36,6 +36,9 @@ public:
     virtual void on_order_filled(const Order& order) = 0;
     virtual void on_order_partially_filled(const Order& order, Quantity fill_qty) = 0;
     virtual void on_order_cancelled(const Order& order) = 0;
+    // Self-trade prevention shrank a resting order without cancelling it;
+    // order carries the new quantity
+    virtual void on_order_reduced(const Order& /*order*/, Quantity /*reduced_qty*/) {}
 };
 
 // No-op listener for when notifications aren't needed
@@ -82,12 +82,18 @@ constexpr I128 X18_HALF = 500000000000000000LL;  // 0.5e18
 // Safe X18 operations
 namespace x18 {
 
+// Both operands are split at X18_ONE so the intermediate products stay in
+// range whenever the result does; every term shares the result's sign, so
+// the truncation matches (a * b) / X18_ONE
 inline I128 mul(I128 a, I128 b) {
-    return (a * b) / X18_ONE;
+    I128 a_hi = a / X18_ONE, a_lo = a % X18_ONE;
+    I128 b_hi = b / X18_ONE, b_lo = b % X18_ONE;
+    return a_hi * b_hi * X18_ONE + a_hi * b_lo + a_lo * b_hi + (a_lo * b_lo) / X18_ONE;
 }
 
+// Same split for the dividend; exact while |b| stays below about 1.7e20
 inline I128 div(I128 a, I128 b) {
-    return (a * X18_ONE) / b;
+    return (a / b) * X18_ONE + ((a % b) * X18_ONE) / b;
 }
 
 inline I128 from_double(double v) {
@@ -182,6 +188,27 @@ constexpr uint32_t FEE_005 = 500;     // 0.05%
 constexpr uint32_t FEE_030 = 3000;    // 0.30%
 constexpr uint32_t FEE_100 = 10000;   // 1.00%
 constexpr uint32_t FEE_MAX = 100000;  // 10.00%
+constexpr uint32_t DYNAMIC_FEE_FLAG = 0x800000;  // Pool key fee: LP fee set at runtime
+constexpr uint32_t MAX_PROTOCOL_FEE_FRACTION = 4; // Protocol fee <= LP fee / 4
+}
+
+// Hook flags: the pool callbacks a hook contract declares it implements
+namespace hook_flags {
+constexpr uint32_t BEFORE_INITIALIZE = 1u << 13;
+constexpr uint32_t AFTER_INITIALIZE = 1u << 12;
+constexpr uint32_t BEFORE_ADD_LIQUIDITY = 1u << 11;
+constexpr uint32_t AFTER_ADD_LIQUIDITY = 1u << 10;
+constexpr uint32_t BEFORE_REMOVE_LIQUIDITY = 1u << 9;
+constexpr uint32_t AFTER_REMOVE_LIQUIDITY = 1u << 8;
+constexpr uint32_t BEFORE_SWAP = 1u << 7;
+constexpr uint32_t AFTER_SWAP = 1u << 6;
+constexpr uint32_t BEFORE_DONATE = 1u << 5;
+constexpr uint32_t AFTER_DONATE = 1u << 4;
+constexpr uint32_t BEFORE_SWAP_RETURNS_DELTA = 1u << 3;
+constexpr uint32_t AFTER_SWAP_RETURNS_DELTA = 1u << 2;
+constexpr uint32_t AFTER_ADD_LIQUIDITY_RETURNS_DELTA = 1u << 1;
+constexpr uint32_t AFTER_REMOVE_LIQUIDITY_RETURNS_DELTA = 1u << 0;
+constexpr uint32_t ALL = (1u << 14) - 1;
 }
 
 // Standard tick spacings
@@ -335,6 +362,7 @@ struct LXOrder {
     std::array<uint8_t, 16> cloid;  // Client order ID (UUID)
     std::array<uint8_t, 16> group_id;
     GroupType group_type;
+    uint64_t stp_group = 0;         // Self-trade prevention group (0 = exempt)
 };
 
 struct LXAction {
@@ -349,6 +377,7 @@ struct LXPlaceResult {
     uint8_t status;         // Order status
     I128 filled_size_x18;
     I128 avg_px_x18;
+    int32_t reason;         // errors:: code when status is REJECTED, else OK
 };
 
 struct LXL1 {
@@ -393,7 +422,9 @@ enum class AggregationMethod : uint8_t {
     TWAP = 1,
     VWAP = 2,
     TRIMMED_MEAN = 3,
-    WEIGHTED_MEDIAN = 4
+    WEIGHTED_MEDIAN = 4,
+    CONFIDENCE_WEIGHTED_MEAN = 5,  // Weighted by inverse confidence interval
+    LAST_KNOWN_GOOD = 6            // Newest valid submission, even once stale
 };
 
 // =============================================================================
@@ -424,17 +455,36 @@ constexpr int32_t PRICE_LIMIT_EXCEEDED = -5;
 constexpr int32_t INVALID_CURRENCY = -6;
 constexpr int32_t CURRENCIES_NOT_SORTED = -7;
 constexpr int32_t INVALID_FEE = -8;
+constexpr int32_t NOT_DYNAMIC_FEE = -9;
 constexpr int32_t INSUFFICIENT_BALANCE = -10;
 constexpr int32_t INSUFFICIENT_MARGIN = -11;
 constexpr int32_t POSITION_NOT_FOUND = -12;
 constexpr int32_t ORDER_NOT_FOUND = -13;
 constexpr int32_t MARKET_NOT_FOUND = -14;
 constexpr int32_t NOT_LIQUIDATABLE = -15;
+constexpr int32_t TRADE_NOT_FOUND = -16;
+constexpr int32_t POSITION_OPEN = -17;
+constexpr int32_t INVALID_LEVERAGE = -18;
+constexpr int32_t MARKET_NOT_EMPTY = -19;
 constexpr int32_t PRICE_STALE = -20;
 constexpr int32_t ORACLE_SOURCE_UNAVAILABLE = -21;
 constexpr int32_t INVALID_PRICE = -22;
+constexpr int32_t PRICE_DEVIATION = -23;
+constexpr int32_t NOT_ARMED = -24;
+constexpr int32_t ORDER_TOO_SMALL = -25;
+constexpr int32_t ORDER_TOO_LARGE = -26;
+constexpr int32_t POSITION_LIMIT_EXCEEDED = -27;
+constexpr int32_t REDUCE_ONLY = -28;
 constexpr int32_t REENTRANCY = -30;
 constexpr int32_t HOOK_FAILED = -31;
+constexpr int32_t HOOK_NOT_REGISTERED = -32;
+constexpr int32_t INVALID_HOOK_FLAGS = -33;
+constexpr int32_t INVALID_SNAPSHOT = -34;
+constexpr int32_t INVALID_JOURNAL = -35;
+constexpr int32_t DRAINING = -36;
+constexpr int32_t MARKET_HALTED = -37;
+constexpr int32_t MARKET_RESTRICTED = -38;
+constexpr int32_t ORDER_REJECTED = -39;
 constexpr int32_t UNAUTHORIZED = -40;
 }
 
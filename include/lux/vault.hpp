@@ -10,6 +10,9 @@
 #include <functional>
 
 #include "types.hpp"
+#include "events.hpp"
+#include "journal.hpp"
+#include "snapshot.hpp"
 
 namespace lux {
 
@@ -36,14 +39,41 @@ struct MarketConfig {
 // Account State
 // =============================================================================
 
+// Per-account fee rates that replace a market's maker and taker fees
+struct FeeTier {
+    I128 maker_fee_x18;          // Negative = rebate
+    I128 taker_fee_x18;
+};
+
+// Discount off a market's fees for accounts staking at least min_staked_x18
+struct StakingFeeTier {
+    I128 min_staked_x18;
+    I128 taker_discount_x18;     // Fraction of the fee, 0.5 = 50%
+    I128 maker_discount_x18;
+};
+
 struct AccountState {
     MarginMode margin_mode;
     std::unordered_map<uint64_t, I128> balances;     // currency_hash -> balance_x18
     std::map<uint32_t, LXPosition> positions;        // market_id -> position
-    I128 total_pnl_x18;
+    std::unordered_map<uint32_t, MarginMode> market_margin_modes;  // overrides margin_mode
+    std::unordered_map<uint32_t, I128> leverage_x18;               // market_id -> chosen leverage
+    std::optional<FeeTier> fee_tier;                               // overrides market fees
+    I128 staked_x18;             // Staked governance tokens, selects a StakingFeeTier
+    I128 total_pnl_x18;          // Realized PnL
+    I128 total_fees_x18;         // Trading fees paid
+    I128 total_funding_x18;      // Funding received (negative when paid)
     uint64_t last_update_time;
 };
 
+// Lifetime PnL and fee accounting for one account
+struct LXAccountPnL {
+    I128 realized_pnl_x18;
+    I128 unrealized_pnl_x18;     // Summed across open positions
+    I128 fees_paid_x18;
+    I128 funding_x18;            // Net funding; negative when paid
+};
+
 // =============================================================================
 // Settlement Record
 // =============================================================================
@@ -58,6 +88,13 @@ struct LXSettlement {
     I128 maker_fee_x18;
     I128 taker_fee_x18;
     uint8_t flags;  // fill_flags
+
+    // Set by apply_fills so reverse_fill can undo the fill exactly. A prior
+    // position with zero size means the side had none.
+    LXPosition maker_prior;
+    LXPosition taker_prior;
+    I128 maker_realized_pnl_x18;  // PnL the fill realized
+    I128 taker_realized_pnl_x18;
 };
 
 // =============================================================================
@@ -92,9 +129,13 @@ public:
     // =========================================================================
 
     int32_t create_market(const MarketConfig& config);
+    // Currencies are immutable (INVALID_CURRENCY). Rejects maintenance above
+    // initial margin (INVALID_LEVERAGE) and changes that would leave an
+    // account with a position liquidatable (INSUFFICIENT_MARGIN).
     int32_t update_market(const MarketConfig& config);
     std::optional<MarketConfig> get_market_config(uint32_t market_id) const;
     bool market_exists(uint32_t market_id) const;
+    std::vector<uint32_t> list_markets() const;  // ascending
 
     // =========================================================================
     // Deposit/Withdraw (Custody)
@@ -103,13 +144,26 @@ public:
     // Deposit collateral
     int32_t deposit(const LXAccount& account, const Currency& token, I128 amount_x18);
 
+    // Deposit several tokens at once; nothing is credited unless every amount is valid
+    int32_t deposit_multi(const LXAccount& account,
+                          const std::vector<std::pair<Currency, I128>>& deposits);
+
     // Withdraw collateral (checks margin requirements)
     int32_t withdraw(const LXAccount& account, const Currency& token, I128 amount_x18);
 
-    // Internal transfer between subaccounts
+    // Internal transfer between subaccounts. Both accounts must share an
+    // owner unless cross-owner transfers are enabled, and from must stay
+    // above maintenance margin afterwards.
     int32_t transfer(const LXAccount& from, const LXAccount& to,
                      const Currency& token, I128 amount_x18);
 
+    // Permit transfer() between accounts with different owners
+    void set_cross_owner_transfers(bool allowed) {
+        auto journal_lock = journal_.order();
+        cross_owner_transfers_.store(allowed, std::memory_order_relaxed);
+        journal_.record(JournalOp::VAULT_SET_CROSS_OWNER_TRANSFERS, allowed);
+    }
+
     // Get balance
     I128 get_balance(const LXAccount& account, const Currency& token) const;
     I128 total_collateral_value(const LXAccount& account) const;
@@ -118,15 +172,46 @@ public:
     // Margin Management
     // =========================================================================
 
-    // Set margin mode (cross/isolated)
+    // Set margin mode (cross/isolated) for one market; the account must be
+    // flat in that market
     int32_t set_margin_mode(const LXAccount& account, uint32_t market_id, MarginMode mode);
 
+    // Set leverage for one market, at most the market's max_leverage_x18.
+    // Initial margin becomes the larger of 1/leverage and the market rate.
+    int32_t set_leverage(const LXAccount& account, uint32_t market_id, I128 leverage_x18);
+
+    // Give an account its own maker and taker rates in every market, taking
+    // precedence over each market's fees. The taker rate must be non-negative
+    // and a maker rebate may not exceed it (INVALID_FEE).
+    int32_t set_fee_tier(const LXAccount& account, I128 maker_fee_x18, I128 taker_fee_x18);
+
+    // Record an account's staked balance (INVALID_PRICE if negative)
+    int32_t set_staked_balance(const LXAccount& account, I128 amount_x18);
+
+    // Replace the staking fee schedule; an empty schedule removes the discounts.
+    // Discounts must lie in [0, 1] and thresholds be non-negative (INVALID_FEE).
+    int32_t set_staking_fee_tiers(std::vector<StakingFeeTier> tiers);
+
+    // Rates a fill charges the account: its fee tier if set, otherwise the
+    // market's; nullopt if neither exists. Where the highest staking tier the
+    // account meets discounts the market's rate further, that rate is used
+    // instead; the two do not stack.
+    std::optional<FeeTier> fee_rates(const LXAccount& account, uint32_t market_id) const;
+
     // Get account state
     std::optional<AccountState> get_account_state(const LXAccount& account) const;
 
     // Get margin info
     LXMarginInfo get_margin_info(const LXAccount& account) const;
 
+    // Additional notional the account's free margin supports in a market at
+    // its initial margin rate; zero once margin is used up, nullopt if the
+    // market does not exist
+    std::optional<I128> buying_power(const LXAccount& account, uint32_t market_id) const;
+
+    // Get realized/unrealized PnL, fees and funding
+    LXAccountPnL get_account_pnl(const LXAccount& account) const;
+
     // Account equity (collateral + unrealized PnL)
     I128 account_equity_x18(const LXAccount& account) const;
 
@@ -147,6 +232,16 @@ public:
     // Get all positions
     std::vector<LXPosition> get_all_positions(const LXAccount& account) const;
 
+    // Whether the account has an open position in any market
+    bool has_positions(const LXAccount& account) const;
+
+    // Move size of from's position to to at price. to's entry is blended
+    // with price and from realizes PnL on the size moved.
+    // Returns: OK, POSITION_NOT_FOUND if from holds less than size, or
+    // INSUFFICIENT_MARGIN if to cannot margin the size at price
+    int32_t transfer_position(const LXAccount& from, const LXAccount& to, uint32_t market_id,
+                              I128 size_x18, I128 price_x18);
+
     // =========================================================================
     // Settlement (from CLOB matches)
     // =========================================================================
@@ -154,8 +249,14 @@ public:
     // Pre-check fills (validate margin requirements)
     int32_t pre_check_fills(const std::vector<LXSettlement>& settlements);
 
-    // Apply fills (update positions after matching)
-    int32_t apply_fills(const std::vector<LXSettlement>& settlements);
+    // Apply fills (update positions after matching). Records each side's
+    // prior position and realized PnL in its settlement.
+    int32_t apply_fills(std::vector<LXSettlement>& settlements);
+
+    // Undo a fill from apply_fills: both sides get back the position they
+    // held before it, with its entry price, lose the PnL it realized and are
+    // refunded their fees.
+    int32_t reverse_fill(const LXSettlement& settlement);
 
     // =========================================================================
     // Liquidation
@@ -164,6 +265,10 @@ public:
     // Check if account is liquidatable
     bool is_liquidatable(const LXAccount& account) const;
 
+    // Mark price at which the account's equity falls to its maintenance
+    // margin, holding every other position at its last marked PnL
+    std::optional<I128> liquidation_price(const LXAccount& account, uint32_t market_id) const;
+
     // Liquidate a position
     LXLiquidationResult liquidate(const LXAccount& liquidator, const LXAccount& account,
                                    uint32_t market_id, I128 size_x18);
@@ -175,8 +280,13 @@ public:
     // Funding
     // =========================================================================
 
-    // Accrue funding for all positions in a market
-    int32_t accrue_funding(uint32_t market_id);
+    // Accrue funding for all positions in a market, if its interval has
+    // passed by now (unix seconds; 0 for the current time)
+    int32_t accrue_funding(uint32_t market_id, uint64_t now = 0);
+
+    // Move an account's accrued funding for a market into its balance and
+    // return the signed amount (negative = paid); nullopt if no position
+    std::optional<I128> settle_funding(const LXAccount& account, uint32_t market_id);
 
     // Get current funding rate
     I128 funding_rate_x18(uint32_t market_id) const;
@@ -204,6 +314,17 @@ public:
     // Set callback to fetch mark prices
     void set_mark_price_callback(MarkPriceCallback callback);
 
+    // Receives LIQUIDATION and INSURANCE_FUND_DRAW events
+    void set_event_callback(SystemEventCallback callback) { events_.set(std::move(callback)); }
+
+    // Receives a record for each call that changes markets, balances,
+    // positions, account settings or funding, except the fills the book
+    // settles, which replay with its placements. A deposit_multi is recorded
+    // as one deposit per token; accrue_funding with the time it used.
+    void set_journal_callback(JournalCallback callback, std::recursive_mutex* order = nullptr) {
+        journal_.set(std::move(callback), order);
+    }
+
     // Update mark prices for all positions
     int32_t update_mark_prices(const std::vector<std::pair<uint32_t, I128>>& prices);
 
@@ -223,6 +344,15 @@ public:
     };
     Stats get_stats() const;
 
+    // =========================================================================
+    // Snapshot
+    // =========================================================================
+
+    // Accounts, markets, funding, the insurance fund and fee schedules;
+    // callbacks are not included
+    void snapshot(SnapshotWriter& out) const;
+    bool restore(SnapshotReader& in);  // false if the data is malformed
+
 private:
     // Account storage: account_hash -> state
     std::unordered_map<uint64_t, AccountState> accounts_;
@@ -248,15 +378,48 @@ private:
     // Statistics
     std::atomic<uint64_t> total_liquidations_{0};
 
+    // Transfer policy
+    std::atomic<bool> cross_owner_transfers_{false};
+
+    // Staking fee schedule, ascending by min_staked_x18; guarded by accounts_mutex_
+    std::vector<StakingFeeTier> staking_fee_tiers_;
+
     // Mark price callback
     MarkPriceCallback mark_price_callback_;
+    SystemEventSink events_;
+    JournalSink journal_;
+
+    // Unjournaled bodies of the recorded operations. LXLend and LXLiquid
+    // move balances with try_deposit and try_withdraw, as part of their own
+    // recorded operations.
+    friend class LXLend;
+    friend class LXLiquid;
+    int32_t try_create_market(const MarketConfig& config);
+    int32_t try_update_market(const MarketConfig& config);
+    int32_t try_deposit(const LXAccount& account, const Currency& token, I128 amount_x18);
+    int32_t try_withdraw(const LXAccount& account, const Currency& token, I128 amount_x18);
+    int32_t try_transfer(const LXAccount& from, const LXAccount& to,
+                         const Currency& token, I128 amount_x18);
+    int32_t try_set_margin_mode(const LXAccount& account, uint32_t market_id, MarginMode mode);
+    int32_t try_set_leverage(const LXAccount& account, uint32_t market_id, I128 leverage_x18);
+    int32_t try_set_fee_tier(const LXAccount& account, I128 maker_fee_x18, I128 taker_fee_x18);
+    int32_t try_set_staked_balance(const LXAccount& account, I128 amount_x18);
+    int32_t try_set_staking_fee_tiers(std::vector<StakingFeeTier> tiers);
+    int32_t try_transfer_position(const LXAccount& from, const LXAccount& to, uint32_t market_id,
+                                  I128 size_x18, I128 price_x18);
+    LXLiquidationResult try_liquidate(const LXAccount& liquidator, const LXAccount& account,
+                                      uint32_t market_id, I128 size_x18);
+    int32_t try_accrue_funding(uint32_t market_id, uint64_t now);
+    std::optional<I128> try_settle_funding(const LXAccount& account, uint32_t market_id);
+    void try_set_funding_rate(uint32_t market_id, I128 rate_x18);
 
     // Internal helpers
     AccountState* get_or_create_account(const LXAccount& account);
     const AccountState* get_account(const LXAccount& account) const;
 
     // Margin calculations
-    I128 calculate_initial_margin(const LXPosition& pos, const MarketConfig& config) const;
+    I128 calculate_initial_margin(const AccountState& state, const LXPosition& pos,
+                                  const MarketConfig& config) const;
     I128 calculate_maintenance_margin(const LXPosition& pos, const MarketConfig& config) const;
     I128 calculate_unrealized_pnl(const LXPosition& pos, I128 mark_price_x18) const;
 
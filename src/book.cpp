@@ -5,10 +5,30 @@
 #include "lux/book.hpp"
 #include <chrono>
 #include <algorithm>
+#include <cmath>
 #include <cstring>
 
 namespace lux {
 
+static uint64_t unix_ms() {
+    return static_cast<uint64_t>(
+        std::chrono::duration_cast<std::chrono::milliseconds>(
+            std::chrono::system_clock::now().time_since_epoch()
+        ).count()
+    );
+}
+
+// Halted and cancel-only markets take no new orders
+static bool is_trading_status(uint8_t status) {
+    return status != 0 && status != 2;
+}
+
+static bool same_l1(const LXL1& a, const LXL1& b) {
+    return a.best_bid_px_x18 == b.best_bid_px_x18 && a.best_bid_sz_x18 == b.best_bid_sz_x18 &&
+           a.best_ask_px_x18 == b.best_ask_px_x18 && a.best_ask_sz_x18 == b.best_ask_sz_x18 &&
+           a.last_trade_px_x18 == b.last_trade_px_x18;
+}
+
 // =============================================================================
 // BookTradeListener Implementation
 // =============================================================================
@@ -29,6 +49,7 @@ void LXBook::BookTradeListener::on_order_filled(const Order& order) {
         order.id,
         [](BookOrderState& state) {
             state.status = BookOrderStatus::FILLED;
+            state.filled_size_x18 += state.remaining_size_x18;
             state.remaining_size_x18 = 0;
         }
     );
@@ -71,6 +92,13 @@ LXBook::LXBook() : engine_(EngineConfig{}) {
 // =============================================================================
 
 int32_t LXBook::create_market(const BookMarketConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_create_market(config);
+    journal_.record(JournalOp::BOOK_CREATE_MARKET, config, result);
+    return result;
+}
+
+int32_t LXBook::try_create_market(const BookMarketConfig& config) {
     std::unique_lock lock(markets_mutex_);
 
     if (markets_.find(config.market_id) != markets_.end()) {
@@ -82,20 +110,51 @@ int32_t LXBook::create_market(const BookMarketConfig& config) {
         return errors::POOL_ALREADY_INITIALIZED;
     }
 
+    engine_.get_orderbook(config.symbol_id)->set_stp_mode(
+        static_cast<STPMode>(config.stp_mode));
+
     markets_[config.market_id] = config;
     market_to_symbol_[config.market_id] = config.symbol_id;
+    lock.unlock();
 
+    if (!is_trading_status(config.status)) {
+        events_.emit(SystemEventKind::MARKET_HALTED, Severity::CRITICAL, config.market_id);
+    }
     return errors::OK;
 }
 
 int32_t LXBook::update_market_config(const BookMarketConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_update_market_config(config);
+    journal_.record(JournalOp::BOOK_UPDATE_MARKET, config, result);
+    return result;
+}
+
+int32_t LXBook::try_update_market_config(const BookMarketConfig& config) {
     std::unique_lock lock(markets_mutex_);
 
     auto it = markets_.find(config.market_id);
     if (it == markets_.end()) {
         return errors::MARKET_NOT_FOUND;
     }
+    const BookMarketConfig& current = it->second;
+    if (config.symbol_id != current.symbol_id ||
+        config.base_currency != current.base_currency ||
+        config.quote_currency != current.quote_currency) {
+        return errors::INVALID_CURRENCY;
+    }
+
+    // Resting orders were validated against the old increments
+    auto* book = engine_.get_orderbook(current.symbol_id);
+    if ((config.tick_size_x18 != current.tick_size_x18 ||
+         config.lot_size_x18 != current.lot_size_x18) &&
+        book && book->total_orders() > 0) {
+        return errors::MARKET_NOT_EMPTY;
+    }
 
+    if (book) {
+        book->set_stp_mode(static_cast<STPMode>(config.stp_mode));
+    }
     it->second = config;
     return errors::OK;
 }
@@ -114,11 +173,93 @@ uint8_t LXBook::get_market_status(uint32_t market_id) const {
     return it->second.status;
 }
 
+int32_t LXBook::set_market_status(uint32_t market_id, uint8_t status) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_market_status(market_id, status);
+    journal_.record(JournalOp::BOOK_SET_MARKET_STATUS, market_id, status, result);
+    return result;
+}
+
+int32_t LXBook::try_set_market_status(uint32_t market_id, uint8_t status) {
+    std::unique_lock lock(markets_mutex_);
+    auto it = markets_.find(market_id);
+    if (it == markets_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+    bool halted = is_trading_status(it->second.status) && !is_trading_status(status);
+    it->second.status = status;
+    lock.unlock();
+
+    if (halted) {
+        events_.emit(SystemEventKind::MARKET_HALTED, Severity::CRITICAL, market_id);
+    }
+    return errors::OK;
+}
+
 bool LXBook::market_exists(uint32_t market_id) const {
     std::shared_lock lock(markets_mutex_);
     return markets_.find(market_id) != markets_.end();
 }
 
+std::vector<uint32_t> LXBook::list_markets() const {
+    std::shared_lock lock(markets_mutex_);
+    std::vector<uint32_t> ids;
+    ids.reserve(markets_.size());
+    for (const auto& [market_id, config] : markets_) {
+        ids.push_back(market_id);
+    }
+    std::sort(ids.begin(), ids.end());
+    return ids;
+}
+
+int32_t LXBook::remove_market(uint32_t market_id) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_remove_market(market_id);
+    journal_.record(JournalOp::BOOK_REMOVE_MARKET, market_id, result);
+    return result;
+}
+
+int32_t LXBook::try_remove_market(uint32_t market_id) {
+    std::unique_lock lock(markets_mutex_);
+
+    auto it = markets_.find(market_id);
+    if (it == markets_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    {
+        std::shared_lock triggers_lock(triggers_mutex_);
+        auto trig_it = pending_triggers_.find(market_id);
+        if (trig_it != pending_triggers_.end() && !trig_it->second.empty()) {
+            return errors::MARKET_NOT_EMPTY;
+        }
+    }
+
+    // The engine only drops an empty book
+    if (!engine_.remove_symbol(it->second.symbol_id)) {
+        return errors::MARKET_NOT_EMPTY;
+    }
+
+    markets_.erase(it);
+    market_to_symbol_.erase(market_id);
+    lock.unlock();
+
+    {
+        std::unique_lock triggers_lock(triggers_mutex_);
+        pending_triggers_.erase(market_id);
+    }
+    {
+        std::unique_lock trades_lock(trades_mutex_);
+        last_trades_.erase(market_id);
+        recent_trades_.erase(market_id);
+    }
+    {
+        std::unique_lock stats_lock(stats_mutex_);
+        market_counters_.erase(market_id);
+    }
+    return errors::OK;
+}
+
 // =============================================================================
 // Execute Interface
 // =============================================================================
@@ -166,11 +307,33 @@ std::vector<ExecuteResult> LXBook::execute_batch(const LXAccount& sender,
 // =============================================================================
 
 LXPlaceResult LXBook::place_order(const LXAccount& sender, const LXOrder& order) {
+    auto journal_lock = journal_.order();
+    LXPlaceResult result = try_place_order(sender, order);
+    if (result.status == static_cast<uint8_t>(BookOrderStatus::REJECTED)) {
+        events_.emit(SystemEventKind::ORDER_REJECTED, Severity::INFO, order.market_id, 0, sender);
+    }
+    journal_.record(JournalOp::BOOK_PLACE_ORDER, sender, order, result);
+    return result;
+}
+
+LXPlaceResult LXBook::try_place_order(const LXAccount& sender, const LXOrder& order) {
     LXPlaceResult result{};
 
+    if (draining_.load()) {
+        result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::DRAINING;
+        return result;
+    }
+
+    uint64_t now_ms = unix_ms();
+    if (now_ms >= next_deadline_ms_.load()) {
+        check_heartbeats(now_ms);
+    }
+
     uint64_t symbol_id = get_symbol_id(order.market_id);
     if (symbol_id == 0) {
         result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::MARKET_NOT_FOUND;
         return result;
     }
 
@@ -179,22 +342,59 @@ LXPlaceResult LXBook::place_order(const LXAccount& sender, const LXOrder& order)
     auto market_it = markets_.find(order.market_id);
     if (market_it == markets_.end()) {
         result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::MARKET_NOT_FOUND;
         return result;
     }
 
     const BookMarketConfig& config = market_it->second;
-    if (config.status == 0) { // Inactive
+    if (config.status == 0) { // Halted
         result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::MARKET_HALTED;
         return result;
     }
-    if (config.status == 2 && order.kind != OrderKind::LIMIT) { // Cancel-only
+    if (config.status == 2 || // Cancel-only
+        (config.status == 4 && order.kind != OrderKind::LIMIT) || // Limit-only
+        (config.status == 3 && (order.kind != OrderKind::LIMIT || order.tif == TIF::IOC))) { // Post-only
         result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::MARKET_RESTRICTED;
         return result;
     }
+    bool post_only = config.status == 3;
     lock.unlock();
 
+    // Conditional orders are checked when they fire, against the position then
+    if (order_check_ && !is_conditional(order.kind)) {
+        int32_t check = order_check_(sender, order);
+        if (check != errors::OK) {
+            result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+            result.reason = check;
+            return result;
+        }
+    }
+
+    {
+        std::unique_lock accounts_lock(accounts_mutex_);
+        accounts_.emplace(sender.hash(), sender);
+    }
+
     // Convert to internal order format
     Order internal_order = convert_to_internal(order, symbol_id, sender);
+    if (post_only) {
+        // Limits that would cross are rejected rather than taking
+        internal_order.tif = TimeInForce::PostOnly;
+    }
+
+    // Conditional orders wait off the book until evaluate_triggers fires them
+    if (is_conditional(order.kind)) {
+        return hold_trigger(sender, order, internal_order);
+    }
+
+    return submit_order(sender, order, internal_order, true);
+}
+
+LXPlaceResult LXBook::submit_order(const LXAccount& sender, const LXOrder& order,
+                                   const Order& internal_order, bool count_placed) {
+    LXPlaceResult result{};
 
     // Place order on engine
     OrderResult engine_result = engine_.place_order(internal_order);
@@ -203,6 +403,9 @@ LXPlaceResult LXBook::place_order(const LXAccount& sender, const LXOrder& order)
     result.status = engine_result.success ?
         static_cast<uint8_t>(BookOrderStatus::NEW) :
         static_cast<uint8_t>(BookOrderStatus::REJECTED);
+    if (!engine_result.success) {
+        result.reason = errors::ORDER_REJECTED;
+    }
 
     // Calculate fills
     I128 total_fill_size = 0;
@@ -218,6 +421,13 @@ LXPlaceResult LXBook::place_order(const LXAccount& sender, const LXOrder& order)
     if (total_fill_size > 0) {
         result.avg_px_x18 = x18::div(total_fill_value, total_fill_size);
     }
+    if (engine_result.success && total_fill_size >= order.size_x18) {
+        result.status = static_cast<uint8_t>(BookOrderStatus::FILLED);
+    } else if (engine_result.success &&
+               engine_.get_order(internal_order.symbol_id, result.oid)) {
+        // Resting on the book, as get_order will report it
+        result.status = static_cast<uint8_t>(BookOrderStatus::OPEN);
+    }
 
     // Track order state
     if (engine_result.success) {
@@ -251,20 +461,173 @@ LXPlaceResult LXBook::place_order(const LXAccount& sender, const LXOrder& order)
         account_orders.cloid_to_oid[order.cloid] = result.oid;
     }
 
+    if (count_placed) {
+        total_orders_placed_.fetch_add(1, std::memory_order_relaxed);
+    }
+
+    if (engine_result.success) {
+        std::unique_lock stats_lock(stats_mutex_);
+        auto& counters = market_counters_[order.market_id];
+        if (count_placed) {
+            counters.orders_placed++;
+        }
+        counters.trades += engine_result.trades.size();
+        counters.volume_x18 += total_fill_size;
+    }
+
+    publish_l1(order.market_id);
+    return result;
+}
+
+// =============================================================================
+// Conditional Orders
+// =============================================================================
+
+bool LXBook::is_conditional(OrderKind kind) {
+    return kind == OrderKind::STOP_MARKET || kind == OrderKind::STOP_LIMIT ||
+           kind == OrderKind::TAKE_MARKET || kind == OrderKind::TAKE_LIMIT;
+}
+
+bool LXBook::trigger_fires(const LXOrder& order, I128 ref_px_x18) {
+    // Stops fire when the price moves against the position being protected
+    // (buy stops on a rise, sell stops on a fall); take-profits the other way
+    bool stop = order.kind == OrderKind::STOP_MARKET || order.kind == OrderKind::STOP_LIMIT;
+    bool fire_on_rise = (stop == order.is_buy);
+    return fire_on_rise ? ref_px_x18 >= order.trigger_px_x18
+                        : ref_px_x18 <= order.trigger_px_x18;
+}
+
+LXPlaceResult LXBook::hold_trigger(const LXAccount& sender, const LXOrder& order,
+                                   const Order& internal_order) {
+    LXPlaceResult result{};
+    if (order.trigger_px_x18 <= 0 || order.size_x18 <= 0) {
+        result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = order.trigger_px_x18 <= 0 ? errors::INVALID_PRICE : errors::ORDER_TOO_SMALL;
+        return result;
+    }
+
+    {
+        std::unique_lock lock(triggers_mutex_);
+        pending_triggers_[order.market_id].push_back({sender, order, internal_order});
+    }
+
+    result.oid = internal_order.id;
+    result.status = static_cast<uint8_t>(BookOrderStatus::NEW);
+
+    {
+        std::unique_lock orders_lock(orders_mutex_);
+        auto& account_orders = account_orders_[sender.hash()];
+
+        BookOrderState state{};
+        state.oid = result.oid;
+        state.cloid = order.cloid;
+        state.market_id = order.market_id;
+        state.is_buy = order.is_buy;
+        state.kind = order.kind;
+        state.tif = order.tif;
+        state.original_size_x18 = order.size_x18;
+        state.remaining_size_x18 = order.size_x18;
+        state.limit_price_x18 = order.limit_px_x18;
+        state.trigger_price_x18 = order.trigger_px_x18;
+        state.status = BookOrderStatus::NEW;
+        state.created_at = static_cast<uint64_t>(
+            std::chrono::duration_cast<std::chrono::nanoseconds>(
+                std::chrono::system_clock::now().time_since_epoch()
+            ).count()
+        );
+        state.updated_at = state.created_at;
+        state.flags = order.reduce_only ? fill_flags::REDUCE_ONLY : 0;
+
+        account_orders.orders[result.oid] = state;
+        account_orders.cloid_to_oid[order.cloid] = result.oid;
+    }
+
     total_orders_placed_.fetch_add(1, std::memory_order_relaxed);
+    {
+        std::unique_lock stats_lock(stats_mutex_);
+        market_counters_[order.market_id].orders_placed++;
+    }
 
     return result;
 }
 
+std::vector<uint64_t> LXBook::evaluate_triggers(uint32_t market_id, I128 ref_px_x18) {
+    auto journal_lock = journal_.order();
+    std::vector<uint64_t> oids = try_evaluate_triggers(market_id, ref_px_x18);
+    journal_.record(JournalOp::BOOK_EVALUATE_TRIGGERS, market_id, ref_px_x18, oids);
+    return oids;
+}
+
+std::vector<uint64_t> LXBook::try_evaluate_triggers(uint32_t market_id, I128 ref_px_x18) {
+    std::vector<PendingTrigger> fired;
+    {
+        std::unique_lock lock(triggers_mutex_);
+        auto it = pending_triggers_.find(market_id);
+        if (it == pending_triggers_.end()) return {};
+
+        auto& pending = it->second;
+        auto split = std::stable_partition(pending.begin(), pending.end(),
+            [ref_px_x18](const PendingTrigger& p) { return !trigger_fires(p.order, ref_px_x18); });
+        fired.assign(std::make_move_iterator(split), std::make_move_iterator(pending.end()));
+        pending.erase(split, pending.end());
+    }
+
+    // Activate in placement order
+    std::vector<uint64_t> oids;
+    oids.reserve(fired.size());
+    for (auto& p : fired) {
+        if (order_check_ && order_check_(p.sender, p.order) != errors::OK) {
+            events_.emit(SystemEventKind::ORDER_REJECTED, Severity::INFO, market_id, 0, p.sender);
+            continue;
+        }
+        bool is_market = p.order.kind == OrderKind::STOP_MARKET ||
+                         p.order.kind == OrderKind::TAKE_MARKET;
+        p.internal.type = is_market ? OrderType::Market : OrderType::Limit;
+        p.internal.timestamp = std::chrono::duration_cast<Timestamp>(
+            std::chrono::system_clock::now().time_since_epoch()
+        );
+        submit_order(p.sender, p.order, p.internal, false);
+        oids.push_back(p.internal.id);
+    }
+    return oids;
+}
+
+bool LXBook::cancel_trigger(uint32_t market_id, uint64_t oid) {
+    std::unique_lock lock(triggers_mutex_);
+    auto it = pending_triggers_.find(market_id);
+    if (it == pending_triggers_.end()) return false;
+
+    auto& pending = it->second;
+    auto found = std::find_if(pending.begin(), pending.end(),
+        [oid](const PendingTrigger& p) { return p.internal.id == oid; });
+    if (found == pending.end()) return false;
+    pending.erase(found);
+    return true;
+}
+
 int32_t LXBook::cancel_order(const LXAccount& sender, uint32_t market_id, uint64_t oid) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_cancel_order(sender, market_id, oid);
+    journal_.record(JournalOp::BOOK_CANCEL_ORDER, sender, market_id, oid, result);
+    return result;
+}
+
+int32_t LXBook::try_cancel_order(const LXAccount& sender, uint32_t market_id, uint64_t oid) {
     uint64_t symbol_id = get_symbol_id(market_id);
     if (symbol_id == 0) {
         return errors::MARKET_NOT_FOUND;
     }
 
-    CancelResult result = engine_.cancel_order(symbol_id, oid);
-    if (!result.success) {
-        return errors::ORDER_NOT_FOUND;
+    if (!cancel_trigger(market_id, oid)) {
+        CancelResult result = engine_.cancel_order(symbol_id, oid);
+        if (!result.success) {
+            return errors::ORDER_NOT_FOUND;
+        }
+    }
+
+    {
+        std::unique_lock stats_lock(stats_mutex_);
+        market_counters_[market_id].orders_cancelled++;
     }
 
     // Update order state
@@ -272,6 +635,7 @@ int32_t LXBook::cancel_order(const LXAccount& sender, uint32_t market_id, uint64
         state.status = BookOrderStatus::CANCELLED;
     });
 
+    publish_l1(market_id);
     return errors::OK;
 }
 
@@ -317,13 +681,132 @@ int32_t LXBook::cancel_all(const LXAccount& sender, uint32_t market_id) {
     return errors::OK;
 }
 
+int32_t LXBook::reset_quotes(const LXAccount& sender, uint32_t market_id,
+                             const std::vector<LXOrder>& orders,
+                             std::vector<LXPlaceResult>& results) {
+    if (!market_exists(market_id)) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    cancel_all(sender, market_id);
+
+    results.clear();
+    results.reserve(orders.size());
+    for (const auto& order : orders) {
+        if (order.market_id != market_id) {
+            LXPlaceResult rejected{};
+            rejected.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+            rejected.reason = errors::MARKET_NOT_FOUND;
+            results.push_back(rejected);
+            events_.emit(SystemEventKind::ORDER_REJECTED, Severity::INFO, order.market_id, 0, sender);
+            continue;
+        }
+        results.push_back(place_order(sender, order));
+    }
+    return errors::OK;
+}
+
+int32_t LXBook::arm_cancel_on_disconnect(const LXAccount& account, uint32_t timeout_ms) {
+    uint64_t now_ms = unix_ms();
+
+    std::lock_guard lock(switches_mutex_);
+    if (timeout_ms == 0) {
+        dead_man_switches_.erase(account.hash());
+    } else {
+        dead_man_switches_[account.hash()] = DeadManSwitch{account, timeout_ms, now_ms + timeout_ms};
+    }
+    update_next_deadline();
+    return errors::OK;
+}
+
+int32_t LXBook::heartbeat(const LXAccount& account) {
+    uint64_t now_ms = unix_ms();
+
+    std::lock_guard lock(switches_mutex_);
+    auto it = dead_man_switches_.find(account.hash());
+    if (it == dead_man_switches_.end() || now_ms >= it->second.deadline_ms) {
+        // A lapsed switch trips on the next check; a late heartbeat can't save it
+        return errors::NOT_ARMED;
+    }
+    it->second.deadline_ms = now_ms + it->second.timeout_ms;
+    update_next_deadline();
+    return errors::OK;
+}
+
+size_t LXBook::check_heartbeats(uint64_t now_ms) {
+    std::vector<LXAccount> tripped;
+    {
+        std::lock_guard lock(switches_mutex_);
+        for (auto it = dead_man_switches_.begin(); it != dead_man_switches_.end();) {
+            if (it->second.deadline_ms <= now_ms) {
+                tripped.push_back(it->second.account);
+                it = dead_man_switches_.erase(it);
+            } else {
+                ++it;
+            }
+        }
+        update_next_deadline();
+    }
+
+    size_t cancelled = 0;
+    for (const auto& account : tripped) {
+        for (const auto& state : get_open_orders(account, 0)) {
+            if (cancel_order(account, state.market_id, state.oid) == errors::OK) {
+                ++cancelled;
+            }
+        }
+    }
+    return cancelled;
+}
+
+void LXBook::update_next_deadline() {
+    uint64_t next = UINT64_MAX;
+    for (const auto& [hash, sw] : dead_man_switches_) {
+        next = std::min(next, sw.deadline_ms);
+    }
+    next_deadline_ms_.store(next);
+}
+
 LXPlaceResult LXBook::amend_order(const LXAccount& sender, uint32_t market_id,
                                    uint64_t oid, I128 new_size_x18, I128 new_price_x18) {
+    auto journal_lock = journal_.order();
+    LXPlaceResult result = try_amend_order(sender, market_id, oid, new_size_x18, new_price_x18);
+    journal_.record(JournalOp::BOOK_AMEND_ORDER, sender, market_id, oid, new_size_x18,
+                    new_price_x18, result);
+    return result;
+}
+
+LXPlaceResult LXBook::try_amend_order(const LXAccount& sender, uint32_t market_id,
+                                      uint64_t oid, I128 new_size_x18, I128 new_price_x18) {
     LXPlaceResult result{};
+    result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
 
     uint64_t symbol_id = get_symbol_id(market_id);
     if (symbol_id == 0) {
-        result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::MARKET_NOT_FOUND;
+        return result;
+    }
+
+    // Only the owner may amend, and only while the order is live
+    {
+        std::shared_lock orders_lock(orders_mutex_);
+        auto account_it = account_orders_.find(sender.hash());
+        if (account_it == account_orders_.end()) {
+            result.reason = errors::ORDER_NOT_FOUND;
+            return result;
+        }
+        auto order_it = account_it->second.orders.find(oid);
+        if (order_it == account_it->second.orders.end() ||
+            order_it->second.market_id != market_id ||
+            (order_it->second.status != BookOrderStatus::NEW &&
+             order_it->second.status != BookOrderStatus::OPEN)) {
+            result.reason = errors::ORDER_NOT_FOUND;
+            return result;
+        }
+    }
+
+    if (new_size_x18 <= 0 || new_price_x18 <= 0) {
+        result.reason = errors::ORDER_REJECTED;
         return result;
     }
 
@@ -334,7 +817,7 @@ LXPlaceResult LXBook::amend_order(const LXAccount& sender, uint32_t market_id,
     OrderResult modify_result = engine_.modify_order(symbol_id, oid, new_price, new_qty);
 
     if (!modify_result.success) {
-        result.status = static_cast<uint8_t>(BookOrderStatus::REJECTED);
+        result.reason = errors::ORDER_REJECTED;
         return result;
     }
 
@@ -352,6 +835,7 @@ LXPlaceResult LXBook::amend_order(const LXAccount& sender, uint32_t market_id,
         );
     });
 
+    publish_l1(market_id);
     return result;
 }
 
@@ -424,6 +908,49 @@ std::vector<BookOrderState> LXBook::get_all_orders(const LXAccount& account) con
     return orders;
 }
 
+std::optional<LXAccount> LXBook::get_account(uint64_t account_id) const {
+    std::shared_lock lock(accounts_mutex_);
+    auto it = accounts_.find(account_id);
+    if (it == accounts_.end()) return std::nullopt;
+    return it->second;
+}
+
+std::vector<BookOrderState> LXBook::get_open_orders(const LXAccount& account, uint32_t market_id) const {
+    std::vector<BookOrderState> orders;
+
+    std::shared_lock lock(orders_mutex_);
+    auto account_it = account_orders_.find(account.hash());
+    if (account_it == account_orders_.end()) {
+        return orders;
+    }
+
+    for (const auto& [oid, state] : account_it->second.orders) {
+        if ((state.status == BookOrderStatus::NEW || state.status == BookOrderStatus::OPEN) &&
+            (market_id == 0 || state.market_id == market_id)) {
+            orders.push_back(state);
+        }
+    }
+    std::sort(orders.begin(), orders.end(),
+              [](const BookOrderState& a, const BookOrderState& b) { return a.oid < b.oid; });
+
+    return orders;
+}
+
+bool LXBook::has_open_orders(const LXAccount& account) const {
+    std::shared_lock lock(orders_mutex_);
+    auto account_it = account_orders_.find(account.hash());
+    if (account_it == account_orders_.end()) {
+        return false;
+    }
+
+    for (const auto& [oid, state] : account_it->second.orders) {
+        if (state.status == BookOrderStatus::NEW || state.status == BookOrderStatus::OPEN) {
+            return true;
+        }
+    }
+    return false;
+}
+
 // =============================================================================
 // Market Data
 // =============================================================================
@@ -444,6 +971,17 @@ LXL1 LXBook::get_l1(uint32_t market_id) const {
         l1.best_ask_px_x18 = static_cast<I128>(*best_ask) * X18_ONE / 100000000LL;
     }
 
+    // Depth reports quantities as doubles; round back to engine units
+    auto top = engine_.get_depth(symbol_id, 1);
+    if (!top.bids.empty()) {
+        l1.best_bid_sz_x18 = static_cast<I128>(std::llround(top.bids[0].quantity * 100000000.0)) *
+                             X18_ONE / 100000000LL;
+    }
+    if (!top.asks.empty()) {
+        l1.best_ask_sz_x18 = static_cast<I128>(std::llround(top.asks[0].quantity * 100000000.0)) *
+                             X18_ONE / 100000000LL;
+    }
+
     // Get last trade
     std::shared_lock lock(trades_mutex_);
     auto last_it = last_trades_.find(market_id);
@@ -454,6 +992,51 @@ LXL1 LXBook::get_l1(uint32_t market_id) const {
     return l1;
 }
 
+int32_t LXBook::subscribe_l1(uint32_t market_id, L1Callback callback, uint64_t& id) {
+    if (!market_exists(market_id)) {
+        return errors::MARKET_NOT_FOUND;
+    }
+    LXL1 current = get_l1(market_id);
+
+    std::lock_guard lock(l1_mutex_);
+    id = next_l1_subscription_id_++;
+    l1_subscriptions_[id] = L1Subscription{market_id, std::move(callback)};
+    published_l1_.try_emplace(market_id, current);
+    return errors::OK;
+}
+
+void LXBook::unsubscribe_l1(uint64_t id) {
+    std::lock_guard lock(l1_mutex_);
+    l1_subscriptions_.erase(id);
+}
+
+void LXBook::publish_l1(uint32_t market_id) {
+    {
+        std::lock_guard lock(l1_mutex_);
+        if (l1_subscriptions_.empty()) return;
+    }
+    LXL1 l1 = get_l1(market_id);
+
+    // Callbacks run outside the lock so they may unsubscribe
+    std::vector<L1Callback> callbacks;
+    {
+        std::lock_guard lock(l1_mutex_);
+        auto [it, inserted] = published_l1_.try_emplace(market_id, l1);
+        if (!inserted) {
+            if (same_l1(it->second, l1)) return;
+            it->second = l1;
+        }
+        for (const auto& [id, sub] : l1_subscriptions_) {
+            if (sub.market_id == market_id) {
+                callbacks.push_back(sub.callback);
+            }
+        }
+    }
+    for (const auto& callback : callbacks) {
+        callback(market_id, l1);
+    }
+}
+
 MarketDepth LXBook::get_depth(uint32_t market_id, size_t levels) const {
     uint64_t symbol_id = get_symbol_id(market_id);
     if (symbol_id == 0) return MarketDepth{};
@@ -461,6 +1044,23 @@ MarketDepth LXBook::get_depth(uint32_t market_id, size_t levels) const {
     return engine_.get_depth(symbol_id, levels);
 }
 
+std::optional<LXBook::FillQuote> LXBook::quote_fill(uint32_t market_id, bool is_buy,
+                                                    I128 size_x18, I128 limit_px_x18) const {
+    uint64_t symbol_id = get_symbol_id(market_id);
+    if (symbol_id == 0) return std::nullopt;
+
+    Quantity quantity = static_cast<Quantity>(x18::to_double(size_x18) * 100000000.0);
+    Price limit = static_cast<Price>(x18::to_double(limit_px_x18) * 100000000.0);
+    auto quote = engine_.quote_market_order(symbol_id, is_buy ? Side::Buy : Side::Sell,
+                                            quantity, limit);
+    if (!quote) return std::nullopt;
+
+    FillQuote fill;
+    fill.avg_px_x18 = static_cast<I128>(quote->avg_price) * X18_ONE / 100000000LL;
+    fill.filled_x18 = static_cast<I128>(quote->filled) * X18_ONE / 100000000LL;
+    return fill;
+}
+
 std::optional<Trade> LXBook::get_last_trade(uint32_t market_id) const {
     std::shared_lock lock(trades_mutex_);
     auto it = last_trades_.find(market_id);
@@ -481,6 +1081,63 @@ std::vector<Trade> LXBook::get_recent_trades(uint32_t market_id, size_t count) c
     return std::vector<Trade>(trades.end() - count, trades.end());
 }
 
+std::optional<Trade> LXBook::bust_trade(uint32_t market_id, uint64_t trade_id) {
+    Trade trade;
+    {
+        std::unique_lock lock(trades_mutex_);
+        auto it = recent_trades_.find(market_id);
+        if (it == recent_trades_.end()) return std::nullopt;
+
+        auto& trades = it->second;
+        auto trade_it = std::find_if(trades.begin(), trades.end(),
+                                     [trade_id](const Trade& t) { return t.id == trade_id; });
+        if (trade_it == trades.end()) return std::nullopt;
+        trade = *trade_it;
+        trades.erase(trade_it);
+
+        if (trades.empty()) {
+            last_trades_.erase(market_id);
+        } else {
+            last_trades_[market_id] = trades.back();
+        }
+    }
+
+    I128 size_x18 = static_cast<I128>(trade.quantity) * X18_ONE / 100000000LL;
+    {
+        std::unique_lock lock(orders_mutex_);
+        auto unwind = [&](uint64_t account_id, uint64_t oid) {
+            auto account_it = account_orders_.find(account_id);
+            if (account_it == account_orders_.end()) return;
+            auto order_it = account_it->second.orders.find(oid);
+            if (order_it == account_it->second.orders.end()) return;
+            auto& state = order_it->second;
+            state.filled_size_x18 -= size_x18;
+            state.remaining_size_x18 += size_x18;
+            if (state.filled_size_x18 <= 0) {
+                state.avg_fill_price_x18 = 0;
+            }
+            // The busted size does not rest again, so an order the trade
+            // filled is left cancelled for that size
+            if (state.status == BookOrderStatus::FILLED) {
+                state.status = BookOrderStatus::CANCELLED;
+            }
+            state.updated_at = unix_ms() * 1000000;
+        };
+        unwind(trade.buyer_account_id, trade.buy_order_id);
+        unwind(trade.seller_account_id, trade.sell_order_id);
+    }
+
+    {
+        std::unique_lock lock(stats_mutex_);
+        auto& counters = market_counters_[market_id];
+        if (counters.trades > 0) counters.trades--;
+        counters.volume_x18 -= size_x18;
+    }
+
+    publish_l1(market_id);
+    return trade;
+}
+
 // =============================================================================
 // HFT Interface
 // =============================================================================
@@ -535,6 +1192,10 @@ void LXBook::set_settlement_callback(SettlementCallback callback) {
     settlement_callback_ = std::move(callback);
 }
 
+void LXBook::set_order_check(OrderCheck check) {
+    order_check_ = std::move(check);
+}
+
 // =============================================================================
 // Statistics
 // =============================================================================
@@ -554,6 +1215,174 @@ LXBook::Stats LXBook::get_stats() const {
     };
 }
 
+std::optional<LXBook::MarketStats> LXBook::get_market_stats(uint32_t market_id) const {
+    uint64_t symbol_id = get_symbol_id(market_id);
+    if (symbol_id == 0) {
+        return std::nullopt;
+    }
+
+    MarketStats stats{};
+    {
+        std::shared_lock lock(stats_mutex_);
+        auto it = market_counters_.find(market_id);
+        if (it != market_counters_.end()) {
+            stats.orders_placed = it->second.orders_placed;
+            stats.orders_cancelled = it->second.orders_cancelled;
+            stats.trades = it->second.trades;
+            stats.volume_x18 = it->second.volume_x18;
+        }
+    }
+
+    if (const OrderBook* book = engine_.get_orderbook(symbol_id)) {
+        stats.open_orders = book->total_orders();
+    }
+    return stats;
+}
+
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXBook::snapshot(SnapshotWriter& out) const {
+    {
+        std::shared_lock lock(markets_mutex_);
+        out.put(static_cast<uint64_t>(markets_.size()));
+        for (const auto& [market_id, config] : markets_) {
+            const OrderBook* book = engine_.get_orderbook(config.symbol_id);
+            out.put(config);
+            out.put(book ? book->next_trade_id() : uint64_t{1});
+            out.put(book ? book->resting_orders() : std::vector<Order>{});
+        }
+    }
+    {
+        std::shared_lock lock(orders_mutex_);
+        out.put(static_cast<uint64_t>(account_orders_.size()));
+        for (const auto& [account_hash, orders] : account_orders_) {
+            out.put(account_hash);
+            out.put(orders.orders);
+            out.put(orders.cloid_to_oid);
+        }
+    }
+    {
+        std::shared_lock lock(accounts_mutex_);
+        out.put(accounts_);
+    }
+    {
+        std::shared_lock lock(trades_mutex_);
+        out.put(last_trades_);
+        out.put(recent_trades_);
+    }
+    {
+        std::shared_lock lock(triggers_mutex_);
+        out.put(pending_triggers_);
+    }
+    {
+        std::lock_guard lock(switches_mutex_);
+        out.put(dead_man_switches_);
+    }
+    {
+        std::shared_lock lock(stats_mutex_);
+        out.put(market_counters_);
+    }
+    out.put(total_orders_placed_.load(std::memory_order_relaxed));
+    out.put(total_orders_filled_.load(std::memory_order_relaxed));
+}
+
+bool LXBook::restore(SnapshotReader& in) {
+    std::unique_lock markets_lock(markets_mutex_);
+    if (!markets_.empty()) return false;
+
+    // Restored orders keep their IDs, so new ones must start above them
+    uint64_t max_oid = 0;
+
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        BookMarketConfig config{};
+        uint64_t next_trade_id = 0;
+        std::vector<Order> resting;
+        if (!in.get(config) || !in.get(next_trade_id) || !in.get(resting) ||
+            markets_.count(config.market_id) != 0 || !engine_.add_symbol(config.symbol_id)) {
+            return false;
+        }
+        OrderBook* book = engine_.get_orderbook(config.symbol_id);
+        book->set_stp_mode(static_cast<STPMode>(config.stp_mode));
+        book->set_next_trade_id(next_trade_id);
+        for (const auto& order : resting) {
+            if (!book->restore_order(order)) return false;
+            max_oid = std::max(max_oid, order.id);
+        }
+        markets_[config.market_id] = config;
+        market_to_symbol_[config.market_id] = config.symbol_id;
+    }
+    markets_lock.unlock();
+
+    std::unordered_map<uint64_t, AccountOrders> account_orders;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint64_t account_hash = 0;
+        AccountOrders orders;
+        if (!in.get(account_hash) || !in.get(orders.orders) || !in.get(orders.cloid_to_oid)) {
+            return false;
+        }
+        for (const auto& [oid, state] : orders.orders) {
+            max_oid = std::max(max_oid, oid);
+        }
+        account_orders.emplace(account_hash, std::move(orders));
+    }
+
+    std::unordered_map<uint64_t, LXAccount> accounts;
+    std::unordered_map<uint32_t, Trade> last_trades;
+    std::unordered_map<uint32_t, std::vector<Trade>> recent_trades;
+    std::unordered_map<uint32_t, std::vector<PendingTrigger>> pending_triggers;
+    std::unordered_map<uint64_t, DeadManSwitch> dead_man_switches;
+    std::unordered_map<uint32_t, MarketCounters> market_counters;
+    uint64_t total_orders_placed = 0;
+    uint64_t total_orders_filled = 0;
+    if (!in.get(accounts) || !in.get(last_trades) || !in.get(recent_trades) ||
+        !in.get(pending_triggers) || !in.get(dead_man_switches) ||
+        !in.get(market_counters) || !in.get(total_orders_placed) ||
+        !in.get(total_orders_filled)) {
+        return false;
+    }
+    for (const auto& [market_id, triggers] : pending_triggers) {
+        for (const auto& trigger : triggers) {
+            max_oid = std::max(max_oid, trigger.internal.id);
+        }
+    }
+
+    {
+        std::unique_lock lock(orders_mutex_);
+        account_orders_ = std::move(account_orders);
+    }
+    {
+        std::unique_lock lock(accounts_mutex_);
+        accounts_ = std::move(accounts);
+    }
+    {
+        std::unique_lock lock(trades_mutex_);
+        last_trades_ = std::move(last_trades);
+        recent_trades_ = std::move(recent_trades);
+    }
+    {
+        std::unique_lock lock(triggers_mutex_);
+        pending_triggers_ = std::move(pending_triggers);
+    }
+    {
+        std::lock_guard lock(switches_mutex_);
+        dead_man_switches_ = std::move(dead_man_switches);
+        update_next_deadline();
+    }
+    {
+        std::unique_lock lock(stats_mutex_);
+        market_counters_ = std::move(market_counters);
+    }
+    total_orders_placed_.store(total_orders_placed, std::memory_order_relaxed);
+    total_orders_filled_.store(total_orders_filled, std::memory_order_relaxed);
+    OrderIdGenerator::instance().advance_to(max_oid + 1);
+    return true;
+}
+
 // =============================================================================
 // Internal Helpers
 // =============================================================================
@@ -605,12 +1434,12 @@ Order LXBook::convert_to_internal(const LXOrder& order, uint64_t symbol_id,
             internal.tif = TimeInForce::IOC;
             break;
         case TIF::ALO:
-            internal.tif = TimeInForce::GTC; // ALO is GTC with post-only flag
+            internal.tif = TimeInForce::PostOnly;
             break;
     }
 
     internal.status = OrderStatus::New;
-    internal.stp_group = 0;
+    internal.stp_group = order.stp_group;
     internal.timestamp = std::chrono::duration_cast<Timestamp>(
         std::chrono::system_clock::now().time_since_epoch()
     );
@@ -650,9 +1479,8 @@ void LXBook::record_trade(uint32_t market_id, const Trade& trade) {
     auto& trades = recent_trades_[market_id];
     trades.push_back(trade);
 
-    // Keep only last 1000 trades
-    if (trades.size() > 1000) {
-        trades.erase(trades.begin(), trades.begin() + (trades.size() - 1000));
+    if (trades.size() > TRADE_HISTORY_SIZE) {
+        trades.erase(trades.begin(), trades.begin() + (trades.size() - TRADE_HISTORY_SIZE));
     }
 }
 
@@ -1,6 +1,7 @@
 #include "lux/engine.hpp"
 #include <stdexcept>
 #include <algorithm>
+#include <chrono>
 
 namespace lux {
 
@@ -60,6 +61,7 @@ void Engine::worker_loop() {
             if (!order_queue_.empty()) {
                 async_order = std::move(order_queue_.front());
                 order_queue_.pop();
+                ++in_flight_;
                 has_order = true;
             }
         }
@@ -67,6 +69,8 @@ void Engine::worker_loop() {
         if (has_order) {
             OrderResult result = process_single_order(async_order.batch_order);
             async_order.promise.set_value(std::move(result));
+            std::lock_guard lock(queue_mutex_);
+            --in_flight_;
         }
     }
 }
@@ -108,7 +112,10 @@ bool Engine::add_symbol(uint64_t symbol_id) {
         return false;  // Symbol already exists
     }
 
-    orderbooks_[symbol_id] = std::make_unique<OrderBook>(symbol_id);
+    auto book = std::make_unique<OrderBook>(symbol_id);
+    book->set_matching(config_.matching, config_.pro_rata_tie_break);
+    book->set_stp_mode(config_.stp_mode);
+    orderbooks_[symbol_id] = std::move(book);
     return true;
 }
 
@@ -144,6 +151,20 @@ std::vector<uint64_t> Engine::symbols() const {
     return result;
 }
 
+size_t Engine::symbol_count() const {
+    std::shared_lock lock(orderbooks_mutex_);
+    return orderbooks_.size();
+}
+
+size_t Engine::copy_symbols(uint64_t* out, size_t max) const {
+    std::shared_lock lock(orderbooks_mutex_);
+    size_t i = 0;
+    for (auto it = orderbooks_.begin(); it != orderbooks_.end() && i < max; ++it) {
+        out[i++] = it->first;
+    }
+    return orderbooks_.size();
+}
+
 OrderResult Engine::place_order(Order order) {
     OrderResult result;
     result.order_id = order.id;
@@ -161,9 +182,18 @@ OrderResult Engine::place_order(Order order) {
     }
 
     try {
+        auto started = std::chrono::steady_clock::now();
         result.trades = book->place_order(std::move(order), trade_listener_);
         result.success = true;
 
+        if (!result.trades.empty()) {
+            auto elapsed = std::chrono::duration_cast<std::chrono::nanoseconds>(
+                std::chrono::steady_clock::now() - started).count();
+            std::lock_guard lock(latency_mutex_);
+            latency_samples_[latency_recorded_ % LATENCY_WINDOW] = static_cast<uint64_t>(elapsed);
+            ++latency_recorded_;
+        }
+
         // Update statistics
         total_orders_placed_.fetch_add(1, std::memory_order_relaxed);
         total_trades_.fetch_add(result.trades.size(), std::memory_order_relaxed);
@@ -180,6 +210,25 @@ OrderResult Engine::place_order(Order order) {
     return result;
 }
 
+std::vector<Order> Engine::expire_orders(Timestamp now) {
+    std::vector<Order> expired;
+    {
+        std::shared_lock lock(orderbooks_mutex_);
+        for (auto& [symbol_id, book] : orderbooks_) {
+            auto orders = book->expire_orders(now);
+            expired.insert(expired.end(), orders.begin(), orders.end());
+        }
+    }
+
+    total_orders_cancelled_.fetch_add(expired.size(), std::memory_order_relaxed);
+    if (trade_listener_) {
+        for (const auto& order : expired) {
+            trade_listener_->on_order_cancelled(order);
+        }
+    }
+    return expired;
+}
+
 CancelResult Engine::cancel_order(uint64_t symbol_id, uint64_t order_id) {
     CancelResult result;
 
@@ -375,6 +424,16 @@ std::optional<Price> Engine::best_ask(uint64_t symbol_id) const {
     return it->second->best_ask();
 }
 
+std::optional<MarketQuote> Engine::quote_market_order(uint64_t symbol_id, Side side,
+                                                      Quantity quantity, Price limit) const {
+    std::shared_lock lock(orderbooks_mutex_);
+    auto it = orderbooks_.find(symbol_id);
+    if (it == orderbooks_.end()) {
+        return std::nullopt;
+    }
+    return it->second->quote_market(side, quantity, limit);
+}
+
 Engine::Stats Engine::get_stats() const {
     return {
         total_orders_placed_.load(std::memory_order_relaxed),
@@ -384,6 +443,41 @@ Engine::Stats Engine::get_stats() const {
     };
 }
 
+Engine::LatencyStats Engine::get_latency_stats() const {
+    std::vector<uint64_t> window;
+    {
+        std::lock_guard lock(latency_mutex_);
+        size_t n = static_cast<size_t>(std::min<uint64_t>(latency_recorded_, LATENCY_WINDOW));
+        window.assign(latency_samples_.begin(), latency_samples_.begin() + n);
+    }
+
+    LatencyStats stats{};
+    stats.samples = window.size();
+    if (window.empty()) {
+        return stats;
+    }
+    std::sort(window.begin(), window.end());
+    auto rank = [&window](uint64_t pct) {
+        size_t idx = (window.size() * pct + 99) / 100;
+        return window[idx - 1];
+    };
+    stats.p50_ns = rank(50);
+    stats.p90_ns = rank(90);
+    stats.p99_ns = rank(99);
+    stats.max_ns = window.back();
+    return stats;
+}
+
+void Engine::reset_latency_stats() {
+    std::lock_guard lock(latency_mutex_);
+    latency_recorded_ = 0;
+}
+
+size_t Engine::pending_orders() const {
+    std::lock_guard lock(queue_mutex_);
+    return order_queue_.size() + in_flight_;
+}
+
 void Engine::set_trade_listener(TradeListener* listener) {
     trade_listener_ = listener;
 }
@@ -15,11 +15,32 @@ namespace lux {
 
 LXFeed::LXFeed(LXOracle& oracle) : oracle_(oracle) {}
 
+namespace {
+
+// Funding for markets without explicit params: 8h interval, 1% cap
+FundingParams default_funding_params() {
+    FundingParams params;
+    params.funding_interval = 28800;
+    params.max_funding_rate_x18 = x18::from_double(0.01);
+    params.interest_rate_x18 = x18::from_double(0.0001); // 0.01%
+    params.premium_fraction_x18 = X18_ONE;
+    params.use_twap_premium = true;
+    return params;
+}
+
+} // namespace
+
 // =============================================================================
 // Configuration
 // =============================================================================
 
 void LXFeed::set_mark_price_config(uint32_t market_id, const MarkPriceConfig& config) {
+    auto journal_lock = journal_.order();
+    try_set_mark_price_config(market_id, config);
+    journal_.record(JournalOp::FEED_SET_MARK_PRICE_CONFIG, market_id, config);
+}
+
+void LXFeed::try_set_mark_price_config(uint32_t market_id, const MarkPriceConfig& config) {
     std::unique_lock lock(config_mutex_);
     mark_configs_[market_id] = config;
 }
@@ -31,11 +52,71 @@ std::optional<MarkPriceConfig> LXFeed::get_mark_price_config(uint32_t market_id)
     return it->second;
 }
 
+int32_t LXFeed::set_mark_smoothing(uint32_t market_id, uint64_t ewma_window,
+                                   uint32_t premium_clamp_bps) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_mark_smoothing(market_id, ewma_window, premium_clamp_bps);
+    journal_.record(JournalOp::FEED_SET_MARK_SMOOTHING, market_id, ewma_window, premium_clamp_bps,
+                    result);
+    return result;
+}
+
+int32_t LXFeed::try_set_mark_smoothing(uint32_t market_id, uint64_t ewma_window,
+                                       uint32_t premium_clamp_bps) {
+    if (!market_exists(market_id)) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    std::unique_lock lock(config_mutex_);
+    auto [it, inserted] = mark_configs_.try_emplace(market_id);
+    if (inserted) {
+        it->second.use_mid_price = true;
+    }
+    it->second.premium_ewma_window = ewma_window;
+    it->second.premium_clamp_bps = premium_clamp_bps;
+    return errors::OK;
+}
+
 void LXFeed::set_funding_params(uint32_t market_id, const FundingParams& params) {
+    auto journal_lock = journal_.order();
+    try_set_funding_params(market_id, params);
+    journal_.record(JournalOp::FEED_SET_FUNDING_PARAMS, market_id, params);
+}
+
+void LXFeed::try_set_funding_params(uint32_t market_id, const FundingParams& params) {
     std::unique_lock lock(config_mutex_);
     funding_params_[market_id] = params;
 }
 
+int32_t LXFeed::set_funding_config(uint32_t market_id, uint64_t interval,
+                                   uint32_t clamp_bps) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_funding_config(market_id, interval, clamp_bps);
+    journal_.record(JournalOp::FEED_SET_FUNDING_CONFIG, market_id, interval, clamp_bps, result);
+    return result;
+}
+
+int32_t LXFeed::try_set_funding_config(uint32_t market_id, uint64_t interval,
+                                       uint32_t clamp_bps) {
+    if (!market_exists(market_id)) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    std::unique_lock lock(config_mutex_);
+    auto it = funding_params_.try_emplace(market_id, default_funding_params()).first;
+    it->second.funding_interval = interval;
+    it->second.max_funding_rate_x18 = X18_ONE * static_cast<I128>(clamp_bps) / 10000;
+    lock.unlock();
+
+    // Move the pending funding time onto the new cadence
+    std::unique_lock price_lock(price_mutex_);
+    MarketPriceState* state = get_price_state(market_id);
+    if (state && state->last_funding_calc_time != 0) {
+        state->next_funding_time = state->last_funding_calc_time + interval;
+    }
+    return errors::OK;
+}
+
 std::optional<FundingParams> LXFeed::get_funding_params(uint32_t market_id) const {
     std::shared_lock lock(config_mutex_);
     auto it = funding_params_.find(market_id);
@@ -56,8 +137,15 @@ std::optional<I128> LXFeed::index_price(uint32_t market_id) const {
     std::shared_lock lock(market_mutex_);
     auto it = market_assets_.find(market_id);
     if (it == market_assets_.end()) return std::nullopt;
+    uint64_t asset_id = it->second;
+    lock.unlock();
 
-    return oracle_.index_price(it->second);
+    auto price = oracle_.index_price(asset_id);
+    if (!price) {
+        events_.emit(SystemEventKind::ORACLE_PRICE_STALE, Severity::WARNING, market_id, asset_id,
+                     {}, errors::PRICE_STALE);
+    }
+    return price;
 }
 
 std::optional<std::pair<I128, uint64_t>> LXFeed::index_price_with_time(uint32_t market_id) const {
@@ -96,7 +184,10 @@ std::optional<LXMarkPrice> LXFeed::get_mark_price(uint32_t market_id) const {
     if (config_it != mark_configs_.end()) {
         const MarkPriceConfig& config = config_it->second;
         // Clamp premium
-        if (premium > config.max_premium_x18) {
+        if (config.premium_clamp_bps > 0) {
+            I128 bound = *index * static_cast<I128>(config.premium_clamp_bps) / 10000;
+            premium = std::clamp(premium, -bound, bound);
+        } else if (premium > config.max_premium_x18) {
             premium = config.max_premium_x18;
         } else if (premium < config.min_premium_x18) {
             premium = config.min_premium_x18;
@@ -125,6 +216,7 @@ std::optional<I128> LXFeed::last_price(uint32_t market_id) const {
 }
 
 void LXFeed::update_last_price(uint32_t market_id, I128 price_x18, uint64_t timestamp) {
+    auto journal_lock = journal_.order();
     if (timestamp == 0) {
         timestamp = current_timestamp();
     }
@@ -140,6 +232,10 @@ void LXFeed::update_last_price(uint32_t market_id, I128 price_x18, uint64_t time
     state->last_price_time = timestamp;
 
     total_price_updates_.fetch_add(1, std::memory_order_relaxed);
+    lock.unlock();
+
+    sample_premium(market_id, true);
+    journal_.record(JournalOp::FEED_UPDATE_LAST_PRICE, market_id, price_x18, timestamp);
 }
 
 // =============================================================================
@@ -159,6 +255,7 @@ std::optional<I128> LXFeed::mid_price(uint32_t market_id) const {
 }
 
 void LXFeed::update_bbo(uint32_t market_id, I128 best_bid_x18, I128 best_ask_x18) {
+    auto journal_lock = journal_.order();
     std::unique_lock lock(price_mutex_);
     MarketPriceState* state = get_price_state(market_id);
     if (!state) {
@@ -168,6 +265,10 @@ void LXFeed::update_bbo(uint32_t market_id, I128 best_bid_x18, I128 best_ask_x18
 
     state->best_bid_x18 = best_bid_x18;
     state->best_ask_x18 = best_ask_x18;
+    lock.unlock();
+
+    sample_premium(market_id, false);
+    journal_.record(JournalOp::FEED_UPDATE_BBO, market_id, best_bid_x18, best_ask_x18);
 }
 
 // =============================================================================
@@ -198,19 +299,19 @@ std::optional<I128> LXFeed::get_price(uint32_t market_id, PriceType type) const
 std::optional<LXFeed::AllPrices> LXFeed::get_all_prices(uint32_t market_id) const {
     AllPrices prices{};
 
-    auto idx = index_price(market_id);
-    auto mrk = mark_price(market_id);
-    auto lst = last_price(market_id);
-    auto mid = mid_price(market_id);
+    auto funding = get_funding_rate(market_id);
+    if (!funding) return std::nullopt;
+    prices.funding_rate_x18 = funding->rate_x18;
+    prices.next_funding_time = funding->next_funding_time;
 
-    if (!idx && !mrk && !lst && !mid) {
-        return std::nullopt;
+    // The mark needs the index, so it carries both and the premium
+    if (auto mark = get_mark_price(market_id)) {
+        prices.index_x18 = mark->index_px_x18;
+        prices.mark_x18 = mark->mark_px_x18;
+        prices.premium_x18 = mark->premium_x18;
     }
-
-    prices.index_x18 = idx.value_or(0);
-    prices.mark_x18 = mrk.value_or(0);
-    prices.last_x18 = lst.value_or(0);
-    prices.mid_x18 = mid.value_or(0);
+    prices.last_x18 = last_price(market_id).value_or(0);
+    prices.mid_x18 = mid_price(market_id).value_or(0);
     prices.timestamp = current_timestamp();
 
     return prices;
@@ -298,6 +399,21 @@ void LXFeed::record_premium(uint32_t market_id, I128 premium_x18, uint64_t times
     state->premium_ewma_x18 = calculate_ewma(state->premium_history, window, timestamp);
 }
 
+void LXFeed::sample_premium(uint32_t market_id, bool from_trade) {
+    std::shared_lock config_lock(config_mutex_);
+    auto config_it = mark_configs_.find(market_id);
+    bool use_mid = config_it == mark_configs_.end() || config_it->second.use_mid_price;
+    config_lock.unlock();
+
+    if (use_mid == from_trade) return;
+
+    auto index = index_price(market_id);
+    auto px = from_trade ? last_price(market_id) : mid_price(market_id);
+    if (!index || !px) return;
+
+    record_premium(market_id, *px - *index);
+}
+
 // =============================================================================
 // Funding Rate
 // =============================================================================
@@ -343,16 +459,8 @@ std::optional<I128> LXFeed::predicted_funding_rate(uint32_t market_id) const {
 
     std::shared_lock config_lock(config_mutex_);
     auto params_it = funding_params_.find(market_id);
-    FundingParams params;
-    if (params_it != funding_params_.end()) {
-        params = params_it->second;
-    } else {
-        params.funding_interval = 28800;
-        params.max_funding_rate_x18 = x18::from_double(0.01);
-        params.interest_rate_x18 = x18::from_double(0.0001); // 0.01%
-        params.premium_fraction_x18 = X18_ONE;
-        params.use_twap_premium = true;
-    }
+    FundingParams params = params_it != funding_params_.end()
+        ? params_it->second : default_funding_params();
     config_lock.unlock();
 
     return compute_funding_rate(*state, params);
@@ -368,25 +476,39 @@ void LXFeed::calculate_funding_rate(uint32_t market_id) {
 
     std::shared_lock config_lock(config_mutex_);
     auto params_it = funding_params_.find(market_id);
-    FundingParams params;
-    if (params_it != funding_params_.end()) {
-        params = params_it->second;
-    } else {
-        params.funding_interval = 28800;
-        params.max_funding_rate_x18 = x18::from_double(0.01);
-        params.interest_rate_x18 = x18::from_double(0.0001);
-        params.premium_fraction_x18 = X18_ONE;
-        params.use_twap_premium = true;
-    }
+    FundingParams params = params_it != funding_params_.end()
+        ? params_it->second : default_funding_params();
     config_lock.unlock();
 
     state->current_funding_rate_x18 = compute_funding_rate(*state, params);
     state->last_funding_calc_time = current_timestamp();
     state->next_funding_time = state->last_funding_calc_time + params.funding_interval;
 
+    // Keep the most recent funding samples
+    constexpr size_t MAX_FUNDING_HISTORY = 1024;
+    state->funding_history.emplace_back(state->last_funding_calc_time, state->current_funding_rate_x18);
+    if (state->funding_history.size() > MAX_FUNDING_HISTORY) {
+        state->funding_history.erase(state->funding_history.begin());
+    }
+
     funding_calculations_.fetch_add(1, std::memory_order_relaxed);
 }
 
+std::vector<std::pair<uint64_t, I128>> LXFeed::funding_history(uint32_t market_id, size_t limit) const {
+    std::vector<std::pair<uint64_t, I128>> result;
+
+    std::shared_lock lock(price_mutex_);
+    const MarketPriceState* state = get_price_state(market_id);
+    if (!state) return result;
+
+    size_t n = std::min(limit, state->funding_history.size());
+    result.reserve(n);
+    for (auto it = state->funding_history.rbegin(); result.size() < n; ++it) {
+        result.push_back(*it);
+    }
+    return result;
+}
+
 // =============================================================================
 // Trigger Price
 // =============================================================================
@@ -457,6 +579,13 @@ std::optional<I128> LXFeed::liquidation_price(uint32_t market_id, const LXPositi
 // =============================================================================
 
 int32_t LXFeed::register_market(uint32_t market_id, uint64_t asset_id) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_register_market(market_id, asset_id);
+    journal_.record(JournalOp::FEED_REGISTER_MARKET, market_id, asset_id, result);
+    return result;
+}
+
+int32_t LXFeed::try_register_market(uint32_t market_id, uint64_t asset_id) {
     std::unique_lock lock(market_mutex_);
 
     if (market_assets_.find(market_id) != market_assets_.end()) {
@@ -503,6 +632,92 @@ LXFeed::Stats LXFeed::get_stats() const {
     };
 }
 
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXFeed::snapshot(SnapshotWriter& out) const {
+    {
+        std::shared_lock lock(market_mutex_);
+        out.put(market_assets_);
+    }
+    {
+        std::shared_lock lock(config_mutex_);
+        out.put(mark_configs_);
+        out.put(funding_params_);
+        out.put(trigger_rules_);
+    }
+    {
+        std::shared_lock lock(price_mutex_);
+        out.put(static_cast<uint64_t>(price_states_.size()));
+        for (const auto& [market_id, state] : price_states_) {
+            out.put(market_id);
+            out.put(state.last_price_x18);
+            out.put(state.best_bid_x18);
+            out.put(state.best_ask_x18);
+            out.put(state.premium_ewma_x18);
+            out.put(state.current_funding_rate_x18);
+            out.put(state.last_price_time);
+            out.put(state.last_funding_calc_time);
+            out.put(state.next_funding_time);
+            out.put(state.premium_history);
+            out.put(state.funding_history);
+        }
+    }
+    out.put(total_price_updates_.load(std::memory_order_relaxed));
+    out.put(funding_calculations_.load(std::memory_order_relaxed));
+}
+
+bool LXFeed::restore(SnapshotReader& in) {
+    std::unordered_map<uint32_t, uint64_t> market_assets;
+    std::unordered_map<uint32_t, MarkPriceConfig> mark_configs;
+    std::unordered_map<uint32_t, FundingParams> funding_params;
+    std::unordered_map<uint32_t, std::vector<TriggerRule>> trigger_rules;
+    if (!in.get(market_assets) || !in.get(mark_configs) || !in.get(funding_params) ||
+        !in.get(trigger_rules)) {
+        return false;
+    }
+
+    std::unordered_map<uint32_t, MarketPriceState> price_states;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint32_t market_id = 0;
+        MarketPriceState state{};
+        if (!in.get(market_id) || !in.get(state.last_price_x18) ||
+            !in.get(state.best_bid_x18) || !in.get(state.best_ask_x18) ||
+            !in.get(state.premium_ewma_x18) || !in.get(state.current_funding_rate_x18) ||
+            !in.get(state.last_price_time) || !in.get(state.last_funding_calc_time) ||
+            !in.get(state.next_funding_time) || !in.get(state.premium_history) ||
+            !in.get(state.funding_history)) {
+            return false;
+        }
+        price_states.emplace(market_id, std::move(state));
+    }
+
+    uint64_t total_price_updates = 0;
+    uint64_t funding_calculations = 0;
+    if (!in.get(total_price_updates) || !in.get(funding_calculations)) return false;
+
+    {
+        std::unique_lock lock(market_mutex_);
+        market_assets_ = std::move(market_assets);
+    }
+    {
+        std::unique_lock lock(config_mutex_);
+        mark_configs_ = std::move(mark_configs);
+        funding_params_ = std::move(funding_params);
+        trigger_rules_ = std::move(trigger_rules);
+    }
+    {
+        std::unique_lock lock(price_mutex_);
+        price_states_ = std::move(price_states);
+    }
+    total_price_updates_.store(total_price_updates, std::memory_order_relaxed);
+    funding_calculations_.store(funding_calculations, std::memory_order_relaxed);
+    return true;
+}
+
 // =============================================================================
 // Internal Helpers
 // =============================================================================
@@ -520,6 +735,7 @@ const LXFeed::MarketPriceState* LXFeed::get_price_state(uint32_t market_id) cons
 I128 LXFeed::calculate_ewma(const std::vector<std::pair<uint64_t, I128>>& history,
                              uint64_t window_seconds, uint64_t current_time) const {
     if (history.empty()) return 0;
+    if (window_seconds == 0) return history.back().second;
 
     // EWMA with decay factor based on time
     double decay = 2.0 / (static_cast<double>(window_seconds) + 1.0);
@@ -0,0 +1,262 @@
+// =============================================================================
+// lend.cpp - LXLend Lending Pool Implementation
+// =============================================================================
+
+#include "lux/lend.hpp"
+#include <algorithm>
+
+namespace lux {
+
+namespace {
+
+uint64_t currency_hash(const Currency& c) {
+    uint64_t h = 0;
+    for (uint8_t b : c.addr) h = h * 31 + b;
+    return h;
+}
+
+} // namespace
+
+// =============================================================================
+// Constructor
+// =============================================================================
+
+LXLend::LXLend(LXVault& vault) : vault_(vault) {}
+
+LXLendLiquidity LXLend::liquidity_of(const AccountState& state) {
+    LXLendLiquidity liquidity{0, 0};
+    for (const auto& [hash, amount] : state.supplied) {
+        liquidity.collateral_value_x18 += amount;
+    }
+    for (const auto& [hash, amount] : state.borrowed) {
+        liquidity.borrow_value_x18 += amount;
+    }
+    return liquidity;
+}
+
+// Scaled in bps rather than with x18::mul, whose product overflows for
+// collateral above a few hundred units
+I128 LXLend::borrow_limit(I128 collateral_value_x18) {
+    return collateral_value_x18 * COLLATERAL_FACTOR_BPS / 10000;
+}
+
+// =============================================================================
+// Supply / Withdraw
+// =============================================================================
+
+int32_t LXLend::supply(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_supply(account, token, amount_x18);
+    journal_.record(JournalOp::LEND_SUPPLY, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXLend::try_supply(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    if (!token.is_native()) {
+        return errors::INVALID_CURRENCY;
+    }
+    if (amount_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    if (int32_t rc = vault_.try_withdraw(account, token, amount_x18); rc != errors::OK) {
+        return rc;
+    }
+
+    uint64_t hash = currency_hash(token);
+    accounts_[account.hash()].supplied[hash] += amount_x18;
+    reserves_[hash].total_supplied_x18 += amount_x18;
+    return errors::OK;
+}
+
+int32_t LXLend::withdraw(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_withdraw(account, token, amount_x18);
+    journal_.record(JournalOp::LEND_WITHDRAW, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXLend::try_withdraw(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    if (!token.is_native()) {
+        return errors::INVALID_CURRENCY;
+    }
+    if (amount_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    auto account_it = accounts_.find(account.hash());
+    if (account_it == accounts_.end()) {
+        return errors::INSUFFICIENT_BALANCE;
+    }
+    AccountState& state = account_it->second;
+
+    uint64_t hash = currency_hash(token);
+    auto supplied_it = state.supplied.find(hash);
+    Reserve& reserve = reserves_[hash];
+    if (supplied_it == state.supplied.end() || supplied_it->second < amount_x18 ||
+        reserve.total_supplied_x18 - reserve.total_borrowed_x18 < amount_x18) {
+        return errors::INSUFFICIENT_BALANCE;
+    }
+
+    LXLendLiquidity liquidity = liquidity_of(state);
+    if (liquidity.borrow_value_x18 > borrow_limit(liquidity.collateral_value_x18 - amount_x18)) {
+        return errors::INSUFFICIENT_MARGIN;
+    }
+
+    if (int32_t rc = vault_.try_deposit(account, token, amount_x18); rc != errors::OK) {
+        return rc;
+    }
+    supplied_it->second -= amount_x18;
+    if (supplied_it->second == 0) {
+        state.supplied.erase(supplied_it);
+    }
+    reserve.total_supplied_x18 -= amount_x18;
+    return errors::OK;
+}
+
+// =============================================================================
+// Borrow / Repay
+// =============================================================================
+
+int32_t LXLend::borrow(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_borrow(account, token, amount_x18);
+    journal_.record(JournalOp::LEND_BORROW, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXLend::try_borrow(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    if (!token.is_native()) {
+        return errors::INVALID_CURRENCY;
+    }
+    if (amount_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    uint64_t hash = currency_hash(token);
+    Reserve& reserve = reserves_[hash];
+    if (reserve.total_supplied_x18 - reserve.total_borrowed_x18 < amount_x18) {
+        return errors::INSUFFICIENT_LIQUIDITY;
+    }
+
+    AccountState& state = accounts_[account.hash()];
+    LXLendLiquidity liquidity = liquidity_of(state);
+    if (liquidity.borrow_value_x18 + amount_x18 > borrow_limit(liquidity.collateral_value_x18)) {
+        return errors::INSUFFICIENT_MARGIN;
+    }
+
+    if (int32_t rc = vault_.try_deposit(account, token, amount_x18); rc != errors::OK) {
+        return rc;
+    }
+    state.borrowed[hash] += amount_x18;
+    reserve.total_borrowed_x18 += amount_x18;
+    return errors::OK;
+}
+
+int32_t LXLend::repay(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_repay(account, token, amount_x18);
+    journal_.record(JournalOp::LEND_REPAY, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXLend::try_repay(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    if (!token.is_native()) {
+        return errors::INVALID_CURRENCY;
+    }
+    if (amount_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    auto account_it = accounts_.find(account.hash());
+    if (account_it == accounts_.end()) {
+        return errors::POSITION_NOT_FOUND;
+    }
+    AccountState& state = account_it->second;
+
+    uint64_t hash = currency_hash(token);
+    auto borrowed_it = state.borrowed.find(hash);
+    if (borrowed_it == state.borrowed.end()) {
+        return errors::POSITION_NOT_FOUND;
+    }
+
+    I128 payment = std::min(amount_x18, borrowed_it->second);
+    if (int32_t rc = vault_.try_withdraw(account, token, payment); rc != errors::OK) {
+        return rc;
+    }
+    borrowed_it->second -= payment;
+    if (borrowed_it->second == 0) {
+        state.borrowed.erase(borrowed_it);
+    }
+    reserves_[hash].total_borrowed_x18 -= payment;
+    return errors::OK;
+}
+
+// =============================================================================
+// Queries
+// =============================================================================
+
+LXLendLiquidity LXLend::get_account_liquidity(const LXAccount& account) const {
+    std::shared_lock lock(mutex_);
+    auto it = accounts_.find(account.hash());
+    if (it == accounts_.end()) return LXLendLiquidity{0, 0};
+    return liquidity_of(it->second);
+}
+
+I128 LXLend::get_supplied(const LXAccount& account, const Currency& token) const {
+    std::shared_lock lock(mutex_);
+    auto it = accounts_.find(account.hash());
+    if (it == accounts_.end()) return 0;
+    auto supplied_it = it->second.supplied.find(currency_hash(token));
+    return supplied_it != it->second.supplied.end() ? supplied_it->second : 0;
+}
+
+I128 LXLend::get_borrowed(const LXAccount& account, const Currency& token) const {
+    std::shared_lock lock(mutex_);
+    auto it = accounts_.find(account.hash());
+    if (it == accounts_.end()) return 0;
+    auto borrowed_it = it->second.borrowed.find(currency_hash(token));
+    return borrowed_it != it->second.borrowed.end() ? borrowed_it->second : 0;
+}
+
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXLend::snapshot(SnapshotWriter& out) const {
+    std::shared_lock lock(mutex_);
+    out.put(static_cast<uint64_t>(accounts_.size()));
+    for (const auto& [account_hash, state] : accounts_) {
+        out.put(account_hash);
+        out.put(state.supplied);
+        out.put(state.borrowed);
+    }
+    out.put(reserves_);
+}
+
+bool LXLend::restore(SnapshotReader& in) {
+    std::unordered_map<uint64_t, AccountState> accounts;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint64_t account_hash = 0;
+        AccountState state;
+        if (!in.get(account_hash) || !in.get(state.supplied) || !in.get(state.borrowed)) {
+            return false;
+        }
+        accounts.emplace(account_hash, std::move(state));
+    }
+    std::unordered_map<uint64_t, Reserve> reserves;
+    if (!in.get(reserves)) return false;
+
+    std::unique_lock lock(mutex_);
+    accounts_ = std::move(accounts);
+    reserves_ = std::move(reserves);
+    return true;
+}
+
+} // namespace lux
@@ -0,0 +1,146 @@
+// =============================================================================
+// liquid.cpp - LXLiquid Self-Repaying Loans Implementation
+// =============================================================================
+
+#include "lux/liquid.hpp"
+#include <algorithm>
+#include <chrono>
+
+namespace lux {
+
+namespace {
+
+uint64_t unix_ms() {
+    return static_cast<uint64_t>(
+        std::chrono::duration_cast<std::chrono::milliseconds>(
+            std::chrono::system_clock::now().time_since_epoch()
+        ).count()
+    );
+}
+
+} // namespace
+
+// =============================================================================
+// Constructor
+// =============================================================================
+
+LXLiquid::LXLiquid(LXVault& vault) : vault_(vault) {}
+
+// Ratio taken in bps, as x18::div overflows for collateral above a few
+// hundred units
+I128 LXLiquid::health_of(const LXLiquidLoan& loan) {
+    if (loan.principal_x18 <= 0) return 0;
+    return loan.collateral_x18 * 10000 / loan.principal_x18 * (X18_ONE / 10000);
+}
+
+// =============================================================================
+// Open / Apply Yield
+// =============================================================================
+
+int32_t LXLiquid::open(const LXAccount& account, const Currency& collateral, I128 amount_x18,
+                       uint64_t& loan_id) {
+    auto journal_lock = journal_.order();
+    uint64_t id = 0;
+    int32_t result = try_open(account, collateral, amount_x18, id);
+    if (result == errors::OK) {
+        loan_id = id;
+    }
+    journal_.record(JournalOp::LIQUID_OPEN, account, collateral, amount_x18, id, result);
+    return result;
+}
+
+int32_t LXLiquid::try_open(const LXAccount& account, const Currency& collateral, I128 amount_x18,
+                           uint64_t& loan_id) {
+    I128 principal = amount_x18 * MAX_LTV_BPS / 10000;
+    if (amount_x18 <= 0 || principal <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    if (int32_t rc = vault_.try_withdraw(account, collateral, amount_x18); rc != errors::OK) {
+        return rc;
+    }
+    if (int32_t rc = vault_.try_deposit(account, collateral, principal); rc != errors::OK) {
+        vault_.try_deposit(account, collateral, amount_x18);
+        return rc;
+    }
+
+    LXLiquidLoan loan{};
+    loan.id = next_loan_id_++;
+    loan.owner = account;
+    loan.collateral = collateral;
+    loan.collateral_x18 = amount_x18;
+    loan.principal_x18 = principal;
+    loan.opened_at = unix_ms();
+    loans_[loan.id] = loan;
+
+    loan_id = loan.id;
+    return errors::OK;
+}
+
+int32_t LXLiquid::apply_yield(uint64_t loan_id, I128 yield_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_apply_yield(loan_id, yield_x18);
+    journal_.record(JournalOp::LIQUID_APPLY_YIELD, loan_id, yield_x18, result);
+    return result;
+}
+
+int32_t LXLiquid::try_apply_yield(uint64_t loan_id, I128 yield_x18) {
+    if (yield_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(mutex_);
+    auto it = loans_.find(loan_id);
+    if (it == loans_.end() || it->second.principal_x18 == 0) {
+        return errors::POSITION_NOT_FOUND;
+    }
+    LXLiquidLoan& loan = it->second;
+
+    I128 applied = std::min(yield_x18, loan.principal_x18);
+    loan.principal_x18 -= applied;
+    loan.yield_applied_x18 += applied;
+
+    // Paid off: release the collateral along with the yield left over
+    if (loan.principal_x18 == 0) {
+        return vault_.try_deposit(loan.owner, loan.collateral,
+                                  loan.collateral_x18 + yield_x18 - applied);
+    }
+    return errors::OK;
+}
+
+// =============================================================================
+// Queries
+// =============================================================================
+
+std::optional<LXLiquidLoan> LXLiquid::get_loan(uint64_t loan_id) const {
+    std::shared_lock lock(mutex_);
+    auto it = loans_.find(loan_id);
+    if (it == loans_.end()) return std::nullopt;
+    LXLiquidLoan loan = it->second;
+    loan.health_x18 = health_of(loan);
+    return loan;
+}
+
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXLiquid::snapshot(SnapshotWriter& out) const {
+    std::shared_lock lock(mutex_);
+    out.put(loans_);
+    out.put(next_loan_id_);
+}
+
+bool LXLiquid::restore(SnapshotReader& in) {
+    std::unordered_map<uint64_t, LXLiquidLoan> loans;
+    uint64_t next_loan_id = 0;
+    if (!in.get(loans) || !in.get(next_loan_id)) return false;
+
+    std::unique_lock lock(mutex_);
+    loans_ = std::move(loans);
+    next_loan_id_ = next_loan_id;
+    return true;
+}
+
+} // namespace lux
@@ -7,6 +7,20 @@
 #include <cstring>
 #include <algorithm>
 
+// Set by CMake; builds outside it report "unknown"
+#ifndef LUX_GIT_COMMIT
+#define LUX_GIT_COMMIT "unknown"
+#endif
+#ifndef LUX_BUILD_DATE
+#define LUX_BUILD_DATE "unknown"
+#endif
+
+#ifdef NDEBUG
+#define LUX_BUILD_FEATURES "pool,book,vault,oracle,feed"
+#else
+#define LUX_BUILD_FEATURES "pool,book,vault,oracle,feed,debug"
+#endif
+
 namespace lux {
 
 // =============================================================================
@@ -114,6 +128,8 @@ LX::LX()
     , vault_(std::make_unique<LXVault>())
     , book_(std::make_unique<LXBook>())
     , feed_(std::make_unique<LXFeed>(*oracle_))
+    , lend_(std::make_unique<LXLend>(*vault_))
+    , liquid_(std::make_unique<LXLiquid>(*vault_))
     , running_(false)
     , start_time_(0) {
 
@@ -121,12 +137,21 @@ LX::LX()
     book_->set_settlement_callback([this](const std::vector<Trade>& trades) {
         return on_book_trades(trades);
     });
+
+    // Reduce-only orders may never open or flip a position
+    book_->set_order_check([this](const LXAccount& account, const LXOrder& order) {
+        return check_reduce_only(account, order);
+    });
 }
 
 LX::~LX() {
     stop();
 }
 
+LX::BuildInfo LX::build_info() {
+    return {version(), LUX_GIT_COMMIT, LUX_BUILD_DATE, LUX_BUILD_FEATURES};
+}
+
 // =============================================================================
 // Initialization
 // =============================================================================
@@ -136,6 +161,7 @@ void LX::initialize() {
     default_config.engine_config = EngineConfig{};
     default_config.enable_hooks = true;
     default_config.enable_flash_loans = true;
+    default_config.enable_trade_busts = true;
     default_config.funding_interval = 28800; // 8 hours
     default_config.default_maker_fee_x18 = x18::from_double(0.0002); // 0.02%
     default_config.default_taker_fee_x18 = x18::from_double(0.0005); // 0.05%
@@ -146,6 +172,7 @@ void LX::initialize() {
 void LX::initialize(const Config& config) {
     // Engine is already initialized in book constructor
     // Additional configuration can be applied here
+    trade_busts_enabled_.store(config.enable_trade_busts);
 
     // Set default funding params for feed
     FundingParams funding;
@@ -163,6 +190,7 @@ void LX::initialize(const Config& config) {
     mark_config.min_premium_x18 = x18::from_double(-0.05); // -5%
     mark_config.use_mid_price = true;
     mark_config.cap_to_oracle = true;
+    mark_config.premium_clamp_bps = 0;
 
     start_time_ = static_cast<uint64_t>(
         std::chrono::duration_cast<std::chrono::seconds>(
@@ -180,6 +208,7 @@ void LX::start() {
         return; // Already running
     }
 
+    book_->set_draining(false);
     start_time_ = static_cast<uint64_t>(
         std::chrono::duration_cast<std::chrono::seconds>(
             std::chrono::system_clock::now().time_since_epoch()
@@ -202,6 +231,18 @@ bool LX::is_running() const {
     return running_.load(std::memory_order_relaxed);
 }
 
+void LX::begin_drain() {
+    book_->set_draining(true);
+}
+
+bool LX::is_draining() const {
+    return book_->is_draining();
+}
+
+size_t LX::pending_orders() const {
+    return book_->get_engine()->pending_orders();
+}
+
 // =============================================================================
 // Market Creation
 // =============================================================================
@@ -249,6 +290,7 @@ int32_t LX::create_perp_market(uint32_t market_id, uint64_t asset_id,
     mark_config.min_premium_x18 = x18::from_double(-0.05);
     mark_config.use_mid_price = true;
     mark_config.cap_to_oracle = true;
+    mark_config.premium_clamp_bps = 0;
     feed_->set_mark_price_config(market_id, mark_config);
 
     return errors::OK;
@@ -391,6 +433,70 @@ int32_t LX::update_funding(uint32_t market_id) {
     return vault_->accrue_funding(market_id);
 }
 
+int32_t LX::bust_trade(uint32_t market_id, uint64_t trade_id) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_bust_trade(market_id, trade_id);
+    journal_.record(JournalOp::BUST_TRADE, market_id, trade_id, result);
+    return result;
+}
+
+int32_t LX::try_bust_trade(uint32_t market_id, uint64_t trade_id) {
+    if (!trade_busts_enabled_.load()) {
+        return errors::UNAUTHORIZED;
+    }
+
+    auto trade = book_->bust_trade(market_id, trade_id);
+    if (!trade) {
+        return errors::TRADE_NOT_FOUND;
+    }
+
+    // A trade the vault rejected moved no balances, so there is nothing to
+    // reverse
+    std::optional<LXSettlement> settlement;
+    {
+        std::lock_guard lock(settled_mutex_);
+        auto& fills = settled_fills_[market_id];
+        auto it = std::find_if(fills.begin(), fills.end(),
+                               [trade_id](const auto& fill) { return fill.first == trade_id; });
+        if (it != fills.end()) {
+            settlement = it->second;
+            fills.erase(it);
+        }
+    }
+    if (settlement) {
+        vault_->reverse_fill(*settlement);
+    }
+
+    TradeBustCallback callback;
+    {
+        std::lock_guard lock(trade_callback_mutex_);
+        callback = trade_bust_callback_;
+    }
+    if (callback) {
+        callback(*trade);
+    }
+    events_.emit(SystemEventKind::TRADE_BUSTED, Severity::WARNING, market_id);
+    return errors::OK;
+}
+
+void LX::set_trade_bust_callback(TradeBustCallback callback) {
+    std::lock_guard lock(trade_callback_mutex_);
+    trade_bust_callback_ = std::move(callback);
+}
+
+void LX::set_trade_callback(TradeCallback callback) {
+    std::lock_guard lock(trade_callback_mutex_);
+    trade_callback_ = std::move(callback);
+}
+
+void LX::set_system_event_callback(SystemEventCallback callback) {
+    book_->set_event_callback(callback);
+    vault_->set_event_callback(callback);
+    oracle_->set_event_callback(callback);
+    feed_->set_event_callback(callback);
+    events_.set(std::move(callback));
+}
+
 int32_t LX::run_liquidations(uint32_t market_id) {
     // Get mark price for liquidation checks
     auto mark = feed_->mark_price(market_id);
@@ -404,6 +510,802 @@ int32_t LX::run_liquidations(uint32_t market_id) {
     return errors::OK;
 }
 
+std::optional<LX::FeeEstimate> LX::estimate_fees(const LXOrder& order) const {
+    auto config = vault_->get_market_config(order.market_id);
+    if (!config) return std::nullopt;
+
+    bool is_market = order.kind == OrderKind::MARKET ||
+                     order.kind == OrderKind::STOP_MARKET ||
+                     order.kind == OrderKind::TAKE_MARKET;
+    // Fees take the rate times the price first, as size times price
+    // overflows x18::mul once the notional passes ~170
+    FeeEstimate estimate{0, 0};
+
+    // A post-only order never takes; it is rejected rather than crossing
+    I128 filled = 0;
+    if (order.tif != TIF::ALO) {
+        auto fill = book_->quote_fill(order.market_id, order.is_buy, order.size_x18,
+                                      is_market ? 0 : order.limit_px_x18);
+        if (!fill) return std::nullopt;
+        filled = fill->filled_x18;
+        estimate.taker_fee_x18 = x18::mul(filled, x18::mul(fill->avg_px_x18,
+                                                           config->taker_fee_x18));
+    } else if (!book_->market_exists(order.market_id)) {
+        return std::nullopt;
+    }
+
+    // Market and IOC remainders are cancelled rather than rested
+    if (!is_market && order.tif != TIF::IOC && filled < order.size_x18) {
+        estimate.maker_fee_x18 = x18::mul(order.size_x18 - filled,
+                                          x18::mul(order.limit_px_x18, config->maker_fee_x18));
+    }
+    return estimate;
+}
+
+int32_t LX::check_order_limits(const LXAccount& account, const LXOrder& order) const {
+    auto config = vault_->get_market_config(order.market_id);
+    if (!config) return errors::MARKET_NOT_FOUND;
+    auto book_config = book_->get_market_config(order.market_id);
+
+    if (order.size_x18 <= 0 || order.size_x18 < config->min_order_size_x18) {
+        return errors::ORDER_TOO_SMALL;
+    }
+    if (book_config && book_config->max_order_size_x18 > 0 &&
+        order.size_x18 > book_config->max_order_size_x18) {
+        return errors::ORDER_TOO_LARGE;
+    }
+
+    // Position before and after the order fills in full; vault positions
+    // are signed, negative when short
+    I128 current = 0;
+    if (auto pos = vault_->get_position(account, order.market_id)) {
+        current = pos->size_x18;
+    }
+    I128 after = order.is_buy ? current + order.size_x18 : current - order.size_x18;
+    I128 current_abs = current < 0 ? -current : current;
+    I128 after_abs = after < 0 ? -after : after;
+
+    // Orders that shrink the position are always allowed through the cap
+    if (config->max_position_size_x18 > 0 && after_abs > config->max_position_size_x18 &&
+        after_abs > current_abs) {
+        return errors::POSITION_LIMIT_EXCEEDED;
+    }
+
+    return check_reduce_only(account, order);
+}
+
+int32_t LX::check_reduce_only(const LXAccount& account, const LXOrder& order) const {
+    auto config = vault_->get_market_config(order.market_id);
+    auto book_config = book_->get_market_config(order.market_id);
+    bool reduce_only = order.reduce_only || (config && config->reduce_only_mode) ||
+                       (book_config && book_config->reduce_only_mode);
+    if (!reduce_only) return errors::OK;
+
+    I128 current = 0;
+    if (auto pos = vault_->get_position(account, order.market_id)) {
+        current = pos->size_x18;
+    }
+    I128 current_abs = current < 0 ? -current : current;
+
+    // Reducing means the opposite side and no more than the position, so it
+    // can never flip
+    if (current == 0 || (current > 0) == order.is_buy || order.size_x18 > current_abs) {
+        return errors::REDUCE_ONLY;
+    }
+    return errors::OK;
+}
+
+// =============================================================================
+// Snapshot & Restore
+// =============================================================================
+
+std::vector<uint8_t> LX::snapshot() const {
+    SnapshotWriter out;
+    out.put(SNAPSHOT_VERSION);
+    pool_->snapshot(out);
+    oracle_->snapshot(out);
+    vault_->snapshot(out);
+    book_->snapshot(out);
+    feed_->snapshot(out);
+    lend_->snapshot(out);
+    liquid_->snapshot(out);
+
+    std::lock_guard lock(settled_mutex_);
+    out.put(static_cast<uint64_t>(settled_fills_.size()));
+    for (const auto& [market_id, fills] : settled_fills_) {
+        out.put(market_id);
+        out.put(std::vector<std::pair<uint64_t, LXSettlement>>(fills.begin(), fills.end()));
+    }
+    return out.take();
+}
+
+int32_t LX::restore(const uint8_t* data, size_t size) {
+    SnapshotReader in(data, size);
+    uint32_t version = 0;
+    if (!in.get(version) || version != SNAPSHOT_VERSION) {
+        return errors::INVALID_SNAPSHOT;
+    }
+    if (!pool_->restore(in) || !oracle_->restore(in) || !vault_->restore(in) ||
+        !book_->restore(in) || !feed_->restore(in) || !lend_->restore(in) ||
+        !liquid_->restore(in)) {
+        return errors::INVALID_SNAPSHOT;
+    }
+
+    std::unordered_map<uint32_t, std::deque<std::pair<uint64_t, LXSettlement>>> settled_fills;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return errors::INVALID_SNAPSHOT;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint32_t market_id = 0;
+        std::vector<std::pair<uint64_t, LXSettlement>> fills;
+        if (!in.get(market_id) || !in.get(fills)) return errors::INVALID_SNAPSHOT;
+        settled_fills[market_id].assign(fills.begin(), fills.end());
+    }
+    if (!in.done()) return errors::INVALID_SNAPSHOT;
+
+    std::lock_guard lock(settled_mutex_);
+    settled_fills_ = std::move(settled_fills);
+    return errors::OK;
+}
+
+// =============================================================================
+// Journal
+// =============================================================================
+
+void LX::set_journal_callback(JournalCallback callback) {
+    bool enabled = static_cast<bool>(callback);
+    {
+        std::lock_guard lock(journal_mutex_);
+        journal_callback_ = std::move(callback);
+        journal_sequence_ = 0;
+    }
+
+    JournalCallback sink;
+    if (enabled) {
+        sink = [this](const std::vector<uint8_t>& body) { write_journal(body); };
+    }
+    pool_->set_journal_callback(sink, &journal_order_);
+    oracle_->set_journal_callback(sink, &journal_order_);
+    vault_->set_journal_callback(sink, &journal_order_);
+    book_->set_journal_callback(sink, &journal_order_);
+    feed_->set_journal_callback(sink, &journal_order_);
+    lend_->set_journal_callback(sink, &journal_order_);
+    liquid_->set_journal_callback(sink, &journal_order_);
+    journal_.set(sink, &journal_order_);
+}
+
+void LX::write_journal(const std::vector<uint8_t>& body) {
+    std::lock_guard lock(journal_mutex_);
+    if (!journal_callback_) return;
+
+    SnapshotWriter out;
+    out.put(++journal_sequence_);
+    out.put(static_cast<uint64_t>(
+        std::chrono::duration_cast<std::chrono::nanoseconds>(
+            std::chrono::system_clock::now().time_since_epoch()
+        ).count()
+    ));
+    std::vector<uint8_t> record = out.take();
+    record.insert(record.end(), body.begin(), body.end());
+    journal_callback_(record);
+}
+
+namespace {
+
+// OracleConfig as register_asset and update_config record it
+bool get_oracle_config(SnapshotReader& in, OracleConfig& config) {
+    return in.get(config.asset_id) && in.get(config.base_token) &&
+           in.get(config.quote_token) && in.get(config.max_staleness) &&
+           in.get(config.max_deviation_x18) && in.get(config.method) &&
+           in.get(config.sources) && in.get(config.weights_x18) &&
+           in.get(config.max_move_bps) && in.get(config.removed_sources);
+}
+
+} // namespace
+
+int32_t LX::journal_apply(const uint8_t* data, size_t size) {
+    SnapshotReader in(data, size);
+    uint64_t sequence = 0;
+    uint64_t timestamp = 0;
+    JournalOp op{};
+    if (!in.get(sequence) || !in.get(timestamp) || !in.get(op)) {
+        return errors::INVALID_JOURNAL;
+    }
+
+    std::lock_guard lock(replay_mutex_);
+    if (sequence != applied_sequence_ + 1) {
+        return errors::INVALID_JOURNAL;
+    }
+
+    bool matches = false;
+    switch (op) {
+    case JournalOp::POOL_INITIALIZE: {
+        PoolKey key{};
+        I128 sqrt_price_x96 = 0;
+        int32_t result = 0;
+        if (!in.get(key) || !in.get(sqrt_price_x96) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = pool_->initialize(key, sqrt_price_x96) == result;
+        break;
+    }
+    case JournalOp::POOL_SWAP:
+    case JournalOp::POOL_MODIFY_LIQUIDITY: {
+        PoolKey key{};
+        std::vector<uint8_t> hook_data;
+        BalanceDelta recorded{};
+        BalanceDelta delta{};
+        if (op == JournalOp::POOL_SWAP) {
+            SwapParams params{};
+            if (!in.get(key) || !in.get(params) || !in.get(hook_data) ||
+                !in.get(recorded) || !in.done()) {
+                return errors::INVALID_JOURNAL;
+            }
+            delta = pool_->swap(key, params, hook_data);
+        } else {
+            ModifyLiquidityParams params{};
+            if (!in.get(key) || !in.get(params) || !in.get(hook_data) ||
+                !in.get(recorded) || !in.done()) {
+                return errors::INVALID_JOURNAL;
+            }
+            delta = pool_->modify_liquidity(key, params, hook_data);
+        }
+        matches = delta.amount0 == recorded.amount0 && delta.amount1 == recorded.amount1;
+        break;
+    }
+    case JournalOp::BOOK_CREATE_MARKET: {
+        BookMarketConfig config{};
+        int32_t result = 0;
+        if (!in.get(config) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = book_->create_market(config) == result;
+        break;
+    }
+    case JournalOp::BOOK_PLACE_ORDER: {
+        LXAccount sender{};
+        LXOrder order{};
+        LXPlaceResult recorded{};
+        if (!in.get(sender) || !in.get(order) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        LXPlaceResult result = book_->place_order(sender, order);
+        if (recorded.oid != 0) {
+            replayed_oids_[recorded.oid] = result.oid;
+        }
+        matches = result.status == recorded.status && result.reason == recorded.reason &&
+                  result.filled_size_x18 == recorded.filled_size_x18 &&
+                  result.avg_px_x18 == recorded.avg_px_x18;
+        break;
+    }
+    case JournalOp::BOOK_CANCEL_ORDER: {
+        LXAccount sender{};
+        uint32_t market_id = 0;
+        uint64_t oid = 0;
+        int32_t result = 0;
+        if (!in.get(sender) || !in.get(market_id) || !in.get(oid) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        auto it = replayed_oids_.find(oid);
+        if (it != replayed_oids_.end()) {
+            oid = it->second;
+        }
+        matches = book_->cancel_order(sender, market_id, oid) == result;
+        break;
+    }
+    case JournalOp::VAULT_CREATE_MARKET: {
+        MarketConfig config{};
+        int32_t result = 0;
+        if (!in.get(config) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->create_market(config) == result;
+        break;
+    }
+    case JournalOp::VAULT_DEPOSIT:
+    case JournalOp::VAULT_WITHDRAW: {
+        LXAccount account{};
+        Currency token{};
+        I128 amount_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(token) || !in.get(amount_x18) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        int32_t replayed = op == JournalOp::VAULT_DEPOSIT ?
+            vault_->deposit(account, token, amount_x18) :
+            vault_->withdraw(account, token, amount_x18);
+        matches = replayed == result;
+        break;
+    }
+    case JournalOp::ORACLE_REGISTER_ASSET: {
+        OracleConfig config{};
+        int32_t result = 0;
+        if (!get_oracle_config(in, config) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->register_asset(config) == result;
+        break;
+    }
+    case JournalOp::ORACLE_UPDATE_PRICE: {
+        uint64_t asset_id = 0;
+        PriceSource source{};
+        I128 price_x18 = 0;
+        I128 confidence_x18 = 0;
+        uint64_t price_time = 0;
+        int32_t result = 0;
+        if (!in.get(asset_id) || !in.get(source) || !in.get(price_x18) ||
+            !in.get(confidence_x18) || !in.get(price_time) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->update_price(asset_id, source, price_x18, confidence_x18,
+                                        price_time) == result;
+        break;
+    }
+    case JournalOp::FEED_REGISTER_MARKET: {
+        uint32_t market_id = 0;
+        uint64_t asset_id = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(asset_id) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = feed_->register_market(market_id, asset_id) == result;
+        break;
+    }
+    case JournalOp::FEED_UPDATE_LAST_PRICE: {
+        uint32_t market_id = 0;
+        I128 price_x18 = 0;
+        uint64_t price_time = 0;
+        if (!in.get(market_id) || !in.get(price_x18) || !in.get(price_time) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        feed_->update_last_price(market_id, price_x18, price_time);
+        matches = true;
+        break;
+    }
+    case JournalOp::BOOK_AMEND_ORDER: {
+        LXAccount sender{};
+        uint32_t market_id = 0;
+        uint64_t oid = 0;
+        I128 new_size_x18 = 0;
+        I128 new_price_x18 = 0;
+        LXPlaceResult recorded{};
+        if (!in.get(sender) || !in.get(market_id) || !in.get(oid) || !in.get(new_size_x18) ||
+            !in.get(new_price_x18) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        auto it = replayed_oids_.find(oid);
+        if (it != replayed_oids_.end()) {
+            oid = it->second;
+        }
+        LXPlaceResult result = book_->amend_order(sender, market_id, oid, new_size_x18,
+                                                  new_price_x18);
+        matches = result.status == recorded.status && result.reason == recorded.reason;
+        break;
+    }
+    case JournalOp::BOOK_UPDATE_MARKET: {
+        BookMarketConfig config{};
+        int32_t result = 0;
+        if (!in.get(config) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = book_->update_market_config(config) == result;
+        break;
+    }
+    case JournalOp::BOOK_SET_MARKET_STATUS: {
+        uint32_t market_id = 0;
+        uint8_t status = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(status) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = book_->set_market_status(market_id, status) == result;
+        break;
+    }
+    case JournalOp::BOOK_REMOVE_MARKET: {
+        uint32_t market_id = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = book_->remove_market(market_id) == result;
+        break;
+    }
+    case JournalOp::BOOK_EVALUATE_TRIGGERS: {
+        uint32_t market_id = 0;
+        I128 ref_px_x18 = 0;
+        std::vector<uint64_t> recorded;
+        if (!in.get(market_id) || !in.get(ref_px_x18) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        for (auto& oid : recorded) {
+            auto it = replayed_oids_.find(oid);
+            if (it != replayed_oids_.end()) {
+                oid = it->second;
+            }
+        }
+        matches = book_->evaluate_triggers(market_id, ref_px_x18) == recorded;
+        break;
+    }
+    case JournalOp::BUST_TRADE: {
+        uint32_t market_id = 0;
+        uint64_t trade_id = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(trade_id) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = bust_trade(market_id, trade_id) == result;
+        break;
+    }
+    case JournalOp::VAULT_UPDATE_MARKET: {
+        MarketConfig config{};
+        int32_t result = 0;
+        if (!in.get(config) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->update_market(config) == result;
+        break;
+    }
+    case JournalOp::VAULT_TRANSFER: {
+        LXAccount from{};
+        LXAccount to{};
+        Currency token{};
+        I128 amount_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(from) || !in.get(to) || !in.get(token) || !in.get(amount_x18) ||
+            !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->transfer(from, to, token, amount_x18) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_CROSS_OWNER_TRANSFERS: {
+        bool allowed = false;
+        if (!in.get(allowed) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        vault_->set_cross_owner_transfers(allowed);
+        matches = true;
+        break;
+    }
+    case JournalOp::VAULT_TRANSFER_POSITION: {
+        LXAccount from{};
+        LXAccount to{};
+        uint32_t market_id = 0;
+        I128 size_x18 = 0;
+        I128 price_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(from) || !in.get(to) || !in.get(market_id) || !in.get(size_x18) ||
+            !in.get(price_x18) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->transfer_position(from, to, market_id, size_x18, price_x18) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_MARGIN_MODE: {
+        LXAccount account{};
+        uint32_t market_id = 0;
+        MarginMode mode{};
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(market_id) || !in.get(mode) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->set_margin_mode(account, market_id, mode) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_LEVERAGE: {
+        LXAccount account{};
+        uint32_t market_id = 0;
+        I128 leverage_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(market_id) || !in.get(leverage_x18) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->set_leverage(account, market_id, leverage_x18) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_FEE_TIER: {
+        LXAccount account{};
+        I128 maker_fee_x18 = 0;
+        I128 taker_fee_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(maker_fee_x18) || !in.get(taker_fee_x18) ||
+            !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->set_fee_tier(account, maker_fee_x18, taker_fee_x18) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_STAKED_BALANCE: {
+        LXAccount account{};
+        I128 amount_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(amount_x18) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->set_staked_balance(account, amount_x18) == result;
+        break;
+    }
+    case JournalOp::VAULT_SET_STAKING_FEE_TIERS: {
+        std::vector<StakingFeeTier> tiers;
+        int32_t result = 0;
+        if (!in.get(tiers) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->set_staking_fee_tiers(std::move(tiers)) == result;
+        break;
+    }
+    case JournalOp::VAULT_LIQUIDATE: {
+        LXAccount liquidator{};
+        LXAccount account{};
+        uint32_t market_id = 0;
+        I128 size_x18 = 0;
+        LXLiquidationResult recorded{};
+        if (!in.get(liquidator) || !in.get(account) || !in.get(market_id) || !in.get(size_x18) ||
+            !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        LXLiquidationResult result = vault_->liquidate(liquidator, account, market_id, size_x18);
+        matches = result.size_x18 == recorded.size_x18 && result.price_x18 == recorded.price_x18 &&
+                  result.penalty_x18 == recorded.penalty_x18;
+        break;
+    }
+    case JournalOp::VAULT_ACCRUE_FUNDING: {
+        uint32_t market_id = 0;
+        uint64_t now = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(now) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->accrue_funding(market_id, now) == result;
+        break;
+    }
+    case JournalOp::VAULT_SETTLE_FUNDING: {
+        LXAccount account{};
+        uint32_t market_id = 0;
+        std::optional<I128> recorded;
+        if (!in.get(account) || !in.get(market_id) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = vault_->settle_funding(account, market_id) == recorded;
+        break;
+    }
+    case JournalOp::VAULT_SET_FUNDING_RATE: {
+        uint32_t market_id = 0;
+        I128 rate_x18 = 0;
+        if (!in.get(market_id) || !in.get(rate_x18) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        vault_->set_funding_rate(market_id, rate_x18);
+        matches = true;
+        break;
+    }
+    case JournalOp::LEND_SUPPLY:
+    case JournalOp::LEND_WITHDRAW:
+    case JournalOp::LEND_BORROW:
+    case JournalOp::LEND_REPAY: {
+        LXAccount account{};
+        Currency token{};
+        I128 amount_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(token) || !in.get(amount_x18) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        int32_t replayed = 0;
+        switch (op) {
+        case JournalOp::LEND_SUPPLY: replayed = lend_->supply(account, token, amount_x18); break;
+        case JournalOp::LEND_WITHDRAW: replayed = lend_->withdraw(account, token, amount_x18); break;
+        case JournalOp::LEND_BORROW: replayed = lend_->borrow(account, token, amount_x18); break;
+        default: replayed = lend_->repay(account, token, amount_x18); break;
+        }
+        matches = replayed == result;
+        break;
+    }
+    case JournalOp::LIQUID_OPEN: {
+        LXAccount account{};
+        Currency collateral{};
+        I128 amount_x18 = 0;
+        uint64_t recorded_id = 0;
+        int32_t result = 0;
+        if (!in.get(account) || !in.get(collateral) || !in.get(amount_x18) ||
+            !in.get(recorded_id) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        uint64_t loan_id = 0;
+        matches = liquid_->open(account, collateral, amount_x18, loan_id) == result &&
+                  loan_id == recorded_id;
+        break;
+    }
+    case JournalOp::LIQUID_APPLY_YIELD: {
+        uint64_t loan_id = 0;
+        I128 yield_x18 = 0;
+        int32_t result = 0;
+        if (!in.get(loan_id) || !in.get(yield_x18) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = liquid_->apply_yield(loan_id, yield_x18) == result;
+        break;
+    }
+    case JournalOp::POOL_DONATE: {
+        PoolKey key{};
+        I128 amount0 = 0;
+        I128 amount1 = 0;
+        std::vector<uint8_t> hook_data;
+        BalanceDelta recorded{};
+        if (!in.get(key) || !in.get(amount0) || !in.get(amount1) || !in.get(hook_data) ||
+            !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        BalanceDelta delta = pool_->donate(key, amount0, amount1, hook_data);
+        matches = delta.amount0 == recorded.amount0 && delta.amount1 == recorded.amount1;
+        break;
+    }
+    case JournalOp::POOL_COLLECT_FEES: {
+        PoolKey key{};
+        Address owner{};
+        int32_t tick_lower = 0;
+        int32_t tick_upper = 0;
+        uint64_t salt = 0;
+        std::optional<BalanceDelta> recorded;
+        if (!in.get(key) || !in.get(owner) || !in.get(tick_lower) || !in.get(tick_upper) ||
+            !in.get(salt) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        auto delta = pool_->collect_fees(key, owner, tick_lower, tick_upper, salt);
+        matches = delta.has_value() == recorded.has_value() &&
+                  (!delta || (delta->amount0 == recorded->amount0 &&
+                              delta->amount1 == recorded->amount1));
+        break;
+    }
+    case JournalOp::POOL_SET_PROTOCOL_FEE:
+    case JournalOp::POOL_SET_DYNAMIC_FEE: {
+        PoolKey key{};
+        uint32_t new_fee = 0;
+        int32_t result = 0;
+        if (!in.get(key) || !in.get(new_fee) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        int32_t replayed = op == JournalOp::POOL_SET_PROTOCOL_FEE ?
+            pool_->set_protocol_fee(key, new_fee) :
+            pool_->set_dynamic_fee(key, new_fee);
+        matches = replayed == result;
+        break;
+    }
+    case JournalOp::POOL_COLLECT_PROTOCOL: {
+        PoolKey key{};
+        Address recipient{};
+        BalanceDelta recorded{};
+        if (!in.get(key) || !in.get(recipient) || !in.get(recorded) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        BalanceDelta delta = pool_->collect_protocol(key, recipient);
+        matches = delta.amount0 == recorded.amount0 && delta.amount1 == recorded.amount1;
+        break;
+    }
+    case JournalOp::ORACLE_UPDATE_CONFIG: {
+        uint64_t asset_id = 0;
+        OracleConfig config{};
+        int32_t result = 0;
+        if (!in.get(asset_id) || !get_oracle_config(in, config) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->update_config(asset_id, config) == result;
+        break;
+    }
+    case JournalOp::ORACLE_SET_CIRCUIT_BREAKER: {
+        uint64_t asset_id = 0;
+        uint32_t max_move_bps = 0;
+        int32_t result = 0;
+        if (!in.get(asset_id) || !in.get(max_move_bps) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->set_circuit_breaker(asset_id, max_move_bps) == result;
+        break;
+    }
+    case JournalOp::ORACLE_SET_MAX_STALENESS: {
+        uint64_t asset_id = 0;
+        uint64_t max_staleness = 0;
+        int32_t result = 0;
+        if (!in.get(asset_id) || !in.get(max_staleness) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->set_max_staleness(asset_id, max_staleness) == result;
+        break;
+    }
+    case JournalOp::ORACLE_SET_AGGREGATION_METHOD: {
+        uint64_t asset_id = 0;
+        AggregationMethod method{};
+        int32_t result = 0;
+        if (!in.get(asset_id) || !in.get(method) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = oracle_->set_aggregation_method(asset_id, method) == result;
+        break;
+    }
+    case JournalOp::ORACLE_REMOVE_SOURCE:
+    case JournalOp::ORACLE_RESTORE_SOURCE: {
+        uint64_t asset_id = 0;
+        PriceSource source{};
+        int32_t result = 0;
+        if (!in.get(asset_id) || !in.get(source) || !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        int32_t replayed = op == JournalOp::ORACLE_REMOVE_SOURCE ?
+            oracle_->remove_source(asset_id, source) :
+            oracle_->restore_source(asset_id, source);
+        matches = replayed == result;
+        break;
+    }
+    case JournalOp::FEED_SET_MARK_PRICE_CONFIG: {
+        uint32_t market_id = 0;
+        MarkPriceConfig config{};
+        if (!in.get(market_id) || !in.get(config) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        feed_->set_mark_price_config(market_id, config);
+        matches = true;
+        break;
+    }
+    case JournalOp::FEED_SET_MARK_SMOOTHING: {
+        uint32_t market_id = 0;
+        uint64_t ewma_window = 0;
+        uint32_t premium_clamp_bps = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(ewma_window) || !in.get(premium_clamp_bps) ||
+            !in.get(result) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = feed_->set_mark_smoothing(market_id, ewma_window, premium_clamp_bps) == result;
+        break;
+    }
+    case JournalOp::FEED_SET_FUNDING_PARAMS: {
+        uint32_t market_id = 0;
+        FundingParams params{};
+        if (!in.get(market_id) || !in.get(params) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        feed_->set_funding_params(market_id, params);
+        matches = true;
+        break;
+    }
+    case JournalOp::FEED_SET_FUNDING_CONFIG: {
+        uint32_t market_id = 0;
+        uint64_t interval = 0;
+        uint32_t clamp_bps = 0;
+        int32_t result = 0;
+        if (!in.get(market_id) || !in.get(interval) || !in.get(clamp_bps) || !in.get(result) ||
+            !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        matches = feed_->set_funding_config(market_id, interval, clamp_bps) == result;
+        break;
+    }
+    case JournalOp::FEED_UPDATE_BBO: {
+        uint32_t market_id = 0;
+        I128 best_bid_x18 = 0;
+        I128 best_ask_x18 = 0;
+        if (!in.get(market_id) || !in.get(best_bid_x18) || !in.get(best_ask_x18) || !in.done()) {
+            return errors::INVALID_JOURNAL;
+        }
+        feed_->update_bbo(market_id, best_bid_x18, best_ask_x18);
+        matches = true;
+        break;
+    }
+    default:
+        return errors::INVALID_JOURNAL;
+    }
+
+    if (!matches) {
+        return errors::INVALID_JOURNAL;
+    }
+    applied_sequence_ = sequence;
+    return errors::OK;
+}
+
 // =============================================================================
 // Statistics
 // =============================================================================
@@ -427,6 +1329,24 @@ LX::GlobalStats LX::get_stats() const {
     return stats;
 }
 
+LX::Health LX::health() const {
+    Health h{};
+    h.running = is_running();
+
+    auto stale = oracle_->stale_assets();
+    h.stale_asset_count = stale.size();
+    if (!stale.empty()) {
+        h.oldest_stale_asset = stale.front();
+    }
+
+    for (uint32_t market_id : book_->list_markets()) {
+        if (auto stats = book_->get_market_stats(market_id)) {
+            h.open_orders += stats->open_orders;
+        }
+    }
+    return h;
+}
+
 // =============================================================================
 // Internal Settlement Callback
 // =============================================================================
@@ -443,12 +1363,17 @@ int32_t LX::on_book_trades(const std::vector<Trade>& trades) {
     for (const auto& trade : trades) {
         LXSettlement settlement;
 
-        // Convert account IDs to LXAccount
-        // In production, we'd have proper account mapping
-        settlement.maker.main = {};
-        settlement.maker.subaccount_id = static_cast<uint16_t>(trade.seller_account_id & 0xFFFF);
-        settlement.taker.main = {};
-        settlement.taker.subaccount_id = static_cast<uint16_t>(trade.buyer_account_id & 0xFFFF);
+        // Engine account IDs are account hashes; the book knows which
+        // account placed each order
+        auto resolve = [this](uint64_t account_id) {
+            return book_->get_account(account_id).value_or(
+                LXAccount{{}, static_cast<uint16_t>(account_id & 0xFFFF)});
+        };
+        LXAccount buyer = resolve(trade.buyer_account_id);
+        LXAccount seller = resolve(trade.seller_account_id);
+        bool buyer_took = trade.aggressor_side == Side::Buy;
+        settlement.maker = buyer_took ? seller : buyer;
+        settlement.taker = buyer_took ? buyer : seller;
 
         settlement.market_id = static_cast<uint32_t>(trade.symbol_id);
         settlement.taker_is_buy = (trade.aggressor_side == Side::Buy);
@@ -457,10 +1382,12 @@ int32_t LX::on_book_trades(const std::vector<Trade>& trades) {
         settlement.size_x18 = static_cast<I128>(trade.quantity) * X18_ONE / 100000000LL;
         settlement.price_x18 = static_cast<I128>(trade.price) * X18_ONE / 100000000LL;
 
-        // Calculate fees (simplified)
+        // Each side pays its fee tier, or the market's rates without one
         I128 notional = x18::mul(settlement.size_x18, settlement.price_x18);
-        settlement.maker_fee_x18 = x18::mul(notional, x18::from_double(0.0002)); // 0.02%
-        settlement.taker_fee_x18 = x18::mul(notional, x18::from_double(0.0005)); // 0.05%
+        auto maker_rates = vault_->fee_rates(settlement.maker, settlement.market_id);
+        auto taker_rates = vault_->fee_rates(settlement.taker, settlement.market_id);
+        settlement.maker_fee_x18 = maker_rates ? x18::mul(notional, maker_rates->maker_fee_x18) : 0;
+        settlement.taker_fee_x18 = taker_rates ? x18::mul(notional, taker_rates->taker_fee_x18) : 0;
 
         settlement.flags = (trade.aggressor_side == Side::Buy) ?
             fill_flags::TAKER : fill_flags::MAKER;
@@ -475,7 +1402,47 @@ int32_t LX::on_book_trades(const std::vector<Trade>& trades) {
     }
 
     // Apply fills
-    return vault_->apply_fills(settlements);
+    int32_t result = vault_->apply_fills(settlements);
+    if (result != errors::OK) {
+        return result;
+    }
+
+    {
+        std::lock_guard lock(settled_mutex_);
+        for (size_t i = 0; i < trades.size(); ++i) {
+            auto& fills = settled_fills_[settlements[i].market_id];
+            fills.emplace_back(trades[i].id, settlements[i]);
+            if (fills.size() > LXBook::TRADE_HISTORY_SIZE) {
+                fills.pop_front();
+            }
+        }
+    }
+
+    TradeCallback callback;
+    {
+        std::lock_guard lock(trade_callback_mutex_);
+        callback = trade_callback_;
+    }
+    if (callback) {
+        for (size_t i = 0; i < trades.size(); ++i) {
+            const Trade& trade = trades[i];
+            const LXSettlement& settlement = settlements[i];
+            bool buyer_took = trade.aggressor_side == Side::Buy;
+            LXTrade t{};
+            t.trade_id = trade.id;
+            t.market_id = settlement.market_id;
+            t.maker_oid = buyer_took ? trade.sell_order_id : trade.buy_order_id;
+            t.taker_oid = buyer_took ? trade.buy_order_id : trade.sell_order_id;
+            t.maker = settlement.maker;
+            t.taker = settlement.taker;
+            t.taker_is_buy = settlement.taker_is_buy;
+            t.px_x18 = settlement.price_x18;
+            t.sz_x18 = settlement.size_x18;
+            t.timestamp = static_cast<uint64_t>(trade.timestamp.count());
+            callback(t);
+        }
+    }
+    return errors::OK;
 }
 
 // =============================================================================
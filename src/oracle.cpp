@@ -21,6 +21,16 @@ LXOracle::LXOracle() = default;
 // =============================================================================
 
 int32_t LXOracle::register_asset(const OracleConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_register_asset(config);
+    journal_.record(JournalOp::ORACLE_REGISTER_ASSET, config.asset_id, config.base_token,
+                    config.quote_token, config.max_staleness, config.max_deviation_x18,
+                    config.method, config.sources, config.weights_x18, config.max_move_bps,
+                    config.removed_sources, result);
+    return result;
+}
+
+int32_t LXOracle::try_register_asset(const OracleConfig& config) {
     std::unique_lock lock(config_mutex_);
 
     if (configs_.find(config.asset_id) != configs_.end()) {
@@ -41,6 +51,16 @@ int32_t LXOracle::register_asset(const OracleConfig& config) {
 }
 
 int32_t LXOracle::update_config(uint64_t asset_id, const OracleConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_update_config(asset_id, config);
+    journal_.record(JournalOp::ORACLE_UPDATE_CONFIG, asset_id, config.asset_id, config.base_token,
+                    config.quote_token, config.max_staleness, config.max_deviation_x18,
+                    config.method, config.sources, config.weights_x18, config.max_move_bps,
+                    config.removed_sources, result);
+    return result;
+}
+
+int32_t LXOracle::try_update_config(uint64_t asset_id, const OracleConfig& config) {
     std::unique_lock lock(config_mutex_);
 
     auto it = configs_.find(asset_id);
@@ -59,6 +79,104 @@ std::optional<OracleConfig> LXOracle::get_config(uint64_t asset_id) const {
     return it->second;
 }
 
+int32_t LXOracle::set_circuit_breaker(uint64_t asset_id, uint32_t max_move_bps) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_circuit_breaker(asset_id, max_move_bps);
+    journal_.record(JournalOp::ORACLE_SET_CIRCUIT_BREAKER, asset_id, max_move_bps, result);
+    return result;
+}
+
+int32_t LXOracle::try_set_circuit_breaker(uint64_t asset_id, uint32_t max_move_bps) {
+    std::unique_lock lock(config_mutex_);
+
+    auto it = configs_.find(asset_id);
+    if (it == configs_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    it->second.max_move_bps = max_move_bps;
+    return errors::OK;
+}
+
+int32_t LXOracle::set_max_staleness(uint64_t asset_id, uint64_t max_staleness) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_max_staleness(asset_id, max_staleness);
+    journal_.record(JournalOp::ORACLE_SET_MAX_STALENESS, asset_id, max_staleness, result);
+    return result;
+}
+
+int32_t LXOracle::try_set_max_staleness(uint64_t asset_id, uint64_t max_staleness) {
+    std::unique_lock lock(config_mutex_);
+
+    auto it = configs_.find(asset_id);
+    if (it == configs_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    it->second.max_staleness = max_staleness;
+    return errors::OK;
+}
+
+int32_t LXOracle::set_aggregation_method(uint64_t asset_id, AggregationMethod method) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_aggregation_method(asset_id, method);
+    journal_.record(JournalOp::ORACLE_SET_AGGREGATION_METHOD, asset_id, method, result);
+    return result;
+}
+
+int32_t LXOracle::try_set_aggregation_method(uint64_t asset_id, AggregationMethod method) {
+    std::unique_lock lock(config_mutex_);
+
+    auto it = configs_.find(asset_id);
+    if (it == configs_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    it->second.method = method;
+    return errors::OK;
+}
+
+int32_t LXOracle::remove_source(uint64_t asset_id, PriceSource source) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_remove_source(asset_id, source);
+    journal_.record(JournalOp::ORACLE_REMOVE_SOURCE, asset_id, source, result);
+    return result;
+}
+
+int32_t LXOracle::try_remove_source(uint64_t asset_id, PriceSource source) {
+    std::unique_lock lock(config_mutex_);
+    auto it = configs_.find(asset_id);
+    if (it == configs_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+    it->second.removed_sources |= static_cast<uint16_t>(1u << static_cast<uint8_t>(source));
+    lock.unlock();
+
+    std::unique_lock price_lock(prices_mutex_);
+    auto asset_it = prices_.find(asset_id);
+    if (asset_it != prices_.end()) {
+        asset_it->second.erase(static_cast<uint8_t>(source));
+    }
+    return errors::OK;
+}
+
+int32_t LXOracle::restore_source(uint64_t asset_id, PriceSource source) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_restore_source(asset_id, source);
+    journal_.record(JournalOp::ORACLE_RESTORE_SOURCE, asset_id, source, result);
+    return result;
+}
+
+int32_t LXOracle::try_restore_source(uint64_t asset_id, PriceSource source) {
+    std::unique_lock lock(config_mutex_);
+    auto it = configs_.find(asset_id);
+    if (it == configs_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+    it->second.removed_sources &= static_cast<uint16_t>(~(1u << static_cast<uint8_t>(source)));
+    return errors::OK;
+}
+
 void LXOracle::set_robust_params(uint64_t asset_id, const RobustParams& params) {
     std::unique_lock lock(config_mutex_);
     robust_params_[asset_id] = params;
@@ -78,13 +196,21 @@ std::optional<RobustParams> LXOracle::get_robust_params(uint64_t asset_id) const
 int32_t LXOracle::update_price(uint64_t asset_id, PriceSource source,
                                 I128 price_x18, I128 confidence_x18,
                                 uint64_t timestamp) {
-    if (price_x18 <= 0) {
-        return errors::INVALID_PRICE;
-    }
-
+    auto journal_lock = journal_.order();
     if (timestamp == 0) {
         timestamp = current_timestamp();
     }
+    int32_t result = try_update_price(asset_id, source, price_x18, confidence_x18, timestamp);
+    journal_.record(JournalOp::ORACLE_UPDATE_PRICE, asset_id, source, price_x18,
+                    confidence_x18, timestamp, result);
+    return result;
+}
+
+int32_t LXOracle::try_update_price(uint64_t asset_id, PriceSource source,
+                                   I128 price_x18, I128 confidence_x18, uint64_t timestamp) {
+    if (int32_t rc = validate_update(asset_id, source, price_x18); rc != errors::OK) {
+        return rc;
+    }
 
     std::unique_lock lock(prices_mutex_);
 
@@ -99,17 +225,32 @@ int32_t LXOracle::update_price(uint64_t asset_id, PriceSource source,
     prices_[asset_id][static_cast<uint8_t>(source)] = data;
 
     total_updates_.fetch_add(1, std::memory_order_relaxed);
+    lock.unlock();
+
+    // Feed the aggregate into TWAP history
+    if (auto aggregate = get_price(asset_id)) {
+        record_twap_price(asset_id, *aggregate, timestamp);
+    }
 
     return errors::OK;
 }
 
-int32_t LXOracle::update_prices(const std::vector<std::tuple<uint64_t, PriceSource, I128, I128>>& updates) {
+std::vector<int32_t> LXOracle::update_prices(const std::vector<std::tuple<uint64_t, PriceSource, I128, I128>>& updates) {
     uint64_t timestamp = current_timestamp();
+    std::vector<int32_t> results(updates.size(), errors::OK);
+    uint64_t applied = 0;
+
+    // Validate against the aggregates from before the batch
+    for (size_t i = 0; i < updates.size(); ++i) {
+        const auto& [asset_id, source, price, confidence] = updates[i];
+        results[i] = validate_update(asset_id, source, price);
+    }
 
     std::unique_lock lock(prices_mutex_);
 
-    for (const auto& [asset_id, source, price, confidence] : updates) {
-        if (price <= 0) continue;
+    for (size_t i = 0; i < updates.size(); ++i) {
+        if (results[i] != errors::OK) continue;
+        const auto& [asset_id, source, price, confidence] = updates[i];
 
         SourcePriceData data;
         data.source = source;
@@ -120,11 +261,21 @@ int32_t LXOracle::update_prices(const std::vector<std::tuple<uint64_t, PriceSour
         data.is_valid = true;
 
         prices_[asset_id][static_cast<uint8_t>(source)] = data;
+        ++applied;
     }
 
-    total_updates_.fetch_add(updates.size(), std::memory_order_relaxed);
+    total_updates_.fetch_add(applied, std::memory_order_relaxed);
+    lock.unlock();
 
-    return errors::OK;
+    for (size_t i = 0; i < updates.size(); ++i) {
+        if (results[i] != errors::OK) continue;
+        uint64_t asset_id = std::get<0>(updates[i]);
+        if (auto aggregate = get_price(asset_id)) {
+            record_twap_price(asset_id, *aggregate, timestamp);
+        }
+    }
+
+    return results;
 }
 
 // =============================================================================
@@ -151,22 +302,35 @@ std::optional<AggregatedPriceData> LXOracle::get_price_data(uint64_t asset_id) c
 
     // Collect valid prices from all sources
     std::vector<I128> valid_prices;
+    std::vector<I128> confidences;
     uint64_t latest_timestamp = 0;
     uint64_t now = current_timestamp();
+    const SourcePriceData* newest = nullptr;
 
     for (const auto& [source_id, data] : asset_it->second) {
+        if (!data.is_valid) continue;
+        if (!newest || data.timestamp > newest->timestamp) {
+            newest = &data;
+        }
+
         // Check staleness
         if (now - data.timestamp > config.max_staleness) {
             continue;
         }
-        if (!data.is_valid) continue;
 
         valid_prices.push_back(data.price_x18);
+        confidences.push_back(data.confidence_x18);
         if (data.timestamp > latest_timestamp) {
             latest_timestamp = data.timestamp;
         }
     }
 
+    // Last-known-good keeps serving the newest submission once it goes stale
+    if (config.method == AggregationMethod::LAST_KNOWN_GOOD && valid_prices.empty() && newest) {
+        valid_prices.push_back(newest->price_x18);
+        latest_timestamp = newest->timestamp;
+    }
+
     if (valid_prices.empty()) return std::nullopt;
 
     // Aggregate based on method
@@ -189,6 +353,12 @@ std::optional<AggregatedPriceData> LXOracle::get_price_data(uint64_t asset_id) c
         case AggregationMethod::WEIGHTED_MEDIAN:
             aggregated_price = aggregate_weighted_median(valid_prices, config.weights_x18);
             break;
+        case AggregationMethod::CONFIDENCE_WEIGHTED_MEAN:
+            aggregated_price = aggregate_confidence_weighted(valid_prices, confidences);
+            break;
+        case AggregationMethod::LAST_KNOWN_GOOD:
+            aggregated_price = newest->price_x18;
+            break;
         default:
             aggregated_price = aggregate_mean(valid_prices);
     }
@@ -457,6 +627,29 @@ uint64_t LXOracle::price_age(uint64_t asset_id) const {
     return now > latest ? now - latest : 0;
 }
 
+std::vector<uint64_t> LXOracle::stale_assets() const {
+    std::vector<std::pair<uint64_t, uint64_t>> stale;  // (age, asset_id)
+    {
+        std::shared_lock config_lock(config_mutex_);
+        for (const auto& [asset_id, config] : configs_) {
+            uint64_t age = price_age(asset_id);
+            if (age > config.max_staleness) {
+                stale.emplace_back(age, asset_id);
+            }
+        }
+    }
+
+    std::sort(stale.begin(), stale.end(), [](const auto& a, const auto& b) {
+        return a.first != b.first ? a.first > b.first : a.second < b.second;
+    });
+    std::vector<uint64_t> ids;
+    ids.reserve(stale.size());
+    for (const auto& [age, asset_id] : stale) {
+        ids.push_back(asset_id);
+    }
+    return ids;
+}
+
 // =============================================================================
 // Statistics
 // =============================================================================
@@ -490,6 +683,82 @@ LXOracle::Stats LXOracle::get_stats() const {
     };
 }
 
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXOracle::snapshot(SnapshotWriter& out) const {
+    {
+        std::shared_lock lock(config_mutex_);
+        out.put(static_cast<uint64_t>(configs_.size()));
+        for (const auto& [asset_id, config] : configs_) {
+            out.put(asset_id);
+            out.put(config.base_token);
+            out.put(config.quote_token);
+            out.put(config.max_staleness);
+            out.put(config.max_deviation_x18);
+            out.put(config.method);
+            out.put(config.sources);
+            out.put(config.weights_x18);
+            out.put(config.max_move_bps);
+            out.put(config.removed_sources);
+        }
+        out.put(robust_params_);
+    }
+    {
+        std::shared_lock lock(prices_mutex_);
+        out.put(prices_);
+    }
+    {
+        std::shared_lock lock(twap_mutex_);
+        out.put(twap_data_);
+    }
+    out.put(total_updates_.load(std::memory_order_relaxed));
+}
+
+bool LXOracle::restore(SnapshotReader& in) {
+    std::unordered_map<uint64_t, OracleConfig> configs;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        OracleConfig config{};
+        if (!in.get(config.asset_id) || !in.get(config.base_token) ||
+            !in.get(config.quote_token) || !in.get(config.max_staleness) ||
+            !in.get(config.max_deviation_x18) || !in.get(config.method) ||
+            !in.get(config.sources) || !in.get(config.weights_x18) ||
+            !in.get(config.max_move_bps) || !in.get(config.removed_sources)) {
+            return false;
+        }
+        uint64_t asset_id = config.asset_id;
+        configs.emplace(asset_id, std::move(config));
+    }
+
+    std::unordered_map<uint64_t, RobustParams> robust_params;
+    std::unordered_map<uint64_t, std::unordered_map<uint8_t, SourcePriceData>> prices;
+    std::unordered_map<uint64_t, std::vector<std::pair<uint64_t, I128>>> twap_data;
+    uint64_t total_updates = 0;
+    if (!in.get(robust_params) || !in.get(prices) || !in.get(twap_data) ||
+        !in.get(total_updates)) {
+        return false;
+    }
+
+    {
+        std::unique_lock lock(config_mutex_);
+        configs_ = std::move(configs);
+        robust_params_ = std::move(robust_params);
+    }
+    {
+        std::unique_lock lock(prices_mutex_);
+        prices_ = std::move(prices);
+    }
+    {
+        std::unique_lock lock(twap_mutex_);
+        twap_data_ = std::move(twap_data);
+    }
+    total_updates_.store(total_updates, std::memory_order_relaxed);
+    return true;
+}
+
 // =============================================================================
 // Internal Helpers - Aggregation
 // =============================================================================
@@ -570,6 +839,29 @@ I128 LXOracle::aggregate_weighted_median(const std::vector<I128>& prices,
     return price_weight.back().first;
 }
 
+I128 LXOracle::aggregate_confidence_weighted(const std::vector<I128>& prices,
+                                              const std::vector<I128>& confidences) const {
+    if (prices.empty()) return 0;
+    if (confidences.size() != prices.size()) return aggregate_mean(prices);
+
+    // Weight each price by 1/confidence; a zero interval counts as 1 wei.
+    // Weights are normalized in floating point since price * weight
+    // overflows I128 for realistic magnitudes.
+    std::vector<long double> weights(prices.size());
+    long double total_weight = 0;
+    for (size_t i = 0; i < prices.size(); ++i) {
+        I128 conf = std::max<I128>(confidences[i], 1);
+        weights[i] = 1.0L / static_cast<long double>(conf);
+        total_weight += weights[i];
+    }
+
+    long double weighted = 0;
+    for (size_t i = 0; i < prices.size(); ++i) {
+        weighted += static_cast<long double>(prices[i]) * (weights[i] / total_weight);
+    }
+    return static_cast<I128>(weighted);
+}
+
 // =============================================================================
 // Internal Helpers - Outlier Detection
 // =============================================================================
@@ -605,6 +897,38 @@ std::vector<bool> LXOracle::detect_outliers(const std::vector<I128>& prices, I12
     return outliers;
 }
 
+int32_t LXOracle::validate_update(uint64_t asset_id, PriceSource source, I128 price_x18) const {
+    if (price_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::shared_lock lock(config_mutex_);
+    auto it = configs_.find(asset_id);
+    uint32_t max_move_bps = 0;
+    if (it != configs_.end()) {
+        if (it->second.removed_sources & (1u << static_cast<uint8_t>(source))) {
+            return errors::ORACLE_SOURCE_UNAVAILABLE;
+        }
+        max_move_bps = it->second.max_move_bps;
+    }
+    lock.unlock();
+
+    if (max_move_bps == 0) return errors::OK;
+
+    // Without a fresh aggregate there is nothing to measure the move against
+    auto aggregate = get_price(asset_id);
+    if (!aggregate || *aggregate <= 0) return errors::OK;
+
+    I128 move = price_x18 - *aggregate;
+    if (move < 0) move = -move;
+    if (move * 10000 > *aggregate * static_cast<I128>(max_move_bps)) {
+        events_.emit(SystemEventKind::CIRCUIT_BREAKER_TRIPPED, Severity::WARNING, 0, asset_id,
+                     {}, errors::PRICE_DEVIATION);
+        return errors::PRICE_DEVIATION;
+    }
+    return errors::OK;
+}
+
 uint64_t LXOracle::current_timestamp() const {
     return static_cast<uint64_t>(
         std::chrono::duration_cast<std::chrono::seconds>(
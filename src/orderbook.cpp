@@ -7,6 +7,17 @@ namespace lux {
 OrderBook::OrderBook(uint64_t symbol_id)
     : symbol_id_(symbol_id) {}
 
+void OrderBook::set_matching(MatchingAlgorithm algorithm, ProRataTieBreak tie_break) {
+    std::unique_lock lock(mutex_);
+    matching_ = algorithm;
+    tie_break_ = tie_break;
+}
+
+void OrderBook::set_stp_mode(STPMode mode) {
+    std::unique_lock lock(mutex_);
+    stp_mode_ = mode;
+}
+
 std::vector<Trade> OrderBook::place_order(Order order, TradeListener* listener) {
     std::unique_lock lock(mutex_);
 
@@ -30,6 +41,19 @@ std::vector<Trade> OrderBook::place_order(Order order, TradeListener* listener)
         );
     }
 
+    // Post-only orders must add liquidity
+    if (order.tif == TimeInForce::PostOnly) {
+        if (order.type != OrderType::Limit) {
+            throw std::invalid_argument("Post-only order must be a limit order");
+        }
+        bool crosses = order.is_buy() ?
+            !asks_.empty() && prices_cross(order.price, asks_.begin()->first) :
+            !bids_.empty() && prices_cross(bids_.begin()->first, order.price);
+        if (crosses) {
+            throw std::invalid_argument("Post-only order would cross the book");
+        }
+    }
+
     std::vector<Trade> trades;
 
     // Market orders and limit orders get matched
@@ -38,7 +62,12 @@ std::vector<Trade> OrderBook::place_order(Order order, TradeListener* listener)
     }
 
     // Handle remaining quantity based on TimeInForce
-    if (order.remaining() > 0) {
+    if (order.status == OrderStatus::Cancelled) {
+        // Self-trade prevention cancelled the remainder
+        if (listener) {
+            listener->on_order_cancelled(order);
+        }
+    } else if (order.remaining() > 0) {
         switch (order.tif) {
             case TimeInForce::IOC:
                 // Immediate or Cancel: cancel remaining
@@ -58,6 +87,7 @@ std::vector<Trade> OrderBook::place_order(Order order, TradeListener* listener)
             case TimeInForce::GTC:
             case TimeInForce::GTD:
             case TimeInForce::DAY:
+            case TimeInForce::PostOnly:
                 // Add to book if limit order
                 if (order.type == OrderType::Limit) {
                     add_to_book(order);
@@ -69,10 +99,8 @@ std::vector<Trade> OrderBook::place_order(Order order, TradeListener* listener)
                 break;
         }
     } else {
+        // match_order already reported the fill
         order.status = OrderStatus::Filled;
-        if (listener) {
-            listener->on_order_filled(order);
-        }
     }
 
     return trades;
@@ -88,7 +116,7 @@ std::vector<Trade> OrderBook::match_order(Order& order, TradeListener* listener)
             for (const auto& [price, level] : asks_) {
                 if (order.type == OrderType::Market ||
                     prices_cross(order.price, price)) {
-                    available += level.total_quantity;
+                    available += level.fillable_quantity();
                     if (available >= order.quantity) break;
                 } else {
                     break;
@@ -98,7 +126,7 @@ std::vector<Trade> OrderBook::match_order(Order& order, TradeListener* listener)
             for (const auto& [price, level] : bids_) {
                 if (order.type == OrderType::Market ||
                     prices_cross(price, order.price)) {
-                    available += level.total_quantity;
+                    available += level.fillable_quantity();
                     if (available >= order.quantity) break;
                 } else {
                     break;
@@ -131,7 +159,8 @@ std::vector<Trade> OrderBook::match_against_side(
     std::vector<Trade> trades;
 
     auto it = book_side.begin();
-    while (it != book_side.end() && aggressor.remaining() > 0) {
+    while (it != book_side.end() && aggressor.remaining() > 0 &&
+           aggressor.status != OrderStatus::Cancelled) {
         PriceLevel& level = it->second;
         Price level_price = it->first;
 
@@ -149,29 +178,54 @@ std::vector<Trade> OrderBook::match_against_side(
             break;
         }
 
+        if (matching_ == MatchingAlgorithm::ProRata) {
+            match_level_pro_rata(aggressor, level, trades, listener);
+            if (level.empty()) {
+                it = book_side.erase(it);
+            } else if (aggressor.remaining() == 0) {
+                ++it;
+            }
+            // Otherwise the level only holds refilled icebergs: match it again
+            continue;
+        }
+
         // Match against orders at this price level (FIFO)
         while (!level.empty() && aggressor.remaining() > 0) {
             Order* resting = level.front();
 
             // Self-trade prevention
             if (would_self_trade(aggressor, *resting)) {
-                // Cancel the resting order
-                Order cancelled = *resting;
-                cancelled.status = OrderStatus::Cancelled;
-                level.pop_front();
-                order_locations_.erase(cancelled.id);
-                if (listener) {
-                    listener->on_order_cancelled(cancelled);
+                Quantity removed = resolve_self_trade(aggressor, *resting);
+                if (removed == resting->remaining()) {
+                    Order cancelled = *resting;
+                    cancelled.status = OrderStatus::Cancelled;
+                    level.pop_front();
+                    order_locations_.erase(cancelled.id);
+                    if (listener) {
+                        listener->on_order_cancelled(cancelled);
+                    }
+                } else if (removed > 0) {
+                    level.reduce_order(resting->id, resting->quantity - removed);
+                    if (listener) {
+                        listener->on_order_reduced(*resting, removed);
+                    }
+                }
+                if (aggressor.status == OrderStatus::Cancelled) {
+                    break;
                 }
                 continue;
             }
 
-            // Calculate fill quantity
-            Quantity fill_qty = std::min(aggressor.remaining(), resting->remaining());
+            // Calculate fill quantity (an iceberg fills up to its visible slice)
+            Quantity fill_qty = std::min(aggressor.remaining(), resting->visible());
 
             // Update orders
             aggressor.filled += fill_qty;
             resting->filled += fill_qty;
+            resting->display_left -= fill_qty;
+            resting->status = resting->is_filled() ?
+                OrderStatus::Filled : OrderStatus::PartiallyFilled;
+            level.total_quantity -= fill_qty;
 
             // Create trade
             Trade trade = aggressor.is_buy() ?
@@ -200,6 +254,8 @@ std::vector<Trade> OrderBook::match_against_side(
             if (resting->is_filled()) {
                 order_locations_.erase(resting->id);
                 level.pop_front();
+            } else if (resting->visible() == 0) {
+                level.replenish(level.orders.begin());
             }
         }
 
@@ -212,7 +268,7 @@ std::vector<Trade> OrderBook::match_against_side(
     }
 
     // Update aggressor status
-    if (aggressor.filled > 0) {
+    if (aggressor.filled > 0 && aggressor.status != OrderStatus::Cancelled) {
         aggressor.status = aggressor.is_filled() ?
             OrderStatus::Filled : OrderStatus::PartiallyFilled;
     }
@@ -220,6 +276,167 @@ std::vector<Trade> OrderBook::match_against_side(
     return trades;
 }
 
+void OrderBook::match_level_pro_rata(
+    Order& aggressor,
+    PriceLevel& level,
+    std::vector<Trade>& trades,
+    TradeListener* listener
+) {
+    // Self-trade prevention runs before allocation, as in FIFO matching
+    for (auto it = level.orders.begin(); it != level.orders.end();) {
+        if (!would_self_trade(aggressor, *it)) {
+            ++it;
+            continue;
+        }
+        Quantity removed = resolve_self_trade(aggressor, *it);
+        Quantity shown = it->visible();
+        if (removed == it->remaining()) {
+            Order cancelled = *it;
+            cancelled.status = OrderStatus::Cancelled;
+            level.total_quantity -= shown;
+            order_locations_.erase(cancelled.id);
+            it = level.orders.erase(it);
+            if (listener) {
+                listener->on_order_cancelled(cancelled);
+            }
+        } else {
+            it->quantity -= removed;
+            level.total_quantity -= shown - it->visible();
+            if (listener && removed > 0) {
+                listener->on_order_reduced(*it, removed);
+            }
+            ++it;
+        }
+        if (aggressor.status == OrderStatus::Cancelled) {
+            return;
+        }
+    }
+    if (level.empty()) {
+        return;
+    }
+
+    struct Share {
+        Order* order;
+        size_t queue_pos;
+        Quantity fill;
+        __int128 fraction;  // Truncated part of remaining * qty / level_qty
+    };
+
+    Quantity level_qty = level.total_quantity;
+    Quantity qty = std::min(aggressor.remaining(), level_qty);
+
+    std::vector<Share> shares;
+    shares.reserve(level.order_count());
+    Quantity allocated = 0;
+    size_t pos = 0;
+    for (auto& order : level.orders) {
+        __int128 num = static_cast<__int128>(order.visible()) * qty;
+        Share share{&order, pos++, static_cast<Quantity>(num / level_qty), num % level_qty};
+        allocated += share.fill;
+        shares.push_back(share);
+    }
+
+    // Hand out the rounding leftover one unit per order, in tie-break order
+    Quantity leftover = qty - allocated;
+    if (leftover > 0) {
+        std::vector<Share*> ranked;
+        ranked.reserve(shares.size());
+        for (auto& share : shares) {
+            if (share.fill < share.order->visible()) {
+                ranked.push_back(&share);
+            }
+        }
+        std::stable_sort(ranked.begin(), ranked.end(), [this](const Share* a, const Share* b) {
+            switch (tie_break_) {
+                case ProRataTieBreak::LargestRemainder:
+                    return a->fraction > b->fraction;
+                case ProRataTieBreak::OrderID:
+                    return a->order->id < b->order->id;
+                case ProRataTieBreak::Time:
+                    return a->order->timestamp < b->order->timestamp;
+            }
+            return false;
+        });
+        for (Share* share : ranked) {
+            if (leftover == 0) break;
+            ++share->fill;
+            --leftover;
+        }
+    }
+
+    // Execute in queue order so trade IDs follow time priority
+    for (auto& share : shares) {
+        if (share.fill == 0) continue;
+        Order& resting = *share.order;
+
+        aggressor.filled += share.fill;
+        resting.filled += share.fill;
+        resting.display_left -= share.fill;
+        resting.status = resting.is_filled() ?
+            OrderStatus::Filled : OrderStatus::PartiallyFilled;
+
+        Trade trade = aggressor.is_buy() ?
+            create_trade(aggressor, resting, level.price, share.fill, aggressor.side) :
+            create_trade(resting, aggressor, level.price, share.fill, aggressor.side);
+        trades.push_back(trade);
+
+        if (listener) {
+            listener->on_trade(trade);
+            if (resting.is_filled()) {
+                listener->on_order_filled(resting);
+            } else {
+                listener->on_order_partially_filled(resting, share.fill);
+            }
+        }
+    }
+
+    if (listener) {
+        if (aggressor.is_filled()) {
+            listener->on_order_filled(aggressor);
+        } else {
+            listener->on_order_partially_filled(aggressor, qty);
+        }
+    }
+
+    // Drop filled orders, refill exhausted icebergs in place and rebuild
+    // the level total
+    level.total_quantity = 0;
+    for (auto it = level.orders.begin(); it != level.orders.end();) {
+        if (it->is_filled()) {
+            order_locations_.erase(it->id);
+            it = level.orders.erase(it);
+        } else {
+            if (it->visible() == 0) {
+                it->display_left = it->display_quantity;
+            }
+            level.total_quantity += it->visible();
+            ++it;
+        }
+    }
+}
+
+Quantity OrderBook::resolve_self_trade(Order& aggressor, const Order& resting) const {
+    switch (stp_mode_) {
+        case STPMode::CancelMaker:
+            return resting.remaining();
+        case STPMode::CancelTaker:
+            aggressor.status = OrderStatus::Cancelled;
+            return 0;
+        case STPMode::CancelBoth:
+            aggressor.status = OrderStatus::Cancelled;
+            return resting.remaining();
+        case STPMode::Decrement: {
+            Quantity qty = std::min(aggressor.remaining(), resting.remaining());
+            aggressor.quantity -= qty;
+            if (aggressor.remaining() == 0) {
+                aggressor.status = OrderStatus::Cancelled;
+            }
+            return qty;
+        }
+    }
+    return resting.remaining();
+}
+
 // Explicit template instantiations
 template std::vector<Trade> OrderBook::match_against_side(
     Order&, std::map<Price, PriceLevel, std::greater<Price>>&, TradeListener*);
@@ -229,6 +446,7 @@ template std::vector<Trade> OrderBook::match_against_side(
 void OrderBook::add_to_book(Order order) {
     order.status = order.filled > 0 ?
         OrderStatus::PartiallyFilled : OrderStatus::New;
+    order.display_left = order.display_quantity;
 
     OrderLocation loc{order.id, order.price, order.side};
     order_locations_[order.id] = loc;
@@ -265,6 +483,77 @@ void OrderBook::remove_from_book(uint64_t order_id, Price price, Side side) {
     order_locations_.erase(order_id);
 }
 
+// Expiry of a resting order, or zero if it never expires
+static Timestamp order_expiry(const Order& order) {
+    constexpr Timestamp day = std::chrono::hours(24);
+    switch (order.tif) {
+        case TimeInForce::GTD:
+            return order.expire_time;
+        case TimeInForce::DAY:
+            if (order.expire_time.count() != 0) {
+                return order.expire_time;
+            }
+            return (order.timestamp / day + 1) * day;
+        default:
+            return Timestamp(0);
+    }
+}
+
+std::vector<Order> OrderBook::expire_orders(Timestamp now) {
+    std::unique_lock lock(mutex_);
+
+    std::vector<Order> expired;
+    auto collect = [&](const auto& side) {
+        for (const auto& [price, level] : side) {
+            for (const auto& order : level.orders) {
+                Timestamp expiry = order_expiry(order);
+                if (expiry.count() != 0 && expiry <= now) {
+                    expired.push_back(order);
+                }
+            }
+        }
+    };
+    collect(bids_);
+    collect(asks_);
+
+    for (auto& order : expired) {
+        remove_from_book(order.id, order.price, order.side);
+        order.status = OrderStatus::Expired;
+    }
+    return expired;
+}
+
+std::vector<Order> OrderBook::resting_orders() const {
+    std::shared_lock lock(mutex_);
+
+    std::vector<Order> orders;
+    orders.reserve(order_locations_.size());
+    for (const auto& [price, level] : bids_) {
+        orders.insert(orders.end(), level.orders.begin(), level.orders.end());
+    }
+    for (const auto& [price, level] : asks_) {
+        orders.insert(orders.end(), level.orders.begin(), level.orders.end());
+    }
+    return orders;
+}
+
+bool OrderBook::restore_order(const Order& order) {
+    std::unique_lock lock(mutex_);
+
+    if (order.remaining() <= 0 || order_locations_.count(order.id) != 0) {
+        return false;
+    }
+    bool crosses = order.is_buy() ?
+        !asks_.empty() && prices_cross(order.price, asks_.begin()->first) :
+        !bids_.empty() && prices_cross(bids_.begin()->first, order.price);
+    if (crosses) {
+        return false;
+    }
+
+    add_to_book(order);
+    return true;
+}
+
 std::optional<Order> OrderBook::cancel_order(uint64_t order_id) {
     std::unique_lock lock(mutex_);
 
@@ -354,6 +643,18 @@ std::optional<Order> OrderBook::modify_order(uint64_t order_id, Price new_price,
         return std::nullopt;
     }
 
+    // Same price, smaller size: amend in place and keep queue position
+    if (new_price == original.price && new_quantity < original.quantity &&
+        new_quantity > original.filled) {
+        if (loc.side == Side::Buy) {
+            bids_[loc.price].reduce_order(order_id, new_quantity);
+        } else {
+            asks_[loc.price].reduce_order(order_id, new_quantity);
+        }
+        original.quantity = new_quantity;
+        return original;
+    }
+
     // Remove old order
     remove_from_book(order_id, loc.price, loc.side);
 
@@ -468,6 +769,37 @@ MarketDepth OrderBook::get_depth(size_t levels) const {
     return depth;
 }
 
+MarketQuote OrderBook::quote_market(Side side, Quantity quantity, Price limit) const {
+    std::shared_lock lock(mutex_);
+
+    MarketQuote quote;
+    __int128 notional = 0;
+
+    auto walk = [&](const auto& book_side) {
+        for (const auto& [price, level] : book_side) {
+            if (quote.filled >= quantity) break;
+            if (limit != 0 && (side == Side::Buy ? price > limit : price < limit)) break;
+            Quantity take = std::min(quantity - quote.filled, level.fillable_quantity());
+            notional += static_cast<__int128>(price) * take;
+            quote.filled += take;
+            quote.worst_price = price;
+        }
+    };
+
+    // A buy consumes asks, a sell consumes bids
+    if (side == Side::Buy) {
+        walk(asks_);
+    } else {
+        walk(bids_);
+    }
+
+    if (quote.filled > 0) {
+        quote.avg_price = static_cast<Price>(notional / quote.filled);
+    }
+
+    return quote;
+}
+
 size_t OrderBook::bid_levels() const {
     std::shared_lock lock(mutex_);
     return bids_.size();
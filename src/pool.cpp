@@ -19,12 +19,9 @@ namespace {
 
 // Q64.96 scaling factor
 constexpr I128 Q96 = I128(1) << 96;
-// Q128: 2^128 - computed as two 64-bit halves to avoid overflow
-inline I128 make_q128() {
-    I128 high = I128(1) << 64;
-    return high << 64;
-}
-const I128 Q128 = make_q128();
+// Scale of fee growth per unit of liquidity. 2^128 does not fit in I128,
+// so the *_x128 fee growth fields hold Q96 values.
+constexpr I128 FEE_GROWTH_SCALE = Q96;
 
 // Fee denominator: 1e6 (1 pip = 0.0001%)
 constexpr uint32_t FEE_DENOMINATOR = 1000000;
@@ -106,65 +103,20 @@ inline U128 div_u256_u128(U256 num, U128 denom) {
     if (denom == 0) return 0;
     if (num.hi == 0) return num.lo / denom;
 
-    // Binary long division for 256/128
-    U256 rem = num;
+    // Restoring long division, one numerator bit at a time from the top.
+    // The remainder stays below denom, but shifting it can carry out of
+    // 128 bits; the carried value is then >= denom and the subtraction
+    // wraps back into range.
+    U128 rem = 0;
     U128 quot = 0;
-
-    // Find highest bit position in numerator
-    int shift = 0;
-    if (rem.hi != 0) {
-        // Count leading zeros in hi
-        U128 tmp = rem.hi;
-        while (tmp != 0) { tmp >>= 1; shift++; }
-        shift += 128;
-    } else {
-        U128 tmp = rem.lo;
-        while (tmp != 0) { tmp >>= 1; shift++; }
-    }
-
-    // Long division bit by bit
-    for (int i = shift - 1; i >= 0; --i) {
-        // Shift quotient left
+    for (int i = 255; i >= 0; --i) {
+        U128 bit = i >= 128 ? (num.hi >> (i - 128)) & 1 : (num.lo >> i) & 1;
+        bool carry = (rem >> 127) != 0;
+        rem = (rem << 1) | bit;
         quot <<= 1;
-
-        // Get bit i of remainder
-        U128 bit;
-        if (i >= 128) {
-            bit = (rem.hi >> (i - 128)) & 1;
-        } else {
-            bit = (rem.lo >> i) & 1;
-        }
-
-        // Build current dividend portion (accumulate bits)
-        // Check if current portion >= denom
-        U256 test;
-        if (i >= 128) {
-            test.hi = rem.hi >> (i - 128);
-            test.lo = 0;
-        } else if (i >= 0) {
-            test.hi = 0;
-            test.lo = (rem.hi << (128 - i)) | (rem.lo >> i);
-        }
-
-        if (test.hi > 0 || test.lo >= denom) {
+        if (carry || rem >= denom) {
+            rem -= denom;
             quot |= 1;
-            // Subtract denom << i from rem
-            U256 sub;
-            if (i >= 128) {
-                sub.hi = denom << (i - 128);
-                sub.lo = 0;
-            } else {
-                sub.lo = denom << i;
-                sub.hi = (i > 0) ? (denom >> (128 - i)) : 0;
-            }
-            // rem -= sub
-            if (rem.lo < sub.lo) {
-                rem.lo = rem.lo - sub.lo;  // Wraps
-                rem.hi -= 1;               // Borrow
-            } else {
-                rem.lo -= sub.lo;
-            }
-            rem.hi -= sub.hi;
         }
     }
     return quot;
@@ -267,11 +219,49 @@ uint64_t LXPool::position_key(const Address& owner, int32_t tick_lower,
     return h;
 }
 
+std::pair<I128, I128> LXPool::fee_growth_inside(const PoolState& pool,
+                                                int32_t tick_lower, int32_t tick_upper) {
+    static const TickInfo empty{};
+    auto lower_it = pool.ticks.find(tick_lower);
+    auto upper_it = pool.ticks.find(tick_upper);
+    const TickInfo& lower = lower_it != pool.ticks.end() ? lower_it->second : empty;
+    const TickInfo& upper = upper_it != pool.ticks.end() ? upper_it->second : empty;
+    int32_t tick_current = pool.slot0.tick;
+
+    I128 fee_below0, fee_below1;
+    if (tick_current >= tick_lower) {
+        fee_below0 = lower.fee_growth_outside0_x128;
+        fee_below1 = lower.fee_growth_outside1_x128;
+    } else {
+        fee_below0 = pool.fee_growth_global0_x128 - lower.fee_growth_outside0_x128;
+        fee_below1 = pool.fee_growth_global1_x128 - lower.fee_growth_outside1_x128;
+    }
+
+    I128 fee_above0, fee_above1;
+    if (tick_current < tick_upper) {
+        fee_above0 = upper.fee_growth_outside0_x128;
+        fee_above1 = upper.fee_growth_outside1_x128;
+    } else {
+        fee_above0 = pool.fee_growth_global0_x128 - upper.fee_growth_outside0_x128;
+        fee_above1 = pool.fee_growth_global1_x128 - upper.fee_growth_outside1_x128;
+    }
+
+    return {pool.fee_growth_global0_x128 - fee_below0 - fee_above0,
+            pool.fee_growth_global1_x128 - fee_below1 - fee_above1};
+}
+
 // =============================================================================
 // Initialize Pool
 // =============================================================================
 
 int32_t LXPool::initialize(const PoolKey& key, I128 sqrt_price_x96) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_initialize(key, sqrt_price_x96);
+    journal_.record(JournalOp::POOL_INITIALIZE, key, sqrt_price_x96, result);
+    return result;
+}
+
+int32_t LXPool::try_initialize(const PoolKey& key, I128 sqrt_price_x96) {
     // Validate: currencies must be sorted
     if (!(key.currency0 < key.currency1)) {
         return errors::CURRENCIES_NOT_SORTED;
@@ -284,7 +274,7 @@ int32_t LXPool::initialize(const PoolKey& key, I128 sqrt_price_x96) {
     }
 
     // Validate: fee not exceeding maximum
-    if (key.fee > fees::FEE_MAX) {
+    if (key.fee > fees::FEE_MAX && key.fee != fees::DYNAMIC_FEE_FLAG) {
         return errors::INVALID_FEE;
     }
 
@@ -293,6 +283,11 @@ int32_t LXPool::initialize(const PoolKey& key, I128 sqrt_price_x96) {
         return errors::INVALID_TICK_RANGE;
     }
 
+    // A named hook contract must have declared its callbacks
+    if (!is_zero_address(key.hooks) && !get_hook_flags(key.hooks)) {
+        return errors::HOOK_NOT_REGISTERED;
+    }
+
     // Call before_initialize hook
     IHooks* hooks = get_hooks(key);
     if (hooks && !hooks->before_initialize(key, sqrt_price_x96)) {
@@ -313,10 +308,12 @@ int32_t LXPool::initialize(const PoolKey& key, I128 sqrt_price_x96) {
 
     // Initialize pool state
     PoolState state{};
+    state.key = key;
     state.slot0.sqrt_price_x96 = sqrt_price_x96;
     state.slot0.tick = tick;
     state.slot0.protocol_fee = 0;
-    state.slot0.lp_fee = key.fee;
+    // Dynamic-fee pools start at zero until set_dynamic_fee is called
+    state.slot0.lp_fee = key.fee == fees::DYNAMIC_FEE_FLAG ? 0 : key.fee;
     state.slot0.unlocked = true;
     state.fee_growth_global0_x128 = 0;
     state.fee_growth_global1_x128 = 0;
@@ -474,6 +471,7 @@ LXPool::SwapState LXPool::compute_swap_step(SwapState state, I128 sqrt_price_tar
         }
     }
 
+    state.fee_amount = fee_amount;
     state.tick = get_tick_at_sqrt_ratio(state.sqrt_price_x96);
     return state;
 }
@@ -482,35 +480,11 @@ LXPool::SwapState LXPool::compute_swap_step(SwapState state, I128 sqrt_price_tar
 // Swap
 // =============================================================================
 
-// Standalone swap (no flash context)
-BalanceDelta LXPool::swap(const PoolKey& key, const SwapParams& params,
-                          const std::vector<uint8_t>& hook_data) {
-    FlashContext dummy_ctx;
-    return swap(dummy_ctx, key, params, hook_data);
-}
-
-// Swap with explicit flash context
-BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParams& params,
-                          const std::vector<uint8_t>& hook_data) {
-    // Call before_swap hook
-    IHooks* hooks = get_hooks(key);
-    if (hooks && !hooks->before_swap(key, params)) {
-        return {0, 0};
-    }
-
-    std::unique_lock lock(pools_mutex_);
-
-    PoolState* pool = get_pool(key);
-    if (!pool) {
-        return {0, 0};
-    }
-
-    // Reentrancy check
-    if (!pool->slot0.unlocked) {
-        return {0, 0};
-    }
-    pool->slot0.unlocked = false;
-
+// Walk a swap across the pool's ticks, moving its price and liquidity,
+// flipping crossed ticks and accruing protocol fees. full is false when the
+// price limit or the pool's liquidity stopped it short of the amount.
+int32_t LXPool::swap_in_pool(PoolState& pool, const PoolKey& key, const SwapParams& params,
+                             BalanceDelta& delta, bool& full) {
     // Determine price limit
     I128 sqrt_price_limit = params.sqrt_price_limit;
     if (sqrt_price_limit == 0) {
@@ -521,16 +495,14 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
 
     // Validate price limit
     if (params.zero_for_one) {
-        if (sqrt_price_limit >= pool->slot0.sqrt_price_x96 ||
+        if (sqrt_price_limit >= pool.slot0.sqrt_price_x96 ||
             sqrt_price_limit <= tick_math::MIN_SQRT_RATIO) {
-            pool->slot0.unlocked = true;
-            return {0, 0};
+            return errors::PRICE_LIMIT_EXCEEDED;
         }
     } else {
-        if (sqrt_price_limit <= pool->slot0.sqrt_price_x96 ||
+        if (sqrt_price_limit <= pool.slot0.sqrt_price_x96 ||
             sqrt_price_limit >= tick_math::MAX_SQRT_RATIO) {
-            pool->slot0.unlocked = true;
-            return {0, 0};
+            return errors::PRICE_LIMIT_EXCEEDED;
         }
     }
 
@@ -538,15 +510,17 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
     SwapState state{};
     state.amount_remaining = params.amount_specified;
     state.amount_calculated = 0;
-    state.sqrt_price_x96 = pool->slot0.sqrt_price_x96;
-    state.tick = pool->slot0.tick;
-    state.liquidity = pool->liquidity;
+    state.sqrt_price_x96 = pool.slot0.sqrt_price_x96;
+    state.tick = pool.slot0.tick;
+    state.liquidity = pool.liquidity;
 
     // Fee for this swap
-    uint32_t swap_fee = pool->slot0.lp_fee;
+    uint32_t swap_fee = pool.slot0.lp_fee;
 
-    // Track total fees for fee_growth_global accumulation
-    I128 total_fee_amount = 0;
+    // Protocol share of the fee, charged in the input token
+    uint32_t protocol_fee = params.zero_for_one
+        ? pool.slot0.protocol_fee & 0xFFFF
+        : pool.slot0.protocol_fee >> 16;
 
     // Main swap loop: iterate through tick ranges
     // Limit iterations to prevent infinite loops
@@ -559,11 +533,11 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
 
         if (params.zero_for_one) {
             // Moving down: find highest initialized tick below current
-            auto it = pool->ticks.lower_bound(state.tick);
-            if (it != pool->ticks.begin()) {
+            auto it = pool.ticks.lower_bound(state.tick);
+            if (it != pool.ticks.begin()) {
                 --it;
                 // Find first initialized tick going backwards
-                while (!it->second.initialized && it != pool->ticks.begin()) --it;
+                while (!it->second.initialized && it != pool.ticks.begin()) --it;
                 if (it->second.initialized && it->first < state.tick) {
                     next_tick = it->first;
                     found_tick = true;
@@ -576,9 +550,9 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
             }
         } else {
             // Moving up: find lowest initialized tick above current
-            auto it = pool->ticks.upper_bound(state.tick);
-            while (it != pool->ticks.end() && !it->second.initialized) ++it;
-            if (it != pool->ticks.end() && it->first > state.tick) {
+            auto it = pool.ticks.upper_bound(state.tick);
+            while (it != pool.ticks.end() && !it->second.initialized) ++it;
+            if (it != pool.ticks.end() && it->first > state.tick) {
                 next_tick = it->first;
                 found_tick = true;
             } else {
@@ -622,6 +596,26 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
         // Compute swap within this step
         state = compute_swap_step(state, sqrt_price_target, swap_fee, params.zero_for_one);
 
+        // Split the step's fee between the protocol and in-range LPs
+        if (state.fee_amount > 0) {
+            I128 protocol_amount = 0;
+            if (protocol_fee > 0 && swap_fee > 0) {
+                protocol_amount = mul_div(state.fee_amount, protocol_fee, swap_fee);
+                I128 protocol_max = state.fee_amount / fees::MAX_PROTOCOL_FEE_FRACTION;
+                if (protocol_amount > protocol_max) protocol_amount = protocol_max;
+            }
+            I128 lp_growth = state.liquidity > 0
+                ? mul_div(state.fee_amount - protocol_amount, FEE_GROWTH_SCALE, state.liquidity)
+                : 0;
+            if (params.zero_for_one) {
+                pool.protocol_fees0 += protocol_amount;
+                pool.fee_growth_global0_x128 += lp_growth;
+            } else {
+                pool.protocol_fees1 += protocol_amount;
+                pool.fee_growth_global1_x128 += lp_growth;
+            }
+        }
+
         // If price didn't move and we still have amount, we're done
         if (state.sqrt_price_x96 == sqrt_price_before && state.amount_remaining != 0) {
             break;
@@ -629,13 +623,13 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
 
         // Cross tick if reached exactly and it was initialized
         if (state.sqrt_price_x96 == sqrt_price_next && found_tick) {
-            auto tick_it = pool->ticks.find(next_tick);
-            if (tick_it != pool->ticks.end() && tick_it->second.initialized) {
+            auto tick_it = pool.ticks.find(next_tick);
+            if (tick_it != pool.ticks.end() && tick_it->second.initialized) {
                 // Flip fee growth outside when crossing
                 tick_it->second.fee_growth_outside0_x128 =
-                    pool->fee_growth_global0_x128 - tick_it->second.fee_growth_outside0_x128;
+                    pool.fee_growth_global0_x128 - tick_it->second.fee_growth_outside0_x128;
                 tick_it->second.fee_growth_outside1_x128 =
-                    pool->fee_growth_global1_x128 - tick_it->second.fee_growth_outside1_x128;
+                    pool.fee_growth_global1_x128 - tick_it->second.fee_growth_outside1_x128;
 
                 // Update liquidity
                 I128 liquidity_net = tick_it->second.liquidity_net;
@@ -651,13 +645,12 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
     }
 
     // Persist state changes
-    pool->slot0.sqrt_price_x96 = state.sqrt_price_x96;
-    pool->slot0.tick = state.tick;
-    pool->liquidity = state.liquidity;
+    pool.slot0.sqrt_price_x96 = state.sqrt_price_x96;
+    pool.slot0.tick = state.tick;
+    pool.liquidity = state.liquidity;
 
     // Calculate balance delta
     bool exact_in = params.amount_specified > 0;
-    BalanceDelta delta{};
 
     if (params.zero_for_one) {
         // Sold token0, bought token1
@@ -677,6 +670,49 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
             : state.amount_calculated;
     }
 
+    full = state.amount_remaining == 0;
+    return errors::OK;
+}
+
+// Standalone swap (no flash context)
+BalanceDelta LXPool::swap(const PoolKey& key, const SwapParams& params,
+                          const std::vector<uint8_t>& hook_data) {
+    auto journal_lock = journal_.order();
+    FlashContext dummy_ctx;
+    BalanceDelta delta = swap(dummy_ctx, key, params, hook_data);
+    journal_.record(JournalOp::POOL_SWAP, key, params, hook_data, delta);
+    return delta;
+}
+
+// Swap with explicit flash context
+BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParams& params,
+                          const std::vector<uint8_t>& hook_data) {
+    // Call before_swap hook
+    IHooks* hooks = get_hooks(key);
+    if (hooks && !hooks->before_swap(key, params)) {
+        return {0, 0};
+    }
+
+    std::unique_lock lock(pools_mutex_);
+
+    PoolState* pool = get_pool(key);
+    if (!pool) {
+        return {0, 0};
+    }
+
+    // Reentrancy check
+    if (!pool->slot0.unlocked) {
+        return {0, 0};
+    }
+    pool->slot0.unlocked = false;
+
+    BalanceDelta delta{};
+    bool full = false;
+    if (swap_in_pool(*pool, key, params, delta, full) != errors::OK) {
+        pool->slot0.unlocked = true;
+        return {0, 0};
+    }
+
     // Unlock pool
     pool->slot0.unlocked = true;
 
@@ -699,12 +735,112 @@ BalanceDelta LXPool::swap(FlashContext& ctx, const PoolKey& key, const SwapParam
     return delta;
 }
 
+// Quotes run on a copy of the pool's price, liquidity and ticks; positions
+// are left out as swaps never touch them
+int32_t LXPool::quote_swap(const PoolKey& key, const SwapParams& params, bool allow_partial,
+                           BalanceDelta& delta, bool& full) const {
+    std::shared_lock lock(pools_mutex_);
+
+    const PoolState* pool = get_pool(key);
+    if (!pool) {
+        return errors::POOL_NOT_INITIALIZED;
+    }
+
+    PoolState sim;
+    sim.key = pool->key;
+    sim.slot0 = pool->slot0;
+    sim.fee_growth_global0_x128 = pool->fee_growth_global0_x128;
+    sim.fee_growth_global1_x128 = pool->fee_growth_global1_x128;
+    sim.protocol_fees0 = pool->protocol_fees0;
+    sim.protocol_fees1 = pool->protocol_fees1;
+    sim.liquidity = pool->liquidity;
+    sim.ticks = pool->ticks;
+    lock.unlock();
+
+    BalanceDelta quoted{};
+    bool quoted_full = false;
+    if (int32_t rc = swap_in_pool(sim, key, params, quoted, quoted_full); rc != errors::OK) {
+        return rc;
+    }
+    if (!quoted_full && !allow_partial) {
+        return errors::INSUFFICIENT_LIQUIDITY;
+    }
+
+    delta = quoted;
+    full = quoted_full;
+    return errors::OK;
+}
+
+// =============================================================================
+// Trigger Swaps
+// =============================================================================
+
+int32_t LXPool::place_trigger_swap(const LXAccount& owner, const PoolKey& key,
+                                   const SwapParams& params, I128 trigger_px_x18,
+                                   TriggerDir direction, uint64_t& trigger_id) {
+    if (trigger_px_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+    if (!pool_exists(key)) {
+        return errors::POOL_NOT_INITIALIZED;
+    }
+
+    std::lock_guard lock(triggers_mutex_);
+    trigger_id = next_trigger_id_++;
+    triggers_.push_back({trigger_id, owner, key, params, trigger_px_x18, direction});
+    return errors::OK;
+}
+
+int32_t LXPool::execute_triggers(const PoolKey& key, std::vector<TriggeredSwap>& fired) {
+    if (!pool_exists(key)) {
+        return errors::POOL_NOT_INITIALIZED;
+    }
+
+    std::lock_guard lock(triggers_mutex_);
+    uint64_t pool_id = key.id();
+    for (auto it = triggers_.begin(); it != triggers_.end();) {
+        auto slot0 = get_slot0(key);
+        if (it->key.id() != pool_id || !slot0) {
+            ++it;
+            continue;
+        }
+
+        // currency1 per currency0, from sqrt(price) in Q64.96
+        I128 px_x18 = mul_div(mul_div(slot0->sqrt_price_x96, slot0->sqrt_price_x96, Q96),
+                              X18_ONE, Q96);
+        bool crossed = it->direction == TriggerDir::BELOW ? px_x18 <= it->trigger_px_x18
+                                                          : px_x18 >= it->trigger_px_x18;
+        if (!crossed) {
+            ++it;
+            continue;
+        }
+
+        // A swap that moves nothing (hook veto, price limit) stays registered
+        BalanceDelta delta = swap(it->key, it->params);
+        if (delta.amount0 == 0 && delta.amount1 == 0) {
+            ++it;
+            continue;
+        }
+        fired.push_back({it->id, it->owner, delta});
+        it = triggers_.erase(it);
+    }
+    return errors::OK;
+}
+
 // =============================================================================
 // Modify Liquidity
 // =============================================================================
 
 BalanceDelta LXPool::modify_liquidity(const PoolKey& key, const ModifyLiquidityParams& params,
                                        const std::vector<uint8_t>& hook_data) {
+    auto journal_lock = journal_.order();
+    BalanceDelta delta = try_modify_liquidity(key, params, hook_data);
+    journal_.record(JournalOp::POOL_MODIFY_LIQUIDITY, key, params, hook_data, delta);
+    return delta;
+}
+
+BalanceDelta LXPool::try_modify_liquidity(const PoolKey& key, const ModifyLiquidityParams& params,
+                                          const std::vector<uint8_t>& hook_data) {
     // Validate tick range
     if (params.tick_lower >= params.tick_upper) {
         return {0, 0};
@@ -773,26 +909,7 @@ BalanceDelta LXPool::modify_liquidity(const PoolKey& key, const ModifyLiquidityP
     }
 
     // Compute fee growth inside range
-    I128 fee_below0, fee_below1;
-    if (tick_current >= params.tick_lower) {
-        fee_below0 = lower.fee_growth_outside0_x128;
-        fee_below1 = lower.fee_growth_outside1_x128;
-    } else {
-        fee_below0 = pool->fee_growth_global0_x128 - lower.fee_growth_outside0_x128;
-        fee_below1 = pool->fee_growth_global1_x128 - lower.fee_growth_outside1_x128;
-    }
-
-    I128 fee_above0, fee_above1;
-    if (tick_current < params.tick_upper) {
-        fee_above0 = upper.fee_growth_outside0_x128;
-        fee_above1 = upper.fee_growth_outside1_x128;
-    } else {
-        fee_above0 = pool->fee_growth_global0_x128 - upper.fee_growth_outside0_x128;
-        fee_above1 = pool->fee_growth_global1_x128 - upper.fee_growth_outside1_x128;
-    }
-
-    I128 fee_inside0 = pool->fee_growth_global0_x128 - fee_below0 - fee_above0;
-    I128 fee_inside1 = pool->fee_growth_global1_x128 - fee_below1 - fee_above1;
+    auto [fee_inside0, fee_inside1] = fee_growth_inside(*pool, params.tick_lower, params.tick_upper);
 
     // Update position
     Address owner{};  // Would be caller address in production
@@ -806,12 +923,12 @@ BalanceDelta LXPool::modify_liquidity(const PoolKey& key, const ModifyLiquidityP
         tokens_owed0 = mul_div(
             fee_inside0 - pos.fee_growth_inside0_last_x128,
             pos.liquidity,
-            Q128
+            FEE_GROWTH_SCALE
         );
         tokens_owed1 = mul_div(
             fee_inside1 - pos.fee_growth_inside1_last_x128,
             pos.liquidity,
-            Q128
+            FEE_GROWTH_SCALE
         );
     }
 
@@ -866,12 +983,66 @@ BalanceDelta LXPool::modify_liquidity(const PoolKey& key, const ModifyLiquidityP
     return total_delta;
 }
 
+// =============================================================================
+// Collect Fees
+// =============================================================================
+
+std::optional<BalanceDelta> LXPool::collect_fees(const PoolKey& key, const Address& owner,
+                                                 int32_t tick_lower, int32_t tick_upper,
+                                                 uint64_t salt) {
+    auto journal_lock = journal_.order();
+    std::optional<BalanceDelta> delta = try_collect_fees(key, owner, tick_lower, tick_upper, salt);
+    journal_.record(JournalOp::POOL_COLLECT_FEES, key, owner, tick_lower, tick_upper, salt, delta);
+    return delta;
+}
+
+std::optional<BalanceDelta> LXPool::try_collect_fees(const PoolKey& key, const Address& owner,
+                                                     int32_t tick_lower, int32_t tick_upper,
+                                                     uint64_t salt) {
+    std::unique_lock lock(pools_mutex_);
+
+    PoolState* pool = get_pool(key);
+    if (!pool) return std::nullopt;
+
+    auto it = pool->positions.find(position_key(owner, tick_lower, tick_upper, salt));
+    if (it == pool->positions.end()) return std::nullopt;
+    PositionInfo& pos = it->second;
+
+    // Credit fees accrued since the position was last modified
+    auto [fee_inside0, fee_inside1] = fee_growth_inside(*pool, tick_lower, tick_upper);
+    if (pos.liquidity > 0) {
+        pos.tokens_owed0 += mul_div(fee_inside0 - pos.fee_growth_inside0_last_x128, pos.liquidity, FEE_GROWTH_SCALE);
+        pos.tokens_owed1 += mul_div(fee_inside1 - pos.fee_growth_inside1_last_x128, pos.liquidity, FEE_GROWTH_SCALE);
+    }
+    pos.fee_growth_inside0_last_x128 = fee_inside0;
+    pos.fee_growth_inside1_last_x128 = fee_inside1;
+
+    // Negative delta: pool pays out
+    BalanceDelta delta{-pos.tokens_owed0, -pos.tokens_owed1};
+    pos.tokens_owed0 = 0;
+    pos.tokens_owed1 = 0;
+
+    if (locked_) {
+        currency_deltas_[currency_hash(key.currency0)] += delta.amount0;
+        currency_deltas_[currency_hash(key.currency1)] += delta.amount1;
+    }
+    return delta;
+}
+
 // =============================================================================
 // Donate
 // =============================================================================
 
 BalanceDelta LXPool::donate(const PoolKey& key, I128 amount0, I128 amount1,
                              const std::vector<uint8_t>& hook_data) {
+    auto journal_lock = journal_.order();
+    BalanceDelta delta = try_donate(key, amount0, amount1, hook_data);
+    journal_.record(JournalOp::POOL_DONATE, key, amount0, amount1, hook_data, delta);
+    return delta;
+}
+
+BalanceDelta LXPool::try_donate(const PoolKey& key, I128 amount0, I128 amount1,
+                                 const std::vector<uint8_t>& hook_data) {
     // Call before hook
     IHooks* hooks = get_hooks(key);
     if (hooks && !hooks->before_donate(key, amount0, amount1)) {
@@ -892,10 +1063,10 @@ BalanceDelta LXPool::donate(const PoolKey& key, I128 amount0, I128 amount1,
 
     // Distribute donation to fee growth (all in-range LPs benefit)
     if (amount0 > 0) {
-        pool->fee_growth_global0_x128 += mul_div(amount0, Q128, pool->liquidity);
+        pool->fee_growth_global0_x128 += mul_div(amount0, FEE_GROWTH_SCALE, pool->liquidity);
     }
     if (amount1 > 0) {
-        pool->fee_growth_global1_x128 += mul_div(amount1, Q128, pool->liquidity);
+        pool->fee_growth_global1_x128 += mul_div(amount1, FEE_GROWTH_SCALE, pool->liquidity);
     }
 
     BalanceDelta delta{amount0, amount1};
@@ -1010,24 +1181,94 @@ std::optional<PositionInfo> LXPool::get_position(const PoolKey& key,
     return it != pool->positions.end() ? std::optional{it->second} : std::nullopt;
 }
 
+std::optional<std::vector<std::pair<int32_t, TickInfo>>> LXPool::get_ticks(
+    const PoolKey& key, int32_t tick_lower, int32_t tick_upper) const {
+    std::shared_lock lock(pools_mutex_);
+    const PoolState* pool = get_pool(key);
+    if (!pool) return std::nullopt;
+
+    std::vector<std::pair<int32_t, TickInfo>> ticks;
+    if (tick_lower > tick_upper) return ticks;
+    auto end = pool->ticks.upper_bound(tick_upper);
+    for (auto it = pool->ticks.lower_bound(tick_lower); it != end; ++it) {
+        if (it->second.initialized) {
+            ticks.emplace_back(it->first, it->second);
+        }
+    }
+    return ticks;
+}
+
 bool LXPool::pool_exists(const PoolKey& key) const {
     std::shared_lock lock(pools_mutex_);
     return pools_.find(key.id()) != pools_.end();
 }
 
+std::vector<PoolKey> LXPool::list_pools() const {
+    std::shared_lock lock(pools_mutex_);
+    std::vector<PoolKey> keys;
+    keys.reserve(pools_.size());
+    for (const auto& [id, pool] : pools_) {
+        keys.push_back(pool.key);
+    }
+    return keys;
+}
+
 // =============================================================================
 // Protocol Fee Management
 // =============================================================================
 
-void LXPool::set_protocol_fee(const PoolKey& key, uint32_t new_fee) {
+int32_t LXPool::set_protocol_fee(const PoolKey& key, uint32_t new_fee) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_protocol_fee(key, new_fee);
+    journal_.record(JournalOp::POOL_SET_PROTOCOL_FEE, key, new_fee, result);
+    return result;
+}
+
+int32_t LXPool::try_set_protocol_fee(const PoolKey& key, uint32_t new_fee) {
     std::unique_lock lock(pools_mutex_);
     PoolState* pool = get_pool(key);
-    if (pool) {
-        pool->slot0.protocol_fee = new_fee;
+    if (!pool) {
+        return errors::POOL_NOT_INITIALIZED;
+    }
+    uint32_t max_fee = pool->slot0.lp_fee / fees::MAX_PROTOCOL_FEE_FRACTION;
+    if ((new_fee & 0xFFFF) > max_fee || (new_fee >> 16) > max_fee) {
+        return errors::INVALID_FEE;
     }
+    pool->slot0.protocol_fee = new_fee;
+    return errors::OK;
+}
+
+int32_t LXPool::set_dynamic_fee(const PoolKey& key, uint32_t new_fee) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_dynamic_fee(key, new_fee);
+    journal_.record(JournalOp::POOL_SET_DYNAMIC_FEE, key, new_fee, result);
+    return result;
+}
+
+int32_t LXPool::try_set_dynamic_fee(const PoolKey& key, uint32_t new_fee) {
+    if (key.fee != fees::DYNAMIC_FEE_FLAG) {
+        return errors::NOT_DYNAMIC_FEE;
+    }
+    if (new_fee > fees::FEE_MAX) {
+        return errors::INVALID_FEE;
+    }
+    std::unique_lock lock(pools_mutex_);
+    PoolState* pool = get_pool(key);
+    if (!pool) {
+        return errors::POOL_NOT_INITIALIZED;
+    }
+    pool->slot0.lp_fee = new_fee;
+    return errors::OK;
 }
 
 BalanceDelta LXPool::collect_protocol(const PoolKey& key, const Address& recipient) {
+    auto journal_lock = journal_.order();
+    BalanceDelta delta = try_collect_protocol(key, recipient);
+    journal_.record(JournalOp::POOL_COLLECT_PROTOCOL, key, recipient, delta);
+    return delta;
+}
+
+BalanceDelta LXPool::try_collect_protocol(const PoolKey& key, const Address& recipient) {
     std::unique_lock lock(pools_mutex_);
     PoolState* pool = get_pool(key);
     if (!pool) {
@@ -1062,6 +1303,37 @@ void LXPool::unregister_hooks(const Address& hook_addr) {
     hooks_.erase(address_hash(hook_addr));
 }
 
+int32_t LXPool::register_hook_flags(const Address& hook_addr, uint32_t flags) {
+    if (is_zero_address(hook_addr) || !valid_hook_flags(flags)) {
+        return errors::INVALID_HOOK_FLAGS;
+    }
+    std::unique_lock lock(hooks_mutex_);
+    hook_flags_[address_hash(hook_addr)] = flags;
+    return errors::OK;
+}
+
+std::optional<uint32_t> LXPool::get_hook_flags(const Address& hook_addr) const {
+    std::shared_lock lock(hooks_mutex_);
+    auto it = hook_flags_.find(address_hash(hook_addr));
+    if (it == hook_flags_.end()) return std::nullopt;
+    return it->second;
+}
+
+bool LXPool::valid_hook_flags(uint32_t flags) {
+    if (flags == 0 || (flags & ~hook_flags::ALL) != 0) return false;
+
+    static constexpr std::pair<uint32_t, uint32_t> requires_base[] = {
+        {hook_flags::BEFORE_SWAP_RETURNS_DELTA, hook_flags::BEFORE_SWAP},
+        {hook_flags::AFTER_SWAP_RETURNS_DELTA, hook_flags::AFTER_SWAP},
+        {hook_flags::AFTER_ADD_LIQUIDITY_RETURNS_DELTA, hook_flags::AFTER_ADD_LIQUIDITY},
+        {hook_flags::AFTER_REMOVE_LIQUIDITY_RETURNS_DELTA, hook_flags::AFTER_REMOVE_LIQUIDITY},
+    };
+    for (const auto& [delta, base] : requires_base) {
+        if ((flags & delta) && !(flags & base)) return false;
+    }
+    return true;
+}
+
 // =============================================================================
 // Statistics
 // =============================================================================
@@ -1077,4 +1349,73 @@ LXPool::Stats LXPool::get_stats() const {
     };
 }
 
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXPool::snapshot(SnapshotWriter& out) const {
+    {
+        std::shared_lock lock(pools_mutex_);
+        out.put(static_cast<uint64_t>(pools_.size()));
+        for (const auto& [id, pool] : pools_) {
+            out.put(id);
+            out.put(pool.key);
+            out.put(pool.slot0);
+            out.put(pool.fee_growth_global0_x128);
+            out.put(pool.fee_growth_global1_x128);
+            out.put(pool.protocol_fees0);
+            out.put(pool.protocol_fees1);
+            out.put(pool.liquidity);
+            out.put(pool.ticks);
+            out.put(pool.positions);
+        }
+    }
+    {
+        std::lock_guard lock(triggers_mutex_);
+        out.put(triggers_);
+        out.put(next_trigger_id_);
+    }
+    out.put(total_swaps_.load(std::memory_order_relaxed));
+    out.put(total_liquidity_ops_.load(std::memory_order_relaxed));
+}
+
+bool LXPool::restore(SnapshotReader& in) {
+    std::unordered_map<uint64_t, PoolState> pools;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint64_t id = 0;
+        PoolState pool{};
+        if (!in.get(id) || !in.get(pool.key) || !in.get(pool.slot0) ||
+            !in.get(pool.fee_growth_global0_x128) || !in.get(pool.fee_growth_global1_x128) ||
+            !in.get(pool.protocol_fees0) || !in.get(pool.protocol_fees1) ||
+            !in.get(pool.liquidity) || !in.get(pool.ticks) || !in.get(pool.positions)) {
+            return false;
+        }
+        pools.emplace(id, std::move(pool));
+    }
+
+    std::vector<TriggerSwap> triggers;
+    uint64_t next_trigger_id = 0;
+    uint64_t total_swaps = 0;
+    uint64_t total_liquidity_ops = 0;
+    if (!in.get(triggers) || !in.get(next_trigger_id) ||
+        !in.get(total_swaps) || !in.get(total_liquidity_ops)) {
+        return false;
+    }
+
+    {
+        std::unique_lock lock(pools_mutex_);
+        pools_ = std::move(pools);
+    }
+    {
+        std::lock_guard lock(triggers_mutex_);
+        triggers_ = std::move(triggers);
+        next_trigger_id_ = next_trigger_id;
+    }
+    total_swaps_.store(total_swaps, std::memory_order_relaxed);
+    total_liquidity_ops_.store(total_liquidity_ops, std::memory_order_relaxed);
+    return true;
+}
+
 } // namespace lux
@@ -19,6 +19,13 @@ LXVault::LXVault() = default;
 // =============================================================================
 
 int32_t LXVault::create_market(const MarketConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_create_market(config);
+    journal_.record(JournalOp::VAULT_CREATE_MARKET, config, result);
+    return result;
+}
+
+int32_t LXVault::try_create_market(const MarketConfig& config) {
     std::unique_lock lock(markets_mutex_);
 
     if (markets_.find(config.market_id) != markets_.end()) {
@@ -44,12 +51,53 @@ int32_t LXVault::create_market(const MarketConfig& config) {
 }
 
 int32_t LXVault::update_market(const MarketConfig& config) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_update_market(config);
+    journal_.record(JournalOp::VAULT_UPDATE_MARKET, config, result);
+    return result;
+}
+
+int32_t LXVault::try_update_market(const MarketConfig& config) {
+    std::shared_lock accounts_lock(accounts_mutex_);
     std::unique_lock lock(markets_mutex_);
 
     auto it = markets_.find(config.market_id);
     if (it == markets_.end()) {
         return errors::MARKET_NOT_FOUND;
     }
+    if (config.base_currency != it->second.base_currency ||
+        config.quote_currency != it->second.quote_currency) {
+        return errors::INVALID_CURRENCY;
+    }
+    if (config.maintenance_margin_x18 <= 0 ||
+        config.maintenance_margin_x18 > config.initial_margin_x18) {
+        return errors::INVALID_LEVERAGE;
+    }
+
+    // No account holding this market may become liquidatable by the change
+    for (const auto& [hash, state] : accounts_) {
+        auto pos_it = state.positions.find(config.market_id);
+        if (pos_it == state.positions.end() || pos_it->second.size_x18 == 0) continue;
+
+        I128 equity = 0;
+        for (const auto& [currency_hash, bal] : state.balances) {
+            equity += bal;
+        }
+        I128 old_maintenance = 0;
+        I128 new_maintenance = 0;
+        for (const auto& [market_id, position] : state.positions) {
+            auto config_it = markets_.find(market_id);
+            if (config_it == markets_.end()) continue;
+            equity += position.unrealized_pnl_x18;
+            I128 mm = calculate_maintenance_margin(position, config_it->second);
+            old_maintenance += mm;
+            new_maintenance += market_id == config.market_id ?
+                calculate_maintenance_margin(position, config) : mm;
+        }
+        if (new_maintenance > old_maintenance && new_maintenance >= equity) {
+            return errors::INSUFFICIENT_MARGIN;
+        }
+    }
 
     it->second = config;
     return errors::OK;
@@ -67,11 +115,29 @@ bool LXVault::market_exists(uint32_t market_id) const {
     return markets_.find(market_id) != markets_.end();
 }
 
+std::vector<uint32_t> LXVault::list_markets() const {
+    std::shared_lock lock(markets_mutex_);
+    std::vector<uint32_t> ids;
+    ids.reserve(markets_.size());
+    for (const auto& [market_id, config] : markets_) {
+        ids.push_back(market_id);
+    }
+    std::sort(ids.begin(), ids.end());
+    return ids;
+}
+
 // =============================================================================
 // Deposit/Withdraw
 // =============================================================================
 
 int32_t LXVault::deposit(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_deposit(account, token, amount_x18);
+    journal_.record(JournalOp::VAULT_DEPOSIT, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_deposit(const LXAccount& account, const Currency& token, I128 amount_x18) {
     if (amount_x18 <= 0) {
         return errors::INVALID_PRICE;
     }
@@ -91,7 +157,44 @@ int32_t LXVault::deposit(const LXAccount& account, const Currency& token, I128 a
     return errors::OK;
 }
 
+int32_t LXVault::deposit_multi(const LXAccount& account,
+                               const std::vector<std::pair<Currency, I128>>& deposits) {
+    auto journal_lock = journal_.order();
+    for (const auto& [token, amount_x18] : deposits) {
+        if (amount_x18 <= 0) {
+            return errors::INVALID_PRICE;
+        }
+    }
+
+    std::unique_lock lock(accounts_mutex_);
+    AccountState* state = get_or_create_account(account);
+    for (const auto& [token, amount_x18] : deposits) {
+        uint64_t currency_hash = 0;
+        for (auto b : token.addr) currency_hash = currency_hash * 31 + b;
+        state->balances[currency_hash] += amount_x18;
+    }
+    state->last_update_time = static_cast<uint64_t>(
+        std::chrono::duration_cast<std::chrono::seconds>(
+            std::chrono::system_clock::now().time_since_epoch()
+        ).count()
+    );
+    lock.unlock();
+
+    // Replaying the deposits one by one ends in the same balances
+    for (const auto& [token, amount_x18] : deposits) {
+        journal_.record(JournalOp::VAULT_DEPOSIT, account, token, amount_x18, errors::OK);
+    }
+    return errors::OK;
+}
+
 int32_t LXVault::withdraw(const LXAccount& account, const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_withdraw(account, token, amount_x18);
+    journal_.record(JournalOp::VAULT_WITHDRAW, account, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_withdraw(const LXAccount& account, const Currency& token, I128 amount_x18) {
     if (amount_x18 <= 0) {
         return errors::INVALID_PRICE;
     }
@@ -124,7 +227,7 @@ int32_t LXVault::withdraw(const LXAccount& account, const Currency& token, I128
         auto config_it = markets_.find(market_id);
         if (config_it == markets_.end()) continue;
         total_unrealized_pnl += position.unrealized_pnl_x18;
-        total_initial_margin += calculate_initial_margin(position, config_it->second);
+        total_initial_margin += calculate_initial_margin(*state, position, config_it->second);
     }
 
     I128 equity = total_collateral + total_unrealized_pnl;
@@ -147,14 +250,26 @@ int32_t LXVault::withdraw(const LXAccount& account, const Currency& token, I128
 
 int32_t LXVault::transfer(const LXAccount& from, const LXAccount& to,
                           const Currency& token, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_transfer(from, to, token, amount_x18);
+    journal_.record(JournalOp::VAULT_TRANSFER, from, to, token, amount_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_transfer(const LXAccount& from, const LXAccount& to,
+                              const Currency& token, I128 amount_x18) {
     if (amount_x18 <= 0) {
         return errors::INVALID_PRICE;
     }
+    if (from.main != to.main && !cross_owner_transfers_.load(std::memory_order_relaxed)) {
+        return errors::UNAUTHORIZED;
+    }
 
     uint64_t currency_hash = 0;
     for (auto b : token.addr) currency_hash = currency_hash * 31 + b;
 
     std::unique_lock lock(accounts_mutex_);
+    std::shared_lock markets_lock(markets_mutex_);
 
     AccountState* from_state = get_or_create_account(from);
     auto it = from_state->balances.find(currency_hash);
@@ -162,6 +277,22 @@ int32_t LXVault::transfer(const LXAccount& from, const LXAccount& to,
         return errors::INSUFFICIENT_BALANCE;
     }
 
+    // The sender must remain above maintenance margin
+    I128 equity = 0;
+    for (const auto& [hash, bal] : from_state->balances) {
+        equity += bal;
+    }
+    I128 total_maintenance_margin = 0;
+    for (const auto& [market_id, position] : from_state->positions) {
+        auto config_it = markets_.find(market_id);
+        if (config_it == markets_.end()) continue;
+        equity += position.unrealized_pnl_x18;
+        total_maintenance_margin += calculate_maintenance_margin(position, config_it->second);
+    }
+    if (equity - amount_x18 < total_maintenance_margin) {
+        return errors::INSUFFICIENT_MARGIN;
+    }
+
     from_state->balances[currency_hash] -= amount_x18;
 
     AccountState* to_state = get_or_create_account(to);
@@ -201,13 +332,157 @@ I128 LXVault::total_collateral_value(const LXAccount& account) const {
 // =============================================================================
 
 int32_t LXVault::set_margin_mode(const LXAccount& account, uint32_t market_id, MarginMode mode) {
-    (void)market_id;  // Could be used for per-market margin mode in the future
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_margin_mode(account, market_id, mode);
+    journal_.record(JournalOp::VAULT_SET_MARGIN_MODE, account, market_id, mode, result);
+    return result;
+}
+
+int32_t LXVault::try_set_margin_mode(const LXAccount& account, uint32_t market_id, MarginMode mode) {
+    if (!market_exists(market_id)) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    std::unique_lock lock(accounts_mutex_);
+    AccountState* state = get_or_create_account(account);
+    auto pos_it = state->positions.find(market_id);
+    if (pos_it != state->positions.end() && pos_it->second.size_x18 != 0) {
+        return errors::POSITION_OPEN;
+    }
+    state->market_margin_modes[market_id] = mode;
+    return errors::OK;
+}
+
+int32_t LXVault::set_leverage(const LXAccount& account, uint32_t market_id, I128 leverage_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_leverage(account, market_id, leverage_x18);
+    journal_.record(JournalOp::VAULT_SET_LEVERAGE, account, market_id, leverage_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_set_leverage(const LXAccount& account, uint32_t market_id, I128 leverage_x18) {
+    auto config = get_market_config(market_id);
+    if (!config) {
+        return errors::MARKET_NOT_FOUND;
+    }
+    if (leverage_x18 < X18_ONE || leverage_x18 > config->max_leverage_x18) {
+        return errors::INVALID_LEVERAGE;
+    }
+
+    std::unique_lock lock(accounts_mutex_);
+    AccountState* state = get_or_create_account(account);
+    state->leverage_x18[market_id] = leverage_x18;
+    return errors::OK;
+}
+
+int32_t LXVault::set_fee_tier(const LXAccount& account, I128 maker_fee_x18, I128 taker_fee_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_fee_tier(account, maker_fee_x18, taker_fee_x18);
+    journal_.record(JournalOp::VAULT_SET_FEE_TIER, account, maker_fee_x18, taker_fee_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_set_fee_tier(const LXAccount& account, I128 maker_fee_x18, I128 taker_fee_x18) {
+    if (taker_fee_x18 < 0 || maker_fee_x18 < -taker_fee_x18) {
+        return errors::INVALID_FEE;
+    }
+
     std::unique_lock lock(accounts_mutex_);
     AccountState* state = get_or_create_account(account);
-    state->margin_mode = mode;
+    state->fee_tier = FeeTier{maker_fee_x18, taker_fee_x18};
+    return errors::OK;
+}
+
+int32_t LXVault::set_staked_balance(const LXAccount& account, I128 amount_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_staked_balance(account, amount_x18);
+    journal_.record(JournalOp::VAULT_SET_STAKED_BALANCE, account, amount_x18, result);
+    return result;
+}
+
+int32_t LXVault::try_set_staked_balance(const LXAccount& account, I128 amount_x18) {
+    if (amount_x18 < 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(accounts_mutex_);
+    get_or_create_account(account)->staked_x18 = amount_x18;
     return errors::OK;
 }
 
+int32_t LXVault::set_staking_fee_tiers(std::vector<StakingFeeTier> tiers) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_set_staking_fee_tiers(tiers);
+    journal_.record(JournalOp::VAULT_SET_STAKING_FEE_TIERS, tiers, result);
+    return result;
+}
+
+int32_t LXVault::try_set_staking_fee_tiers(std::vector<StakingFeeTier> tiers) {
+    // A discount above 1 would turn the fee into a credit on every fill
+    for (const auto& t : tiers) {
+        if (t.min_staked_x18 < 0 ||
+            t.taker_discount_x18 < 0 || t.taker_discount_x18 > X18_ONE ||
+            t.maker_discount_x18 < 0 || t.maker_discount_x18 > X18_ONE) {
+            return errors::INVALID_FEE;
+        }
+    }
+
+    std::sort(tiers.begin(), tiers.end(), [](const StakingFeeTier& a, const StakingFeeTier& b) {
+        return a.min_staked_x18 < b.min_staked_x18;
+    });
+
+    std::unique_lock lock(accounts_mutex_);
+    staking_fee_tiers_ = std::move(tiers);
+    return errors::OK;
+}
+
+std::optional<FeeTier> LXVault::fee_rates(const LXAccount& account, uint32_t market_id) const {
+    auto config = get_market_config(market_id);
+
+    std::shared_lock lock(accounts_mutex_);
+    const AccountState* state = get_account(account);
+    std::optional<FeeTier> rates;
+    if (state && state->fee_tier) {
+        rates = state->fee_tier;
+    } else if (config) {
+        rates = FeeTier{config->maker_fee_x18, config->taker_fee_x18};
+    }
+    if (!rates || !config || !state) return rates;
+
+    const StakingFeeTier* tier = nullptr;
+    for (const auto& t : staking_fee_tiers_) {
+        if (state->staked_x18 < t.min_staked_x18) break;
+        tier = &t;
+    }
+    if (!tier) return rates;
+
+    // Rebates are not discounted
+    auto discounted = [](I128 fee, I128 discount) {
+        return fee > 0 ? x18::mul(fee, X18_ONE - discount) : fee;
+    };
+    rates->maker_fee_x18 = std::min(rates->maker_fee_x18,
+                                    discounted(config->maker_fee_x18, tier->maker_discount_x18));
+    rates->taker_fee_x18 = std::min(rates->taker_fee_x18,
+                                    discounted(config->taker_fee_x18, tier->taker_discount_x18));
+    return rates;
+}
+
+LXAccountPnL LXVault::get_account_pnl(const LXAccount& account) const {
+    LXAccountPnL pnl{};
+
+    std::shared_lock lock(accounts_mutex_);
+    const AccountState* state = get_account(account);
+    if (!state) return pnl;
+
+    pnl.realized_pnl_x18 = state->total_pnl_x18;
+    pnl.fees_paid_x18 = state->total_fees_x18;
+    pnl.funding_x18 = state->total_funding_x18;
+    for (const auto& [market_id, position] : state->positions) {
+        pnl.unrealized_pnl_x18 += position.unrealized_pnl_x18;
+    }
+    return pnl;
+}
+
 std::optional<AccountState> LXVault::get_account_state(const LXAccount& account) const {
     std::shared_lock lock(accounts_mutex_);
     const AccountState* state = get_account(account);
@@ -242,7 +517,7 @@ LXMarginInfo LXVault::get_margin_info(const LXAccount& account) const {
 
         const MarketConfig& config = config_it->second;
         total_unrealized_pnl += position.unrealized_pnl_x18;
-        total_initial_margin += calculate_initial_margin(position, config);
+        total_initial_margin += calculate_initial_margin(*state, position, config);
         total_maintenance_margin += calculate_maintenance_margin(position, config);
     }
 
@@ -263,6 +538,38 @@ LXMarginInfo LXVault::get_margin_info(const LXAccount& account) const {
     return info;
 }
 
+std::optional<I128> LXVault::buying_power(const LXAccount& account, uint32_t market_id) const {
+    std::shared_lock accounts_lock(accounts_mutex_);
+    std::shared_lock markets_lock(markets_mutex_);
+
+    auto market_it = markets_.find(market_id);
+    if (market_it == markets_.end()) return std::nullopt;
+
+    const AccountState* state = get_account(account);
+    if (!state) return I128{0};
+
+    I128 free_margin = 0;
+    for (const auto& [currency_hash, balance] : state->balances) {
+        free_margin += balance;
+    }
+    for (const auto& [id, position] : state->positions) {
+        auto config_it = markets_.find(id);
+        if (config_it == markets_.end()) continue;
+        free_margin += position.unrealized_pnl_x18;
+        free_margin -= calculate_initial_margin(*state, position, config_it->second);
+    }
+
+    // New notional is margined at the market rate or the account's chosen
+    // leverage, whichever is stricter
+    I128 rate = market_it->second.initial_margin_x18;
+    auto lev_it = state->leverage_x18.find(market_id);
+    if (lev_it != state->leverage_x18.end()) {
+        rate = std::max(rate, x18::div(X18_ONE, lev_it->second));
+    }
+    if (free_margin <= 0 || rate <= 0) return I128{0};
+    return x18::div(free_margin, rate);
+}
+
 I128 LXVault::account_equity_x18(const LXAccount& account) const {
     // Equity = collateral + unrealized PnL
     std::shared_lock lock(accounts_mutex_);
@@ -330,6 +637,71 @@ std::vector<LXPosition> LXVault::get_all_positions(const LXAccount& account) con
     return positions;
 }
 
+bool LXVault::has_positions(const LXAccount& account) const {
+    std::shared_lock lock(accounts_mutex_);
+    const AccountState* state = get_account(account);
+    return state && !state->positions.empty();
+}
+
+int32_t LXVault::transfer_position(const LXAccount& from, const LXAccount& to,
+                                   uint32_t market_id, I128 size_x18, I128 price_x18) {
+    auto journal_lock = journal_.order();
+    int32_t result = try_transfer_position(from, to, market_id, size_x18, price_x18);
+    journal_.record(JournalOp::VAULT_TRANSFER_POSITION, from, to, market_id, size_x18, price_x18,
+                    result);
+    return result;
+}
+
+int32_t LXVault::try_transfer_position(const LXAccount& from, const LXAccount& to,
+                                       uint32_t market_id, I128 size_x18, I128 price_x18) {
+    if (size_x18 <= 0 || price_x18 <= 0) {
+        return errors::INVALID_PRICE;
+    }
+
+    std::unique_lock lock(accounts_mutex_);
+    std::shared_lock markets_lock(markets_mutex_);
+
+    auto config_it = markets_.find(market_id);
+    if (config_it == markets_.end()) {
+        return errors::MARKET_NOT_FOUND;
+    }
+
+    AccountState* from_state = get_or_create_account(from);
+    auto pos_it = from_state->positions.find(market_id);
+    if (pos_it == from_state->positions.end()) {
+        return errors::POSITION_NOT_FOUND;
+    }
+    I128 from_size = pos_it->second.size_x18;
+    bool from_long = from_size > 0;
+    if ((from_long ? from_size : -from_size) < size_x18) {
+        return errors::POSITION_NOT_FOUND;
+    }
+
+    // The receiver must have free margin for the size taken on
+    I128 required_margin = x18::mul(x18::mul(size_x18, price_x18),
+                                    config_it->second.initial_margin_x18);
+    I128 free_margin = 0;
+    if (const AccountState* to_state = get_account(to)) {
+        for (const auto& [hash, bal] : to_state->balances) {
+            free_margin += bal;
+        }
+        for (const auto& [mid, pos] : to_state->positions) {
+            free_margin += pos.unrealized_pnl_x18;
+            auto mit = markets_.find(mid);
+            if (mit != markets_.end()) {
+                free_margin -= calculate_initial_margin(*to_state, pos, mit->second);
+            }
+        }
+    }
+    if (free_margin < required_margin) {
+        return errors::INSUFFICIENT_MARGIN;
+    }
+
+    update_position(*from_state, market_id, !from_long, size_x18, price_x18);
+    update_position(*get_or_create_account(to), market_id, from_long, size_x18, price_x18);
+    return errors::OK;
+}
+
 // =============================================================================
 // Settlement
 // =============================================================================
@@ -368,7 +740,7 @@ int32_t LXVault::pre_check_fills(const std::vector<LXSettlement>& settlements) {
             for (const auto& [mid, pos] : taker_state->positions) {
                 auto mit = markets_.find(mid);
                 if (mit != markets_.end()) {
-                    used_margin += calculate_initial_margin(pos, mit->second);
+                    used_margin += calculate_initial_margin(*taker_state, pos, mit->second);
                 }
             }
 
@@ -382,7 +754,7 @@ int32_t LXVault::pre_check_fills(const std::vector<LXSettlement>& settlements) {
     return errors::OK;
 }
 
-int32_t LXVault::apply_fills(const std::vector<LXSettlement>& settlements) {
+int32_t LXVault::apply_fills(std::vector<LXSettlement>& settlements) {
     std::unique_lock lock(accounts_mutex_);
 
     // FIX: Validate balances before fee deduction to prevent negative balances.
@@ -406,28 +778,68 @@ int32_t LXVault::apply_fills(const std::vector<LXSettlement>& settlements) {
     }
 
     // Second pass: apply all fills atomically
-    for (const auto& settlement : settlements) {
+    auto prior_position = [](const AccountState& state, uint32_t market_id) {
+        auto it = state.positions.find(market_id);
+        return it != state.positions.end() ? it->second : LXPosition{market_id, PositionSide::LONG};
+    };
+    for (auto& settlement : settlements) {
         AccountState* maker_state = get_or_create_account(settlement.maker);
         AccountState* taker_state = get_or_create_account(settlement.taker);
 
         // Update maker position
+        settlement.maker_prior = prior_position(*maker_state, settlement.market_id);
+        I128 maker_pnl = maker_state->total_pnl_x18;
         update_position(*maker_state, settlement.market_id,
                         !settlement.taker_is_buy, // Maker is opposite side
                         settlement.size_x18, settlement.price_x18);
+        settlement.maker_realized_pnl_x18 = maker_state->total_pnl_x18 - maker_pnl;
 
         // Update taker position
+        settlement.taker_prior = prior_position(*taker_state, settlement.market_id);
+        I128 taker_pnl = taker_state->total_pnl_x18;
         update_position(*taker_state, settlement.market_id,
                         settlement.taker_is_buy,
                         settlement.size_x18, settlement.price_x18);
+        settlement.taker_realized_pnl_x18 = taker_state->total_pnl_x18 - taker_pnl;
 
         // Deduct fees (validated above)
         maker_state->balances[quote_hash] -= settlement.maker_fee_x18;
         taker_state->balances[quote_hash] -= settlement.taker_fee_x18;
+        maker_state->total_fees_x18 += settlement.maker_fee_x18;
+        taker_state->total_fees_x18 += settlement.taker_fee_x18;
     }
 
     return errors::OK;
 }
 
+int32_t LXVault::reverse_fill(const LXSettlement& settlement) {
+    std::unique_lock lock(accounts_mutex_);
+
+    AccountState* maker_state = get_or_create_account(settlement.maker);
+    AccountState* taker_state = get_or_create_account(settlement.taker);
+
+    // Put back the positions held before the fill, taker first since it was
+    // applied last
+    auto restore = [&settlement](AccountState& state, const LXPosition& prior, I128 realized_pnl) {
+        if (prior.size_x18 == 0) {
+            state.positions.erase(settlement.market_id);
+        } else {
+            state.positions[settlement.market_id] = prior;
+        }
+        state.total_pnl_x18 -= realized_pnl;
+    };
+    restore(*taker_state, settlement.taker_prior, settlement.taker_realized_pnl_x18);
+    restore(*maker_state, settlement.maker_prior, settlement.maker_realized_pnl_x18);
+
+    uint64_t quote_hash = 0; // Fees were charged in the default currency
+    maker_state->balances[quote_hash] += settlement.maker_fee_x18;
+    taker_state->balances[quote_hash] += settlement.taker_fee_x18;
+    maker_state->total_fees_x18 -= settlement.maker_fee_x18;
+    taker_state->total_fees_x18 -= settlement.taker_fee_x18;
+
+    return errors::OK;
+}
+
 // =============================================================================
 // Liquidation
 // =============================================================================
@@ -436,8 +848,52 @@ bool LXVault::is_liquidatable(const LXAccount& account) const {
     return get_margin_info(account).liquidatable;
 }
 
+std::optional<I128> LXVault::liquidation_price(const LXAccount& account, uint32_t market_id) const {
+    std::shared_lock accounts_lock(accounts_mutex_);
+    std::shared_lock markets_lock(markets_mutex_);
+
+    const AccountState* state = get_account(account);
+    if (!state) return std::nullopt;
+    auto pos_it = state->positions.find(market_id);
+    if (pos_it == state->positions.end() || pos_it->second.size_x18 == 0) {
+        return std::nullopt;
+    }
+    const LXPosition& position = pos_it->second;
+
+    // Cross margin: all collateral and every other position's PnL back this one
+    I128 equity_ex_position = 0;
+    for (const auto& [hash, bal] : state->balances) {
+        equity_ex_position += bal;
+    }
+    I128 total_maintenance_margin = 0;
+    for (const auto& [id, pos] : state->positions) {
+        auto config_it = markets_.find(id);
+        if (config_it == markets_.end()) continue;
+        if (id != market_id) equity_ex_position += pos.unrealized_pnl_x18;
+        total_maintenance_margin += calculate_maintenance_margin(pos, config_it->second);
+    }
+
+    // equity_ex_position + size * (P - entry) * dir = maintenance margin.
+    // Split the division so the X18 scaling cannot overflow.
+    I128 size_abs = position.size_x18 > 0 ? position.size_x18 : -position.size_x18;
+    I128 deficit = total_maintenance_margin - equity_ex_position;
+    I128 offset = (deficit / size_abs) * X18_ONE + (deficit % size_abs) * X18_ONE / size_abs;
+
+    I128 liq_px = position.side == PositionSide::LONG ?
+        position.entry_px_x18 + offset : position.entry_px_x18 - offset;
+    return std::max<I128>(liq_px, 0);
+}
+
 LXLiquidationResult LXVault::liquidate(const LXAccount& liquidator, const LXAccount& account,
                                         uint32_t market_id, I128 size_x18) {
+    auto journal_lock = journal_.order();
+    LXLiquidationResult result = try_liquidate(liquidator, account, market_id, size_x18);
+    journal_.record(JournalOp::VAULT_LIQUIDATE, liquidator, account, market_id, size_x18, result);
+    return result;
+}
+
+LXLiquidationResult LXVault::try_liquidate(const LXAccount& liquidator, const LXAccount& account,
+                                            uint32_t market_id, I128 size_x18) {
     LXLiquidationResult result{};
     result.liquidated = account;
     result.liquidator = liquidator;
@@ -487,6 +943,9 @@ LXLiquidationResult LXVault::liquidate(const LXAccount& liquidator, const LXAcco
 
     total_liquidations_.fetch_add(1, std::memory_order_relaxed);
 
+    lock.unlock();
+    events_.emit(SystemEventKind::LIQUIDATION, Severity::WARNING, market_id, 0, account);
+
     return result;
 }
 
@@ -501,7 +960,21 @@ int32_t LXVault::run_adl(uint32_t market_id) {
 // Funding
 // =============================================================================
 
-int32_t LXVault::accrue_funding(uint32_t market_id) {
+int32_t LXVault::accrue_funding(uint32_t market_id, uint64_t now) {
+    auto journal_lock = journal_.order();
+    if (now == 0) {
+        now = static_cast<uint64_t>(
+            std::chrono::duration_cast<std::chrono::seconds>(
+                std::chrono::system_clock::now().time_since_epoch()
+            ).count()
+        );
+    }
+    int32_t result = try_accrue_funding(market_id, now);
+    journal_.record(JournalOp::VAULT_ACCRUE_FUNDING, market_id, now, result);
+    return result;
+}
+
+int32_t LXVault::try_accrue_funding(uint32_t market_id, uint64_t now) {
     std::unique_lock funding_lock(funding_mutex_);
 
     auto it = funding_.find(market_id);
@@ -510,12 +983,6 @@ int32_t LXVault::accrue_funding(uint32_t market_id) {
     }
 
     FundingState& funding = it->second;
-    uint64_t now = static_cast<uint64_t>(
-        std::chrono::duration_cast<std::chrono::seconds>(
-            std::chrono::system_clock::now().time_since_epoch()
-        ).count()
-    );
-
     if (now < funding.last_funding_time + funding.funding_interval) {
         return errors::OK; // Not time yet
     }
@@ -537,16 +1004,43 @@ int32_t LXVault::accrue_funding(uint32_t market_id) {
 
         // Long pays funding when rate is positive
         if (position.side == PositionSide::LONG) {
-            position.accumulated_funding_x18 -= funding_payment;
-        } else {
-            position.accumulated_funding_x18 += funding_payment;
+            funding_payment = -funding_payment;
         }
+        position.accumulated_funding_x18 += funding_payment;
+        account_state.total_funding_x18 += funding_payment;
         position.last_funding_time = now;
     }
 
     return errors::OK;
 }
 
+std::optional<I128> LXVault::settle_funding(const LXAccount& account, uint32_t market_id) {
+    auto journal_lock = journal_.order();
+    std::optional<I128> amount = try_settle_funding(account, market_id);
+    journal_.record(JournalOp::VAULT_SETTLE_FUNDING, account, market_id, amount);
+    return amount;
+}
+
+std::optional<I128> LXVault::try_settle_funding(const LXAccount& account, uint32_t market_id) {
+    std::unique_lock lock(accounts_mutex_);
+
+    auto it = accounts_.find(account.hash());
+    if (it == accounts_.end()) {
+        return std::nullopt;
+    }
+
+    auto pos_it = it->second.positions.find(market_id);
+    if (pos_it == it->second.positions.end()) {
+        return std::nullopt;
+    }
+
+    // Funding settles in the default quote currency, as fees do in apply_fills
+    I128 amount = pos_it->second.accumulated_funding_x18;
+    it->second.balances[0] += amount;
+    pos_it->second.accumulated_funding_x18 = 0;
+    return amount;
+}
+
 I128 LXVault::funding_rate_x18(uint32_t market_id) const {
     std::shared_lock lock(funding_mutex_);
     auto it = funding_.find(market_id);
@@ -562,6 +1056,12 @@ uint64_t LXVault::next_funding_time(uint32_t market_id) const {
 }
 
 void LXVault::set_funding_rate(uint32_t market_id, I128 rate_x18) {
+    auto journal_lock = journal_.order();
+    try_set_funding_rate(market_id, rate_x18);
+    journal_.record(JournalOp::VAULT_SET_FUNDING_RATE, market_id, rate_x18);
+}
+
+void LXVault::try_set_funding_rate(uint32_t market_id, I128 rate_x18) {
     std::unique_lock lock(funding_mutex_);
     auto it = funding_.find(market_id);
     if (it != funding_.end()) {
@@ -590,6 +1090,9 @@ I128 LXVault::withdraw_from_insurance(I128 amount_x18) {
         withdraw = std::min(amount_x18, current);
     }
 
+    if (withdraw > 0) {
+        events_.emit(SystemEventKind::INSURANCE_FUND_DRAW, Severity::CRITICAL);
+    }
     return withdraw;
 }
 
@@ -665,6 +1168,91 @@ LXVault::Stats LXVault::get_stats() const {
     };
 }
 
+// =============================================================================
+// Snapshot
+// =============================================================================
+
+void LXVault::snapshot(SnapshotWriter& out) const {
+    {
+        std::shared_lock lock(accounts_mutex_);
+        out.put(static_cast<uint64_t>(accounts_.size()));
+        for (const auto& [account_hash, state] : accounts_) {
+            out.put(account_hash);
+            out.put(state.margin_mode);
+            out.put(state.balances);
+            out.put(state.positions);
+            out.put(state.market_margin_modes);
+            out.put(state.leverage_x18);
+            out.put(state.fee_tier);
+            out.put(state.staked_x18);
+            out.put(state.total_pnl_x18);
+            out.put(state.total_fees_x18);
+            out.put(state.total_funding_x18);
+            out.put(state.last_update_time);
+        }
+        out.put(staking_fee_tiers_);
+    }
+    {
+        std::shared_lock lock(markets_mutex_);
+        out.put(markets_);
+    }
+    {
+        std::shared_lock lock(funding_mutex_);
+        out.put(funding_);
+    }
+    out.put(insurance_fund_.load(std::memory_order_relaxed));
+    out.put(total_liquidations_.load(std::memory_order_relaxed));
+    out.put(cross_owner_transfers_.load());
+}
+
+bool LXVault::restore(SnapshotReader& in) {
+    std::unordered_map<uint64_t, AccountState> accounts;
+    uint64_t count = 0;
+    if (!in.get_count(count)) return false;
+    for (uint64_t i = 0; i < count; ++i) {
+        uint64_t account_hash = 0;
+        AccountState state{};
+        if (!in.get(account_hash) || !in.get(state.margin_mode) || !in.get(state.balances) ||
+            !in.get(state.positions) || !in.get(state.market_margin_modes) ||
+            !in.get(state.leverage_x18) || !in.get(state.fee_tier) || !in.get(state.staked_x18) ||
+            !in.get(state.total_pnl_x18) || !in.get(state.total_fees_x18) ||
+            !in.get(state.total_funding_x18) || !in.get(state.last_update_time)) {
+            return false;
+        }
+        accounts.emplace(account_hash, std::move(state));
+    }
+
+    std::vector<StakingFeeTier> staking_fee_tiers;
+    std::unordered_map<uint32_t, MarketConfig> markets;
+    std::unordered_map<uint32_t, FundingState> funding;
+    I128 insurance_fund = 0;
+    uint64_t total_liquidations = 0;
+    bool cross_owner_transfers = false;
+    if (!in.get(staking_fee_tiers) || !in.get(markets) || !in.get(funding) ||
+        !in.get(insurance_fund) || !in.get(total_liquidations) ||
+        !in.get(cross_owner_transfers)) {
+        return false;
+    }
+
+    {
+        std::unique_lock lock(accounts_mutex_);
+        accounts_ = std::move(accounts);
+        staking_fee_tiers_ = std::move(staking_fee_tiers);
+    }
+    {
+        std::unique_lock lock(markets_mutex_);
+        markets_ = std::move(markets);
+    }
+    {
+        std::unique_lock lock(funding_mutex_);
+        funding_ = std::move(funding);
+    }
+    insurance_fund_.store(insurance_fund, std::memory_order_relaxed);
+    total_liquidations_.store(total_liquidations, std::memory_order_relaxed);
+    cross_owner_transfers_.store(cross_owner_transfers);
+    return true;
+}
+
 // =============================================================================
 // Internal Helpers
 // =============================================================================
@@ -676,6 +1264,9 @@ AccountState* LXVault::get_or_create_account(const LXAccount& account) {
         AccountState state;
         state.margin_mode = MarginMode::CROSS;
         state.total_pnl_x18 = 0;
+        state.total_fees_x18 = 0;
+        state.total_funding_x18 = 0;
+        state.staked_x18 = 0;
         state.last_update_time = static_cast<uint64_t>(
             std::chrono::duration_cast<std::chrono::seconds>(
                 std::chrono::system_clock::now().time_since_epoch()
@@ -693,9 +1284,15 @@ const AccountState* LXVault::get_account(const LXAccount& account) const {
     return (it != accounts_.end()) ? &it->second : nullptr;
 }
 
-I128 LXVault::calculate_initial_margin(const LXPosition& pos, const MarketConfig& config) const {
+I128 LXVault::calculate_initial_margin(const AccountState& state, const LXPosition& pos,
+                                       const MarketConfig& config) const {
+    I128 rate = config.initial_margin_x18;
+    auto lev_it = state.leverage_x18.find(pos.market_id);
+    if (lev_it != state.leverage_x18.end()) {
+        rate = std::max(rate, x18::div(X18_ONE, lev_it->second));
+    }
     I128 notional = x18::mul(pos.size_x18 > 0 ? pos.size_x18 : -pos.size_x18, pos.entry_px_x18);
-    return x18::mul(notional, config.initial_margin_x18);
+    return x18::mul(notional, rate);
 }
 
 I128 LXVault::calculate_maintenance_margin(const LXPosition& pos, const MarketConfig& config) const {
@@ -718,8 +1315,14 @@ void LXVault::update_position(AccountState& state, uint32_t market_id,
     bool increasing = (is_buy && position.size_x18 >= 0) ||
                       (!is_buy && position.size_x18 <= 0);
 
-    if (increasing || position.size_x18 == 0) {
-        // Increasing position or opening new
+    if (position.size_x18 == 0) {
+        // Opening new: entry is the fill price. Skips the notional blend
+        // below, which overflows x18::mul above ~170 notional.
+        position.size_x18 = is_buy ? size_x18 : -size_x18;
+        position.entry_px_x18 = price_x18;
+        position.side = is_buy ? PositionSide::LONG : PositionSide::SHORT;
+    } else if (increasing) {
+        // Increasing position
         I128 old_notional = x18::mul(position.size_x18 > 0 ? position.size_x18 : -position.size_x18,
                                       position.entry_px_x18);
         I128 new_notional = x18::mul(size_x18, price_x18);
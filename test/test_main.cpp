@@ -8,6 +8,7 @@
 #include "lux/engine.hpp"
 #include "lux/oracle.hpp"
 #include "lux/book.hpp"
+#include "lux/lx.hpp"
 
 using namespace lux;
 
@@ -304,6 +305,37 @@ TEST(order_modification) {
     ASSERT_EQ(retrieved->price, Order::to_price(99.0));
 }
 
+// Test: Size reduction keeps time priority
+TEST(order_modification_keeps_priority) {
+    OrderBook book(1);
+
+    for (uint64_t id = 1; id <= 2; ++id) {
+        book.place_order(OrderBuilder()
+            .id(id).account(100 + id).side(Side::Buy)
+            .type(OrderType::Limit).price(100.0).quantity(10.0)
+            .tif(TimeInForce::GTC).build());
+    }
+
+    // Shrinking order 1 at the same price keeps it ahead of order 2
+    auto modified = book.modify_order(1, Order::to_price(100.0), Order::to_quantity(4.0));
+    ASSERT(modified.has_value());
+    ASSERT_EQ(modified->quantity, Order::to_quantity(4.0));
+
+    auto depth = book.get_depth(1);
+    ASSERT_EQ(depth.bids[0].quantity, 14.0);
+
+    Order sell = OrderBuilder()
+        .id(3).account(200).side(Side::Sell)
+        .type(OrderType::Limit).price(100.0).quantity(5.0)
+        .tif(TimeInForce::GTC).build();
+
+    auto trades = book.place_order(sell);
+    ASSERT_EQ(trades.size(), 2u);
+    ASSERT_EQ(trades[0].buy_order_id, 1u);
+    ASSERT_EQ(trades[0].quantity, Order::to_quantity(4.0));
+    ASSERT_EQ(trades[1].buy_order_id, 2u);
+}
+
 // Test: Market depth
 TEST(market_depth) {
     OrderBook book(1);
@@ -467,6 +499,10 @@ TEST(oracle_median) {
     ASSERT(price.has_value());
     double p = x18::to_double(*price);
     ASSERT(std::abs(p - 101.0) < 0.0001);
+
+    ASSERT_EQ(oracle.set_aggregation_method(1, AggregationMethod::LAST_KNOWN_GOOD), errors::OK);
+    ASSERT(oracle.get_config(1)->method == AggregationMethod::LAST_KNOWN_GOOD);
+    ASSERT_EQ(oracle.set_aggregation_method(2, AggregationMethod::MEDIAN), errors::MARKET_NOT_FOUND);
 }
 
 // Test: Trimmed mean aggregation
@@ -598,6 +634,12 @@ TEST(oracle_staleness) {
     // Should be stale
     ASSERT(!oracle.is_price_fresh(1));
     ASSERT(oracle.price_age(1) > 60);
+
+    // A wider threshold makes it fresh again
+    ASSERT_EQ(oracle.set_max_staleness(1, 300), errors::OK);
+    ASSERT_EQ(oracle.get_config(1)->max_staleness, 300u);
+    ASSERT(oracle.is_price_fresh(1));
+    ASSERT_EQ(oracle.set_max_staleness(2, 300), errors::MARKET_NOT_FOUND);
 }
 
 // Test: Statistics
@@ -775,6 +817,303 @@ TEST(lxbook_matching) {
     ASSERT(result.filled_size_x18 > 0);
 }
 
+// Test: LXBook stop and take-profit triggers
+TEST(lxbook_stop_triggers) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount trader{};
+    trader.main[19] = 0x02;
+
+    LXOrder ask{};
+    ask.market_id = 1;
+    ask.kind = OrderKind::LIMIT;
+    ask.size_x18 = x18::from_double(10.0);
+    ask.limit_px_x18 = x18::from_double(110.0);
+    ask.tif = TIF::GTC;
+    book.place_order(maker, ask);
+
+    // Buy stop above the market, sell take-profit above the market
+    LXOrder stop{};
+    stop.market_id = 1;
+    stop.is_buy = true;
+    stop.kind = OrderKind::STOP_MARKET;
+    stop.size_x18 = x18::from_double(2.0);
+    stop.trigger_px_x18 = x18::from_double(105.0);
+    auto stop_result = book.place_order(trader, stop);
+    ASSERT(stop_result.oid > 0);
+    ASSERT(stop_result.filled_size_x18 == 0);
+
+    LXOrder take{};
+    take.market_id = 1;
+    take.kind = OrderKind::TAKE_LIMIT;
+    take.size_x18 = x18::from_double(1.0);
+    take.limit_px_x18 = x18::from_double(120.0);
+    take.trigger_px_x18 = x18::from_double(115.0);
+    auto take_result = book.place_order(trader, take);
+
+    // Held off the book: nothing rests or matches until triggered
+    ASSERT(book.get_depth(1).bids.empty());
+    ASSERT(book.evaluate_triggers(1, x18::from_double(104.0)).empty());
+
+    auto fired = book.evaluate_triggers(1, x18::from_double(105.0));
+    ASSERT_EQ(fired.size(), 1u);
+    ASSERT_EQ(fired[0], stop_result.oid);
+    auto state = book.get_order(1, stop_result.oid);
+    ASSERT(state.has_value());
+    ASSERT(state->status == BookOrderStatus::FILLED);
+    ASSERT(book.evaluate_triggers(1, x18::from_double(105.0)).empty());
+
+    // A cancelled conditional order never fires
+    ASSERT_EQ(book.cancel_order(trader, 1, take_result.oid), errors::OK);
+    ASSERT(book.evaluate_triggers(1, x18::from_double(130.0)).empty());
+    ASSERT(book.get_order(1, take_result.oid)->status == BookOrderStatus::CANCELLED);
+}
+
+// Test: LXBook market removal requires an empty market
+TEST(lxbook_remove_market) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    LXAccount trader{};
+    trader.main[19] = 0x01;
+
+    LXOrder bid{};
+    bid.market_id = 1;
+    bid.is_buy = true;
+    bid.kind = OrderKind::LIMIT;
+    bid.size_x18 = x18::from_double(1.0);
+    bid.limit_px_x18 = x18::from_double(90.0);
+    bid.tif = TIF::GTC;
+    book.place_order(trader, bid);
+
+    LXOrder stop{};
+    stop.market_id = 1;
+    stop.kind = OrderKind::STOP_MARKET;
+    stop.size_x18 = x18::from_double(1.0);
+    stop.trigger_px_x18 = x18::from_double(80.0);
+    book.place_order(trader, stop);
+
+    ASSERT_EQ(book.remove_market(1), errors::MARKET_NOT_EMPTY);
+    ASSERT(book.market_exists(1));
+
+    ASSERT_EQ(book.cancel_all(trader, 1), errors::OK);
+    ASSERT_EQ(book.remove_market(1), errors::OK);
+    ASSERT(!book.market_exists(1));
+    ASSERT_EQ(book.remove_market(1), errors::MARKET_NOT_FOUND);
+
+    // The symbol is free to be listed again
+    ASSERT_EQ(book.create_market(config), errors::OK);
+}
+
+TEST(lxbook_quote_fill) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    for (double px : {100.0, 101.0}) {
+        LXOrder ask{};
+        ask.market_id = 1;
+        ask.kind = OrderKind::LIMIT;
+        ask.size_x18 = x18::from_double(2.0);
+        ask.limit_px_x18 = x18::from_double(px);
+        ask.tif = TIF::GTC;
+        book.place_order(maker, ask);
+    }
+
+    // The limit stops the walk at the first level
+    auto quote = book.quote_fill(1, true, x18::from_double(3.0), x18::from_double(100.5));
+    ASSERT(quote.has_value());
+    ASSERT(quote->filled_x18 == x18::from_double(2.0));
+    ASSERT(quote->avg_px_x18 == x18::from_double(100.0));
+
+    // Larger than the book: partial fill across both levels
+    quote = book.quote_fill(1, true, x18::from_double(10.0));
+    ASSERT(quote.has_value());
+    ASSERT(quote->filled_x18 == x18::from_double(4.0));
+    ASSERT(quote->avg_px_x18 == x18::from_double(100.5));
+
+    ASSERT_EQ(book.get_depth(1).asks[0].quantity, 2.0);
+    ASSERT(!book.quote_fill(2, true, x18::from_double(1.0)).has_value());
+}
+
+// Test: LXBook cancel-on-disconnect
+TEST(lxbook_cancel_on_disconnect) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXOrder ask{};
+    ask.market_id = 1;
+    ask.kind = OrderKind::LIMIT;
+    ask.size_x18 = x18::from_double(2.0);
+    ask.limit_px_x18 = x18::from_double(100.0);
+    ask.tif = TIF::GTC;
+
+    ASSERT_EQ(book.heartbeat(maker), errors::NOT_ARMED);
+    ASSERT_EQ(book.arm_cancel_on_disconnect(maker, 60000), errors::OK);
+    book.place_order(maker, ask);
+    ASSERT_EQ(book.heartbeat(maker), errors::OK);
+
+    uint64_t now_ms = static_cast<uint64_t>(
+        std::chrono::duration_cast<std::chrono::milliseconds>(
+            std::chrono::system_clock::now().time_since_epoch()
+        ).count()
+    );
+    ASSERT_EQ(book.check_heartbeats(now_ms), 0u);
+    ASSERT_EQ(book.get_depth(1).asks.size(), 1u);
+
+    // Past the deadline the order goes and the switch disarms
+    ASSERT_EQ(book.check_heartbeats(now_ms + 120000), 1u);
+    ASSERT(book.get_depth(1).asks.empty());
+    ASSERT_EQ(book.heartbeat(maker), errors::NOT_ARMED);
+
+    // A lapsed switch trips before the next placement can match against it
+    ASSERT_EQ(book.arm_cancel_on_disconnect(maker, 1), errors::OK);
+    book.place_order(maker, ask);
+    std::this_thread::sleep_for(std::chrono::milliseconds(5));
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    LXOrder bid = ask;
+    bid.is_buy = true;
+    auto result = book.place_order(taker, bid);
+    ASSERT(result.filled_size_x18 == 0);
+    ASSERT_EQ(book.get_depth(1).asks.size(), 0u);
+
+    // Disarming leaves orders alone
+    ASSERT_EQ(book.arm_cancel_on_disconnect(taker, 1), errors::OK);
+    ASSERT_EQ(book.arm_cancel_on_disconnect(taker, 0), errors::OK);
+    ASSERT_EQ(book.check_heartbeats(now_ms + 120000), 0u);
+    ASSERT_EQ(book.get_depth(1).bids.size(), 1u);
+}
+
+// Test: LXBook open orders for a reconnecting account
+TEST(lxbook_get_open_orders) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    config.market_id = 1;
+    config.symbol_id = 100;
+    book.create_market(config);
+    config.market_id = 2;
+    config.symbol_id = 200;
+    book.create_market(config);
+
+    LXAccount trader{};
+    trader.main[19] = 0x01;
+    LXOrder bid{};
+    bid.is_buy = true;
+    bid.kind = OrderKind::LIMIT;
+    bid.size_x18 = x18::from_double(1.0);
+    bid.limit_px_x18 = x18::from_double(99.0);
+    bid.tif = TIF::GTC;
+
+    bid.market_id = 1;
+    auto first = book.place_order(trader, bid);
+    bid.market_id = 2;
+    auto second = book.place_order(trader, bid);
+    bid.market_id = 1;
+    auto third = book.place_order(trader, bid);
+    ASSERT_EQ(book.cancel_order(trader, 1, first.oid), errors::OK);
+
+    auto in_market = book.get_open_orders(trader, 1);
+    ASSERT_EQ(in_market.size(), 1u);
+    ASSERT_EQ(in_market[0].oid, third.oid);
+
+    auto all = book.get_open_orders(trader, 0);
+    ASSERT_EQ(all.size(), 2u);
+    ASSERT_EQ(all[0].oid, second.oid);
+    ASSERT_EQ(all[1].oid, third.oid);
+
+    LXAccount idle{};
+    idle.main[19] = 0x02;
+    ASSERT(book.get_open_orders(idle, 0).empty());
+}
+
+// Test: resetting quotes swaps the account's orders for the new set
+TEST(lxbook_reset_quotes) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    auto quote = [](bool is_buy, double px) {
+        LXOrder order{};
+        order.market_id = 1;
+        order.is_buy = is_buy;
+        order.kind = OrderKind::LIMIT;
+        order.size_x18 = x18::from_double(1.0);
+        order.limit_px_x18 = x18::from_double(px);
+        order.tif = TIF::ALO;
+        return order;
+    };
+    auto old_bid = book.place_order(maker, quote(true, 98.0));
+    auto old_ask = book.place_order(maker, quote(false, 102.0));
+
+    LXOrder elsewhere = quote(true, 99.0);
+    elsewhere.market_id = 2;
+    std::vector<LXPlaceResult> results;
+    ASSERT_EQ(book.reset_quotes(maker, 1, {quote(true, 99.0), quote(false, 101.0), elsewhere}, results),
+              errors::OK);
+    ASSERT_EQ(results.size(), 3u);
+    ASSERT_EQ(results[0].status, static_cast<uint8_t>(BookOrderStatus::OPEN));
+    ASSERT_EQ(results[1].status, static_cast<uint8_t>(BookOrderStatus::OPEN));
+    ASSERT_EQ(results[2].status, static_cast<uint8_t>(BookOrderStatus::REJECTED));
+
+    ASSERT(book.get_order(1, old_bid.oid)->status == BookOrderStatus::CANCELLED);
+    ASSERT(book.get_order(1, old_ask.oid)->status == BookOrderStatus::CANCELLED);
+    auto open = book.get_open_orders(maker, 1);
+    ASSERT_EQ(open.size(), 2u);
+    ASSERT_EQ(open[0].oid, results[0].oid);
+    ASSERT_EQ(open[1].oid, results[1].oid);
+
+    // An unknown market leaves the live quotes alone
+    ASSERT_EQ(book.reset_quotes(maker, 42, {}, results), errors::MARKET_NOT_FOUND);
+    ASSERT_EQ(book.get_open_orders(maker, 1).size(), 2u);
+}
+
 // Test: LXBook L1 market data
 TEST(lxbook_l1) {
     LXBook book;
@@ -813,6 +1152,55 @@ TEST(lxbook_l1) {
     auto l1 = book.get_l1(1);
     ASSERT(l1.best_bid_px_x18 > 0);
     ASSERT(l1.best_ask_px_x18 > 0);
+    ASSERT(l1.best_bid_sz_x18 == x18::from_int(10));
+    ASSERT(l1.best_ask_sz_x18 == x18::from_int(10));
+}
+
+// Test: LXBook L1 subscriptions see top-of-book changes only
+TEST(lxbook_subscribe_l1) {
+    LXBook book;
+
+    BookMarketConfig config{};
+    config.market_id = 1;
+    config.symbol_id = 100;
+    config.lot_size_x18 = x18::from_double(0.001);
+    config.max_order_size_x18 = x18::from_double(1000000.0);
+    config.status = 1;
+    book.create_market(config);
+
+    std::vector<LXL1> updates;
+    uint64_t id = 0;
+    ASSERT_EQ(book.subscribe_l1(2, [](uint32_t, const LXL1&) {}, id), errors::MARKET_NOT_FOUND);
+    ASSERT_EQ(book.subscribe_l1(1, [&updates](uint32_t, const LXL1& l1) { updates.push_back(l1); }, id),
+              errors::OK);
+    ASSERT(id > 0);
+
+    LXAccount trader{};
+    trader.main[19] = 0x01;
+    LXOrder bid{};
+    bid.market_id = 1;
+    bid.is_buy = true;
+    bid.kind = OrderKind::LIMIT;
+    bid.size_x18 = x18::from_double(10.0);
+    bid.limit_px_x18 = x18::from_double(99.0);
+    bid.tif = TIF::GTC;
+    auto placed = book.place_order(trader, bid);
+    ASSERT_EQ(updates.size(), 1u);
+    ASSERT(updates[0].best_bid_px_x18 == x18::from_int(99));
+
+    // A bid behind the best leaves L1 unchanged
+    bid.limit_px_x18 = x18::from_double(98.0);
+    book.place_order(trader, bid);
+    ASSERT_EQ(updates.size(), 1u);
+
+    ASSERT_EQ(book.cancel_order(trader, 1, placed.oid), errors::OK);
+    ASSERT_EQ(updates.size(), 2u);
+    ASSERT(updates[1].best_bid_px_x18 == x18::from_int(98));
+
+    book.unsubscribe_l1(id);
+    bid.limit_px_x18 = x18::from_double(100.0);
+    book.place_order(trader, bid);
+    ASSERT_EQ(updates.size(), 2u);
 }
 
 // Test: LXBook packed HFT interface
@@ -900,6 +1288,819 @@ TEST(lxbook_settlement_callback) {
     // Just verify no crash
 }
 
+// =============================================================================
+// LX Tests
+// =============================================================================
+
+// Create vault and book market 1 with 10% initial margin and 0.02%/0.05%
+// maker/taker fees
+static void setup_lx_market(LX& dex) {
+    MarketConfig vault_config{};
+    vault_config.market_id = 1;
+    vault_config.initial_margin_x18 = x18::from_double(0.1);
+    vault_config.maintenance_margin_x18 = x18::from_double(0.05);
+    vault_config.max_leverage_x18 = x18::from_int(10);
+    vault_config.maker_fee_x18 = x18::from_double(0.0002);
+    vault_config.taker_fee_x18 = x18::from_double(0.0005);
+    vault_config.max_position_size_x18 = x18::from_int(1000);
+    vault_config.active = true;
+    ASSERT_EQ(dex.vault().create_market(vault_config), errors::OK);
+
+    BookMarketConfig book_config{};
+    book_config.market_id = 1;
+    book_config.symbol_id = 1;
+    book_config.lot_size_x18 = x18::from_double(0.001);
+    book_config.max_order_size_x18 = x18::from_int(1000);
+    book_config.status = 1;
+    ASSERT_EQ(dex.book().create_market(book_config), errors::OK);
+}
+
+// Test: LX trade bust reverses the fill on both accounts
+TEST(lx_bust_trade) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(taker, NATIVE_LUX, x18::from_int(10000));
+
+    std::vector<Trade> busted;
+    dex.set_trade_bust_callback([&busted](const Trade& trade) { busted.push_back(trade); });
+    std::vector<LXSystemEvent> events;
+    dex.set_system_event_callback([&events](const LXSystemEvent& event) { events.push_back(event); });
+
+    LXOrder sell{};
+    sell.market_id = 1;
+    sell.kind = OrderKind::LIMIT;
+    sell.size_x18 = x18::from_int(1);
+    sell.limit_px_x18 = x18::from_int(100);
+    sell.tif = TIF::GTC;
+    dex.book().place_order(maker, sell);
+
+    LXOrder buy = sell;
+    buy.is_buy = true;
+    buy.tif = TIF::IOC;
+    auto result = dex.book().place_order(taker, buy);
+    ASSERT(result.filled_size_x18 == x18::from_int(1));
+    ASSERT_EQ(result.status, static_cast<uint8_t>(BookOrderStatus::FILLED));
+
+    // Settled to the accounts that placed the orders, fees charged
+    auto long_pos = dex.vault().get_position(taker, 1);
+    auto short_pos = dex.vault().get_position(maker, 1);
+    ASSERT(long_pos && long_pos->size_x18 == x18::from_int(1));
+    ASSERT(short_pos && short_pos->size_x18 == -x18::from_int(1));
+    ASSERT(dex.vault().get_balance(maker, NATIVE_LUX) == x18::from_int(10000) - x18::from_double(0.02));
+    ASSERT(dex.vault().get_balance(taker, NATIVE_LUX) == x18::from_int(10000) - x18::from_double(0.05));
+
+    // Trade IDs start at 1 per market
+    ASSERT_EQ(dex.bust_trade(1, 1), errors::OK);
+    ASSERT(!dex.vault().get_position(taker, 1));
+    ASSERT(!dex.vault().get_position(maker, 1));
+    ASSERT(dex.vault().get_balance(maker, NATIVE_LUX) == x18::from_int(10000));
+    ASSERT(dex.vault().get_balance(taker, NATIVE_LUX) == x18::from_int(10000));
+    ASSERT_EQ(busted.size(), 1u);
+    ASSERT_EQ(busted[0].id, 1u);
+    ASSERT_EQ(events.size(), 1u);
+    ASSERT(events[0].kind == SystemEventKind::TRADE_BUSTED);
+    ASSERT_EQ(events[0].market_id, 1u);
+    ASSERT(dex.book().get_recent_trades(1).empty());
+    ASSERT_EQ(dex.book().get_market_stats(1)->trades, 0u);
+
+    ASSERT_EQ(dex.bust_trade(1, 1), errors::TRADE_NOT_FOUND);
+}
+
+// Test: busting a trade that closed a position reopens it at its entry
+TEST(lx_bust_trade_closing) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount trader{};
+    trader.main[19] = 0x01;
+    LXAccount opener{};
+    opener.main[19] = 0x02;
+    LXAccount closer{};
+    closer.main[19] = 0x03;
+    for (const auto& account : {trader, opener, closer}) {
+        dex.vault().deposit(account, NATIVE_LUX, x18::from_int(10000));
+    }
+
+    // Trade 1: trader buys 1 at 100
+    LXOrder order{};
+    order.market_id = 1;
+    order.kind = OrderKind::LIMIT;
+    order.size_x18 = x18::from_int(1);
+    order.limit_px_x18 = x18::from_int(100);
+    order.tif = TIF::GTC;
+    dex.book().place_order(opener, order);
+    order.is_buy = true;
+    order.tif = TIF::IOC;
+    dex.book().place_order(trader, order);
+
+    auto opened = dex.vault().get_position(trader, 1);
+    ASSERT(opened && opened->size_x18 == x18::from_int(1));
+    I128 pnl_before = dex.vault().get_account_pnl(trader).realized_pnl_x18;
+
+    // Trade 2: trader sells 1 at 110 into a resting bid, closing the long
+    order.limit_px_x18 = x18::from_int(110);
+    order.tif = TIF::GTC;
+    auto bid = dex.book().place_order(closer, order);
+    order.is_buy = false;
+    order.tif = TIF::IOC;
+    dex.book().place_order(trader, order);
+    ASSERT(!dex.vault().get_position(trader, 1));
+    ASSERT(dex.vault().get_account_pnl(trader).realized_pnl_x18 != pnl_before);
+
+    ASSERT_EQ(dex.bust_trade(1, 2), errors::OK);
+
+    auto restored = dex.vault().get_position(trader, 1);
+    ASSERT(restored && restored->size_x18 == x18::from_int(1));
+    ASSERT(restored->entry_px_x18 == opened->entry_px_x18);
+    ASSERT(dex.vault().get_account_pnl(trader).realized_pnl_x18 == pnl_before);
+    ASSERT(!dex.vault().get_position(closer, 1));
+
+    // The bid the trade filled is not filled any more and does not rest again
+    auto state = dex.book().get_order(1, bid.oid);
+    ASSERT(state.has_value());
+    ASSERT(state->filled_size_x18 == 0);
+    ASSERT(state->remaining_size_x18 == x18::from_int(1));
+    ASSERT(state->status == BookOrderStatus::CANCELLED);
+}
+
+// Test: trade busts are refused unless the instance permits them
+TEST(lx_bust_trade_disabled) {
+    LX dex;
+    LX::Config config{};
+    config.enable_trade_busts = false;
+    dex.initialize(config);
+    setup_lx_market(dex);
+
+    ASSERT_EQ(dex.bust_trade(1, 1), errors::UNAUTHORIZED);
+}
+
+// Test: exposure checks see resting orders and open positions
+TEST(lx_account_exposure) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(taker, NATIVE_LUX, x18::from_int(10000));
+    ASSERT(!dex.book().has_open_orders(maker));
+    ASSERT(!dex.vault().has_positions(maker));
+
+    LXOrder sell{};
+    sell.market_id = 1;
+    sell.kind = OrderKind::LIMIT;
+    sell.size_x18 = x18::from_int(1);
+    sell.limit_px_x18 = x18::from_int(100);
+    sell.tif = TIF::GTC;
+    dex.book().place_order(maker, sell);
+    ASSERT(dex.book().has_open_orders(maker));
+    ASSERT(!dex.vault().has_positions(maker));
+
+    LXOrder buy = sell;
+    buy.is_buy = true;
+    buy.tif = TIF::IOC;
+    dex.book().place_order(taker, buy);
+    ASSERT(!dex.book().has_open_orders(maker));
+    ASSERT(!dex.book().has_open_orders(taker));
+    ASSERT(dex.vault().has_positions(maker));
+    ASSERT(dex.vault().has_positions(taker));
+}
+
+// Test: the trade callback sees each settled trade with its accounts
+TEST(lx_trade_callback) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(taker, NATIVE_LUX, x18::from_int(10000));
+
+    std::vector<LXTrade> trades;
+    dex.set_trade_callback([&trades](const LXTrade& t) { trades.push_back(t); });
+
+    LXOrder sell{};
+    sell.market_id = 1;
+    sell.kind = OrderKind::LIMIT;
+    sell.size_x18 = x18::from_int(1);
+    sell.limit_px_x18 = x18::from_int(100);
+    sell.tif = TIF::GTC;
+    auto rested = dex.book().place_order(maker, sell);
+    LXOrder buy = sell;
+    buy.is_buy = true;
+    buy.tif = TIF::IOC;
+    auto took = dex.book().place_order(taker, buy);
+
+    ASSERT_EQ(trades.size(), 1u);
+    ASSERT_EQ(trades[0].market_id, 1u);
+    ASSERT_EQ(trades[0].maker_oid, rested.oid);
+    ASSERT_EQ(trades[0].taker_oid, took.oid);
+    ASSERT(trades[0].maker == maker && trades[0].taker == taker);
+    ASSERT(trades[0].taker_is_buy);
+    ASSERT(trades[0].px_x18 == x18::from_int(100));
+    ASSERT(trades[0].sz_x18 == x18::from_int(1));
+    ASSERT(trades[0].timestamp > 0);
+
+    dex.set_trade_callback(nullptr);
+    dex.book().place_order(maker, sell);
+    dex.book().place_order(taker, buy);
+    ASSERT_EQ(trades.size(), 1u);
+}
+
+// Test: part of a position moves to another account at a given price
+TEST(lx_transfer_position) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    LXAccount receiver{};
+    receiver.main[19] = 0x03;
+    LXAccount unfunded{};
+    unfunded.main[19] = 0x04;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(taker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(receiver, NATIVE_LUX, x18::from_int(10000));
+
+    LXOrder sell{};
+    sell.market_id = 1;
+    sell.kind = OrderKind::LIMIT;
+    sell.size_x18 = x18::from_int(2);
+    sell.limit_px_x18 = x18::from_int(100);
+    sell.tif = TIF::GTC;
+    dex.book().place_order(maker, sell);
+    LXOrder buy = sell;
+    buy.is_buy = true;
+    buy.tif = TIF::IOC;
+    dex.book().place_order(taker, buy);
+
+    int32_t rc = dex.vault().transfer_position(taker, receiver, 1, x18::from_int(1),
+                                               x18::from_int(110));
+    ASSERT_EQ(rc, errors::OK);
+    auto from_pos = dex.vault().get_position(taker, 1);
+    auto to_pos = dex.vault().get_position(receiver, 1);
+    ASSERT(from_pos && to_pos);
+    ASSERT(from_pos->size_x18 == x18::from_int(1));
+    ASSERT(from_pos->entry_px_x18 == x18::from_int(100));
+    ASSERT(to_pos->size_x18 == x18::from_int(1));
+    ASSERT(to_pos->entry_px_x18 == x18::from_int(110));
+
+    rc = dex.vault().transfer_position(taker, receiver, 1, x18::from_int(2), x18::from_int(110));
+    ASSERT_EQ(rc, errors::POSITION_NOT_FOUND);
+    rc = dex.vault().transfer_position(taker, unfunded, 1, x18::from_int(1), x18::from_int(110));
+    ASSERT_EQ(rc, errors::INSUFFICIENT_MARGIN);
+    ASSERT(dex.vault().get_position(taker, 1)->size_x18 == x18::from_int(1));
+    ASSERT(!dex.vault().get_position(unfunded, 1));
+}
+
+// Test: staking discounts the market's fees without stacking on a fee tier
+TEST(lx_staking_fee_tiers) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount staker{};
+    staker.main[19] = 0x01;
+    I128 market_taker = x18::from_double(0.0005);
+    I128 market_maker = x18::from_double(0.0002);
+    ASSERT_EQ(dex.vault().set_staking_fee_tiers({
+        {x18::from_int(5000), x18::from_double(0.8), 0},
+        {x18::from_int(1000), x18::from_double(0.5), 0},
+    }), errors::OK);
+
+    ASSERT_EQ(dex.vault().set_staked_balance(staker, x18::from_int(-1)), errors::INVALID_PRICE);
+    ASSERT_EQ(dex.vault().set_staked_balance(staker, x18::from_int(500)), errors::OK);
+    auto rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates && rates->taker_fee_x18 == market_taker);
+
+    ASSERT_EQ(dex.vault().set_staked_balance(staker, x18::from_int(1500)), errors::OK);
+    rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates->taker_fee_x18 == x18::mul(market_taker, x18::from_double(0.5)));
+    ASSERT(rates->maker_fee_x18 == market_maker);
+
+    // The lower of the fee tier and the staking rate applies
+    ASSERT_EQ(dex.vault().set_fee_tier(staker, 0, x18::from_double(0.0001)), errors::OK);
+    rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates->taker_fee_x18 == x18::from_double(0.0001));
+    ASSERT_EQ(dex.vault().set_fee_tier(staker, 0, x18::from_double(0.0004)), errors::OK);
+    rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates->taker_fee_x18 == x18::mul(market_taker, x18::from_double(0.5)));
+
+    // Discounts outside [0, 1] and negative thresholds leave the schedule alone
+    ASSERT_EQ(dex.vault().set_staking_fee_tiers({{0, x18::from_double(1.5), 0}}), errors::INVALID_FEE);
+    ASSERT_EQ(dex.vault().set_staking_fee_tiers({{0, 0, x18::from_double(-0.1)}}), errors::INVALID_FEE);
+    ASSERT_EQ(dex.vault().set_staking_fee_tiers({{x18::from_int(-1), 0, 0}}), errors::INVALID_FEE);
+    rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates->taker_fee_x18 == x18::mul(market_taker, x18::from_double(0.5)));
+
+    ASSERT_EQ(dex.vault().set_staking_fee_tiers({}), errors::OK);
+    rates = dex.vault().fee_rates(staker, 1);
+    ASSERT(rates->taker_fee_x18 == x18::from_double(0.0004));
+}
+
+// Test: LX chosen leverage raises initial margin above the market rate
+TEST(lx_set_leverage) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    LXAccount taker{};
+    taker.main[19] = 0x02;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+    dex.vault().deposit(taker, NATIVE_LUX, x18::from_int(10000));
+
+    ASSERT_EQ(dex.vault().set_leverage(taker, 1, x18::from_int(5)), errors::OK);
+    ASSERT_EQ(dex.vault().set_leverage(taker, 1, x18::from_int(11)), errors::INVALID_LEVERAGE);
+    ASSERT_EQ(dex.vault().set_leverage(taker, 1, x18::from_double(0.5)), errors::INVALID_LEVERAGE);
+    ASSERT_EQ(dex.vault().set_leverage(taker, 2, x18::from_int(5)), errors::MARKET_NOT_FOUND);
+
+    LXOrder sell{};
+    sell.market_id = 1;
+    sell.kind = OrderKind::LIMIT;
+    sell.size_x18 = x18::from_int(10);
+    sell.limit_px_x18 = x18::from_int(100);
+    sell.tif = TIF::GTC;
+    dex.book().place_order(maker, sell);
+    LXOrder buy = sell;
+    buy.is_buy = true;
+    buy.tif = TIF::IOC;
+    dex.book().place_order(taker, buy);
+
+    // 5x is 20% of the 1000 notional; the maker keeps the market's 10%
+    ASSERT(dex.vault().get_margin_info(taker).used_margin_x18 == x18::from_int(200));
+    ASSERT(dex.vault().get_margin_info(maker).used_margin_x18 == x18::from_int(100));
+}
+
+// Test: LX routes component events to one callback
+TEST(lx_system_events) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    std::vector<LXSystemEvent> events;
+    dex.set_system_event_callback([&events](const LXSystemEvent& event) { events.push_back(event); });
+
+    // Halting a market
+    ASSERT_EQ(dex.book().set_market_status(1, 0), errors::OK);
+    ASSERT_EQ(events.size(), 1u);
+    ASSERT(events[0].kind == SystemEventKind::MARKET_HALTED);
+    ASSERT(events[0].severity == Severity::CRITICAL);
+    ASSERT_EQ(events[0].market_id, 1u);
+    ASSERT(events[0].timestamp > 0);
+    ASSERT_EQ(dex.book().set_market_status(1, 1), errors::OK);
+    ASSERT_EQ(events.size(), 1u);
+
+    // A rejected order names its sender
+    LXAccount trader{};
+    trader.main[19] = 0x01;
+    LXOrder order{};
+    order.market_id = 1;
+    order.kind = OrderKind::LIMIT;
+    order.size_x18 = 0;
+    order.limit_px_x18 = x18::from_int(100);
+    order.tif = TIF::GTC;
+    auto result = dex.book().place_order(trader, order);
+    ASSERT_EQ(result.status, static_cast<uint8_t>(BookOrderStatus::REJECTED));
+    ASSERT_EQ(events.size(), 2u);
+    ASSERT(events[1].kind == SystemEventKind::ORDER_REJECTED);
+    ASSERT(events[1].account.main == trader.main);
+
+    // An index read without an oracle price
+    OracleConfig config{};
+    config.asset_id = 7;
+    config.max_staleness = 3600;
+    config.max_deviation_x18 = x18::from_double(0.05);
+    config.method = AggregationMethod::MEDIAN;
+    config.sources = {PriceSource::BINANCE};
+    ASSERT_EQ(dex.oracle().register_asset(config), errors::OK);
+    ASSERT_EQ(dex.feed().register_market(1, 7), errors::OK);
+    ASSERT(!dex.feed().index_price(1));
+    ASSERT_EQ(events.size(), 3u);
+    ASSERT(events[2].kind == SystemEventKind::ORACLE_PRICE_STALE);
+    ASSERT_EQ(events[2].asset_id, 7u);
+    ASSERT_EQ(events[2].code, errors::PRICE_STALE);
+
+    // A price move past the circuit breaker
+    ASSERT_EQ(dex.oracle().update_price(7, PriceSource::BINANCE, x18::from_int(100), 0), errors::OK);
+    ASSERT_EQ(dex.oracle().set_circuit_breaker(7, 100), errors::OK);
+    ASSERT_EQ(dex.oracle().update_price(7, PriceSource::BINANCE, x18::from_int(200), 0),
+              errors::PRICE_DEVIATION);
+    ASSERT_EQ(events.size(), 4u);
+    ASSERT(events[3].kind == SystemEventKind::CIRCUIT_BREAKER_TRIPPED);
+    ASSERT_EQ(events[3].asset_id, 7u);
+
+    dex.set_system_event_callback(nullptr);
+    ASSERT_EQ(dex.book().set_market_status(1, 0), errors::OK);
+    ASSERT_EQ(events.size(), 4u);
+}
+
+// Test: LX restores a snapshot into a fresh instance
+TEST(lx_snapshot_restore) {
+    LX dex;
+    dex.initialize();
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+
+    LXOrder bid{};
+    bid.market_id = 1;
+    bid.is_buy = true;
+    bid.kind = OrderKind::LIMIT;
+    bid.size_x18 = x18::from_int(2);
+    bid.limit_px_x18 = x18::from_int(99);
+    bid.tif = TIF::GTC;
+    auto placed = dex.book().place_order(maker, bid);
+    LXOrder ask = bid;
+    ask.is_buy = false;
+    ask.size_x18 = x18::from_int(3);
+    ask.limit_px_x18 = x18::from_int(101);
+    dex.book().place_order(maker, ask);
+
+    auto snapshot = dex.snapshot();
+
+    LX restored;
+    restored.initialize();
+    ASSERT_EQ(restored.restore(snapshot.data(), snapshot.size()), errors::OK);
+    LXL1 want = dex.book().get_l1(1);
+    LXL1 got = restored.book().get_l1(1);
+    ASSERT(got.best_bid_px_x18 == want.best_bid_px_x18);
+    ASSERT(got.best_bid_sz_x18 == want.best_bid_sz_x18);
+    ASSERT(got.best_ask_px_x18 == want.best_ask_px_x18);
+    ASSERT(got.best_ask_sz_x18 == want.best_ask_sz_x18);
+    ASSERT(restored.vault().get_balance(maker, NATIVE_LUX) ==
+           dex.vault().get_balance(maker, NATIVE_LUX));
+    ASSERT(restored.vault().get_market_config(1).has_value());
+    ASSERT(restored.book().get_order(1, placed.oid).has_value());
+
+    // Restored orders can be cancelled and new ones take fresh IDs
+    ASSERT_EQ(restored.book().cancel_order(maker, 1, placed.oid), errors::OK);
+    bid.limit_px_x18 = x18::from_int(98);
+    ASSERT(restored.book().place_order(maker, bid).oid > placed.oid);
+
+    // Another version, truncated data and a non-fresh instance are rejected
+    auto other_version = snapshot;
+    other_version[0]++;
+    LX fresh;
+    fresh.initialize();
+    ASSERT_EQ(fresh.restore(other_version.data(), other_version.size()), errors::INVALID_SNAPSHOT);
+    LX truncated;
+    truncated.initialize();
+    ASSERT_EQ(truncated.restore(snapshot.data(), snapshot.size() - 1), errors::INVALID_SNAPSHOT);
+    ASSERT_EQ(restored.restore(snapshot.data(), snapshot.size()), errors::INVALID_SNAPSHOT);
+}
+
+// Test: a journal replayed into a fresh instance reproduces the book and balances
+TEST(lx_journal_replay) {
+    LX dex;
+    dex.initialize();
+    std::vector<std::vector<uint8_t>> records;
+    dex.set_journal_callback([&](const std::vector<uint8_t>& record) {
+        records.push_back(record);
+    });
+    setup_lx_market(dex);
+
+    LXAccount maker{};
+    maker.main[19] = 0x01;
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(10000));
+
+    LXOrder bid{};
+    bid.market_id = 1;
+    bid.is_buy = true;
+    bid.kind = OrderKind::LIMIT;
+    bid.size_x18 = x18::from_int(2);
+    bid.limit_px_x18 = x18::from_int(99);
+    bid.tif = TIF::GTC;
+    auto placed = dex.book().place_order(maker, bid);
+    bid.limit_px_x18 = x18::from_int(98);
+    dex.book().place_order(maker, bid);
+    LXOrder ask = bid;
+    ask.is_buy = false;
+    ask.size_x18 = x18::from_int(3);
+    ask.limit_px_x18 = x18::from_int(101);
+    dex.book().place_order(maker, ask);
+    ASSERT_EQ(dex.book().cancel_order(maker, 1, placed.oid), errors::OK);
+    ASSERT(!records.empty());
+
+    LX replayed;
+    replayed.initialize();
+    for (const auto& record : records) {
+        ASSERT_EQ(replayed.journal_apply(record.data(), record.size()), errors::OK);
+    }
+    LXL1 want = dex.book().get_l1(1);
+    LXL1 got = replayed.book().get_l1(1);
+    ASSERT(got.best_bid_px_x18 == x18::from_int(98));
+    ASSERT(got.best_bid_px_x18 == want.best_bid_px_x18);
+    ASSERT(got.best_ask_px_x18 == want.best_ask_px_x18);
+    ASSERT(got.best_ask_sz_x18 == want.best_ask_sz_x18);
+    ASSERT(replayed.vault().get_balance(maker, NATIVE_LUX) ==
+           dex.vault().get_balance(maker, NATIVE_LUX));
+
+    // Replayed and out-of-sequence records are rejected
+    ASSERT_EQ(replayed.journal_apply(records[0].data(), records[0].size()),
+              errors::INVALID_JOURNAL);
+    LX skipped;
+    skipped.initialize();
+    ASSERT_EQ(skipped.journal_apply(records[1].data(), records[1].size()),
+              errors::INVALID_JOURNAL);
+    ASSERT_EQ(skipped.journal_apply(records[0].data(), records[0].size() - 1),
+              errors::INVALID_JOURNAL);
+
+    // Clearing the callback stops journaling
+    dex.set_journal_callback(nullptr);
+    size_t recorded = records.size();
+    dex.vault().deposit(maker, NATIVE_LUX, x18::from_int(1));
+    ASSERT_EQ(records.size(), recorded);
+}
+
+// Test: lending moves tokens between the vault and the pool
+TEST(lxlend_supply_borrow_repay) {
+    LX dex;
+    dex.initialize();
+
+    LXAccount lender{};
+    lender.main[19] = 0x01;
+    LXAccount borrower{};
+    borrower.main[19] = 0x02;
+    dex.vault().deposit(lender, NATIVE_LUX, x18::from_int(1000));
+    dex.vault().deposit(borrower, NATIVE_LUX, x18::from_int(100));
+
+    // Only the native currency can be valued, so only it is accepted
+    Currency eth{};
+    eth.addr[19] = 0xe7;
+    dex.vault().deposit(borrower, eth, x18::from_int(100));
+    ASSERT_EQ(dex.lend().supply(borrower, eth, x18::from_int(100)), errors::INVALID_CURRENCY);
+    ASSERT_EQ(dex.lend().borrow(borrower, eth, x18::from_int(1)), errors::INVALID_CURRENCY);
+    ASSERT(dex.vault().get_balance(borrower, eth) == x18::from_int(100));
+
+    ASSERT_EQ(dex.lend().supply(lender, NATIVE_LUX, x18::from_int(2000)), errors::INSUFFICIENT_BALANCE);
+    ASSERT_EQ(dex.lend().supply(lender, NATIVE_LUX, x18::from_int(1000)), errors::OK);
+    ASSERT_EQ(dex.lend().supply(borrower, NATIVE_LUX, x18::from_int(100)), errors::OK);
+    ASSERT(dex.vault().get_balance(lender, NATIVE_LUX) == 0);
+
+    // 75% of the 100 supplied
+    ASSERT_EQ(dex.lend().borrow(borrower, NATIVE_LUX, x18::from_int(80)), errors::INSUFFICIENT_MARGIN);
+    ASSERT_EQ(dex.lend().borrow(borrower, NATIVE_LUX, x18::from_int(75)), errors::OK);
+    ASSERT(dex.vault().get_balance(borrower, NATIVE_LUX) == x18::from_int(75));
+    LXAccount stranger{};
+    stranger.main[19] = 0x03;
+    ASSERT_EQ(dex.lend().borrow(stranger, NATIVE_LUX, x18::from_int(1)), errors::INSUFFICIENT_MARGIN);
+
+    auto liquidity = dex.lend().get_account_liquidity(borrower);
+    ASSERT(liquidity.collateral_value_x18 == x18::from_int(100));
+    ASSERT(liquidity.borrow_value_x18 == x18::from_int(75));
+
+    // The debt pins the collateral
+    ASSERT_EQ(dex.lend().withdraw(borrower, NATIVE_LUX, x18::from_int(1)), errors::INSUFFICIENT_MARGIN);
+    ASSERT_EQ(dex.lend().withdraw(lender, NATIVE_LUX, x18::from_int(1001)), errors::INSUFFICIENT_BALANCE);
+    ASSERT_EQ(dex.lend().withdraw(lender, NATIVE_LUX, x18::from_int(1000)), errors::OK);
+
+    // Overpaying repays the debt in full
+    ASSERT_EQ(dex.lend().repay(borrower, NATIVE_LUX, x18::from_int(100)), errors::OK);
+    ASSERT(dex.vault().get_balance(borrower, NATIVE_LUX) == 0);
+    ASSERT(dex.lend().get_borrowed(borrower, NATIVE_LUX) == 0);
+    ASSERT_EQ(dex.lend().repay(borrower, NATIVE_LUX, x18::from_int(1)), errors::POSITION_NOT_FOUND);
+
+    ASSERT_EQ(dex.lend().withdraw(borrower, NATIVE_LUX, x18::from_int(100)), errors::OK);
+    ASSERT(dex.vault().get_balance(borrower, NATIVE_LUX) == x18::from_int(100));
+    ASSERT(dex.lend().get_account_liquidity(borrower).collateral_value_x18 == 0);
+}
+
+// Test: collateral yield pays a self-repaying loan down
+TEST(lxliquid_self_repaying_loan) {
+    LX dex;
+    dex.initialize();
+
+    LXAccount owner{};
+    owner.main[19] = 0x01;
+    Currency eth{};
+    eth.addr[19] = 0xe7;
+    dex.vault().deposit(owner, eth, x18::from_int(1000));
+
+    uint64_t loan_id = 0;
+    ASSERT_EQ(dex.liquid().open(owner, eth, x18::from_int(2000), loan_id), errors::INSUFFICIENT_BALANCE);
+    ASSERT_EQ(dex.liquid().open(owner, eth, 0, loan_id), errors::INVALID_PRICE);
+    ASSERT_EQ(dex.liquid().open(owner, eth, x18::from_int(1000), loan_id), errors::OK);
+    ASSERT(loan_id > 0);
+
+    // Half the collateral comes back as principal
+    ASSERT(dex.vault().get_balance(owner, eth) == x18::from_int(500));
+    auto loan = dex.liquid().get_loan(loan_id);
+    ASSERT(loan.has_value());
+    ASSERT(loan->owner == owner);
+    ASSERT(loan->collateral_x18 == x18::from_int(1000));
+    ASSERT(loan->principal_x18 == x18::from_int(500));
+    ASSERT(loan->health_x18 == x18::from_int(2));
+    ASSERT(loan->opened_at > 0);
+
+    ASSERT_EQ(dex.liquid().apply_yield(loan_id, x18::from_int(100)), errors::OK);
+    loan = dex.liquid().get_loan(loan_id);
+    ASSERT(loan->principal_x18 == x18::from_int(400));
+    ASSERT(loan->yield_applied_x18 == x18::from_int(100));
+    ASSERT(loan->health_x18 == x18::from_int(25) / 10);
+
+    // Paying it off releases the collateral and the surplus yield
+    ASSERT_EQ(dex.liquid().apply_yield(loan_id, x18::from_int(450)), errors::OK);
+    loan = dex.liquid().get_loan(loan_id);
+    ASSERT(loan->principal_x18 == 0 && loan->health_x18 == 0);
+    ASSERT(loan->yield_applied_x18 == x18::from_int(500));
+    ASSERT(dex.vault().get_balance(owner, eth) == x18::from_int(1550));
+    ASSERT_EQ(dex.liquid().apply_yield(loan_id, x18::from_int(1)), errors::POSITION_NOT_FOUND);
+
+    ASSERT(!dex.liquid().get_loan(loan_id + 1));
+}
+
+// Test: pools naming a hook contract need its flags registered
+TEST(lxpool_hook_flags) {
+    LXPool pool;
+
+    PoolKey key{};
+    key.currency1.addr[19] = 0x01;
+    key.fee = fees::FEE_030;
+    key.tick_spacing = tick_spacings::TICK_SPACING_030;
+    key.hooks[19] = 0x42;
+    I128 sqrt_price = tick_math::get_sqrt_ratio_at_tick(0);
+
+    ASSERT_EQ(pool.initialize(key, sqrt_price), errors::HOOK_NOT_REGISTERED);
+    ASSERT(!pool.get_hook_flags(key.hooks));
+
+    // Zero flags, unknown bits and an unpaired returns-delta flag are refused
+    ASSERT_EQ(pool.register_hook_flags(key.hooks, 0), errors::INVALID_HOOK_FLAGS);
+    ASSERT_EQ(pool.register_hook_flags(key.hooks, 1u << 14), errors::INVALID_HOOK_FLAGS);
+    ASSERT_EQ(pool.register_hook_flags(key.hooks, hook_flags::BEFORE_SWAP_RETURNS_DELTA),
+              errors::INVALID_HOOK_FLAGS);
+    ASSERT_EQ(pool.register_hook_flags(Address{}, hook_flags::BEFORE_SWAP),
+              errors::INVALID_HOOK_FLAGS);
+
+    uint32_t flags = hook_flags::BEFORE_SWAP | hook_flags::BEFORE_SWAP_RETURNS_DELTA;
+    ASSERT_EQ(pool.register_hook_flags(key.hooks, flags), errors::OK);
+    ASSERT(pool.get_hook_flags(key.hooks) == flags);
+    ASSERT_EQ(pool.initialize(key, sqrt_price), 0);
+
+    // Re-registering replaces the flags
+    ASSERT_EQ(pool.register_hook_flags(key.hooks, hook_flags::AFTER_SWAP), errors::OK);
+    ASSERT(pool.get_hook_flags(key.hooks) == hook_flags::AFTER_SWAP);
+}
+
+TEST(lxpool_quote_swap) {
+    LXPool pool;
+
+    PoolKey key{};
+    key.currency1.addr[19] = 0x01;
+    key.fee = fees::FEE_030;
+    key.tick_spacing = tick_spacings::TICK_SPACING_030;
+    ASSERT_EQ(pool.initialize(key, tick_math::get_sqrt_ratio_at_tick(0)), 0);
+    pool.modify_liquidity(key, {-600, 600, x18::from_int(10), 0});
+
+    BalanceDelta delta{};
+    bool full = false;
+
+    // An exact-input swap the pool covers matches the executed swap
+    SwapParams small{true, x18::from_int(1) / 100, 0};
+    ASSERT_EQ(pool.quote_swap(key, small, false, delta, full), errors::OK);
+    ASSERT(full);
+    BalanceDelta executed = pool.swap(key, small);
+    ASSERT(delta.amount0 == executed.amount0 && delta.amount1 == executed.amount1);
+
+    // Exact output far beyond the pool's depth
+    SwapParams large{true, -x18::from_int(1000), 0};
+    I128 sqrt_price = pool.get_slot0(key)->sqrt_price_x96;
+    ASSERT_EQ(pool.quote_swap(key, large, false, delta, full), errors::INSUFFICIENT_LIQUIDITY);
+    ASSERT_EQ(pool.quote_swap(key, large, true, delta, full), errors::OK);
+    ASSERT(!full);
+    ASSERT(delta.amount0 > 0);
+    ASSERT(delta.amount1 < 0 && delta.amount1 > -x18::from_int(1000));
+
+    // Quoting leaves the pool where it was
+    ASSERT(pool.get_slot0(key)->sqrt_price_x96 == sqrt_price);
+    ASSERT(*pool.get_liquidity(key) == x18::from_int(10));
+
+    PoolKey missing = key;
+    missing.fee = fees::FEE_005;
+    ASSERT_EQ(pool.quote_swap(missing, small, false, delta, full), errors::POOL_NOT_INITIALIZED);
+}
+
+// Test: swap fees accrue to in-range positions
+TEST(lxpool_swap_fees_accrue) {
+    LXPool pool;
+
+    PoolKey key{};
+    key.currency1.addr[19] = 0x01;
+    key.fee = fees::FEE_030;
+    key.tick_spacing = tick_spacings::TICK_SPACING_030;
+    ASSERT_EQ(pool.initialize(key, tick_math::get_sqrt_ratio_at_tick(0)), 0);
+    pool.modify_liquidity(key, {-600, 600, x18::from_int(1000000), 0});
+
+    pool.swap(key, {true, x18::from_int(10000), 0});
+    Address owner{};
+    auto before = pool.get_position(key, owner, -600, 600);
+    ASSERT(before.has_value());
+    ASSERT(before->tokens_owed0 == 0);
+
+    // Credited when the position is next touched; 0.3% of the input
+    auto fees_paid = pool.collect_fees(key, owner, -600, 600, 0);
+    ASSERT(fees_paid.has_value());
+    ASSERT(-fees_paid->amount0 > x18::from_int(29));
+    ASSERT(-fees_paid->amount0 <= x18::from_int(30));
+    ASSERT(fees_paid->amount1 == 0);
+}
+
+// Test: get_ticks lists initialized ticks in range, ascending
+TEST(lxpool_get_ticks) {
+    LXPool pool;
+
+    PoolKey key{};
+    key.currency1.addr[19] = 0x01;
+    key.fee = fees::FEE_030;
+    key.tick_spacing = tick_spacings::TICK_SPACING_030;
+    ASSERT_EQ(pool.initialize(key, tick_math::get_sqrt_ratio_at_tick(0)), 0);
+    pool.modify_liquidity(key, {-600, 600, x18::from_int(10), 0});
+    pool.modify_liquidity(key, {-120, 600, x18::from_int(5), 0});
+
+    auto ticks = pool.get_ticks(key, -600, 600);
+    ASSERT(ticks.has_value());
+    ASSERT_EQ(ticks->size(), 3u);
+    ASSERT_EQ((*ticks)[0].first, -600);
+    ASSERT_EQ((*ticks)[1].first, -120);
+    ASSERT_EQ((*ticks)[2].first, 600);
+    ASSERT((*ticks)[2].second.liquidity_gross == x18::from_int(15));
+    ASSERT((*ticks)[2].second.liquidity_net == -x18::from_int(15));
+
+    ASSERT_EQ(pool.get_ticks(key, -119, 599)->size(), 0u);
+    ASSERT_EQ(pool.get_ticks(key, 600, -600)->size(), 0u);
+
+    PoolKey missing = key;
+    missing.fee = fees::FEE_005;
+    ASSERT(!pool.get_ticks(missing, -600, 600));
+}
+
+// Test: trigger swaps fire once the price crosses, in registration order
+TEST(lxpool_trigger_swaps) {
+    LXPool pool;
+
+    PoolKey key{};
+    key.currency1.addr[19] = 0x01;
+    key.fee = fees::FEE_030;
+    key.tick_spacing = tick_spacings::TICK_SPACING_030;
+    ASSERT_EQ(pool.initialize(key, tick_math::get_sqrt_ratio_at_tick(0)), 0);
+    pool.modify_liquidity(key, {-6000, 6000, x18::from_int(1000), 0});
+
+    LXAccount owner{};
+    owner.main[19] = 0x01;
+    SwapParams buy{false, x18::from_int(1), 0};
+    uint64_t below_id = 0;
+    uint64_t above_id = 0;
+    ASSERT_EQ(pool.place_trigger_swap(owner, key, buy, x18::from_double(0.99), TriggerDir::BELOW, below_id),
+              errors::OK);
+    ASSERT_EQ(pool.place_trigger_swap(owner, key, buy, x18::from_double(1.5), TriggerDir::ABOVE, above_id),
+              errors::OK);
+    ASSERT(above_id > below_id);
+
+    std::vector<TriggeredSwap> fired;
+    ASSERT_EQ(pool.execute_triggers(key, fired), errors::OK);
+    ASSERT(fired.empty());
+
+    pool.swap(key, {true, x18::from_int(20), 0});
+    ASSERT_EQ(pool.execute_triggers(key, fired), errors::OK);
+    ASSERT_EQ(fired.size(), 1u);
+    ASSERT_EQ(fired[0].trigger_id, below_id);
+    ASSERT(fired[0].delta.amount1 == x18::from_int(1));
+    ASSERT(fired[0].delta.amount0 < 0);
+
+    // Fired triggers are consumed
+    fired.clear();
+    ASSERT_EQ(pool.execute_triggers(key, fired), errors::OK);
+    ASSERT(fired.empty());
+
+    PoolKey missing = key;
+    missing.fee = fees::FEE_005;
+    uint64_t id = 0;
+    ASSERT_EQ(pool.place_trigger_swap(owner, missing, buy, X18_ONE, TriggerDir::BELOW, id),
+              errors::POOL_NOT_INITIALIZED);
+    ASSERT_EQ(pool.place_trigger_swap(owner, key, buy, 0, TriggerDir::BELOW, id), errors::INVALID_PRICE);
+    ASSERT_EQ(pool.execute_triggers(missing, fired), errors::POOL_NOT_INITIALIZED);
+}
+
 // Performance test
 void bench_order_throughput() {
     std::cout << "\nRunning performance benchmark...\n";
@@ -948,6 +2149,7 @@ int main() {
     RUN_TEST(market_order);
     RUN_TEST(order_cancellation);
     RUN_TEST(order_modification);
+    RUN_TEST(order_modification_keeps_priority);
     RUN_TEST(market_depth);
     RUN_TEST(engine_multi_symbol);
     RUN_TEST(engine_statistics);
@@ -963,12 +2165,41 @@ int main() {
     RUN_TEST(oracle_stats);
     RUN_TEST(oracle_multi_asset);
 
+    std::cout << "\n=== LXPool Tests ===" << std::endl;
+    RUN_TEST(lxpool_hook_flags);
+    RUN_TEST(lxpool_quote_swap);
+    RUN_TEST(lxpool_swap_fees_accrue);
+    RUN_TEST(lxpool_get_ticks);
+    RUN_TEST(lxpool_trigger_swaps);
+
+    std::cout << "\n=== LX Tests ===" << std::endl;
+    RUN_TEST(lx_bust_trade);
+    RUN_TEST(lx_bust_trade_closing);
+    RUN_TEST(lx_bust_trade_disabled);
+    RUN_TEST(lx_account_exposure);
+    RUN_TEST(lx_trade_callback);
+    RUN_TEST(lx_transfer_position);
+    RUN_TEST(lx_staking_fee_tiers);
+    RUN_TEST(lx_set_leverage);
+    RUN_TEST(lx_system_events);
+    RUN_TEST(lx_snapshot_restore);
+    RUN_TEST(lx_journal_replay);
+    RUN_TEST(lxlend_supply_borrow_repay);
+    RUN_TEST(lxliquid_self_repaying_loan);
+
     std::cout << "\n=== LXBook Tests (LP-9020) ===" << std::endl;
 
     RUN_TEST(lxbook_market_creation);
     RUN_TEST(lxbook_order_lifecycle);
     RUN_TEST(lxbook_matching);
+    RUN_TEST(lxbook_stop_triggers);
+    RUN_TEST(lxbook_remove_market);
+    RUN_TEST(lxbook_quote_fill);
+    RUN_TEST(lxbook_cancel_on_disconnect);
+    RUN_TEST(lxbook_get_open_orders);
+    RUN_TEST(lxbook_reset_quotes);
     RUN_TEST(lxbook_l1);
+    RUN_TEST(lxbook_subscribe_l1);
     RUN_TEST(lxbook_packed_interface);
     RUN_TEST(lxbook_settlement_callback);
 
@@ -169,7 +169,8 @@ void test_position_management() {
     assert(result == errors::OK);
 
     // Apply fills
-    result = vault.apply_fills({settlement});
+    std::vector<LXSettlement> fills{settlement};
+    result = vault.apply_fills(fills);
     assert(result == errors::OK);
 
     // Check positions
@@ -272,7 +273,8 @@ void test_liquidation() {
         .taker_fee_x18 = 0,
         .flags = 0
     };
-    vault.apply_fills({settlement});
+    std::vector<LXSettlement> fills{settlement};
+    vault.apply_fills(fills);
 
     // Account has 0.5 collateral, position notional 10, maint margin 2.5
     // equity (0.5) < maintenance_margin (2.5), so should be liquidatable
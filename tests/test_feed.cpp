@@ -287,6 +287,10 @@ void test_get_all_prices() {
     assert(approx_equal(prices->index_x18, x18::from_int(50000)));
     assert(approx_equal(prices->last_x18, x18::from_int(50010)));
     assert(approx_equal(prices->mid_x18, x18::from_int(50000)));
+    assert(prices->premium_x18 == prices->mark_x18 - prices->index_x18);
+    assert(prices->funding_rate_x18 == feed.get_funding_rate(1)->rate_x18);
+
+    assert(!feed.get_all_prices(99).has_value());
 
     print_pass("test_get_all_prices");
 }